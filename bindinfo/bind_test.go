@@ -2114,3 +2114,50 @@ func (s *testSuite) TestTemporaryTable(c *C) {
 	tk.MustGetErrCode("create binding for update t set a = 1 where b = 1 and c > 1 using update /*+ use_index(t, c) */ t set a = 1 where b = 1 and c > 1", errno.ErrOptOnTemporaryTable)
 	tk.MustGetErrCode("create binding for delete from t where b = 1 and c > 1 using delete /*+ use_index(t, c) */ from t where b = 1 and c > 1", errno.ErrOptOnTemporaryTable)
 }
+
+func (s *testSuite) TestCrossDBBinding(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	s.cleanBindingEnv(tk)
+	tk.MustExec("drop database if exists db1")
+	tk.MustExec("drop database if exists db2")
+	tk.MustExec("create database db1")
+	tk.MustExec("create database db2")
+	defer func() {
+		tk.MustExec("drop database db1")
+		tk.MustExec("drop database db2")
+	}()
+	tk.MustExec("create table db1.t(a int, b int, key(a))")
+	tk.MustExec("create table db2.t(a int, b int, key(a))")
+	tk.MustExec("use db1")
+
+	tk.MustExec("create global binding for select * from db2.t where b = 1 using select * from db2.t ignore index(a) where b = 1")
+	tk.MustExec("create global binding for select * from `*`.t where b = 1 using select * from `*`.t force index(a) where b = 1")
+	c.Assert(tk.Se.GetSessionVars().StmtCtx.WarningCount(), Equals, uint16(1))
+	rows := tk.MustQuery("show global bindings").Sort().Rows()
+	c.Assert(len(rows), Equals, 2)
+	c.Assert(rows[0][0], Equals, "select * from `*` . `t` where `b` = ?")
+	c.Assert(rows[0][2], Equals, "*")
+
+	// The cross-database binding is used in any database, but the binding of the database takes priority.
+	c.Assert(tk.MustUseIndex("select * from t where b = 2", "a"), IsTrue)
+	tk.MustQuery("select @@last_plan_from_binding").Check(testkit.Rows("1"))
+	tk.MustExec("use db2")
+	c.Assert(tk.MustUseIndex("select * from t where b = 2", "a"), IsFalse)
+	c.Assert(tk.MustUseIndex("select * from db1.t where b = 2", "a"), IsTrue)
+
+	tk.MustExec("create session binding for select * from `*`.t where b = 1 using select * from `*`.t ignore index(a) where b = 1")
+	c.Assert(tk.MustUseIndex("select * from db1.t where b = 2", "a"), IsFalse)
+	// The dropped session binding hides the global one as well.
+	tk.MustExec("drop session binding for select * from `*`.t where b = 1")
+	c.Assert(tk.MustUseIndex("select * from db1.t where b = 2", "a"), IsFalse)
+	tk.MustExec("select * from db1.t where b = 2")
+	tk.MustQuery("select @@last_plan_from_binding").Check(testkit.Rows("0"))
+
+	err := tk.ExecToErr("create global binding for select * from `*`.t, db1.t t1 where t.a = t1.a using select * from `*`.t, db1.t t1 where t.a = t1.a")
+	c.Assert(err, ErrorMatches, ".*must all be in the database.*")
+
+	tk.MustExec("drop global binding for select * from `*`.t where b = 1")
+	tk.MustExec("use db1")
+	tk.MustExec("select * from t where b = 2")
+	tk.MustQuery("select @@last_plan_from_binding").Check(testkit.Rows("0"))
+}
@@ -120,15 +120,23 @@ func (br *BindRecord) prepareHints(sctx sessionctx.Context) error {
 		if (bind.Hint != nil && bind.ID != "") || bind.Status == deleted {
 			continue
 		}
-		hintsSet, stmt, warns, err := hint.ParseHintsSet(p, bind.BindSQL, bind.Charset, bind.Collation, br.Db)
+		hintsSet, stmt, warns, err := hint.ParseHintsSet(p, bind.BindSQL, bind.Charset, bind.Collation, br.hintDB())
 		if err != nil {
 			return err
 		}
 		if sctx != nil {
 			paramChecker := &paramMarkerChecker{}
 			stmt.Accept(paramChecker)
-			if !paramChecker.hasParamMarker {
-				_, err = getHintsForSQL(sctx, bind.BindSQL)
+			sql := bind.BindSQL
+			if br.IsWildcard() {
+				// The bind SQL of a cross-database binding is checked with its tables in the current database.
+				sql = ""
+				if db := sctx.GetSessionVars().CurrentDB; db != "" && db != WildcardDB {
+					sql = restoreWithDB(stmt, db)
+				}
+			}
+			if !paramChecker.hasParamMarker && sql != "" {
+				_, err = getHintsForSQL(sctx, sql)
 				if err != nil {
 					return err
 				}
@@ -74,6 +74,9 @@ type BindHandle struct {
 		lastUpdateTime types.Time
 	}
 
+	// wildcard is set once a cross-database binding is in the cache, it's only reset when the cache is cleared.
+	wildcard int32
+
 	// invalidBindRecordMap indicates the invalid bind records found during querying.
 	// A record will be deleted from this map, after 2 bind-lease, after it is dropped from the kv.
 	invalidBindRecordMap tmpBindRecordMap
@@ -159,6 +162,9 @@ func (h *BindHandle) Update(fullLoad bool) (err error) {
 			logutil.BgLogger().Debug("[sql-bind] failed to generate bind record from data row", zap.Error(err))
 			continue
 		}
+		if meta.IsWildcard() {
+			atomic.StoreInt32(&h.wildcard, 1)
+		}
 		// Update lastUpdateTime to the newest one.
 		if meta.Bindings[0].UpdateTime.Compare(lastUpdateTime) > 0 {
 			lastUpdateTime = meta.Bindings[0].UpdateTime
@@ -524,6 +530,9 @@ func (h *BindHandle) setBindRecord(hash string, meta *BindRecord) {
 	newCache := h.bindInfo.Value.Load().(cache).copy()
 	oldRecord := newCache.getBindRecord(hash, meta.OriginalSQL, meta.Db)
 	newCache.setBindRecord(hash, meta)
+	if meta.IsWildcard() {
+		atomic.StoreInt32(&h.wildcard, 1)
+	}
 	h.bindInfo.Value.Store(newCache)
 	updateMetrics(metrics.ScopeGlobal, oldRecord, meta, false)
 }
@@ -535,6 +544,9 @@ func (h *BindHandle) appendBindRecord(hash string, meta *BindRecord) {
 	oldRecord := newCache.getBindRecord(hash, meta.OriginalSQL, meta.Db)
 	newRecord := merge(oldRecord, meta)
 	newCache.setBindRecord(hash, newRecord)
+	if meta.IsWildcard() {
+		atomic.StoreInt32(&h.wildcard, 1)
+	}
 	h.bindInfo.Value.Store(newCache)
 	updateMetrics(metrics.ScopeGlobal, oldRecord, newRecord, false)
 }
@@ -921,7 +933,7 @@ func (h *BindHandle) HandleEvolvePlanTask(sctx sessionctx.Context, adminEvolve b
 	if err != nil {
 		return h.DropBindRecord(originalSQL, db, &binding)
 	}
-	if verifyPlanTime == -1 || (float64(verifyPlanTime)*acceptFactor > float64(currentPlanTime)) {
+	if !acceptVerifiedPlan(currentPlanTime, verifyPlanTime) {
 		binding.Status = Rejected
 		digestText, _ := parser.NormalizeDigest(binding.BindSQL) // for log desensitization
 		logutil.BgLogger().Debug("[sql-bind] new plan rejected",
@@ -936,11 +948,24 @@ func (h *BindHandle) HandleEvolvePlanTask(sctx sessionctx.Context, adminEvolve b
 	return h.AddBindRecord(nil, &BindRecord{OriginalSQL: originalSQL, Db: db, Bindings: []Binding{binding}})
 }
 
+// acceptVerifiedPlan decides whether the verified plan is better than the accepted plan. -1 means the plan timed out.
+// The verified plan is accepted if the accepted plan timed out and it runs successfully within the max time.
+func acceptVerifiedPlan(currentPlanTime, verifyPlanTime time.Duration) bool {
+	if verifyPlanTime == -1 {
+		return false
+	}
+	if currentPlanTime == -1 {
+		return true
+	}
+	return float64(verifyPlanTime)*acceptFactor <= float64(currentPlanTime)
+}
+
 // Clear resets the bind handle. It is only used for test.
 func (h *BindHandle) Clear() {
 	h.bindInfo.Lock()
 	h.bindInfo.Store(make(cache))
 	h.bindInfo.lastUpdateTime = types.ZeroTimestamp
+	atomic.StoreInt32(&h.wildcard, 0)
 	h.bindInfo.Unlock()
 	h.invalidBindRecordMap.Store(make(map[string]*bindRecordUpdate))
 	h.pendingVerifyBindRecordMap.Store(make(map[string]*bindRecordUpdate))
@@ -959,6 +984,7 @@ func (h *BindHandle) ReloadBindings() error {
 	h.bindInfo.Lock()
 	h.bindInfo.Store(make(cache))
 	h.bindInfo.lastUpdateTime = types.ZeroTimestamp
+	atomic.StoreInt32(&h.wildcard, 0)
 	h.bindInfo.Unlock()
 	return h.Update(true)
 }
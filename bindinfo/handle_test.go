@@ -0,0 +1,42 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bindinfo
+
+import (
+	"time"
+
+	. "github.com/pingcap/check"
+)
+
+var _ = Suite(&testHandleSuite{})
+
+type testHandleSuite struct{}
+
+func (s *testHandleSuite) TestAcceptVerifiedPlan(c *C) {
+	tests := []struct {
+		currentPlanTime time.Duration
+		verifyPlanTime  time.Duration
+		accept          bool
+	}{
+		{time.Second, 500 * time.Millisecond, true},
+		{time.Second, time.Second, false},
+		{time.Second, -1, false},
+		// The accepted plan timed out, the verified plan is accepted if it runs within the max time.
+		{-1, time.Second, true},
+		{-1, -1, false},
+	}
+	for _, tt := range tests {
+		c.Assert(acceptVerifiedPlan(tt.currentPlanTime, tt.verifyPlanTime), Equals, tt.accept, Commentf("%v", tt))
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bindinfo
+
+import (
+	"sync/atomic"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/tidb/metrics"
+	utilparser "github.com/pingcap/tidb/util/parser"
+)
+
+// WildcardDB is the database of the tables in the cross-database bindings. A cross-database binding is created for a
+// statement whose tables are all in the database `*`, like `SELECT * FROM `*`.t`, and it's used by the statements on
+// the tables of the same names in any database.
+const WildcardDB = "*"
+
+// IsWildcard returns whether the BindRecord is a cross-database binding.
+func (br *BindRecord) IsWildcard() bool {
+	return br.Db == WildcardDB
+}
+
+// hintDB returns the database of the tables in the hints without the database, the tables of a cross-database binding
+// are in the current database.
+func (br *BindRecord) hintDB() string {
+	if br.IsWildcard() {
+		return ""
+	}
+	return br.Db
+}
+
+// CheckWildcardTables returns whether the statement of a binding is for a cross-database binding. It returns an error
+// if only some of its tables are in the wildcard database.
+func CheckWildcardTables(stmt ast.StmtNode) (bool, error) {
+	checker := &wildcardChecker{}
+	stmt.Accept(checker)
+	if checker.wildcard && checker.other {
+		return false, errors.New("the tables of a cross-database binding must all be in the database `*`")
+	}
+	return checker.wildcard, nil
+}
+
+type wildcardChecker struct {
+	wildcard bool
+	other    bool
+}
+
+// Enter implements Visitor interface.
+func (c *wildcardChecker) Enter(in ast.Node) (ast.Node, bool) {
+	if t, ok := in.(*ast.TableName); ok {
+		if t.Schema.O == WildcardDB {
+			c.wildcard = true
+		} else {
+			c.other = true
+		}
+		return in, true
+	}
+	return in, false
+}
+
+// Leave implements Visitor interface.
+func (c *wildcardChecker) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
+
+// NormalizeWildcard returns the normalized SQL and the digest of the statement whose tables are all in the wildcard
+// database, with which the cross-database bindings of the statement are found. The statement itself is not changed.
+func NormalizeWildcard(stmt ast.StmtNode) (string, string) {
+	sql := restoreWithDB(stmt, WildcardDB)
+	if sql == "" {
+		return "", ""
+	}
+	normalizedSQL, digest := parser.NormalizeDigest(sql)
+	return normalizedSQL, digest.String()
+}
+
+// restoreWithDB restores the statement with all of its tables in the database db.
+func restoreWithDB(stmt ast.StmtNode, db string) string {
+	replacer := &dbReplacer{db: model.NewCIStr(db)}
+	stmt.Accept(replacer)
+	defer func() {
+		for i, t := range replacer.tables {
+			t.Schema = replacer.origins[i]
+		}
+	}()
+	// The empty origin SQL makes the statement always restored from the AST.
+	return utilparser.RestoreWithDefaultDB(stmt, db, "")
+}
+
+type dbReplacer struct {
+	db      model.CIStr
+	tables  []*ast.TableName
+	origins []model.CIStr
+}
+
+// Enter implements Visitor interface.
+func (r *dbReplacer) Enter(in ast.Node) (ast.Node, bool) {
+	if t, ok := in.(*ast.TableName); ok {
+		r.tables = append(r.tables, t)
+		r.origins = append(r.origins, t.Schema)
+		t.Schema = r.db
+		return in, true
+	}
+	return in, false
+}
+
+// Leave implements Visitor interface.
+func (r *dbReplacer) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
+
+// FindBindRecord finds the BindRecord used to optimize the statement, whose normalized SQL and digest are
+// normalizedSQL and hash, and returns its scope. The bindings in the session take priority over the global ones, and
+// the bindings of the database take priority over the cross-database ones. The statements with the empty normalized SQL
+// don't use any binding.
+func FindBindRecord(sessionHandle *SessionHandle, globalHandle *BindHandle, stmt ast.StmtNode, normalizedSQL, hash string) (*BindRecord, string) {
+	if normalizedSQL == "" {
+		return nil, ""
+	}
+	bindRecord, scope, found := findBindRecord(sessionHandle, globalHandle, normalizedSQL, hash)
+	if found || !(sessionHandle.hasWildcard() || globalHandle.hasWildcard()) {
+		return bindRecord, scope
+	}
+	normalizedSQL, hash = NormalizeWildcard(stmt)
+	if normalizedSQL == "" {
+		return nil, ""
+	}
+	bindRecord, scope, _ = findBindRecord(sessionHandle, globalHandle, normalizedSQL, hash)
+	return bindRecord, scope
+}
+
+// findBindRecord finds the BindRecord of the normalized SQL. found is true if there is a BindRecord even if it's
+// not used, since a session BindRecord without using bindings hides the global one.
+func findBindRecord(sessionHandle *SessionHandle, globalHandle *BindHandle, normalizedSQL, hash string) (bindRecord *BindRecord, scope string, found bool) {
+	bindRecord = sessionHandle.GetBindRecord(normalizedSQL, "")
+	if bindRecord != nil {
+		if bindRecord.HasUsingBinding() {
+			return bindRecord, metrics.ScopeSession, true
+		}
+		return nil, "", true
+	}
+	if globalHandle == nil {
+		return nil, "", false
+	}
+	bindRecord = globalHandle.GetBindRecord(hash, normalizedSQL, "")
+	return bindRecord, metrics.ScopeGlobal, bindRecord != nil
+}
+
+// hasWildcard returns whether there may be cross-database bindings in the session, with which the statements
+// without the bindings of the database need to find the cross-database bindings.
+func (h *SessionHandle) hasWildcard() bool {
+	for _, bindRecords := range h.ch {
+		for _, bindRecord := range bindRecords {
+			if bindRecord.IsWildcard() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasWildcard returns whether there may be global cross-database bindings.
+func (h *BindHandle) hasWildcard() bool {
+	return h != nil && atomic.LoadInt32(&h.wildcard) != 0
+}
+
+// ShadowingRecords returns the bindings of the databases which take priority over the cross-database binding record.
+func ShadowingRecords(wildcard *BindRecord, bindRecords []*BindRecord) []*BindRecord {
+	p := parser.New()
+	var shadowing []*BindRecord
+	for _, bindRecord := range bindRecords {
+		if bindRecord.IsWildcard() || !bindRecord.HasUsingBinding() {
+			continue
+		}
+		stmt, err := p.ParseOneStmt(bindRecord.OriginalSQL, "", "")
+		if err != nil {
+			continue
+		}
+		if normalizedSQL, _ := NormalizeWildcard(stmt); normalizedSQL == wildcard.OriginalSQL {
+			shadowing = append(shadowing, bindRecord)
+		}
+	}
+	return shadowing
+}
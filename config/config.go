@@ -115,6 +115,7 @@ type Config struct {
 	PreparedPlanCache          PreparedPlanCache  `toml:"prepared-plan-cache" json:"prepared-plan-cache"`
 	OpenTracing                OpenTracing        `toml:"opentracing" json:"opentracing"`
 	ProxyProtocol              ProxyProtocol      `toml:"proxy-protocol" json:"proxy-protocol"`
+	Drain                      Drain              `toml:"drain" json:"drain"`
 	PDClient                   tikvcfg.PDClient   `toml:"pd-client" json:"pd-client"`
 	TiKVClient                 tikvcfg.TiKVClient `toml:"tikv-client" json:"tikv-client"`
 	Binlog                     Binlog             `toml:"binlog" json:"binlog"`
@@ -182,6 +183,13 @@ type Config struct {
 	// 1. there is a network partition problem between TiDB and PD leader.
 	// 2. there is a network partition problem between TiDB and TiKV leader.
 	EnableForwarding bool `toml:"enable-forwarding" json:"enable-forwarding"`
+	// ResourceGroups are the resource groups which limit the resources used by the statements in them.
+	ResourceGroups []ResourceGroup `toml:"resource-groups" json:"resource-groups"`
+	// Runaway are the rules to find the runaway statements which are not in any resource group, or whose
+	// resource group has no rule.
+	Runaway RunawayRules `toml:"runaway" json:"runaway"`
+	// Audit is the config of the audit log.
+	Audit Audit `toml:"audit" json:"audit"`
 }
 
 // UpdateTempStoragePath is to update the `TempStoragePath` if port/statusPort was changed
@@ -478,6 +486,17 @@ type ProxyProtocol struct {
 	HeaderTimeout uint `toml:"header-timeout" json:"header-timeout"`
 }
 
+// Drain is the config of draining the server, which is done before shutting down or on the status API for
+// rolling restarts.
+type Drain struct {
+	// Timeout is the max seconds to wait for the active transactions, the remaining connections are killed after it.
+	Timeout uint `toml:"timeout" json:"timeout"`
+	// SaveSessionStates indicates whether to save the states of the idle sessions closed by draining on the status
+	// API, with which the clients can restore the sessions on other servers after reconnecting. The states are only
+	// returned to the clients with a certificate verified by cluster-verify-cn.
+	SaveSessionStates bool `toml:"save-session-states" json:"save-session-states"`
+}
+
 // Binlog is the config for binlog.
 type Binlog struct {
 	Enable bool `toml:"enable" json:"enable"`
@@ -496,6 +515,10 @@ type PessimisticTxn struct {
 	// The max count of retry for a single statement in a pessimistic transaction.
 	MaxRetryCount           uint `toml:"max-retry-count" json:"max-retry-count"`
 	DeadlockHistoryCapacity uint `toml:"deadlock-history-capacity" json:"deadlock-history-capacity"`
+	// Whether to collect the retryable deadlocks, which are not reported to the client, in the deadlock history.
+	DeadlockHistoryCollectRetryable bool `toml:"deadlock-history-collect-retryable" json:"deadlock-history-collect-retryable"`
+	// The max count of the recent lock waits kept in information_schema.data_lock_wait_history.
+	LockWaitHistoryCapacity uint `toml:"lock-wait-history-capacity" json:"lock-wait-history-capacity"`
 }
 
 // DefaultPessimisticTxn returns the default configuration for PessimisticTxn
@@ -503,6 +526,7 @@ func DefaultPessimisticTxn() PessimisticTxn {
 	return PessimisticTxn{
 		MaxRetryCount:           256,
 		DeadlockHistoryCapacity: 10,
+		LockWaitHistoryCapacity: 100,
 	}
 }
 
@@ -526,6 +550,10 @@ type StmtSummary struct {
 	RefreshInterval int `toml:"refresh-interval" json:"refresh-interval"`
 	// The maximum history size of statement summary.
 	HistorySize int `toml:"history-size" json:"history-size"`
+	// Persist the expired statement summaries to mysql.statements_summary_history or not.
+	EnablePersistent bool `toml:"enable-persistent" json:"enable-persistent"`
+	// The days to keep the persisted statement summaries.
+	PersistentRetentionDays int `toml:"persistent-retention-days" json:"persistent-retention-days"`
 }
 
 // IsolationRead is the config for isolation read.
@@ -534,6 +562,139 @@ type IsolationRead struct {
 	Engines []string `toml:"engines" json:"engines"`
 }
 
+// ResourceGroup is the config of a resource group. The statements of the users in the group, or of the sessions
+// whose tidb_resource_group is the group, are limited by it.
+// The groups are only defined in the config file. There is no CREATE RESOURCE GROUP statement or RESOURCE_GROUP hint,
+// and neither the CPU share nor the MPP task concurrency is limited.
+type ResourceGroup struct {
+	Name string `toml:"name" json:"name"`
+	// Users are the users whose statements are in the group if tidb_resource_group is not set.
+	Users []string `toml:"users" json:"users"`
+	// Priority is the priority of the statements in the group, which is used to schedule the requests in TiKV.
+	// It's one of "NO_PRIORITY", "LOW_PRIORITY", "HIGH_PRIORITY" and "DELAYED".
+	Priority string `toml:"priority" json:"priority"`
+	// MemQuotaQuery is the memory quota of a statement in the group, 0 means it's not limited by the group.
+	MemQuotaQuery int64 `toml:"mem-quota-query" json:"mem-quota-query"`
+	// CopConcurrency is the max concurrency of the coprocessor requests of a statement in the group, 0 means it's
+	// not limited by the group.
+	CopConcurrency int `toml:"cop-concurrency" json:"cop-concurrency"`
+	// Runaway are the rules to find the runaway statements in the group.
+	Runaway RunawayRules `toml:"runaway" json:"runaway"`
+}
+
+// Actions on the runaway statements.
+const (
+	// RunawayActionLog logs the runaway statement.
+	RunawayActionLog = "log"
+	// RunawayActionDeprioritize sends the following coprocessor requests of the runaway statement with low priority.
+	RunawayActionDeprioritize = "deprioritize"
+	// RunawayActionKill kills the runaway statement.
+	RunawayActionKill = "kill"
+)
+
+// RunawayRules are the rules to find the runaway statements while they're running, a statement breaking any rule
+// is runaway. The rule whose limit is 0 is disabled.
+type RunawayRules struct {
+	// MaxExecutionTime is the max execution time of a statement, in milliseconds.
+	MaxExecutionTime uint64 `toml:"max-execution-time" json:"max-execution-time"`
+	// MaxScanRows is the max number of the rows scanned by the coprocessor requests of a statement.
+	MaxScanRows int64 `toml:"max-scan-rows" json:"max-scan-rows"`
+	// MaxMemory is the max memory consumed by a statement, in bytes.
+	MaxMemory int64 `toml:"max-memory" json:"max-memory"`
+	// Action is the action on the runaway statements, it's one of "log", "deprioritize" and "kill".
+	Action string `toml:"action" json:"action"`
+}
+
+// Enabled returns whether any rule is enabled.
+func (r *RunawayRules) Enabled() bool {
+	return r.MaxExecutionTime > 0 || r.MaxScanRows > 0 || r.MaxMemory > 0
+}
+
+func (r *RunawayRules) valid() error {
+	if len(r.Action) == 0 {
+		r.Action = RunawayActionLog
+	}
+	r.Action = strings.ToLower(r.Action)
+	switch r.Action {
+	case RunawayActionLog, RunawayActionDeprioritize, RunawayActionKill:
+	default:
+		return fmt.Errorf("unsupported runaway action %v, TiDB only supports [%v, %v, %v]", r.Action, RunawayActionLog, RunawayActionDeprioritize, RunawayActionKill)
+	}
+	if r.MaxScanRows < 0 || r.MaxMemory < 0 {
+		return fmt.Errorf("max-scan-rows and max-memory of runaway should be greater than or equal to 0")
+	}
+	return nil
+}
+
+// Classes of the audit events.
+const (
+	// AuditClassConnection is the class of the connection events, like connecting and disconnecting.
+	AuditClassConnection = "connection"
+	// AuditClassQuery is the class of the statements which read data, like SELECT, SHOW and EXPLAIN.
+	AuditClassQuery = "query"
+	// AuditClassDML is the class of the statements which write data, like INSERT, UPDATE and DELETE.
+	AuditClassDML = "dml"
+	// AuditClassDDL is the class of the statements which change the schemas.
+	AuditClassDDL = "ddl"
+	// AuditClassDCL is the class of the statements which manage the users and privileges.
+	AuditClassDCL = "dcl"
+	// AuditClassOther is the class of the other statements, like SET and BEGIN.
+	AuditClassOther = "other"
+)
+
+// Audit is the config of the audit log, which records the connection events and the statements matching the filters.
+// An empty filter matches all.
+type Audit struct {
+	Enable bool `toml:"enable" json:"enable"`
+	// File is the file of the audit log, it's rotated in the same way as the log file. The events are only written
+	// to the registered sinks if its filename is empty.
+	File logutil.FileLogConfig `toml:"file" json:"file"`
+	// Users are the names of the users whose events are recorded.
+	Users []string `toml:"users" json:"users"`
+	// Databases are the databases whose statements are recorded, a statement matches if its current database or
+	// any table it accesses is in one of them.
+	Databases []string `toml:"databases" json:"databases"`
+	// Classes are the classes of the events recorded, they're "connection", "query", "dml", "ddl", "dcl" and "other".
+	Classes []string `toml:"classes" json:"classes"`
+	// Redact replaces the literals in the statements with "?".
+	Redact bool `toml:"redact" json:"redact"`
+}
+
+func (a *Audit) valid() error {
+	for i, class := range a.Classes {
+		a.Classes[i] = strings.ToLower(class)
+		switch a.Classes[i] {
+		case AuditClassConnection, AuditClassQuery, AuditClassDML, AuditClassDDL, AuditClassDCL, AuditClassOther:
+		default:
+			return fmt.Errorf("unsupported audit class %v, TiDB only supports [%v, %v, %v, %v, %v, %v]", class,
+				AuditClassConnection, AuditClassQuery, AuditClassDML, AuditClassDDL, AuditClassDCL, AuditClassOther)
+		}
+	}
+	return nil
+}
+
+// GetResourceGroup returns the resource group of the name, it returns nil if there is no such group.
+func (c *Config) GetResourceGroup(name string) *ResourceGroup {
+	for i := range c.ResourceGroups {
+		if strings.EqualFold(c.ResourceGroups[i].Name, name) {
+			return &c.ResourceGroups[i]
+		}
+	}
+	return nil
+}
+
+// GetUserResourceGroup returns the resource group of the user, it returns nil if the user is not in any group.
+func (c *Config) GetUserResourceGroup(user string) *ResourceGroup {
+	for i := range c.ResourceGroups {
+		for _, u := range c.ResourceGroups[i].Users {
+			if u == user {
+				return &c.ResourceGroups[i]
+			}
+		}
+	}
+	return nil
+}
+
 // Experimental controls the features that are still experimental: their semantics, interfaces are subject to change.
 // Using these features in the production environment is not recommended.
 type Experimental struct {
@@ -630,6 +791,10 @@ var defaultConf = Config{
 		Networks:      "",
 		HeaderTimeout: 5,
 	},
+	Drain: Drain{
+		Timeout:           15,
+		SaveSessionStates: false,
+	},
 	PreparedPlanCache: PreparedPlanCache{
 		Enabled:          false,
 		Capacity:         100,
@@ -651,12 +816,14 @@ var defaultConf = Config{
 	},
 	PessimisticTxn: DefaultPessimisticTxn(),
 	StmtSummary: StmtSummary{
-		Enable:              true,
-		EnableInternalQuery: false,
-		MaxStmtCount:        200,
-		MaxSQLLength:        4096,
-		RefreshInterval:     1800,
-		HistorySize:         24,
+		Enable:                  true,
+		EnableInternalQuery:     false,
+		MaxStmtCount:            200,
+		MaxSQLLength:            4096,
+		RefreshInterval:         1800,
+		HistorySize:             24,
+		EnablePersistent:        false,
+		PersistentRetentionDays: 7,
 	},
 	IsolationRead: IsolationRead{
 		Engines: []string{"tikv", "tiflash", "tidb"},
@@ -677,6 +844,14 @@ var defaultConf = Config{
 	EnableEnumLengthLimit:        true,
 	StoresRefreshInterval:        defTiKVCfg.StoresRefreshInterval,
 	EnableForwarding:             defTiKVCfg.EnableForwarding,
+	Runaway: RunawayRules{
+		Action: RunawayActionLog,
+	},
+	Audit: Audit{
+		Enable: false,
+		File:   logutil.NewFileLogConfig(logutil.DefaultLogMaxSize),
+		Redact: true,
+	},
 }
 
 var (
@@ -890,6 +1065,13 @@ func (c *Config) Valid() error {
 	if c.StmtSummary.RefreshInterval <= 0 {
 		return fmt.Errorf("refresh-interval in [stmt-summary] should be greater than 0")
 	}
+	if c.StmtSummary.PersistentRetentionDays <= 0 {
+		return fmt.Errorf("persistent-retention-days in [stmt-summary] should be greater than 0")
+	}
+
+	if c.Drain.Timeout == 0 {
+		return fmt.Errorf("timeout in [drain] should be greater than 0")
+	}
 
 	if c.PreparedPlanCache.Capacity < 1 {
 		return fmt.Errorf("capacity in [prepared-plan-cache] should be at least 1")
@@ -906,6 +1088,37 @@ func (c *Config) Valid() error {
 		}
 	}
 
+	names := make(map[string]struct{}, len(c.ResourceGroups))
+	for i := range c.ResourceGroups {
+		group := &c.ResourceGroups[i]
+		if len(group.Name) == 0 {
+			return fmt.Errorf("name of [[resource-groups]] should not be empty")
+		}
+		if _, ok := names[strings.ToLower(group.Name)]; ok {
+			return fmt.Errorf("duplicate resource group %v in [[resource-groups]]", group.Name)
+		}
+		names[strings.ToLower(group.Name)] = struct{}{}
+		if len(group.Priority) == 0 {
+			group.Priority = mysql.Priority2Str[mysql.NoPriority]
+		}
+		group.Priority = strings.ToUpper(group.Priority)
+		if mysql.Str2Priority(group.Priority) == mysql.NoPriority && group.Priority != mysql.Priority2Str[mysql.NoPriority] {
+			return fmt.Errorf("unsupported priority %v of resource group %v", group.Priority, group.Name)
+		}
+		if group.MemQuotaQuery < 0 || group.CopConcurrency < 0 {
+			return fmt.Errorf("mem-quota-query and cop-concurrency of resource group %v should be greater than or equal to 0", group.Name)
+		}
+		if err := group.Runaway.valid(); err != nil {
+			return err
+		}
+	}
+	if err := c.Runaway.valid(); err != nil {
+		return err
+	}
+	if err := c.Audit.valid(); err != nil {
+		return err
+	}
+
 	// test security
 	c.Security.SpilledFileEncryptionMethod = strings.ToLower(c.Security.SpilledFileEncryptionMethod)
 	switch c.Security.SpilledFileEncryptionMethod {
@@ -600,6 +600,34 @@ func (s *testConfigSuite) TestSecurityValid(c *C) {
 	}
 }
 
+func (s *testConfigSuite) TestResourceGroupsValid(c *C) {
+	c1 := NewConfig()
+	tests := []struct {
+		groups []ResourceGroup
+		valid  bool
+	}{
+		{nil, true},
+		{[]ResourceGroup{{Name: "olap", Users: []string{"u1"}, Priority: "low_priority", MemQuotaQuery: 1 << 30, CopConcurrency: 4}}, true},
+		{[]ResourceGroup{{Name: "olap"}, {Name: "oltp", Priority: "HIGH_PRIORITY"}}, true},
+		{[]ResourceGroup{{Name: ""}}, false},
+		{[]ResourceGroup{{Name: "olap"}, {Name: "OLAP"}}, false},
+		{[]ResourceGroup{{Name: "olap", Priority: "urgent"}}, false},
+		{[]ResourceGroup{{Name: "olap", CopConcurrency: -1}}, false},
+	}
+	for _, tt := range tests {
+		c1.ResourceGroups = tt.groups
+		c.Assert(c1.Valid() == nil, Equals, tt.valid)
+	}
+
+	c1.ResourceGroups = []ResourceGroup{{Name: "olap", Users: []string{"u1"}}, {Name: "oltp", Users: []string{"u2"}}}
+	c.Assert(c1.Valid(), IsNil)
+	c.Assert(c1.ResourceGroups[0].Priority, Equals, "NO_PRIORITY")
+	c.Assert(c1.GetResourceGroup("OLTP").Name, Equals, "oltp")
+	c.Assert(c1.GetResourceGroup("unknown"), IsNil)
+	c.Assert(c1.GetUserResourceGroup("u1").Name, Equals, "olap")
+	c.Assert(c1.GetUserResourceGroup("u3"), IsNil)
+}
+
 func (s *testConfigSuite) TestTcpNoDelay(c *C) {
 	c1 := NewConfig()
 	//check default value
@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -419,7 +420,97 @@ func (w *worker) handleReorgTasks(reorgInfo *reorgInfo, totalAddedCount *int64,
 		zap.String("nextHandle", tryDecodeToHandleString(nextKey)),
 		zap.Int64("batchAddedCount", taskAddedCount),
 		zap.String("takeTime", elapsedTime.String()))
-	return nil
+	return errors.Trace(w.waitReorgResumed(reorgInfo))
+}
+
+// reorgPausedCheckInterval is the interval to check whether the paused reorg job is resumed.
+var reorgPausedCheckInterval = time.Second
+
+// waitReorgResumed blocks the backfill between two batches while the job is listed in tidb_ddl_reorg_paused_jobs.
+// The global variable is read at most once per reorgPausedCheckInterval, not on every batch.
+// The next key has been set to the reorg context, so runReorgJob keeps saving it as the checkpoint, and the job
+// can still be cancelled or taken over by another owner while it's paused.
+func (w *worker) waitReorgResumed(reorgInfo *reorgInfo) error {
+	if time.Since(w.reorgCtx.pausedCheckTime) < reorgPausedCheckInterval {
+		return nil
+	}
+	paused := false
+	for {
+		w.reorgCtx.pausedCheckTime = time.Now()
+		isPaused, err := w.isReorgPaused(reorgInfo.Job.ID)
+		if err != nil {
+			logutil.BgLogger().Warn("[ddl] check whether the reorg job is paused failed", zap.Int64("jobID", reorgInfo.Job.ID), zap.Error(err))
+			isPaused = false
+		}
+		if !isPaused {
+			if paused {
+				logutil.BgLogger().Info("[ddl] reorg job is resumed", zap.Int64("jobID", reorgInfo.Job.ID))
+			}
+			return nil
+		}
+		if !paused {
+			_, nextKey, _ := w.reorgCtx.getRowCountAndKey()
+			logutil.BgLogger().Info("[ddl] reorg job is paused", zap.Int64("jobID", reorgInfo.Job.ID),
+				zap.String("nextHandle", tryDecodeToHandleString(nextKey)))
+			paused = true
+		}
+		if err := w.isReorgRunnable(reorgInfo.d); err != nil {
+			return errors.Trace(err)
+		}
+		select {
+		case <-w.ctx.Done():
+			return errInvalidWorker.GenWithStack("worker is closed")
+		case <-time.After(reorgPausedCheckInterval):
+		}
+	}
+}
+
+func (w *worker) isReorgPaused(jobID int64) (bool, error) {
+	ctx, err := w.sessPool.get()
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	defer w.sessPool.put(ctx)
+	val, err := ctx.GetSessionVars().GlobalVarsAccessor.GetGlobalSysVar(variable.TiDBDDLReorgPausedJobs)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	jobIDs, err := variable.ParseDDLReorgPausedJobs(val)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	_, ok := jobIDs[jobID]
+	return ok, nil
+}
+
+// removeReorgPausedJob removes the finished or cancelled job from tidb_ddl_reorg_paused_jobs, so the variable only lists
+// the jobs that can still be resumed.
+func (w *worker) removeReorgPausedJob(jobID int64) error {
+	ctx, err := w.sessPool.get()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer w.sessPool.put(ctx)
+	accessor := ctx.GetSessionVars().GlobalVarsAccessor
+	val, err := accessor.GetGlobalSysVar(variable.TiDBDDLReorgPausedJobs)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	jobIDs, err := variable.ParseDDLReorgPausedJobs(val)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if _, ok := jobIDs[jobID]; !ok {
+		return nil
+	}
+	ids := make([]string, 0, len(jobIDs)-1)
+	for _, str := range strings.Split(val, ",") {
+		str = strings.TrimSpace(str)
+		if len(str) > 0 && str != strconv.FormatInt(jobID, 10) {
+			ids = append(ids, str)
+		}
+	}
+	return errors.Trace(accessor.SetGlobalSysVar(variable.TiDBDDLReorgPausedJobs, strings.Join(ids, ",")))
 }
 
 func tryDecodeToHandleString(key kv.Key) string {
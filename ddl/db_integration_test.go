@@ -1442,6 +1442,43 @@ func (s *testIntegrationSuite2) TestAddIndexAfterAddColumn(c *C) {
 	tk.MustGetErrCode(sql, errno.ErrTooManyKeyParts)
 }
 
+func (s *testIntegrationSuite2) TestMultiSchemaChange(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t_multi_schema")
+	tk.MustExec("create table t_multi_schema(a int, b int)")
+	tk.MustExec("insert into t_multi_schema values(1, 1), (2, 1)")
+
+	tk.MustExec("alter table t_multi_schema add column c int default 5, add index idx_c(c), add index idx_a(a), modify column b varchar(10)")
+	tk.MustExec("admin check table t_multi_schema")
+	tk.MustQuery("select a, b, c from t_multi_schema use index(idx_c) order by a").Check(testkit.Rows("1 1 5", "2 1 5"))
+	tk.MustQuery("select data_type from information_schema.columns where table_name = 't_multi_schema' and column_name = 'b'").Check(testkit.Rows("varchar"))
+	tk.MustQuery("select key_name from information_schema.tidb_indexes where table_name = 't_multi_schema' order by key_name").Check(testkit.Rows("idx_a", "idx_c"))
+	c.Assert(tk.MustQuery("admin show ddl jobs 1").Rows()[0][3], Equals, "multi-schema change")
+
+	// The done sub-jobs are reverted if a later one fails.
+	sql := "alter table t_multi_schema add column d int, add unique index idx_b(b), modify column a varchar(10)"
+	tk.MustGetErrCode(sql, errno.ErrDupEntry)
+	tk.MustExec("admin check table t_multi_schema")
+	tk.MustQuery("select * from t_multi_schema order by a").Check(testkit.Rows("1 1 5", "2 1 5"))
+	tk.MustQuery("select data_type from information_schema.columns where table_name = 't_multi_schema' and column_name = 'a'").Check(testkit.Rows("int"))
+	tk.MustQuery("select key_name from information_schema.tidb_indexes where table_name = 't_multi_schema' order by key_name").Check(testkit.Rows("idx_a", "idx_c"))
+	c.Assert(tk.MustQuery("admin show ddl jobs 1").Rows()[0][10], Equals, "rollback done")
+
+	tk.MustExec("alter table t_multi_schema add index idx_ac(a, c), add unique index idx_ca(c, a)")
+	tk.MustExec("admin check table t_multi_schema")
+
+	// The column added by the statement can be modified by it.
+	tk.MustExec("alter table t_multi_schema add column e int default 3, modify column e bigint not null default 4")
+	tk.MustExec("admin check table t_multi_schema")
+	tk.MustQuery("select a, e from t_multi_schema order by a").Check(testkit.Rows("1 3", "2 3"))
+	tk.MustQuery("select data_type, column_default, is_nullable from information_schema.columns where table_name = 't_multi_schema' and column_name = 'e'").Check(testkit.Rows("bigint 4 NO"))
+
+	// Only ADD COLUMN, ADD INDEX and one MODIFY COLUMN can run together.
+	tk.MustGetErrCode("alter table t_multi_schema add column e int, drop column c", errno.ErrUnsupportedDDLOperation)
+	tk.MustGetErrCode("alter table t_multi_schema modify column a bigint, modify column c bigint", errno.ErrUnsupportedDDLOperation)
+}
+
 func (s *testIntegrationSuite3) TestResolveCharset(c *C) {
 	tk := testkit.NewTestKit(c, s.store)
 	tk.MustExec("use test")
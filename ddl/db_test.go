@@ -23,6 +23,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	. "github.com/pingcap/check"
@@ -1705,6 +1706,67 @@ func (s *testDBSuite3) TestCancelDropColumns(c *C) {
 	s.mustExec(tk, c, "alter table test_drop_column drop column c3, drop column c4")
 }
 
+func (s *testDBSuite3) TestCancelMultiSchemaChange(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use " + s.schemaName)
+	s.mustExec(tk, c, "drop table if exists test_multi_schema")
+	s.mustExec(tk, c, "create table test_multi_schema(c1 int, c2 int)")
+	defer s.mustExec(tk, c, "drop table test_multi_schema;")
+	s.mustExec(tk, c, "insert into test_multi_schema values(1, 1)")
+	// The first one cancels the adding column, the second one cancels the adding index after the column is added.
+	cancelStates := []model.SchemaState{model.StateDeleteOnly, model.StatePublic}
+	var (
+		checkErr    error
+		cancelled   bool
+		cancelState model.SchemaState
+	)
+	hook := &ddl.TestDDLCallback{Do: s.dom}
+	hook.OnJobRunBeforeExported = func(job *model.Job) {
+		if cancelled || job.Type != ddl.ActionMultiSchemaChange || job.State != model.JobStateRunning || job.SchemaState != cancelState {
+			return
+		}
+		cancelled = true
+		hookCtx := mock.NewContext()
+		hookCtx.Store = s.store
+		err := hookCtx.NewTxn(context.TODO())
+		if err != nil {
+			checkErr = errors.Trace(err)
+			return
+		}
+		txn, err := hookCtx.Txn(true)
+		if err != nil {
+			checkErr = errors.Trace(err)
+			return
+		}
+		errs, err := admin.CancelJobs(txn, []int64{job.ID})
+		if err != nil {
+			checkErr = errors.Trace(err)
+			return
+		}
+		if errs[0] != nil {
+			checkErr = errors.Trace(errs[0])
+			return
+		}
+		checkErr = txn.Commit(context.Background())
+	}
+	originalHook := s.dom.DDL().GetHook()
+	s.dom.DDL().(ddl.DDLForTest).SetHook(hook)
+	defer s.dom.DDL().(ddl.DDLForTest).SetHook(originalHook)
+
+	for _, state := range cancelStates {
+		cancelled, cancelState = false, state
+		_, err := tk.Exec("alter table test_multi_schema add column c3 int, add index idx_c3(c3)")
+		c.Assert(checkErr, IsNil)
+		c.Assert(cancelled, IsTrue)
+		c.Assert(err, NotNil)
+		c.Assert(err.Error(), Equals, "[ddl:8214]Cancelled DDL job")
+		t := s.testGetTable(c, "test_multi_schema")
+		c.Assert(table.FindCol(t.Cols(), "c3"), IsNil)
+		c.Assert(t.Meta().FindIndexByName("idx_c3"), IsNil)
+		tk.MustExec("admin check table test_multi_schema")
+	}
+}
+
 func checkDelRangeDone(c *C, ctx sessionctx.Context, idx table.Index) {
 	startTime := time.Now()
 	f := func() map[int64]struct{} {
@@ -6911,3 +6973,75 @@ func (s *testDBSuite8) TestDdlMaxLimitOfIdentifier(c *C) {
 	tk.MustExec(fmt.Sprintf("alter table %s change f2 %s int", longTblName, strings.Repeat("二", mysql.MaxColumnNameLength-1)))
 
 }
+
+func (s *testSerialDBSuite) TestPauseAddIndexReorg(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test_db")
+	tk.MustExec("drop table if exists t1")
+	tk.MustExec("create table t1 (c1 int, c2 int, c3 int)")
+	batchInsert(tk, "t1", 0, 10)
+	defer tk.MustExec("set @@global.tidb_ddl_reorg_paused_jobs = ''")
+	tk.MustGetErrCode("set @@global.tidb_ddl_reorg_paused_jobs = 'a'", errno.ErrWrongValueForVar)
+
+	tk1 := testkit.NewTestKit(c, s.store)
+	var checkErr error
+	var jobID int64
+	d := s.dom.DDL()
+	originalHook := d.GetHook()
+	defer d.(ddl.DDLForTest).SetHook(originalHook)
+	hook := &ddl.TestDDLCallback{Do: s.dom}
+	hook.OnJobRunBeforeExported = func(job *model.Job) {
+		if atomic.LoadInt64(&jobID) != 0 || job.SchemaState != model.StateWriteReorganization {
+			return
+		}
+		_, checkErr = tk1.Exec(fmt.Sprintf("set @@global.tidb_ddl_reorg_paused_jobs = '%d'", job.ID))
+		atomic.StoreInt64(&jobID, job.ID)
+	}
+	d.(ddl.DDLForTest).SetHook(hook)
+
+	done := make(chan error, 1)
+	go backgroundExec(s.store, "alter table t1 add index idx(c2)", done)
+
+	isPaused := func() bool {
+		id := atomic.LoadInt64(&jobID)
+		if id == 0 {
+			return false
+		}
+		for _, row := range tk.MustQuery("admin show ddl jobs").Rows() {
+			if row[0] == strconv.FormatInt(id, 10) && row[10] == "paused" {
+				return true
+			}
+		}
+		return false
+	}
+	for deadline := time.Now().Add(10 * time.Second); !isPaused(); {
+		c.Assert(time.Now().Before(deadline), IsTrue, Commentf("the job isn't paused"))
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(checkErr, IsNil)
+	select {
+	case err := <-done:
+		c.Fatalf("the paused job is finished, err: %v", err)
+	default:
+	}
+
+	tk.MustExec("set @@global.tidb_ddl_reorg_paused_jobs = ''")
+	c.Assert(<-done, IsNil)
+	tk.MustExec("admin check table t1")
+
+	// The cancelled job is removed from the paused jobs.
+	atomic.StoreInt64(&jobID, 0)
+	go backgroundExec(s.store, "alter table t1 add index idx2(c3)", done)
+	for deadline := time.Now().Add(10 * time.Second); !isPaused(); {
+		c.Assert(time.Now().Before(deadline), IsTrue, Commentf("the job isn't paused"))
+		time.Sleep(10 * time.Millisecond)
+	}
+	tk.MustExec(fmt.Sprintf("set @@global.tidb_ddl_reorg_paused_jobs = '1,%d,2'", atomic.LoadInt64(&jobID)))
+	tk.MustExec(fmt.Sprintf("admin cancel ddl jobs %d", atomic.LoadInt64(&jobID)))
+	c.Assert(<-done, NotNil)
+	for deadline := time.Now().Add(10 * time.Second); tk.MustQuery("select @@global.tidb_ddl_reorg_paused_jobs").Rows()[0][0] != "1,2"; {
+		c.Assert(time.Now().Before(deadline), IsTrue, Commentf("the cancelled job isn't removed from the paused jobs"))
+		time.Sleep(10 * time.Millisecond)
+	}
+	tk.MustExec("admin check table t1")
+}
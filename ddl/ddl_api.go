@@ -2400,14 +2400,14 @@ func (d *ddl) AlterTable(ctx sessionctx.Context, ident ast.Ident, specs []*ast.A
 			case ast.AlterTableDropColumn:
 				err = d.DropColumns(ctx, ident, validSpecs)
 			default:
-				return errRunMultiSchemaChanges
+				err = d.MultiSchemaChange(ctx, ident, validSpecs)
 			}
 			if err != nil {
 				return errors.Trace(err)
 			}
 			return nil
 		}
-		return errRunMultiSchemaChanges
+		return d.MultiSchemaChange(ctx, ident, validSpecs)
 	}
 
 	for _, spec := range validSpecs {
@@ -3768,18 +3768,22 @@ func processAndCheckDefaultValueAndColumn(ctx sessionctx.Context, col *table.Col
 	return nil
 }
 
+// getModifiableColumnJob builds the job modifying the column of t, or of the latest table of ident if t is nil.
 func (d *ddl) getModifiableColumnJob(ctx sessionctx.Context, ident ast.Ident, originalColName model.CIStr,
-	spec *ast.AlterTableSpec) (*model.Job, error) {
+	spec *ast.AlterTableSpec, t table.Table) (*model.Job, error) {
 	specNewColumn := spec.NewColumns[0]
 	is := d.infoCache.GetLatest()
 	schema, ok := is.SchemaByName(ident.Schema)
 	if !ok {
 		return nil, errors.Trace(infoschema.ErrDatabaseNotExists)
 	}
-	t, err := is.TableByName(ident.Schema, ident.Name)
+	latest, err := is.TableByName(ident.Schema, ident.Name)
 	if err != nil {
 		return nil, errors.Trace(infoschema.ErrTableNotExists.GenWithStackByArgs(ident.Schema, ident.Name))
 	}
+	if t == nil {
+		t = latest
+	}
 
 	col := table.FindCol(t.Cols(), originalColName.L)
 	if col == nil {
@@ -3905,8 +3909,11 @@ func (d *ddl) getModifiableColumnJob(ctx sessionctx.Context, ident ast.Ident, or
 	// We support modifying the type definitions of 'null' to 'not null' now.
 	var modifyColumnTp byte
 	if !mysql.HasNotNullFlag(col.Flag) && mysql.HasNotNullFlag(newCol.Flag) {
-		if err = checkForNullValue(ctx, col.Tp != newCol.Tp, ident.Schema, ident.Name, newCol.Name, col.ColumnInfo); err != nil {
-			return nil, errors.Trace(err)
+		// The column added by the same statement isn't stored yet, its values are checked when the job runs.
+		if table.FindCol(latest.Cols(), col.Name.L) != nil {
+			if err = checkForNullValue(ctx, col.Tp != newCol.Tp, ident.Schema, ident.Name, newCol.Name, col.ColumnInfo); err != nil {
+				return nil, errors.Trace(err)
+			}
 		}
 		// `modifyColumnTp` indicates that there is a type modification.
 		modifyColumnTp = mysql.TypeNull
@@ -4098,28 +4105,14 @@ func checkAutoRandom(tableInfo *model.TableInfo, originCol *table.Column, specNe
 // currently we only support limited kind of changes
 // that do not need to change or check data on the table.
 func (d *ddl) ChangeColumn(ctx sessionctx.Context, ident ast.Ident, spec *ast.AlterTableSpec) error {
-	specNewColumn := spec.NewColumns[0]
-	if len(specNewColumn.Name.Schema.O) != 0 && ident.Schema.L != specNewColumn.Name.Schema.L {
-		return ErrWrongDBName.GenWithStackByArgs(specNewColumn.Name.Schema.O)
-	}
-	if len(spec.OldColumnName.Schema.O) != 0 && ident.Schema.L != spec.OldColumnName.Schema.L {
-		return ErrWrongDBName.GenWithStackByArgs(spec.OldColumnName.Schema.O)
-	}
-	if len(specNewColumn.Name.Table.O) != 0 && ident.Name.L != specNewColumn.Name.Table.L {
-		return ErrWrongTableName.GenWithStackByArgs(specNewColumn.Name.Table.O)
-	}
-	if len(spec.OldColumnName.Table.O) != 0 && ident.Name.L != spec.OldColumnName.Table.L {
-		return ErrWrongTableName.GenWithStackByArgs(spec.OldColumnName.Table.O)
-	}
-
-	job, err := d.getModifiableColumnJob(ctx, ident, spec.OldColumnName.Name, spec)
+	job, err := d.buildModifyColumnJob(ctx, ident, spec, nil)
 	if err != nil {
-		if infoschema.ErrColumnNotExists.Equal(err) && spec.IfExists {
-			ctx.GetSessionVars().StmtCtx.AppendNote(infoschema.ErrColumnNotExists.GenWithStackByArgs(spec.OldColumnName.Name, ident.Name))
-			return nil
-		}
 		return errors.Trace(err)
 	}
+	// The column doesn't exist and if_exists flag is true.
+	if job == nil {
+		return nil
+	}
 
 	err = d.doDDLJob(ctx, job)
 	// column not exists, but if_exists flags is true, so we ignore this error.
@@ -4131,6 +4124,38 @@ func (d *ddl) ChangeColumn(ctx sessionctx.Context, ident ast.Ident, spec *ast.Al
 	return errors.Trace(err)
 }
 
+// buildModifyColumnJob checks the MODIFY COLUMN or CHANGE COLUMN spec against t, or against the latest table of ident
+// if t is nil, and builds the job for it. It returns nil if the column doesn't exist and if_exists flag is true.
+func (d *ddl) buildModifyColumnJob(ctx sessionctx.Context, ident ast.Ident, spec *ast.AlterTableSpec, t table.Table) (*model.Job, error) {
+	specNewColumn := spec.NewColumns[0]
+	if len(specNewColumn.Name.Schema.O) != 0 && ident.Schema.L != specNewColumn.Name.Schema.L {
+		return nil, ErrWrongDBName.GenWithStackByArgs(specNewColumn.Name.Schema.O)
+	}
+	if len(specNewColumn.Name.Table.O) != 0 && ident.Name.L != specNewColumn.Name.Table.L {
+		return nil, ErrWrongTableName.GenWithStackByArgs(specNewColumn.Name.Table.O)
+	}
+	originalColName := specNewColumn.Name.Name
+	if spec.Tp == ast.AlterTableChangeColumn {
+		if len(spec.OldColumnName.Schema.O) != 0 && ident.Schema.L != spec.OldColumnName.Schema.L {
+			return nil, ErrWrongDBName.GenWithStackByArgs(spec.OldColumnName.Schema.O)
+		}
+		if len(spec.OldColumnName.Table.O) != 0 && ident.Name.L != spec.OldColumnName.Table.L {
+			return nil, ErrWrongTableName.GenWithStackByArgs(spec.OldColumnName.Table.O)
+		}
+		originalColName = spec.OldColumnName.Name
+	}
+
+	job, err := d.getModifiableColumnJob(ctx, ident, originalColName, spec, t)
+	if err != nil {
+		if infoschema.ErrColumnNotExists.Equal(err) && spec.IfExists {
+			ctx.GetSessionVars().StmtCtx.AppendNote(infoschema.ErrColumnNotExists.GenWithStackByArgs(originalColName, ident.Name))
+			return nil, nil
+		}
+		return nil, errors.Trace(err)
+	}
+	return job, nil
+}
+
 // RenameColumn renames an existing column.
 func (d *ddl) RenameColumn(ctx sessionctx.Context, ident ast.Ident, spec *ast.AlterTableSpec) error {
 	oldColName := spec.OldColumnName.Name
@@ -4198,23 +4223,14 @@ func (d *ddl) RenameColumn(ctx sessionctx.Context, ident ast.Ident, spec *ast.Al
 // ModifyColumn does modification on an existing column, currently we only support limited kind of changes
 // that do not need to change or check data on the table.
 func (d *ddl) ModifyColumn(ctx sessionctx.Context, ident ast.Ident, spec *ast.AlterTableSpec) error {
-	specNewColumn := spec.NewColumns[0]
-	if len(specNewColumn.Name.Schema.O) != 0 && ident.Schema.L != specNewColumn.Name.Schema.L {
-		return ErrWrongDBName.GenWithStackByArgs(specNewColumn.Name.Schema.O)
-	}
-	if len(specNewColumn.Name.Table.O) != 0 && ident.Name.L != specNewColumn.Name.Table.L {
-		return ErrWrongTableName.GenWithStackByArgs(specNewColumn.Name.Table.O)
-	}
-
-	originalColName := specNewColumn.Name.Name
-	job, err := d.getModifiableColumnJob(ctx, ident, originalColName, spec)
+	job, err := d.buildModifyColumnJob(ctx, ident, spec, nil)
 	if err != nil {
-		if infoschema.ErrColumnNotExists.Equal(err) && spec.IfExists {
-			ctx.GetSessionVars().StmtCtx.AppendNote(infoschema.ErrColumnNotExists.GenWithStackByArgs(originalColName, ident.Name))
-			return nil
-		}
 		return errors.Trace(err)
 	}
+	// The column doesn't exist and if_exists flag is true.
+	if job == nil {
+		return nil
+	}
 
 	err = d.doDDLJob(ctx, job)
 	// column not exists, but if_exists flags is true, so we ignore this error.
@@ -5072,12 +5088,35 @@ func (d *ddl) CreateIndex(ctx sessionctx.Context, ti ast.Ident, keyType ast.Inde
 	if keyType == ast.IndexKeyTypeFullText || keyType == ast.IndexKeyTypeSpatial {
 		return errUnsupportedIndexType.GenWithStack("FULLTEXT and SPATIAL index is not supported")
 	}
-	unique := keyType == ast.IndexKeyTypeUnique
 	schema, t, err := d.getSchemaAndTableByIdent(ctx, ti)
 	if err != nil {
 		return errors.Trace(err)
 	}
+	job, err := buildCreateIndexJob(ctx, schema, t, keyType, indexName, indexPartSpecifications, indexOption, ifNotExists)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// The index has existed and if_not_exists flag is true.
+	if job == nil {
+		return nil
+	}
 
+	err = d.doDDLJob(ctx, job)
+	// key exists, but if_not_exists flags is true, so we ignore this error.
+	if ErrDupKeyName.Equal(err) && ifNotExists {
+		ctx.GetSessionVars().StmtCtx.AppendNote(err)
+		return nil
+	}
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+// buildCreateIndexJob checks the index and builds the job to add it. It returns nil if the index has existed and
+// ifNotExists is true.
+func buildCreateIndexJob(ctx sessionctx.Context, schema *model.DBInfo, t table.Table, keyType ast.IndexKeyType, indexName model.CIStr,
+	indexPartSpecifications []*ast.IndexPartSpecification, indexOption *ast.IndexOption, ifNotExists bool) (*model.Job, error) {
+	unique := keyType == ast.IndexKeyTypeUnique
+	var err error
 	// Deal with anonymous index.
 	if len(indexName.L) == 0 {
 		colName := model.NewCIStr("expression_index")
@@ -5098,13 +5137,13 @@ func (d *ddl) CreateIndex(ctx sessionctx.Context, ti ast.Ident, keyType ast.Inde
 		}
 		if ifNotExists {
 			ctx.GetSessionVars().StmtCtx.AppendNote(err)
-			return nil
+			return nil, nil
 		}
-		return err
+		return nil, err
 	}
 
 	if err = checkTooLongIndex(indexName); err != nil {
-		return errors.Trace(err)
+		return nil, errors.Trace(err)
 	}
 
 	tblInfo := t.Meta()
@@ -5112,10 +5151,10 @@ func (d *ddl) CreateIndex(ctx sessionctx.Context, ti ast.Ident, keyType ast.Inde
 	// Build hidden columns if necessary.
 	hiddenCols, err := buildHiddenColumnInfo(ctx, indexPartSpecifications, indexName, t.Meta(), t.Cols())
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if err = checkAddColumnTooManyColumns(len(t.Cols()) + len(hiddenCols)); err != nil {
-		return errors.Trace(err)
+		return nil, errors.Trace(err)
 	}
 
 	// Check before the job is put to the queue.
@@ -5126,7 +5165,7 @@ func (d *ddl) CreateIndex(ctx sessionctx.Context, ti ast.Ident, keyType ast.Inde
 	// For same reason, decide whether index is global here.
 	indexColumns, err := buildIndexColumns(append(tblInfo.Columns, hiddenCols...), indexPartSpecifications)
 	if err != nil {
-		return errors.Trace(err)
+		return nil, errors.Trace(err)
 	}
 
 	if !unique && tblInfo.IsCommonHandle {
@@ -5134,14 +5173,14 @@ func (d *ddl) CreateIndex(ctx sessionctx.Context, ti ast.Ident, keyType ast.Inde
 		var pkLen, idxLen int
 		pkLen, err = indexColumnsLen(tblInfo.Columns, tables.FindPrimaryIndex(tblInfo).Columns)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		idxLen, err = indexColumnsLen(tblInfo.Columns, indexColumns)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if pkLen+idxLen > config.GetGlobalConfig().MaxIndexLength {
-			return errTooLongKey.GenWithStackByArgs(config.GetGlobalConfig().MaxIndexLength)
+			return nil, errTooLongKey.GenWithStackByArgs(config.GetGlobalConfig().MaxIndexLength)
 		}
 	}
 
@@ -5149,11 +5188,11 @@ func (d *ddl) CreateIndex(ctx sessionctx.Context, ti ast.Ident, keyType ast.Inde
 	if unique && tblInfo.GetPartitionInfo() != nil {
 		ck, err := checkPartitionKeysConstraint(tblInfo.GetPartitionInfo(), indexColumns, tblInfo)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if !ck {
 			if !config.GetGlobalConfig().EnableGlobalIndex {
-				return ErrUniqueKeyNeedAllFieldsInPf.GenWithStackByArgs("UNIQUE INDEX")
+				return nil, ErrUniqueKeyNeedAllFieldsInPf.GenWithStackByArgs("UNIQUE INDEX")
 			}
 			// index columns does not contain all partition columns, must set global
 			global = true
@@ -5161,7 +5200,7 @@ func (d *ddl) CreateIndex(ctx sessionctx.Context, ti ast.Ident, keyType ast.Inde
 	}
 	// May be truncate comment here, when index comment too long and sql_mode is't strict.
 	if _, err = validateCommentLength(ctx.GetSessionVars(), indexName.String(), indexOption); err != nil {
-		return errors.Trace(err)
+		return nil, errors.Trace(err)
 	}
 	job := &model.Job{
 		SchemaID:   schema.ID,
@@ -5177,15 +5216,7 @@ func (d *ddl) CreateIndex(ctx sessionctx.Context, ti ast.Ident, keyType ast.Inde
 		Args:     []interface{}{unique, indexName, indexPartSpecifications, indexOption, hiddenCols, global},
 		Priority: ctx.GetSessionVars().DDLReorgPriority,
 	}
-
-	err = d.doDDLJob(ctx, job)
-	// key exists, but if_not_exists flags is true, so we ignore this error.
-	if ErrDupKeyName.Equal(err) && ifNotExists {
-		ctx.GetSessionVars().StmtCtx.AppendNote(err)
-		return nil
-	}
-	err = d.callHookOnChanged(err)
-	return errors.Trace(err)
+	return job, nil
 }
 
 func buildFKInfo(fkName model.CIStr, keys []*ast.IndexPartSpecification, refer *ast.ReferenceDef, cols []*table.Column, tbInfo *model.TableInfo) (*model.FKInfo, error) {
@@ -384,6 +384,8 @@ func (w *worker) finishDDLJob(t *meta.Meta, job *model.Job) (err error) {
 		case model.ActionDropSchema, model.ActionDropTable, model.ActionTruncateTable, model.ActionDropIndex, model.ActionDropPrimaryKey,
 			model.ActionDropTablePartition, model.ActionTruncateTablePartition, model.ActionDropColumn, model.ActionDropColumns, model.ActionModifyColumn:
 			err = w.deleteRange(job)
+		case ActionMultiSchemaChange:
+			err = w.deleteRangeForMultiSchemaChange(job)
 		}
 	}
 	if job.Type == model.ActionRecoverTable {
@@ -564,6 +566,9 @@ func (w *worker) handleDDLJobQueue(d *ddlCtx) error {
 		d.mu.RUnlock()
 
 		if job.IsSynced() || job.IsCancelled() || job.IsRollbackDone() {
+			if err := w.removeReorgPausedJob(job.ID); err != nil {
+				logutil.Logger(w.logCtx).Warn("[ddl] remove the finished job from the paused reorg jobs failed", zap.Int64("jobID", job.ID), zap.Error(err))
+			}
 			asyncNotify(d.ddlJobDoneCh)
 		}
 	}
@@ -732,6 +737,8 @@ func (w *worker) runDDLJob(d *ddlCtx, t *meta.Meta, job *model.Job) (ver int64,
 		ver, err = onDropColumns(t, job)
 	case model.ActionModifyColumn:
 		ver, err = w.onModifyColumn(d, t, job)
+	case ActionMultiSchemaChange:
+		ver, err = w.onMultiSchemaChange(d, t, job)
 	case model.ActionSetDefaultValue:
 		ver, err = onSetDefaultValue(t, job)
 	case model.ActionAddIndex:
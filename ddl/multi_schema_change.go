@@ -0,0 +1,412 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ddl
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/terror"
+	"github.com/pingcap/tidb/meta"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/table/tables"
+)
+
+// ActionMultiSchemaChange is the type of the job that runs the ADD COLUMN, ADD INDEX and MODIFY COLUMN clauses of an
+// ALTER TABLE statement together. The model of pingcap/parser doesn't define it yet, so it takes the largest value,
+// which is away from the types the parser numbers one by one. It should be replaced by the one defined in the parser
+// model once the dependency is bumped.
+const ActionMultiSchemaChange model.ActionType = math.MaxUint8
+
+// JobTypeString returns the name of the job type, including the types that the parser model doesn't define.
+func JobTypeString(tp model.ActionType) string {
+	if tp == ActionMultiSchemaChange {
+		return "multi-schema change"
+	}
+	return tp.String()
+}
+
+// MultiSchemaChange runs the ADD COLUMN, ADD INDEX and MODIFY COLUMN clauses of an ALTER TABLE statement as one job.
+// Every clause is a sub-job, they run in that order and the done ones are reverted if a later one fails.
+func (d *ddl) MultiSchemaChange(ctx sessionctx.Context, ti ast.Ident, specs []*ast.AlterTableSpec) error {
+	var addColumnSpecs, addIndexSpecs, modifyColumnSpecs []*ast.AlterTableSpec
+	for _, spec := range specs {
+		switch spec.Tp {
+		case ast.AlterTableAddColumns:
+			if len(spec.NewColumns) != 1 || len(spec.NewConstraints) != 0 {
+				return errRunMultiSchemaChanges
+			}
+			addColumnSpecs = append(addColumnSpecs, spec)
+		case ast.AlterTableAddConstraint:
+			switch spec.Constraint.Tp {
+			case ast.ConstraintKey, ast.ConstraintIndex, ast.ConstraintUniq, ast.ConstraintUniqIndex, ast.ConstraintUniqKey:
+				addIndexSpecs = append(addIndexSpecs, spec)
+			default:
+				return errRunMultiSchemaChanges
+			}
+		case ast.AlterTableModifyColumn, ast.AlterTableChangeColumn:
+			modifyColumnSpecs = append(modifyColumnSpecs, spec)
+		default:
+			return errRunMultiSchemaChanges
+		}
+	}
+	// A column type change may reorganize the data, only one of them can be reverted.
+	if len(modifyColumnSpecs) > 1 {
+		return errRunMultiSchemaChanges
+	}
+
+	schema, t, err := d.getSchemaAndTableByIdent(ctx, ti)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// The later clauses are checked with the columns and indexes added by the former ones.
+	tblInfo := t.Meta().Clone()
+	subJobs := make([]*model.Job, 0, len(specs))
+	for _, spec := range addColumnSpecs {
+		tbl, err := tables.TableFromMeta(nil, tblInfo)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		col, err := checkAndCreateNewColumn(ctx, ti, schema, spec, tbl, spec.NewColumns[0])
+		if err != nil {
+			return errors.Trace(err)
+		}
+		// Added column has existed and if_not_exists flag is true.
+		if col == nil {
+			continue
+		}
+		subJobs = append(subJobs, &model.Job{
+			SchemaID:   schema.ID,
+			TableID:    tblInfo.ID,
+			SchemaName: schema.Name.L,
+			Type:       model.ActionAddColumn,
+			BinlogInfo: &model.HistoryInfo{},
+			Args:       []interface{}{col, spec.Position, 0},
+		})
+		colInfo := col.ToInfo().Clone()
+		colInfo.ID = allocateColumnID(tblInfo)
+		colInfo.Offset = len(tblInfo.Columns)
+		colInfo.State = model.StatePublic
+		tblInfo.Columns = append(tblInfo.Columns, colInfo)
+	}
+	for _, spec := range addIndexSpecs {
+		constr := spec.Constraint
+		keyType, ifNotExists := ast.IndexKeyTypeNone, constr.IfNotExists
+		if constr.Tp != ast.ConstraintKey && constr.Tp != ast.ConstraintIndex {
+			// IfNotExists should be not applied
+			keyType, ifNotExists = ast.IndexKeyTypeUnique, false
+		}
+		tbl, err := tables.TableFromMeta(nil, tblInfo)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		sub, err := buildCreateIndexJob(ctx, schema, tbl, keyType, model.NewCIStr(constr.Name), constr.Keys, constr.Option, ifNotExists)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		// The index has existed and if_not_exists flag is true.
+		if sub == nil {
+			continue
+		}
+		subJobs = append(subJobs, sub)
+		indexName, hiddenCols := sub.Args[1].(model.CIStr), sub.Args[4].([]*model.ColumnInfo)
+		for _, hiddenCol := range hiddenCols {
+			colInfo := hiddenCol.Clone()
+			colInfo.ID = allocateColumnID(tblInfo)
+			colInfo.Offset = len(tblInfo.Columns)
+			colInfo.State = model.StatePublic
+			tblInfo.Columns = append(tblInfo.Columns, colInfo)
+		}
+		indexInfo, err := buildIndexInfo(tblInfo, indexName, constr.Keys, model.StatePublic)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		indexInfo.ID = allocateIndexID(tblInfo)
+		indexInfo.Unique = keyType == ast.IndexKeyTypeUnique
+		tblInfo.Indices = append(tblInfo.Indices, indexInfo)
+	}
+	if err = checkAddColumnTooManyColumns(len(tblInfo.Columns)); err != nil {
+		return errors.Trace(err)
+	}
+	for _, spec := range modifyColumnSpecs {
+		tbl, err := tables.TableFromMeta(nil, tblInfo)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		sub, err := d.buildModifyColumnJob(ctx, ti, spec, tbl)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		// The column doesn't exist and if_exists flag is true.
+		if sub == nil {
+			continue
+		}
+		subJobs = append(subJobs, sub)
+	}
+
+	var job *model.Job
+	switch len(subJobs) {
+	case 0:
+		return nil
+	case 1:
+		job = subJobs[0]
+	default:
+		if err = encodeSubJobArgs(subJobs); err != nil {
+			return errors.Trace(err)
+		}
+		job = &model.Job{
+			SchemaID:   schema.ID,
+			TableID:    tblInfo.ID,
+			SchemaName: schema.Name.L,
+			Type:       ActionMultiSchemaChange,
+			BinlogInfo: &model.HistoryInfo{},
+			ReorgMeta: &model.DDLReorgMeta{
+				SQLMode:       ctx.GetSessionVars().SQLMode,
+				Warnings:      make(map[errors.ErrorID]*terror.Error),
+				WarningsCount: make(map[errors.ErrorID]int64),
+			},
+			Args:     []interface{}{subJobs},
+			Priority: ctx.GetSessionVars().DDLReorgPriority,
+		}
+	}
+
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+// encodeSubJobArgs marshals the args of the sub-jobs that have been decoded, so that they are saved with the job.
+func encodeSubJobArgs(subJobs []*model.Job) error {
+	for _, sub := range subJobs {
+		if sub.Args == nil {
+			continue
+		}
+		rawArgs, err := json.Marshal(sub.Args)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		sub.RawArgs = rawArgs
+	}
+	return nil
+}
+
+// initSubJob sets the fields that the sub-job shares with the multi-schema change job.
+func initSubJob(job, sub *model.Job) {
+	sub.ID = job.ID
+	sub.StartTS = job.StartTS
+	sub.Version = job.Version
+	sub.Query = job.Query
+}
+
+func (w *worker) runSubJob(d *ddlCtx, t *meta.Meta, sub *model.Job) (ver int64, err error) {
+	switch sub.Type {
+	case model.ActionAddColumn:
+		ver, err = onAddColumn(d, t, sub)
+	case model.ActionAddIndex:
+		ver, err = w.onCreateIndex(d, t, sub, false)
+	case model.ActionModifyColumn:
+		ver, err = w.onModifyColumn(d, t, sub)
+	default:
+		sub.State = model.JobStateCancelled
+		err = errInvalidDDLJob.GenWithStack("invalid sub-job type %v", sub.Type)
+	}
+	return ver, errors.Trace(err)
+}
+
+func (w *worker) onMultiSchemaChange(d *ddlCtx, t *meta.Meta, job *model.Job) (ver int64, err error) {
+	var subJobs []*model.Job
+	if err = job.DecodeArgs(&subJobs); err != nil {
+		job.State = model.JobStateCancelled
+		return ver, errors.Trace(err)
+	}
+	defer func() {
+		if err1 := encodeSubJobArgs(subJobs); err1 != nil && err == nil {
+			err = err1
+		}
+	}()
+
+	if job.IsRollingback() {
+		return w.rollbackMultiSchemaChange(d, t, job, subJobs)
+	}
+	for i, sub := range subJobs {
+		if sub.IsDone() {
+			continue
+		}
+		initSubJob(job, sub)
+		sub.State = model.JobStateRunning
+		ver, err = w.runSubJob(d, t, sub)
+		job.SchemaState = sub.SchemaState
+		var rowCount int64
+		for _, sub := range subJobs {
+			rowCount += sub.GetRowCount()
+		}
+		job.SetRowCount(rowCount)
+
+		switch {
+		case sub.IsDone():
+			if sub.ReorgMeta != nil {
+				job.SetWarnings(mergeWarningsAndWarningsCount(sub.ReorgMeta.Warnings, job.ReorgMeta.Warnings, sub.ReorgMeta.WarningsCount, job.ReorgMeta.WarningsCount))
+			}
+			if i == len(subJobs)-1 {
+				job.FinishTableJob(model.JobStateDone, model.StatePublic, ver, sub.BinlogInfo.TableInfo)
+			}
+		case sub.IsCancelled() && i == 0:
+			job.State = model.JobStateCancelled
+		case sub.IsCancelled(), sub.IsRollingback():
+			// Revert the sub-jobs that have been done.
+			job.State = model.JobStateRollingback
+		}
+		return ver, errors.Trace(err)
+	}
+	return ver, nil
+}
+
+// rollbackMultiSchemaChange reverts the sub-jobs one by one from the last one that has been run.
+func (w *worker) rollbackMultiSchemaChange(d *ddlCtx, t *meta.Meta, job *model.Job, subJobs []*model.Job) (ver int64, err error) {
+	for i := len(subJobs) - 1; i >= 0; i-- {
+		sub := subJobs[i]
+		if !sub.IsRollingback() && !sub.IsDone() {
+			continue
+		}
+		initSubJob(job, sub)
+		if sub.IsDone() {
+			if err = revertSubJob(sub); err != nil {
+				return ver, errors.Trace(err)
+			}
+		}
+		ver, err = w.runSubJob(d, t, sub)
+		job.SchemaState = sub.SchemaState
+		if sub.IsRollbackDone() {
+			hasDone := false
+			for _, sub := range subJobs[:i] {
+				hasDone = hasDone || sub.IsDone()
+			}
+			if !hasDone {
+				job.FinishTableJob(model.JobStateRollbackDone, model.StateNone, ver, sub.BinlogInfo.TableInfo)
+			}
+		}
+		return ver, errors.Trace(err)
+	}
+
+	// None of the sub-jobs has to be reverted.
+	tblInfo, err := getTableInfo(t, job.TableID, job.SchemaID)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	job.FinishTableJob(model.JobStateRollbackDone, model.StateNone, ver, tblInfo)
+	return ver, nil
+}
+
+// revertSubJob turns the done sub-job into a rolling back one, which undoes what the sub-job has done.
+func revertSubJob(sub *model.Job) (err error) {
+	switch sub.Type {
+	case model.ActionAddColumn:
+		col := &model.ColumnInfo{}
+		if err = sub.DecodeArgs(col); err != nil {
+			return errors.Trace(err)
+		}
+		// the arg will be used in onDropColumn.
+		sub.Args = []interface{}{col.Name}
+	case model.ActionAddIndex:
+		var (
+			unique    bool
+			indexName model.CIStr
+		)
+		if err = sub.DecodeArgs(&unique, &indexName); err != nil {
+			return errors.Trace(err)
+		}
+		// the args will be used in onDropIndex and the delete-range.
+		sub.Args = []interface{}{indexName, getPartitionIDs(sub.BinlogInfo.TableInfo)}
+	default:
+		return errInvalidDDLJob.GenWithStack("can't revert the sub-job type %v", sub.Type)
+	}
+	// The handler decodes the args at once.
+	if sub.RawArgs, err = json.Marshal(sub.Args); err != nil {
+		return errors.Trace(err)
+	}
+	sub.State = model.JobStateRollingback
+	return nil
+}
+
+// rollingbackMultiSchemaChange cancels the running sub-job, the job is reverted if the sub-job can be cancelled.
+func rollingbackMultiSchemaChange(w *worker, d *ddlCtx, t *meta.Meta, job *model.Job) (ver int64, err error) {
+	var subJobs []*model.Job
+	if err = job.DecodeArgs(&subJobs); err != nil {
+		job.State = model.JobStateCancelled
+		return ver, errors.Trace(err)
+	}
+	defer func() {
+		if err1 := encodeSubJobArgs(subJobs); err1 != nil && err == nil {
+			err = err1
+		}
+	}()
+
+	i := 0
+	for i < len(subJobs) && subJobs[i].IsDone() {
+		i++
+	}
+	if i == len(subJobs) {
+		// All the sub-jobs are done, it's too late to cancel.
+		job.State = model.JobStateRunning
+		return ver, nil
+	}
+	sub := subJobs[i]
+	initSubJob(job, sub)
+	sub.State = model.JobStateCancelling
+	ver, err = convertJob2RollbackJob(w, d, t, sub)
+	job.SchemaState = sub.SchemaState
+	switch {
+	case sub.IsCancelled() && i == 0:
+		job.State = model.JobStateCancelled
+	case sub.IsCancelled(), sub.IsRollingback():
+		job.State = model.JobStateRollingback
+	case sub.IsCancelling():
+		// Converting the sub-job meets an error, retry it with the cancelling job.
+		return ver, errors.Trace(err)
+	default:
+		// The sub-job can't be cancelled in its state.
+		job.State = model.JobStateRunning
+		return ver, nil
+	}
+	return ver, errCancelledDDLJob
+}
+
+// deleteRangeForMultiSchemaChange deletes the data of the rolled back indexes and the old data of the modified column.
+func (w *worker) deleteRangeForMultiSchemaChange(job *model.Job) error {
+	var subJobs []*model.Job
+	if err := job.DecodeArgs(&subJobs); err != nil {
+		return errors.Trace(err)
+	}
+	for _, sub := range subJobs {
+		switch sub.Type {
+		case model.ActionAddIndex:
+			if !sub.IsRollbackDone() {
+				continue
+			}
+		case model.ActionModifyColumn:
+			if !sub.IsDone() && !sub.IsRollbackDone() {
+				continue
+			}
+		default:
+			continue
+		}
+		if err := w.deleteRange(sub); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
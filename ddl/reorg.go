@@ -68,6 +68,10 @@ type reorgCtx struct {
 	// accessed by reorg-worker and daemon-worker concurrently.
 	element atomic.Value
 
+	// pausedCheckTime is the last time the backfill checked whether the job is paused, it's only accessed by the
+	// backfill goroutine.
+	pausedCheckTime time.Time
+
 	// warnings is used to store the warnings when doing the reorg job under
 	// a certain SQL Mode.
 	mu struct {
@@ -152,6 +156,7 @@ func (rc *reorgCtx) clean() {
 	rc.setRowCount(0)
 	rc.setNextKey(nil)
 	rc.resetWarnings()
+	rc.pausedCheckTime = time.Time{}
 	rc.doneCh = nil
 }
 
@@ -52,6 +52,7 @@ func DispatchMPPTasks(ctx context.Context, sctx sessionctx.Context, tasks []*kv.
 		copPlanIDs: planIDs,
 		rootPlanID: rootID,
 		storeType:  kv.TiFlash,
+		isMPP:      true,
 	}, nil
 
 }
@@ -70,8 +71,15 @@ func Select(ctx context.Context, sctx sessionctx.Context, kvReq *kv.Request, fie
 		hook.(func(*kv.Request))(kvReq)
 	}
 
-	if !sctx.GetSessionVars().EnableStreaming {
-		kvReq.Streaming = false
+	if kvReq.Paging && kvReq.StoreType == kv.TiKV {
+		// The pages are fetched by the streaming API, which returns the resume key of every response. The unary
+		// API can't be paged.
+		kvReq.Streaming = true
+	} else {
+		kvReq.Paging = false
+		if !sctx.GetSessionVars().EnableStreaming {
+			kvReq.Streaming = false
+		}
 	}
 	enabledRateLimitAction := sctx.GetSessionVars().EnabledRateLimitAction
 	resp := sctx.GetClient().Send(ctx, kvReq, sctx.GetSessionVars().KVVars, sctx.GetSessionVars().StmtCtx.MemTracker, enabledRateLimitAction)
@@ -191,7 +199,7 @@ func canUseChunkRPC(ctx sessionctx.Context) bool {
 	if !ctx.GetSessionVars().EnableChunkRPC {
 		return false
 	}
-	if ctx.GetSessionVars().EnableStreaming {
+	if ctx.GetSessionVars().EnableStreaming || ctx.GetSessionVars().EnablePaging {
 		return false
 	}
 	if !checkAlignment() {
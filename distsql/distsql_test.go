@@ -15,6 +15,7 @@ package distsql
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -27,6 +28,7 @@ import (
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/statistics"
+	"github.com/pingcap/tidb/store/copr"
 	"github.com/pingcap/tidb/types"
 	"github.com/pingcap/tidb/util/chunk"
 	"github.com/pingcap/tidb/util/codec"
@@ -36,6 +38,7 @@ import (
 	tikvstore "github.com/tikv/client-go/v2/kv"
 	"github.com/tikv/client-go/v2/tikv"
 	"github.com/tikv/client-go/v2/tikvrpc"
+	"github.com/tikv/client-go/v2/util"
 )
 
 func (s *testSuite) createSelectNormal(batch, totalRows int, c *C, planIDs []int) (*selectResult, []*types.FieldType) {
@@ -198,6 +201,57 @@ func (s *testSuite) TestSelectResultRuntimeStats(c *C) {
 	}
 	expect = "cop_task: {num: 1, max: 1s, proc_keys: 100, tot_proc: 1s, tot_wait: 1s, copr_cache_hit_ratio: 0.00}, backoff{RegionMiss: 1ms}"
 	c.Assert(s1.String(), Equals, expect)
+
+	// The retry budget is only shown when it is exhausted.
+	s1.mergeRetryBudget(time.Second, 200*time.Millisecond)
+	c.Assert(s1.String(), Equals, expect)
+	s1.mergeRetryBudget(time.Second, 0)
+	s1.mergeRetryBudget(time.Second, 100*time.Millisecond)
+	c.Assert(s1.String(), Equals, expect+", retry_budget: {total: 1s, remaining: 0s}")
+
+	// The served replicas are only shown when some tasks are not served by the leader.
+	s1 = &selectResultRuntimeStats{
+		copRespTime:      []time.Duration{time.Second},
+		procKeys:         []int64{100},
+		backoffSleep:     map[string]time.Duration{"RegionMiss": time.Millisecond},
+		totalProcessTime: time.Second,
+		totalWaitTime:    time.Second,
+		rpcStat:          tikv.NewRegionRequestRuntimeStats(),
+	}
+	s1.mergeServedReplicas(map[string]int{"leader": 1})
+	c.Assert(s1.String(), Equals, expect)
+	s2 = *s1.Clone().(*selectResultRuntimeStats)
+	s2.mergeServedReplicas(map[string]int{"follower": 2})
+	s1.Merge(&s2)
+	c.Assert(s1.String(), Equals, "cop_task: {num: 2, max: 1s, min: 1s, avg: 1s, p95: 1s, max_proc_keys: 100, p95_proc_keys: 100, tot_proc: 2s, tot_wait: 2s, copr_cache_hit_ratio: 0.00}, backoff{RegionMiss: 2ms}, replica: {follower: 2, leader: 2}")
+}
+
+func (s *testSuite) TestSelectResultCopTaskDetails(c *C) {
+	newCopStats := func(regionID uint64, store string, waitTime time.Duration, procKeys int64) *copr.CopRuntimeStats {
+		copStats := &copr.CopRuntimeStats{RegionID: regionID}
+		copStats.CalleeAddress = store
+		copStats.TimeDetail.WaitTime = waitTime
+		copStats.TimeDetail.ProcessTime = time.Millisecond
+		copStats.ScanDetail = &util.ScanDetail{ProcessedKeys: procKeys, TotalKeys: procKeys * 2}
+		return copStats
+	}
+	s1 := &selectResultRuntimeStats{
+		backoffSleep: make(map[string]time.Duration),
+		rpcStat:      tikv.NewRegionRequestRuntimeStats(),
+	}
+	s1.mergeCopRuntimeStats(newCopStats(2, "store1", time.Millisecond, 10), 10*time.Millisecond)
+	// The details are only shown if there are more than one cop task.
+	c.Assert(strings.Contains(s1.String(), "slowest_task"), IsFalse)
+	s1.mergeCopRuntimeStats(newCopStats(3, "store1", 2*time.Millisecond, 20), 20*time.Millisecond)
+	c.Assert(strings.Contains(s1.String(), "stores:"), IsFalse)
+	c.Assert(strings.Contains(s1.String(), ", slowest_task: {region: 3, store: store1, time: 20ms, wait: 2ms, proc: 1ms, proc_keys: 20, total_keys: 40}"), IsTrue)
+
+	s2 := s1.Clone().(*selectResultRuntimeStats)
+	s2.mergeCopRuntimeStats(newCopStats(4, "store2", 50*time.Millisecond, 30), 100*time.Millisecond)
+	s1.Merge(s2)
+	c.Assert(strings.Contains(s1.String(), ", stores: {store1: {num: 4, max: 20ms, p95: 20ms, max_wait: 2ms, p95_wait: 2ms}, "+
+		"store2: {num: 1, max: 100ms, p95: 100ms, max_wait: 50ms, p95_wait: 50ms}}, "+
+		"slowest_task: {region: 4, store: store2, time: 100ms, wait: 50ms, proc: 1ms, proc_keys: 30, total_keys: 60}"), IsTrue)
 }
 
 func (s *testSuite) createSelectStreaming(batch, totalRows int, c *C) (*streamResult, []*types.FieldType) {
@@ -273,6 +327,25 @@ func (s *testSuite) TestSelectStreamingChunkSize(c *C) {
 	c.Assert(response.Close(), IsNil)
 }
 
+func (s *testSuite) TestSelectPaging(c *C) {
+	colTypes := []*types.FieldType{types.NewFieldType(mysql.TypeLonglong)}
+	for _, storeType := range []kv.StoreType{kv.TiKV, kv.TiFlash} {
+		request, err := (&RequestBuilder{}).SetKeyRanges(nil).
+			SetDAGRequest(&tipb.DAGRequest{}).
+			SetStoreType(storeType).
+			SetMemTracker(memory.NewTracker(-1, -1)).
+			Build()
+		c.Assert(err, IsNil)
+		request.Paging = true
+		response, err := Select(context.TODO(), s.sctx, request, colTypes, statistics.NewQueryFeedback(0, nil, 0, false))
+		c.Assert(err, IsNil)
+		// The paged requests are sent by the streaming API, the others are not paged.
+		c.Assert(request.Paging, Equals, storeType == kv.TiKV)
+		c.Assert(request.Streaming, Equals, storeType == kv.TiKV)
+		c.Assert(response.Close(), IsNil)
+	}
+}
+
 func (s *testSuite) testChunkSize(response SelectResult, colTypes []*types.FieldType, c *C) {
 	chk := chunk.New(colTypes, 32, 32)
 
@@ -17,10 +17,12 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"time"
 
 	"github.com/pingcap/errors"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/ddl/placement"
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/kv"
@@ -125,6 +127,7 @@ func (builder *RequestBuilder) SetDAGRequest(dag *tipb.DAGRequest) *RequestBuild
 	if builder.err == nil {
 		builder.Request.Tp = kv.ReqTypeDAG
 		builder.Request.Cacheable = true
+		builder.Request.KeepRanges = dag.GetCollectRangeCounts()
 		builder.Request.Data, builder.err = dag.Marshal()
 	}
 	// When the DAG is just simple scan and small limit, set concurrency to 1 would be sufficient.
@@ -206,6 +209,9 @@ func (builder *RequestBuilder) getIsolationLevel() kv.IsoLevel {
 }
 
 func (builder *RequestBuilder) getKVPriority(sv *variable.SessionVars) int {
+	if sv.StmtCtx.Deprioritized.Load() {
+		return kv.PriorityLow
+	}
 	switch sv.StmtCtx.Priority {
 	case mysql.NoPriority, mysql.DelayedPriority:
 		return kv.PriorityNormal
@@ -217,18 +223,48 @@ func (builder *RequestBuilder) getKVPriority(sv *variable.SessionVars) int {
 	return kv.PriorityNormal
 }
 
+// getRetryBudget derives the retry budget of a request from max_execution_time, so the retries of
+// the cop tasks can't take longer than the rest of the time the statement is allowed to run.
+func getRetryBudget(sv *variable.SessionVars) time.Duration {
+	sc := sv.StmtCtx
+	maxExecutionTime := sv.MaxExecutionTime
+	if sc.HasMaxExecutionTime {
+		maxExecutionTime = sc.MaxExecutionTime
+	}
+	// max_execution_time only applies to SELECT statements.
+	if maxExecutionTime == 0 || !sc.InSelectStmt {
+		return 0
+	}
+	budget := time.Duration(maxExecutionTime) * time.Millisecond
+	if !sv.StartTime.IsZero() {
+		budget -= time.Since(sv.StartTime)
+	}
+	if budget < time.Millisecond {
+		budget = time.Millisecond
+	}
+	return budget
+}
+
 // SetFromSessionVars sets the following fields for "kv.Request" from session variables:
-// "Concurrency", "IsolationLevel", "NotFillCache", "ReplicaRead", "SchemaVar".
+// "Concurrency", "IsolationLevel", "NotFillCache", "ReplicaRead", "SchemaVar", "Backpressure", "RetryBudget", "Paging",
+// "MaxRangesPerTask".
 func (builder *RequestBuilder) SetFromSessionVars(sv *variable.SessionVars) *RequestBuilder {
 	if builder.Request.Concurrency == 0 {
 		// Concurrency may be set to 1 by SetDAGRequest
 		builder.Request.Concurrency = sv.DistSQLScanConcurrency()
 	}
+	if limit := sv.StmtCtx.CopConcurrencyLimit; limit > 0 && builder.Request.Concurrency > limit {
+		builder.Request.Concurrency = limit
+	}
 	builder.Request.IsolationLevel = builder.getIsolationLevel()
 	builder.Request.NotFillCache = sv.StmtCtx.NotFillCache
 	builder.Request.TaskID = sv.StmtCtx.TaskID
 	builder.Request.Priority = builder.getKVPriority(sv)
 	builder.Request.ReplicaRead = sv.GetReplicaRead()
+	builder.Request.Backpressure = sv.EnableDistSQLBackpressure
+	builder.Request.RetryBudget = getRetryBudget(sv)
+	builder.Request.Paging = sv.EnablePaging && builder.Request.Tp == kv.ReqTypeDAG
+	builder.Request.MaxRangesPerTask = sv.MaxRangesPerCopTask
 	builder.txnScope = sv.TxnCtx.TxnScope
 	builder.IsStaleness = sv.TxnCtx.IsStaleness
 	if builder.IsStaleness && builder.txnScope != kv.GlobalTxnScope {
@@ -239,6 +275,18 @@ func (builder *RequestBuilder) SetFromSessionVars(sv *variable.SessionVars) *Req
 			},
 		}
 	}
+	if builder.Request.ReplicaRead == kv.ReplicaReadClosest && len(builder.MatchStoreLabels) == 0 {
+		// Read the replicas in the same zone as this TiDB server, falls back to
+		// any replica if the zone label is not configured.
+		if zone := config.GetGlobalConfig().Labels[placement.DCLabelKey]; zone != "" {
+			builder.MatchStoreLabels = []*metapb.StoreLabel{
+				{
+					Key:   placement.DCLabelKey,
+					Value: zone,
+				},
+			}
+		}
+	}
 	builder.SetResourceGroupTag(sv.StmtCtx)
 	return builder
 }
@@ -275,11 +323,10 @@ func (builder *RequestBuilder) SetFromInfoSchema(pis interface{}) *RequestBuilde
 	return builder
 }
 
-// SetResourceGroupTag sets the request resource group tag.
+// SetResourceGroupTag sets the request resource group tag, with which the storage correlates the request to the
+// statement in Top SQL and the slow log.
 func (builder *RequestBuilder) SetResourceGroupTag(sc *stmtctx.StatementContext) *RequestBuilder {
-	if variable.TopSQLEnabled() {
-		builder.Request.ResourceGroupTag = sc.GetResourceGroupTag()
-	}
+	builder.Request.ResourceGroupTag = sc.GetResourceGroupTag()
 	return builder
 }
 
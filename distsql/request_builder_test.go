@@ -16,9 +16,12 @@ package distsql
 import (
 	"os"
 	"testing"
+	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/tidb/config"
+	"github.com/pingcap/tidb/ddl/placement"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/sessionctx/stmtctx"
@@ -313,16 +316,17 @@ func (s *testSuite) TestRequestBuilder1(c *C) {
 				EndKey:   kv.Key{0x74, 0x80, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xc, 0x5f, 0x72, 0x80, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x23},
 			},
 		},
-		Cacheable:      true,
-		KeepOrder:      false,
-		Desc:           false,
-		Concurrency:    variable.DefDistSQLScanConcurrency,
-		IsolationLevel: 0,
-		Priority:       0,
-		NotFillCache:   false,
-		SyncLog:        false,
-		Streaming:      false,
-		ReplicaRead:    kv.ReplicaReadLeader,
+		Cacheable:        true,
+		KeepOrder:        false,
+		Desc:             false,
+		Concurrency:      variable.DefDistSQLScanConcurrency,
+		IsolationLevel:   0,
+		Priority:         0,
+		NotFillCache:     false,
+		SyncLog:          false,
+		Streaming:        false,
+		ReplicaRead:      kv.ReplicaReadLeader,
+		MaxRangesPerTask: variable.DefTiDBMaxRangesPerCopTask,
 	}
 	c.Assert(actual, DeepEquals, expect)
 }
@@ -389,16 +393,17 @@ func (s *testSuite) TestRequestBuilder2(c *C) {
 				EndKey:   kv.Key{0x74, 0x80, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xc, 0x5f, 0x69, 0x80, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xf, 0x3, 0x80, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x23},
 			},
 		},
-		Cacheable:      true,
-		KeepOrder:      false,
-		Desc:           false,
-		Concurrency:    variable.DefDistSQLScanConcurrency,
-		IsolationLevel: 0,
-		Priority:       0,
-		NotFillCache:   false,
-		SyncLog:        false,
-		Streaming:      false,
-		ReplicaRead:    kv.ReplicaReadLeader,
+		Cacheable:        true,
+		KeepOrder:        false,
+		Desc:             false,
+		Concurrency:      variable.DefDistSQLScanConcurrency,
+		IsolationLevel:   0,
+		Priority:         0,
+		NotFillCache:     false,
+		SyncLog:          false,
+		Streaming:        false,
+		ReplicaRead:      kv.ReplicaReadLeader,
+		MaxRangesPerTask: variable.DefTiDBMaxRangesPerCopTask,
 	}
 	c.Assert(actual, DeepEquals, expect)
 }
@@ -436,16 +441,17 @@ func (s *testSuite) TestRequestBuilder3(c *C) {
 				EndKey:   kv.Key{0x74, 0x80, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xf, 0x5f, 0x72, 0x80, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x65},
 			},
 		},
-		Cacheable:      true,
-		KeepOrder:      false,
-		Desc:           false,
-		Concurrency:    variable.DefDistSQLScanConcurrency,
-		IsolationLevel: 0,
-		Priority:       0,
-		NotFillCache:   false,
-		SyncLog:        false,
-		Streaming:      false,
-		ReplicaRead:    kv.ReplicaReadLeader,
+		Cacheable:        true,
+		KeepOrder:        false,
+		Desc:             false,
+		Concurrency:      variable.DefDistSQLScanConcurrency,
+		IsolationLevel:   0,
+		Priority:         0,
+		NotFillCache:     false,
+		SyncLog:          false,
+		Streaming:        false,
+		ReplicaRead:      kv.ReplicaReadLeader,
+		MaxRangesPerTask: variable.DefTiDBMaxRangesPerCopTask,
 	}
 	c.Assert(actual, DeepEquals, expect)
 }
@@ -479,20 +485,21 @@ func (s *testSuite) TestRequestBuilder4(c *C) {
 		Build()
 	c.Assert(err, IsNil)
 	expect := &kv.Request{
-		Tp:             103,
-		StartTs:        0x0,
-		Data:           []uint8{0x18, 0x0, 0x20, 0x0, 0x40, 0x0, 0x5a, 0x0},
-		KeyRanges:      keyRanges,
-		Cacheable:      true,
-		KeepOrder:      false,
-		Desc:           false,
-		Concurrency:    variable.DefDistSQLScanConcurrency,
-		IsolationLevel: 0,
-		Priority:       0,
-		Streaming:      true,
-		NotFillCache:   false,
-		SyncLog:        false,
-		ReplicaRead:    kv.ReplicaReadLeader,
+		Tp:               103,
+		StartTs:          0x0,
+		Data:             []uint8{0x18, 0x0, 0x20, 0x0, 0x40, 0x0, 0x5a, 0x0},
+		KeyRanges:        keyRanges,
+		Cacheable:        true,
+		KeepOrder:        false,
+		Desc:             false,
+		Concurrency:      variable.DefDistSQLScanConcurrency,
+		IsolationLevel:   0,
+		Priority:         0,
+		Streaming:        true,
+		NotFillCache:     false,
+		SyncLog:          false,
+		ReplicaRead:      kv.ReplicaReadLeader,
+		MaxRangesPerTask: variable.DefTiDBMaxRangesPerCopTask,
 	}
 	c.Assert(actual, DeepEquals, expect)
 }
@@ -592,17 +599,18 @@ func (s *testSuite) TestRequestBuilder7(c *C) {
 		c.Assert(err, IsNil)
 
 		expect := &kv.Request{
-			Tp:             0,
-			StartTs:        0x0,
-			KeepOrder:      false,
-			Desc:           false,
-			Concurrency:    concurrency,
-			IsolationLevel: 0,
-			Priority:       0,
-			NotFillCache:   false,
-			SyncLog:        false,
-			Streaming:      false,
-			ReplicaRead:    replicaRead,
+			Tp:               0,
+			StartTs:          0x0,
+			KeepOrder:        false,
+			Desc:             false,
+			Concurrency:      concurrency,
+			IsolationLevel:   0,
+			Priority:         0,
+			NotFillCache:     false,
+			SyncLog:          false,
+			Streaming:        false,
+			ReplicaRead:      replicaRead,
+			MaxRangesPerTask: variable.DefTiDBMaxRangesPerCopTask,
 		}
 
 		c.Assert(actual, DeepEquals, expect)
@@ -616,14 +624,15 @@ func (s *testSuite) TestRequestBuilder8(c *C) {
 		Build()
 	c.Assert(err, IsNil)
 	expect := &kv.Request{
-		Tp:             0,
-		StartTs:        0x0,
-		Data:           []uint8(nil),
-		Concurrency:    variable.DefDistSQLScanConcurrency,
-		IsolationLevel: 0,
-		Priority:       0,
-		MemTracker:     (*memory.Tracker)(nil),
-		SchemaVar:      0,
+		Tp:               0,
+		StartTs:          0x0,
+		Data:             []uint8(nil),
+		Concurrency:      variable.DefDistSQLScanConcurrency,
+		IsolationLevel:   0,
+		Priority:         0,
+		MemTracker:       (*memory.Tracker)(nil),
+		SchemaVar:        0,
+		MaxRangesPerTask: variable.DefTiDBMaxRangesPerCopTask,
 	}
 	c.Assert(actual, DeepEquals, expect)
 }
@@ -718,3 +727,54 @@ func (s *testSuite) TestScanLimitConcurrency(c *C) {
 		c.Assert(actual.Concurrency, Equals, tt.concurrency)
 	}
 }
+
+func (s *testSuite) TestRetryBudget(c *C) {
+	vars := variable.NewSessionVars()
+	vars.StmtCtx.InSelectStmt = true
+	c.Assert(getRetryBudget(vars), Equals, time.Duration(0))
+
+	vars.MaxExecutionTime = 1000
+	budget := getRetryBudget(vars)
+	c.Assert(budget, Equals, time.Second)
+
+	vars.StartTime = time.Now().Add(-400 * time.Millisecond)
+	budget = getRetryBudget(vars)
+	c.Assert(budget <= 600*time.Millisecond, IsTrue)
+	c.Assert(budget > 0, IsTrue)
+
+	// The hint overrides the session variable.
+	vars.StmtCtx.HasMaxExecutionTime = true
+	vars.StmtCtx.MaxExecutionTime = 100
+	c.Assert(getRetryBudget(vars), Equals, time.Millisecond)
+
+	// max_execution_time doesn't apply to statements other than SELECT.
+	vars.StmtCtx.InSelectStmt = false
+	c.Assert(getRetryBudget(vars), Equals, time.Duration(0))
+}
+
+func (s *testSuite) TestClosestReplicaRead(c *C) {
+	defer config.RestoreFunc()()
+	vars := variable.NewSessionVars()
+	vars.SetReplicaRead(kv.ReplicaReadClosest)
+
+	// No zone label, read any replica.
+	actual, err := (&RequestBuilder{}).SetFromSessionVars(vars).Build()
+	c.Assert(err, IsNil)
+	c.Assert(actual.ReplicaRead, Equals, kv.ReplicaReadClosest)
+	c.Assert(actual.MatchStoreLabels, HasLen, 0)
+
+	config.UpdateGlobal(func(conf *config.Config) {
+		conf.Labels = map[string]string{placement.DCLabelKey: "bj"}
+	})
+	actual, err = (&RequestBuilder{}).SetFromSessionVars(vars).Build()
+	c.Assert(err, IsNil)
+	c.Assert(actual.MatchStoreLabels, HasLen, 1)
+	c.Assert(actual.MatchStoreLabels[0].Key, Equals, placement.DCLabelKey)
+	c.Assert(actual.MatchStoreLabels[0].Value, Equals, "bj")
+
+	// Other replica read types are not restricted by the zone label.
+	vars.SetReplicaRead(kv.ReplicaReadFollower)
+	actual, err = (&RequestBuilder{}).SetFromSessionVars(vars).Build()
+	c.Assert(err, IsNil)
+	c.Assert(actual.MatchStoreLabels, HasLen, 0)
+}
@@ -71,6 +71,18 @@ type SelectResult interface {
 	Close() error
 }
 
+// RowFilter returns whether each row of the chunk is selected.
+type RowFilter func(chk *chunk.Chunk) ([]bool, error)
+
+// FilterableSelectResult is the SelectResult which can filter the rows before copying them to the chunk of Next.
+type FilterableSelectResult interface {
+	SelectResult
+	// SetFilter makes Next only return the selected rows. For the responses encoded in chunk, the filter is evaluated
+	// on the chunks referencing the responses, so the columns not referenced by the filter are only copied for the
+	// selected rows. It must be called before Next.
+	SetFilter(filter RowFilter)
+}
+
 // NewSerialSelectResults create a SelectResult which will read each SelectResult serially.
 func NewSerialSelectResults(selectResults []SelectResult) SelectResult {
 	return &serialSelectResults{
@@ -112,6 +124,27 @@ func (ssr *serialSelectResults) Next(ctx context.Context, chk *chunk.Chunk) erro
 	return nil
 }
 
+// SetFilter implements the FilterableSelectResult interface, all the SelectResults must be FilterableSelectResult.
+func (ssr *serialSelectResults) SetFilter(filter RowFilter) {
+	for _, r := range ssr.selectResults {
+		r.(FilterableSelectResult).SetFilter(filter)
+	}
+}
+
+// CanFilter returns whether the SelectResult can filter the rows by FilterableSelectResult.SetFilter.
+func CanFilter(r SelectResult) bool {
+	if ssr, ok := r.(*serialSelectResults); ok {
+		for _, r := range ssr.selectResults {
+			if !CanFilter(r) {
+				return false
+			}
+		}
+		return true
+	}
+	_, ok := r.(FilterableSelectResult)
+	return ok
+}
+
 func (ssr *serialSelectResults) Close() (err error) {
 	for _, r := range ssr.selectResults {
 		if rerr := r.Close(); rerr != nil {
@@ -133,6 +166,9 @@ type selectResult struct {
 	selectRespSize   int64 // record the selectResp.Size() when it is initialized.
 	respChkIdx       int
 	respChunkDecoder *chunk.Decoder
+	filter           RowFilter
+	// filterChk keeps the rows decoded from the responses not encoded in chunk before filtering.
+	filterChk *chunk.Chunk
 
 	feedback     *statistics.QueryFeedback
 	partialCount int64 // number of partial results.
@@ -145,6 +181,7 @@ type selectResult struct {
 	rootPlanID int
 
 	storeType kv.StoreType
+	isMPP     bool
 
 	fetchDuration    time.Duration
 	durationReported bool
@@ -191,6 +228,7 @@ func (r *selectResult) fetchResp(ctx context.Context) error {
 		resultSubset, err := r.resp.Next(ctx)
 		duration := time.Since(startTime)
 		r.fetchDuration += duration
+		r.ctx.GetSessionVars().StmtCtx.MergeCopTime(duration, r.isMPP)
 		if err != nil {
 			return errors.Trace(err)
 		}
@@ -263,8 +301,14 @@ func (r *selectResult) Next(ctx context.Context, chk *chunk.Chunk) error {
 	// TODO(Shenghui Wu): add metrics
 	switch r.selectResp.GetEncodeType() {
 	case tipb.EncodeType_TypeDefault:
+		if r.filter != nil {
+			return r.readSelectedFromDefault(ctx, chk)
+		}
 		return r.readFromDefault(ctx, chk)
 	case tipb.EncodeType_TypeChunk:
+		if r.filter != nil {
+			return r.readSelectedFromChunk(ctx, chk)
+		}
 		return r.readFromChunk(ctx, chk)
 	}
 	return errors.Errorf("unsupported encode type:%v", r.encodeType)
@@ -337,6 +381,74 @@ func (r *selectResult) readFromChunk(ctx context.Context, chk *chunk.Chunk) erro
 	return nil
 }
 
+// SetFilter implements the FilterableSelectResult interface.
+func (r *selectResult) SetFilter(filter RowFilter) {
+	r.filter = filter
+}
+
+func (r *selectResult) readSelectedFromChunk(ctx context.Context, chk *chunk.Chunk) error {
+	if r.respChunkDecoder == nil {
+		r.respChunkDecoder = chunk.NewDecoder(
+			chunk.NewChunkWithCapacity(r.fieldTypes, 0),
+			r.fieldTypes,
+		)
+	}
+
+	for !chk.IsFull() {
+		if r.respChkIdx == len(r.selectResp.Chunks) {
+			err := r.fetchResp(ctx)
+			if err != nil || r.selectResp == nil {
+				return err
+			}
+		}
+
+		if r.respChunkDecoder.IsFinished() {
+			r.respChunkDecoder.Reset(r.selectResp.Chunks[r.respChkIdx].RowsData)
+		}
+		if err := r.respChunkDecoder.DecodeSelected(chk, r.filter); err != nil {
+			return err
+		}
+		if r.respChunkDecoder.IsFinished() {
+			r.respChkIdx++
+		}
+	}
+	return nil
+}
+
+// readSelectedFromDefault decodes at most the rows required by chk to filterChk, and appends the selected ones to
+// chk, so all of them can be appended.
+func (r *selectResult) readSelectedFromDefault(ctx context.Context, chk *chunk.Chunk) error {
+	if r.filterChk == nil {
+		r.filterChk = chunk.NewChunkWithCapacity(r.fieldTypes, chk.Capacity())
+	}
+	for !chk.IsFull() {
+		if r.respChkIdx == len(r.selectResp.Chunks) {
+			err := r.fetchResp(ctx)
+			if err != nil || r.selectResp == nil {
+				return err
+			}
+		}
+		r.filterChk.Reset()
+		r.filterChk.SetRequiredRows(chk.RequiredRows()-chk.NumRows(), chk.Capacity())
+		if err := r.readRowsData(r.filterChk); err != nil {
+			return err
+		}
+		if len(r.selectResp.Chunks[r.respChkIdx].RowsData) == 0 {
+			r.respChkIdx++
+		}
+		selected, err := r.filter(r.filterChk)
+		if err != nil {
+			return err
+		}
+		for i, ok := range selected {
+			if ok {
+				chk.AppendRow(r.filterChk.GetRow(i))
+			}
+		}
+	}
+	return nil
+}
+
 func (r *selectResult) updateCopRuntimeStats(ctx context.Context, copStats *copr.CopRuntimeStats, respTime time.Duration) {
 	callee := copStats.CalleeAddress
 	if r.rootPlanID <= 0 || r.ctx.GetSessionVars().StmtCtx.RuntimeStatsColl == nil || callee == "" {
@@ -450,23 +562,73 @@ type CopRuntimeStats interface {
 	GetCopRuntimeStats() *copr.CopRuntimeStats
 }
 
+// copTaskDetail is the execution detail of a cop task, it's used to find out the slow regions and stores.
+type copTaskDetail struct {
+	regionID    uint64
+	storeAddr   string
+	respTime    time.Duration
+	waitTime    time.Duration
+	processTime time.Duration
+	procKeys    int64
+	totalKeys   int64
+}
+
 type selectResultRuntimeStats struct {
 	copRespTime      []time.Duration
 	procKeys         []int64
+	copTasks         []copTaskDetail
 	backoffSleep     map[string]time.Duration
 	totalProcessTime time.Duration
 	totalWaitTime    time.Duration
 	rpcStat          tikv.RegionRequestRuntimeStats
 	CoprCacheHitNum  int64
+
+	// retryBudget is the total backoff time shared by the cop tasks, 0 means there is no budget.
+	retryBudget time.Duration
+	// retryBudgetRemaining is the least remaining retry budget reported by the cop tasks.
+	retryBudgetRemaining time.Duration
+	// servedReplicas counts the cop tasks by the kind of the replica serving them.
+	servedReplicas map[string]int
+}
+
+func (s *selectResultRuntimeStats) mergeServedReplicas(replicas map[string]int) {
+	if len(replicas) == 0 {
+		return
+	}
+	if s.servedReplicas == nil {
+		s.servedReplicas = make(map[string]int, len(replicas))
+	}
+	for k, v := range replicas {
+		s.servedReplicas[k] += v
+	}
+}
+
+func (s *selectResultRuntimeStats) mergeRetryBudget(total, remaining time.Duration) {
+	if total <= 0 {
+		return
+	}
+	if s.retryBudget == 0 || remaining < s.retryBudgetRemaining {
+		s.retryBudgetRemaining = remaining
+	}
+	s.retryBudget = total
 }
 
 func (s *selectResultRuntimeStats) mergeCopRuntimeStats(copStats *copr.CopRuntimeStats, respTime time.Duration) {
 	s.copRespTime = append(s.copRespTime, respTime)
+	task := copTaskDetail{
+		regionID:    copStats.RegionID,
+		storeAddr:   copStats.CalleeAddress,
+		respTime:    respTime,
+		waitTime:    copStats.TimeDetail.WaitTime,
+		processTime: copStats.TimeDetail.ProcessTime,
+	}
 	if copStats.ScanDetail != nil {
 		s.procKeys = append(s.procKeys, copStats.ScanDetail.ProcessedKeys)
+		task.procKeys, task.totalKeys = copStats.ScanDetail.ProcessedKeys, copStats.ScanDetail.TotalKeys
 	} else {
 		s.procKeys = append(s.procKeys, 0)
 	}
+	s.copTasks = append(s.copTasks, task)
 
 	for k, v := range copStats.BackoffSleep {
 		s.backoffSleep[k] += v
@@ -477,6 +639,10 @@ func (s *selectResultRuntimeStats) mergeCopRuntimeStats(copStats *copr.CopRuntim
 	if copStats.CoprCacheHit {
 		s.CoprCacheHitNum++
 	}
+	s.mergeRetryBudget(copStats.RetryBudget, copStats.RetryBudgetRemaining)
+	if copStats.ServedReplica != "" {
+		s.mergeServedReplicas(map[string]int{copStats.ServedReplica: 1})
+	}
 }
 
 func (s *selectResultRuntimeStats) Clone() execdetails.RuntimeStats {
@@ -488,6 +654,7 @@ func (s *selectResultRuntimeStats) Clone() execdetails.RuntimeStats {
 	}
 	newRs.copRespTime = append(newRs.copRespTime, s.copRespTime...)
 	newRs.procKeys = append(newRs.procKeys, s.procKeys...)
+	newRs.copTasks = append(newRs.copTasks, s.copTasks...)
 	for k, v := range s.backoffSleep {
 		newRs.backoffSleep[k] += v
 	}
@@ -496,6 +663,9 @@ func (s *selectResultRuntimeStats) Clone() execdetails.RuntimeStats {
 	for k, v := range s.rpcStat.Stats {
 		newRs.rpcStat.Stats[k] = v
 	}
+	newRs.retryBudget = s.retryBudget
+	newRs.retryBudgetRemaining = s.retryBudgetRemaining
+	newRs.mergeServedReplicas(s.servedReplicas)
 	return &newRs
 }
 
@@ -506,6 +676,7 @@ func (s *selectResultRuntimeStats) Merge(rs execdetails.RuntimeStats) {
 	}
 	s.copRespTime = append(s.copRespTime, other.copRespTime...)
 	s.procKeys = append(s.procKeys, other.procKeys...)
+	s.copTasks = append(s.copTasks, other.copTasks...)
 
 	for k, v := range other.backoffSleep {
 		s.backoffSleep[k] += v
@@ -514,6 +685,8 @@ func (s *selectResultRuntimeStats) Merge(rs execdetails.RuntimeStats) {
 	s.totalWaitTime += other.totalWaitTime
 	s.rpcStat.Merge(other.rpcStat)
 	s.CoprCacheHitNum += other.CoprCacheHitNum
+	s.mergeRetryBudget(other.retryBudget, other.retryBudgetRemaining)
+	s.mergeServedReplicas(other.servedReplicas)
 }
 
 func (s *selectResultRuntimeStats) String() string {
@@ -574,6 +747,9 @@ func (s *selectResultRuntimeStats) String() string {
 			buf.WriteString(", copr_cache: disabled")
 		}
 		buf.WriteString("}")
+		if len(s.copTasks) > 1 {
+			s.writeCopTaskDetails(buf)
+		}
 	}
 
 	rpcStatsStr := rpcStat.String()
@@ -594,9 +770,78 @@ func (s *selectResultRuntimeStats) String() string {
 		}
 		buf.WriteString("}")
 	}
+	// Only show the replicas when some tasks are not served by the leader.
+	if len(s.servedReplicas) > 1 || (len(s.servedReplicas) == 1 && s.servedReplicas["leader"] == 0) {
+		replicas := make([]string, 0, len(s.servedReplicas))
+		for k := range s.servedReplicas {
+			replicas = append(replicas, k)
+		}
+		sort.Strings(replicas)
+		buf.WriteString(", replica: {")
+		for i, k := range replicas {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(fmt.Sprintf("%s: %d", k, s.servedReplicas[k]))
+		}
+		buf.WriteString("}")
+	}
+	if s.retryBudget > 0 && s.retryBudgetRemaining <= 0 {
+		buf.WriteString(fmt.Sprintf(", retry_budget: {total: %s, remaining: %s}",
+			execdetails.FormatDuration(s.retryBudget), execdetails.FormatDuration(s.retryBudgetRemaining)))
+	}
 	return buf.String()
 }
 
+// writeCopTaskDetails writes the summaries of the cop tasks of each store and the slowest cop task,
+// the store summaries are only written if the tasks are sent to more than one store.
+func (s *selectResultRuntimeStats) writeCopTaskDetails(buf *bytes.Buffer) {
+	slowest := s.copTasks[0]
+	storeTasks := make(map[string][]copTaskDetail)
+	for _, task := range s.copTasks {
+		if task.respTime > slowest.respTime {
+			slowest = task
+		}
+		storeTasks[task.storeAddr] = append(storeTasks[task.storeAddr], task)
+	}
+	if len(storeTasks) > 1 {
+		stores := make([]string, 0, len(storeTasks))
+		for store := range storeTasks {
+			stores = append(stores, store)
+		}
+		sort.Strings(stores)
+		buf.WriteString(", stores: {")
+		for i, store := range stores {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			tasks := storeTasks[store]
+			respTimes := make([]time.Duration, 0, len(tasks))
+			waitTimes := make([]time.Duration, 0, len(tasks))
+			for _, task := range tasks {
+				respTimes = append(respTimes, task.respTime)
+				waitTimes = append(waitTimes, task.waitTime)
+			}
+			sort.Slice(respTimes, func(i, j int) bool { return respTimes[i] < respTimes[j] })
+			sort.Slice(waitTimes, func(i, j int) bool { return waitTimes[i] < waitTimes[j] })
+			size := len(tasks)
+			buf.WriteString(fmt.Sprintf("%s: {num: %v, max: %v, p95: %v, max_wait: %v, p95_wait: %v}", store, size,
+				execdetails.FormatDuration(respTimes[size-1]), execdetails.FormatDuration(respTimes[size*19/20]),
+				execdetails.FormatDuration(waitTimes[size-1]), execdetails.FormatDuration(waitTimes[size*19/20])))
+		}
+		buf.WriteString("}")
+	}
+	buf.WriteString(", slowest_task: {")
+	if slowest.regionID > 0 {
+		buf.WriteString("region: ")
+		buf.WriteString(strconv.FormatUint(slowest.regionID, 10))
+		buf.WriteString(", ")
+	}
+	buf.WriteString(fmt.Sprintf("store: %s, time: %v, wait: %v, proc: %v, proc_keys: %v, total_keys: %v}", slowest.storeAddr,
+		execdetails.FormatDuration(slowest.respTime), execdetails.FormatDuration(slowest.waitTime),
+		execdetails.FormatDuration(slowest.processTime), slowest.procKeys, slowest.totalKeys))
+}
+
 // Tp implements the RuntimeStats interface.
 func (s *selectResultRuntimeStats) Tp() int {
 	return execdetails.TpSelectResultRuntimeStats
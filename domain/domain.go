@@ -54,6 +54,7 @@ import (
 	"github.com/pingcap/tidb/util/expensivequery"
 	"github.com/pingcap/tidb/util/logutil"
 	"github.com/pingcap/tidb/util/sqlexec"
+	"github.com/pingcap/tidb/util/stmtsummary"
 	"github.com/tikv/client-go/v2/tikv"
 	"go.etcd.io/etcd/clientv3"
 	"go.etcd.io/etcd/clientv3/concurrency"
@@ -89,6 +90,12 @@ type Domain struct {
 	serverID             uint64
 	serverIDSession      *concurrency.Session
 	isLostConnectionToPD sync2.AtomicInt32 // !0: true, 0: false.
+
+	// ownerManagers are the owner managers campaigned by the domain except the DDL owner manager.
+	ownerManagers struct {
+		sync.Mutex
+		managers []owner.Manager
+	}
 }
 
 // loadInfoSchema loads infoschema at startTS.
@@ -120,9 +127,9 @@ func (do *Domain) loadInfoSchema(startTS uint64) (infoschema.InfoSchema, bool, i
 	// And it is only used to diff upgrading the current latest infoschema, if:
 	// 1. Not first time bootstrap loading, which needs a full load.
 	// 2. It is newer than the current one, so it will be "the current one" after this function call.
-	// 3. There are less 100 diffs.
+	// 3. There are less than maxSchemaDiffsToLoad diffs.
 	startTime := time.Now()
-	if currentSchemaVersion != 0 && neededSchemaVersion > currentSchemaVersion && neededSchemaVersion-currentSchemaVersion < 100 {
+	if currentSchemaVersion != 0 && neededSchemaVersion > currentSchemaVersion && neededSchemaVersion-currentSchemaVersion < maxSchemaDiffsToLoad {
 		is, relatedChanges, err := do.tryLoadSchemaDiffs(m, currentSchemaVersion, neededSchemaVersion)
 		if err == nil {
 			do.infoCache.Insert(is, startTS)
@@ -234,6 +241,11 @@ func (do *Domain) fetchSchemasWithTables(schemas []*model.DBInfo, m *meta.Meta,
 	done <- nil
 }
 
+// maxSchemaDiffsToLoad is the max number of the schema diffs loaded to upgrade the infoschema. Each diff is a point get
+// and the diffs covered by the later ones aren't applied, so loading the diffs is still much cheaper than the full load
+// when there are many tables.
+const maxSchemaDiffsToLoad = 1000
+
 // tryLoadSchemaDiffs tries to only load latest schema changes.
 // Return true if the schema is loaded successfully.
 // Return false if the schema can not be loaded by schema diff, then we need to do full load.
@@ -255,17 +267,31 @@ func (do *Domain) tryLoadSchemaDiffs(m *meta.Meta, usedVersion, newVersion int64
 	builder := infoschema.NewBuilder(do.Store()).InitWithOldInfoSchema(do.infoCache.GetLatest())
 	phyTblIDs := make([]int64, 0, len(diffs))
 	actions := make([]uint64, 0, len(diffs))
-	for _, diff := range diffs {
+	// The changes of the covered diffs are related to the tables affected by the diffs covering them.
+	covering := coveringSchemaDiffs(diffs)
+	coveredTypes := make(map[int][]model.ActionType)
+	for i, diff := range diffs {
+		if covering[i] >= 0 && !canSkipSchemaCheckerDDL(diff.Type) {
+			coveredTypes[covering[i]] = append(coveredTypes[covering[i]], diff.Type)
+		}
+	}
+	for i, diff := range diffs {
+		if covering[i] >= 0 {
+			continue
+		}
 		IDs, err := builder.ApplyDiff(m, diff)
 		if err != nil {
 			return nil, nil, err
 		}
-		if canSkipSchemaCheckerDDL(diff.Type) {
-			continue
+		tps := coveredTypes[i]
+		if !canSkipSchemaCheckerDDL(diff.Type) {
+			tps = append(tps, diff.Type)
 		}
-		phyTblIDs = append(phyTblIDs, IDs...)
-		for i := 0; i < len(IDs); i++ {
-			actions = append(actions, uint64(1<<diff.Type))
+		for _, tp := range tps {
+			phyTblIDs = append(phyTblIDs, IDs...)
+			for range IDs {
+				actions = append(actions, uint64(1<<tp))
+			}
 		}
 	}
 	is := builder.Build()
@@ -283,6 +309,70 @@ func canSkipSchemaCheckerDDL(tp model.ActionType) bool {
 	return false
 }
 
+// coveringSchemaDiffs returns the index of the diff covering each diff, or -1 if the diff isn't covered and must be
+// applied. Applying a diff always loads the latest table info, so a diff which alters a table in place is covered by
+// the next diff on the same table if the next one alters it in place too or drops it. The covered diffs are skipped,
+// so a table altered by many diffs is loaded only once, and a table dropped later isn't loaded at all.
+func coveringSchemaDiffs(diffs []*model.SchemaDiff) []int {
+	covering := make([]int, len(diffs))
+	// next is the index of the next diff on each table.
+	next := make(map[int64]int)
+	for i := len(diffs) - 1; i >= 0; i-- {
+		diff := diffs[i]
+		covering[i] = -1
+		if j, ok := next[diff.TableID]; ok && isInPlaceTableDiff(diff.Type) && coversTableDiff(diffs[j], diff) {
+			covering[i] = j
+			if covering[j] >= 0 {
+				covering[i] = covering[j]
+			}
+		}
+		switch diff.Type {
+		case model.ActionCreateSchema, model.ActionDropSchema, model.ActionModifySchemaCharsetAndCollate:
+			// The tables of the schema are unknown, so the diffs before it are never covered.
+			next = make(map[int64]int)
+			continue
+		}
+		next[diff.TableID] = i
+		if diff.OldTableID != 0 {
+			next[diff.OldTableID] = i
+		}
+		for _, opt := range diff.AffectedOpts {
+			next[opt.TableID] = i
+			next[opt.OldTableID] = i
+		}
+	}
+	return covering
+}
+
+// isInPlaceTableDiff returns whether the diff only changes the table info, but keeps the IDs of the table, the
+// partitions and the allocators.
+func isInPlaceTableDiff(tp model.ActionType) bool {
+	switch tp {
+	case model.ActionAddColumn, model.ActionAddColumns, model.ActionDropColumn, model.ActionDropColumns,
+		model.ActionModifyColumn, model.ActionSetDefaultValue, model.ActionAddIndex, model.ActionDropIndex,
+		model.ActionRenameIndex, model.ActionAlterIndexVisibility, model.ActionAddPrimaryKey, model.ActionDropPrimaryKey,
+		model.ActionAddForeignKey, model.ActionDropForeignKey, model.ActionModifyTableComment,
+		model.ActionModifyTableCharsetAndCollate, model.ActionShardRowID, model.ActionSetTiFlashReplica,
+		model.ActionUpdateTiFlashReplicaStatus:
+		return true
+	}
+	return false
+}
+
+// coversTableDiff returns whether the diff covers the previous in place diff on the same table.
+func coversTableDiff(diff, prev *model.SchemaDiff) bool {
+	if diff.SchemaID != prev.SchemaID || len(diff.AffectedOpts) > 0 {
+		return false
+	}
+	switch diff.Type {
+	case model.ActionDropTable:
+		return diff.TableID == prev.TableID
+	case model.ActionTruncateTable:
+		return diff.OldTableID == prev.TableID
+	}
+	return isInPlaceTableDiff(diff.Type) && diff.TableID == prev.TableID
+}
+
 // InfoSchema gets the latest information schema from domain.
 func (do *Domain) InfoSchema() infoschema.InfoSchema {
 	return do.infoCache.GetLatest()
@@ -610,6 +700,9 @@ func (do *Domain) Close() {
 		return
 	}
 	startTime := time.Now()
+	// The owners must be handed off before the etcd client is closed, otherwise their keys are kept until
+	// the leases expire.
+	do.HandoffOwners()
 	if do.ddl != nil {
 		terror.Log(do.ddl.Stop())
 	}
@@ -1103,6 +1196,103 @@ func (do *Domain) TelemetryRotateSubWindowLoop(ctx sessionctx.Context) {
 	}()
 }
 
+const (
+	// stmtSummaryPersistInterval is the interval to persist the statement summaries of the expired intervals.
+	stmtSummaryPersistInterval = time.Minute
+	// stmtSummaryCleanInterval is the interval to delete the persisted statement summaries out of the retention.
+	stmtSummaryCleanInterval = time.Hour
+)
+
+// StmtSummaryPersistLoop creates a goroutine that persists the statement summaries of the expired intervals to
+// mysql.statements_summary_history regularly, and deletes the ones out of the retention. It should be called only
+// once in BootstrapSession.
+func (do *Domain) StmtSummaryPersistLoop(ctx sessionctx.Context) {
+	ctx.GetSessionVars().InRestrictedSQL = true
+	do.wg.Add(1)
+	go func() {
+		defer func() {
+			do.wg.Done()
+			logutil.BgLogger().Info("StmtSummaryPersistLoop exited.")
+			util.Recover(metrics.LabelDomain, "StmtSummaryPersistLoop", nil, false)
+		}()
+		// The summaries are in memory, so there are none of the intervals ended before starting.
+		persistedTime := time.Now().Unix()
+		var cleanTime time.Time
+		for {
+			select {
+			case <-do.exit:
+				return
+			case <-time.After(stmtSummaryPersistInterval):
+			}
+			now := time.Now().Unix()
+			summaries := stmtsummary.StmtSummaryByDigestMap.ToPersistentSummaries(persistedTime, now)
+			if err := persistStmtSummaries(ctx, summaries); err != nil {
+				// The summaries are persisted next time.
+				logutil.BgLogger().Warn("persist statement summaries failed", zap.Error(err))
+			} else {
+				persistedTime = now
+			}
+			if time.Since(cleanTime) >= stmtSummaryCleanInterval {
+				cleanTime = time.Now()
+				retention := config.GetGlobalConfig().StmtSummary.PersistentRetentionDays
+				_, err := ctx.(sqlexec.SQLExecutor).ExecuteInternal(context.Background(),
+					"delete from mysql.statements_summary_history where summary_end_time < %?", cleanTime.AddDate(0, 0, -retention))
+				if err != nil {
+					logutil.BgLogger().Warn("delete persisted statement summaries failed", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+func persistStmtSummaries(ctx sessionctx.Context, summaries []*stmtsummary.PersistentSummary) (err error) {
+	if len(summaries) == 0 {
+		return nil
+	}
+	serverInfo, err := infosync.GetServerInfo()
+	if err != nil {
+		return err
+	}
+	instance := serverInfo.IP + ":" + strconv.FormatUint(uint64(serverInfo.StatusPort), 10)
+
+	exec := ctx.(sqlexec.SQLExecutor)
+	internalCtx := context.Background()
+	if _, err = exec.ExecuteInternal(internalCtx, "begin"); err != nil {
+		return err
+	}
+	defer func() {
+		sql := "commit"
+		if err != nil {
+			sql = "rollback"
+		}
+		_, err1 := exec.ExecuteInternal(internalCtx, sql)
+		if err == nil {
+			err = err1
+		}
+	}()
+	const sql = `insert into mysql.statements_summary_history values (%?, %?, %?, %?, %?, %?, %?, %?, %?, %?, %?, %?, %?,
+		%?, %?, %?, %?, %?, %?, %?, %?, %?, %?, %?, %?, %?, %?, %?, %?)`
+	for _, s := range summaries {
+		_, err = exec.ExecuteInternal(internalCtx, sql, instance, s.BeginTime, s.EndTime, s.StmtType, nullIfEmpty(s.SchemaName),
+			s.Digest, s.DigestText, nullIfEmpty(s.TableNames), nullIfEmpty(s.PlanDigest), nullIfEmpty(s.SampleUser),
+			s.ExecCount, s.SumErrors, s.SumWarnings, int64(s.SumLatency), int64(s.MaxLatency), int64(s.MinLatency),
+			int64(s.P50Latency), int64(s.P95Latency), int64(s.P99Latency), int64(s.SumCopTime), int64(s.MaxCopTime),
+			int64(s.SumMPPTime), int64(s.MaxMPPTime), s.MaxMem, s.MaxDisk, s.SumAffectedRows, s.FirstSeen, s.LastSeen,
+			s.QuerySampleText)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
 // StatsHandle returns the statistic handle.
 func (do *Domain) StatsHandle() *handle.Handle {
 	return (*handle.Handle)(atomic.LoadPointer(&do.statsHandle))
@@ -1182,9 +1372,35 @@ func (do *Domain) newOwnerManager(prompt, ownerKey string) owner.Manager {
 	if err != nil {
 		logutil.BgLogger().Warn("campaign owner failed", zap.Error(err))
 	}
+	do.ownerManagers.Lock()
+	do.ownerManagers.managers = append(do.ownerManagers.managers, statsOwner)
+	do.ownerManagers.Unlock()
 	return statsOwner
 }
 
+// HandoffOwners gives up all the owners of the domain, including the DDL owner, the stats owner and the
+// bind-info owner. The owner keys are deleted from etcd at once, so other TiDB servers can take them over
+// without waiting for the leases to expire. It should be called when the server is shutting down.
+func (do *Domain) HandoffOwners() {
+	startTime := time.Now()
+	do.ownerManagers.Lock()
+	managers := append([]owner.Manager(nil), do.ownerManagers.managers...)
+	do.ownerManagers.Unlock()
+	if do.ddl != nil {
+		managers = append(managers, do.ddl.OwnerManager())
+	}
+	var wg sync.WaitGroup
+	for _, m := range managers {
+		wg.Add(1)
+		go func(m owner.Manager) {
+			defer wg.Done()
+			m.Cancel()
+		}(m)
+	}
+	wg.Wait()
+	logutil.BgLogger().Info("owners handed off", zap.Int("owners", len(managers)), zap.Duration("take time", time.Since(startTime)))
+}
+
 func (do *Domain) loadStatsWorker() {
 	defer util.Recover(metrics.LabelDomain, "loadStatsWorker", nil, false)
 	lease := do.statsLease
@@ -1245,6 +1461,11 @@ func (do *Domain) syncIndexUsageWorker(owner owner.Manager) {
 			if err := handle.DumpIndexUsageToKV(); err != nil {
 				logutil.BgLogger().Debug("dump index usage failed", zap.Error(err))
 			}
+			if variable.PersistTableTraffic.Load() {
+				if err := handle.DumpTableTrafficToKV(); err != nil {
+					logutil.BgLogger().Debug("dump table traffic failed", zap.Error(err))
+				}
+			}
 		case <-gcStatsTicker.C:
 			if !owner.IsOwner() {
 				continue
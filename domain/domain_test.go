@@ -35,6 +35,7 @@ import (
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/metrics"
+	"github.com/pingcap/tidb/owner"
 	"github.com/pingcap/tidb/session/txninfo"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/store/mockstore"
@@ -44,6 +45,7 @@ import (
 	dto "github.com/prometheus/client_model/go"
 	"github.com/tikv/client-go/v2/oracle"
 	"github.com/tikv/client-go/v2/tikv"
+	"go.etcd.io/etcd/clientv3/concurrency"
 	"go.etcd.io/etcd/integration"
 )
 
@@ -232,6 +234,38 @@ func TestInfo(t *testing.T) {
 		t.Fatalf("dom.refreshServerIDTTL err %v", err)
 	}
 
+	// Test for HandoffOwners.
+	statsOwner := dom.newOwnerManager("test", "/tidb/test/owner")
+	session, err := concurrency.NewSession(cli)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+	// waitOwner waits until the manager is elected as the owner of the key.
+	waitOwner := func(m owner.Manager, key string) {
+		ctx, cancel := context.WithTimeout(goCtx, 10*time.Second)
+		defer cancel()
+		for resp := range concurrency.NewElection(session, key).Observe(ctx) {
+			if len(resp.Kvs) > 0 && string(resp.Kvs[0].Value) == m.ID() {
+				return
+			}
+		}
+		t.Fatalf("failed to campaign the owner of %s", key)
+	}
+	waitOwner(dom.ddl.OwnerManager(), ddl.DDLOwnerKey)
+	waitOwner(statsOwner, "/tidb/test/owner")
+	dom.HandoffOwners()
+	if dom.ddl.OwnerManager().IsOwner() || statsOwner.IsOwner() {
+		t.Fatal("the owners are not handed off")
+	}
+	// The owner keys are deleted at once.
+	if _, err = dom.ddl.OwnerManager().GetOwnerID(goCtx); err != concurrency.ErrElectionNoLeader {
+		t.Fatalf("the DDL owner key is not deleted, err %v", err)
+	}
+	if _, err = statsOwner.GetOwnerID(goCtx); err != concurrency.ErrElectionNoLeader {
+		t.Fatalf("the stats owner key is not deleted, err %v", err)
+	}
+
 	err = failpoint.Disable("github.com/pingcap/tidb/domain/infosync/FailPlacement")
 	if err != nil {
 		t.Fatal(err)
@@ -476,6 +510,31 @@ type testResource struct {
 
 func (tr *testResource) Close() { tr.status = 1 }
 
+func (*testSuite) TestCoveringSchemaDiffs(c *C) {
+	diffs := []*model.SchemaDiff{
+		{Version: 1, Type: model.ActionAddColumn, SchemaID: 1, TableID: 10},
+		{Version: 2, Type: model.ActionAddIndex, SchemaID: 1, TableID: 11},
+		{Version: 3, Type: model.ActionAddIndex, SchemaID: 1, TableID: 10},
+		{Version: 4, Type: model.ActionModifyColumn, SchemaID: 1, TableID: 11},
+		{Version: 5, Type: model.ActionRebaseAutoID, SchemaID: 1, TableID: 10},
+		{Version: 6, Type: model.ActionAddIndex, SchemaID: 1, TableID: 10},
+		{Version: 7, Type: model.ActionAddIndex, SchemaID: 1, TableID: 12},
+		{Version: 8, Type: model.ActionCreateSchema, SchemaID: 2},
+		{Version: 9, Type: model.ActionAddColumn, SchemaID: 1, TableID: 12},
+		{Version: 10, Type: model.ActionAddColumn, SchemaID: 1, TableID: 13},
+		{Version: 11, Type: model.ActionDropIndex, SchemaID: 1, TableID: 13},
+		{Version: 12, Type: model.ActionTruncateTable, SchemaID: 1, TableID: 14, OldTableID: 13},
+		{Version: 13, Type: model.ActionAddIndex, SchemaID: 1, TableID: 14},
+		{Version: 14, Type: model.ActionDropTable, SchemaID: 1, TableID: 14},
+		{Version: 15, Type: model.ActionAddColumn, SchemaID: 1, TableID: 15},
+		{Version: 16, Type: model.ActionRenameTable, SchemaID: 2, OldSchemaID: 1, TableID: 15},
+	}
+	covering := coveringSchemaDiffs(diffs)
+	// The diff of version 5 rebases the allocators, and the diff of version 8 creates a schema, so the diffs on the
+	// same tables before them aren't covered. The diff of version 16 moves the table to another schema.
+	c.Assert(covering, DeepEquals, []int{2, 3, -1, -1, -1, -1, -1, -1, -1, 11, 11, -1, 13, -1, -1, -1})
+}
+
 func (*testSuite) TestSessionPool(c *C) {
 	f := func() (pools.Resource, error) { return &testResource{}, nil }
 	pool := newSessionPool(1, f)
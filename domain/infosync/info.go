@@ -24,6 +24,7 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -60,6 +61,8 @@ const (
 	ServerMinStartTSPath = "/tidb/server/minstartts"
 	// TiFlashTableSyncProgressPath store the tiflash table replica sync progress.
 	TiFlashTableSyncProgressPath = "/tiflash/table/sync"
+	// TiFlashTableSyncControlPath store the controls of the tiflash table replica sync.
+	TiFlashTableSyncControlPath = "/tiflash/table/sync_control"
 	// keyOpDefaultRetryCnt is the default retry count for etcd store.
 	keyOpDefaultRetryCnt = 5
 	// keyOpDefaultTimeout is the default time out for etcd store.
@@ -95,6 +98,11 @@ type InfoSyncer struct {
 	topologySession *concurrency.Session
 	prometheusAddr  string
 	modifyTime      time.Time
+
+	// syncControls keeps the controls of the tiflash table replica sync when there is no etcd, in which case
+	// there is only one TiDB instance.
+	syncControlsMu sync.Mutex
+	syncControls   map[int64]TiFlashSyncControl
 }
 
 // ServerInfo is server static information.
@@ -305,6 +313,153 @@ func GetTiFlashTableSyncProgress(ctx context.Context) (map[int64]float64, error)
 	return progressMap, nil
 }
 
+// TiFlashSyncControl is the control of the tiflash replica of a table or partition. TiFlash drives the sync of the
+// replica, so the control only affects how TiDB uses the replica.
+type TiFlashSyncControl struct {
+	// Paused indicates TiDB doesn't read the replica, and ignores its progress reported by TiFlash until it's
+	// resumed. TiFlash keeps syncing the replica.
+	Paused bool `json:"paused"`
+}
+
+// UpdateTiFlashTableSyncControl is used to update the control of the tiflash table replica sync by the update
+// function, the zero control is deleted. The control is read and written in an etcd transaction, so the concurrent
+// updates of the same table are not lost.
+func UpdateTiFlashTableSyncControl(ctx context.Context, tid int64, update func(*TiFlashSyncControl)) (TiFlashSyncControl, error) {
+	is, err := getGlobalInfoSyncer()
+	if err != nil {
+		return TiFlashSyncControl{}, err
+	}
+	if is.etcdCli == nil {
+		is.syncControlsMu.Lock()
+		defer is.syncControlsMu.Unlock()
+		if is.syncControls == nil {
+			is.syncControls = make(map[int64]TiFlashSyncControl)
+		}
+		control := is.syncControls[tid]
+		update(&control)
+		if control == (TiFlashSyncControl{}) {
+			delete(is.syncControls, tid)
+		} else {
+			is.syncControls[tid] = control
+		}
+		return control, nil
+	}
+	key := fmt.Sprintf("%s/%v", TiFlashTableSyncControlPath, tid)
+	for i := 0; i < keyOpDefaultRetryCnt; i++ {
+		childCtx, cancel := context.WithTimeout(ctx, keyOpDefaultTimeout)
+		control, rev, err := getTiFlashTableSyncControl(childCtx, is.etcdCli, key)
+		cancel()
+		if err != nil {
+			logutil.BgLogger().Info("get tiflash table replica sync control failed, continue checking.", zap.Error(err))
+			continue
+		}
+		update(&control)
+		// The key is updated only if no one has changed it since it was read.
+		cmp := clientv3.Compare(clientv3.ModRevision(key), "=", rev)
+		op := clientv3.OpDelete(key)
+		if control != (TiFlashSyncControl{}) {
+			value, err := json.Marshal(control)
+			if err != nil {
+				return TiFlashSyncControl{}, errors.Trace(err)
+			}
+			op = clientv3.OpPut(key, string(value))
+		}
+		childCtx, cancel = context.WithTimeout(ctx, keyOpDefaultTimeout)
+		resp, err := is.etcdCli.Txn(childCtx).If(cmp).Then(op).Commit()
+		cancel()
+		if err != nil {
+			logutil.BgLogger().Info("update tiflash table replica sync control failed, continue checking.", zap.Error(err))
+			continue
+		}
+		if resp.Succeeded {
+			return control, nil
+		}
+	}
+	return TiFlashSyncControl{}, errors.Errorf("update tiflash table replica sync control of %d failed", tid)
+}
+
+// GetTiFlashTableSyncControl uses to get the control of the tiflash table replica sync of a table or partition.
+func GetTiFlashTableSyncControl(ctx context.Context, tid int64) (TiFlashSyncControl, error) {
+	is, err := getGlobalInfoSyncer()
+	if err != nil {
+		return TiFlashSyncControl{}, err
+	}
+	if is.etcdCli == nil {
+		is.syncControlsMu.Lock()
+		defer is.syncControlsMu.Unlock()
+		return is.syncControls[tid], nil
+	}
+	key := fmt.Sprintf("%s/%v", TiFlashTableSyncControlPath, tid)
+	for i := 0; i < keyOpDefaultRetryCnt; i++ {
+		var control TiFlashSyncControl
+		control, _, err = getTiFlashTableSyncControl(ctx, is.etcdCli, key)
+		if err == nil {
+			return control, nil
+		}
+		logutil.BgLogger().Info("get tiflash table replica sync control failed, continue checking.", zap.Error(err))
+	}
+	return TiFlashSyncControl{}, errors.Trace(err)
+}
+
+// getTiFlashTableSyncControl gets the control and its mod revision from etcd, the revision is 0 if there is no control.
+func getTiFlashTableSyncControl(ctx context.Context, etcdCli *clientv3.Client, key string) (TiFlashSyncControl, int64, error) {
+	var control TiFlashSyncControl
+	resp, err := etcdCli.Get(ctx, key)
+	if err != nil {
+		return control, 0, errors.Trace(err)
+	}
+	if len(resp.Kvs) == 0 {
+		return control, 0, nil
+	}
+	if err = json.Unmarshal(resp.Kvs[0].Value, &control); err != nil {
+		return control, 0, errors.Trace(err)
+	}
+	return control, resp.Kvs[0].ModRevision, nil
+}
+
+// GetTiFlashTableSyncControls uses to get all the controls of the tiflash table replica sync.
+func GetTiFlashTableSyncControls(ctx context.Context) (map[int64]TiFlashSyncControl, error) {
+	is, err := getGlobalInfoSyncer()
+	if err != nil {
+		return nil, err
+	}
+	controls := make(map[int64]TiFlashSyncControl)
+	if is.etcdCli == nil {
+		is.syncControlsMu.Lock()
+		defer is.syncControlsMu.Unlock()
+		for tid, control := range is.syncControls {
+			controls[tid] = control
+		}
+		return controls, nil
+	}
+	var resp *clientv3.GetResponse
+	for i := 0; i < keyOpDefaultRetryCnt; i++ {
+		resp, err = is.etcdCli.Get(ctx, TiFlashTableSyncControlPath+"/", clientv3.WithPrefix())
+		if err == nil {
+			break
+		}
+		logutil.BgLogger().Info("get tiflash table replica sync control failed, continue checking.", zap.Error(err))
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, kv := range resp.Kvs {
+		tid, err := strconv.ParseInt(string(kv.Key[len(TiFlashTableSyncControlPath)+1:]), 10, 64)
+		if err != nil {
+			logutil.BgLogger().Info("invalid tiflash table replica sync control key.", zap.String("key", string(kv.Key)))
+			continue
+		}
+		var control TiFlashSyncControl
+		if err = json.Unmarshal(kv.Value, &control); err != nil {
+			logutil.BgLogger().Info("invalid tiflash table replica sync control value.",
+				zap.String("key", string(kv.Key)), zap.String("value", string(kv.Value)))
+			continue
+		}
+		controls[tid] = control
+	}
+	return controls, nil
+}
+
 func doRequest(ctx context.Context, addrs []string, route, method string, body io.Reader) ([]byte, error) {
 	var err error
 	var req *http.Request
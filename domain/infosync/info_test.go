@@ -29,6 +29,7 @@ import (
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/tidb/ddl/util"
 	"github.com/pingcap/tidb/owner"
+	"go.etcd.io/etcd/clientv3"
 	"go.etcd.io/etcd/integration"
 )
 
@@ -189,3 +190,74 @@ func TestTopology(t *testing.T) {
 		t.Fatal("ttl non-exists")
 	}
 }
+
+func TestTiFlashTableSyncControl(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("integration.NewClusterV3 will create file contains a colon which is not allowed on Windows")
+	}
+	ctx := context.Background()
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	setControl := func(tid int64, control TiFlashSyncControl) {
+		_, err := UpdateTiFlashTableSyncControl(ctx, tid, func(c *TiFlashSyncControl) { *c = control })
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, cli := range []*clientv3.Client{clus.RandClient(), nil} {
+		_, err := GlobalInfoSyncerInit(ctx, "test", func() uint64 { return 1 }, cli, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paused := TiFlashSyncControl{Paused: true}
+		setControl(1, paused)
+		setControl(2, paused)
+		controls, err := GetTiFlashTableSyncControls(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(controls, map[int64]TiFlashSyncControl{1: paused, 2: paused}) {
+			t.Fatalf("unexpected controls %v", controls)
+		}
+		control, err := GetTiFlashTableSyncControl(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if control != paused {
+			t.Fatalf("unexpected control %v", control)
+		}
+
+		// The concurrent updates are not lost, the odd number of flips resumes the replica.
+		const concurrency = 5
+		errCh := make(chan error, concurrency)
+		for i := 0; i < concurrency; i++ {
+			go func() {
+				_, err := UpdateTiFlashTableSyncControl(ctx, 2, func(c *TiFlashSyncControl) { c.Paused = !c.Paused })
+				errCh <- err
+			}()
+		}
+		for i := 0; i < concurrency; i++ {
+			if err = <-errCh; err != nil {
+				t.Fatal(err)
+			}
+		}
+		control, err = GetTiFlashTableSyncControl(ctx, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if control.Paused {
+			t.Fatalf("unexpected control %v", control)
+		}
+
+		// The zero control is deleted.
+		setControl(1, TiFlashSyncControl{})
+		controls, err = GetTiFlashTableSyncControls(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(controls) != 0 {
+			t.Fatalf("unexpected controls %v", controls)
+		}
+	}
+}
@@ -17,11 +17,13 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/util/logutil"
+	"github.com/pingcap/tidb/util/plancapture"
 	"github.com/pingcap/tidb/util/sqlexec"
 	"github.com/pingcap/tidb/util/stmtsummary"
 	"go.uber.org/zap"
@@ -167,6 +169,8 @@ func checkEnableServerGlobalVar(name, sVal string) {
 		err = stmtsummary.StmtSummaryByDigestMap.SetMaxSQLLength(sVal, false)
 	case variable.TiDBCapturePlanBaseline:
 		variable.CapturePlanBaseline.Set(sVal, false)
+	case variable.TiDBPlanCaptureDigests:
+		plancapture.SetPendingDigests(strings.Split(sVal, ","))
 	case variable.TiDBEnableTopSQL:
 		variable.TopSQLVariable.Enable.Store(variable.TiDBOptOn(sVal))
 	case variable.TiDBTopSQLPrecisionSeconds:
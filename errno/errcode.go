@@ -1006,6 +1006,7 @@ const (
 	ErrDataInConsistentExtraIndex          = 8133
 	ErrDataInConsistentMisMatchIndex       = 8134
 	ErrAsOf                                = 8135
+	ErrInvalidSecurityPolicy               = 8136
 
 	// Error codes used by TiDB ddl package
 	ErrUnsupportedDDLOperation            = 8200
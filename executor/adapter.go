@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"math"
 	"runtime/trace"
+	"sort"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -37,6 +38,7 @@ import (
 	"github.com/pingcap/tidb/domain"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/infoschema/perfschema"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/metrics"
 	"github.com/pingcap/tidb/planner"
@@ -45,11 +47,13 @@ import (
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/audit"
 	"github.com/pingcap/tidb/util/chunk"
 	"github.com/pingcap/tidb/util/execdetails"
 	"github.com/pingcap/tidb/util/hint"
 	"github.com/pingcap/tidb/util/logutil"
 	"github.com/pingcap/tidb/util/memory"
+	"github.com/pingcap/tidb/util/plancapture"
 	"github.com/pingcap/tidb/util/plancodec"
 	"github.com/pingcap/tidb/util/sqlexec"
 	"github.com/pingcap/tidb/util/stmtsummary"
@@ -373,12 +377,15 @@ func (a *ExecStmt) Exec(ctx context.Context) (_ sqlexec.RecordSet, err error) {
 	if sctx.GetSessionVars().StmtCtx.HasMemQuotaHint {
 		sctx.GetSessionVars().StmtCtx.MemTracker.SetBytesLimit(sctx.GetSessionVars().StmtCtx.MemQuotaQuery)
 	}
+	applyResourceGroup(sctx)
 
 	e, err := a.buildExecutor()
 	if err != nil {
 		return nil, err
 	}
 	// ExecuteExec will rewrite `a.Plan`, so set plan label should be executed after `a.buildExecutor`.
+	// It also sets the plan digest of the resource group tags of the coprocessor and MPP requests, the plan digest is
+	// not generated for the tags when Top SQL is disabled.
 	ctx = a.setPlanLabelForTopSQL(ctx)
 
 	if err = e.Open(ctx); err != nil {
@@ -622,21 +629,25 @@ func (a *ExecStmt) handlePessimisticDML(ctx context.Context, e Executor) error {
 			return err1
 		}
 		keys = txnCtx.CollectUnchangedRowKeys(keys)
-		if len(keys) == 0 {
-			return nil
-		}
-		keys = filterTemporaryTableKeys(sctx.GetSessionVars(), keys)
 		seVars := sctx.GetSessionVars()
-		lockCtx := newLockCtx(seVars, seVars.LockWaitTimeout)
-		var lockKeyStats *util.LockKeysDetails
-		ctx = context.WithValue(ctx, util.LockKeysDetailCtxKey, &lockKeyStats)
 		startLocking := time.Now()
-		err = txn.LockKeys(ctx, lockCtx, keys...)
-		if lockKeyStats != nil {
-			seVars.StmtCtx.MergeLockKeysExecDetails(lockKeyStats)
-		}
+		// The error of the locks acquired during the execution is handled as if it's returned by the locks below.
+		err = seVars.StmtCtx.PipelinedLockErr
 		if err == nil {
-			return nil
+			if len(keys) == 0 {
+				return nil
+			}
+			keys = filterTemporaryTableKeys(seVars, keys)
+			lockCtx := newLockCtx(seVars, seVars.LockWaitTimeout)
+			var lockKeyStats *util.LockKeysDetails
+			ctx = context.WithValue(ctx, util.LockKeysDetailCtxKey, &lockKeyStats)
+			err = txn.LockKeys(ctx, lockCtx, keys...)
+			if lockKeyStats != nil {
+				seVars.StmtCtx.MergeLockKeysExecDetails(lockKeyStats)
+			}
+			if err == nil {
+				return nil
+			}
 		}
 		e, err = a.handlePessimisticLockError(ctx, err)
 		if err != nil {
@@ -863,14 +874,17 @@ var (
 // 3. record execute duration metric.
 // 4. update the `PrevStmt` in session variable.
 // 5. reset `DurationParse` in session variable.
+// 6. capture the plan replayer bundle if the digest is flagged.
+// 7. write the audit log if it's enabled.
 func (a *ExecStmt) FinishExecuteStmt(txnTS uint64, err error, hasMoreResults bool) {
 	sessVars := a.Ctx.GetSessionVars()
 	execDetail := sessVars.StmtCtx.GetExecDetails()
 	// Attach commit/lockKeys runtime stats to executor runtime stats.
 	if (execDetail.CommitDetail != nil || execDetail.LockKeysDetail != nil) && sessVars.StmtCtx.RuntimeStatsColl != nil {
 		statsWithCommit := &execdetails.RuntimeStatsWithCommit{
-			Commit:   execDetail.CommitDetail,
-			LockKeys: execDetail.LockKeysDetail,
+			Commit:     execDetail.CommitDetail,
+			LockKeys:   execDetail.LockKeysDetail,
+			CommitMode: execDetail.CommitMode,
 		}
 		sessVars.StmtCtx.RuntimeStatsColl.RegisterStats(a.Plan.ID(), statsWithCommit)
 	}
@@ -883,9 +897,29 @@ func (a *ExecStmt) FinishExecuteStmt(txnTS uint64, err error, hasMoreResults boo
 		a.Ctx.GetTxnWriteThroughputSLI().AddReadKeys(execDetail.ScanDetail.ProcessedKeys)
 	}
 	succ := err == nil
+	if succ && sessVars.EnableSelectivityFeedback {
+		plannercore.CollectSelectivityFeedback(a.Ctx, a.Plan)
+	}
+	if succ && sessVars.EnableCardinalityFeedback {
+		plannercore.CollectCardinalityFeedback(a.Ctx, a.Plan)
+	}
 	// `LowSlowQuery` and `SummaryStmt` must be called before recording `PrevStmt`.
 	a.LogSlowQuery(txnTS, succ, hasMoreResults)
 	a.SummaryStmt(succ)
+	if variable.EnableStmtEvents.Load() && !sessVars.InRestrictedSQL {
+		a.recordStmtEvent(err)
+	}
+	if audit.Enabled() {
+		audit.LogStmt(sessVars, a.StmtNode, GetStmtLabel(a.StmtNode), err)
+	}
+	if plancapture.HasPending() && !sessVars.InRestrictedSQL {
+		if _, digest := sessVars.StmtCtx.SQLDigest(); digest != nil && plancapture.Take(digest.String()) {
+			a.capturePlan(digest.String(), err)
+			if err := removeCapturedDigest(sessVars, digest.String()); err != nil {
+				logutil.BgLogger().Warn("remove the captured digest failed", zap.String("digest", digest.String()), zap.Error(err))
+			}
+		}
+	}
 	if sessVars.StmtCtx.IsTiFlash.Load() {
 		if succ {
 			totalTiFlashQuerySuccCounter.Inc()
@@ -896,6 +930,9 @@ func (a *ExecStmt) FinishExecuteStmt(txnTS uint64, err error, hasMoreResults boo
 	sessVars.PrevStmt = FormatSQL(a.GetTextToLog())
 
 	executeDuration := time.Since(sessVars.StartTime) - sessVars.DurationCompile
+	if group := sessVars.StmtCtx.ResourceGroupName; len(group) > 0 {
+		recordResourceGroupUsage(group, succ, time.Since(sessVars.StartTime), sessVars.StmtCtx.MemTracker.MaxConsumed())
+	}
 	if sessVars.InRestrictedSQL {
 		sessionExecuteRunDurationInternal.Observe(executeDuration.Seconds())
 	} else {
@@ -999,6 +1036,8 @@ func (a *ExecStmt) LogSlowQuery(txnTS uint64, succ bool, hasMoreResults bool) {
 		PDTotal:           time.Duration(atomic.LoadInt64(&tikvExecDetail.WaitPDRespDuration)),
 		BackoffTotal:      time.Duration(atomic.LoadInt64(&tikvExecDetail.BackoffDuration)),
 		WriteSQLRespTotal: stmtDetail.WriteSQLRespDuration,
+		WriteSQLRespRows:  stmtDetail.WriteSQLRespRows,
+		WriteSQLRespBytes: stmtDetail.WriteSQLRespBytes,
 		ExecRetryCount:    a.retryCount,
 	}
 	if a.retryCount > 0 {
@@ -1010,10 +1049,11 @@ func (a *ExecStmt) LogSlowQuery(txnTS uint64, succ bool, hasMoreResults bool) {
 	if trace.IsEnabled() {
 		trace.Log(a.GoCtx, "details", sessVars.SlowLogFormat(slowItems))
 	}
+	slowLog := formatSlowLog(a.Ctx, a.Plan, slowItems)
 	if costTime < threshold {
-		logutil.SlowQueryLogger.Debug(sessVars.SlowLogFormat(slowItems))
+		logutil.SlowQueryLogger.Debug(slowLog)
 	} else {
-		logutil.SlowQueryLogger.Warn(sessVars.SlowLogFormat(slowItems))
+		logutil.SlowQueryLogger.Warn(slowLog)
 		if sessVars.InRestrictedSQL {
 			totalQueryProcHistogramInternal.Observe(costTime.Seconds())
 			totalCopProcHistogramInternal.Observe(execDetail.TimeDetail.ProcessTime.Seconds())
@@ -1049,6 +1089,28 @@ func (a *ExecStmt) LogSlowQuery(txnTS uint64, succ bool, hasMoreResults bool) {
 	}
 }
 
+// formatSlowLog formats the slow log in the format set by tidb_slow_log_format.
+func formatSlowLog(sctx sessionctx.Context, p plannercore.Plan, slowItems *variable.SlowQueryLogItems) string {
+	sessVars := sctx.GetSessionVars()
+	if variable.SlowLogFormat.Load() != variable.SlowLogFormatJSON {
+		return sessVars.SlowLogFormat(slowItems)
+	}
+	if len(slowItems.Plan) > 0 {
+		if explain, ok := p.(*plannercore.Explain); ok {
+			p = explain.TargetPlan
+		}
+		if nodes := plannercore.GetExplainNodesForPlan(sctx, p); len(nodes) > 0 {
+			slowItems.PlanTree = nodes
+		}
+	}
+	slowLog, err := sessVars.SlowLogJSONFormat(slowItems)
+	if err != nil {
+		logutil.BgLogger().Warn("format slow log in JSON failed", zap.Error(err))
+		return sessVars.SlowLogFormat(slowItems)
+	}
+	return slowLog
+}
+
 // getPlanTree will try to get the select plan tree if the plan is select or the select plan of delete/update/insert statement.
 func getPlanTree(sctx sessionctx.Context, p plannercore.Plan) string {
 	cfg := config.GetGlobalConfig()
@@ -1205,6 +1267,68 @@ func (a *ExecStmt) SummaryStmt(succ bool) {
 	stmtsummary.StmtSummaryByDigestMap.AddStatement(stmtExecInfo)
 }
 
+// recordStmtEvent records the statement and its stages for the events tables of performance_schema.
+func (a *ExecStmt) recordStmtEvent(err error) {
+	sessVars := a.Ctx.GetSessionVars()
+	stmtCtx := sessVars.StmtCtx
+	normalizedSQL, digest := stmtCtx.SQLDigest()
+	errCount, warnCount := stmtCtx.NumErrorWarnings()
+	endTime := time.Now()
+	compiledTime := sessVars.StartTime.Add(sessVars.DurationCompile)
+	event := &perfschema.StatementEvent{
+		ThreadID:      sessVars.ConnectionID,
+		EventName:     "statement/sql/" + strings.ToLower(GetStmtLabel(a.StmtNode)),
+		StartTime:     sessVars.StartTime.Add(-sessVars.DurationParse),
+		EndTime:       endTime,
+		SQLText:       a.GetTextToLog(),
+		Digest:        digest.String(),
+		DigestText:    normalizedSQL,
+		CurrentSchema: sessVars.CurrentDB,
+		Errors:        uint64(errCount),
+		Warnings:      uint64(warnCount),
+		RowsAffected:  stmtCtx.AffectedRows(),
+		RowsSent:      stmtCtx.FoundRows(),
+		Stages: []perfschema.StageEvent{
+			{EventName: perfschema.StageParsing, StartTime: sessVars.StartTime.Add(-sessVars.DurationParse), EndTime: sessVars.StartTime},
+			{EventName: perfschema.StageOptimizing, StartTime: sessVars.StartTime, EndTime: compiledTime},
+			{EventName: perfschema.StageExecuting, StartTime: compiledTime, EndTime: endTime},
+		},
+	}
+	if sessVars.User != nil {
+		event.User = sessVars.User.Username
+	}
+	execDetail := stmtCtx.GetExecDetails()
+	if execDetail.ScanDetail != nil {
+		event.RowsExamined = uint64(execDetail.ScanDetail.TotalKeys)
+	}
+	if execDetail.LockKeysDetail != nil {
+		// The time locking the keys includes the time waiting for the locks of the other transactions.
+		event.AddWait(perfschema.WaitLockKeys, "lock", execDetail.LockKeysDetail.TotalTime)
+	}
+	backoffTypes := make([]string, 0, len(execDetail.BackoffSleep))
+	for tp := range execDetail.BackoffSleep {
+		backoffTypes = append(backoffTypes, tp)
+	}
+	sort.Strings(backoffTypes)
+	for _, tp := range backoffTypes {
+		event.AddWait(perfschema.WaitBackoffPrefix+tp, "sleep", execDetail.BackoffSleep[tp])
+	}
+	event.AddWait(perfschema.WaitTiKVQueue, "wait", execDetail.TimeDetail.WaitTime)
+	if err != nil {
+		var sqlErr *mysql.SQLError
+		if te, ok := errors.Cause(err).(*terror.Error); ok {
+			sqlErr = terror.ToSQLError(te)
+		} else {
+			sqlErr = mysql.NewErrf(mysql.ErrUnknown, "%s", nil, err.Error())
+		}
+		event.ErrNo, event.SQLState, event.Message = sqlErr.Code, sqlErr.State, sqlErr.Message
+		event.Errors++
+		// The changes of the failed statement are rolled back, so no rows are affected, the same as MySQL.
+		event.RowsAffected = 0
+	}
+	perfschema.RecordStatementEvent(event)
+}
+
 // GetTextToLog return the query text to log.
 func (a *ExecStmt) GetTextToLog() string {
 	var sql string
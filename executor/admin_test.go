@@ -1147,3 +1147,38 @@ func (s *testSuite5) TestAdminCheckWithSnapshot(c *C) {
 	tk.MustExec("admin check index admin_t_s a;")
 	tk.MustExec("drop table if exists admin_t_s")
 }
+
+func (s *testSuite5) TestAdminCheckTableChecksum(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists admin_checksum, admin_checksum_c")
+	tk.MustExec("create table admin_checksum (a int, b varchar(10), c int, key(a, b), unique key(c))")
+	tk.MustExec("insert into admin_checksum values (1, 'a', 1), (null, 'b', 2), (3, null, null), (null, null, 4)")
+	tk.MustExec("create table admin_checksum_c (a varchar(10), b int, c int, primary key(a, b) clustered, key(c))")
+	tk.MustExec("insert into admin_checksum_c values ('a', 1, 1), ('b', 2, null), ('c', 3, 3)")
+	for _, enabled := range []string{"1", "0"} {
+		tk.MustExec("set @@tidb_enable_fast_table_check = " + enabled)
+		tk.MustExec("admin check table admin_checksum, admin_checksum_c")
+		tk.MustExec("admin check index admin_checksum a")
+	}
+	tk.MustExec("set @@tidb_enable_fast_table_check = 1")
+
+	// Make an index entry point to the row with the different values.
+	s.ctx = mock.NewContext()
+	s.ctx.Store = s.store
+	tbl, err := s.domain.InfoSchema().TableByName(model.NewCIStr("test"), model.NewCIStr("admin_checksum"))
+	c.Assert(err, IsNil)
+	tblInfo := tbl.Meta()
+	idxOpr := tables.NewIndex(tblInfo.ID, tblInfo, tblInfo.FindIndexByName("a"))
+	sc := s.ctx.GetSessionVars().StmtCtx
+	handle := kv.IntHandle(1)
+	txn, err := s.store.Begin()
+	c.Assert(err, IsNil)
+	c.Assert(idxOpr.Delete(sc, txn, types.MakeDatums(1, "a"), handle), IsNil)
+	_, err = idxOpr.Create(s.ctx, txn, types.MakeDatums(1, "x"), handle, nil)
+	c.Assert(err, IsNil)
+	c.Assert(txn.Commit(context.Background()), IsNil)
+	c.Assert(tk.ExecToErr("admin check table admin_checksum"), NotNil)
+	c.Assert(tk.ExecToErr("admin check index admin_checksum a"), NotNil)
+	tk.MustExec("admin check index admin_checksum c")
+}
@@ -69,6 +69,9 @@ func Build(ctx sessionctx.Context, aggFuncDesc *aggregation.AggFuncDesc, ordinal
 	case ast.AggFuncStddevSamp:
 		return buildStddevSamp(aggFuncDesc, ordinal)
 	}
+	if def, ok := aggregation.GetUserDefinedAggFunc(aggFuncDesc.Name); ok {
+		return buildUserDefined(def, aggFuncDesc, ordinal)
+	}
 	return nil
 }
 
@@ -615,6 +618,17 @@ func buildStddevSamp(aggFuncDesc *aggregation.AggFuncDesc, ordinal int) AggFunc
 	}
 }
 
+// buildUserDefined builds the AggFunc implementation for the user defined aggregate function.
+func buildUserDefined(def *aggregation.UserDefinedAggFuncDef, aggFuncDesc *aggregation.AggFuncDesc, ordinal int) AggFunc {
+	return &userDefined{
+		baseAggFunc: baseAggFunc{
+			args:    aggFuncDesc.Args,
+			ordinal: ordinal,
+		},
+		fn: def.New(aggFuncDesc.Args, aggFuncDesc.RetTp),
+	}
+}
+
 // buildJSONObjectAgg builds the AggFunc implementation for function "json_objectagg".
 func buildJSONObjectAgg(aggFuncDesc *aggregation.AggFuncDesc, ordinal int) AggFunc {
 	base := baseAggFunc{
@@ -28,6 +28,7 @@ import (
 	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/collate"
 	"github.com/pingcap/tidb/util/hack"
+	"github.com/pingcap/tidb/util/hyperloglog"
 	"github.com/pingcap/tidb/util/set"
 	"github.com/pingcap/tidb/util/stringutil"
 )
@@ -489,17 +490,83 @@ const (
 
 type approxCountDistinctHashValue uint32
 
-// partialResult4ApproxCountDistinct use `BJKST` algorithm to compute approximate result of count distinct.
+// partialResult4ApproxCountDistinct uses the HyperLogLog sketch to compute the approximate result of count distinct.
+// The sketch is in a fixed size, and it's merged and encoded as the partial state of the two-phase aggregation.
+// The storage engines compute the partial states by the `BJKST` algorithm, which are merged into storeSet. The
+// aggregation is pushed down as a whole, so the states of TiDB and the storage engines are not mixed in a group.
+type partialResult4ApproxCountDistinct struct {
+	sketch   hyperloglog.Sketch
+	storeSet *uniquesHashSet
+}
+
+// NewPartialResult4ApproxCountDistinct builds a partial result for agg function ApproxCountDistinct.
+func NewPartialResult4ApproxCountDistinct() *partialResult4ApproxCountDistinct {
+	return &partialResult4ApproxCountDistinct{}
+}
+
+func (p *partialResult4ApproxCountDistinct) InsertHash64(x uint64) {
+	p.sketch.Insert(x)
+}
+
+func (p *partialResult4ApproxCountDistinct) MemUsage() int64 {
+	if p.storeSet == nil {
+		return 0
+	}
+	return p.storeSet.MemUsage()
+}
+
+func (p *partialResult4ApproxCountDistinct) reset() {
+	p.sketch.Reset()
+	p.storeSet = nil
+}
+
+func (p *partialResult4ApproxCountDistinct) merge(tar *partialResult4ApproxCountDistinct) {
+	p.sketch.Merge(&tar.sketch)
+	if tar.storeSet != nil {
+		if p.storeSet == nil {
+			p.storeSet = newUniquesHashSet()
+		}
+		p.storeSet.merge(tar.storeSet)
+	}
+}
+
+func (p *partialResult4ApproxCountDistinct) readAndMerge(rb []byte) error {
+	if hyperloglog.IsEncoded(rb) {
+		return p.sketch.MergeEncoded(rb)
+	}
+	if p.storeSet == nil {
+		p.storeSet = newUniquesHashSet()
+	}
+	return p.storeSet.readAndMerge(rb)
+}
+
+// useStoreSet returns whether the result is computed by storeSet. If the states are mixed unexpectedly, the larger one
+// is used.
+func (p *partialResult4ApproxCountDistinct) useStoreSet() bool {
+	return p.storeSet != nil && p.storeSet.fixedSize() >= p.sketch.Estimate()
+}
+
+func (p *partialResult4ApproxCountDistinct) estimate() uint64 {
+	if p.useStoreSet() {
+		return p.storeSet.fixedSize()
+	}
+	return p.sketch.Estimate()
+}
+
+func (p *partialResult4ApproxCountDistinct) Serialize() []byte {
+	if p.useStoreSet() {
+		return p.storeSet.Serialize()
+	}
+	return p.sketch.Encode(nil)
+}
+
+// uniquesHashSet uses `BJKST` algorithm to compute approximate result of count distinct, it's the algorithm of the
+// partial states computed by the storage engines.
 // According to an experimental survey http://www.vldb.org/pvldb/vol11/p499-harmouch.pdf, the error guarantee of BJKST
 // was even better than the theoretical lower bounds.
-// For the calculation state, it uses a sample of element hash values with a size up to uniquesHashMaxSize. Compared
-// with the widely known HyperLogLog algorithm, this algorithm is less effective in terms of accuracy and
-// memory consumption (even up to proportionality), but it is adaptive. This means that with fairly high accuracy, it
-// consumes less memory during simultaneous computation of cardinality for a large number of data sets whose cardinality
-// has power law distribution (i.e. in cases when most of the data sets are small).
-// This algorithm is also very accurate for data sets with small cardinality and very efficient on CPU. If number of
-// distinct element is more than 2^32, relative error may be high.
-type partialResult4ApproxCountDistinct struct {
+// For the calculation state, it uses a sample of element hash values with a size up to uniquesHashMaxSize.
+// If number of distinct element is more than 2^32, relative error may be high.
+type uniquesHashSet struct {
 	size       uint32 // Number of elements.
 	sizeDegree uint8  // The size of the table as a power of 2.
 	skipDegree uint8  // Skip elements not divisible by 2 ^ skipDegree.
@@ -507,23 +574,17 @@ type partialResult4ApproxCountDistinct struct {
 	buf        []approxCountDistinctHashValue
 }
 
-// NewPartialResult4ApproxCountDistinct builds a partial result for agg function ApproxCountDistinct.
-func NewPartialResult4ApproxCountDistinct() *partialResult4ApproxCountDistinct {
-	p := &partialResult4ApproxCountDistinct{}
+func newUniquesHashSet() *uniquesHashSet {
+	p := &uniquesHashSet{}
 	p.reset()
 	return p
 }
 
-func (p *partialResult4ApproxCountDistinct) InsertHash64(x uint64) {
-	// no need to rehash, just cast into uint32
-	p.insertHash(approxCountDistinctHashValue(x))
-}
-
-func (p *partialResult4ApproxCountDistinct) MemUsage() int64 {
+func (p *uniquesHashSet) MemUsage() int64 {
 	return int64(len(p.buf)) * DefUint32Size
 }
 
-func (p *partialResult4ApproxCountDistinct) alloc(newSizeDegree uint8) {
+func (p *uniquesHashSet) alloc(newSizeDegree uint8) {
 	p.size = 0
 	p.skipDegree = 0
 	p.hasZero = false
@@ -531,7 +592,7 @@ func (p *partialResult4ApproxCountDistinct) alloc(newSizeDegree uint8) {
 	p.sizeDegree = newSizeDegree
 }
 
-func (p *partialResult4ApproxCountDistinct) reset() {
+func (p *uniquesHashSet) reset() {
 	p.alloc(uniquesHashSetInitialSizeDegree)
 }
 
@@ -543,20 +604,20 @@ func max(a, b uint8) uint8 {
 	return b
 }
 
-func (p *partialResult4ApproxCountDistinct) bufSize() uint32 {
+func (p *uniquesHashSet) bufSize() uint32 {
 	return uint32(1) << p.sizeDegree
 }
 
-func (p *partialResult4ApproxCountDistinct) mask() uint32 {
+func (p *uniquesHashSet) mask() uint32 {
 	return p.bufSize() - 1
 }
 
-func (p *partialResult4ApproxCountDistinct) place(x approxCountDistinctHashValue) uint32 {
+func (p *uniquesHashSet) place(x approxCountDistinctHashValue) uint32 {
 	return uint32(x>>uniquesHashBitsForSkip) & p.mask()
 }
 
 // Increase the size of the buffer 2 times or up to new size degree.
-func (p *partialResult4ApproxCountDistinct) resize(newSizeDegree uint8) {
+func (p *uniquesHashSet) resize(newSizeDegree uint8) {
 	oldSize := p.bufSize()
 	oldBuf := p.buf
 
@@ -576,7 +637,7 @@ func (p *partialResult4ApproxCountDistinct) resize(newSizeDegree uint8) {
 	}
 }
 
-func (p *partialResult4ApproxCountDistinct) readAndMerge(rb []byte) error {
+func (p *uniquesHashSet) readAndMerge(rb []byte) error {
 	rhsSkipDegree := rb[0]
 	rb = rb[1:]
 
@@ -610,7 +671,7 @@ func (p *partialResult4ApproxCountDistinct) readAndMerge(rb []byte) error {
 }
 
 // Correct system errors due to collisions during hashing in uint32.
-func (p *partialResult4ApproxCountDistinct) fixedSize() uint64 {
+func (p *uniquesHashSet) fixedSize() uint64 {
 	if 0 == p.skipDegree {
 		return uint64(p.size)
 	}
@@ -626,7 +687,7 @@ func (p *partialResult4ApproxCountDistinct) fixedSize() uint64 {
 	return uint64(fixedRes)
 }
 
-func (p *partialResult4ApproxCountDistinct) insertHash(hashValue approxCountDistinctHashValue) {
+func (p *uniquesHashSet) insertHash(hashValue approxCountDistinctHashValue) {
 	if !p.good(hashValue) {
 		return
 	}
@@ -636,12 +697,12 @@ func (p *partialResult4ApproxCountDistinct) insertHash(hashValue approxCountDist
 }
 
 // The value is divided by 2 ^ skip_degree
-func (p *partialResult4ApproxCountDistinct) good(hash approxCountDistinctHashValue) bool {
+func (p *uniquesHashSet) good(hash approxCountDistinctHashValue) bool {
 	return hash == ((hash >> p.skipDegree) << p.skipDegree)
 }
 
 // Insert a value
-func (p *partialResult4ApproxCountDistinct) insertImpl(x approxCountDistinctHashValue) {
+func (p *uniquesHashSet) insertImpl(x approxCountDistinctHashValue) {
 	if x == 0 {
 		if !p.hasZero {
 			p.size += 1
@@ -666,7 +727,7 @@ func (p *partialResult4ApproxCountDistinct) insertImpl(x approxCountDistinctHash
 
 // If the hash table is full enough, then do resize.
 // If there are too many items, then throw half the pieces until they are small enough.
-func (p *partialResult4ApproxCountDistinct) shrinkIfNeed() {
+func (p *uniquesHashSet) shrinkIfNeed() {
 	if p.size > p.maxFill() {
 		if p.size > uniquesHashMaxSize {
 			for p.size > uniquesHashMaxSize {
@@ -679,12 +740,12 @@ func (p *partialResult4ApproxCountDistinct) shrinkIfNeed() {
 	}
 }
 
-func (p *partialResult4ApproxCountDistinct) maxFill() uint32 {
+func (p *uniquesHashSet) maxFill() uint32 {
 	return uint32(1) << (p.sizeDegree - 1)
 }
 
 // Delete all values whose hashes do not divide by 2 ^ skip_degree
-func (p *partialResult4ApproxCountDistinct) rehash() {
+func (p *uniquesHashSet) rehash() {
 	for i := uint32(0); i < p.bufSize(); i++ {
 		if p.buf[i] != 0 && !p.good(p.buf[i]) {
 			p.buf[i] = 0
@@ -703,7 +764,7 @@ func (p *partialResult4ApproxCountDistinct) rehash() {
 
 // Insert a value into the new buffer that was in the old buffer.
 // Used when increasing the size of the buffer, as well as when reading from a file.
-func (p *partialResult4ApproxCountDistinct) reinsertImpl(x approxCountDistinctHashValue) {
+func (p *uniquesHashSet) reinsertImpl(x approxCountDistinctHashValue) {
 	placeValue := p.place(x)
 	for p.buf[placeValue] != 0 {
 		placeValue++
@@ -713,7 +774,7 @@ func (p *partialResult4ApproxCountDistinct) reinsertImpl(x approxCountDistinctHa
 	p.buf[placeValue] = x
 }
 
-func (p *partialResult4ApproxCountDistinct) merge(tar *partialResult4ApproxCountDistinct) {
+func (p *uniquesHashSet) merge(tar *uniquesHashSet) {
 	if tar.skipDegree > p.skipDegree {
 		p.skipDegree = tar.skipDegree
 		p.rehash()
@@ -733,7 +794,7 @@ func (p *partialResult4ApproxCountDistinct) merge(tar *partialResult4ApproxCount
 	}
 }
 
-func (p *partialResult4ApproxCountDistinct) Serialize() []byte {
+func (p *uniquesHashSet) Serialize() []byte {
 	var buf [4]byte
 	res := make([]byte, 0, 1+binary.MaxVarintLen64+p.size*4)
 
@@ -756,7 +817,7 @@ func (p *partialResult4ApproxCountDistinct) Serialize() []byte {
 
 func (e *baseApproxCountDistinct) AppendFinalResult2Chunk(sctx sessionctx.Context, pr PartialResult, chk *chunk.Chunk) error {
 	p := (*partialResult4ApproxCountDistinct)(pr)
-	chk.AppendInt64(e.ordinal, int64(p.fixedSize()))
+	chk.AppendInt64(e.ordinal, int64(p.estimate()))
 	return nil
 }
 
@@ -771,8 +832,9 @@ func (e *baseApproxCountDistinct) ResetPartialResult(pr PartialResult) {
 
 func (e *baseApproxCountDistinct) MergePartialResult(sctx sessionctx.Context, src, dst PartialResult) (memDelta int64, err error) {
 	p1, p2 := (*partialResult4ApproxCountDistinct)(src), (*partialResult4ApproxCountDistinct)(dst)
+	oldMemUsage := p2.MemUsage()
 	p2.merge(p1)
-	return 0, nil
+	return p2.MemUsage() - oldMemUsage, nil
 }
 
 type approxCountDistinctOriginal struct {
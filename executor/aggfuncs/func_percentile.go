@@ -22,13 +22,21 @@ import (
 	"github.com/pingcap/tidb/types"
 	"github.com/pingcap/tidb/util/chunk"
 	"github.com/pingcap/tidb/util/selection"
+	"github.com/pingcap/tidb/util/tdigest"
 )
 
 const (
 	// DefSliceSize represents size of an empty Slice
 	DefSliceSize = int64(unsafe.Sizeof([]interface{}{}))
+	// DefPartialResult4ApproxPercentileSize is the size of partialResult4ApproxPercentileInt and
+	// partialResult4ApproxPercentileReal.
+	DefPartialResult4ApproxPercentileSize = int64(unsafe.Sizeof(partialResult4ApproxPercentileInt{}))
 )
 
+// percentileExactLimit is the number of the integers or reals of a group kept exactly, beyond which they are summarized
+// by a t-digest, so the memory of a group is bounded while the percentiles of the small groups are still exact.
+var percentileExactLimit = 4096
+
 var (
 	_ partialResult4Percentile = partialResult4PercentileInt{}
 	_ partialResult4Percentile = partialResult4PercentileReal{}
@@ -43,6 +51,14 @@ func percentile(data sort.Interface, percent int) int {
 	return selection.Select(data, k)
 }
 
+// percentileInDigest estimates the percentile of the values in the t-digest, the ordinal rank is the same as the one
+// of percentile.
+func percentileInDigest(digest *tdigest.TDigest, percent int) float64 {
+	n := float64(digest.Count())
+	k := math.Ceil(n / 100 * float64(percent))
+	return digest.Quantile((k - 0.5) / n)
+}
+
 type basePercentile struct {
 	percent int
 
@@ -121,24 +137,55 @@ func (p partialResult4PercentileDuration) MemSize() int64 {
 	return DefSliceSize + int64(len(p))*DefInt64Size
 }
 
+// partialResult4ApproxPercentileInt keeps the values exactly until there are more than percentileExactLimit values,
+// then all the values are added to the digest.
+type partialResult4ApproxPercentileInt struct {
+	values partialResult4PercentileInt
+	digest *tdigest.TDigest
+}
+
+func (p *partialResult4ApproxPercentileInt) memSize() int64 {
+	if p.digest != nil {
+		return DefPartialResult4ApproxPercentileSize + p.digest.MemUsage()
+	}
+	return DefPartialResult4ApproxPercentileSize + p.values.MemSize()
+}
+
+func (p *partialResult4ApproxPercentileInt) add(v int64) {
+	if p.digest != nil {
+		p.digest.Add(float64(v))
+		return
+	}
+	p.values = append(p.values, v)
+	if len(p.values) > percentileExactLimit {
+		p.toDigest()
+	}
+}
+
+func (p *partialResult4ApproxPercentileInt) toDigest() {
+	p.digest = tdigest.New(tdigest.DefaultCompression)
+	for _, v := range p.values {
+		p.digest.Add(float64(v))
+	}
+	p.values = nil
+}
+
 type percentileOriginal4Int struct {
 	basePercentile
 }
 
 func (e *percentileOriginal4Int) AllocPartialResult() (pr PartialResult, memDelta int64) {
-	// TODO: Preserve appropriate capacity for data
-	pr = PartialResult(&partialResult4PercentileInt{})
-	return pr, DefSliceSize
+	return PartialResult(&partialResult4ApproxPercentileInt{}), DefPartialResult4ApproxPercentileSize
 }
 
 func (e *percentileOriginal4Int) ResetPartialResult(pr PartialResult) {
-	p := (*partialResult4PercentileInt)(pr)
-	*p = partialResult4PercentileInt{}
+	p := (*partialResult4ApproxPercentileInt)(pr)
+	*p = partialResult4ApproxPercentileInt{}
 }
 
 func (e *percentileOriginal4Int) UpdatePartialResult(sctx sessionctx.Context, rowsInGroup []chunk.Row, pr PartialResult) (memDelta int64, err error) {
-	p := (*partialResult4PercentileInt)(pr)
-	startMem := p.MemSize()
+	p := (*partialResult4ApproxPercentileInt)(pr)
+	startMem := p.memSize()
 	for _, row := range rowsInGroup {
 		v, isNull, err := e.args[0].EvalInt(sctx, row)
 		if err != nil {
@@ -147,51 +194,93 @@ func (e *percentileOriginal4Int) UpdatePartialResult(sctx sessionctx.Context, ro
 		if isNull {
 			continue
 		}
-		*p = append(*p, v)
+		p.add(v)
 	}
-	endMem := p.MemSize()
+	endMem := p.memSize()
 	return endMem - startMem, nil
 }
 
 func (e *percentileOriginal4Int) MergePartialResult(sctx sessionctx.Context, src, dst PartialResult) (memDelta int64, err error) {
-	p1, p2 := (*partialResult4PercentileInt)(src), (*partialResult4PercentileInt)(dst)
-	mergeBuff := make([]int64, len(*p1)+len(*p2))
-	copy(mergeBuff, *p2)
-	copy(mergeBuff[len(*p2):], *p1)
-	*p1 = nil
-	*p2 = mergeBuff
-	return 0, nil
+	p1, p2 := (*partialResult4ApproxPercentileInt)(src), (*partialResult4ApproxPercentileInt)(dst)
+	startMem := p2.memSize()
+	if p1.digest != nil && p2.digest == nil {
+		p2.toDigest()
+	}
+	if p1.digest != nil {
+		p2.digest.Merge(p1.digest)
+	} else {
+		for _, v := range p1.values {
+			p2.add(v)
+		}
+	}
+	*p1 = partialResult4ApproxPercentileInt{}
+	return p2.memSize() - startMem, nil
 }
 
 func (e *percentileOriginal4Int) AppendFinalResult2Chunk(sctx sessionctx.Context, pr PartialResult, chk *chunk.Chunk) error {
-	p := (*partialResult4PercentileInt)(pr)
-	if len(*p) == 0 {
+	p := (*partialResult4ApproxPercentileInt)(pr)
+	if p.digest != nil {
+		chk.AppendInt64(e.ordinal, int64(math.Round(percentileInDigest(p.digest, e.percent))))
+		return nil
+	}
+	if len(p.values) == 0 {
 		chk.AppendNull(e.ordinal)
 		return nil
 	}
-	index := percentile(p, e.percent)
-	chk.AppendInt64(e.ordinal, (*p)[index])
+	index := percentile(p.values, e.percent)
+	chk.AppendInt64(e.ordinal, p.values[index])
 	return nil
 }
 
+// partialResult4ApproxPercentileReal keeps the values exactly until there are more than percentileExactLimit values,
+// then all the values are added to the digest.
+type partialResult4ApproxPercentileReal struct {
+	values partialResult4PercentileReal
+	digest *tdigest.TDigest
+}
+
+func (p *partialResult4ApproxPercentileReal) memSize() int64 {
+	if p.digest != nil {
+		return DefPartialResult4ApproxPercentileSize + p.digest.MemUsage()
+	}
+	return DefPartialResult4ApproxPercentileSize + p.values.MemSize()
+}
+
+func (p *partialResult4ApproxPercentileReal) add(v float64) {
+	if p.digest != nil {
+		p.digest.Add(v)
+		return
+	}
+	p.values = append(p.values, v)
+	if len(p.values) > percentileExactLimit {
+		p.toDigest()
+	}
+}
+
+func (p *partialResult4ApproxPercentileReal) toDigest() {
+	p.digest = tdigest.New(tdigest.DefaultCompression)
+	for _, v := range p.values {
+		p.digest.Add(v)
+	}
+	p.values = nil
+}
+
 type percentileOriginal4Real struct {
 	basePercentile
 }
 
 func (e *percentileOriginal4Real) AllocPartialResult() (pr PartialResult, memDelta int64) {
-	// TODO: Preserve appropriate capacity for data
-	pr = PartialResult(&partialResult4PercentileReal{})
-	return pr, DefSliceSize
+	return PartialResult(&partialResult4ApproxPercentileReal{}), DefPartialResult4ApproxPercentileSize
 }
 
 func (e *percentileOriginal4Real) ResetPartialResult(pr PartialResult) {
-	p := (*partialResult4PercentileReal)(pr)
-	*p = partialResult4PercentileReal{}
+	p := (*partialResult4ApproxPercentileReal)(pr)
+	*p = partialResult4ApproxPercentileReal{}
 }
 
 func (e *percentileOriginal4Real) UpdatePartialResult(sctx sessionctx.Context, rowsInGroup []chunk.Row, pr PartialResult) (memDelta int64, err error) {
-	p := (*partialResult4PercentileReal)(pr)
-	startMem := p.MemSize()
+	p := (*partialResult4ApproxPercentileReal)(pr)
+	startMem := p.memSize()
 	for _, row := range rowsInGroup {
 		v, isNull, err := e.args[0].EvalReal(sctx, row)
 		if err != nil {
@@ -200,30 +289,41 @@ func (e *percentileOriginal4Real) UpdatePartialResult(sctx sessionctx.Context, r
 		if isNull {
 			continue
 		}
-		*p = append(*p, v)
+		p.add(v)
 	}
-	endMem := p.MemSize()
+	endMem := p.memSize()
 	return endMem - startMem, nil
 }
 
 func (e *percentileOriginal4Real) MergePartialResult(sctx sessionctx.Context, src, dst PartialResult) (memDelta int64, err error) {
-	p1, p2 := (*partialResult4PercentileReal)(src), (*partialResult4PercentileReal)(dst)
-	mergeBuff := make([]float64, len(*p1)+len(*p2))
-	copy(mergeBuff, *p2)
-	copy(mergeBuff[len(*p2):], *p1)
-	*p1 = nil
-	*p2 = mergeBuff
-	return 0, nil
+	p1, p2 := (*partialResult4ApproxPercentileReal)(src), (*partialResult4ApproxPercentileReal)(dst)
+	startMem := p2.memSize()
+	if p1.digest != nil && p2.digest == nil {
+		p2.toDigest()
+	}
+	if p1.digest != nil {
+		p2.digest.Merge(p1.digest)
+	} else {
+		for _, v := range p1.values {
+			p2.add(v)
+		}
+	}
+	*p1 = partialResult4ApproxPercentileReal{}
+	return p2.memSize() - startMem, nil
 }
 
 func (e *percentileOriginal4Real) AppendFinalResult2Chunk(sctx sessionctx.Context, pr PartialResult, chk *chunk.Chunk) error {
-	p := (*partialResult4PercentileReal)(pr)
-	if len(*p) == 0 {
+	p := (*partialResult4ApproxPercentileReal)(pr)
+	if p.digest != nil {
+		chk.AppendFloat64(e.ordinal, percentileInDigest(p.digest, e.percent))
+		return nil
+	}
+	if len(p.values) == 0 {
 		chk.AppendNull(e.ordinal)
 		return nil
 	}
-	index := percentile(*p, e.percent)
-	chk.AppendFloat64(e.ordinal, (*p)[index])
+	index := percentile(p.values, e.percent)
+	chk.AppendFloat64(e.ordinal, p.values[index])
 	return nil
 }
 
@@ -30,6 +30,8 @@ func (s *testSuite) TestPercentile(c *C) {
 		buildAggTester(ast.AggFuncApproxPercentile, mysql.TypeNewDecimal, 5, nil, types.NewDecFromFloatForTest(2.0)),
 		buildAggTester(ast.AggFuncApproxPercentile, mysql.TypeDate, 5, nil, types.TimeFromDays(367)),
 		buildAggTester(ast.AggFuncApproxPercentile, mysql.TypeDuration, 5, nil, types.Duration{Duration: time.Duration(2)}),
+		// The values are summarized by the t-digest.
+		buildAggTester(ast.AggFuncApproxPercentile, mysql.TypeLonglong, 10001, nil, 5000),
 	}
 	for _, test := range tests {
 		s.testAggFunc(c, test)
@@ -0,0 +1,68 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aggfuncs
+
+import (
+	"unsafe"
+
+	"github.com/pingcap/tidb/expression/aggregation"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/util/chunk"
+)
+
+const (
+	// DefPartialResult4UserDefinedSize is the size of partialResult4UserDefined
+	DefPartialResult4UserDefinedSize = int64(unsafe.Sizeof(partialResult4UserDefined{}))
+)
+
+// userDefined adapts a user defined aggregate function to AggFunc.
+type userDefined struct {
+	baseAggFunc
+	fn aggregation.UserDefinedAggFunc
+}
+
+type partialResult4UserDefined struct {
+	pr interface{}
+}
+
+func (e *userDefined) AllocPartialResult() (pr PartialResult, memDelta int64) {
+	p := new(partialResult4UserDefined)
+	p.pr, memDelta = e.fn.Init()
+	return PartialResult(p), DefPartialResult4UserDefinedSize + memDelta
+}
+
+func (e *userDefined) ResetPartialResult(pr PartialResult) {
+	p := (*partialResult4UserDefined)(pr)
+	p.pr, _ = e.fn.Init()
+}
+
+func (e *userDefined) UpdatePartialResult(sctx sessionctx.Context, rowsInGroup []chunk.Row, pr PartialResult) (memDelta int64, err error) {
+	p := (*partialResult4UserDefined)(pr)
+	return e.fn.Update(sctx, rowsInGroup, p.pr)
+}
+
+func (e *userDefined) MergePartialResult(sctx sessionctx.Context, src, dst PartialResult) (memDelta int64, err error) {
+	p1, p2 := (*partialResult4UserDefined)(src), (*partialResult4UserDefined)(dst)
+	return e.fn.Merge(sctx, p1.pr, p2.pr)
+}
+
+func (e *userDefined) AppendFinalResult2Chunk(sctx sessionctx.Context, pr PartialResult, chk *chunk.Chunk) error {
+	p := (*partialResult4UserDefined)(pr)
+	d, err := e.fn.Finalize(sctx, p.pr)
+	if err != nil {
+		return err
+	}
+	chk.AppendDatum(e.ordinal, &d)
+	return nil
+}
@@ -93,6 +93,10 @@ type HashAggPartialWorker struct {
 	// chk stores the input data from child,
 	// and is reused by childExec and partial worker.
 	chk *chunk.Chunk
+	// skewSampler detects the heavy hitter group keys, it's nil if the two level hash aggregation is disabled.
+	skewSampler *hashAggSkewSampler
+	// heavyHitterOutputIdx is the final worker which the heavy hitters of this worker are sent to.
+	heavyHitterOutputIdx int
 }
 
 // HashAggFinalWorker indicates the final workers of parallel hash agg execution,
@@ -108,6 +112,10 @@ type HashAggFinalWorker struct {
 	outputCh            chan *AfFinalResult
 	finalResultHolderCh chan *chunk.Chunk
 	groupKeys           [][]byte
+	// heavyHitterResultMap holds the partial results of the heavy hitter group keys, they are sent to
+	// heavyHitterOutputCh to be merged by the second level final worker instead of being finalized here.
+	heavyHitterResultMap aggPartialResultMapper
+	heavyHitterOutputCh  chan *HashAggIntermData
 }
 
 // AfFinalResult indicates aggregation functions final result.
@@ -191,6 +199,11 @@ type HashAggExec struct {
 	prepared                bool
 	executed                bool
 
+	// heavyHitterCh and heavyHitterWorker are only used by the two level hash aggregation, the heavy hitter
+	// group keys are spread over the final workers and merged again by the heavyHitterWorker.
+	heavyHitterCh     chan *HashAggIntermData
+	heavyHitterWorker *HashAggFinalWorker
+
 	memTracker *memory.Tracker // track memory usage.
 
 	stats *HashAggRuntimeStats
@@ -210,6 +223,8 @@ type HashAggIntermData struct {
 	groupKeys        []string
 	cursor           int
 	partialResultMap aggPartialResultMapper
+	// isHeavyHitter indicates the group keys are heavy hitters which are merged in the second level.
+	isHeavyHitter bool
 }
 
 // getPartialResultBatch fetches a batch of partial results from HashAggIntermData.
@@ -245,6 +260,9 @@ func (e *HashAggExec) Close() error {
 			for _, ch := range e.partialInputChs {
 				close(ch)
 			}
+			if e.heavyHitterCh != nil {
+				close(e.heavyHitterCh)
+			}
 			close(e.finalOutputCh)
 		}
 		close(e.finishCh)
@@ -258,6 +276,10 @@ func (e *HashAggExec) Close() error {
 		}
 		for range e.finalOutputCh {
 		}
+		if e.heavyHitterCh != nil {
+			for range e.heavyHitterCh {
+			}
+		}
 		e.executed = false
 		if e.memTracker != nil {
 			e.memTracker.ReplaceBytesUsed(0)
@@ -308,7 +330,18 @@ func (e *HashAggExec) initForParallelExec(ctx sessionctx.Context) {
 	finalConcurrency := sessionVars.HashAggFinalConcurrency()
 	partialConcurrency := sessionVars.HashAggPartialConcurrency()
 	e.isChildReturnEmpty = true
-	e.finalOutputCh = make(chan *AfFinalResult, finalConcurrency+partialConcurrency+1)
+	var skewSampler *hashAggSkewSampler
+	e.heavyHitterCh, e.heavyHitterWorker = nil, nil
+	if sessionVars.EnableTwoLevelHashAgg && finalConcurrency > 1 {
+		skewSampler = newHashAggSkewSampler(finalConcurrency)
+		e.heavyHitterCh = make(chan *HashAggIntermData, finalConcurrency)
+	}
+	outputChSize := finalConcurrency + partialConcurrency + 1
+	if e.heavyHitterCh != nil {
+		// The heavy hitter worker works as an extra final worker.
+		outputChSize++
+	}
+	e.finalOutputCh = make(chan *AfFinalResult, outputChSize)
 	e.inputCh = make(chan *HashAggInput, partialConcurrency)
 	e.finishCh = make(chan struct{}, 1)
 
@@ -338,6 +371,10 @@ func (e *HashAggExec) initForParallelExec(ctx sessionctx.Context) {
 			chk:               newFirstChunk(e.children[0]),
 			groupKey:          make([][]byte, 0, 8),
 		}
+		if skewSampler != nil {
+			// The heavy hitters of different partial workers are sent to different final workers.
+			w.skewSampler, w.heavyHitterOutputIdx = skewSampler, i%finalConcurrency
+		}
 		// There is a bucket in the empty partialResultsMap.
 		failpoint.Inject("ConsumeRandomPanic", nil)
 		e.memTracker.Consume(defBucketMemoryUsage * (1 << w.BInMap))
@@ -357,20 +394,11 @@ func (e *HashAggExec) initForParallelExec(ctx sessionctx.Context) {
 
 	// Init final workers.
 	for i := 0; i < finalConcurrency; i++ {
-		groupSet, setSize := set.NewStringSetWithMemoryUsage()
-		w := HashAggFinalWorker{
-			baseHashAggWorker:   newBaseHashAggWorker(e.ctx, e.finishCh, e.FinalAggFuncs, e.maxChunkSize, e.memTracker),
-			partialResultMap:    make(aggPartialResultMapper),
-			groupSet:            groupSet,
-			inputCh:             e.partialOutputChs[i],
-			outputCh:            e.finalOutputCh,
-			finalResultHolderCh: make(chan *chunk.Chunk, 1),
-			rowBuffer:           make([]types.Datum, 0, e.Schema().Len()),
-			mutableRow:          chunk.MutRowFromTypes(retTypes(e)),
-			groupKeys:           make([][]byte, 0, 8),
+		w := e.newHashAggFinalWorker(e.partialOutputChs[i])
+		if e.heavyHitterCh != nil {
+			w.heavyHitterResultMap = make(aggPartialResultMapper)
+			w.heavyHitterOutputCh = e.heavyHitterCh
 		}
-		// There is a bucket in the empty partialResultsMap.
-		e.memTracker.Consume(defBucketMemoryUsage*(1<<w.BInMap) + setSize)
 		if e.stats != nil {
 			w.stats = &AggWorkerStat{}
 			e.stats.FinalStats = append(e.stats.FinalStats, w.stats)
@@ -378,10 +406,33 @@ func (e *HashAggExec) initForParallelExec(ctx sessionctx.Context) {
 		e.finalWorkers[i] = w
 		e.finalWorkers[i].finalResultHolderCh <- newFirstChunk(e)
 	}
+	if e.heavyHitterCh != nil {
+		w := e.newHashAggFinalWorker(e.heavyHitterCh)
+		w.finalResultHolderCh <- newFirstChunk(e)
+		e.heavyHitterWorker = &w
+	}
 
 	e.parallelExecInitialized = true
 }
 
+func (e *HashAggExec) newHashAggFinalWorker(inputCh chan *HashAggIntermData) HashAggFinalWorker {
+	groupSet, setSize := set.NewStringSetWithMemoryUsage()
+	w := HashAggFinalWorker{
+		baseHashAggWorker:   newBaseHashAggWorker(e.ctx, e.finishCh, e.FinalAggFuncs, e.maxChunkSize, e.memTracker),
+		partialResultMap:    make(aggPartialResultMapper),
+		groupSet:            groupSet,
+		inputCh:             inputCh,
+		outputCh:            e.finalOutputCh,
+		finalResultHolderCh: make(chan *chunk.Chunk, 1),
+		rowBuffer:           make([]types.Datum, 0, e.Schema().Len()),
+		mutableRow:          chunk.MutRowFromTypes(retTypes(e)),
+		groupKeys:           make([][]byte, 0, 8),
+	}
+	// There is a bucket in the empty partialResultsMap.
+	e.memTracker.Consume(defBucketMemoryUsage*(1<<w.BInMap) + setSize)
+	return w
+}
+
 func (w *HashAggPartialWorker) getChildInput() bool {
 	select {
 	case <-w.finishCh:
@@ -462,6 +513,9 @@ func (w *HashAggPartialWorker) updatePartialResult(ctx sessionctx.Context, sc *s
 	if err != nil {
 		return err
 	}
+	if w.skewSampler != nil {
+		w.skewSampler.sample(w.groupKey, chk.NumRows())
+	}
 
 	partialResults := w.getPartialResult(sc, w.groupKey, w.partialResultsMap)
 	numRows := chk.NumRows()
@@ -483,9 +537,20 @@ func (w *HashAggPartialWorker) updatePartialResult(ctx sessionctx.Context, sc *s
 
 // shuffleIntermData shuffles the intermediate data of partial workers to corresponded final workers.
 // We only support parallel execution for single-machine, so process of encode and decode can be skipped.
+// In the two level hash aggregation, the heavy hitters are sent to the final worker of this partial worker
+// instead of the one chosen by the hash of the group key, so they are spread over the final workers.
 func (w *HashAggPartialWorker) shuffleIntermData(sc *stmtctx.StatementContext, finalConcurrency int) {
+	var heavyHitters map[string]struct{}
+	if w.skewSampler != nil {
+		heavyHitters = w.skewSampler.getHeavyHitters()
+	}
+	var heavyHitterKeys []string
 	groupKeysSlice := make([][]string, finalConcurrency)
 	for groupKey := range w.partialResultsMap {
+		if _, ok := heavyHitters[groupKey]; ok {
+			heavyHitterKeys = append(heavyHitterKeys, groupKey)
+			continue
+		}
 		finalWorkerIdx := int(murmur3.Sum32([]byte(groupKey))) % finalConcurrency
 		if groupKeysSlice[finalWorkerIdx] == nil {
 			groupKeysSlice[finalWorkerIdx] = make([]string, 0, len(w.partialResultsMap)/finalConcurrency)
@@ -502,6 +567,13 @@ func (w *HashAggPartialWorker) shuffleIntermData(sc *stmtctx.StatementContext, f
 			partialResultMap: w.partialResultsMap,
 		}
 	}
+	if len(heavyHitterKeys) > 0 {
+		w.outputChs[w.heavyHitterOutputIdx] <- &HashAggIntermData{
+			groupKeys:        heavyHitterKeys,
+			partialResultMap: w.partialResultsMap,
+			isHeavyHitter:    true,
+		}
+	}
 }
 
 // getGroupKey evaluates the group items and args of aggregate functions.
@@ -613,10 +685,15 @@ func (w *HashAggFinalWorker) consumeIntermData(sctx sessionctx.Context) (err err
 			}
 			failpoint.Inject("ConsumeRandomPanic", nil)
 			w.memTracker.Consume(getGroupKeyMemUsage(w.groupKeys) - memSize)
-			finalPartialResults := w.getPartialResult(sc, w.groupKeys, w.partialResultMap)
+			resultMap := w.partialResultMap
+			if input.isHeavyHitter {
+				resultMap = w.heavyHitterResultMap
+			}
+			finalPartialResults := w.getPartialResult(sc, w.groupKeys, resultMap)
 			allMemDelta := int64(0)
 			for i, groupKey := range groupKeys {
-				if !w.groupSet.Exist(groupKey) {
+				// The heavy hitters are finalized by the second level, so they are not in the groupSet.
+				if !input.isHeavyHitter && !w.groupSet.Exist(groupKey) {
 					allMemDelta += w.groupSet.Insert(groupKey)
 				}
 				prs := intermDataBuffer[i]
@@ -701,9 +778,26 @@ func (w *HashAggFinalWorker) run(ctx sessionctx.Context, waitGroup *sync.WaitGro
 	if err := w.consumeIntermData(ctx); err != nil {
 		w.outputCh <- &AfFinalResult{err: err}
 	}
+	w.sendHeavyHitters()
 	w.getFinalResult(ctx)
 }
 
+// sendHeavyHitters sends the merged heavy hitters of this worker to the second level final worker.
+func (w *HashAggFinalWorker) sendHeavyHitters() {
+	if len(w.heavyHitterResultMap) == 0 {
+		return
+	}
+	groupKeys := make([]string, 0, len(w.heavyHitterResultMap))
+	for groupKey := range w.heavyHitterResultMap {
+		groupKeys = append(groupKeys, groupKey)
+	}
+	// The channel is buffered with the number of final workers, so it never blocks.
+	w.heavyHitterOutputCh <- &HashAggIntermData{
+		groupKeys:        groupKeys,
+		partialResultMap: w.heavyHitterResultMap,
+	}
+}
+
 // Next implements the Executor Next interface.
 func (e *HashAggExec) Next(ctx context.Context, req *chunk.Chunk) error {
 	req.Reset()
@@ -803,10 +897,19 @@ func (e *HashAggExec) prepare4ParallelExec(ctx context.Context) {
 			atomic.AddInt64(&e.stats.FinalWallTime, int64(time.Since(finalStart)))
 		}
 	}()
+	heavyHitterWorkerWaitGroup := &sync.WaitGroup{}
+	if e.heavyHitterWorker != nil {
+		heavyHitterWorkerWaitGroup.Add(1)
+		go e.heavyHitterWorker.run(e.ctx, heavyHitterWorkerWaitGroup)
+		go func() {
+			finalWorkerWaitGroup.Wait()
+			close(e.heavyHitterCh)
+		}()
+	}
 
 	// All workers may send error message to e.finalOutputCh when they panic.
 	// And e.finalOutputCh should be closed after all goroutines gone.
-	go e.waitAllWorkersAndCloseFinalOutputCh(fetchChildWorkerWaitGroup, partialWorkerWaitGroup, finalWorkerWaitGroup)
+	go e.waitAllWorkersAndCloseFinalOutputCh(fetchChildWorkerWaitGroup, partialWorkerWaitGroup, finalWorkerWaitGroup, heavyHitterWorkerWaitGroup)
 }
 
 // HashAggExec employs one input reader, M partial workers and N final workers to execute parallelly.
@@ -814,6 +917,8 @@ func (e *HashAggExec) prepare4ParallelExec(ctx context.Context) {
 // 1. input reader reads data from child executor and send them to partial workers.
 // 2. partial worker receives the input data, updates the partial results, and shuffle the partial results to the final workers.
 // 3. final worker receives partial results from all the partial workers, evaluates the final results and sends the final results to the main thread.
+// If the two level hash aggregation is enabled, the heavy hitter group keys are spread over the final workers in step 2,
+// and the final workers send their partial results of them to a second level final worker which evaluates their final results.
 func (e *HashAggExec) parallelExec(ctx context.Context, chk *chunk.Chunk) error {
 	if !e.prepared {
 		e.prepare4ParallelExec(ctx)
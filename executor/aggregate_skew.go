@@ -0,0 +1,78 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"sync"
+)
+
+const (
+	// hashAggSkewSampleRows is the number of input rows sampled to detect the heavy hitter group keys.
+	hashAggSkewSampleRows = 8192
+	// hashAggSkewMinSampleRows is the minimum number of sampled rows to trust the sample,
+	// no key is regarded as a heavy hitter if fewer rows are sampled.
+	hashAggSkewMinSampleRows = 256
+)
+
+// hashAggSkewSampler samples the group keys of the input rows of the partial workers to detect the heavy hitters.
+// A group key is a heavy hitter if it alone takes at least the share of one final worker in the sample.
+// The heavy hitters are frozen when the first partial worker shuffles its data, so all the partial workers
+// see the same heavy hitters and a group key is never merged by both levels.
+type hashAggSkewSampler struct {
+	mu               sync.Mutex
+	finalConcurrency int
+	counts           map[string]int
+	sampledRows      int
+	frozen           bool
+	heavyHitters     map[string]struct{}
+}
+
+func newHashAggSkewSampler(finalConcurrency int) *hashAggSkewSampler {
+	return &hashAggSkewSampler{
+		finalConcurrency: finalConcurrency,
+		counts:           make(map[string]int),
+	}
+}
+
+// sample counts the group keys of the first numRows rows until enough rows are sampled.
+func (s *hashAggSkewSampler) sample(groupKey [][]byte, numRows int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < numRows && !s.frozen && s.sampledRows < hashAggSkewSampleRows; i++ {
+		s.counts[string(groupKey[i])]++
+		s.sampledRows++
+	}
+}
+
+// getHeavyHitters freezes the sample and returns the heavy hitter group keys.
+func (s *hashAggSkewSampler) getHeavyHitters() map[string]struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.frozen {
+		return s.heavyHitters
+	}
+	s.frozen = true
+	if s.sampledRows >= hashAggSkewMinSampleRows {
+		for key, cnt := range s.counts {
+			if cnt*s.finalConcurrency >= s.sampledRows {
+				if s.heavyHitters == nil {
+					s.heavyHitters = make(map[string]struct{})
+				}
+				s.heavyHitters[key] = struct{}{}
+			}
+		}
+	}
+	s.counts = nil
+	return s.heavyHitters
+}
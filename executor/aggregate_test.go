@@ -26,11 +26,17 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/errors"
 	"github.com/pingcap/failpoint"
+	"github.com/pingcap/parser/mysql"
 	"github.com/pingcap/parser/terror"
 	"github.com/pingcap/tidb/executor"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/expression/aggregation"
 	plannercore "github.com/pingcap/tidb/planner/core"
 	"github.com/pingcap/tidb/session"
+	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/chunk"
 	"github.com/pingcap/tidb/util/sqlexec"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/testutil"
@@ -1461,3 +1467,96 @@ func (s *testSuiteAgg) TestIssue23314(c *C) {
 	res := tk.MustQuery("select col1 from t1 group by col1")
 	res.Check(testkit.Rows("16:40:20.01"))
 }
+
+func (s *testSuiteAgg) TestTwoLevelHashAgg(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int, b int)")
+	// Most rows fall into the groups 1 and 2.
+	var values []string
+	for i := 0; i < 2000; i++ {
+		a := i % 2
+		if i%10 == 0 {
+			a = i
+		}
+		values = append(values, fmt.Sprintf("(%d, %d)", a+1, i))
+	}
+	tk.MustExec("insert into t values " + strings.Join(values, ","))
+	tk.MustExec("set @@tidb_hashagg_partial_concurrency = 4")
+	tk.MustExec("set @@tidb_hashagg_final_concurrency = 4")
+	tk.MustExec("set @@tidb_max_chunk_size = 32")
+
+	sqls := []string{
+		"select /*+ hash_agg() */ a, count(*), sum(b), avg(b), max(b), min(b) from t group by a",
+		"select /*+ hash_agg() */ count(*), sum(b) from t",
+		"select /*+ hash_agg() */ a, count(*) from t where b < 0 group by a",
+	}
+	for _, sql := range sqls {
+		tk.MustExec("set @@tidb_enable_two_level_hashagg = 0")
+		expected := tk.MustQuery(sql).Sort().Rows()
+		tk.MustExec("set @@tidb_enable_two_level_hashagg = 1")
+		tk.MustQuery(sql).Sort().Check(expected)
+	}
+	tk.MustExec("set @@tidb_enable_two_level_hashagg = 0")
+	tk.MustQuery("select /*+ hash_agg() set_var(tidb_enable_two_level_hashagg=1) */ a, count(*) from t where a < 3 group by a").Sort().Check(testkit.Rows("1 801", "2 1000"))
+}
+
+type testUserDefinedSum struct {
+	arg expression.Expression
+}
+
+func (f *testUserDefinedSum) Init() (interface{}, int64) {
+	return new(int64), 8
+}
+
+func (f *testUserDefinedSum) Update(ctx sessionctx.Context, rows []chunk.Row, pr interface{}) (int64, error) {
+	for _, row := range rows {
+		v, isNull, err := f.arg.EvalInt(ctx, row)
+		if err != nil {
+			return 0, err
+		}
+		if !isNull {
+			*pr.(*int64) += v
+		}
+	}
+	return 0, nil
+}
+
+func (f *testUserDefinedSum) Merge(ctx sessionctx.Context, src, dst interface{}) (int64, error) {
+	*dst.(*int64) += *src.(*int64)
+	return 0, nil
+}
+
+func (f *testUserDefinedSum) Finalize(ctx sessionctx.Context, pr interface{}) (types.Datum, error) {
+	return types.NewIntDatum(*pr.(*int64)), nil
+}
+
+func (s *testSuiteAgg) TestUserDefinedAggFunc(c *C) {
+	def := &aggregation.UserDefinedAggFuncDef{
+		InferType: func(ctx sessionctx.Context, args []expression.Expression) (*types.FieldType, error) {
+			return types.NewFieldType(mysql.TypeLonglong), nil
+		},
+		New: func(args []expression.Expression, retTp *types.FieldType) aggregation.UserDefinedAggFunc {
+			return &testUserDefinedSum{arg: args[0]}
+		},
+	}
+	c.Assert(aggregation.RegisterUserDefinedAggFunc("Test_UDAF_Sum", def), IsNil)
+	c.Assert(aggregation.RegisterUserDefinedAggFunc("test_udaf_sum", def), NotNil)
+	c.Assert(aggregation.RegisterUserDefinedAggFunc("abs", def), NotNil)
+
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int, b int)")
+	tk.MustExec("insert into t values (1, 1), (1, 2), (2, 3), (2, null), (3, null)")
+	for _, concurrency := range []int{1, 4} {
+		tk.MustExec(fmt.Sprintf("set @@tidb_hashagg_partial_concurrency = %d", concurrency))
+		tk.MustExec(fmt.Sprintf("set @@tidb_hashagg_final_concurrency = %d", concurrency))
+		tk.MustQuery("select a, test_udaf_sum(b) from t group by a order by a").Check(testkit.Rows("1 3", "2 3", "3 0"))
+		tk.MustQuery("select /*+ stream_agg() */ a, test_udaf_sum(b + 1) from t group by a order by a").Check(testkit.Rows("1 5", "2 4", "3 0"))
+	}
+	tk.MustQuery("select test_udaf_sum(b) from t").Check(testkit.Rows("6"))
+	tk.MustQuery("select test_udaf_sum(b) from t where a > 3").Check(testkit.Rows("<nil>"))
+	tk.MustQuery("select a from t group by a having test_udaf_sum(b) > 0 order by a").Check(testkit.Rows("1", "2"))
+}
@@ -45,6 +45,8 @@ import (
 	"github.com/pingcap/tidb/sessionctx/stmtctx"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/statistics"
+	"github.com/pingcap/tidb/store/copr"
+	"github.com/pingcap/tidb/store/driver/backoff"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/types"
@@ -176,6 +178,13 @@ func (e *AnalyzeExec) Next(ctx context.Context, req *chunk.Chunk) error {
 				continue
 			}
 		}
+		// Saving the histograms resets the sample rate in stats_meta, so it's saved after them.
+		if result.SampleRate > 0 && len(result.Hist) > 0 {
+			if err1 := statsHandle.SaveSampleRateToStorage(statisticsID, result.SampleRate); err1 != nil {
+				err = err1
+				logutil.Logger(ctx).Error("save sample rate to storage failed", zap.Error(err))
+			}
+		}
 		if err1 := statsHandle.SaveExtendedStatsToStorage(statisticsID, result.ExtStats, false); err1 != nil {
 			err = err1
 			logutil.Logger(ctx).Error("save extended stats to storage failed", zap.Error(err))
@@ -610,14 +619,15 @@ func analyzeColumnsPushdown(colExec *AnalyzeColumnsExec) []analyzeResult {
 		}
 		cLen := len(colExec.analyzePB.ColReq.ColumnsInfo)
 		colGroupResult := analyzeResult{
-			TableID:  colExec.TableID,
-			Hist:     hists[cLen:],
-			TopNs:    topns[cLen:],
-			Fms:      fmSketches[cLen:],
-			job:      colExec.job,
-			StatsVer: colExec.StatsVersion,
-			Count:    count,
-			IsIndex:  1,
+			TableID:    colExec.TableID,
+			Hist:       hists[cLen:],
+			TopNs:      topns[cLen:],
+			Fms:        fmSketches[cLen:],
+			job:        colExec.job,
+			StatsVer:   colExec.StatsVersion,
+			Count:      count,
+			IsIndex:    1,
+			SampleRate: colExec.sampleRate,
 		}
 		// Discard stats of _tidb_rowid.
 		// Because the process of analyzing will keep the order of results be the same as the colsInfo in the analyze task,
@@ -628,14 +638,15 @@ func analyzeColumnsPushdown(colExec *AnalyzeColumnsExec) []analyzeResult {
 			cLen -= 1
 		}
 		colResult := analyzeResult{
-			TableID:  colExec.TableID,
-			Hist:     hists[:cLen],
-			TopNs:    topns[:cLen],
-			Fms:      fmSketches[:cLen],
-			ExtStats: extStats,
-			job:      colExec.job,
-			StatsVer: colExec.StatsVersion,
-			Count:    count,
+			TableID:    colExec.TableID,
+			Hist:       hists[:cLen],
+			TopNs:      topns[:cLen],
+			Fms:        fmSketches[:cLen],
+			ExtStats:   extStats,
+			job:        colExec.job,
+			StatsVer:   colExec.StatsVersion,
+			Count:      count,
+			SampleRate: colExec.sampleRate,
 		}
 
 		return []analyzeResult{colResult, colGroupResult}
@@ -718,6 +729,12 @@ type AnalyzeColumnsExec struct {
 	indexes       []*model.IndexInfo
 	core.AnalyzeInfo
 
+	// sampleRate is the rate of the regions scanned by the analyze of version 2, 0 means all the regions are scanned.
+	sampleRate float64
+	// totalRegions and scannedRegions are the numbers of the regions of the table and the ones sampled to scan.
+	totalRegions   int
+	scannedRegions int
+
 	subIndexWorkerWg  *sync.WaitGroup
 	samplingBuilderWg *sync.WaitGroup
 	samplingMergeWg   *sync.WaitGroup
@@ -761,6 +778,12 @@ func (e *AnalyzeColumnsExec) buildResp(ranges []*ranger.Range) (distsql.SelectRe
 	if err != nil {
 		return nil, err
 	}
+	if e.sampleRate > 0 && e.sampleRate < 1 {
+		kvReq.KeyRanges, err = e.sampleRegions(kvReq.KeyRanges)
+		if err != nil {
+			return nil, err
+		}
+	}
 	ctx := context.TODO()
 	result, err := distsql.Analyze(ctx, e.ctx.GetClient(), kvReq, e.ctx.GetSessionVars().KVVars, e.ctx.GetSessionVars().InRestrictedSQL, e.ctx.GetSessionVars().StmtCtx.MemTracker)
 	if err != nil {
@@ -769,6 +792,60 @@ func (e *AnalyzeColumnsExec) buildResp(ranges []*ranger.Range) (distsql.SelectRe
 	return result, nil
 }
 
+// sampleRegions splits the ranges by the regions and returns the ranges of the regions sampled by the sample rate, so
+// only the sampled regions are scanned by the coprocessor. At least one region is sampled.
+func (e *AnalyzeColumnsExec) sampleRegions(ranges []kv.KeyRange) ([]kv.KeyRange, error) {
+	store, ok := e.ctx.GetStore().(tikv.Storage)
+	if !ok {
+		e.totalRegions += len(ranges)
+		e.scannedRegions += len(ranges)
+		return ranges, nil
+	}
+	bo := backoff.NewBackofferWithVars(context.Background(), 10000, nil)
+	locations, err := copr.NewRegionCache(store.GetRegionCache()).SplitKeyRangesByLocations(bo, copr.NewKeyRanges(ranges))
+	if err != nil {
+		return nil, err
+	}
+	sampled := int(math.Ceil(float64(len(locations)) * e.sampleRate))
+	e.totalRegions += len(locations)
+	e.scannedRegions += sampled
+	// Keep the order of the sampled regions, the ranges of the request are required to be ordered.
+	picked := rand.Perm(len(locations))[:sampled]
+	sort.Ints(picked)
+	sampledRanges := make([]kv.KeyRange, 0, len(picked))
+	for _, i := range picked {
+		locations[i].Ranges.Do(func(ran *kv.KeyRange) {
+			sampledRanges = append(sampledRanges, *ran)
+		})
+	}
+	return sampledRanges, nil
+}
+
+// scaleBySampledRegions scales the counts and the sizes collected from the sampled regions to the whole table.
+func (e *AnalyzeColumnsExec) scaleBySampledRegions(collector *statistics.RowSampleCollector) {
+	if e.scannedRegions == 0 || e.scannedRegions == e.totalRegions {
+		return
+	}
+	scale := func(val int64) int64 {
+		return int64(math.Round(float64(val) * float64(e.totalRegions) / float64(e.scannedRegions)))
+	}
+	collector.Count = scale(collector.Count)
+	for i := range collector.NullCount {
+		collector.NullCount[i] = scale(collector.NullCount[i])
+		collector.TotalSizes[i] = scale(collector.TotalSizes[i])
+	}
+}
+
+// scaleNDVBySampledRegions estimates the NDV of the whole table from the NDV of the sampled regions. The values which
+// are unique in the sampled regions are assumed to be unique in the whole table, otherwise all the values are assumed
+// to be seen in the sampled regions.
+func (e *AnalyzeColumnsExec) scaleNDVBySampledRegions(hist *statistics.Histogram, scannedNotNullCount int64) {
+	if e.scannedRegions == 0 || e.scannedRegions == e.totalRegions || hist.NDV < scannedNotNullCount {
+		return
+	}
+	hist.NDV = int64(math.Round(float64(hist.NDV) * float64(e.totalRegions) / float64(e.scannedRegions)))
+}
+
 // decodeSampleDataWithVirtualColumn constructs the virtual column by evaluating from the deocded normal columns.
 // If it failed, it would return false to trigger normal decoding way without the virtual column.
 func (e AnalyzeColumnsExec) decodeSampleDataWithVirtualColumn(
@@ -880,6 +957,11 @@ func (e *AnalyzeColumnsExec) buildSamplingStats(
 	if err != nil {
 		return 0, nil, nil, nil, nil, err
 	}
+	scannedNotNullCounts := make([]int64, l)
+	for i := range scannedNotNullCounts {
+		scannedNotNullCounts[i] = rootRowCollector.Count - rootRowCollector.NullCount[i]
+	}
+	e.scaleBySampledRegions(rootRowCollector)
 
 	// handling virtual columns
 	virtualColIdx := buildVirtualColumnIndex(e.schemaForVirtualColEval, e.colsInfo)
@@ -925,6 +1007,29 @@ func (e *AnalyzeColumnsExec) buildSamplingStats(
 		return rootRowCollector.Samples[i].Handle.Compare(rootRowCollector.Samples[j].Handle) < 0
 	})
 
+	indexPushedDownResult := <-idxNDVPushDownCh
+	if indexPushedDownResult.err != nil {
+		return 0, nil, nil, nil, nil, indexPushedDownResult.err
+	}
+	for _, offset := range indexesWithVirtualColOffsets {
+		ret := indexPushedDownResult.results[e.indexes[offset].ID]
+		rootRowCollector.NullCount[colLen+offset] = ret.Count
+		rootRowCollector.FMSketches[colLen+offset] = ret.Fms[0]
+	}
+	// The virtual columns are not evaluated by TiKV, so the NDVs, null counts and sizes collected for them are wrong.
+	// The virtual columns which are the single columns of indexes, such as the hidden columns of the expression
+	// indexes, take the NDVs and null counts of the indexes, and their histograms are built on the samples evaluated
+	// above. The other virtual columns have no stats.
+	virtualColIndexes := e.virtualColumnIndexes(indexesWithVirtualColOffsets)
+	for colOffset, idxOffset := range virtualColIndexes {
+		rootRowCollector.NullCount[colOffset] = rootRowCollector.NullCount[colLen+idxOffset]
+		rootRowCollector.FMSketches[colOffset] = rootRowCollector.FMSketches[colLen+idxOffset].Copy()
+		rootRowCollector.TotalSizes[colOffset], err = estimateTotalSizeBySamples(sc, rootRowCollector, colOffset)
+		if err != nil {
+			return 0, nil, nil, nil, nil, err
+		}
+	}
+
 	totalLen := len(e.colsInfo) + len(e.indexes)
 	hists = make([]*statistics.Histogram, totalLen)
 	topns = make([]*statistics.TopN, totalLen)
@@ -939,6 +1044,11 @@ func (e *AnalyzeColumnsExec) buildSamplingStats(
 	}
 
 	for i, col := range e.colsInfo {
+		fmSketches = append(fmSketches, rootRowCollector.FMSketches[i])
+		if _, ok := virtualColIndexes[i]; col.IsGenerated() && !col.GeneratedStored && !ok {
+			// Leave the histogram nil to skip the virtual column.
+			continue
+		}
 		buildTaskChan <- &samplingBuildTask{
 			id:               col.ID,
 			rootRowCollector: rootRowCollector,
@@ -946,17 +1056,6 @@ func (e *AnalyzeColumnsExec) buildSamplingStats(
 			isColumn:         true,
 			slicePos:         i,
 		}
-		fmSketches = append(fmSketches, rootRowCollector.FMSketches[i])
-	}
-
-	indexPushedDownResult := <-idxNDVPushDownCh
-	if indexPushedDownResult.err != nil {
-		return 0, nil, nil, nil, nil, indexPushedDownResult.err
-	}
-	for _, offset := range indexesWithVirtualColOffsets {
-		ret := indexPushedDownResult.results[e.indexes[offset].ID]
-		rootRowCollector.NullCount[colLen+offset] = ret.Count
-		rootRowCollector.FMSketches[colLen+offset] = ret.Fms[0]
 	}
 
 	// build index stats
@@ -988,6 +1087,19 @@ func (e *AnalyzeColumnsExec) buildSamplingStats(
 	if err != nil {
 		return 0, nil, nil, nil, nil, err
 	}
+	// The NDVs of the special indexes and their virtual columns are collected by scanning the whole indexes.
+	fullyScanned := make(map[int]struct{}, len(indexesWithVirtualColOffsets)+len(virtualColIndexes))
+	for _, offset := range indexesWithVirtualColOffsets {
+		fullyScanned[colLen+offset] = struct{}{}
+	}
+	for colOffset := range virtualColIndexes {
+		fullyScanned[colOffset] = struct{}{}
+	}
+	for i, hist := range hists {
+		if _, ok := fullyScanned[i]; !ok && hist != nil {
+			e.scaleNDVBySampledRegions(hist, scannedNotNullCounts[i])
+		}
+	}
 	count = rootRowCollector.Count
 	if needExtStats {
 		statsHandle := domain.GetDomain(e.ctx).StatsHandle()
@@ -999,6 +1111,39 @@ func (e *AnalyzeColumnsExec) buildSamplingStats(
 	return
 }
 
+// virtualColumnIndexes returns the offsets of the virtual columns which are the single columns of the indexes, and
+// the offsets of the indexes in e.indexes. The indexes with virtual columns are in indexesWithVirtualColOffsets.
+func (e *AnalyzeColumnsExec) virtualColumnIndexes(indexesWithVirtualColOffsets []int) map[int]int {
+	virtualColIndexes := make(map[int]int)
+	for _, offset := range indexesWithVirtualColOffsets {
+		idx := e.indexes[offset]
+		if len(idx.Columns) != 1 || idx.Columns[0].Length != types.UnspecifiedLength {
+			continue
+		}
+		colInfo := e.colsInfo[idx.Columns[0].Offset]
+		if colInfo.IsGenerated() && !colInfo.GeneratedStored {
+			virtualColIndexes[idx.Columns[0].Offset] = offset
+		}
+	}
+	return virtualColIndexes
+}
+
+// estimateTotalSizeBySamples estimates the total size of the column by the sizes of its values in the samples.
+func estimateTotalSizeBySamples(sc *stmtctx.StatementContext, collector *statistics.RowSampleCollector, colOffset int) (int64, error) {
+	if len(collector.Samples) == 0 {
+		return 0, nil
+	}
+	var size int64
+	for _, sample := range collector.Samples {
+		valSize, err := codec.EstimateValueSize(sc, sample.Columns[colOffset])
+		if err != nil {
+			return 0, err
+		}
+		size += int64(valSize)
+	}
+	return int64(float64(size) * float64(collector.Count) / float64(len(collector.Samples))), nil
+}
+
 type analyzeIndexNDVTotalResult struct {
 	results map[int64]analyzeResult
 	err     error
@@ -1258,11 +1403,6 @@ workLoop:
 		}
 		var collector *statistics.SampleCollector
 		if task.isColumn {
-			if e.colsInfo[task.slicePos].IsGenerated() && !e.colsInfo[task.slicePos].GeneratedStored {
-				hists[task.slicePos] = nil
-				topns[task.slicePos] = nil
-				continue
-			}
 			sampleItems := make([]*statistics.SampleItem, 0, task.rootRowCollector.MaxSampleSize)
 			for j, row := range task.rootRowCollector.Samples {
 				if row.Columns[task.slicePos].IsNull() {
@@ -2212,4 +2352,6 @@ type analyzeResult struct {
 	Err      error
 	job      *statistics.AnalyzeJob
 	StatsVer int
+	// SampleRate is the rate of the regions scanned to build the stats, 0 means all the regions are scanned.
+	SampleRate float64
 }
@@ -1012,6 +1012,60 @@ func (s *testSuite1) TestAnalyzeFullSamplingOnIndexWithVirtualColumnOrPrefixColu
 	tk.MustQuery("show stats_topn where table_name = 'sampling_index_prefix_col' and column_name = 'idx'").Check(testkit.Rows("test sampling_index_prefix_col  idx 1 a 3"))
 }
 
+func (s *testSuite1) TestAnalyzeFullSamplingOnVirtualColumn(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int, b int as (a+1), c int as (a+2), index idx(b), index expr_idx((a*2)))")
+	tk.MustExec("insert into t (a) values (1), (2), (null), (3), (4), (null), (5), (5), (5), (5)")
+	tk.MustExec("set @@session.tidb_analyze_version = 2")
+	tk.MustExec("analyze table t")
+	// The virtual columns indexed by the single column indexes have the stats, but the other ones don't.
+	rows := tk.MustQuery("show stats_histograms where table_name = 't' and is_index = 0").Sort().Rows()
+	c.Assert(len(rows), Equals, 3)
+	c.Assert(rows[0][3], Equals, "_V$_expr_idx_0")
+	c.Assert(rows[1][3], Equals, "a")
+	c.Assert(rows[2][3], Equals, "b")
+	for _, row := range rows {
+		// The NDV.
+		c.Assert(row[6], Equals, "5")
+		// The NULLs.
+		c.Assert(row[7], Equals, "2")
+	}
+}
+
+func (s *testSuite1) TestAnalyzeWithSampleRate(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int primary key clustered, b int)")
+	values := make([]string, 0, 200)
+	for i := 0; i < 200; i++ {
+		values = append(values, fmt.Sprintf("(%d, %d)", i*100, i%10))
+	}
+	tk.MustExec("insert into t values " + strings.Join(values, ", "))
+	tk.MustQuery("split table t between (0) and (20000) regions 10").Check(testkit.Rows("9 1"))
+	tk.MustExec("set @@session.tidb_analyze_version = 2")
+	tk.MustExec("analyze table t")
+	tk.MustQuery("select sample_rate from mysql.stats_meta where table_id = (select tidb_table_id from information_schema.tables where table_schema = 'test' and table_name = 't')").Check(testkit.Rows("0"))
+	tk.MustExec("set @@session.tidb_analyze_sample_rate = 0.1")
+	defer tk.MustExec("set @@session.tidb_analyze_sample_rate = default")
+	tk.MustExec("analyze table t")
+	tk.MustQuery("select count(*) from information_schema.analyze_status where table_name = 't' and job_info = 'analyze table with sample rate 0.1'").Check(testkit.Rows("1"))
+	// Only 1 of the 10 regions is scanned, but the row count is still the one of the whole table.
+	tk.MustQuery("select count, sample_rate from mysql.stats_meta where table_id = (select tidb_table_id from information_schema.tables where table_schema = 'test' and table_name = 't')").Check(testkit.Rows("200 0.1"))
+	// The samples of the unique column all come from the scanned region, and its NDV is scaled to the whole table.
+	buckets := tk.MustQuery("show stats_buckets where table_name = 't' and column_name = 'a'").Rows()
+	c.Assert(len(buckets) > 0, IsTrue)
+	lower, err := strconv.Atoi(buckets[0][8].(string))
+	c.Assert(err, IsNil)
+	upper, err := strconv.Atoi(buckets[len(buckets)-1][9].(string))
+	c.Assert(err, IsNil)
+	c.Assert(lower/2000, Equals, upper/2000)
+	// The NDV of the column with repeated values isn't scaled.
+	tk.MustQuery("select distinct_count from mysql.stats_histograms where table_id = (select tidb_table_id from information_schema.tables where table_schema = 'test' and table_name = 't') and is_index = 0 order by hist_id").Check(testkit.Rows("200", "10"))
+}
+
 func (s *testSuite2) TestAnalyzeSamplingWorkPanic(c *C) {
 	tk := testkit.NewTestKit(c, s.store)
 	tk.MustExec("use test")
@@ -15,6 +15,7 @@ package executor_test
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -359,3 +360,38 @@ func (s *testBatchPointGetSuite) TestBatchPointGetIssue25167(c *C) {
 	tk.MustExec("insert into t values (1)")
 	tk.MustQuery("select * from t as of timestamp @a where a in (1,2,3)").Check(testkit.Rows())
 }
+
+func (s *testBatchPointGetSuite) TestPointRangesBatchGet(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("set @@tidb_partition_prune_mode = 'static'")
+	tk.MustExec("drop table if exists t, t1")
+	tk.MustExec("create table t (a int primary key, b int) partition by range (a) (partition p0 values less than (10), partition p1 values less than (maxvalue))")
+	tk.MustExec("insert into t values (1, 1), (3, 3), (5, 5), (12, 12), (15, 15), (18, 18)")
+	tk.MustExec("create table t1 (a varchar(10), b int, c int, primary key (a, b) clustered) partition by range (b) (partition p0 values less than (10), partition p1 values less than (maxvalue))")
+	tk.MustExec("insert into t1 values ('a', 1, 1), ('b', 3, 3), ('c', 12, 12), ('d', 15, 15)")
+	// The point ranges of the range partitions are read by the table readers instead of the batch point get.
+	query := "select * from t where a in (1, 2, 3, 5, 12, 13) or a = 18"
+	check := func() {
+		tk.MustQuery(query).Sort().Check(testkit.Rows("1 1", "12 12", "18 18", "3 3", "5 5"))
+		tk.MustQuery("select * from t where a in (1, 3, 5, 12) or a = 18 order by a desc").Check(testkit.Rows("18 18", "12 12", "5 5", "3 3", "1 1"))
+		tk.MustQuery("select * from t1 where (a, b) in (('a', 1), ('b', 3), ('c', 4)) or (a, b) = ('d', 15)").Sort().Check(testkit.Rows("a 1 1", "b 3 3", "d 15 15"))
+	}
+	check()
+	tk.MustExec("set @@tidb_point_ranges_batch_get_size = 2")
+	check()
+	rows := tk.MustQuery("explain analyze " + query).Rows()
+	readers := 0
+	for _, row := range rows {
+		if strings.Contains(row[0].(string), "TableReader") {
+			c.Assert(row[5].(string), Matches, ".*BatchGet:.*")
+			readers++
+		}
+	}
+	c.Assert(readers, Equals, 2)
+	tk.MustExec("begin")
+	tk.MustExec("insert into t values (2, 2)")
+	tk.MustExec("delete from t where a = 3")
+	tk.MustQuery("select * from t where a in (1, 2, 3) or a = 5").Sort().Check(testkit.Rows("1 1", "2 2", "5 5"))
+	tk.MustExec("rollback")
+}
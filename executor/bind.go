@@ -89,11 +89,23 @@ func (e *SQLBindExec) createSQLBind() error {
 		Db:          e.db,
 		Bindings:    []bindinfo.Binding{bindInfo},
 	}
+	sessionHandle := e.ctx.Value(bindinfo.SessionBindInfoKeyType).(*bindinfo.SessionHandle)
+	globalHandle := domain.GetDomain(e.ctx).BindHandle()
+	var err error
 	if !e.isGlobal {
-		handle := e.ctx.Value(bindinfo.SessionBindInfoKeyType).(*bindinfo.SessionHandle)
-		return handle.CreateBindRecord(e.ctx, record)
+		err = sessionHandle.CreateBindRecord(e.ctx, record)
+	} else {
+		err = globalHandle.CreateBindRecord(e.ctx, record)
+	}
+	if err != nil || !record.IsWildcard() {
+		return err
 	}
-	return domain.GetDomain(e.ctx).BindHandle().CreateBindRecord(e.ctx, record)
+	// The bindings of the databases in both scopes take priority over the cross-database binding.
+	bindRecords := append(sessionHandle.GetAllBindRecord(), globalHandle.GetAllBindRecord()...)
+	for _, shadowing := range bindinfo.ShadowingRecords(record, bindRecords) {
+		e.ctx.GetSessionVars().StmtCtx.AppendWarning(errors.Errorf("the binding for %s takes priority over the cross-database binding", shadowing.OriginalSQL))
+	}
+	return nil
 }
 
 func (e *SQLBindExec) flushBindings() error {
@@ -16,7 +16,9 @@ package executor
 import (
 	"bytes"
 	"context"
+	"math"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -782,6 +784,7 @@ func (b *executorBuilder) buildInsert(v *plannercore.Insert) Executor {
 		Lists:                     v.Lists,
 		SetList:                   v.SetList,
 		GenExprs:                  v.GenCols.Exprs,
+		SecurityPolicyConds:       v.SecurityPolicyConds,
 		allAssignmentsAreConstant: v.AllAssignmentsAreConstant,
 		hasRefCols:                v.NeedFillDefaultValue,
 		SelectExec:                selectExec,
@@ -1051,6 +1054,11 @@ func (b *executorBuilder) buildMergeJoin(v *plannercore.PhysicalMergeJoin) Execu
 		return nil
 	}
 
+	if v.SortChildren {
+		leftExec = b.buildMergeJoinChildSorter(leftExec, v.LeftJoinKeys, v.Desc)
+		rightExec = b.buildMergeJoinChildSorter(rightExec, v.RightJoinKeys, v.Desc)
+	}
+
 	defaultValues := v.DefaultValues
 	if defaultValues == nil {
 		if v.JoinType == plannercore.RightOuterJoin {
@@ -1109,6 +1117,20 @@ func (b *executorBuilder) buildMergeJoin(v *plannercore.PhysicalMergeJoin) Execu
 	return e
 }
 
+// buildMergeJoinChildSorter wraps the child of the merge join with a SortExec sorting it by the join keys, which
+// spills the rows to the disk when they exceed the memory quota.
+func (b *executorBuilder) buildMergeJoinChildSorter(child Executor, joinKeys []*expression.Column, desc bool) Executor {
+	byItems := make([]*plannerutil.ByItems, 0, len(joinKeys))
+	for _, key := range joinKeys {
+		byItems = append(byItems, &plannerutil.ByItems{Expr: key, Desc: desc})
+	}
+	return &SortExec{
+		baseExecutor: newBaseExecutor(b.ctx, child.Schema(), 0, child),
+		ByItems:      byItems,
+		schema:       child.Schema(),
+	}
+}
+
 func (b *executorBuilder) buildSideEstCount(v *plannercore.PhysicalHashJoin) float64 {
 	buildSide := v.Children()[v.InnerChildIdx]
 	if v.UseOuterToBuild {
@@ -1195,6 +1217,10 @@ func (b *executorBuilder) buildHashJoin(v *plannercore.PhysicalHashJoin) Executo
 		}
 	}
 	e.buildSideEstCount = b.buildSideEstCount(v)
+	switch v.JoinType {
+	case plannercore.SemiJoin, plannercore.AntiSemiJoin, plannercore.LeftOuterSemiJoin, plannercore.AntiLeftOuterSemiJoin:
+		e.probeFirstMatch = len(v.OtherConditions) == 0 && !v.UseOuterToBuild
+	}
 	childrenUsedSchema := markChildrenUsedCols(v.Schema(), v.Children()[0].Schema(), v.Children()[1].Schema())
 	e.joiners = make([]joiner, e.concurrency)
 	for i := uint(0); i < e.concurrency; i++ {
@@ -1221,9 +1247,41 @@ func (b *executorBuilder) buildHashJoin(v *plannercore.PhysicalHashJoin) Executo
 	for _, key := range e.probeKeys {
 		e.probeTypes[key.Index].Flag = key.RetType.Flag
 	}
+	buildSideUsed := childrenUsedSchema[0]
+	if !leftIsBuildSide {
+		buildSideUsed = childrenUsedSchema[1]
+	}
+	e.buildSideUsedCols = buildSideUsedCols(e.buildSideExec.Schema(), buildSideUsed, e.buildKeys, v.OtherConditions)
 	return e
 }
 
+// buildSideUsedCols returns the indexes of the build side columns used by the
+// output, the join keys and the other conditions of the hash join. It returns
+// nil if all the columns are used.
+func buildSideUsedCols(buildSchema *expression.Schema, outputUsed []bool, buildKeys []*expression.Column,
+	otherConds expression.CNFExprs) []int {
+	used := make([]bool, buildSchema.Len())
+	copy(used, outputUsed)
+	for _, key := range buildKeys {
+		used[key.Index] = true
+	}
+	for _, col := range expression.ExtractColumnsFromExpressions(nil, otherConds, nil) {
+		if idx := buildSchema.ColumnIndex(col); idx >= 0 {
+			used[idx] = true
+		}
+	}
+	usedCols := make([]int, 0, len(used))
+	for i, u := range used {
+		if u {
+			usedCols = append(usedCols, i)
+		}
+	}
+	if len(usedCols) == len(used) {
+		return nil
+	}
+	return usedCols
+}
+
 func (b *executorBuilder) buildHashAgg(v *plannercore.PhysicalHashAgg) Executor {
 	src := b.build(v.Children()[0])
 	if b.err != nil {
@@ -1333,26 +1391,55 @@ func (b *executorBuilder) buildStreamAgg(v *plannercore.PhysicalStreamAgg) Execu
 }
 
 func (b *executorBuilder) buildSelection(v *plannercore.PhysicalSelection) Executor {
+	filters := v.Conditions
+	if b.foldParams() {
+		var alwaysFalse bool
+		filters, alwaysFalse = expression.FoldParamConditions(b.ctx, filters)
+		if alwaysFalse {
+			// No row can pass the filters with the parameters of this execution, the child needn't be executed.
+			return &TableDualExec{baseExecutor: newBaseExecutor(b.ctx, v.Schema(), v.ID())}
+		}
+	}
 	childExec := b.build(v.Children()[0])
 	if b.err != nil {
 		return nil
 	}
+	if len(filters) == 0 {
+		return childExec
+	}
 	e := &SelectionExec{
 		baseExecutor: newBaseExecutor(b.ctx, v.Schema(), v.ID(), childExec),
-		filters:      v.Conditions,
+		filters:      filters,
+	}
+	// Evaluate the filters on the coprocessor responses, so the columns only referenced by the parent are copied
+	// for the selected rows only.
+	if tr, ok := childExec.(*TableReaderExecutor); ok && len(tr.virtualColumnIndex) == 0 && expression.Vectorizable(filters) {
+		tr.filter = e.filter
+		e.pushedDown = true
 	}
 	return e
 }
 
+// foldParams returns whether the expressions of the plan should be folded with the parameters of the
+// execution, which is only needed by the plans from the plan cache.
+func (b *executorBuilder) foldParams() bool {
+	sessVars := b.ctx.GetSessionVars()
+	return sessVars.EnablePlanCacheParamFolding && sessVars.StmtCtx.UseCache
+}
+
 func (b *executorBuilder) buildProjection(v *plannercore.PhysicalProjection) Executor {
 	childExec := b.build(v.Children()[0])
 	if b.err != nil {
 		return nil
 	}
+	exprs := v.Exprs
+	if b.foldParams() {
+		exprs = expression.FoldParams(b.ctx, exprs)
+	}
 	e := &ProjectionExec{
 		baseExecutor:     newBaseExecutor(b.ctx, v.Schema(), v.ID(), childExec),
 		numWorkers:       int64(b.ctx.GetSessionVars().ProjectionConcurrency()),
-		evaluatorSuit:    expression.NewEvaluatorSuite(v.Exprs, v.AvoidColumnEvaluator),
+		evaluatorSuit:    expression.NewEvaluatorSuite(exprs, v.AvoidColumnEvaluator),
 		calculateNoDelay: v.CalculateNoDelay,
 	}
 
@@ -1439,6 +1526,17 @@ func (b *executorBuilder) buildMemTable(v *plannercore.PhysicalMemTable) Executo
 					extractor: v.Extractor.(*plannercore.ClusterTableExtractor),
 				},
 			}
+		case strings.ToLower(infoschema.TableClusterMemoryUsage),
+			strings.ToLower(infoschema.TableClusterThreads),
+			strings.ToLower(infoschema.TableClusterRunningTasks):
+			return &MemTableReaderExec{
+				baseExecutor: newBaseExecutor(b.ctx, v.Schema(), v.ID()),
+				table:        v.Table,
+				retriever: &clusterInstanceStatsRetriever{
+					table:     v.Table.Name.L,
+					extractor: v.Extractor.(*plannercore.ClusterTableExtractor),
+				},
+			}
 		case strings.ToLower(infoschema.TableClusterLoad):
 			return &MemTableReaderExec{
 				baseExecutor: newBaseExecutor(b.ctx, v.Schema(), v.ID()),
@@ -1561,7 +1659,14 @@ func (b *executorBuilder) buildMemTable(v *plannercore.PhysicalMemTable) Executo
 			strings.ToLower(infoschema.ClusterTableTiDBTrx),
 			strings.ToLower(infoschema.TableDeadlocks),
 			strings.ToLower(infoschema.ClusterTableDeadlocks),
-			strings.ToLower(infoschema.TableDataLockWaits):
+			strings.ToLower(infoschema.TableDataLockWaits),
+			strings.ToLower(infoschema.TableDataLockWaitHistory),
+			strings.ToLower(infoschema.TablePlanCaptures),
+			strings.ToLower(infoschema.TableTableTraffic),
+			strings.ToLower(infoschema.TableOptimizerTrace),
+			strings.ToLower(infoschema.TableAutoAnalyzeQueue),
+			strings.ToLower(infoschema.TableRunawayQueries),
+			strings.ToLower(infoschema.TableSessionConnectAttrs):
 			return &MemTableReaderExec{
 				baseExecutor: newBaseExecutor(b.ctx, v.Schema(), v.ID()),
 				table:        v.Table,
@@ -1600,6 +1705,16 @@ func (b *executorBuilder) buildMemTable(v *plannercore.PhysicalMemTable) Executo
 					extractor:  v.Extractor.(*plannercore.TableStorageStatsExtractor),
 				},
 			}
+		case strings.ToLower(infoschema.TableStatsJSON):
+			return &MemTableReaderExec{
+				baseExecutor: newBaseExecutor(b.ctx, v.Schema(), v.ID()),
+				table:        v.Table,
+				retriever: &statsJSONRetriever{
+					table:      v.Table,
+					outputCols: v.Columns,
+					extractor:  v.Extractor.(*plannercore.TableStorageStatsExtractor),
+				},
+			}
 		case strings.ToLower(infoschema.TableDDLJobs):
 			return &DDLJobsReaderExec{
 				baseExecutor: newBaseExecutor(b.ctx, v.Schema(), v.ID()),
@@ -2073,6 +2188,12 @@ func (b *executorBuilder) buildAnalyzeSamplingPushdown(task plannercore.AnalyzeC
 		}
 	}
 
+	jobInfo := autoAnalyze + "analyze table"
+	sampleRate := b.ctx.GetSessionVars().AnalyzeSampleRate
+	if sampleRate > 0 {
+		opts = b.applyAnalyzeSampleRate(task.TblInfo, task.TableID, opts, sampleRate)
+		jobInfo += " with sample rate " + strconv.FormatFloat(sampleRate, 'f', -1, 64)
+	}
 	_, offset := timeutil.Zone(b.ctx.GetSessionVars().Location())
 	sc := b.ctx.GetSessionVars().StmtCtx
 	e := &AnalyzeColumnsExec{
@@ -2087,6 +2208,7 @@ func (b *executorBuilder) buildAnalyzeSamplingPushdown(task plannercore.AnalyzeC
 			TimeZoneOffset: offset,
 		},
 		opts:                    opts,
+		sampleRate:              sampleRate,
 		indexes:                 availableIdx,
 		AnalyzeInfo:             task.AnalyzeInfo,
 		schemaForVirtualColEval: schemaForVirtualColEval,
@@ -2105,10 +2227,40 @@ func (b *executorBuilder) buildAnalyzeSamplingPushdown(task plannercore.AnalyzeC
 		}
 	}
 	b.err = plannercore.SetPBColumnsDefaultValue(b.ctx, e.analyzePB.ColReq.ColumnsInfo, task.ColsInfo)
-	job := &statistics.AnalyzeJob{DBName: task.DBName, TableName: task.TableName, PartitionName: task.PartitionName, JobInfo: autoAnalyze + "analyze table"}
+	job := &statistics.AnalyzeJob{DBName: task.DBName, TableName: task.TableName, PartitionName: task.PartitionName, JobInfo: jobInfo}
 	return &analyzeTask{taskType: colTask, colExec: e, job: job}
 }
 
+// maxSamplesBySampleRate is the max number of the samples converted from the sample rate, it's the same as the
+// limit of WITH N SAMPLES.
+const maxSamplesBySampleRate = 500000
+
+// applyAnalyzeSampleRate returns the options in which the number of the samples is converted from the sample rate
+// by the row count of the table, so all the rows of the sampled regions are kept as samples. The options are kept
+// if the row count is unknown.
+func (b *executorBuilder) applyAnalyzeSampleRate(tblInfo *model.TableInfo, tableID plannercore.AnalyzeTableID, opts map[ast.AnalyzeOptionType]uint64, sampleRate float64) map[ast.AnalyzeOptionType]uint64 {
+	h := domain.GetDomain(b.ctx).StatsHandle()
+	if h == nil {
+		return opts
+	}
+	statsTbl := h.GetPartitionStats(tblInfo, tableID.GetStatisticsID())
+	if statsTbl.Pseudo {
+		return opts
+	}
+	sampleSize := uint64(math.Ceil(float64(statsTbl.Count) * sampleRate))
+	if sampleSize < 1 {
+		sampleSize = 1
+	} else if sampleSize > maxSamplesBySampleRate {
+		sampleSize = maxSamplesBySampleRate
+	}
+	newOpts := make(map[ast.AnalyzeOptionType]uint64, len(opts))
+	for tp, val := range opts {
+		newOpts[tp] = val
+	}
+	newOpts[ast.AnalyzeOptNumSamples] = sampleSize
+	return newOpts
+}
+
 func (b *executorBuilder) buildAnalyzeColumnsPushdown(task plannercore.AnalyzeColumnsTask, opts map[ast.AnalyzeOptionType]uint64, autoAnalyze string, schemaForVirtualColEval *expression.Schema) *analyzeTask {
 	if task.StatsVersion == statistics.Version2 {
 		return b.buildAnalyzeSamplingPushdown(task, opts, autoAnalyze, schemaForVirtualColEval)
@@ -3777,7 +3929,7 @@ func buildKvRangesForIndexJoin(ctx sessionctx.Context, tableID, indexID int64, l
 		sort.Slice(kvRanges, func(i, j int) bool {
 			return bytes.Compare(kvRanges[i].StartKey, kvRanges[j].StartKey) < 0
 		})
-		return kvRanges, nil
+		return mergeSortedKvRanges(kvRanges), nil
 	}
 
 	tmpDatumRanges, err = ranger.UnionRanges(ctx.GetSessionVars().StmtCtx, tmpDatumRanges, true)
@@ -3791,6 +3943,27 @@ func buildKvRangesForIndexJoin(ctx sessionctx.Context, tableID, indexID int64, l
 	return distsql.IndexRangesToKVRanges(ctx.GetSessionVars().StmtCtx, tableID, indexID, tmpDatumRanges, nil)
 }
 
+// mergeSortedKvRanges merges the overlapped and adjacent ranges in place, the
+// ranges should be sorted by the start key. Adjacent ranges are common when the
+// lookup keys are consecutive, e.g. the ranges of the integer keys 1 and 2.
+func mergeSortedKvRanges(kvRanges []kv.KeyRange) []kv.KeyRange {
+	if len(kvRanges) < 2 {
+		return kvRanges
+	}
+	merged := kvRanges[:1]
+	for _, ran := range kvRanges[1:] {
+		last := &merged[len(merged)-1]
+		if bytes.Compare(ran.StartKey, last.EndKey) > 0 {
+			merged = append(merged, ran)
+			continue
+		}
+		if bytes.Compare(ran.EndKey, last.EndKey) > 0 {
+			last.EndKey = ran.EndKey
+		}
+	}
+	return merged
+}
+
 func (b *executorBuilder) buildWindow(v *plannercore.PhysicalWindow) Executor {
 	childExec := b.build(v.Children()[0])
 	if b.err != nil {
@@ -4007,13 +4180,19 @@ func NewRowDecoder(ctx sessionctx.Context, schema *expression.Schema, tbl *model
 			pkCols = []int64{-1}
 		}
 	}
+	// The origin default values are resolved once for the decoder, they fill the columns added after the rows were
+	// written.
+	defDatums := make([]*types.Datum, len(reqCols))
 	defVal := func(i int, chk *chunk.Chunk) error {
-		ci := getColInfoByID(tbl, reqCols[i].ID)
-		d, err := table.GetColOriginDefaultValue(ctx, ci)
-		if err != nil {
-			return err
+		if defDatums[i] == nil {
+			ci := getColInfoByID(tbl, reqCols[i].ID)
+			d, err := table.GetColOriginDefaultValue(ctx, ci)
+			if err != nil {
+				return err
+			}
+			defDatums[i] = &d
 		}
-		chk.AppendDatum(i, &d)
+		chk.AppendDatum(i, defDatums[i])
 		return nil
 	}
 	return rowcodec.NewChunkDecoder(reqCols, pkCols, defVal, ctx.GetSessionVars().TimeZone)
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"math/rand"
 	"sort"
+	"strings"
 
 	"github.com/pingcap/check"
 
@@ -445,3 +446,44 @@ func (test *CTETestSuite) TestCTEWithLimit(c *check.C) {
 	rows = tk.MustQuery("with recursive cte1(c1) as (select c1 from t1 union all select c1 + 1 from cte1 limit 4 offset 4) select * from cte1;")
 	rows.Check(testkit.Rows("3", "4", "3", "4"))
 }
+
+func (test *CTETestSuite) TestInlineCTE(c *check.C) {
+	tk := testkit.NewTestKit(c, test.store)
+	tk.MustExec("use test;")
+	tk.MustExec("drop table if exists t1;")
+	tk.MustExec("create table t1(c1 int, c2 int);")
+	tk.MustExec("insert into t1 values(1, 1), (2, 2), (3, 3);")
+	hasCTEScan := func(sql string) bool {
+		for _, row := range tk.MustQuery("explain " + sql).Rows() {
+			if strings.Contains(fmt.Sprintf("%v", row[0]), "CTEFullScan") {
+				return true
+			}
+		}
+		return false
+	}
+
+	tk.MustExec("set @@tidb_opt_inline_cte = 1;")
+	// The CTE referenced only once is inlined.
+	sql := "with cte1 as (select c1, c2 from t1 where c1 > 1) select * from cte1 where c2 < 3;"
+	c.Assert(hasCTEScan(sql), check.IsFalse)
+	tk.MustQuery(sql).Check(testkit.Rows("2 2"))
+	sql = "with cte1(a, b) as (select c1, c2 from t1) select c.a from cte1 c where c.b = 3;"
+	c.Assert(hasCTEScan(sql), check.IsFalse)
+	tk.MustQuery(sql).Check(testkit.Rows("3"))
+	sql = "with cte1 as (select c1 from t1), cte2 as (select c1 + 1 as c1 from cte1) select * from cte2 order by c1;"
+	c.Assert(hasCTEScan(sql), check.IsFalse)
+	tk.MustQuery(sql).Check(testkit.Rows("2", "3", "4"))
+	// The CTE referenced more than once is materialized.
+	sql = "with cte1 as (select c1 from t1 where c1 > 1) select * from cte1 a join cte1 b on a.c1 = b.c1 order by a.c1;"
+	c.Assert(hasCTEScan(sql), check.IsTrue)
+	tk.MustQuery(sql).Check(testkit.Rows("2 2", "3 3"))
+	// The recursive CTE is materialized.
+	sql = "with recursive cte1(c1) as (select 1 union all select c1 + 1 from cte1 where c1 < 3) select * from cte1;"
+	c.Assert(hasCTEScan(sql), check.IsTrue)
+	tk.MustQuery(sql).Check(testkit.Rows("1", "2", "3"))
+
+	tk.MustExec("set @@tidb_opt_inline_cte = 0;")
+	sql = "with cte1 as (select c1, c2 from t1 where c1 > 1) select * from cte1 where c2 < 3;"
+	c.Assert(hasCTEScan(sql), check.IsTrue)
+	tk.MustQuery(sql).Check(testkit.Rows("2 2"))
+}
@@ -37,6 +37,7 @@ import (
 	"github.com/pingcap/parser/mysql"
 	"github.com/pingcap/parser/terror"
 	"github.com/pingcap/tidb/config"
+	"github.com/pingcap/tidb/ddl"
 	"github.com/pingcap/tidb/domain"
 	"github.com/pingcap/tidb/domain/infosync"
 	"github.com/pingcap/tidb/expression"
@@ -111,6 +112,7 @@ type baseExecutor struct {
 	children      []Executor
 	retFieldTypes []*types.FieldType
 	runtimeStats  *execdetails.BasicRuntimeStats
+	opStats       operatorStats
 }
 
 const (
@@ -176,13 +178,55 @@ func (e *baseExecutor) Open(ctx context.Context) error {
 
 // Close closes all executors and release all resources.
 func (e *baseExecutor) Close() error {
-	var firstErr error
-	for _, src := range e.children {
-		if err := src.Close(); err != nil && firstErr == nil {
-			firstErr = err
+	e.opStats.flush()
+	return closeExecutors(e.ctx, e.children)
+}
+
+// closeExecutors closes the executors and returns the first error. Closing a reader waits for
+// its cop workers to exit, so the executors are closed concurrently to cut the latency at the end
+// of the statement. The goroutines closing the executors are limited by a limiter shared by the
+// whole statement, so there are at most ExecutorCloseConcurrency goroutines closing the executors
+// no matter how deep the tree is. An executor is closed by the current goroutine if the limiter is
+// used up, so a parent waiting for its children never blocks them.
+func closeExecutors(sctx sessionctx.Context, execs []Executor) error {
+	var limiter chan struct{}
+	if sctx != nil && len(execs) > 1 {
+		// The current goroutine is one of the goroutines closing the executors.
+		if concurrency := sctx.GetSessionVars().ExecutorCloseConcurrency; concurrency > 1 {
+			limiter = sctx.GetSessionVars().StmtCtx.GetCloseLimiter(concurrency - 1)
 		}
 	}
-	return firstErr
+
+	errs := make([]error, len(execs))
+	var wg sync.WaitGroup
+	for i := range execs {
+		select {
+		case limiter <- struct{}{}:
+			wg.Add(1)
+			go func(i int) {
+				defer func() {
+					<-limiter
+					wg.Done()
+				}()
+				util.WithRecovery(func() {
+					errs[i] = execs[i].Close()
+				}, func(r interface{}) {
+					if r != nil {
+						errs[i] = errors.Errorf("%v", r)
+					}
+				})
+			}(i)
+		default:
+			errs[i] = execs[i].Close()
+		}
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Schema returns the current baseExecutor's schema. If it is nil, then create and return a new one.
@@ -283,11 +327,28 @@ func Next(ctx context.Context, e Executor, req *chunk.Chunk) error {
 	if trace.IsEnabled() {
 		defer trace.StartRegion(ctx, fmt.Sprintf("%T.Next", e)).End()
 	}
+	timed := base.opStats.sample(e)
+	var start time.Time
+	if timed {
+		start = time.Now()
+	}
 	err := e.Next(ctx, req)
+	if base.opStats.interval > 0 {
+		base.opStats.record(req.NumRows(), start, timed)
+	}
 
 	if err != nil {
 		return err
 	}
+	verifyChunk := chunk.VerifyEnabled
+	failpoint.Inject("verifyChunk", func() {
+		verifyChunk = true
+	})
+	if verifyChunk {
+		if err := req.Verify(); err != nil {
+			panic(fmt.Sprintf("%T(%d) returns an invalid chunk: %v", e, base.id, err))
+		}
+	}
 	// recheck whether the session/query is killed during the Next()
 	if atomic.LoadUint32(&sessVars.Killed) == 1 {
 		err = ErrQueryInterrupted
@@ -446,13 +507,22 @@ type DDLJobRetriever struct {
 	is             infoschema.InfoSchema
 	activeRoles    []*auth.RoleIdentity
 	cacheJobs      []*model.Job
+	pausedJobs     map[int64]struct{}
 }
 
-func (e *DDLJobRetriever) initial(txn kv.Transaction) error {
+func (e *DDLJobRetriever) initial(txn kv.Transaction, sessVars *variable.SessionVars) error {
 	jobs, err := admin.GetDDLJobs(txn)
 	if err != nil {
 		return err
 	}
+	pausedJobs, err := sessVars.GlobalVarsAccessor.GetGlobalSysVar(variable.TiDBDDLReorgPausedJobs)
+	if err != nil {
+		return err
+	}
+	e.pausedJobs, err = variable.ParseDDLReorgPausedJobs(pausedJobs)
+	if err != nil {
+		return err
+	}
 	m := meta.NewMeta(txn)
 	e.historyJobIter, err = m.GetLastHistoryDDLJobsIterator()
 	if err != nil {
@@ -495,7 +565,7 @@ func (e *DDLJobRetriever) appendJobToChunk(req *chunk.Chunk, job *model.Job, che
 	req.AppendInt64(0, job.ID)
 	req.AppendString(1, schemaName)
 	req.AppendString(2, tableName)
-	req.AppendString(3, job.Type.String())
+	req.AppendString(3, ddl.JobTypeString(job.Type))
 	req.AppendString(4, job.SchemaState.String())
 	req.AppendInt64(5, job.SchemaID)
 	req.AppendInt64(6, job.TableID)
@@ -506,7 +576,11 @@ func (e *DDLJobRetriever) appendJobToChunk(req *chunk.Chunk, job *model.Job, che
 	} else {
 		req.AppendNull(9)
 	}
-	req.AppendString(10, job.State.String())
+	state := job.State.String()
+	if _, ok := e.pausedJobs[job.ID]; ok && job.State == model.JobStateRunning && job.SchemaState == model.StateWriteReorganization {
+		state = "paused"
+	}
+	req.AppendString(10, state)
 }
 
 func ts2Time(timestamp uint64) types.Time {
@@ -585,7 +659,7 @@ func (e *ShowDDLJobsExec) Open(ctx context.Context) error {
 	if e.jobNumber == 0 {
 		e.jobNumber = admin.DefNumHistoryJobs
 	}
-	err = e.DDLJobRetriever.initial(txn)
+	err = e.DDLJobRetriever.initial(txn, e.ctx.GetSessionVars())
 	if err != nil {
 		return err
 	}
@@ -746,6 +820,41 @@ func (e *CheckTableExec) Next(ctx context.Context, req *chunk.Chunk) error {
 	}
 	defer func() { e.done = true }()
 
+	if !e.ctx.GetSessionVars().EnableFastTableCheck || e.hasHiddenIndexColumn() {
+		return e.checkRowByRow(ctx)
+	}
+	// The checksums of the table and the indices are read from the snapshot of the transaction.
+	if _, err := e.ctx.Txn(true); err != nil {
+		return err
+	}
+	checksumErr := admin.CheckIndicesChecksum(e.ctx, e.dbName, e.table.Meta(), e.indexInfos, e.ctx.GetSessionVars().IndexLookupConcurrency())
+	if checksumErr == nil || !admin.ErrAdminCheckTable.Equal(checksumErr) {
+		return errors.Trace(checksumErr)
+	}
+	// Find out the inconsistent rows by checking row by row.
+	if err := e.checkRowByRow(ctx); err != nil {
+		return err
+	}
+	return ErrAdminCheckTable.GenWithStack("%v err:%v", e.table.Meta().Name, checksumErr)
+}
+
+// hasHiddenIndexColumn returns whether any index is built on the hidden columns, like the expression indices, whose
+// checksums can't be computed by SQL.
+func (e *CheckTableExec) hasHiddenIndexColumn() bool {
+	cols := e.table.Meta().Columns
+	for _, idx := range e.indexInfos {
+		for _, col := range idx.Columns {
+			if cols[col.Offset].Hidden {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkRowByRow compares the count of the table and the indices, and then compares the rows read from the indices with
+// the ones read from the table.
+func (e *CheckTableExec) checkRowByRow(ctx context.Context) error {
 	idxNames := make([]string, 0, len(e.indexInfos))
 	for _, idx := range e.indexInfos {
 		idxNames = append(idxNames, idx.Name.O)
@@ -768,8 +877,7 @@ func (e *CheckTableExec) Next(ctx context.Context, req *chunk.Chunk) error {
 	}
 
 	// The number of table rows is equal to the number of index rows.
-	// TODO: Make the value of concurrency adjustable. And we can consider the number of records.
-	concurrency := 3
+	concurrency := e.ctx.GetSessionVars().IndexLookupConcurrency()
 	wg := sync.WaitGroup{}
 	for i := range e.srcs {
 		wg.Add(1)
@@ -993,8 +1101,7 @@ func newLockCtx(seVars *variable.SessionVars, lockWaitTime int64) *tikvstore.Loc
 		LockExpired:           &seVars.TxnCtx.LockExpire,
 		ResourceGroupTag:      resourcegrouptag.EncodeResourceGroupTag(sqlDigest, planDigest),
 		OnDeadlock: func(deadlock *tikverr.ErrDeadlock) {
-			// TODO: Support collecting retryable deadlocks according to the config.
-			if !deadlock.IsRetryable {
+			if !deadlock.IsRetryable || config.GetGlobalConfig().PessimisticTxn.DeadlockHistoryCollectRetryable {
 				rec := deadlockhistory.ErrDeadlockToDeadlockRecord(deadlock)
 				deadlockhistory.GlobalDeadlockHistory.Push(rec)
 			}
@@ -1251,6 +1358,16 @@ type SelectionExec struct {
 	childResult *chunk.Chunk
 
 	memTracker *memory.Tracker
+
+	// compileThreshold is the number of filtered rows to compile the filters, 0 means never.
+	compileThreshold int64
+	filteredRows     int64
+	compiled         bool
+	compiledFilter   *expression.CompiledFilter
+
+	// pushedDown indicates the filters are evaluated by the child TableReaderExecutor before the rows are copied
+	// from the coprocessor responses, so the child result is returned directly.
+	pushedDown bool
 }
 
 // Open implements the Executor Open interface.
@@ -1269,12 +1386,17 @@ func (e *SelectionExec) Open(ctx context.Context) error {
 func (e *SelectionExec) open(ctx context.Context) error {
 	e.memTracker = memory.NewTracker(e.id, -1)
 	e.memTracker.AttachTo(e.ctx.GetSessionVars().StmtCtx.MemTracker)
-	e.childResult = newFirstChunk(e.children[0])
-	e.memTracker.Consume(e.childResult.MemoryUsage())
 	e.batched = expression.Vectorizable(e.filters)
 	if e.batched {
 		e.selected = make([]bool, 0, chunk.InitialCapacity)
 	}
+	e.compileThreshold = e.ctx.GetSessionVars().FilterCompileThreshold
+	e.filteredRows, e.compiled, e.compiledFilter = 0, false, nil
+	if e.pushedDown {
+		return nil
+	}
+	e.childResult = newFirstChunk(e.children[0])
+	e.memTracker.Consume(e.childResult.MemoryUsage())
 	e.inputIter = chunk.NewIterator4Chunk(e.childResult)
 	e.inputRow = e.inputIter.End()
 	return nil
@@ -1294,6 +1416,9 @@ func (e *SelectionExec) Close() error {
 func (e *SelectionExec) Next(ctx context.Context, req *chunk.Chunk) error {
 	req.GrowAndReset(e.maxChunkSize)
 
+	if e.pushedDown {
+		return Next(ctx, e.children[0], req)
+	}
 	if !e.batched {
 		return e.unBatchedNext(ctx, req)
 	}
@@ -1318,7 +1443,7 @@ func (e *SelectionExec) Next(ctx context.Context, req *chunk.Chunk) error {
 		if e.childResult.NumRows() == 0 {
 			return nil
 		}
-		e.selected, err = expression.VectorizedFilter(e.ctx, e.filters, e.inputIter, e.selected)
+		_, err = e.filter(e.childResult)
 		if err != nil {
 			return err
 		}
@@ -1326,6 +1451,31 @@ func (e *SelectionExec) Next(ctx context.Context, req *chunk.Chunk) error {
 	}
 }
 
+// filter filters the rows of the chunk, which is the child result or the chunk filtered by the child
+// TableReaderExecutor if the filters are pushed down, and keeps the result in e.selected. The filters are compiled
+// once they have filtered more than compileThreshold rows, the compiled filter is used if all of them can be compiled.
+func (e *SelectionExec) filter(chk *chunk.Chunk) (_ []bool, err error) {
+	if e.compileThreshold > 0 && !e.compiled {
+		e.filteredRows += int64(chk.NumRows())
+		if e.filteredRows > e.compileThreshold {
+			e.compiled = true
+			e.compiledFilter = expression.CompileFilter(e.ctx, e.filters)
+		}
+	}
+	if e.compiledFilter != nil {
+		if selected, ok := e.compiledFilter.Filter(chk, e.selected); ok {
+			e.selected = selected
+			return selected, nil
+		}
+	}
+	iter := e.inputIter
+	if chk != e.childResult {
+		iter = chunk.NewIterator4Chunk(chk)
+	}
+	e.selected, err = expression.VectorizedFilter(e.ctx, e.filters, iter, e.selected)
+	return e.selected, err
+}
+
 // unBatchedNext filters input rows one by one and returns once an input row is selected.
 // For sql with "SETVAR" in filter and "GETVAR" in projection, for example: "SELECT @a FROM t WHERE (@a := 2) > 0",
 // we have to set batch size to 1 to do the evaluation of filter and projection.
@@ -1651,13 +1801,7 @@ func (e *UnionExec) Close() error {
 	}
 	// We do not need to acquire the e.mu.Lock since all the resultPuller can be
 	// promised to exit when reaching here (e.childIDChan been closed).
-	var firstErr error
-	for i := 0; i <= e.mu.maxOpenedChildID; i++ {
-		if err := e.children[i].Close(); err != nil && firstErr == nil {
-			firstErr = err
-		}
-	}
-	return firstErr
+	return closeExecutors(e.ctx, e.children[:e.mu.maxOpenedChildID+1])
 }
 
 // ResetContextOfStmt resets the StmtContext and session variables.
@@ -1671,6 +1815,15 @@ func ResetContextOfStmt(ctx sessionctx.Context, s ast.StmtNode) (err error) {
 		TaskID:        stmtctx.AllocateTaskID(),
 		CTEStorageMap: map[int]*CTEStorages{},
 	}
+	sc.EnableOptimizerNotes = vars.EnableOptimizerNotes
+	sc.EnableOptimizerTrace = vars.EnableOptimizerTrace
+	// Keep the optimizer trace of the last statement, so it can be queried by information_schema.optimizer_trace.
+	// The trace of EXPLAIN FORMAT = 'trace' is returned by the statement itself, so it's not kept.
+	if prevSC := vars.StmtCtx; prevSC != nil && prevSC.EnableOptimizerTrace && !prevSC.InExplainStmt {
+		if steps := prevSC.GetOptimizerTrace(); len(steps) > 0 {
+			vars.LastOptimizerTrace = &variable.OptimizerTrace{Query: prevSC.OriginalSQL, Steps: steps}
+		}
+	}
 	sc.MemTracker.AttachToGlobalTracker(GlobalMemoryUsageTracker)
 	globalConfig := config.GetGlobalConfig()
 	if globalConfig.OOMUseTmpStorage && GlobalDiskUsageTracker != nil {
@@ -1710,6 +1863,9 @@ func ResetContextOfStmt(ctx sessionctx.Context, s ast.StmtNode) (err error) {
 	if explainStmt, ok := s.(*ast.ExplainStmt); ok {
 		sc.InExplainStmt = true
 		sc.IgnoreExplainIDSuffix = (strings.ToLower(explainStmt.Format) == ast.ExplainFormatBrief)
+		if strings.ToLower(explainStmt.Format) == plannercore.ExplainFormatTrace {
+			sc.EnableOptimizerTrace = true
+		}
 		s = explainStmt.Stmt
 	}
 	if _, ok := s.(*ast.ExplainForStmt); ok {
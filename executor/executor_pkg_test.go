@@ -18,10 +18,12 @@ import (
 	"crypto/tls"
 	"runtime"
 	"strconv"
+	"sync/atomic"
 	"time"
 	"unsafe"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/errors"
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/parser/ast"
 	"github.com/pingcap/parser/auth"
@@ -29,8 +31,10 @@ import (
 	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/executor/aggfuncs"
 	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/kv"
 	plannerutil "github.com/pingcap/tidb/planner/util"
 	txninfo "github.com/pingcap/tidb/session/txninfo"
+	"github.com/pingcap/tidb/sessionctx/stmtctx"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/types"
 	"github.com/pingcap/tidb/util"
@@ -174,6 +178,90 @@ func buildSchema(names []string, ftypes []byte) *expression.Schema {
 	return schema
 }
 
+type mockCloseExec struct {
+	baseExecutor
+	closed *int32
+	err    error
+}
+
+func (e *mockCloseExec) Close() error {
+	atomic.AddInt32(e.closed, 1)
+	return e.err
+}
+
+func (s *testExecSuite) TestCloseExecutorsConcurrently(c *C) {
+	sctx := mock.NewContext()
+	var closed int32
+	children := make([]Executor, 0, 10)
+	for i := 0; i < 10; i++ {
+		child := &mockCloseExec{baseExecutor: newBaseExecutor(sctx, nil, i), closed: &closed}
+		if i == 3 || i == 7 {
+			child.err = errors.Errorf("close error %d", i)
+		}
+		children = append(children, child)
+	}
+	parent := newBaseExecutor(sctx, nil, 10, children...)
+
+	for _, concurrency := range []int{1, 4, 20} {
+		closed = 0
+		sctx.GetSessionVars().StmtCtx = &stmtctx.StatementContext{}
+		sctx.GetSessionVars().ExecutorCloseConcurrency = concurrency
+		err := parent.Close()
+		// The error of the first child is returned no matter how the children are closed.
+		c.Assert(err, ErrorMatches, "close error 3")
+		c.Assert(atomic.LoadInt32(&closed), Equals, int32(10))
+	}
+}
+
+type mockSlowCloseExec struct {
+	baseExecutor
+	closing    *int32
+	maxClosing *int32
+}
+
+func (e *mockSlowCloseExec) Close() error {
+	if len(e.children) > 0 {
+		return e.baseExecutor.Close()
+	}
+	closing := atomic.AddInt32(e.closing, 1)
+	for {
+		maxClosing := atomic.LoadInt32(e.maxClosing)
+		if closing <= maxClosing || atomic.CompareAndSwapInt32(e.maxClosing, maxClosing, closing) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond)
+	atomic.AddInt32(e.closing, -1)
+	return nil
+}
+
+func (s *testExecSuite) TestCloseExecutorsLimitedByStatement(c *C) {
+	sctx := mock.NewContext()
+	var closing, maxClosing int32
+	// Build a tree with 4 levels and 4 children for each executor, i.e. 256 leaves.
+	var build func(depth int) Executor
+	build = func(depth int) Executor {
+		var children []Executor
+		if depth > 0 {
+			for i := 0; i < 4; i++ {
+				children = append(children, build(depth-1))
+			}
+		}
+		return &mockSlowCloseExec{baseExecutor: newBaseExecutor(sctx, nil, 0, children...), closing: &closing, maxClosing: &maxClosing}
+	}
+	root := build(4)
+
+	for _, concurrency := range []int{1, 3, 8} {
+		maxClosing = 0
+		sctx.GetSessionVars().StmtCtx = &stmtctx.StatementContext{}
+		sctx.GetSessionVars().ExecutorCloseConcurrency = concurrency
+		c.Assert(root.Close(), IsNil)
+		// The goroutines closing the executors are shared by the whole tree.
+		c.Assert(atomic.LoadInt32(&maxClosing) <= int32(concurrency), IsTrue, Commentf("concurrency %d", concurrency))
+		c.Assert(atomic.LoadInt32(&closing), Equals, int32(0))
+	}
+}
+
 func (s *testExecSuite) TestBuildKvRangesForIndexJoinWithoutCwc(c *C) {
 	indexRanges := make([]*ranger.Range, 0, 6)
 	indexRanges = append(indexRanges, generateIndexRange(1, 1, 1, 1, 1))
@@ -203,6 +291,44 @@ func (s *testExecSuite) TestBuildKvRangesForIndexJoinWithoutCwc(c *C) {
 	}
 }
 
+func (s *testExecSuite) TestMergeSortedKvRanges(c *C) {
+	buildRanges := func(keys ...string) []kv.KeyRange {
+		ranges := make([]kv.KeyRange, 0, len(keys)/2)
+		for i := 0; i < len(keys); i += 2 {
+			ranges = append(ranges, kv.KeyRange{StartKey: kv.Key(keys[i]), EndKey: kv.Key(keys[i+1])})
+		}
+		return ranges
+	}
+	cases := []struct {
+		input  []kv.KeyRange
+		output []kv.KeyRange
+	}{
+		{buildRanges("a", "b"), buildRanges("a", "b")},
+		{buildRanges("a", "b", "c", "d"), buildRanges("a", "b", "c", "d")},
+		// adjacent
+		{buildRanges("a", "b", "b", "c", "d", "e"), buildRanges("a", "c", "d", "e")},
+		// overlapped
+		{buildRanges("a", "c", "b", "d", "e", "f"), buildRanges("a", "d", "e", "f")},
+		// contained
+		{buildRanges("a", "z", "b", "c", "d", "e"), buildRanges("a", "z")},
+	}
+	for _, ca := range cases {
+		c.Assert(mergeSortedKvRanges(ca.input), DeepEquals, ca.output)
+	}
+
+	// The ranges of the consecutive integer keys are merged.
+	indexRanges := []*ranger.Range{generateIndexRange(1)}
+	joinKeyRows := []*indexJoinLookUpContent{
+		{keys: generateDatumSlice(1)},
+		{keys: generateDatumSlice(2)},
+		{keys: generateDatumSlice(3)},
+		{keys: generateDatumSlice(5)},
+	}
+	kvRanges, err := buildKvRangesForIndexJoin(mock.NewContext(), 0, 0, joinKeyRows, indexRanges, []int{0}, nil)
+	c.Assert(err, IsNil)
+	c.Assert(kvRanges, HasLen, 2)
+}
+
 func generateIndexRange(vals ...int64) *ranger.Range {
 	lowDatums := generateDatumSlice(vals...)
 	highDatums := make([]types.Datum, len(vals))
@@ -549,3 +675,31 @@ func getGrowing(m aggPartialResultMapper) bool {
 	value := *point
 	return value.oldbuckets != nil
 }
+
+func (s *pkgTestSuite) TestHashAggSkewSampler(c *C) {
+	groupKeys := func(keys ...string) [][]byte {
+		groupKey := make([][]byte, 0, len(keys))
+		for _, key := range keys {
+			groupKey = append(groupKey, []byte(key))
+		}
+		return groupKey
+	}
+	// Too few rows are sampled.
+	sampler := newHashAggSkewSampler(4)
+	sampler.sample(groupKeys("a", "a", "b"), 3)
+	c.Assert(sampler.getHeavyHitters(), HasLen, 0)
+
+	sampler = newHashAggSkewSampler(4)
+	for i := 0; i < hashAggSkewSampleRows/4; i++ {
+		sampler.sample(groupKeys("a", "a", "b", strconv.Itoa(i)), 4)
+	}
+	// The rows out of the sample are ignored.
+	sampler.sample(groupKeys("c", "c", "c", "c"), 4)
+	heavyHitters := sampler.getHeavyHitters()
+	c.Assert(heavyHitters, HasLen, 2)
+	c.Assert(heavyHitters, HasKey, "a")
+	c.Assert(heavyHitters, HasKey, "b")
+	// The heavy hitters are frozen.
+	sampler.sample(groupKeys("c", "c", "c", "c"), 4)
+	c.Assert(sampler.getHeavyHitters(), DeepEquals, heavyHitters)
+}
@@ -14,12 +14,16 @@
 package executor_test
 
 import (
+	"archive/zip"
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"math"
 	"net"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
@@ -71,6 +75,7 @@ import (
 	"github.com/pingcap/tidb/util/logutil"
 	"github.com/pingcap/tidb/util/memory"
 	"github.com/pingcap/tidb/util/mock"
+	"github.com/pingcap/tidb/util/plancapture"
 	"github.com/pingcap/tidb/util/rowcodec"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/testleak"
@@ -296,6 +301,90 @@ func (s *testSuiteP1) TestLoadStats(c *C) {
 	c.Assert(tk.ExecToErr("load stats ./xxx.json"), NotNil)
 }
 
+func (s *testSuiteP1) TestLoadStatsInSession(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int, index idx(a))")
+	tk.MustExec("insert into t values (1), (2), (3)")
+	tk.MustExec("analyze table t")
+	is := s.domain.InfoSchema()
+	h := s.domain.StatsHandle()
+	c.Assert(h.Update(is), IsNil)
+	tbl, err := is.TableByName(model.NewCIStr("test"), model.NewCIStr("t"))
+	c.Assert(err, IsNil)
+	jsonTbl, err := h.DumpStatsToJSON("test", tbl.Meta(), nil)
+	c.Assert(err, IsNil)
+	jsonTbl.Count = 10000
+	data, err := json.Marshal(jsonTbl)
+	c.Assert(err, IsNil)
+
+	tk.MustExec("set @@tidb_load_stats_in_session = 1")
+	c.Assert((&executor.LoadStatsInfo{Ctx: tk.Se}).Update(data), IsNil)
+	rows := tk.MustQuery("explain select * from t").Rows()
+	c.Assert(rows[len(rows)-1][1], Equals, "10000.00")
+
+	// The statistics of other sessions and the storage are not changed.
+	tk2 := testkit.NewTestKit(c, s.store)
+	tk2.MustExec("use test")
+	rows = tk2.MustQuery("explain select * from t").Rows()
+	c.Assert(rows[len(rows)-1][1], Equals, "3.00")
+	c.Assert(h.GetTableStats(tbl.Meta()).Count, Equals, int64(3))
+
+	// The loaded statistics are dropped when the variable is turned off.
+	tk.MustExec("set @@tidb_load_stats_in_session = 0")
+	rows = tk.MustQuery("explain select * from t").Rows()
+	c.Assert(rows[len(rows)-1][1], Equals, "3.00")
+}
+
+func (s *testSuiteP1) TestPlanCapture(c *C) {
+	dir, err := ioutil.TempDir("", "plan_capture")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	origDir := plancapture.Dir
+	plancapture.Dir = dir
+	defer func() { plancapture.Dir = origDir }()
+
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int, index idx(a))")
+	tk.MustExec("insert into t values (1), (2), (3)")
+	sql := "select * from t where a > 1"
+	_, sqlDigest := parser.NormalizeDigest(sql)
+	digest := sqlDigest.String()
+	tk.MustExec(fmt.Sprintf("set @@global.tidb_plan_capture_digests = '%s'", digest))
+	tk.MustQuery("select @@global.tidb_plan_capture_digests").Check(testkit.Rows(digest))
+	tk.MustQuery("select digest, status from information_schema.plan_captures").Check(testkit.Rows(digest + " PENDING"))
+
+	tk.MustQuery("select * from t where a > 2").Check(testkit.Rows("3"))
+	// The digest is captured only once.
+	tk.MustQuery("select * from t where a > 1").Check(testkit.Rows("2", "3"))
+	tk.MustQuery("select @@global.tidb_plan_capture_digests").Check(testkit.Rows(""))
+	rows := tk.MustQuery("select digest, status, file_name from information_schema.plan_captures").Rows()
+	c.Assert(rows, HasLen, 1)
+	c.Assert(rows[0][0], Equals, digest)
+	c.Assert(rows[0][1], Equals, "CAPTURED")
+
+	r, err := zip.OpenReader(filepath.Join(dir, rows[0][2].(string)))
+	c.Assert(err, IsNil)
+	defer r.Close()
+	files := make(map[string]string)
+	for _, f := range r.File {
+		rc, err := f.Open()
+		c.Assert(err, IsNil)
+		content, err := ioutil.ReadAll(rc)
+		c.Assert(err, IsNil)
+		c.Assert(rc.Close(), IsNil)
+		files[f.Name] = string(content)
+	}
+	c.Assert(files["sql/sql.sql"], Equals, "select * from t where a > 2")
+	c.Assert(strings.Contains(files["explain.txt"], "time:"), IsTrue)
+	c.Assert(strings.Contains(files["schema/test.t.schema.txt"], "CREATE TABLE `t`"), IsTrue)
+	_, ok := files["stats/test.t.json"]
+	c.Assert(ok, IsTrue)
+}
+
 func (s *testSuiteP1) TestShow(c *C) {
 	tk := testkit.NewTestKit(c, s.store)
 	tk.MustExec("create database test_show;")
@@ -356,6 +445,8 @@ func (s *testSuiteP1) TestShow(c *C) {
 		"RESTRICTED_VARIABLES_ADMIN Server Admin ",
 		"RESTRICTED_USER_ADMIN Server Admin ",
 		"RESTRICTED_CONNECTION_ADMIN Server Admin ",
+		"BINDING_ADMIN Server Admin ",
+		"RESOURCE_GROUP_ADMIN Server Admin ",
 	))
 	c.Assert(len(tk.MustQuery("show table status").Rows()), Equals, 1)
 }
@@ -647,6 +738,23 @@ func (s *testSuiteP1) TestSelectWithoutFrom(c *C) {
 	r.Check(testkit.Rows("1"))
 }
 
+func (s *testSuiteP1) TestCompiledFilter(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a bigint, b int)")
+	tk.MustExec("insert into t values (1, 1), (2, 5), (3, null), (null, 4), (9223372036854775807, 1)")
+	// The limit keeps the selection in TiDB.
+	sql := "select * from (select * from t order by a limit 4) s where (a + 1 > b or b is null) and not (b - a = 3) order by a"
+	tk.MustQuery(sql).Check(testkit.Rows("1 1"))
+	tk.MustExec("set @@tidb_filter_compile_threshold = 1")
+	tk.MustQuery(sql).Check(testkit.Rows("1 1"))
+	// The overflow error is still reported.
+	err := tk.QueryToErr("select * from (select * from t order by a limit 5) s where a + 1 > b")
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Matches, ".*BIGINT value is out of range.*")
+}
+
 // TestSelectBackslashN Issue 3685.
 func (s *testSuiteP1) TestSelectBackslashN(c *C) {
 	tk := testkit.NewTestKit(c, s.store)
@@ -2424,6 +2532,64 @@ func (s *testSerialSuite) TestPointGetRepeatableRead(c *C) {
 	c.Assert(failpoint.Disable(step2), IsNil)
 }
 
+func (s *testSerialSuite) TestBatchUpdateConcurrentChange(c *C) {
+	tk1 := testkit.NewTestKit(c, s.store)
+	tk1.MustExec("use test")
+	tk1.MustExec("drop table if exists batch_update")
+	tk1.MustExec("create table batch_update (a int primary key, b int, key k_b(b))")
+	tk1.MustExec("insert into batch_update values (1, 1), (2, 2), (3, 3), (4, 4), (5, 5)")
+	tk1.MustExec("set @@session.tidb_batch_update = 1")
+	tk1.MustExec("set @@session.tidb_dml_batch_size = 2")
+	tk2 := testkit.NewTestKit(c, s.store)
+	tk2.MustExec("use test")
+
+	defer config.RestoreFunc()()
+	config.UpdateGlobal(func(conf *config.Config) {
+		conf.EnableBatchDML = true
+	})
+	var (
+		step1 = "github.com/pingcap/tidb/executor/batchUpdateTest-step1"
+		step2 = "github.com/pingcap/tidb/executor/batchUpdateTest-step2"
+	)
+	runBatchUpdate := func(sql string, change func()) error {
+		c.Assert(failpoint.Enable(step1, "return"), IsNil)
+		c.Assert(failpoint.Enable(step2, "pause"), IsNil)
+		updateWaitCh := make(chan struct{})
+		errCh := make(chan error, 1)
+		go func() {
+			ctx := context.WithValue(context.Background(), "batchUpdateTest", updateWaitCh)
+			ctx = failpoint.WithHook(ctx, func(ctx context.Context, fpname string) bool {
+				return fpname == step1 || fpname == step2
+			})
+			_, err := tk1.Se.Execute(ctx, sql)
+			errCh <- err
+		}()
+		// Wait the rows of the first batch written.
+		<-updateWaitCh
+		c.Assert(failpoint.Disable(step1), IsNil)
+		change()
+		c.Assert(failpoint.Disable(step2), IsNil)
+		return <-errCh
+	}
+
+	// The rows deleted after they are read are skipped.
+	err := runBatchUpdate("update batch_update set b = b + 10", func() {
+		tk2.MustExec("delete from batch_update where a = 4")
+	})
+	c.Assert(err, IsNil)
+	tk1.MustQuery("select * from batch_update").Check(testkit.Rows("1 11", "2 12", "3 13", "5 15"))
+	tk1.MustExec("admin check table batch_update")
+
+	// The update fails instead of overwriting the rows changed after they are read, the committed batches are kept.
+	err = runBatchUpdate("update batch_update set b = b + 10", func() {
+		tk2.MustExec("update batch_update set b = 100 where a = 3")
+	})
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Matches, ".*is changed after it's read.*")
+	tk1.MustQuery("select * from batch_update").Check(testkit.Rows("1 21", "2 22", "3 100", "5 15"))
+	tk1.MustExec("admin check table batch_update")
+}
+
 func (s *testSerialSuite) TestBatchPointGetRepeatableRead(c *C) {
 	tk1 := testkit.NewTestKit(c, s.store)
 	tk1.MustExec("use test")
@@ -3096,6 +3262,40 @@ func (s *testSerialSuite) TestTiDBLastTxnInfoCommitMode(c *C) {
 	c.Assert(rows[0][2], Equals, "true")
 }
 
+func (s *testSerialSuite) TestAsyncCommitRegionsLimit(c *C) {
+	defer config.RestoreFunc()()
+	config.UpdateGlobal(func(conf *config.Config) {
+		conf.TiKVClient.AsyncCommit.SafeWindow = time.Second
+	})
+
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int primary key, v int)")
+	defer executor.SetMinRegionStepValueForTest(10)()
+	tk.MustQuery("split table t between (0) and (100) regions 4").Check(testkit.Rows("3 1"))
+	tk.MustExec("set @@tidb_enable_async_commit = 1")
+	tk.MustExec("set @@tidb_enable_1pc = 0")
+	lastCommitMode := func() string {
+		return tk.MustQuery("select json_extract(@@tidb_last_txn_info, '$.txn_commit_mode')").Rows()[0][0].(string)
+	}
+
+	// The keys span 3 regions.
+	tk.MustExec("set @@tidb_async_commit_regions_limit = 3")
+	tk.MustExec("insert into t values (1, 1), (30, 1), (60, 1)")
+	c.Assert(lastCommitMode(), Equals, `"async_commit"`)
+	tk.MustExec("set @@tidb_async_commit_regions_limit = 2")
+	tk.MustExec("update t set v = v + 1")
+	c.Assert(lastCommitMode(), Equals, `"2pc"`)
+	// The keys in a single region are not affected.
+	tk.MustExec("update t set v = v + 1 where a = 1")
+	c.Assert(lastCommitMode(), Equals, `"async_commit"`)
+	// 0 means no limit.
+	tk.MustExec("set @@tidb_async_commit_regions_limit = 0")
+	tk.MustExec("update t set v = v + 1")
+	c.Assert(lastCommitMode(), Equals, `"async_commit"`)
+}
+
 func (s *testSuite) TestTiDBLastQueryInfo(c *C) {
 	tk := testkit.NewTestKit(c, s.store)
 	tk.MustExec("use test")
@@ -7106,6 +7306,56 @@ func (s *testSlowQuery) TestLogSlowLogIndex(c *C) {
 		))
 }
 
+func (s *testSlowQuery) TestSlowLogJSONFormat(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	f, err := os.CreateTemp("", "tidb-slow-*.log")
+	c.Assert(err, IsNil)
+	f.Close()
+	defer os.Remove(f.Name())
+
+	defer config.RestoreFunc()()
+	config.UpdateGlobal(func(conf *config.Config) {
+		conf.Log.SlowQueryFile = f.Name()
+	})
+	err = logutil.InitLogger(config.GetGlobalConfig().Log.ToLogConfig())
+	c.Assert(err, IsNil)
+
+	tk.MustExec("use test")
+	tk.MustExec("create table t_slow_json (a int, b int, index idx(a))")
+	tk.MustExec("set tidb_slow_log_threshold=0")
+	tk.MustExec("set @@global.tidb_slow_log_format='json'")
+	tk.MustQuery("select * from t_slow_json use index (idx) where a > 1 and b = 2")
+	tk.MustExec("set @@global.tidb_slow_log_format='text'")
+	tk.MustExec("set tidb_slow_log_threshold=300")
+
+	content, err := os.ReadFile(f.Name())
+	c.Assert(err, IsNil)
+	var l struct {
+		Query string
+		DB    string
+		Plan  []*plannercore.ExplainNode
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.HasPrefix(line, "{") && strings.Contains(line, "t_slow_json use index") {
+			c.Assert(json.Unmarshal([]byte(line), &l), IsNil)
+		}
+	}
+	c.Assert(l.Query, Equals, "select * from t_slow_json use index (idx) where a > 1 and b = 2")
+	c.Assert(l.DB, Equals, "test")
+	c.Assert(l.Plan, HasLen, 1)
+	c.Assert(l.Plan[0].ID, Matches, "IndexLookUp_.*")
+	c.Assert(l.Plan[0].TaskType, Equals, "root")
+	c.Assert(l.Plan[0].ActRows, Equals, "0")
+	c.Assert(l.Plan[0].Children, HasLen, 2)
+	c.Assert(l.Plan[0].Children[0].ID, Matches, "IndexRangeScan_.*\\(Build\\)")
+	c.Assert(l.Plan[0].Children[0].AccessObject, Equals, "table:t_slow_json, index:idx(a)")
+	c.Assert(l.Plan[0].Children[1].Children[0].TaskType, Equals, "cop[tikv]")
+
+	// The slow log in JSON is skipped by information_schema.slow_query.
+	tk.MustQuery("select count(*) from information_schema.slow_query where query like '%t_slow_json use index%'").Check(testkit.Rows("0"))
+	tk.MustQuery("select count(*) from information_schema.slow_query where query like 'set @@global.tidb_slow_log_format%'").Check(testkit.Rows("1"))
+}
+
 func (s *testSlowQuery) TestSlowQuery(c *C) {
 	tk := testkit.NewTestKit(c, s.store)
 
@@ -8554,6 +8804,20 @@ func (s *testResourceTagSuite) TestResourceGroupTag(c *C) {
 		}
 		c.Assert(checkCnt > 0, IsTrue, commentf)
 	}
+
+	// The plan digest isn't generated for the tags when Top SQL is disabled.
+	variable.TopSQLVariable.Enable.Store(false)
+	resetVars()
+	checkCnt := 0
+	checkFn = func() {
+		checkCnt++
+	}
+	sql := "select * from t where b>1"
+	_, expectSQLDigest := parser.NormalizeDigest(sql)
+	tk.MustQuery(sql)
+	c.Assert(checkCnt > 0, IsTrue)
+	c.Assert(sqlDigest.String(), Equals, expectSQLDigest.String())
+	c.Assert(planDigest.String(), Equals, "")
 }
 
 func (s *testStaleTxnSuite) TestInvalidReadTemporaryTable(c *C) {
@@ -8649,3 +8913,60 @@ func (s *testStaleTxnSuite) TestInvalidReadTemporaryTable(c *C) {
 		tk.MustGetErrMsg(query.sql, "can not read temporary table when 'tidb_snapshot' is set")
 	}
 }
+
+func (s *testSerialSuite) TestResourceGroup(c *C) {
+	originCfg := config.GetGlobalConfig()
+	newCfg := *originCfg
+	newCfg.ResourceGroups = []config.ResourceGroup{
+		{Name: "olap", Users: []string{"root"}, Priority: "LOW_PRIORITY", MemQuotaQuery: 1 << 20, CopConcurrency: 2},
+		{Name: "oltp", Priority: "HIGH_PRIORITY"},
+	}
+	config.StoreGlobalConfig(&newCfg)
+	defer config.StoreGlobalConfig(originCfg)
+
+	tk := testkit.NewTestKitWithInit(c, s.store)
+	c.Assert(tk.Se.Auth(&auth.UserIdentity{Username: "root", Hostname: "%"}, nil, nil), IsTrue)
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int)")
+	tk.MustExec("insert into t values (1), (2)")
+
+	// The statements of root are in the olap group.
+	tk.MustQuery("select * from t").Sort().Check(testkit.Rows("1", "2"))
+	sc := tk.Se.GetSessionVars().StmtCtx
+	c.Assert(sc.ResourceGroupName, Equals, "olap")
+	c.Assert(sc.Priority, Equals, mysql.LowPriority)
+	c.Assert(sc.MemTracker.GetBytesLimit(), Equals, int64(1<<20))
+	c.Assert(sc.CopConcurrencyLimit, Equals, 2)
+
+	// The priority of the statement takes precedence over the group.
+	tk.MustQuery("select high_priority * from t").Sort().Check(testkit.Rows("1", "2"))
+	c.Assert(tk.Se.GetSessionVars().StmtCtx.Priority, Equals, mysql.HighPriority)
+
+	// The group of a statement can be set by the SET_VAR hint.
+	tk.MustQuery("select /*+ SET_VAR(tidb_resource_group=oltp) */ * from t").Sort().Check(testkit.Rows("1", "2"))
+	sc = tk.Se.GetSessionVars().StmtCtx
+	c.Assert(sc.ResourceGroupName, Equals, "oltp")
+	c.Assert(sc.Priority, Equals, mysql.HighPriority)
+	c.Assert(sc.CopConcurrencyLimit, Equals, 0)
+
+	tk.MustExec("set @@tidb_resource_group = 'oltp'")
+	tk.MustQuery("select * from t").Sort().Check(testkit.Rows("1", "2"))
+	c.Assert(tk.Se.GetSessionVars().StmtCtx.ResourceGroupName, Equals, "oltp")
+	tk.MustGetErrCode("set @@tidb_resource_group = 'unknown'", errno.ErrWrongValueForVar)
+
+	// The users can only use the groups listing them unless they have the RESOURCE_GROUP_ADMIN privilege.
+	tk.MustExec("drop user if exists rg_user")
+	tk.MustExec("create user rg_user")
+	tk.MustExec("grant select on test.t to rg_user")
+	tk1 := testkit.NewTestKitWithInit(c, s.store)
+	c.Assert(tk1.Se.Auth(&auth.UserIdentity{Username: "rg_user", Hostname: "%"}, nil, nil), IsTrue)
+	tk1.MustGetErrCode("set @@tidb_resource_group = 'olap'", errno.ErrSpecificAccessDenied)
+	tk1.MustQuery("select /*+ SET_VAR(tidb_resource_group=olap) */ * from t").Sort().Check(testkit.Rows("1", "2"))
+	c.Assert(tk1.Se.GetSessionVars().StmtCtx.ResourceGroupName, Equals, "")
+	tk1.MustQuery("show warnings").Check(testkit.Rows("Warning 1227 Access denied; you need (at least one of) the SUPER or RESOURCE_GROUP_ADMIN privilege(s) for this operation"))
+	tk.MustExec("grant RESOURCE_GROUP_ADMIN on *.* to rg_user")
+	tk1.MustExec("set @@tidb_resource_group = 'olap'")
+	tk1.MustQuery("select * from t").Sort().Check(testkit.Rows("1", "2"))
+	c.Assert(tk1.Se.GetSessionVars().StmtCtx.ResourceGroupName, Equals, "olap")
+	tk.MustExec("drop user rg_user")
+}
@@ -16,6 +16,7 @@ package executor_test
 import (
 	"bytes"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"math"
 	"strconv"
@@ -135,6 +136,34 @@ func (s *testSerialSuite) TestExplainFor(c *C) {
 	tkRoot.MustExec(fmt.Sprintf("explain for connection %d", tkRootProcess.ID))
 }
 
+func (s *testSerialSuite) TestExplainForConnectionJSONAndDot(c *C) {
+	tk := testkit.NewTestKitWithInit(c, s.store)
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int, b int)")
+	tk.MustExec("insert into t values(1,1),(2,2)")
+	tk.Se.Auth(&auth.UserIdentity{Username: "root", Hostname: "localhost", CurrentUser: true, AuthUsername: "root", AuthHostname: "%"}, nil, []byte("012345678901234567890"))
+	tk.MustExec("set @@tidb_enable_collect_execution_info=1;")
+	tk.MustQuery("select * from t where a > 1").Check(testkit.Rows("2 2"))
+	process := tk.Se.ShowProcess()
+	tk.Se.SetSessionManager(&mockSessionManager1{PS: []*util.ProcessInfo{process}})
+
+	rows := tk.MustQuery(fmt.Sprintf("explain format='json' for connection %d", process.ID)).Rows()
+	c.Assert(rows, HasLen, 1)
+	var nodes []*core.ExplainNode
+	c.Assert(json.Unmarshal([]byte(rows[0][0].(string)), &nodes), IsNil)
+	c.Assert(nodes, HasLen, 1)
+	c.Assert(nodes[0].ID, Matches, "TableReader_.*")
+	c.Assert(nodes[0].ActRows, Equals, "1")
+	c.Assert(nodes[0].ExecutionInfo, Matches, "time:.*, loops:.*")
+	c.Assert(nodes[0].Memory, Not(Equals), "")
+	c.Assert(nodes[0].Children, HasLen, 1)
+	c.Assert(nodes[0].Children[0].ID, Matches, "Selection_.*")
+	c.Assert(nodes[0].Children[0].ActRows, Equals, "1")
+
+	dot := tk.MustQuery(fmt.Sprintf("explain format='dot' for connection %d", process.ID)).Rows()[0][0].(string)
+	c.Assert(dot, Matches, `(?s).*"TableReader_\d+" \[label="TableReader_\d+\\nactRows: 1\\nmemory: .*, disk: .*\\ntime:.*"\].*`)
+}
+
 func (s *testSerialSuite) TestIssue11124(c *C) {
 	tk := testkit.NewTestKitWithInit(c, s.store)
 	tk2 := testkit.NewTestKitWithInit(c, s.store)
@@ -341,6 +370,8 @@ func (s *testPrepareSerialSuite) TestExplainDotForQuery(c *C) {
 	rows := tk.MustQuery("select connection_id()").Rows()
 	c.Assert(len(rows), Equals, 1)
 	connID := rows[0][0].(string)
+	// The runtime stats are shown in the nodes if they're collected.
+	tk.MustExec("set @@tidb_enable_collect_execution_info=0")
 	tk.MustQuery("select 1")
 	tkProcess := tk.Se.ShowProcess()
 	ps := []*util.ProcessInfo{tkProcess}
@@ -17,6 +17,8 @@ import (
 	"fmt"
 	"hash"
 	"hash/fnv"
+	"math/bits"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -38,6 +40,8 @@ type hashContext struct {
 	buf       []byte
 	hashVals  []hash.Hash64
 	hasNull   []bool
+	// probeStat counts the probes with this context, it's merged to the hashStatistic after probing.
+	probeStat probeStatistic
 }
 
 func (hc *hashContext) initHash(rows int) {
@@ -62,10 +66,34 @@ func (hc *hashContext) initHash(rows int) {
 type hashStatistic struct {
 	probeCollision   int
 	buildTableElapse time.Duration
+	probeStatistic
 }
 
 func (s *hashStatistic) String() string {
-	return fmt.Sprintf("probe_collision:%v, build:%v", s.probeCollision, execdetails.FormatDuration(s.buildTableElapse))
+	str := fmt.Sprintf("probe_collision:%v, build:%v", s.probeCollision, execdetails.FormatDuration(s.buildTableElapse))
+	if s.probes > 0 {
+		str += ", " + s.probeStatistic.String()
+	}
+	return str
+}
+
+// probeStatistic is the statistic of probing the hash table.
+type probeStatistic struct {
+	probes int64
+	// probeLength is the number of the entries in the probed buckets.
+	probeLength int64
+	// tagFiltered is the number of the entries filtered by their tags, whose rows are not read.
+	tagFiltered int64
+}
+
+func (s *probeStatistic) merge(other *probeStatistic) {
+	atomic.AddInt64(&s.probes, other.probes)
+	atomic.AddInt64(&s.probeLength, other.probeLength)
+	atomic.AddInt64(&s.tagFiltered, other.tagFiltered)
+}
+
+func (s *probeStatistic) String() string {
+	return fmt.Sprintf("avg_probe_length:%.1f, tag_filtered:%v", float64(s.probeLength)/float64(s.probes), s.tagFiltered)
 }
 
 // hashRowContainer handles the rows and the hash map of a table.
@@ -75,7 +103,7 @@ type hashRowContainer struct {
 	stat hashStatistic
 
 	// hashTable stores the map of hashKey and RowPtr
-	hashTable baseHashTable
+	hashTable *taggedHashTable
 
 	rowContainer *chunk.RowContainer
 }
@@ -86,7 +114,7 @@ func newHashRowContainer(sCtx sessionctx.Context, estCount int, hCtx *hashContex
 	c := &hashRowContainer{
 		sc:           sCtx.GetSessionVars().StmtCtx,
 		hCtx:         hCtx,
-		hashTable:    newConcurrentMapHashTable(),
+		hashTable:    newTaggedHashTable(),
 		rowContainer: rc,
 	}
 	return c
@@ -96,15 +124,22 @@ func newHashRowContainer(sCtx sessionctx.Context, estCount int, hCtx *hashContex
 // in multiple goroutines while each goroutine should keep its own
 // h and buf.
 func (c *hashRowContainer) GetMatchedRowsAndPtrs(probeKey uint64, probeRow chunk.Row, hCtx *hashContext) (matched []chunk.Row, matchedPtrs []chunk.RowPtr, err error) {
-	innerPtrs := c.hashTable.Get(probeKey)
+	innerPtrs := c.hashTable.get(probeKey, &hCtx.probeStat)
 	if len(innerPtrs) == 0 {
 		return
 	}
 	matched = make([]chunk.Row, 0, len(innerPtrs))
 	var matchedRow chunk.Row
 	matchedPtrs = make([]chunk.RowPtr, 0, len(innerPtrs))
+	// When the rows are in disk, only the join keys are read to check the
+	// hash collision, the other columns are read only if the keys are matched.
+	spilled := c.rowContainer.AlreadySpilled()
 	for _, ptr := range innerPtrs {
-		matchedRow, err = c.rowContainer.GetRow(ptr)
+		if spilled {
+			matchedRow, err = c.rowContainer.GetRowWithCols(ptr, c.hCtx.keyColIdx)
+		} else {
+			matchedRow, err = c.rowContainer.GetRow(ptr)
+		}
 		if err != nil {
 			return
 		}
@@ -117,12 +152,57 @@ func (c *hashRowContainer) GetMatchedRowsAndPtrs(probeKey uint64, probeRow chunk
 			c.stat.probeCollision++
 			continue
 		}
+		if spilled {
+			matchedRow, err = c.rowContainer.GetRestOfRow(ptr, c.hCtx.keyColIdx, matchedRow)
+			if err != nil {
+				return
+			}
+		}
 		matched = append(matched, matchedRow)
 		matchedPtrs = append(matchedPtrs, ptr)
 	}
 	return
 }
 
+// GetFirstMatchedRow gets the first row matching the join keys of probeRow, the other rows are not read. It's used
+// when the join result only depends on whether there's a matched row. It can be called in multiple goroutines while
+// each goroutine should keep its own hCtx.
+func (c *hashRowContainer) GetFirstMatchedRow(probeKey uint64, probeRow chunk.Row, hCtx *hashContext) (matched chunk.Row, ok bool, err error) {
+	entries := c.hashTable.bucketEntries(probeKey)
+	hCtx.probeStat.probes++
+	spilled := c.rowContainer.AlreadySpilled()
+	for i, entry := range entries {
+		if !c.hashTable.tagMatched(entry, probeKey) {
+			hCtx.probeStat.tagFiltered++
+			continue
+		}
+		ptr := c.hashTable.rowPtr(entry)
+		if spilled {
+			matched, err = c.rowContainer.GetRowWithCols(ptr, c.hCtx.keyColIdx)
+		} else {
+			matched, err = c.rowContainer.GetRow(ptr)
+		}
+		if err != nil {
+			return
+		}
+		ok, err = c.matchJoinKey(matched, probeRow, hCtx)
+		if err != nil {
+			return
+		}
+		if !ok {
+			c.stat.probeCollision++
+			continue
+		}
+		hCtx.probeStat.probeLength += int64(i + 1)
+		if spilled {
+			matched, err = c.rowContainer.GetRestOfRow(ptr, c.hCtx.keyColIdx, matched)
+		}
+		return
+	}
+	hCtx.probeStat.probeLength += int64(len(entries))
+	return matched, false, nil
+}
+
 // matchJoinKey checks if join keys of buildRow and probeRow are logically equal.
 func (c *hashRowContainer) matchJoinKey(buildRow, probeRow chunk.Row, probeHCtx *hashContext) (ok bool, err error) {
 	return codec.EqualChunkRow(c.sc,
@@ -176,6 +256,19 @@ func (c *hashRowContainer) PutChunkSelected(chk *chunk.Chunk, selected, ignoreNu
 	return nil
 }
 
+// FinishBuild builds the hash table after all the chunks are put, it's called before probing.
+func (c *hashRowContainer) FinishBuild() {
+	start := time.Now()
+	c.hashTable.build()
+	c.stat.buildTableElapse += time.Since(start)
+}
+
+// MergeProbeStat merges the statistic of the probes with hCtx, it can be called concurrently.
+func (c *hashRowContainer) MergeProbeStat(hCtx *hashContext) {
+	c.stat.probeStatistic.merge(&hCtx.probeStat)
+	hCtx.probeStat = probeStatistic{}
+}
+
 // NumChunks returns the number of chunks in the rowContainer
 func (c *hashRowContainer) NumChunks() int {
 	return c.rowContainer.NumChunks()
@@ -344,3 +437,123 @@ func (ht *concurrentMapHashTable) Get(hashKey uint64) (rowPtrs []chunk.RowPtr) {
 	}
 	return
 }
+
+// taggedHashTable is the hash table of hash join, which is built after all the rows are put, and then probed by
+// multiple goroutines concurrently. The entries of a bucket are stored continuously, each of them packs the RowPtr
+// in the low bits and the high bits of the hash key as a tag in the other bits, which are at least 16 bits if the
+// RowPtrs fit in 48 bits. So the entries of the other keys in the bucket are mostly filtered by the tags, without
+// reading their rows or following pointers.
+type taggedHashTable struct {
+	// hashKeys and rowPtrs are the entries put before building.
+	hashKeys  []uint64
+	rowPtrs   []chunk.RowPtr
+	maxChkIdx uint32
+	maxRowIdx uint32
+	length    uint64
+
+	buildOnce  sync.Once
+	bucketMask uint64
+	// offsets[i] is the index of the first entry of the i-th bucket in entries.
+	offsets []uint32
+	entries []uint64
+	// rowIdxBits and ptrBits are the numbers of the bits of RowPtr.RowIdx and the whole RowPtr in an entry.
+	rowIdxBits uint
+	ptrBits    uint
+}
+
+func newTaggedHashTable() *taggedHashTable {
+	return &taggedHashTable{}
+}
+
+// Put puts the key/rowPtr pairs to the taggedHashTable, it can't be called after the taggedHashTable is built.
+func (ht *taggedHashTable) Put(hashKey uint64, rowPtr chunk.RowPtr) {
+	ht.hashKeys = append(ht.hashKeys, hashKey)
+	ht.rowPtrs = append(ht.rowPtrs, rowPtr)
+	if rowPtr.ChkIdx > ht.maxChkIdx {
+		ht.maxChkIdx = rowPtr.ChkIdx
+	}
+	if rowPtr.RowIdx > ht.maxRowIdx {
+		ht.maxRowIdx = rowPtr.RowIdx
+	}
+	ht.length++
+}
+
+// build builds the buckets from the entries put, it's called once before the first Get.
+func (ht *taggedHashTable) build() {
+	ht.buildOnce.Do(func() {
+		numBuckets := 1
+		for numBuckets < len(ht.hashKeys) {
+			numBuckets <<= 1
+		}
+		ht.bucketMask = uint64(numBuckets - 1)
+		ht.rowIdxBits = uint(bits.Len32(ht.maxRowIdx))
+		ht.ptrBits = ht.rowIdxBits + uint(bits.Len32(ht.maxChkIdx))
+
+		ht.offsets = make([]uint32, numBuckets+1)
+		for _, hashKey := range ht.hashKeys {
+			ht.offsets[hashKey&ht.bucketMask+1]++
+		}
+		for i := 1; i <= numBuckets; i++ {
+			ht.offsets[i] += ht.offsets[i-1]
+		}
+		cursors := make([]uint32, numBuckets)
+		copy(cursors, ht.offsets)
+		ht.entries = make([]uint64, len(ht.hashKeys))
+		// The rowPtrs of the same key are got from the last put one, the same as the other hash tables.
+		for i := len(ht.hashKeys) - 1; i >= 0; i-- {
+			bucket := ht.hashKeys[i] & ht.bucketMask
+			ht.entries[cursors[bucket]] = ht.tag(ht.hashKeys[i]) | uint64(ht.rowPtrs[i].ChkIdx)<<ht.rowIdxBits | uint64(ht.rowPtrs[i].RowIdx)
+			cursors[bucket]++
+		}
+		ht.hashKeys, ht.rowPtrs = nil, nil
+	})
+}
+
+// tag returns the high bits of the hash key not used by the RowPtr in an entry.
+func (ht *taggedHashTable) tag(hashKey uint64) uint64 {
+	return hashKey &^ (1<<ht.ptrBits - 1)
+}
+
+// Get gets the values of the "key" and appends them to "values".
+func (ht *taggedHashTable) Get(hashKey uint64) (rowPtrs []chunk.RowPtr) {
+	return ht.get(hashKey, nil)
+}
+
+// get returns the rowPtrs of the entries in the bucket of the hash key whose tags match, the rowPtrs of the other
+// keys with the same tag are also returned, which are filtered by comparing the join keys. It counts the probe in
+// stat if it's not nil.
+func (ht *taggedHashTable) get(hashKey uint64, stat *probeStatistic) (rowPtrs []chunk.RowPtr) {
+	entries := ht.bucketEntries(hashKey)
+	for _, entry := range entries {
+		if ht.tagMatched(entry, hashKey) {
+			rowPtrs = append(rowPtrs, ht.rowPtr(entry))
+		}
+	}
+	if stat != nil {
+		stat.probes++
+		stat.probeLength += int64(len(entries))
+		stat.tagFiltered += int64(len(entries) - len(rowPtrs))
+	}
+	return rowPtrs
+}
+
+// bucketEntries returns the entries in the bucket of the hash key.
+func (ht *taggedHashTable) bucketEntries(hashKey uint64) []uint64 {
+	ht.build()
+	bucket := hashKey & ht.bucketMask
+	return ht.entries[ht.offsets[bucket]:ht.offsets[bucket+1]]
+}
+
+func (ht *taggedHashTable) tagMatched(entry, hashKey uint64) bool {
+	return entry&^(1<<ht.ptrBits-1) == ht.tag(hashKey)
+}
+
+func (ht *taggedHashTable) rowPtr(entry uint64) chunk.RowPtr {
+	return chunk.RowPtr{
+		ChkIdx: uint32((entry & (1<<ht.ptrBits - 1)) >> ht.rowIdxBits),
+		RowIdx: uint32(entry & (1<<ht.rowIdxBits - 1)),
+	}
+}
+
+// Len returns the number of rowPtrs in the taggedHashTable.
+func (ht *taggedHashTable) Len() uint64 { return ht.length }
@@ -102,6 +102,30 @@ func (s *pkgTestSerialSuite) TestHashRowContainer(c *C) {
 	c.Assert(rowContainer.stat.buildTableElapse >= 0, IsTrue)
 }
 
+func (s *pkgTestSerialSuite) TestTaggedHashTable(c *C) {
+	ht := newTaggedHashTable()
+	// The keys 1 and 1<<32|1 are in the same bucket with different tags, the keys 2 and 2|1<<5 are in the same
+	// bucket with the same tag, since the low bits are used by the RowPtrs.
+	keys := []uint64{1, 1<<32 | 1, 1, 2, 2 | 1<<5, 3}
+	for i, key := range keys {
+		ht.Put(key, chunk.RowPtr{ChkIdx: uint32(i / 2), RowIdx: uint32(i % 2 * 100)})
+	}
+	c.Assert(ht.Len(), Equals, uint64(len(keys)))
+
+	var stat probeStatistic
+	c.Assert(ht.get(1, &stat), DeepEquals, []chunk.RowPtr{{ChkIdx: 1, RowIdx: 0}, {ChkIdx: 0, RowIdx: 0}})
+	c.Assert(ht.get(1<<32|1, &stat), DeepEquals, []chunk.RowPtr{{ChkIdx: 0, RowIdx: 100}})
+	c.Assert(ht.get(2, &stat), DeepEquals, []chunk.RowPtr{{ChkIdx: 2, RowIdx: 0}, {ChkIdx: 1, RowIdx: 100}})
+	c.Assert(ht.get(3, &stat), DeepEquals, []chunk.RowPtr{{ChkIdx: 2, RowIdx: 100}})
+	c.Assert(ht.Get(4), HasLen, 0)
+	c.Assert(stat, Equals, probeStatistic{probes: 4, probeLength: 9, tagFiltered: 3})
+
+	var total probeStatistic
+	total.merge(&stat)
+	total.merge(&stat)
+	c.Assert(total.probes, Equals, int64(8))
+}
+
 func (s *pkgTestSerialSuite) testHashRowContainer(c *C, hashFunc func() hash.Hash64, spill bool) *hashRowContainer {
 	sctx := mock.NewContext()
 	var err error
@@ -151,5 +175,10 @@ func (s *pkgTestSerialSuite) testHashRowContainer(c *C, hashFunc func() hash.Has
 	c.Assert(len(matched), Equals, 2)
 	c.Assert(matched[0].GetDatumRow(colTypes), DeepEquals, chk0.GetRow(1).GetDatumRow(colTypes))
 	c.Assert(matched[1].GetDatumRow(colTypes), DeepEquals, chk1.GetRow(1).GetDatumRow(colTypes))
+
+	first, ok, err := rowContainer.GetFirstMatchedRow(hCtx.hashVals[1].Sum64(), probeRow, probeCtx)
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsTrue)
+	c.Assert(first.GetDatumRow(colTypes), DeepEquals, matched[0].GetDatumRow(colTypes))
 	return rowContainer
 }
@@ -554,7 +554,12 @@ func (iw *innerWorker) constructLookupContent(task *lookUpJoinTask) ([]*indexJoi
 	for i := range task.encodedLookUpKeys {
 		task.memTracker.Consume(task.encodedLookUpKeys[i].MemoryUsage())
 	}
+	lookupKeys := len(lookUpContents)
 	lookUpContents = iw.sortAndDedupLookUpContents(lookUpContents)
+	if iw.stats != nil {
+		atomic.AddInt64(&iw.stats.lookupKeys, int64(lookupKeys))
+		atomic.AddInt64(&iw.stats.dedupKeys, int64(lookupKeys-len(lookUpContents)))
+	}
 	return lookUpContents, nil
 }
 
@@ -741,6 +746,10 @@ type innerWorkerRuntimeStats struct {
 	fetch     int64
 	build     int64
 	join      int64
+	// lookupKeys is the number of the lookup keys constructed from the outer rows.
+	lookupKeys int64
+	// dedupKeys is the number of the duplicated lookup keys removed before reading the inner side.
+	dedupKeys int64
 }
 
 func (e *indexLookUpJoinRuntimeStats) String() string {
@@ -766,6 +775,12 @@ func (e *indexLookUpJoinRuntimeStats) String() string {
 			buf.WriteString(", join:")
 			buf.WriteString(execdetails.FormatDuration(time.Duration(e.innerWorker.join)))
 		}
+		if e.innerWorker.lookupKeys > 0 {
+			buf.WriteString(", lookup_keys:")
+			buf.WriteString(strconv.FormatInt(e.innerWorker.lookupKeys, 10))
+			buf.WriteString(", dedup_ratio:")
+			buf.WriteString(strconv.FormatFloat(float64(e.innerWorker.dedupKeys)/float64(e.innerWorker.lookupKeys), 'f', 2, 64))
+		}
 		buf.WriteString("}")
 	}
 	if e.probe > 0 {
@@ -795,6 +810,8 @@ func (e *indexLookUpJoinRuntimeStats) Merge(rs execdetails.RuntimeStats) {
 	e.innerWorker.fetch += tmp.innerWorker.fetch
 	e.innerWorker.build += tmp.innerWorker.build
 	e.innerWorker.join += tmp.innerWorker.join
+	e.innerWorker.lookupKeys += tmp.innerWorker.lookupKeys
+	e.innerWorker.dedupKeys += tmp.innerWorker.dedupKeys
 }
 
 // Tp implements the RuntimeStats interface.
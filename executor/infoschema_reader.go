@@ -54,14 +54,18 @@ import (
 	"github.com/pingcap/tidb/util/chunk"
 	"github.com/pingcap/tidb/util/collate"
 	"github.com/pingcap/tidb/util/deadlockhistory"
+	"github.com/pingcap/tidb/util/lockwaithistory"
 	"github.com/pingcap/tidb/util/logutil"
 	"github.com/pingcap/tidb/util/pdapi"
+	"github.com/pingcap/tidb/util/plancapture"
 	"github.com/pingcap/tidb/util/resourcegrouptag"
+	"github.com/pingcap/tidb/util/runaway"
 	"github.com/pingcap/tidb/util/sem"
 	"github.com/pingcap/tidb/util/set"
 	"github.com/pingcap/tidb/util/sqlexec"
 	"github.com/pingcap/tidb/util/stmtsummary"
 	"github.com/pingcap/tidb/util/stringutil"
+	"github.com/pingcap/tidb/util/tabletraffic"
 	"go.etcd.io/etcd/clientv3"
 	"go.uber.org/zap"
 )
@@ -166,6 +170,20 @@ func (e *memtableRetriever) retrieve(ctx context.Context, sctx sessionctx.Contex
 			err = e.setDataForClusterDeadlock(sctx)
 		case infoschema.TableDataLockWaits:
 			err = e.setDataForTableDataLockWaits(sctx)
+		case infoschema.TableDataLockWaitHistory:
+			err = e.setDataForTableDataLockWaitHistory(sctx)
+		case infoschema.TablePlanCaptures:
+			err = e.setDataForPlanCaptures(sctx)
+		case infoschema.TableTableTraffic:
+			e.setDataForTableTraffic(sctx)
+		case infoschema.TableOptimizerTrace:
+			err = e.setDataForOptimizerTrace(sctx)
+		case infoschema.TableAutoAnalyzeQueue:
+			e.setDataForAutoAnalyzeQueue(sctx)
+		case infoschema.TableRunawayQueries:
+			e.setDataForRunawayQueries(sctx)
+		case infoschema.TableSessionConnectAttrs:
+			e.setDataForSessionConnectAttrs(sctx)
 		}
 		if err != nil {
 			return nil, err
@@ -1047,6 +1065,192 @@ func (e *memtableRetriever) setDataForTableDataLockWaits(ctx sessionctx.Context)
 	return nil
 }
 
+func (e *memtableRetriever) setDataForTableDataLockWaitHistory(ctx sessionctx.Context) error {
+	if !hasPriv(ctx, mysql.ProcessPriv) {
+		return plannercore.ErrSpecificAccessDenied.GenWithStackByArgs("PROCESS")
+	}
+	for _, rec := range lockwaithistory.GlobalLockWaitHistory.GetAll() {
+		var sqlDigest, key, trxHoldingLock interface{}
+		if len(rec.SQLDigest) > 0 {
+			sqlDigest = rec.SQLDigest
+		}
+		if len(rec.Key) > 0 {
+			key = strings.ToUpper(hex.EncodeToString(rec.Key))
+		}
+		if rec.TrxHoldingLock != 0 {
+			trxHoldingLock = rec.TrxHoldingLock
+		}
+		e.rows = append(e.rows, types.MakeDatums(
+			types.NewTime(types.FromGoTime(rec.OccurTime), mysql.TypeTimestamp, types.MaxFsp),
+			rec.WaitTime.Seconds(),
+			rec.TrxID,
+			sqlDigest,
+			key,
+			trxHoldingLock,
+			rec.Result,
+		))
+	}
+	return nil
+}
+
+func (e *memtableRetriever) setDataForPlanCaptures(ctx sessionctx.Context) error {
+	if !hasPriv(ctx, mysql.SuperPriv) {
+		return plannercore.ErrSpecificAccessDenied.GenWithStackByArgs("SUPER")
+	}
+	for _, digest := range plancapture.PendingDigests() {
+		e.rows = append(e.rows, types.MakeDatums(digest, "PENDING", nil, nil, nil))
+	}
+	bundles, err := plancapture.ListBundles()
+	if err != nil {
+		return err
+	}
+	for _, bundle := range bundles {
+		e.rows = append(e.rows, types.MakeDatums(
+			bundle.Digest,
+			"CAPTURED",
+			bundle.FileName,
+			types.NewTime(types.FromGoTime(bundle.CaptureTime), mysql.TypeDatetime, types.MaxFsp),
+			bundle.Size,
+		))
+	}
+	return nil
+}
+
+func (e *memtableRetriever) setDataForTableTraffic(ctx sessionctx.Context) {
+	checker := privilege.GetPrivilegeManager(ctx)
+	is := ctx.GetInfoSchema().(infoschema.InfoSchema)
+	for _, traffic := range tabletraffic.Tables() {
+		// The traffic of the dropped tables is skipped.
+		var (
+			db   *model.DBInfo
+			tbl  table.Table
+			part *model.PartitionDefinition
+			ok   bool
+		)
+		if tbl, ok = is.TableByID(traffic.TableID); ok {
+			if db, ok = is.SchemaByTable(tbl.Meta()); !ok {
+				continue
+			}
+		} else if tbl, db, part = is.FindTableByPartitionID(traffic.TableID); tbl == nil {
+			continue
+		}
+		if checker != nil && !checker.RequestVerification(ctx.GetSessionVars().ActiveRoles, db.Name.L, tbl.Meta().Name.L, "", mysql.AllPrivMask) {
+			continue
+		}
+		row := types.MakeDatums(
+			db.Name.O,
+			tbl.Meta().Name.O,
+			nil,
+			traffic.TableID,
+			traffic.ReadKeys,
+			traffic.ReadBytes,
+			traffic.WriteKeys,
+			traffic.WriteBytes,
+		)
+		if part != nil {
+			row[2].SetString(part.Name.O, mysql.DefaultCollationName)
+		}
+		e.rows = append(e.rows, row)
+	}
+}
+
+func (e *memtableRetriever) setDataForOptimizerTrace(ctx sessionctx.Context) error {
+	// The statement reading the optimizer trace isn't traced, so the trace of the last statement is kept.
+	ctx.GetSessionVars().StmtCtx.ClearOptimizerTrace()
+	trace := ctx.GetSessionVars().LastOptimizerTrace
+	if trace == nil {
+		return nil
+	}
+	// The steps are shown as a JSON document in TRACE like MySQL.
+	steps, err := json.MarshalIndent(trace.Steps, "", "  ")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	e.rows = append(e.rows, types.MakeDatums(
+		trace.Query,
+		string(steps),
+		0, // MISSING_BYTES_BEYOND_MAX_MEM_SIZE
+		0, // INSUFFICIENT_PRIVILEGES
+	))
+	return nil
+}
+
+func (e *memtableRetriever) setDataForAutoAnalyzeQueue(ctx sessionctx.Context) {
+	do := domain.GetDomain(ctx)
+	if do == nil || do.StatsHandle() == nil {
+		return
+	}
+	checker := privilege.GetPrivilegeManager(ctx)
+	for _, job := range do.StatsHandle().AutoAnalyzeQueue() {
+		if checker != nil && !checker.RequestVerification(ctx.GetSessionVars().ActiveRoles, job.DBName, job.TableName, "", mysql.AllPrivMask) {
+			continue
+		}
+		e.rows = append(e.rows, types.MakeDatums(
+			job.DBName,
+			job.TableName,
+			job.PartitionName,
+			job.State,
+			job.Priority,
+			job.Count,
+			job.ModifyCount,
+			job.ReadKeys,
+			job.Reason,
+		))
+	}
+}
+
+func (e *memtableRetriever) setDataForRunawayQueries(ctx sessionctx.Context) {
+	loginUser := ctx.GetSessionVars().User
+	hasProcessPriv := hasPriv(ctx, mysql.ProcessPriv)
+	for _, record := range runaway.Records() {
+		// The records of the other users are only visible to the users with the PROCESS privilege.
+		if !hasProcessPriv && loginUser != nil && record.User != loginUser.Username {
+			continue
+		}
+		e.rows = append(e.rows, types.MakeDatums(
+			types.NewTime(types.FromGoTime(record.Time), mysql.TypeDatetime, types.MaxFsp),
+			record.ConnID,
+			record.User,
+			record.DB,
+			record.ResourceGroup,
+			record.Rule,
+			record.Action,
+			record.Query,
+		))
+	}
+}
+
+func (e *memtableRetriever) setDataForSessionConnectAttrs(ctx sessionctx.Context) {
+	sm := ctx.GetSessionManager()
+	if sm == nil {
+		return
+	}
+	loginUser := ctx.GetSessionVars().User
+	hasProcessPriv := hasPriv(ctx, mysql.ProcessPriv)
+	pl := sm.ShowProcessList()
+	ids := make([]uint64, 0, len(pl))
+	for id, pi := range pl {
+		// The attributes of the sessions of the other users are only visible to the users with the PROCESS privilege,
+		// the same as the processlist.
+		if !hasProcessPriv && loginUser != nil && pi.User != loginUser.Username {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		attrs := pl[id].ConnectAttrs
+		names := make([]string, 0, len(attrs))
+		for name := range attrs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			e.rows = append(e.rows, types.MakeDatums(id, name, attrs[name]))
+		}
+	}
+}
+
 // DDLJobsReaderExec executes DDLJobs information retrieving.
 type DDLJobsReaderExec struct {
 	baseExecutor
@@ -1067,7 +1271,7 @@ func (e *DDLJobsReaderExec) Open(ctx context.Context) error {
 	}
 	e.DDLJobRetriever.is = e.is
 	e.activeRoles = e.ctx.GetSessionVars().ActiveRoles
-	err = e.DDLJobRetriever.initial(txn)
+	err = e.DDLJobRetriever.initial(txn, e.ctx.GetSessionVars())
 	if err != nil {
 		return err
 	}
@@ -1714,6 +1918,72 @@ func (e *tableStorageStatsRetriever) setDataForTableStorageStats(ctx sessionctx.
 	return rows, nil
 }
 
+// statsJSONRetriever dumps the statistics of the tables as JSON, which can be loaded by LOAD STATS.
+type statsJSONRetriever struct {
+	dummyCloser
+	table      *model.TableInfo
+	outputCols []*model.ColumnInfo
+	retrieved  bool
+	extractor  *plannercore.TableStorageStatsExtractor
+}
+
+func (e *statsJSONRetriever) retrieve(ctx context.Context, sctx sessionctx.Context) ([][]types.Datum, error) {
+	if e.retrieved || e.extractor.SkipRequest {
+		return nil, nil
+	}
+	e.retrieved = true
+	// Dumping the statistics is expensive, so the schema must be specified to avoid dumping all the tables.
+	if len(e.extractor.TableSchema) == 0 {
+		return nil, errors.Errorf("Please specify the 'table_schema'")
+	}
+	h := domain.GetDomain(sctx).StatsHandle()
+	if h == nil {
+		return nil, nil
+	}
+	is := sctx.GetInfoSchema().(infoschema.InfoSchema)
+	checker := privilege.GetPrivilegeManager(sctx)
+	var rows [][]types.Datum
+	for schema := range e.extractor.TableSchema {
+		db, ok := is.SchemaByName(model.NewCIStr(schema))
+		if !ok || util.IsMemDB(db.Name.L) {
+			continue
+		}
+		for _, tbl := range is.SchemaTables(db.Name) {
+			tblInfo := tbl.Meta()
+			if len(e.extractor.TableName) > 0 && !e.extractor.TableName.Exist(tblInfo.Name.L) {
+				continue
+			}
+			if tblInfo.IsView() || tblInfo.IsSequence() {
+				continue
+			}
+			if checker != nil && !checker.RequestVerification(sctx.GetSessionVars().ActiveRoles, db.Name.L, tblInfo.Name.L, "", mysql.SelectPriv) {
+				continue
+			}
+			jsonTbl, err := h.DumpStatsToJSON(db.Name.O, tblInfo, nil)
+			if err != nil {
+				return nil, err
+			}
+			js, err := json.Marshal(jsonTbl)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			rows = append(rows, types.MakeDatums(db.Name.O, tblInfo.Name.O, string(js)))
+		}
+	}
+	if len(e.outputCols) == len(e.table.Columns) {
+		return rows, nil
+	}
+	retRows := make([][]types.Datum, len(rows))
+	for i, fullRow := range rows {
+		row := make([]types.Datum, len(e.outputCols))
+		for j, col := range e.outputCols {
+			row[j] = fullRow[col.Offset]
+		}
+		retRows[i] = row
+	}
+	return retRows, nil
+}
+
 func (e *memtableRetriever) setDataFromSessionVar(ctx sessionctx.Context) error {
 	var rows [][]types.Datum
 	var err error
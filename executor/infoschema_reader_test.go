@@ -15,6 +15,7 @@ package executor_test
 
 import (
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/http/httptest"
@@ -44,7 +45,9 @@ import (
 	"github.com/pingcap/tidb/store/mockstore"
 	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/pdapi"
+	"github.com/pingcap/tidb/util/runaway"
 	"github.com/pingcap/tidb/util/stringutil"
+	"github.com/pingcap/tidb/util/tabletraffic"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/testutil"
@@ -499,6 +502,45 @@ func (s *testInfoschemaTableSerialSuite) TestPartitionsTable(c *C) {
 	tk.MustExec("drop table test_partitions")
 }
 
+func (s *testInfoschemaTableSerialSuite) TestTableTraffic(c *C) {
+	tabletraffic.Reset()
+	defer tabletraffic.Reset()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t_traffic, pt_traffic")
+	// The write traffic is not counted by default.
+	tk.MustExec("create table t_traffic (a int primary key)")
+	tk.MustExec("insert into t_traffic values (1)")
+	tk.MustQuery("select count(*) from information_schema.table_traffic where table_name = 't_traffic'").Check(testkit.Rows("0"))
+	tk.MustExec("drop table t_traffic")
+	tk.MustExec("set @@global.tidb_enable_table_write_traffic = on")
+	defer tk.MustExec("set @@global.tidb_enable_table_write_traffic = default")
+	tk.MustExec("create table t_traffic (a int primary key, b varchar(10))")
+	tk.MustExec("create table pt_traffic (a int primary key) partition by range (a) (partition p0 values less than (10), partition p1 values less than (20))")
+	tk.MustExec("insert into t_traffic values (1, 'a'), (2, 'b'), (3, 'c')")
+	tk.MustExec("insert into pt_traffic values (1), (11), (12)")
+	tk.MustQuery("select * from t_traffic").Check(testkit.Rows("1 a", "2 b", "3 c"))
+
+	tk.MustQuery("select table_schema, table_name, partition_name, write_keys, read_bytes > 0, write_bytes > 0 from information_schema.table_traffic where table_name in ('t_traffic', 'pt_traffic') order by table_id").Check(testkit.Rows(
+		"test t_traffic <nil> 3 1 1",
+		"test pt_traffic p0 1 0 1",
+		"test pt_traffic p1 2 0 1",
+	))
+
+	// The traffic of the dropped tables is not shown.
+	tk.MustExec("drop table t_traffic")
+	tk.MustQuery("select count(*) from information_schema.table_traffic where table_name = 't_traffic'").Check(testkit.Rows("0"))
+
+	// The users can only see the traffic of the tables they have privileges on.
+	tk.MustExec("drop user if exists 'traffic_user'@'%'")
+	tk.MustExec("create user 'traffic_user'@'%'")
+	defer tk.MustExec("drop user 'traffic_user'@'%'")
+	tk1 := testkit.NewTestKit(c, s.store)
+	tk1.MustExec("use information_schema")
+	c.Assert(tk1.Se.Auth(&auth.UserIdentity{Username: "traffic_user", Hostname: "%"}, nil, nil), IsTrue)
+	tk1.MustQuery("select count(*) from information_schema.table_traffic where table_name = 'pt_traffic'").Check(testkit.Rows("0"))
+}
+
 func (s *testInfoschemaTableSuite) TestMetricTables(c *C) {
 	tk := testkit.NewTestKit(c, s.store)
 	statistics.ClearHistoryJobs()
@@ -873,7 +915,105 @@ func (s *testInfoschemaClusterTableSuite) TestTableStorageStats(c *C) {
 	tk.MustQuery("select TABLE_SCHEMA, sum(TABLE_SIZE) from information_schema.TABLE_STORAGE_STATS where TABLE_SCHEMA = 'test' group by TABLE_SCHEMA;").Check(testkit.Rows(
 		"test 2",
 	))
-	c.Assert(len(tk.MustQuery("select TABLE_NAME from information_schema.TABLE_STORAGE_STATS where TABLE_SCHEMA = 'mysql';").Rows()), Equals, 24)
+	c.Assert(len(tk.MustQuery("select TABLE_NAME from information_schema.TABLE_STORAGE_STATS where TABLE_SCHEMA = 'mysql';").Rows()), Equals, 27)
+}
+
+func (s *testInfoschemaTableSuite) TestStatsJSON(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t_stats_json")
+	tk.MustExec("create table t_stats_json (a int, b int, index idx(b))")
+	tk.MustExec("insert into t_stats_json values (1, 1), (2, 2), (3, 3)")
+	tk.MustExec("analyze table t_stats_json")
+
+	rows := tk.MustQuery("select table_schema, table_name, stats_json from information_schema.stats_json where table_schema = 'test' and table_name = 't_stats_json'").Rows()
+	c.Assert(rows, HasLen, 1)
+	c.Assert(rows[0][0], Equals, "test")
+	c.Assert(rows[0][1], Equals, "t_stats_json")
+	jsonTbl := &handle.JSONTable{}
+	c.Assert(json.Unmarshal([]byte(rows[0][2].(string)), jsonTbl), IsNil)
+	c.Assert(jsonTbl.DatabaseName, Equals, "test")
+	c.Assert(jsonTbl.TableName, Equals, "t_stats_json")
+	c.Assert(jsonTbl.Count, Equals, int64(3))
+	c.Assert(jsonTbl.Columns, HasLen, 2)
+	c.Assert(jsonTbl.Indices, HasLen, 1)
+
+	// The schema must be specified.
+	err := tk.QueryToErr("select * from information_schema.stats_json where table_name = 't_stats_json'")
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "Please specify the 'table_schema'")
+}
+
+func (s *testInfoschemaTableSuite) TestRunawayQueries(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	runaway.AddRecord(runaway.Record{
+		Time:          time.Date(2021, 6, 1, 10, 0, 0, 0, time.Local),
+		ConnID:        901,
+		User:          "root",
+		DB:            "test",
+		ResourceGroup: "olap",
+		Rule:          "execution time 2s > 1s",
+		Action:        "kill",
+		Query:         "select * from t",
+	})
+	runaway.AddRecord(runaway.Record{
+		Time:   time.Date(2021, 6, 1, 10, 0, 1, 0, time.Local),
+		ConnID: 902,
+		User:   "runaway_tester",
+		Rule:   "scan rows 101 > 100",
+		Action: "log",
+		Query:  "select * from t2",
+	})
+	tk.MustQuery("select * from information_schema.runaway_queries where conn_id in (901, 902)").Check(testkit.Rows(
+		"2021-06-01 10:00:00.000000 901 root test olap execution time 2s > 1s kill select * from t",
+		"2021-06-01 10:00:01.000000 902 runaway_tester   scan rows 101 > 100 log select * from t2"))
+
+	// The users without the PROCESS privilege can only see their own records.
+	tk.MustExec("create user runaway_tester")
+	runawayTester := testkit.NewTestKit(c, s.store)
+	runawayTester.MustExec("use test")
+	c.Assert(runawayTester.Se.Auth(&auth.UserIdentity{
+		Username: "runaway_tester",
+		Hostname: "127.0.0.1",
+	}, nil, nil), IsTrue)
+	runawayTester.MustQuery("select conn_id from information_schema.runaway_queries where conn_id in (901, 902)").Check(testkit.Rows("902"))
+}
+
+func (s *testInfoschemaTableSuite) TestSessionConnectAttrs(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	sm := &mockSessionManager{processInfoMap: make(map[uint64]*util.ProcessInfo, 2)}
+	sm.processInfoMap[1] = &util.ProcessInfo{
+		ID:   1,
+		User: "root",
+		ConnectAttrs: map[string]string{
+			"_client_version":  "8.0.25",
+			"program_name":     "mysql",
+			"_proxy_authority": "tidb.example.com",
+		},
+	}
+	sm.processInfoMap[2] = &util.ProcessInfo{
+		ID:           2,
+		User:         "attrs_tester",
+		ConnectAttrs: map[string]string{"_client_name": "libmysql"},
+	}
+	tk.Se.SetSessionManager(sm)
+	tk.MustQuery("select * from information_schema.session_connect_attrs").Check(testkit.Rows(
+		"1 _client_version 8.0.25",
+		"1 _proxy_authority tidb.example.com",
+		"1 program_name mysql",
+		"2 _client_name libmysql"))
+
+	// The users without the PROCESS privilege can only see the attributes of their own sessions.
+	tk.MustExec("create user attrs_tester")
+	attrsTester := testkit.NewTestKit(c, s.store)
+	attrsTester.MustExec("use test")
+	c.Assert(attrsTester.Se.Auth(&auth.UserIdentity{
+		Username: "attrs_tester",
+		Hostname: "127.0.0.1",
+	}, nil, nil), IsTrue)
+	attrsTester.Se.SetSessionManager(sm)
+	attrsTester.MustQuery("select processlist_id, attr_value from information_schema.session_connect_attrs").Check(testkit.Rows("2 libmysql"))
 }
 
 func (s *testInfoschemaTableSuite) TestSequences(c *C) {
@@ -62,6 +62,10 @@ type InsertValues struct {
 
 	GenExprs []expression.Expression
 
+	// SecurityPolicyConds are the conditions of the row-level security policies the duplicate rows must satisfy to
+	// be updated or replaced.
+	SecurityPolicyConds []expression.Expression
+
 	insertColumns []*table.Column
 
 	// colDefaultVals is used to store casted default value.
@@ -1102,6 +1106,22 @@ func (e *InsertValues) batchCheckAndInsert(ctx context.Context, rows [][]types.D
 	return nil
 }
 
+// checkDupRowVisible returns dupErr if the duplicate row is filtered out by the row-level security policies, so the
+// row invisible to the user is neither updated nor replaced.
+func (e *InsertValues) checkDupRowVisible(oldRow []types.Datum, dupErr error) error {
+	if len(e.SecurityPolicyConds) == 0 {
+		return nil
+	}
+	visible, _, err := expression.EvalBool(e.ctx, e.SecurityPolicyConds, chunk.MutRowFromDatums(oldRow).ToRow())
+	if err != nil {
+		return err
+	}
+	if !visible {
+		return dupErr
+	}
+	return nil
+}
+
 func (e *InsertValues) addRecord(ctx context.Context, row []types.Datum) error {
 	return e.addRecordWithAutoIDHint(ctx, row, 0)
 }
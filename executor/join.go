@@ -58,6 +58,10 @@ type HashJoinExec struct {
 	isNullEQ          []bool
 	probeTypes        []*types.FieldType
 	buildTypes        []*types.FieldType
+	// buildSideUsedCols stores the build side columns needed by the join keys,
+	// other conditions and the output, only these columns are spilled.
+	// All the columns are spilled if it is nil.
+	buildSideUsedCols []int
 
 	// concurrency is the number of partition, build and join workers.
 	concurrency   uint
@@ -83,6 +87,9 @@ type HashJoinExec struct {
 
 	outerMatchedStatus []*bitmap.ConcurrentBitmap
 	useOuterToBuild    bool
+	// probeFirstMatch indicates only the first build side row matching the join keys is needed by a probe side row,
+	// which is true for the semi joins without other conditions.
+	probeFirstMatch bool
 
 	prepared    bool
 	isOuterJoin bool
@@ -250,6 +257,9 @@ func (e *HashJoinExec) wait4BuildSide() (emptyBuild bool, err error) {
 		}
 	}
 	if e.rowContainer.Len() == uint64(0) && (e.joinType == plannercore.InnerJoin || e.joinType == plannercore.SemiJoin) {
+		if e.stats != nil {
+			atomic.StoreInt32(&e.stats.emptyBuild, 1)
+		}
 		return true, nil
 	}
 	return false, nil
@@ -438,6 +448,16 @@ func (e *HashJoinExec) runJoinWorker(workerID uint, probeKeyColIdx []int) {
 		allTypes:  e.probeTypes,
 		keyColIdx: probeKeyColIdx,
 	}
+	defer func() {
+		// The hash table is probed only after it's built.
+		if hCtx.probeStat.probes > 0 {
+			e.rowContainer.MergeProbeStat(hCtx)
+		}
+	}()
+	var nullKeyFilter *probeNullKeyFilter
+	if e.joinType == plannercore.InnerJoin && !e.useOuterToBuild {
+		nullKeyFilter = &probeNullKeyFilter{}
+	}
 	for ok := true; ok; {
 		if e.finished.Load().(bool) {
 			break
@@ -454,7 +474,7 @@ func (e *HashJoinExec) runJoinWorker(workerID uint, probeKeyColIdx []int) {
 		if e.useOuterToBuild {
 			ok, joinResult = e.join2ChunkForOuterHashJoin(workerID, probeSideResult, hCtx, joinResult)
 		} else {
-			ok, joinResult = e.join2Chunk(workerID, probeSideResult, hCtx, joinResult, selected)
+			ok, joinResult = e.join2Chunk(workerID, probeSideResult, hCtx, joinResult, selected, nullKeyFilter)
 		}
 		probeTime += int64(time.Since(start))
 		if !ok {
@@ -512,6 +532,9 @@ func (e *HashJoinExec) joinMatchedProbeSideRow2ChunkForOuterHashJoin(workerID ui
 }
 func (e *HashJoinExec) joinMatchedProbeSideRow2Chunk(workerID uint, probeKey uint64, probeSideRow chunk.Row, hCtx *hashContext,
 	joinResult *hashjoinWorkerResult) (bool, *hashjoinWorkerResult) {
+	if e.probeFirstMatch {
+		return e.joinFirstMatchedProbeSideRow2Chunk(workerID, probeKey, probeSideRow, hCtx, joinResult)
+	}
 	buildSideRows, _, err := e.rowContainer.GetMatchedRowsAndPtrs(probeKey, probeSideRow, hCtx)
 	if err != nil {
 		joinResult.err = err
@@ -546,6 +569,28 @@ func (e *HashJoinExec) joinMatchedProbeSideRow2Chunk(workerID uint, probeKey uin
 	return true, joinResult
 }
 
+// joinFirstMatchedProbeSideRow2Chunk joins the probe side row with the first matched build side row, the other build
+// side rows with the same join keys are neither read nor compared, since the result only depends on whether there's
+// a match.
+func (e *HashJoinExec) joinFirstMatchedProbeSideRow2Chunk(workerID uint, probeKey uint64, probeSideRow chunk.Row, hCtx *hashContext,
+	joinResult *hashjoinWorkerResult) (bool, *hashjoinWorkerResult) {
+	buildSideRow, ok, err := e.rowContainer.GetFirstMatchedRow(probeKey, probeSideRow, hCtx)
+	if err != nil {
+		joinResult.err = err
+		return false, joinResult
+	}
+	if !ok {
+		e.joiners[workerID].onMissMatch(false, probeSideRow, joinResult.chk)
+		return true, joinResult
+	}
+	_, _, err = e.joiners[workerID].tryToMatchInners(probeSideRow, chunk.NewIterator4Slice([]chunk.Row{buildSideRow}), joinResult.chk)
+	if err != nil {
+		joinResult.err = err
+		return false, joinResult
+	}
+	return true, joinResult
+}
+
 func (e *HashJoinExec) getNewJoinResult(workerID uint) (bool, *hashjoinWorkerResult) {
 	joinResult := &hashjoinWorkerResult{
 		src: e.joinChkResourceCh[workerID],
@@ -560,13 +605,19 @@ func (e *HashJoinExec) getNewJoinResult(workerID uint) (bool, *hashjoinWorkerRes
 }
 
 func (e *HashJoinExec) join2Chunk(workerID uint, probeSideChk *chunk.Chunk, hCtx *hashContext, joinResult *hashjoinWorkerResult,
-	selected []bool) (ok bool, _ *hashjoinWorkerResult) {
+	selected []bool, nullKeyFilter *probeNullKeyFilter) (ok bool, _ *hashjoinWorkerResult) {
 	var err error
 	selected, err = expression.VectorizedFilter(e.ctx, e.outerFilter, chunk.NewIterator4Chunk(probeSideChk), selected)
 	if err != nil {
 		joinResult.err = err
 		return false, joinResult
 	}
+	if nullKeyFilter != nil && nullKeyFilter.enabled {
+		filtered := e.filterNullProbeKeys(probeSideChk, hCtx, selected)
+		if e.stats != nil {
+			atomic.AddInt64(&e.stats.nullKeyFiltered, filtered)
+		}
+	}
 
 	hCtx.initHash(probeSideChk.NumRows())
 	for keyIdx, i := range hCtx.keyColIdx {
@@ -577,6 +628,9 @@ func (e *HashJoinExec) join2Chunk(workerID uint, probeSideChk *chunk.Chunk, hCtx
 			return false, joinResult
 		}
 	}
+	if nullKeyFilter != nil && !nullKeyFilter.enabled {
+		nullKeyFilter.sample(selected, hCtx.hasNull)
+	}
 
 	for i := range selected {
 		killed := atomic.LoadUint32(&e.ctx.GetSessionVars().Killed) == 1
@@ -609,6 +663,60 @@ func (e *HashJoinExec) join2Chunk(workerID uint, probeSideChk *chunk.Chunk, hCtx
 	return true, joinResult
 }
 
+// probeNullKeyFilter decides whether the probe rows with NULL join keys should be filtered before hashing.
+// In inner joins, these rows never match any build row, so filtering them column by column is cheaper than
+// hashing all the keys of them. The filter is enabled once the NULL keys dominate the sampled probe rows.
+type probeNullKeyFilter struct {
+	sampledRows int
+	nullKeyRows int
+	enabled     bool
+}
+
+const (
+	// nullKeyFilterSampleRows is the number of probe rows sampled to decide whether to filter the NULL keys.
+	nullKeyFilterSampleRows = 1024
+	// nullKeyFilterRatio is the ratio of the rows with NULL keys in the sample to enable the filter.
+	nullKeyFilterRatio = 0.5
+)
+
+func (f *probeNullKeyFilter) sample(selected, hasNull []bool) {
+	for i := range selected {
+		if !selected[i] {
+			continue
+		}
+		f.sampledRows++
+		if hasNull[i] {
+			f.nullKeyRows++
+		}
+	}
+	if f.sampledRows >= nullKeyFilterSampleRows {
+		f.enabled = float64(f.nullKeyRows) > float64(f.sampledRows)*nullKeyFilterRatio
+		if !f.enabled {
+			// Keep sampling the latest rows.
+			f.sampledRows, f.nullKeyRows = 0, 0
+		}
+	}
+}
+
+// filterNullProbeKeys unselects the probe rows with NULL keys which can't be matched, it returns the number of
+// the unselected rows.
+func (e *HashJoinExec) filterNullProbeKeys(probeSideChk *chunk.Chunk, hCtx *hashContext, selected []bool) (filtered int64) {
+	for keyIdx, colIdx := range hCtx.keyColIdx {
+		// The NULL keys of the null-safe equal conditions can be matched.
+		if len(e.isNullEQ) > keyIdx && e.isNullEQ[keyIdx] {
+			continue
+		}
+		column := probeSideChk.Column(colIdx)
+		for i := range selected {
+			if selected[i] && column.IsNull(i) {
+				selected[i] = false
+				filtered++
+			}
+		}
+	}
+	return filtered
+}
+
 // join2ChunkForOuterHashJoin joins chunks when using the outer to build a hash table (refer to outer hash join)
 func (e *HashJoinExec) join2ChunkForOuterHashJoin(workerID uint, probeSideChk *chunk.Chunk, hCtx *hashContext, joinResult *hashjoinWorkerResult) (ok bool, _ *hashjoinWorkerResult) {
 	hCtx.initHash(probeSideChk.NumRows())
@@ -741,6 +849,7 @@ func (e *HashJoinExec) buildHashTableForList(buildSideResultCh <-chan *chunk.Chu
 	var err error
 	var selected []bool
 	e.rowContainer = newHashRowContainer(e.ctx, int(e.buildSideEstCount), hCtx)
+	e.rowContainer.rowContainer.SetSpillUsedCols(e.buildSideUsedCols)
 	e.rowContainer.GetMemTracker().AttachTo(e.memTracker)
 	e.rowContainer.GetMemTracker().SetLabel(memory.LabelForBuildSideResult)
 	e.rowContainer.GetDiskTracker().AttachTo(e.diskTracker)
@@ -779,6 +888,7 @@ func (e *HashJoinExec) buildHashTableForList(buildSideResultCh <-chan *chunk.Chu
 			return err
 		}
 	}
+	e.rowContainer.FinishBuild()
 	return nil
 }
 
@@ -1099,6 +1209,10 @@ type hashJoinRuntimeStats struct {
 	probe                  int64
 	concurrent             int
 	maxFetchAndProbe       int64
+	// emptyBuild is 1 if the probe is skipped because the build side is empty.
+	emptyBuild int32
+	// nullKeyFiltered is the number of the probe rows filtered because of their NULL join keys.
+	nullKeyFiltered int64
 }
 
 func (e *hashJoinRuntimeStats) setMaxFetchAndProbeTime(t int64) {
@@ -1144,8 +1258,19 @@ func (e *hashJoinRuntimeStats) String() string {
 			buf.WriteString(", probe_collision:")
 			buf.WriteString(strconv.Itoa(e.hashStat.probeCollision))
 		}
+		if e.hashStat.probes > 0 {
+			buf.WriteString(", ")
+			buf.WriteString(e.hashStat.probeStatistic.String())
+		}
+		if nullKeyFiltered := atomic.LoadInt64(&e.nullKeyFiltered); nullKeyFiltered > 0 {
+			buf.WriteString(", null_key_filtered:")
+			buf.WriteString(strconv.FormatInt(nullKeyFiltered, 10))
+		}
 		buf.WriteString("}")
 	}
+	if atomic.LoadInt32(&e.emptyBuild) == 1 {
+		buf.WriteString(", probe_skipped:empty_build")
+	}
 	return buf.String()
 }
 
@@ -1157,6 +1282,8 @@ func (e *hashJoinRuntimeStats) Clone() execdetails.RuntimeStats {
 		probe:                  e.probe,
 		concurrent:             e.concurrent,
 		maxFetchAndProbe:       e.maxFetchAndProbe,
+		emptyBuild:             e.emptyBuild,
+		nullKeyFiltered:        e.nullKeyFiltered,
 	}
 }
 
@@ -1168,9 +1295,14 @@ func (e *hashJoinRuntimeStats) Merge(rs execdetails.RuntimeStats) {
 	e.fetchAndBuildHashTable += tmp.fetchAndBuildHashTable
 	e.hashStat.buildTableElapse += tmp.hashStat.buildTableElapse
 	e.hashStat.probeCollision += tmp.hashStat.probeCollision
+	e.hashStat.probeStatistic.merge(&tmp.hashStat.probeStatistic)
 	e.fetchAndProbe += tmp.fetchAndProbe
 	e.probe += tmp.probe
 	if e.maxFetchAndProbe < tmp.maxFetchAndProbe {
 		e.maxFetchAndProbe = tmp.maxFetchAndProbe
 	}
+	if tmp.emptyBuild == 1 {
+		e.emptyBuild = 1
+	}
+	e.nullKeyFiltered += tmp.nullKeyFiltered
 }
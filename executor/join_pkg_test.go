@@ -122,6 +122,47 @@ func (s *pkgTestSuite) TestHashJoinRuntimeStats(c *C) {
 	c.Assert(stats.String(), Equals, stats.Clone().String())
 	stats.Merge(stats.Clone())
 	c.Assert(stats.String(), Equals, "build_hash_table:{total:4s, fetch:3.8s, build:200ms}, probe:{concurrency:4, total:10s, max:2s, probe:8s, fetch:2s, probe_collision:2}")
+
+	stats.nullKeyFiltered = 100
+	c.Assert(stats.String(), Equals, "build_hash_table:{total:4s, fetch:3.8s, build:200ms}, probe:{concurrency:4, total:10s, max:2s, probe:8s, fetch:2s, probe_collision:2, null_key_filtered:100}")
+	stats.Merge(stats.Clone())
+	c.Assert(stats.nullKeyFiltered, Equals, int64(200))
+
+	stats.hashStat.probeStatistic = probeStatistic{probes: 4, probeLength: 6, tagFiltered: 1}
+	c.Assert(stats.String(), Equals, "build_hash_table:{total:8s, fetch:7.6s, build:400ms}, probe:{concurrency:4, total:20s, max:2s, probe:16s, fetch:4s, probe_collision:4, avg_probe_length:1.5, tag_filtered:1, null_key_filtered:200}")
+	stats.Merge(stats.Clone())
+	c.Assert(stats.hashStat.tagFiltered, Equals, int64(2))
+
+	stats = &hashJoinRuntimeStats{
+		fetchAndBuildHashTable: time.Second,
+		emptyBuild:             1,
+	}
+	c.Assert(stats.String(), Equals, "build_hash_table:{total:1s, fetch:1s, build:0s}, probe_skipped:empty_build")
+	c.Assert(stats.String(), Equals, stats.Clone().String())
+}
+
+func (s *pkgTestSuite) TestProbeNullKeyFilter(c *C) {
+	selected := make([]bool, nullKeyFilterSampleRows)
+	hasNull := make([]bool, nullKeyFilterSampleRows)
+	for i := range selected {
+		selected[i] = true
+		hasNull[i] = i%2 == 0
+	}
+	// Half of the keys are NULL, the filter is not enabled.
+	f := &probeNullKeyFilter{}
+	f.sample(selected, hasNull)
+	c.Assert(f.enabled, IsFalse)
+	c.Assert(f.sampledRows, Equals, 0)
+
+	// The unselected rows are not sampled.
+	for i := range selected {
+		selected[i] = hasNull[i] || i%4 == 1
+	}
+	f.sample(selected, hasNull)
+	c.Assert(f.enabled, IsFalse)
+	c.Assert(f.sampledRows, Equals, nullKeyFilterSampleRows*3/4)
+	f.sample(selected, hasNull)
+	c.Assert(f.enabled, IsTrue)
 }
 
 func (s *pkgTestSuite) TestIndexJoinRuntimeStats(c *C) {
@@ -141,4 +182,10 @@ func (s *pkgTestSuite) TestIndexJoinRuntimeStats(c *C) {
 	c.Assert(stats.String(), Equals, stats.Clone().String())
 	stats.Merge(stats.Clone())
 	c.Assert(stats.String(), Equals, "inner:{total:10s, concurrency:5, task:32, construct:200ms, fetch:600ms, build:500ms, join:300ms}, probe:2s")
+
+	stats.innerWorker.lookupKeys = 100
+	stats.innerWorker.dedupKeys = 40
+	c.Assert(stats.String(), Equals, "inner:{total:10s, concurrency:5, task:32, construct:200ms, fetch:600ms, build:500ms, join:300ms, lookup_keys:100, dedup_ratio:0.40}, probe:2s")
+	stats.Merge(&indexLookUpJoinRuntimeStats{innerWorker: innerWorkerRuntimeStats{lookupKeys: 100}})
+	c.Assert(stats.String(), Equals, "inner:{total:10s, concurrency:5, task:32, construct:200ms, fetch:600ms, build:500ms, join:300ms, lookup_keys:200, dedup_ratio:0.20}, probe:2s")
 }
@@ -81,10 +81,25 @@ func (e *LoadStatsInfo) Update(data []byte) error {
 	if err := json.Unmarshal(data, jsonTbl); err != nil {
 		return errors.Trace(err)
 	}
+	is := e.Ctx.GetInfoSchema().(infoschema.InfoSchema)
+	sessVars := e.Ctx.GetSessionVars()
+	if sessVars.LoadStatsInSession {
+		tbls, err := handle.SessionStatsFromJSON(is, jsonTbl)
+		if err != nil {
+			return err
+		}
+		if sessVars.OverriddenStats == nil {
+			sessVars.OverriddenStats = make(map[int64]interface{}, len(tbls))
+		}
+		for id, tbl := range tbls {
+			sessVars.OverriddenStats[id] = tbl
+		}
+		return nil
+	}
 	do := domain.GetDomain(e.Ctx)
 	h := do.StatsHandle()
 	if h == nil {
 		return errors.New("Load Stats: handle is nil")
 	}
-	return h.LoadStatsFromJSON(e.Ctx.GetInfoSchema().(infoschema.InfoSchema), jsonTbl)
+	return h.LoadStatsFromJSON(is, jsonTbl)
 }
@@ -30,6 +30,7 @@ import (
 	"github.com/pingcap/kvproto/pkg/diagnosticspb"
 	"github.com/pingcap/log"
 	"github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/mysql"
 	"github.com/pingcap/parser/terror"
 	"github.com/pingcap/sysutil"
 	"github.com/pingcap/tidb/config"
@@ -41,6 +42,7 @@ import (
 	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/chunk"
 	"github.com/pingcap/tidb/util/execdetails"
+	"github.com/pingcap/tidb/util/instancestats"
 	"github.com/pingcap/tidb/util/pdapi"
 	"github.com/pingcap/tidb/util/set"
 	"go.uber.org/zap"
@@ -397,6 +399,124 @@ func getServerInfoByGRPC(ctx context.Context, address string, tp diagnosticspb.S
 	return r.Items, nil
 }
 
+// clusterInstanceStatsTimeout is the timeout of fetching the runtime statistics of an instance. The instances which
+// fail to respond in time are skipped with warnings.
+const clusterInstanceStatsTimeout = 5 * time.Second
+
+// clusterInstanceStatsRetriever retrieves the runtime statistics served by the status servers of the TiDB instances.
+type clusterInstanceStatsRetriever struct {
+	dummyCloser
+	table     string
+	extractor *plannercore.ClusterTableExtractor
+	retrieved bool
+}
+
+// retrieve implements the memTableRetriever interface
+func (e *clusterInstanceStatsRetriever) retrieve(ctx context.Context, sctx sessionctx.Context) ([][]types.Datum, error) {
+	if e.extractor.SkipRequest || e.retrieved {
+		return nil, nil
+	}
+	e.retrieved = true
+	if !hasPriv(sctx, mysql.ProcessPriv) {
+		return nil, plannercore.ErrSpecificAccessDenied.GenWithStackByArgs("PROCESS")
+	}
+
+	serversInfo, err := infoschema.GetClusterServerInfo(sctx)
+	failpoint.Inject("mockClusterInstanceStatsServerInfo", func(val failpoint.Value) {
+		if s := val.(string); len(s) > 0 {
+			// erase the error
+			serversInfo, err = parseFailpointServerInfo(s), nil
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	serversInfo = filterClusterServerInfo(serversInfo, e.extractor.NodeTypes, e.extractor.Instances)
+
+	type result struct {
+		idx   int
+		stats *instancestats.Stats
+		err   error
+	}
+	wg := sync.WaitGroup{}
+	ch := make(chan result, len(serversInfo))
+	for i, srv := range serversInfo {
+		// Only the TiDB instances serve the runtime statistics.
+		if srv.ServerType != "tidb" {
+			continue
+		}
+		wg.Add(1)
+		go func(index int, statusAddr string) {
+			util.WithRecovery(func() {
+				defer wg.Done()
+				stats, err := fetchInstanceStats(ctx, statusAddr)
+				ch <- result{idx: index, stats: stats, err: err}
+			}, nil)
+		}(i, srv.StatusAddr)
+	}
+	wg.Wait()
+	close(ch)
+
+	// Keep the original order to make the result more stable
+	var results []result
+	for result := range ch {
+		if result.err != nil {
+			sctx.GetSessionVars().StmtCtx.AppendWarning(result.err)
+			continue
+		}
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].idx < results[j].idx })
+	var finalRows [][]types.Datum
+	for _, result := range results {
+		srv := serversInfo[result.idx]
+		finalRows = append(finalRows, instanceStatsToRows(e.table, srv.ServerType, srv.Address, result.stats)...)
+	}
+	return finalRows, nil
+}
+
+func fetchInstanceStats(ctx context.Context, statusAddr string) (*instancestats.Stats, error) {
+	ctx, cancel := context.WithTimeout(ctx, clusterInstanceStatsTimeout)
+	defer cancel()
+	url := fmt.Sprintf("%s://%s%s", util.InternalHTTPSchema(), statusAddr, instancestats.Path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	resp, err := util.InternalHTTPClient().Do(req)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer func() {
+		terror.Log(resp.Body.Close())
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("request %s failed: %s", url, resp.Status)
+	}
+	var stats instancestats.Stats
+	if err = json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &stats, nil
+}
+
+func instanceStatsToRows(table, tp, addr string, stats *instancestats.Stats) [][]types.Datum {
+	var rows [][]types.Datum
+	switch table {
+	case strings.ToLower(infoschema.TableClusterMemoryUsage):
+		for _, item := range stats.Memory {
+			rows = append(rows, types.MakeDatums(tp, addr, item.Name, item.Bytes))
+		}
+	case strings.ToLower(infoschema.TableClusterThreads):
+		rows = append(rows, types.MakeDatums(tp, addr, stats.Goroutines, stats.Threads, stats.GoMaxProcs))
+	case strings.ToLower(infoschema.TableClusterRunningTasks):
+		for _, task := range stats.RunningTasks {
+			rows = append(rows, types.MakeDatums(tp, addr, task.Type, task.Count))
+		}
+	}
+	return rows
+}
+
 func parseFailpointServerInfo(s string) []infoschema.ServerInfo {
 	servers := strings.Split(s, ";")
 	serversInfo := make([]infoschema.ServerInfo, 0, len(servers))
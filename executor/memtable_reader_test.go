@@ -34,6 +34,7 @@ import (
 	"github.com/pingcap/tidb/domain"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/session"
+	"github.com/pingcap/tidb/util/instancestats"
 	"github.com/pingcap/tidb/util/pdapi"
 	"github.com/pingcap/tidb/util/testkit"
 	pmodel "github.com/prometheus/common/model"
@@ -434,6 +435,50 @@ func (s *testClusterTableBase) writeTmpFile(c *C, dir, filename string, lines []
 	c.Assert(err, IsNil, Commentf("write tmp file %s failed", filename))
 }
 
+func (s *testMemTableReaderSuite) TestClusterInstanceStats(c *C) {
+	router := mux.NewRouter()
+	router.Handle(instancestats.Path, fn.Wrap(func() (*instancestats.Stats, error) {
+		return &instancestats.Stats{
+			Memory:       []instancestats.MemoryItem{{Name: "heap_alloc", Bytes: 100}, {Name: "tracked", Bytes: 10}},
+			Goroutines:   20,
+			Threads:      8,
+			GoMaxProcs:   4,
+			RunningTasks: []instancestats.RunningTask{{Type: instancestats.TaskCop, Count: 3}, {Type: instancestats.TaskMPP, Count: 1}},
+		}, nil
+	}))
+	server := httptest.NewServer(router)
+	defer server.Close()
+	address := strings.TrimPrefix(server.URL, "http://")
+	// The closed server fails, and the TiKV doesn't serve the runtime statistics.
+	closedServer := httptest.NewServer(router)
+	closedAddress := strings.TrimPrefix(closedServer.URL, "http://")
+	closedServer.Close()
+	servers := []string{
+		strings.Join([]string{"tidb", address, address}, ","),
+		strings.Join([]string{"tidb", closedAddress, closedAddress}, ","),
+		strings.Join([]string{"tikv", address, address}, ","),
+	}
+	fpName := "github.com/pingcap/tidb/executor/mockClusterInstanceStatsServerInfo"
+	c.Assert(failpoint.Enable(fpName, fmt.Sprintf(`return("%s")`, strings.Join(servers, ";"))), IsNil)
+	defer func() { c.Assert(failpoint.Disable(fpName), IsNil) }()
+
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustQuery("select * from information_schema.cluster_memory_usage").Check(testkit.Rows(
+		fmt.Sprintf("tidb %s heap_alloc 100", address),
+		fmt.Sprintf("tidb %s tracked 10", address),
+	))
+	c.Assert(tk.Se.GetSessionVars().StmtCtx.WarningCount(), Equals, uint16(1))
+	tk.MustQuery("select * from information_schema.cluster_threads").Check(testkit.Rows(
+		fmt.Sprintf("tidb %s 20 8 4", address),
+	))
+	tk.MustQuery("select task_type, count from information_schema.cluster_running_tasks where instance = '" + address + "'").Check(testkit.Rows(
+		"cop 3",
+		"mpp 1",
+	))
+	c.Assert(tk.Se.GetSessionVars().StmtCtx.WarningCount(), Equals, uint16(0))
+	tk.MustQuery("select * from information_schema.cluster_threads where type = 'tikv'").Check(testkit.Rows())
+}
+
 type testServer struct {
 	typ     string
 	server  *grpc.Server
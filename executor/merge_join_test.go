@@ -278,6 +278,41 @@ func (s *testSerialSuite1) TestShuffleMergeJoinInDisk(c *C) {
 	c.Assert(tk.Se.GetSessionVars().StmtCtx.DiskTracker.BytesConsumed(), Equals, int64(0))
 	c.Assert(tk.Se.GetSessionVars().StmtCtx.DiskTracker.MaxConsumed(), Greater, int64(0))
 }
+func (s *testSerialSuite1) TestMergeJoinSortingChildrenInDisk(c *C) {
+	defer config.RestoreFunc()()
+	config.UpdateGlobal(func(conf *config.Config) {
+		conf.OOMUseTmpStorage = true
+	})
+
+	c.Assert(failpoint.Enable("github.com/pingcap/tidb/executor/testSortedRowContainerSpill", "return(true)"), IsNil)
+	defer func() {
+		c.Assert(failpoint.Disable("github.com/pingcap/tidb/executor/testSortedRowContainerSpill"), IsNil)
+	}()
+
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t1, t2")
+	tk.MustExec("create table t1(a int, b int)")
+	tk.MustExec("create table t2(a int, b int)")
+	for i := 50; i > 0; i-- {
+		tk.MustExec(fmt.Sprintf("insert into t1 values (%d, %d)", i%3, i))
+		tk.MustExec(fmt.Sprintf("insert into t2 values (%d, %d)", i%2, i))
+	}
+	tk.MustExec("analyze table t1, t2")
+	sql := "select * from t1 join t2 on t1.a = t2.a"
+	expected := tk.MustQuery(sql).Sort().Rows()
+	c.Assert(expected, HasLen, (16+17)*25)
+
+	tk.MustExec("set @@tidb_mem_quota_query=100")
+	plan := tk.MustQuery("explain format = 'brief' " + sql).Rows()
+	c.Assert(plan[0][0], Equals, "MergeJoin")
+	c.Assert(plan[0][4], Matches, ".*sort children")
+	tk.MustQuery(sql).Sort().Check(expected)
+	c.Assert(tk.Se.GetSessionVars().StmtCtx.MemTracker.BytesConsumed(), Equals, int64(0))
+	c.Assert(tk.Se.GetSessionVars().StmtCtx.DiskTracker.BytesConsumed(), Equals, int64(0))
+	c.Assert(tk.Se.GetSessionVars().StmtCtx.DiskTracker.MaxConsumed(), Greater, int64(0))
+}
+
 func (s *testSerialSuite1) TestMergeJoinInDisk(c *C) {
 	c.Skip("unstable, skip it and fix it before 20210618")
 
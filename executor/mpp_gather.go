@@ -74,14 +74,15 @@ func (e *MPPGather) appendMPPDispatchReq(pf *plannercore.Fragment) error {
 		}
 		logutil.BgLogger().Info("Dispatch mpp task", zap.Uint64("timestamp", mppTask.StartTs), zap.Int64("ID", mppTask.ID), zap.String("address", mppTask.Meta.GetAddress()), zap.String("plan", plannercore.ToString(pf.ExchangeSender)))
 		req := &kv.MPPDispatchRequest{
-			Data:      pbData,
-			Meta:      mppTask.Meta,
-			ID:        mppTask.ID,
-			IsRoot:    pf.IsRoot,
-			Timeout:   10,
-			SchemaVar: e.is.SchemaMetaVersion(),
-			StartTs:   e.startTS,
-			State:     kv.MppTaskReady,
+			Data:             pbData,
+			Meta:             mppTask.Meta,
+			ID:               mppTask.ID,
+			IsRoot:           pf.IsRoot,
+			Timeout:          10,
+			SchemaVar:        e.is.SchemaMetaVersion(),
+			StartTs:          e.startTS,
+			State:            kv.MppTaskReady,
+			ResourceGroupTag: e.ctx.GetSessionVars().StmtCtx.GetResourceGroupTag(),
 		}
 		e.mppReqs = append(e.mppReqs, req)
 	}
@@ -117,6 +118,7 @@ func (e *MPPGather) Open(ctx context.Context) (err error) {
 			failpoint.Return(errors.Errorf("The number of tasks is not right, expect %d tasks but actually there are %d tasks", val.(int), len(e.mppReqs)))
 		}
 	})
+	e.ctx.GetSessionVars().StmtCtx.AddMPPTasks(len(e.mppReqs))
 	e.respIter, err = distsql.DispatchMPPTasks(ctx, e.ctx, e.mppReqs, e.retFieldTypes, planIDs, e.id)
 	if err != nil {
 		return errors.Trace(err)
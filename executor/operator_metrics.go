@@ -0,0 +1,113 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/pingcap/tidb/metrics"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// operatorMetrics are the metrics of an operator type. They're created once per type and shared by the operators of
+// the type, so the operators don't look up the metric vectors while running.
+type operatorMetrics struct {
+	executions prometheus.Counter
+	rows       prometheus.Counter
+	chunks     prometheus.Counter
+	duration   prometheus.Counter
+}
+
+// operatorMetricsRegistry maps the type of the executors to their operatorMetrics.
+var operatorMetricsRegistry sync.Map
+
+// operatorType returns the label of the operator type, which is the name of the executor type, like "HashJoinExec".
+func operatorType(tp reflect.Type) string {
+	if tp.Kind() == reflect.Ptr {
+		tp = tp.Elem()
+	}
+	return tp.Name()
+}
+
+func getOperatorMetrics(e Executor) *operatorMetrics {
+	tp := reflect.TypeOf(e)
+	if m, ok := operatorMetricsRegistry.Load(tp); ok {
+		return m.(*operatorMetrics)
+	}
+	label := operatorType(tp)
+	m, _ := operatorMetricsRegistry.LoadOrStore(tp, &operatorMetrics{
+		executions: metrics.OperatorCounter.WithLabelValues(label),
+		rows:       metrics.OperatorRowsCounter.WithLabelValues(label),
+		chunks:     metrics.OperatorChunksCounter.WithLabelValues(label),
+		duration:   metrics.OperatorDurationCounter.WithLabelValues(label),
+	})
+	return m.(*operatorMetrics)
+}
+
+// operatorStats accumulates the metrics of an operator locally and flushes them every interval calls of Next, so the
+// shared counters are rarely touched. The time is only measured on the first call of every interval calls, and the time
+// of the calls not measured is estimated by the average.
+type operatorStats struct {
+	metrics *operatorMetrics
+	// interval is read from tidb_operator_metrics_sample_interval on the first call of Next, 0 means disabled.
+	interval uint32
+	inited   bool
+
+	calls         uint32
+	rows          int
+	timedCalls    uint32
+	timedDuration time.Duration
+}
+
+// sample initializes the stats on the first call of Next, and returns whether the current call should be timed.
+func (s *operatorStats) sample(e Executor) bool {
+	if !s.inited {
+		s.inited = true
+		s.interval = variable.OperatorMetricsSampleInterval.Load()
+		if s.interval > 0 {
+			s.metrics = getOperatorMetrics(e)
+			s.metrics.executions.Inc()
+		}
+	}
+	return s.interval > 0 && s.calls == 0
+}
+
+// record records a call of Next, start is the time the call starts if it's timed.
+func (s *operatorStats) record(rows int, start time.Time, timed bool) {
+	s.calls++
+	s.rows += rows
+	if timed {
+		s.timedCalls++
+		s.timedDuration += time.Since(start)
+	}
+	if s.calls >= s.interval {
+		s.flush()
+	}
+}
+
+// flush flushes the metrics accumulated since the last flush, it's also called when the executor is closed.
+func (s *operatorStats) flush() {
+	if s.interval == 0 || s.calls == 0 {
+		return
+	}
+	s.metrics.chunks.Add(float64(s.calls))
+	s.metrics.rows.Add(float64(s.rows))
+	if s.timedCalls > 0 {
+		s.metrics.duration.Add(s.timedDuration.Seconds() * float64(s.calls) / float64(s.timedCalls))
+	}
+	s.calls, s.rows, s.timedCalls, s.timedDuration = 0, 0, 0, 0
+}
@@ -0,0 +1,84 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/mock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func readCounter(c *C, counter prometheus.Counter) float64 {
+	pb := &dto.Metric{}
+	c.Assert(counter.Write(pb), IsNil)
+	return pb.GetCounter().GetValue()
+}
+
+func (s *pkgTestSuite) TestOperatorMetrics(c *C) {
+	ctx := context.Background()
+	sctx := mock.NewContext()
+	sctx.GetSessionVars().MaxChunkSize = 2
+	col := &expression.Column{Index: 0, RetType: types.NewFieldType(mysql.TypeLonglong)}
+	run := func() {
+		src := buildMockDataSource(mockDataSourceParameters{
+			schema: expression.NewSchema(col),
+			rows:   5,
+			ctx:    sctx,
+			genDataFunc: func(row int, typ *types.FieldType) interface{} {
+				return int64(row)
+			},
+		})
+		src.prepareChunks()
+		limit := &LimitExec{
+			baseExecutor: newBaseExecutor(sctx, src.Schema(), 0, src),
+			begin:        0,
+			end:          100,
+		}
+		c.Assert(limit.Open(ctx), IsNil)
+		chk := newFirstChunk(limit)
+		for {
+			c.Assert(Next(ctx, limit, chk), IsNil)
+			if chk.NumRows() == 0 {
+				break
+			}
+		}
+		c.Assert(limit.Close(), IsNil)
+	}
+
+	origin := variable.OperatorMetricsSampleInterval.Load()
+	defer variable.OperatorMetricsSampleInterval.Store(origin)
+	m := getOperatorMetrics(&LimitExec{})
+	executions, rows, chunks := readCounter(c, m.executions), readCounter(c, m.rows), readCounter(c, m.chunks)
+
+	// The rows are returned in 3 chunks and the empty one, they're flushed every 2 calls and on close.
+	variable.OperatorMetricsSampleInterval.Store(2)
+	run()
+	c.Assert(readCounter(c, m.executions)-executions, Equals, float64(1))
+	c.Assert(readCounter(c, m.rows)-rows, Equals, float64(5))
+	c.Assert(readCounter(c, m.chunks)-chunks, Equals, float64(4))
+
+	// Nothing is recorded once disabled.
+	variable.OperatorMetricsSampleInterval.Store(0)
+	run()
+	c.Assert(readCounter(c, m.executions)-executions, Equals, float64(1))
+	c.Assert(readCounter(c, m.rows)-rows, Equals, float64(5))
+	c.Assert(readCounter(c, m.chunks)-chunks, Equals, float64(4))
+}
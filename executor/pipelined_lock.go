@@ -0,0 +1,135 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/tablecodec"
+	tikvutil "github.com/tikv/client-go/v2/util"
+)
+
+// pipelinedLocker acquires the pessimistic locks of the rows written by a DML asynchronously, while the DML reads and
+// computes the following rows. The rows are written in batches, the row keys of a batch are locked after it's written,
+// and at most one batch is being locked. The lock request updates the flags in the membuffer, so the DML must wait for
+// it before writing the next batch.
+// The errors of the locks don't fail the execution, the first one is kept in the statement context and handled with
+// the locks acquired after the execution, so the statement is retried as usual. The keys which are not locked here,
+// like the unique index keys, are locked after the execution too.
+type pipelinedLocker struct {
+	sctx          sessionctx.Context
+	txn           kv.Transaction
+	stagingHandle kv.StagingHandle
+	// done receives the result of the batch being locked, it's nil if there is no such batch.
+	done chan pipelinedLockResult
+}
+
+type pipelinedLockResult struct {
+	err   error
+	stats *tikvutil.LockKeysDetails
+}
+
+// newPipelinedLocker returns a pipelinedLocker if the DML with the child executor can lock the keys asynchronously,
+// or nil otherwise.
+func newPipelinedLocker(sctx sessionctx.Context, child Executor) (*pipelinedLocker, error) {
+	sessVars := sctx.GetSessionVars()
+	if !sessVars.EnablePipelinedLock || !sessVars.TxnCtx.IsPessimistic || readsMemBufferWithoutLock(child) {
+		return nil, nil
+	}
+	txn, err := sctx.Txn(true)
+	if err != nil {
+		return nil, err
+	}
+	return &pipelinedLocker{sctx: sctx, txn: txn}, nil
+}
+
+// readsMemBufferWithoutLock returns whether the executor reads the membuffer without holding its lock, which races
+// with the lock request. The UnionScanExec holds the read lock when reading the membuffer.
+func readsMemBufferWithoutLock(e Executor) bool {
+	switch e.(type) {
+	case *PointGetExecutor, *BatchPointGetExec:
+		return true
+	}
+	for _, child := range e.base().children {
+		if readsMemBufferWithoutLock(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// beginBatch waits for the batch being locked, and begins to write a new batch.
+func (l *pipelinedLocker) beginBatch() {
+	l.wait()
+	l.stagingHandle = l.txn.GetMemBuffer().Staging()
+}
+
+// endBatch ends writing the batch, and begins to lock its row keys asynchronously.
+func (l *pipelinedLocker) endBatch(ctx context.Context) {
+	var keys []kv.Key
+	memBuffer := l.txn.GetMemBuffer()
+	memBuffer.InspectStage(l.stagingHandle, func(k kv.Key, _ kv.KeyFlags, _ []byte) {
+		if tablecodec.IsRecordKey(k) {
+			keys = append(keys, k)
+		}
+	})
+	memBuffer.Release(l.stagingHandle)
+	l.stagingHandle = kv.InvalidStagingHandle
+
+	sessVars := l.sctx.GetSessionVars()
+	if sessVars.StmtCtx.PipelinedLockErr != nil {
+		return
+	}
+	keys = filterTemporaryTableKeys(sessVars, keys)
+	if len(keys) == 0 {
+		return
+	}
+	lockCtx := newLockCtx(sessVars, sessVars.LockWaitTimeout)
+	ctx = tikvutil.SetSessionID(ctx, sessVars.ConnectionID)
+	done := make(chan pipelinedLockResult, 1)
+	l.done = done
+	go func() {
+		var res pipelinedLockResult
+		ctx := context.WithValue(ctx, tikvutil.LockKeysDetailCtxKey, &res.stats)
+		res.err = l.txn.LockKeys(ctx, lockCtx, keys...)
+		done <- res
+	}()
+}
+
+// wait waits for the batch being locked.
+func (l *pipelinedLocker) wait() {
+	if l.done == nil {
+		return
+	}
+	res := <-l.done
+	l.done = nil
+	stmtCtx := l.sctx.GetSessionVars().StmtCtx
+	if res.stats != nil {
+		stmtCtx.MergeLockKeysExecDetails(res.stats)
+	}
+	if res.err != nil && stmtCtx.PipelinedLockErr == nil {
+		stmtCtx.PipelinedLockErr = res.err
+	}
+}
+
+// close waits for the batch being locked, and discards the batch being written if the DML fails in the middle of it.
+func (l *pipelinedLocker) close() {
+	l.wait()
+	if l.stagingHandle != kv.InvalidStagingHandle {
+		l.txn.GetMemBuffer().Cleanup(l.stagingHandle)
+		l.stagingHandle = kv.InvalidStagingHandle
+	}
+}
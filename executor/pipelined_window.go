@@ -24,6 +24,7 @@ import (
 	"github.com/pingcap/tidb/planner/core"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/memory"
 )
 
 type dataInfo struct {
@@ -66,17 +67,25 @@ type PipelinedWindowExec struct {
 	// expectedCmpResult is used to decide if one value is included in the frame.
 	expectedCmpResult int64
 
-	// rows keeps rows starting from curStartRow
-	rows                     []chunk.Row
+	// rows keeps rows starting from curStartRow, rowStart is the index of its first row in the partition.
+	rows                     *chunk.RingBuffer
+	rowsBuf                  []chunk.Row
 	rowCnt                   uint64
 	whole                    bool
 	isRangeFrame             bool
 	emptyFrame               bool
 	initializedSlidingWindow bool
+
+	memTracker *memory.Tracker
 }
 
 // Close implements the Executor Close interface.
 func (e *PipelinedWindowExec) Close() error {
+	if e.rows != nil {
+		e.memTracker.Consume(-e.rows.GetMemTracker().BytesConsumed())
+		e.rows = nil
+	}
+	e.memTracker = nil
 	return errors.Trace(e.baseExecutor.Close())
 }
 
@@ -94,7 +103,12 @@ func (e *PipelinedWindowExec) Open(ctx context.Context) (err error) {
 			e.slidingWindowFuncs[i] = slidingWindowAggFunc
 		}
 	}
-	e.rows = make([]chunk.Row, 0)
+	if e.memTracker == nil {
+		e.memTracker = memory.NewTracker(e.id, -1)
+		e.memTracker.AttachTo(e.ctx.GetSessionVars().StmtCtx.MemTracker)
+	}
+	e.rows = chunk.NewRingBuffer(e.maxChunkSize)
+	e.rows.GetMemTracker().AttachTo(e.memTracker)
 	return e.baseExecutor.Open(ctx)
 }
 
@@ -166,7 +180,7 @@ func (e *PipelinedWindowExec) Next(ctx context.Context, chk *chunk.Chunk) (err e
 
 func (e *PipelinedWindowExec) getRowsInPartition(ctx context.Context) (err error) {
 	e.newPartition = true
-	if len(e.rows) == 0 {
+	if e.rows.Len() == 0 {
 		// if getRowsInPartition is called for the first time, we ignore it as a new partition
 		e.newPartition = false
 	}
@@ -194,7 +208,7 @@ func (e *PipelinedWindowExec) getRowsInPartition(ctx context.Context) (err error
 	begin, end := e.groupChecker.getNextGroup()
 	e.rowToConsume += uint64(end - begin)
 	for i := begin; i < end; i++ {
-		e.rows = append(e.rows, e.childResult.GetRow(i))
+		e.rows.Append(e.childResult.GetRow(i))
 	}
 	return
 }
@@ -236,11 +250,12 @@ func (e *PipelinedWindowExec) copyChk(src, dst *chunk.Chunk) error {
 }
 
 func (e *PipelinedWindowExec) getRow(i uint64) chunk.Row {
-	return e.rows[i-e.rowStart]
+	return e.rows.GetRow(e.rows.Begin() + i - e.rowStart)
 }
 
 func (e *PipelinedWindowExec) getRows(start, end uint64) []chunk.Row {
-	return e.rows[start-e.rowStart : end-e.rowStart]
+	e.rowsBuf = e.rows.GetRows(e.rows.Begin()+start-e.rowStart, e.rows.Begin()+end-e.rowStart, e.rowsBuf[:0])
+	return e.rowsBuf
 }
 
 // finish is called upon a whole partition is consumed
@@ -412,7 +427,7 @@ func (e *PipelinedWindowExec) produce(ctx sessionctx.Context, chk *chunk.Chunk,
 	if extend > e.rowStart {
 		numDrop := extend - e.rowStart
 		e.dropped += numDrop
-		e.rows = e.rows[numDrop:]
+		e.rows.EvictTo(e.rows.Begin() + numDrop)
 		e.rowStart = extend
 	}
 	return
@@ -447,7 +462,7 @@ func (e *PipelinedWindowExec) reset() {
 	e.whole = false
 	numDrop := e.rowCnt - e.rowStart
 	e.dropped += numDrop
-	e.rows = e.rows[numDrop:]
+	e.rows.EvictTo(e.rows.Begin() + numDrop)
 	e.rowStart = 0
 	e.rowCnt = 0
 	e.initializedSlidingWindow = false
@@ -0,0 +1,178 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/tidb/domain"
+	"github.com/pingcap/tidb/planner"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/util/logutil"
+	"github.com/pingcap/tidb/util/plancapture"
+	"github.com/pingcap/tidb/util/plancodec"
+	"go.uber.org/zap"
+)
+
+// capturedTableExtractor collects the tables referred by a statement.
+type capturedTableExtractor struct {
+	curDB  model.CIStr
+	names  map[[2]string]struct{}
+	tables []*ast.TableName
+}
+
+// Enter implements the ast.Visitor interface.
+func (e *capturedTableExtractor) Enter(in ast.Node) (ast.Node, bool) {
+	if _, ok := in.(*ast.TableName); ok {
+		return in, true
+	}
+	return in, false
+}
+
+// Leave implements the ast.Visitor interface.
+func (e *capturedTableExtractor) Leave(in ast.Node) (ast.Node, bool) {
+	if t, ok := in.(*ast.TableName); ok {
+		tn := &ast.TableName{Schema: t.Schema, Name: t.Name}
+		if tn.Schema.L == "" {
+			tn.Schema = e.curDB
+		}
+		key := [2]string{tn.Schema.L, tn.Name.L}
+		if _, ok := e.names[key]; !ok {
+			e.names[key] = struct{}{}
+			e.tables = append(e.tables, tn)
+		}
+	}
+	return in, true
+}
+
+// capturePlan captures a plan replayer bundle of the statement for the digest flagged by
+// tidb_plan_capture_digests. The failures are only logged since they must not fail the statement.
+func (a *ExecStmt) capturePlan(digest string, execErr error) {
+	sessVars := a.Ctx.GetSessionVars()
+	var files []plancapture.File
+	addFile := func(name string, content []byte) {
+		files = append(files, plancapture.File{Name: name, Content: content})
+	}
+	addFile("sql/sql.sql", []byte(a.GetTextToLog()))
+
+	var meta bytes.Buffer
+	fmt.Fprintf(&meta, "digest: %s\n", digest)
+	if _, planDigest := getPlanDigest(a.Ctx, a.Plan); planDigest != nil {
+		fmt.Fprintf(&meta, "plan_digest: %s\n", planDigest.String())
+	}
+	fmt.Fprintf(&meta, "db: %s\n", sessVars.CurrentDB)
+	fmt.Fprintf(&meta, "start_time: %s\n", sessVars.StartTime.Format(time.RFC3339Nano))
+	fmt.Fprintf(&meta, "duration: %s\n", time.Since(sessVars.StartTime))
+	if execErr != nil {
+		fmt.Fprintf(&meta, "error: %s\n", execErr.Error())
+	}
+	addFile("meta.txt", meta.Bytes())
+
+	// The encoded plan contains the runtime stats if the execution info is collected.
+	encodedPlan, _ := getEncodedPlan(a.Ctx, a.Plan, false, nil)
+	plan, err := plancodec.DecodePlan(encodedPlan)
+	if err != nil {
+		plan = fmt.Sprintf("decode plan failed: %v", err)
+	}
+	addFile("explain.txt", []byte(plan))
+
+	var vars bytes.Buffer
+	sysVars := variable.GetSysVars()
+	names := make([]string, 0, len(sysVars))
+	for name, sv := range sysVars {
+		if sv.HasSessionScope() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if val, ok := sessVars.GetSystemVar(name); ok && val != sysVars[name].Value {
+			fmt.Fprintf(&vars, "%s = %s\n", name, strconv.Quote(val))
+		}
+	}
+	addFile("variables.toml", vars.Bytes())
+
+	stmtNode := a.StmtNode
+	if execStmt, ok := stmtNode.(*ast.ExecuteStmt); ok {
+		if prepared, err := planner.GetPreparedStmt(execStmt, sessVars); err == nil {
+			stmtNode = prepared.PreparedAst.Stmt
+		}
+	}
+	extractor := &capturedTableExtractor{curDB: model.NewCIStr(sessVars.CurrentDB), names: make(map[[2]string]struct{})}
+	stmtNode.Accept(extractor)
+	statsHandle := domain.GetDomain(a.Ctx).StatsHandle()
+	for _, tn := range extractor.tables {
+		var tbl table.Table
+		if a.InfoSchema != nil {
+			tbl, err = a.InfoSchema.TableByName(tn.Schema, tn.Name)
+		}
+		// The names of the CTEs can't be found in the infoschema.
+		if tbl == nil || err != nil {
+			continue
+		}
+		name := fmt.Sprintf("%s.%s", tn.Schema.O, tn.Name.O)
+		var schema bytes.Buffer
+		if err := ConstructResultOfShowCreateTable(a.Ctx, tbl.Meta(), tbl.Allocators(a.Ctx), &schema); err != nil {
+			logutil.BgLogger().Warn("capture plan: show create table failed", zap.String("table", name), zap.Error(err))
+			continue
+		}
+		addFile(fmt.Sprintf("schema/%s.schema.txt", name), schema.Bytes())
+		if statsHandle == nil || tbl.Meta().IsView() || tbl.Meta().IsSequence() {
+			continue
+		}
+		jsonTbl, err := statsHandle.DumpStatsToJSON(tn.Schema.O, tbl.Meta(), nil)
+		if err == nil {
+			var content []byte
+			if content, err = json.Marshal(jsonTbl); err == nil {
+				addFile(fmt.Sprintf("stats/%s.json", name), content)
+			}
+		}
+		if err != nil {
+			logutil.BgLogger().Warn("capture plan: dump stats failed", zap.String("table", name), zap.Error(err))
+		}
+	}
+
+	path, err := plancapture.WriteBundle(digest, files)
+	if err != nil {
+		logutil.BgLogger().Warn("capture plan failed", zap.String("digest", digest), zap.Error(err))
+		return
+	}
+	logutil.BgLogger().Info("plan captured", zap.String("digest", digest), zap.String("file", path))
+}
+
+// removeCapturedDigest removes the captured digest from the global variable tidb_plan_capture_digests, so the
+// digest isn't captured again by this or the other TiDB servers.
+func removeCapturedDigest(sessVars *variable.SessionVars, digest string) error {
+	val, err := sessVars.GlobalVarsAccessor.GetGlobalSysVar(variable.TiDBPlanCaptureDigests)
+	if err != nil {
+		return err
+	}
+	digests := make([]string, 0, strings.Count(val, ",")+1)
+	for _, d := range strings.Split(val, ",") {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if len(d) > 0 && d != digest {
+			digests = append(digests, d)
+		}
+	}
+	return sessVars.GlobalVarsAccessor.SetGlobalSysVar(variable.TiDBPlanCaptureDigests, strings.Join(digests, ","))
+}
@@ -957,3 +957,22 @@ func (s *testPointGetSuite) TestPointGetIssue25167(c *C) {
 	tk.MustExec("insert into t values (1)")
 	tk.MustQuery("select * from t as of timestamp @a where a = 1").Check(testkit.Rows())
 }
+
+func (s *testPointGetSuite) TestPointGetInstantAddedColumn(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int primary key)")
+	tk.MustExec("insert into t values (1), (2), (3)")
+	tk.MustExec("alter table t add column b datetime not null default '2021-06-01 12:00:00'")
+	tk.MustExec("alter table t add column c varchar(10) default 'abc'")
+	tk.MustExec("insert into t values (4, '2021-06-02 12:00:00', 'def')")
+	// The rows written before the columns were added are filled with the origin default values.
+	tk.MustQuery("select * from t where a = 1").Check(testkit.Rows("1 2021-06-01 12:00:00 abc"))
+	tk.MustQuery("select * from t where a in (1, 2, 3, 4)").Sort().Check(testkit.Rows(
+		"1 2021-06-01 12:00:00 abc",
+		"2 2021-06-01 12:00:00 abc",
+		"3 2021-06-01 12:00:00 abc",
+		"4 2021-06-02 12:00:00 def",
+	))
+}
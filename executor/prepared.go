@@ -185,13 +185,14 @@ func (e *PrepareExec) Next(ctx context.Context, req *chunk.Chunk) error {
 		ctx = topsql.AttachSQLInfo(ctx, normalizedSQL, digest, "", nil)
 	}
 
+	var uncacheableReason string
 	if !plannercore.PreparedPlanCacheEnabled() {
 		prepared.UseCache = false
 	} else {
 		if !e.ctx.GetSessionVars().UseDynamicPartitionPrune() {
-			prepared.UseCache = plannercore.Cacheable(stmt, ret.InfoSchema)
+			prepared.UseCache, uncacheableReason = plannercore.CacheableWithReason(stmt, ret.InfoSchema)
 		} else {
-			prepared.UseCache = plannercore.Cacheable(stmt, nil)
+			prepared.UseCache, uncacheableReason = plannercore.CacheableWithReason(stmt, nil)
 		}
 	}
 
@@ -226,6 +227,7 @@ func (e *PrepareExec) Next(ctx context.Context, req *chunk.Chunk) error {
 		SQLDigest:           digest,
 		ForUpdateRead:       destBuilder.GetIsForUpdateRead(),
 		SnapshotTSEvaluator: ret.SnapshotTSEvaluator,
+		UncacheableReason:   uncacheableReason,
 	}
 	return vars.AddPreparedStmt(e.ID, preparedObj)
 }
@@ -195,6 +195,87 @@ func (s *testSuite12) TestPreparedStmtWithHint(c *C) {
 	c.Check(sm.killed, Equals, true)
 }
 
+func (s *testSerialSuite) TestPlanCacheParamFolding(c *C) {
+	store, dom, err := newStoreWithBootstrap()
+	c.Assert(err, IsNil)
+	tk := testkit.NewTestKit(c, store)
+	defer func() {
+		dom.Close()
+		store.Close()
+	}()
+	orgEnable := plannercore.PreparedPlanCacheEnabled()
+	defer func() {
+		plannercore.SetPreparedPlanCache(orgEnable)
+	}()
+	plannercore.SetPreparedPlanCache(true)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int, b varchar(10), key(a))")
+	tk.MustExec("insert into t values(1, 'x'), (2, 'y'), (3, null)")
+	tk.MustExec("set @@tidb_enable_plan_cache_param_folding = 1")
+
+	tk.MustExec(`prepare stmt from "select a, concat(b, ?) from t where (a > ? or ? = 1) and ifnull(b, ?) != 'z' order by a"`)
+	tk.MustExec("set @s = '!', @a = 1, @f = 0, @n = 'n'")
+	tk.MustQuery("execute stmt using @s, @a, @f, @n").Check(testkit.Rows("2 y!", "3 <nil>"))
+	tk.MustExec("set @s = '?', @a = 5, @f = 1, @n = 'z'")
+	tk.MustQuery("execute stmt using @s, @a, @f, @n").Check(testkit.Rows("1 x?", "2 y?"))
+	tk.MustQuery("select @@last_plan_from_cache").Check(testkit.Rows("1"))
+	// The filters are always false.
+	tk.MustExec("set @a = 5, @f = 0")
+	tk.MustQuery("execute stmt using @s, @a, @f, @n").Check(testkit.Rows())
+	tk.MustQuery("select @@last_plan_from_cache").Check(testkit.Rows("1"))
+	// The cached plan is not changed by the folding.
+	tk.MustExec("set @@tidb_enable_plan_cache_param_folding = 0")
+	tk.MustExec("set @s = '.', @a = 1, @f = 0, @n = 'n'")
+	tk.MustQuery("execute stmt using @s, @a, @f, @n").Check(testkit.Rows("2 y.", "3 <nil>"))
+	tk.MustQuery("select @@last_plan_from_cache").Check(testkit.Rows("1"))
+}
+
+func (s *testSerialSuite) TestPlanCacheOptimizerNotes(c *C) {
+	store, dom, err := newStoreWithBootstrap()
+	c.Assert(err, IsNil)
+	tk := testkit.NewTestKit(c, store)
+	defer func() {
+		dom.Close()
+		store.Close()
+	}()
+	orgEnable := plannercore.PreparedPlanCacheEnabled()
+	defer func() {
+		plannercore.SetPreparedPlanCache(orgEnable)
+	}()
+	plannercore.SetPreparedPlanCache(true)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int, b int, key(a))")
+	tk.MustExec("insert into t values (1, 1)")
+	hasNote := func(note string) bool {
+		for _, row := range tk.MustQuery("show warnings").Rows() {
+			if row[0] == "Note" && row[2] == note {
+				return true
+			}
+		}
+		return false
+	}
+
+	tk.MustExec(`prepare stmt from "select * from t where a in (select a from t where b > ?)"`)
+	tk.MustExec("set @a = 0")
+	tk.MustQuery("execute stmt using @a").Check(testkit.Rows("1 1"))
+	c.Assert(hasNote("skip plan-cache: query has sub-queries"), IsFalse)
+	tk.MustExec("set @@tidb_enable_optimizer_notes = 1")
+	tk.MustQuery("execute stmt using @a").Check(testkit.Rows("1 1"))
+	c.Assert(hasNote("skip plan-cache: query has sub-queries"), IsTrue)
+
+	tk.MustExec(`prepare stmt from "select * from t where a > ?"`)
+	tk.MustQuery("execute stmt using @a").Check(testkit.Rows("1 1"))
+	tk.MustQuery("execute stmt using @a").Check(testkit.Rows("1 1"))
+	tk.MustQuery("select @@last_plan_from_cache").Check(testkit.Rows("1"))
+	tk.MustExec("begin")
+	tk.MustExec("insert into t values (2, 2)")
+	tk.MustQuery("execute stmt using @a").Sort().Check(testkit.Rows("1 1", "2 2"))
+	c.Assert(hasNote("skip plan-cache: table `t` is modified in the transaction"), IsTrue)
+	tk.MustExec("rollback")
+}
+
 func (s *testSerialSuite) TestPlanCacheClusterIndex(c *C) {
 	store, dom, err := newStoreWithBootstrap()
 	c.Assert(err, IsNil)
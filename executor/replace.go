@@ -61,7 +61,7 @@ func (e *ReplaceExec) Open(ctx context.Context) error {
 
 // removeRow removes the duplicate row and cleanup its keys in the key-value map,
 // but if the to-be-removed row equals to the to-be-added row, no remove or add things to do.
-func (e *ReplaceExec) removeRow(ctx context.Context, txn kv.Transaction, handle kv.Handle, r toBeCheckedRow) (bool, error) {
+func (e *ReplaceExec) removeRow(ctx context.Context, txn kv.Transaction, handle kv.Handle, r toBeCheckedRow, dupErr error) (bool, error) {
 	newRow := r.row
 	oldRow, err := getOldRow(ctx, e.ctx, txn, r.t, handle, e.GenExprs)
 	if err != nil {
@@ -73,6 +73,9 @@ func (e *ReplaceExec) removeRow(ctx context.Context, txn kv.Transaction, handle
 		}
 		return false, err
 	}
+	if err = e.checkDupRowVisible(oldRow, dupErr); err != nil {
+		return false, err
+	}
 
 	rowUnchanged, err := e.EqualDatumsAsBinary(e.ctx.GetSessionVars().StmtCtx, oldRow, newRow)
 	if err != nil {
@@ -126,7 +129,7 @@ func (e *ReplaceExec) replaceRow(ctx context.Context, r toBeCheckedRow) error {
 		}
 
 		if _, err := txn.Get(ctx, r.handleKey.newKey); err == nil {
-			rowUnchanged, err := e.removeRow(ctx, txn, handle, r)
+			rowUnchanged, err := e.removeRow(ctx, txn, handle, r, r.handleKey.dupErr)
 			if err != nil {
 				return err
 			}
@@ -182,7 +185,7 @@ func (e *ReplaceExec) removeIndexRow(ctx context.Context, txn kv.Transaction, r
 		if err != nil {
 			return false, true, err
 		}
-		rowUnchanged, err := e.removeRow(ctx, txn, handle, r)
+		rowUnchanged, err := e.removeRow(ctx, txn, handle, r, uk.dupErr)
 		if err != nil {
 			return false, true, err
 		}
@@ -0,0 +1,99 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"time"
+
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/tidb/config"
+	"github.com/pingcap/tidb/metrics"
+	plannercore "github.com/pingcap/tidb/planner/core"
+	"github.com/pingcap/tidb/privilege"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/sessionctx/variable"
+)
+
+// canUseResourceGroup checks whether the user of the session can put the statements in the group by
+// tidb_resource_group. The group must list the user, or the user must have the RESOURCE_GROUP_ADMIN privilege,
+// otherwise the user could escape the limits of its own group.
+func canUseResourceGroup(sctx sessionctx.Context, group *config.ResourceGroup) bool {
+	sessVars := sctx.GetSessionVars()
+	if sessVars.User != nil {
+		for _, u := range group.Users {
+			if u == sessVars.User.Username {
+				return true
+			}
+		}
+	}
+	checker := privilege.GetPrivilegeManager(sctx)
+	return checker == nil || checker.RequestDynamicVerification(sessVars.ActiveRoles, "RESOURCE_GROUP_ADMIN", false)
+}
+
+// getResourceGroup returns the resource group of the statement, which is the group set by the SET_VAR hint or
+// tidb_resource_group, or the group listing the user of the session if neither is set.
+func getResourceGroup(sctx sessionctx.Context) *config.ResourceGroup {
+	sessVars := sctx.GetSessionVars()
+	if sessVars.InRestrictedSQL {
+		return nil
+	}
+	cfg := config.GetGlobalConfig()
+	// SET checks the privilege of the session value, the value set by the SET_VAR hint is checked here.
+	if name, ok := sessVars.StmtCtx.StmtHints.SetVars[variable.TiDBResourceGroup]; ok {
+		if group := cfg.GetResourceGroup(name); group != nil {
+			if canUseResourceGroup(sctx, group) {
+				return group
+			}
+			sessVars.StmtCtx.AppendWarning(plannercore.ErrSpecificAccessDenied.GenWithStackByArgs("SUPER or RESOURCE_GROUP_ADMIN"))
+		}
+	}
+	if len(sessVars.ResourceGroup) > 0 {
+		return cfg.GetResourceGroup(sessVars.ResourceGroup)
+	}
+	if sessVars.User != nil {
+		return cfg.GetUserResourceGroup(sessVars.User.Username)
+	}
+	return nil
+}
+
+// applyResourceGroup limits the statement by its resource group. It's called after the statement is compiled,
+// so the group set by the SET_VAR hint takes effect, and the MEMORY_QUOTA hint can't exceed the quota of the group.
+func applyResourceGroup(sctx sessionctx.Context) {
+	group := getResourceGroup(sctx)
+	if group == nil {
+		return
+	}
+	sc := sctx.GetSessionVars().StmtCtx
+	sc.ResourceGroupName = group.Name
+	sc.CopConcurrencyLimit = group.CopConcurrency
+	// The priority specified by the statement or tidb_force_priority takes precedence over the group.
+	if sc.Priority == mysql.NoPriority {
+		sc.Priority = mysql.Str2Priority(group.Priority)
+	}
+	if quota := group.MemQuotaQuery; quota > 0 {
+		if limit := sc.MemTracker.GetBytesLimit(); limit <= 0 || limit > quota {
+			sc.MemTracker.SetBytesLimit(quota)
+		}
+	}
+}
+
+func recordResourceGroupUsage(group string, succ bool, duration time.Duration, memConsumed int64) {
+	result := metrics.LblOK
+	if !succ {
+		result = metrics.LblError
+	}
+	metrics.ResourceGroupStmtCounter.WithLabelValues(group, result).Inc()
+	metrics.ResourceGroupStmtDuration.WithLabelValues(group).Observe(duration.Seconds())
+	metrics.ResourceGroupStmtMemory.WithLabelValues(group).Observe(float64(memConsumed))
+}
@@ -961,6 +961,25 @@ func (s *seqTestSuite) TestCartesianProduct(c *C) {
 	plannercore.AllowCartesianProduct.Store(true)
 }
 
+func (s *seqTestSuite) TestTxnTotalSizeLimitVar(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t_size_limit")
+	tk.MustExec("create table t_size_limit (c varchar(255))")
+	val := strings.Repeat("a", 255)
+	insertSQL := fmt.Sprintf("insert into t_size_limit values ('%s'), ('%s'), ('%s'), ('%s'), ('%s')", val, val, val, val, val)
+
+	tk.MustExec("set @@session.tidb_txn_total_size_limit = 1024")
+	err := tk.ExecToErr(insertSQL)
+	c.Assert(kv.ErrTxnTooLarge.Equal(err), IsTrue, Commentf("%v", err))
+	tk.MustQuery("select count(*) from t_size_limit").Check(testkit.Rows("0"))
+
+	// 0 means using the limit in the config.
+	tk.MustExec("set @@session.tidb_txn_total_size_limit = 0")
+	tk.MustExec(insertSQL)
+	tk.MustQuery("select count(*) from t_size_limit").Check(testkit.Rows("5"))
+}
+
 func (s *seqTestSuite) TestBatchInsertDelete(c *C) {
 	originLimit := atomic.LoadUint64(&kv.TxnTotalSizeLimit)
 	defer func() {
@@ -1077,6 +1096,20 @@ func (s *seqTestSuite) TestBatchInsertDelete(c *C) {
 	tk.MustExec(sql)
 	tk.MustQuery("select count(*) from com_batch_insert;").Check(testkit.Rows("200"))
 
+	// Test case for batch update.
+	// This will meet txn too large error.
+	_, err = tk.Exec("update batch_insert set c = c + 1;")
+	c.Assert(err, NotNil)
+	c.Assert(kv.ErrTxnTooLarge.Equal(err), IsTrue)
+	r = tk.MustQuery("select count(*) from batch_insert where c = 2;")
+	r.Check(testkit.Rows("0"))
+	// Enable batch update, the batch size is 50.
+	tk.MustExec("set @@session.tidb_batch_update=on;")
+	tk.MustExec("update batch_insert set c = c + 1;")
+	r = tk.MustQuery("select count(*) from batch_insert where c = 2;")
+	r.Check(testkit.Rows("640"))
+	tk.MustExec("set @@session.tidb_batch_update=off;")
+
 	// Test case for batch delete.
 	// This will meet txn too large error.
 	_, err = tk.Exec("delete from batch_insert;")
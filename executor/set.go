@@ -21,8 +21,10 @@ import (
 	"github.com/pingcap/parser/ast"
 	"github.com/pingcap/parser/charset"
 	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/domain"
 	"github.com/pingcap/tidb/expression"
+	plannercore "github.com/pingcap/tidb/planner/core"
 	"github.com/pingcap/tidb/plugin"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/util/chunk"
@@ -146,6 +148,11 @@ func (e *SetExecutor) setSysVariable(name string, v *expression.VarAssignment) e
 			sessionVars.TxnReadTS.SetTxnReadTS(oldSnapshotTS)
 		}
 	}
+	if name == variable.TiDBResourceGroup && len(valStr) > 0 {
+		if group := config.GetGlobalConfig().GetResourceGroup(valStr); group != nil && !canUseResourceGroup(e.ctx, group) {
+			return plannercore.ErrSpecificAccessDenied.GenWithStackByArgs("SUPER or RESOURCE_GROUP_ADMIN")
+		}
+	}
 	if name == variable.TxnIsolationOneShot && sessionVars.InTxn() {
 		return errors.Trace(ErrCantChangeTxCharacteristics)
 	}
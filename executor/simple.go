@@ -26,9 +26,11 @@ import (
 	"github.com/pingcap/parser/auth"
 	"github.com/pingcap/parser/model"
 	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/terror"
 	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/distsql"
 	"github.com/pingcap/tidb/domain"
+	"github.com/pingcap/tidb/domain/infosync"
 	"github.com/pingcap/tidb/errno"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/infoschema"
@@ -37,6 +39,7 @@ import (
 	"github.com/pingcap/tidb/planner/core"
 	"github.com/pingcap/tidb/plugin"
 	"github.com/pingcap/tidb/privilege"
+	"github.com/pingcap/tidb/privilege/privileges"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/util"
@@ -1368,6 +1371,21 @@ func killRemoteConn(ctx context.Context, sctx sessionctx.Context, connID *util.G
 	if connID.ServerID == 0 {
 		return errors.New("Unexpected ZERO ServerID. Please file a bug to the TiDB Team")
 	}
+	// The kill is sent to no instance if the serverID is unknown, which returns no error.
+	servers, err := infosync.GetAllServerInfo(ctx)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, ser := range servers {
+		if ser.ServerIDGetter() == connID.ServerID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return errors.Errorf("no TiDB instance with serverID %d", connID.ServerID)
+	}
 
 	killExec := &tipb.Executor{
 		Tp:   tipb.ExecType_TypeKill,
@@ -1401,10 +1419,18 @@ func killRemoteConn(ctx context.Context, sctx sessionctx.Context, connID *util.G
 		err := errors.New("client returns nil response")
 		return err
 	}
+	defer func() {
+		terror.Log(resp.Close())
+	}()
+	// The remote instance returns no rows for the kill, the response is read to get the error of the kill if any,
+	// such as the remote instance is unavailable.
+	if _, err = resp.Next(ctx); err != nil {
+		return err
+	}
 
 	logutil.BgLogger().Info("Killed remote connection", zap.Uint64("serverID", connID.ServerID),
 		zap.Uint64("connID", connID.ID()), zap.Bool("query", query))
-	return err
+	return nil
 }
 
 func (e *SimpleExec) executeFlush(s *ast.FlushStmt) error {
@@ -1428,8 +1454,10 @@ func (e *SimpleExec) executeFlush(s *ast.FlushStmt) error {
 			return err
 		}
 		defer sysSessionPool.Put(ctx)
-		err = dom.PrivilegeHandle().Update(ctx.(sessionctx.Context))
-		return err
+		if err = dom.PrivilegeHandle().Update(ctx.(sessionctx.Context)); err != nil {
+			return err
+		}
+		e.checkSecurityPolicies(dom.PrivilegeHandle().Get())
 	case ast.FlushTiDBPlugin:
 		dom := domain.GetDomain(e.ctx)
 		for _, pluginName := range s.Plugins {
@@ -1444,6 +1472,22 @@ func (e *SimpleExec) executeFlush(s *ast.FlushStmt) error {
 	return nil
 }
 
+// checkSecurityPolicies warns about the row-level security policies that can't be applied to their tables. The
+// policies are still loaded, so the queries on the tables fail instead of reading the rows that should be filtered out.
+func (e *SimpleExec) checkSecurityPolicies(priv *privileges.MySQLPrivilege) {
+	sc := e.ctx.GetSessionVars().StmtCtx
+	for i := range priv.SecurityPolicy {
+		record := &priv.SecurityPolicy[i]
+		tbl, err := e.is.TableByName(model.NewCIStr(record.DB), model.NewCIStr(record.TableName))
+		if err == nil {
+			err = core.CheckSecurityPolicy(tbl.Meta(), record.Predicate)
+		}
+		if err != nil {
+			sc.AppendWarning(core.ErrInvalidSecurityPolicy.GenWithStackByArgs(record.DB, record.TableName, err.Error()))
+		}
+	}
+}
+
 func (e *SimpleExec) executeAlterInstance(s *ast.AlterInstanceStmt) error {
 	if s.ReloadTLS {
 		logutil.BgLogger().Info("execute reload tls", zap.Bool("NoRollbackOnError", s.NoRollbackOnError))
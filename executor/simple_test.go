@@ -551,8 +551,14 @@ func (s *testSuite3) TestKillStmt(c *C) {
 	result = tk.MustQuery("show warnings")
 	result.Check(testkit.Rows())
 
+	// remote kill, the error is reported if the instance doesn't exist.
+	connID = util.GlobalConnID{Is64bits: true, ServerID: 3, LocalConnID: 101}
+	tk.MustExec("kill " + strconv.FormatUint(connID.ID(), 10))
+	result = tk.MustQuery("show warnings")
+	result.Check(testkit.Rows("Warning 1105 KILL remote connection failed: no TiDB instance with serverID 3"))
+
 	config.StoreGlobalConfig(originCfg)
-	// remote kill is tested in `tests/globalkilltest`
+	// the successful remote kill is tested in `tests/globalkilltest`
 }
 
 func (s *testSuite3) TestFlushPrivileges(c *C) {
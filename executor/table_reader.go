@@ -18,6 +18,7 @@ import (
 	"sort"
 
 	"github.com/opentracing/opentracing-go"
+	"github.com/pingcap/errors"
 	"github.com/pingcap/parser/model"
 	"github.com/pingcap/tidb/distsql"
 	"github.com/pingcap/tidb/expression"
@@ -26,13 +27,18 @@ import (
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/statistics"
 	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/table/tables"
+	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/types"
 	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/logutil"
 	"github.com/pingcap/tidb/util/memory"
 	"github.com/pingcap/tidb/util/ranger"
+	"github.com/pingcap/tidb/util/rowcodec"
 	"github.com/pingcap/tidb/util/stringutil"
 	"github.com/pingcap/tipb/go-tipb"
+	"github.com/tikv/client-go/v2/tikv"
 )
 
 // make sure `TableReaderExecutor` implements `Executor`.
@@ -104,6 +110,11 @@ type TableReaderExecutor struct {
 	virtualColumnRetFieldTypes []*types.FieldType
 	// batchCop indicates whether use super batch coprocessor request, only works for TiFlash engine.
 	batchCop bool
+
+	// filter is the filter of the parent SelectionExec, it's evaluated before the rows are copied from the responses.
+	filter distsql.RowFilter
+	// unfilteredChk is used to filter the rows if the results don't support filtering, such as the streaming results.
+	unfilteredChk *chunk.Chunk
 }
 
 // Open initializes necessary variables for using this executor.
@@ -145,6 +156,7 @@ func (e *TableReaderExecutor) Open(ctx context.Context) error {
 	}
 
 	e.resultHandler = &tableResultHandler{}
+	e.unfilteredChk = nil
 	if e.feedback != nil && e.feedback.Hist != nil {
 		// EncodeInt don't need *statement.Context.
 		var ok bool
@@ -180,6 +192,7 @@ func (e *TableReaderExecutor) Open(ctx context.Context) error {
 	}
 	if len(secondPartRanges) == 0 {
 		e.resultHandler.open(nil, firstResult)
+		e.setFilter()
 		return nil
 	}
 	var secondResult distsql.SelectResult
@@ -189,9 +202,19 @@ func (e *TableReaderExecutor) Open(ctx context.Context) error {
 		return err
 	}
 	e.resultHandler.open(firstResult, secondResult)
+	e.setFilter()
 	return nil
 }
 
+func (e *TableReaderExecutor) setFilter() {
+	if e.filter == nil {
+		return
+	}
+	if !e.resultHandler.setFilter(e.filter) {
+		e.unfilteredChk = newFirstChunk(e)
+	}
+}
+
 // Next fills data into the chunk passed by its caller.
 // The task was actually done by tableReaderHandler.
 func (e *TableReaderExecutor) Next(ctx context.Context, req *chunk.Chunk) error {
@@ -208,6 +231,13 @@ func (e *TableReaderExecutor) Next(ctx context.Context, req *chunk.Chunk) error
 		}
 		return tableName
 	}), e.ranges)
+	if e.unfilteredChk != nil {
+		if err := e.nextSelected(ctx, req); err != nil {
+			e.feedback.Invalidate()
+			return err
+		}
+		return nil
+	}
 	if err := e.resultHandler.nextChunk(ctx, req); err != nil {
 		e.feedback.Invalidate()
 		return err
@@ -221,6 +251,30 @@ func (e *TableReaderExecutor) Next(ctx context.Context, req *chunk.Chunk) error
 	return nil
 }
 
+// nextSelected reads the rows from the results which don't support filtering, and appends the selected ones to req
+// until it's not empty or there's no more data.
+func (e *TableReaderExecutor) nextSelected(ctx context.Context, req *chunk.Chunk) error {
+	req.Reset()
+	for req.NumRows() == 0 {
+		if err := e.resultHandler.nextChunk(ctx, e.unfilteredChk); err != nil {
+			return err
+		}
+		if e.unfilteredChk.NumRows() == 0 {
+			return nil
+		}
+		selected, err := e.filter(e.unfilteredChk)
+		if err != nil {
+			return err
+		}
+		for i, ok := range selected {
+			if ok {
+				req.AppendRow(e.unfilteredChk.GetRow(i))
+			}
+		}
+	}
+	return nil
+}
+
 // Close implements the Executor Close interface.
 func (e *TableReaderExecutor) Close() error {
 	if e.table.Meta() != nil && e.table.Meta().TempTableType != model.TempTableNone {
@@ -261,6 +315,9 @@ func (e *TableReaderExecutor) buildResp(ctx context.Context, ranges []*ranger.Ra
 		return nil, err
 	}
 	e.kvRanges = append(e.kvRanges, kvReq.KeyRanges...)
+	if e.canReadByBatchGet(ranges) {
+		return e.buildBatchGetResult(kvReq, ranges)
+	}
 
 	result, err := e.SelectResult(ctx, e.ctx, kvReq, retTypes(e), e.feedback, getPhysicalPlanIDs(e.plans), e.id)
 	if err != nil {
@@ -376,6 +433,24 @@ func (tr *tableResultHandler) open(optionalResult, result distsql.SelectResult)
 	tr.optionalFinished = false
 }
 
+// setFilter sets the filter to the results, it returns false without setting any of them if some of the results
+// don't support filtering.
+func (tr *tableResultHandler) setFilter(filter distsql.RowFilter) bool {
+	results := []distsql.SelectResult{tr.result}
+	if !tr.optionalFinished {
+		results = append(results, tr.optionalResult)
+	}
+	for _, r := range results {
+		if !distsql.CanFilter(r) {
+			return false
+		}
+	}
+	for _, r := range results {
+		r.(distsql.FilterableSelectResult).SetFilter(filter)
+	}
+	return true
+}
+
 func (tr *tableResultHandler) nextChunk(ctx context.Context, chk *chunk.Chunk) error {
 	if !tr.optionalFinished {
 		err := tr.optionalResult.Next(ctx, chk)
@@ -413,3 +488,171 @@ func (tr *tableResultHandler) Close() error {
 	tr.optionalResult, tr.result = nil, nil
 	return err
 }
+
+// canReadByBatchGet checks whether the ranges are read by the batched point gets instead of the cop tasks, it requires
+// tidb_point_ranges_batch_get_size is set, the cop request only scans the table and all the ranges are handle points.
+func (e *TableReaderExecutor) canReadByBatchGet(ranges []*ranger.Range) bool {
+	tblInfo := e.table.Meta()
+	if e.ctx.GetSessionVars().PointRangesBatchGetSize <= 0 || e.storeType != kv.TiKV || tblInfo == nil || len(ranges) == 0 {
+		return false
+	}
+	// The feedback needs the row count of every range, which is collected by the cop tasks.
+	if e.feedback != nil && e.feedback.Valid {
+		return false
+	}
+	if len(e.dagPB.Executors) != 1 || e.dagPB.Executors[0].Tp != tipb.ExecType_TypeTableScan {
+		return false
+	}
+	for _, col := range e.schema.Columns {
+		if col.ID == model.ExtraPidColID {
+			return false
+		}
+	}
+	handleLen := 1
+	if tblInfo.IsCommonHandle {
+		handleLen = len(tables.FindPrimaryIndex(tblInfo).Columns)
+	}
+	sc := e.ctx.GetSessionVars().StmtCtx
+	for _, ran := range ranges {
+		if len(ran.LowVal) != handleLen || !ran.IsPoint(sc) {
+			return false
+		}
+	}
+	return true
+}
+
+// buildBatchGetResult builds the result reading the point ranges by the batched point gets on the snapshot of the
+// request. The snapshot groups the keys of a batch by region and gets them from the regions concurrently.
+func (e *TableReaderExecutor) buildBatchGetResult(kvReq *kv.Request, ranges []*ranger.Range) (distsql.SelectResult, error) {
+	tblInfo := e.table.Meta()
+	sc := e.ctx.GetSessionVars().StmtCtx
+	physicalID := getPhysicalTableID(e.table)
+	handles := make([]kv.Handle, 0, len(ranges))
+	keys := make([]kv.Key, 0, len(ranges))
+	for _, ran := range ranges {
+		var handle kv.Handle
+		if tblInfo.IsCommonHandle {
+			encoded, err := codec.EncodeKey(sc, nil, ran.LowVal...)
+			if err != nil {
+				return nil, err
+			}
+			handle, err = kv.NewCommonHandle(encoded)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			handle = kv.IntHandle(ran.LowVal[0].GetInt64())
+		}
+		handles = append(handles, handle)
+		keys = append(keys, tablecodec.EncodeRowKeyWithHandle(physicalID, handle))
+	}
+	if e.desc {
+		for i, j := 0, len(keys)-1; i < j; i, j = i+1, j-1 {
+			handles[i], handles[j] = handles[j], handles[i]
+			keys[i], keys[j] = keys[j], keys[i]
+		}
+	}
+
+	snapshot := e.ctx.GetStore().GetSnapshot(kv.Version{Ver: kvReq.StartTs})
+	snapshot.SetOption(kv.Priority, kvReq.Priority)
+	snapshot.SetOption(kv.NotFillCache, kvReq.NotFillCache)
+	if kvReq.ReplicaRead.IsFollowerRead() {
+		snapshot.SetOption(kv.ReplicaRead, kv.ReplicaReadFollower)
+	}
+	snapshot.SetOption(kv.TaskID, kvReq.TaskID)
+	snapshot.SetOption(kv.TxnScope, kvReq.TxnScope)
+	snapshot.SetOption(kv.IsStalenessReadOnly, kvReq.IsStaleness)
+	if len(kvReq.MatchStoreLabels) > 0 {
+		snapshot.SetOption(kv.MatchStoreLabels, kvReq.MatchStoreLabels)
+	}
+	setResourceGroupTagForTxn(sc, snapshot)
+	if e.runtimeStats != nil {
+		snapshotStats := &tikv.SnapshotRuntimeStats{}
+		snapshot.SetOption(kv.CollectRuntimeStats, snapshotStats)
+		sc.RuntimeStatsColl.RegisterStats(e.id, &runtimeStatsWithSnapshot{SnapshotRuntimeStats: snapshotStats})
+	}
+	return &batchGetSelectResult{
+		ctx:        e.ctx,
+		schema:     e.schema,
+		tblInfo:    tblInfo,
+		snapshot:   snapshot,
+		rowDecoder: NewRowDecoder(e.ctx, e.schema, tblInfo),
+		memTracker: e.memTracker,
+		handles:    handles,
+		keys:       keys,
+		batchSize:  e.ctx.GetSessionVars().PointRangesBatchGetSize,
+	}, nil
+}
+
+// batchGetSelectResult reads the rows of the handles by the batched point gets, in the order of the handles.
+type batchGetSelectResult struct {
+	ctx        sessionctx.Context
+	schema     *expression.Schema
+	tblInfo    *model.TableInfo
+	snapshot   kv.Snapshot
+	rowDecoder *rowcodec.ChunkDecoder
+	memTracker *memory.Tracker
+
+	handles   []kv.Handle
+	keys      []kv.Key
+	batchSize int
+
+	// values are the rows of the keys in [cursor, batchEnd), the keys not found are absent.
+	values     map[string][]byte
+	valuesSize int64
+	batchEnd   int
+	cursor     int
+}
+
+// NextRaw implements the distsql.SelectResult interface.
+func (r *batchGetSelectResult) NextRaw(context.Context) ([]byte, error) {
+	return nil, errors.New("the batched point gets don't return the raw responses")
+}
+
+// Next implements the distsql.SelectResult interface.
+func (r *batchGetSelectResult) Next(ctx context.Context, chk *chunk.Chunk) error {
+	chk.Reset()
+	for !chk.IsFull() && r.cursor < len(r.keys) {
+		if r.cursor == r.batchEnd {
+			if err := r.fetchBatch(ctx); err != nil {
+				return err
+			}
+		}
+		for ; r.cursor < r.batchEnd && !chk.IsFull(); r.cursor++ {
+			val := r.values[string(r.keys[r.cursor])]
+			if len(val) == 0 {
+				continue
+			}
+			err := DecodeRowValToChunk(r.ctx, r.schema, r.tblInfo, r.handles[r.cursor], val, chk, r.rowDecoder)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *batchGetSelectResult) fetchBatch(ctx context.Context) error {
+	end := r.cursor + r.batchSize
+	if end > len(r.keys) {
+		end = len(r.keys)
+	}
+	values, err := r.snapshot.BatchGet(ctx, r.keys[r.cursor:end])
+	if err != nil {
+		return err
+	}
+	var size int64
+	for k, v := range values {
+		size += int64(len(k) + len(v))
+	}
+	r.memTracker.Consume(size - r.valuesSize)
+	r.values, r.valuesSize, r.batchEnd = values, size, end
+	return nil
+}
+
+// Close implements the distsql.SelectResult interface.
+func (r *batchGetSelectResult) Close() error {
+	r.memTracker.Consume(-r.valuesSize)
+	r.values, r.valuesSize = nil, 0
+	return nil
+}
@@ -856,3 +856,38 @@ func (s *tiflashTestSuite) TestTiFlashPartitionTableBroadcastJoin(c *C) {
 		}
 	}
 }
+
+func (s *tiflashTestSuite) TestMppBuildTasksInOneRequest(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists tb1, tb2")
+	tk.MustExec("create table tb1(a int not null primary key, b int not null)")
+	tk.MustExec("create table tb2(a int not null primary key, b int not null) partition by hash(a) partitions 4")
+	for _, name := range []string{"tb1", "tb2"} {
+		tk.MustExec(fmt.Sprintf("alter table %s set tiflash replica 1", name))
+		tb := testGetTableByName(c, tk.Se, "test", name)
+		err := domain.GetDomain(tk.Se).DDL().UpdateTableReplicaInfo(tk.Se, tb.Meta().ID, true)
+		c.Assert(err, IsNil)
+		tk.MustExec(fmt.Sprintf("insert into %s values(1,0),(2,0),(3,0),(4,0)", name))
+	}
+	tk.MustExec("set @@session.tidb_isolation_read_engines=\"tiflash\"")
+	tk.MustExec("set @@session.tidb_allow_mpp=ON")
+	tk.MustExec("set @@session.tidb_opt_broadcast_join=ON")
+	tk.MustExec("set @@session.tidb_partition_prune_mode='dynamic'")
+
+	// the tasks of all the table scans are built in one request, a second request fails.
+	buildTasksErr := "github.com/pingcap/tidb/store/copr/mppBuildTasksRequestError"
+	for _, sql := range []string{
+		"select count(*) from tb2, tb1 where tb2.a = tb1.a",
+		"select count(*) from tb1 t1, tb1 t2 where t1.a = t2.a",
+		"select count(*) from tb2, tb1 t1, tb1 t2 where tb2.a = t1.a and t2.a = t1.a",
+	} {
+		c.Assert(failpoint.Enable(buildTasksErr, `1*return(false)->return(true)`), IsNil)
+		failpoint.Enable("github.com/pingcap/tidb/executor/checkUseMPP", `return(true)`)
+		tk.MustQuery(sql).Check(testkit.Rows("4"))
+		failpoint.Disable("github.com/pingcap/tidb/executor/checkUseMPP")
+		_, err := tk.Exec(sql)
+		c.Assert(err, ErrorMatches, ".*mock mpp build tasks request error.*")
+		c.Assert(failpoint.Disable(buildTasksErr), IsNil)
+	}
+}
@@ -14,17 +14,21 @@
 package executor
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"runtime/trace"
 
+	"github.com/pingcap/failpoint"
 	"github.com/pingcap/parser/model"
 	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/kv"
 	plannercore "github.com/pingcap/tidb/planner/core"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/types"
 	"github.com/pingcap/tidb/util/chunk"
 	"github.com/pingcap/tidb/util/memory"
@@ -244,6 +248,32 @@ func (e *UpdateExec) updateRows(ctx context.Context) (int, error) {
 	if !e.allAssignmentsAreConstant {
 		composeFunc = e.composeNewRow
 	}
+	batchDMLSize := e.ctx.GetSessionVars().DMLBatchSize
+	// If tidb_batch_update is ON and not in a transaction, we could use BatchUpdate mode for the single-table update.
+	// The batches are cut by tidb_dml_batch_size in the read order, it can't dry run or resume a failed update.
+	batchUpdate := e.ctx.GetSessionVars().BatchUpdate && !e.ctx.GetSessionVars().InTxn() &&
+		config.GetGlobalConfig().EnableBatchDML && batchDMLSize > 0 && len(e.tblColPosInfos) == 1
+	var (
+		locker *pipelinedLocker
+		// readTS is the ts of the snapshot the rows are read from, the rows read in the later batches are checked
+		// against the batch transactions because they may be changed after readTS.
+		readTS     uint64
+		batchRows  *batchUpdateRows
+		newBatches bool
+	)
+	if batchUpdate {
+		readTS = e.ctx.GetSessionVars().TxnCtx.GetForUpdateTS()
+	} else {
+		var err error
+		locker, err = newPipelinedLocker(e.ctx, e.children[0])
+		if err != nil {
+			return 0, err
+		}
+		if locker != nil {
+			defer locker.close()
+		}
+	}
+	rowCount := 0
 	memUsageOfChk := int64(0)
 	totalNumRows := 0
 	for {
@@ -258,6 +288,11 @@ func (e *UpdateExec) updateRows(ctx context.Context) (int, error) {
 		}
 		memUsageOfChk = chk.MemoryUsage()
 		e.memTracker.Consume(memUsageOfChk)
+		batchRows = nil
+		// The rows of the previous chunk are locked while reading this chunk.
+		if locker != nil {
+			locker.beginBatch()
+		}
 		if e.collectRuntimeStatsEnabled() {
 			txn, err := e.ctx.Txn(true)
 			if err == nil && txn.GetSnapshot() != nil {
@@ -271,12 +306,42 @@ func (e *UpdateExec) updateRows(ctx context.Context) (int, error) {
 			}
 		}
 		for rowIdx := 0; rowIdx < chk.NumRows(); rowIdx++ {
+			if batchUpdate && rowCount >= batchDMLSize {
+				failpoint.InjectContext(ctx, "batchUpdateTest-step1", func() {
+					if ch, ok := ctx.Value("batchUpdateTest").(chan struct{}); ok {
+						// Make the concurrent `UPDATE` continue
+						close(ch)
+					}
+					// Wait the concurrent `UPDATE` finished
+					failpoint.InjectContext(ctx, "batchUpdateTest-step2", nil)
+				})
+				if err := e.doBatchUpdate(ctx); err != nil {
+					return 0, err
+				}
+				rowCount = 0
+				batchRows, newBatches = nil, true
+			}
+			if newBatches && batchRows == nil {
+				batchRows, err = e.getBatchUpdateRows(ctx, readTS, chk, rowIdx, fields)
+				if err != nil {
+					return 0, err
+				}
+			}
 			chunkRow := chk.GetRow(rowIdx)
 			datumRow := chunkRow.GetDatumRow(fields)
 			// precomputes handles
 			if err := e.prepare(datumRow); err != nil {
 				return 0, err
 			}
+			if batchRows != nil {
+				exists, err := batchRows.check(e.handles[0], datumRow)
+				if err != nil {
+					return 0, err
+				}
+				if !exists {
+					continue
+				}
+			}
 			// compose non-generated columns
 			newRow, err := composeFunc(globalRowIdx, datumRow, colsInfo)
 			if err != nil {
@@ -301,6 +366,10 @@ func (e *UpdateExec) updateRows(ctx context.Context) (int, error) {
 			if err := e.exec(ctx, e.children[0].Schema(), datumRow, newRow); err != nil {
 				return 0, err
 			}
+			rowCount++
+		}
+		if locker != nil {
+			locker.endBatch(ctx)
 		}
 		totalNumRows += chk.NumRows()
 		chk = chunk.Renew(chk, e.maxChunkSize)
@@ -308,6 +377,90 @@ func (e *UpdateExec) updateRows(ctx context.Context) (int, error) {
 	return totalNumRows, nil
 }
 
+func (e *UpdateExec) doBatchUpdate(ctx context.Context) error {
+	txn, err := e.ctx.Txn(false)
+	if err != nil {
+		return ErrBatchInsertFail.GenWithStack("BatchUpdate failed with error: %v", err)
+	}
+	e.memTracker.Consume(-int64(txn.Size()))
+	e.ctx.StmtCommit()
+	if err := e.ctx.NewTxn(ctx); err != nil {
+		// We should return a special error for batch insert.
+		return ErrBatchInsertFail.GenWithStack("BatchUpdate failed with error: %v", err)
+	}
+	return nil
+}
+
+// batchUpdateRows are the rows of a chunk in the snapshot the update reads and in the current batch transaction.
+type batchUpdateRows struct {
+	e         *UpdateExec
+	readRows  map[string][]byte
+	batchRows map[string][]byte
+}
+
+func (e *UpdateExec) recordKey(handle kv.Handle, row []types.Datum) (kv.Key, error) {
+	content := e.tblColPosInfos[0]
+	tbl := e.tblID2table[content.TblID]
+	if pt, ok := tbl.(table.PartitionedTable); ok {
+		p, err := pt.GetPartitionByRow(e.ctx, row[content.Start:content.End])
+		if err != nil {
+			return nil, err
+		}
+		return tablecodec.EncodeRecordKey(p.RecordPrefix(), handle), nil
+	}
+	return tablecodec.EncodeRecordKey(tbl.RecordPrefix(), handle), nil
+}
+
+// getBatchUpdateRows gets the rows from the begin-th row of the chunk both in the snapshot at readTS and in the current
+// batch transaction.
+func (e *UpdateExec) getBatchUpdateRows(ctx context.Context, readTS uint64, chk *chunk.Chunk, begin int,
+	fields []*types.FieldType) (*batchUpdateRows, error) {
+	content := e.tblColPosInfos[0]
+	keys := make([]kv.Key, 0, chk.NumRows()-begin)
+	for i := begin; i < chk.NumRows(); i++ {
+		row := chk.GetRow(i)
+		handle, err := content.HandleCols.BuildHandle(row)
+		if err != nil {
+			return nil, err
+		}
+		key, err := e.recordKey(handle, row.GetDatumRow(fields))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	readRows, err := e.ctx.GetStore().GetSnapshot(kv.Version{Ver: readTS}).BatchGet(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	txn, err := e.ctx.Txn(true)
+	if err != nil {
+		return nil, err
+	}
+	batchRows, err := txn.BatchGet(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	return &batchUpdateRows{e: e, readRows: readRows, batchRows: batchRows}, nil
+}
+
+// check returns whether the row still exists in the batch transaction, it returns an error if the row is changed
+// after it's read, because updating it by the read values would overwrite the change.
+func (r *batchUpdateRows) check(handle kv.Handle, row []types.Datum) (bool, error) {
+	key, err := r.e.recordKey(handle, row)
+	if err != nil {
+		return false, err
+	}
+	val, ok := r.batchRows[string(key)]
+	if !ok {
+		return false, nil
+	}
+	if !bytes.Equal(val, r.readRows[string(key)]) {
+		return false, ErrBatchInsertFail.GenWithStack("BatchUpdate failed: the row of handle %s is changed after it's read, the batches before it are committed", handle)
+	}
+	return true, nil
+}
+
 func (e *UpdateExec) handleErr(colName model.CIStr, rowIdx int, err error) error {
 	if err == nil {
 		return nil
@@ -413,7 +566,8 @@ func (e *UpdateExec) Close() error {
 	e.setMessage()
 	if e.runtimeStats != nil && e.stats != nil {
 		txn, err := e.ctx.Txn(false)
-		if err == nil && txn.GetSnapshot() != nil {
+		// The transaction is invalid if the commit of a batch fails in the batch update.
+		if err == nil && txn.Valid() && txn.GetSnapshot() != nil {
 			txn.GetSnapshot().SetOption(kv.CollectRuntimeStats, nil)
 		}
 	}
@@ -197,6 +197,9 @@ func CheckAggPushDown(aggFunc *AggFuncDesc, storeType kv.StoreType) bool {
 	if aggFunc.Name == ast.AggFuncApproxPercentile {
 		return false
 	}
+	if _, ok := GetUserDefinedAggFunc(aggFunc.Name); ok {
+		return false
+	}
 	ret := true
 	switch storeType {
 	case kv.TiFlash:
@@ -118,7 +118,15 @@ func (a *baseFuncDesc) typeInfer(ctx sessionctx.Context) error {
 	case ast.AggFuncJsonObjectAgg:
 		a.typeInfer4JsonFuncs(ctx)
 	default:
-		return errors.Errorf("unsupported agg function: %s", a.Name)
+		def, ok := GetUserDefinedAggFunc(a.Name)
+		if !ok {
+			return errors.Errorf("unsupported agg function: %s", a.Name)
+		}
+		retTp, err := def.InferType(ctx, a.Args)
+		if err != nil {
+			return err
+		}
+		a.RetTp = retTp
 	}
 	return nil
 }
@@ -385,6 +393,10 @@ func (a *baseFuncDesc) WrapCastForAggArgs(ctx sessionctx.Context) {
 	if _, ok := noNeedCastAggFuncs[a.Name]; ok {
 		return
 	}
+	// The user defined functions evaluate the arguments by themselves.
+	if _, ok := GetUserDefinedAggFunc(a.Name); ok {
+		return
+	}
 	var castFunc func(ctx sessionctx.Context, expr expression.Expression) expression.Expression
 	switch retTp := a.RetTp; retTp.EvalType() {
 	case types.ETInt:
@@ -192,6 +192,9 @@ func (a *AggFuncDesc) EvalNullValueInOuterJoin(ctx sessionctx.Context, schema *e
 	case ast.AggFuncBitOr, ast.AggFuncBitXor:
 		return a.evalNullValueInOuterJoin4BitOr(ctx, schema)
 	default:
+		if _, ok := GetUserDefinedAggFunc(a.Name); ok {
+			return types.Datum{}, false
+		}
 		panic("unsupported agg function")
 	}
 }
@@ -305,7 +308,11 @@ func (a *AggFuncDesc) UpdateNotNullFlag4RetType(hasGroupBy, allAggsFirstRow bool
 			removeNotNull = true
 		}
 	default:
-		return errors.Errorf("unsupported agg function: %s", a.Name)
+		if _, ok := GetUserDefinedAggFunc(a.Name); !ok {
+			return errors.Errorf("unsupported agg function: %s", a.Name)
+		}
+		// The user defined functions return NULL for the empty input without GROUP BY.
+		removeNotNull = !hasGroupBy
 	}
 	if removeNotNull {
 		a.RetTp = a.RetTp.Clone()
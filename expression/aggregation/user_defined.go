@@ -0,0 +1,89 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aggregation
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/chunk"
+)
+
+// UserDefinedAggFunc is the implementation of an aggregate function registered by RegisterUserDefinedAggFunc. The
+// partial result is the intermediate state of a group. In the parallel hash aggregation, the partial results updated
+// by the partial workers are merged by the final workers, so the function works in two phases without any change.
+// It's executed by TiDB only and never pushed down to TiKV or TiFlash.
+type UserDefinedAggFunc interface {
+	// Init returns a new partial result and its memory usage.
+	Init() (pr interface{}, memDelta int64)
+	// Update updates the partial result with the rows of a group, on which the arguments are evaluated.
+	Update(ctx sessionctx.Context, rows []chunk.Row, pr interface{}) (memDelta int64, err error)
+	// Merge merges the partial result src into dst.
+	Merge(ctx sessionctx.Context, src, dst interface{}) (memDelta int64, err error)
+	// Finalize returns the final result of the partial result.
+	Finalize(ctx sessionctx.Context, pr interface{}) (types.Datum, error)
+}
+
+// UserDefinedAggFuncDef is the definition of a user defined aggregate function.
+type UserDefinedAggFuncDef struct {
+	// InferType returns the result type of the function for the arguments.
+	InferType func(ctx sessionctx.Context, args []expression.Expression) (*types.FieldType, error)
+	// New returns the implementation of the function for the arguments and the result type.
+	New func(args []expression.Expression, retTp *types.FieldType) UserDefinedAggFunc
+}
+
+var userDefinedAggFuncs = struct {
+	sync.RWMutex
+	defs map[string]*UserDefinedAggFuncDef
+}{defs: make(map[string]*UserDefinedAggFuncDef)}
+
+// RegisterUserDefinedAggFunc registers a user defined aggregate function, which is called in SQL by its name like a
+// builtin aggregate function, but without DISTINCT or ORDER BY. It returns NULL for an empty input without GROUP BY.
+// The name can't be the name of a builtin scalar function, and the names of the builtin aggregate functions always
+// refer to the builtin ones.
+func RegisterUserDefinedAggFunc(name string, def *UserDefinedAggFuncDef) error {
+	name = strings.ToLower(name)
+	if name == "" || def == nil || def.InferType == nil || def.New == nil {
+		return errors.Errorf("invalid user defined aggregate function %s", name)
+	}
+	if expression.IsFunctionSupported(name) {
+		return errors.Errorf("user defined aggregate function %s conflicts with the builtin function", name)
+	}
+	userDefinedAggFuncs.Lock()
+	defer userDefinedAggFuncs.Unlock()
+	if _, ok := userDefinedAggFuncs.defs[name]; ok {
+		return errors.Errorf("user defined aggregate function %s is already registered", name)
+	}
+	userDefinedAggFuncs.defs[name] = def
+	return nil
+}
+
+// GetUserDefinedAggFunc returns the definition of the user defined aggregate function with the lower case name.
+func GetUserDefinedAggFunc(name string) (*UserDefinedAggFuncDef, bool) {
+	userDefinedAggFuncs.RLock()
+	defer userDefinedAggFuncs.RUnlock()
+	def, ok := userDefinedAggFuncs.defs[name]
+	return def, ok
+}
+
+// HasUserDefinedAggFuncs returns whether any user defined aggregate function is registered.
+func HasUserDefinedAggFuncs() bool {
+	userDefinedAggFuncs.RLock()
+	defer userDefinedAggFuncs.RUnlock()
+	return len(userDefinedAggFuncs.defs) > 0
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/pingcap/tidb/types"
 	"github.com/pingcap/tidb/types/json"
 	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/collate"
 	"github.com/pingcap/tipb/go-tipb"
 )
 
@@ -2648,8 +2649,20 @@ func CompareInt(sctx sessionctx.Context, lhsArg, rhsArg Expression, lhsRow, rhsR
 }
 
 func genCompareString(collation string) func(sctx sessionctx.Context, lhsArg Expression, rhsArg Expression, lhsRow chunk.Row, rhsRow chunk.Row) (int64, bool, error) {
+	compare := collate.GetCompareFunc(collation)
 	return func(sctx sessionctx.Context, lhsArg, rhsArg Expression, lhsRow, rhsRow chunk.Row) (int64, bool, error) {
-		return CompareStringWithCollationInfo(sctx, lhsArg, rhsArg, lhsRow, rhsRow, collation)
+		arg0, isNull0, err := lhsArg.EvalString(sctx, lhsRow)
+		if err != nil {
+			return 0, true, err
+		}
+		arg1, isNull1, err := rhsArg.EvalString(sctx, rhsRow)
+		if err != nil {
+			return 0, true, err
+		}
+		if isNull0 || isNull1 {
+			return compareNull(isNull0, isNull1), true, nil
+		}
+		return int64(compare(arg0, arg1)), false, nil
 	}
 }
 
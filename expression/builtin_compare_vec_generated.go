@@ -19,6 +19,7 @@ import (
 	"github.com/pingcap/tidb/types"
 	"github.com/pingcap/tidb/types/json"
 	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/collate"
 )
 
 func (b *builtinLTRealSig) vecEvalInt(input *chunk.Chunk, result *chunk.Column) error {
@@ -127,11 +128,12 @@ func (b *builtinLTStringSig) vecEvalInt(input *chunk.Chunk, result *chunk.Column
 	result.ResizeInt64(n, false)
 	result.MergeNulls(buf0, buf1)
 	i64s := result.Int64s()
+	compare := collate.GetCompareFunc(b.collation)
 	for i := 0; i < n; i++ {
 		if result.IsNull(i) {
 			continue
 		}
-		val := types.CompareString(buf0.GetString(i), buf1.GetString(i), b.collation)
+		val := compare(buf0.GetString(i), buf1.GetString(i))
 		if val < 0 {
 			i64s[i] = 1
 		} else {
@@ -375,11 +377,12 @@ func (b *builtinLEStringSig) vecEvalInt(input *chunk.Chunk, result *chunk.Column
 	result.ResizeInt64(n, false)
 	result.MergeNulls(buf0, buf1)
 	i64s := result.Int64s()
+	compare := collate.GetCompareFunc(b.collation)
 	for i := 0; i < n; i++ {
 		if result.IsNull(i) {
 			continue
 		}
-		val := types.CompareString(buf0.GetString(i), buf1.GetString(i), b.collation)
+		val := compare(buf0.GetString(i), buf1.GetString(i))
 		if val <= 0 {
 			i64s[i] = 1
 		} else {
@@ -623,11 +626,12 @@ func (b *builtinGTStringSig) vecEvalInt(input *chunk.Chunk, result *chunk.Column
 	result.ResizeInt64(n, false)
 	result.MergeNulls(buf0, buf1)
 	i64s := result.Int64s()
+	compare := collate.GetCompareFunc(b.collation)
 	for i := 0; i < n; i++ {
 		if result.IsNull(i) {
 			continue
 		}
-		val := types.CompareString(buf0.GetString(i), buf1.GetString(i), b.collation)
+		val := compare(buf0.GetString(i), buf1.GetString(i))
 		if val > 0 {
 			i64s[i] = 1
 		} else {
@@ -871,11 +875,12 @@ func (b *builtinGEStringSig) vecEvalInt(input *chunk.Chunk, result *chunk.Column
 	result.ResizeInt64(n, false)
 	result.MergeNulls(buf0, buf1)
 	i64s := result.Int64s()
+	compare := collate.GetCompareFunc(b.collation)
 	for i := 0; i < n; i++ {
 		if result.IsNull(i) {
 			continue
 		}
-		val := types.CompareString(buf0.GetString(i), buf1.GetString(i), b.collation)
+		val := compare(buf0.GetString(i), buf1.GetString(i))
 		if val >= 0 {
 			i64s[i] = 1
 		} else {
@@ -1119,11 +1124,12 @@ func (b *builtinEQStringSig) vecEvalInt(input *chunk.Chunk, result *chunk.Column
 	result.ResizeInt64(n, false)
 	result.MergeNulls(buf0, buf1)
 	i64s := result.Int64s()
+	compare := collate.GetCompareFunc(b.collation)
 	for i := 0; i < n; i++ {
 		if result.IsNull(i) {
 			continue
 		}
-		val := types.CompareString(buf0.GetString(i), buf1.GetString(i), b.collation)
+		val := compare(buf0.GetString(i), buf1.GetString(i))
 		if val == 0 {
 			i64s[i] = 1
 		} else {
@@ -1367,11 +1373,12 @@ func (b *builtinNEStringSig) vecEvalInt(input *chunk.Chunk, result *chunk.Column
 	result.ResizeInt64(n, false)
 	result.MergeNulls(buf0, buf1)
 	i64s := result.Int64s()
+	compare := collate.GetCompareFunc(b.collation)
 	for i := 0; i < n; i++ {
 		if result.IsNull(i) {
 			continue
 		}
-		val := types.CompareString(buf0.GetString(i), buf1.GetString(i), b.collation)
+		val := compare(buf0.GetString(i), buf1.GetString(i))
 		if val != 0 {
 			i64s[i] = 1
 		} else {
@@ -1614,6 +1621,7 @@ func (b *builtinNullEQStringSig) vecEvalInt(input *chunk.Chunk, result *chunk.Co
 
 	result.ResizeInt64(n, false)
 	i64s := result.Int64s()
+	compare := collate.GetCompareFunc(b.collation)
 	for i := 0; i < n; i++ {
 		isNull0 := buf0.IsNull(i)
 		isNull1 := buf1.IsNull(i)
@@ -1622,7 +1630,7 @@ func (b *builtinNullEQStringSig) vecEvalInt(input *chunk.Chunk, result *chunk.Co
 			i64s[i] = 1
 		case isNull0 != isNull1:
 			i64s[i] = 0
-		case types.CompareString(buf0.GetString(i), buf1.GetString(i), b.collation) == 0:
+		case compare(buf0.GetString(i), buf1.GetString(i)) == 0:
 			i64s[i] = 1
 		}
 	}
@@ -22,113 +22,100 @@ import (
 	"github.com/pingcap/tidb/util/chunk"
 )
 
-// NOTE: Control expressions optionally evaluate some branches depending on conditions, but vectorization executes all
-// branches, during which the unnecessary branches may return errors or warnings. To avoid this case, when branches
-// meet errors or warnings, the vectorization falls back the scalar execution.
-
-func (b *builtinCaseWhenIntSig) fallbackEvalInt(input *chunk.Chunk, result *chunk.Column) error {
-	n := input.NumRows()
-	result.ResizeInt64(n, false)
-	x := result.Int64s()
-	for i := 0; i < n; i++ {
-		res, isNull, err := b.evalInt(input.GetRow(i))
-		if err != nil {
-			return err
-		}
-		result.SetNull(i, isNull)
-		if isNull {
-			continue
-		}
-
-		x[i] = res
-
-	}
-	return nil
-}
+// NOTE: Control expressions optionally evaluate some branches depending on conditions. The vectorization evaluates
+// each branch only on the rows which take it by the selection vector of the input chunk, so the unnecessary branches
+// never return errors or warnings, the same as the scalar execution.
 
 func (b *builtinCaseWhenIntSig) vecEvalInt(input *chunk.Chunk, result *chunk.Column) error {
 	n := input.NumRows()
 	args, l := b.getArgs(), len(b.getArgs())
-	whens := make([]*chunk.Column, l/2)
-	whensSlice := make([][]int64, l/2)
-	thens := make([]*chunk.Column, l/2)
-	var eLse *chunk.Column
-	thensSlice := make([][]int64, l/2)
-	var eLseSlice []int64
-	sc := b.ctx.GetSessionVars().StmtCtx
-	beforeWarns := sc.WarningCount()
+	// clauses[i] is the index in results of the clause which the i-th row takes its result from, -1 means NULL.
+	// offsets[i] is the position of the i-th row in the result of its clause.
+	clauses := make([]int, n)
+	offsets := make([]int, n)
+	results := make([]*chunk.Column, 0, l/2+1)
+	// remaining are the rows not matched by the evaluated when clauses.
+	remaining := make([]int, n)
+	for i := 0; i < n; i++ {
+		clauses[i] = -1
+		remaining[i] = i
+	}
+	matched := make([]int, 0, n)
+	sel := newVecSelection(input)
+	defer sel.restore()
 
-	for j := 0; j < l-1; j += 2 {
-		bufWhen, err := b.bufAllocator.get(types.ETInt, n)
+	// when clause(condition, result) -> args[i], args[i+1]; (i >= 0 && i+1 < l-1)
+	// else clause -> args[l-1]
+	// If case clause has else clause, l%2 == 1.
+	for j := 0; j < l-1 && len(remaining) > 0; j += 2 {
+		sel.selectRows(remaining)
+		bufWhen, err := b.bufAllocator.get(types.ETInt, len(remaining))
 		if err != nil {
 			return err
 		}
 		defer b.bufAllocator.put(bufWhen)
-		err = args[j].VecEvalInt(b.ctx, input, bufWhen)
-		afterWarns := sc.WarningCount()
-		if err != nil || afterWarns > beforeWarns {
-			if afterWarns > beforeWarns {
-				sc.TruncateWarnings(int(beforeWarns))
+		if err := args[j].VecEvalInt(b.ctx, input, bufWhen); err != nil {
+			return err
+		}
+		whens := bufWhen.Int64s()
+		matched = matched[:0]
+		k := 0
+		for i, row := range remaining {
+			if bufWhen.IsNull(i) || whens[i] == 0 {
+				remaining[k] = row
+				k++
+				continue
 			}
-			return b.fallbackEvalInt(input, result)
+			clauses[row] = len(results)
+			offsets[row] = len(matched)
+			matched = append(matched, row)
+		}
+		remaining = remaining[:k]
+		if len(matched) == 0 {
+			continue
 		}
-		whens[j/2] = bufWhen
-		whensSlice[j/2] = bufWhen.Int64s()
 
-		bufThen, err := b.bufAllocator.get(types.ETInt, n)
+		sel.selectRows(matched)
+		bufThen, err := b.bufAllocator.get(types.ETInt, len(matched))
 		if err != nil {
 			return err
 		}
 		defer b.bufAllocator.put(bufThen)
-		err = args[j+1].VecEvalInt(b.ctx, input, bufThen)
-		afterWarns = sc.WarningCount()
-		if err != nil || afterWarns > beforeWarns {
-			if afterWarns > beforeWarns {
-				sc.TruncateWarnings(int(beforeWarns))
-			}
-			return b.fallbackEvalInt(input, result)
+		if err := args[j+1].VecEvalInt(b.ctx, input, bufThen); err != nil {
+			return err
 		}
-		thens[j/2] = bufThen
-		thensSlice[j/2] = bufThen.Int64s()
+		results = append(results, bufThen)
 	}
-	// when clause(condition, result) -> args[i], args[i+1]; (i >= 0 && i+1 < l-1)
-	// else clause -> args[l-1]
-	// If case clause has else clause, l%2 == 1.
-	if l%2 == 1 {
-		bufElse, err := b.bufAllocator.get(types.ETInt, n)
+	if l%2 == 1 && len(remaining) > 0 {
+		for i, row := range remaining {
+			clauses[row] = len(results)
+			offsets[row] = i
+		}
+		sel.selectRows(remaining)
+		bufElse, err := b.bufAllocator.get(types.ETInt, len(remaining))
 		if err != nil {
 			return err
 		}
 		defer b.bufAllocator.put(bufElse)
-		err = args[l-1].VecEvalInt(b.ctx, input, bufElse)
-		afterWarns := sc.WarningCount()
-		if err != nil || afterWarns > beforeWarns {
-			if afterWarns > beforeWarns {
-				sc.TruncateWarnings(int(beforeWarns))
-			}
-			return b.fallbackEvalInt(input, result)
+		if err := args[l-1].VecEvalInt(b.ctx, input, bufElse); err != nil {
+			return err
 		}
-		eLse = bufElse
-		eLseSlice = bufElse.Int64s()
+		results = append(results, bufElse)
+	}
+	resultsSlice := make([][]int64, len(results))
+	for j := range results {
+		resultsSlice[j] = results[j].Int64s()
 	}
 	result.ResizeInt64(n, false)
 	resultSlice := result.Int64s()
-ROW:
 	for i := 0; i < n; i++ {
-		for j := 0; j < l/2; j++ {
-			if whens[j].IsNull(i) || whensSlice[j][i] == 0 {
-				continue
-			}
-			resultSlice[i] = thensSlice[j][i]
-			result.SetNull(i, thens[j].IsNull(i))
-			continue ROW
-		}
-		if eLse != nil {
-			resultSlice[i] = eLseSlice[i]
-			result.SetNull(i, eLse.IsNull(i))
-		} else {
+		j := clauses[i]
+		if j < 0 {
 			result.SetNull(i, true)
+			continue
 		}
+		resultSlice[i] = resultsSlice[j][offsets[i]]
+		result.SetNull(i, results[j].IsNull(offsets[i]))
 	}
 	return nil
 }
@@ -137,109 +124,96 @@ func (b *builtinCaseWhenIntSig) vectorized() bool {
 	return true
 }
 
-func (b *builtinCaseWhenRealSig) fallbackEvalReal(input *chunk.Chunk, result *chunk.Column) error {
-	n := input.NumRows()
-	result.ResizeFloat64(n, false)
-	x := result.Float64s()
-	for i := 0; i < n; i++ {
-		res, isNull, err := b.evalReal(input.GetRow(i))
-		if err != nil {
-			return err
-		}
-		result.SetNull(i, isNull)
-		if isNull {
-			continue
-		}
-
-		x[i] = res
-
-	}
-	return nil
-}
-
 func (b *builtinCaseWhenRealSig) vecEvalReal(input *chunk.Chunk, result *chunk.Column) error {
 	n := input.NumRows()
 	args, l := b.getArgs(), len(b.getArgs())
-	whens := make([]*chunk.Column, l/2)
-	whensSlice := make([][]int64, l/2)
-	thens := make([]*chunk.Column, l/2)
-	var eLse *chunk.Column
-	thensSlice := make([][]float64, l/2)
-	var eLseSlice []float64
-	sc := b.ctx.GetSessionVars().StmtCtx
-	beforeWarns := sc.WarningCount()
+	// clauses[i] is the index in results of the clause which the i-th row takes its result from, -1 means NULL.
+	// offsets[i] is the position of the i-th row in the result of its clause.
+	clauses := make([]int, n)
+	offsets := make([]int, n)
+	results := make([]*chunk.Column, 0, l/2+1)
+	// remaining are the rows not matched by the evaluated when clauses.
+	remaining := make([]int, n)
+	for i := 0; i < n; i++ {
+		clauses[i] = -1
+		remaining[i] = i
+	}
+	matched := make([]int, 0, n)
+	sel := newVecSelection(input)
+	defer sel.restore()
 
-	for j := 0; j < l-1; j += 2 {
-		bufWhen, err := b.bufAllocator.get(types.ETInt, n)
+	// when clause(condition, result) -> args[i], args[i+1]; (i >= 0 && i+1 < l-1)
+	// else clause -> args[l-1]
+	// If case clause has else clause, l%2 == 1.
+	for j := 0; j < l-1 && len(remaining) > 0; j += 2 {
+		sel.selectRows(remaining)
+		bufWhen, err := b.bufAllocator.get(types.ETInt, len(remaining))
 		if err != nil {
 			return err
 		}
 		defer b.bufAllocator.put(bufWhen)
-		err = args[j].VecEvalInt(b.ctx, input, bufWhen)
-		afterWarns := sc.WarningCount()
-		if err != nil || afterWarns > beforeWarns {
-			if afterWarns > beforeWarns {
-				sc.TruncateWarnings(int(beforeWarns))
+		if err := args[j].VecEvalInt(b.ctx, input, bufWhen); err != nil {
+			return err
+		}
+		whens := bufWhen.Int64s()
+		matched = matched[:0]
+		k := 0
+		for i, row := range remaining {
+			if bufWhen.IsNull(i) || whens[i] == 0 {
+				remaining[k] = row
+				k++
+				continue
 			}
-			return b.fallbackEvalReal(input, result)
+			clauses[row] = len(results)
+			offsets[row] = len(matched)
+			matched = append(matched, row)
+		}
+		remaining = remaining[:k]
+		if len(matched) == 0 {
+			continue
 		}
-		whens[j/2] = bufWhen
-		whensSlice[j/2] = bufWhen.Int64s()
 
-		bufThen, err := b.bufAllocator.get(types.ETReal, n)
+		sel.selectRows(matched)
+		bufThen, err := b.bufAllocator.get(types.ETReal, len(matched))
 		if err != nil {
 			return err
 		}
 		defer b.bufAllocator.put(bufThen)
-		err = args[j+1].VecEvalReal(b.ctx, input, bufThen)
-		afterWarns = sc.WarningCount()
-		if err != nil || afterWarns > beforeWarns {
-			if afterWarns > beforeWarns {
-				sc.TruncateWarnings(int(beforeWarns))
-			}
-			return b.fallbackEvalReal(input, result)
+		if err := args[j+1].VecEvalReal(b.ctx, input, bufThen); err != nil {
+			return err
 		}
-		thens[j/2] = bufThen
-		thensSlice[j/2] = bufThen.Float64s()
+		results = append(results, bufThen)
 	}
-	// when clause(condition, result) -> args[i], args[i+1]; (i >= 0 && i+1 < l-1)
-	// else clause -> args[l-1]
-	// If case clause has else clause, l%2 == 1.
-	if l%2 == 1 {
-		bufElse, err := b.bufAllocator.get(types.ETReal, n)
+	if l%2 == 1 && len(remaining) > 0 {
+		for i, row := range remaining {
+			clauses[row] = len(results)
+			offsets[row] = i
+		}
+		sel.selectRows(remaining)
+		bufElse, err := b.bufAllocator.get(types.ETReal, len(remaining))
 		if err != nil {
 			return err
 		}
 		defer b.bufAllocator.put(bufElse)
-		err = args[l-1].VecEvalReal(b.ctx, input, bufElse)
-		afterWarns := sc.WarningCount()
-		if err != nil || afterWarns > beforeWarns {
-			if afterWarns > beforeWarns {
-				sc.TruncateWarnings(int(beforeWarns))
-			}
-			return b.fallbackEvalReal(input, result)
+		if err := args[l-1].VecEvalReal(b.ctx, input, bufElse); err != nil {
+			return err
 		}
-		eLse = bufElse
-		eLseSlice = bufElse.Float64s()
+		results = append(results, bufElse)
+	}
+	resultsSlice := make([][]float64, len(results))
+	for j := range results {
+		resultsSlice[j] = results[j].Float64s()
 	}
 	result.ResizeFloat64(n, false)
 	resultSlice := result.Float64s()
-ROW:
 	for i := 0; i < n; i++ {
-		for j := 0; j < l/2; j++ {
-			if whens[j].IsNull(i) || whensSlice[j][i] == 0 {
-				continue
-			}
-			resultSlice[i] = thensSlice[j][i]
-			result.SetNull(i, thens[j].IsNull(i))
-			continue ROW
-		}
-		if eLse != nil {
-			resultSlice[i] = eLseSlice[i]
-			result.SetNull(i, eLse.IsNull(i))
-		} else {
+		j := clauses[i]
+		if j < 0 {
 			result.SetNull(i, true)
+			continue
 		}
+		resultSlice[i] = resultsSlice[j][offsets[i]]
+		result.SetNull(i, results[j].IsNull(offsets[i]))
 	}
 	return nil
 }
@@ -248,109 +222,96 @@ func (b *builtinCaseWhenRealSig) vectorized() bool {
 	return true
 }
 
-func (b *builtinCaseWhenDecimalSig) fallbackEvalDecimal(input *chunk.Chunk, result *chunk.Column) error {
-	n := input.NumRows()
-	result.ResizeDecimal(n, false)
-	x := result.Decimals()
-	for i := 0; i < n; i++ {
-		res, isNull, err := b.evalDecimal(input.GetRow(i))
-		if err != nil {
-			return err
-		}
-		result.SetNull(i, isNull)
-		if isNull {
-			continue
-		}
-
-		x[i] = *res
-
-	}
-	return nil
-}
-
 func (b *builtinCaseWhenDecimalSig) vecEvalDecimal(input *chunk.Chunk, result *chunk.Column) error {
 	n := input.NumRows()
 	args, l := b.getArgs(), len(b.getArgs())
-	whens := make([]*chunk.Column, l/2)
-	whensSlice := make([][]int64, l/2)
-	thens := make([]*chunk.Column, l/2)
-	var eLse *chunk.Column
-	thensSlice := make([][]types.MyDecimal, l/2)
-	var eLseSlice []types.MyDecimal
-	sc := b.ctx.GetSessionVars().StmtCtx
-	beforeWarns := sc.WarningCount()
+	// clauses[i] is the index in results of the clause which the i-th row takes its result from, -1 means NULL.
+	// offsets[i] is the position of the i-th row in the result of its clause.
+	clauses := make([]int, n)
+	offsets := make([]int, n)
+	results := make([]*chunk.Column, 0, l/2+1)
+	// remaining are the rows not matched by the evaluated when clauses.
+	remaining := make([]int, n)
+	for i := 0; i < n; i++ {
+		clauses[i] = -1
+		remaining[i] = i
+	}
+	matched := make([]int, 0, n)
+	sel := newVecSelection(input)
+	defer sel.restore()
 
-	for j := 0; j < l-1; j += 2 {
-		bufWhen, err := b.bufAllocator.get(types.ETInt, n)
+	// when clause(condition, result) -> args[i], args[i+1]; (i >= 0 && i+1 < l-1)
+	// else clause -> args[l-1]
+	// If case clause has else clause, l%2 == 1.
+	for j := 0; j < l-1 && len(remaining) > 0; j += 2 {
+		sel.selectRows(remaining)
+		bufWhen, err := b.bufAllocator.get(types.ETInt, len(remaining))
 		if err != nil {
 			return err
 		}
 		defer b.bufAllocator.put(bufWhen)
-		err = args[j].VecEvalInt(b.ctx, input, bufWhen)
-		afterWarns := sc.WarningCount()
-		if err != nil || afterWarns > beforeWarns {
-			if afterWarns > beforeWarns {
-				sc.TruncateWarnings(int(beforeWarns))
+		if err := args[j].VecEvalInt(b.ctx, input, bufWhen); err != nil {
+			return err
+		}
+		whens := bufWhen.Int64s()
+		matched = matched[:0]
+		k := 0
+		for i, row := range remaining {
+			if bufWhen.IsNull(i) || whens[i] == 0 {
+				remaining[k] = row
+				k++
+				continue
 			}
-			return b.fallbackEvalDecimal(input, result)
+			clauses[row] = len(results)
+			offsets[row] = len(matched)
+			matched = append(matched, row)
+		}
+		remaining = remaining[:k]
+		if len(matched) == 0 {
+			continue
 		}
-		whens[j/2] = bufWhen
-		whensSlice[j/2] = bufWhen.Int64s()
 
-		bufThen, err := b.bufAllocator.get(types.ETDecimal, n)
+		sel.selectRows(matched)
+		bufThen, err := b.bufAllocator.get(types.ETDecimal, len(matched))
 		if err != nil {
 			return err
 		}
 		defer b.bufAllocator.put(bufThen)
-		err = args[j+1].VecEvalDecimal(b.ctx, input, bufThen)
-		afterWarns = sc.WarningCount()
-		if err != nil || afterWarns > beforeWarns {
-			if afterWarns > beforeWarns {
-				sc.TruncateWarnings(int(beforeWarns))
-			}
-			return b.fallbackEvalDecimal(input, result)
+		if err := args[j+1].VecEvalDecimal(b.ctx, input, bufThen); err != nil {
+			return err
 		}
-		thens[j/2] = bufThen
-		thensSlice[j/2] = bufThen.Decimals()
+		results = append(results, bufThen)
 	}
-	// when clause(condition, result) -> args[i], args[i+1]; (i >= 0 && i+1 < l-1)
-	// else clause -> args[l-1]
-	// If case clause has else clause, l%2 == 1.
-	if l%2 == 1 {
-		bufElse, err := b.bufAllocator.get(types.ETDecimal, n)
+	if l%2 == 1 && len(remaining) > 0 {
+		for i, row := range remaining {
+			clauses[row] = len(results)
+			offsets[row] = i
+		}
+		sel.selectRows(remaining)
+		bufElse, err := b.bufAllocator.get(types.ETDecimal, len(remaining))
 		if err != nil {
 			return err
 		}
 		defer b.bufAllocator.put(bufElse)
-		err = args[l-1].VecEvalDecimal(b.ctx, input, bufElse)
-		afterWarns := sc.WarningCount()
-		if err != nil || afterWarns > beforeWarns {
-			if afterWarns > beforeWarns {
-				sc.TruncateWarnings(int(beforeWarns))
-			}
-			return b.fallbackEvalDecimal(input, result)
+		if err := args[l-1].VecEvalDecimal(b.ctx, input, bufElse); err != nil {
+			return err
 		}
-		eLse = bufElse
-		eLseSlice = bufElse.Decimals()
+		results = append(results, bufElse)
+	}
+	resultsSlice := make([][]types.MyDecimal, len(results))
+	for j := range results {
+		resultsSlice[j] = results[j].Decimals()
 	}
 	result.ResizeDecimal(n, false)
 	resultSlice := result.Decimals()
-ROW:
 	for i := 0; i < n; i++ {
-		for j := 0; j < l/2; j++ {
-			if whens[j].IsNull(i) || whensSlice[j][i] == 0 {
-				continue
-			}
-			resultSlice[i] = thensSlice[j][i]
-			result.SetNull(i, thens[j].IsNull(i))
-			continue ROW
-		}
-		if eLse != nil {
-			resultSlice[i] = eLseSlice[i]
-			result.SetNull(i, eLse.IsNull(i))
-		} else {
+		j := clauses[i]
+		if j < 0 {
 			result.SetNull(i, true)
+			continue
 		}
+		resultSlice[i] = resultsSlice[j][offsets[i]]
+		result.SetNull(i, results[j].IsNull(offsets[i]))
 	}
 	return nil
 }
@@ -359,107 +320,90 @@ func (b *builtinCaseWhenDecimalSig) vectorized() bool {
 	return true
 }
 
-func (b *builtinCaseWhenStringSig) fallbackEvalString(input *chunk.Chunk, result *chunk.Column) error {
-	n := input.NumRows()
-	result.ReserveString(n)
-	for i := 0; i < n; i++ {
-		res, isNull, err := b.evalString(input.GetRow(i))
-		if err != nil {
-			return err
-		}
-		if isNull {
-			result.AppendNull()
-			continue
-		}
-		result.AppendString(res)
-	}
-	return nil
-}
-
 func (b *builtinCaseWhenStringSig) vecEvalString(input *chunk.Chunk, result *chunk.Column) error {
 	n := input.NumRows()
 	args, l := b.getArgs(), len(b.getArgs())
-	whens := make([]*chunk.Column, l/2)
-	whensSlice := make([][]int64, l/2)
-	thens := make([]*chunk.Column, l/2)
-	var eLse *chunk.Column
-	sc := b.ctx.GetSessionVars().StmtCtx
-	beforeWarns := sc.WarningCount()
+	// clauses[i] is the index in results of the clause which the i-th row takes its result from, -1 means NULL.
+	// offsets[i] is the position of the i-th row in the result of its clause.
+	clauses := make([]int, n)
+	offsets := make([]int, n)
+	results := make([]*chunk.Column, 0, l/2+1)
+	// remaining are the rows not matched by the evaluated when clauses.
+	remaining := make([]int, n)
+	for i := 0; i < n; i++ {
+		clauses[i] = -1
+		remaining[i] = i
+	}
+	matched := make([]int, 0, n)
+	sel := newVecSelection(input)
+	defer sel.restore()
 
-	for j := 0; j < l-1; j += 2 {
-		bufWhen, err := b.bufAllocator.get(types.ETInt, n)
+	// when clause(condition, result) -> args[i], args[i+1]; (i >= 0 && i+1 < l-1)
+	// else clause -> args[l-1]
+	// If case clause has else clause, l%2 == 1.
+	for j := 0; j < l-1 && len(remaining) > 0; j += 2 {
+		sel.selectRows(remaining)
+		bufWhen, err := b.bufAllocator.get(types.ETInt, len(remaining))
 		if err != nil {
 			return err
 		}
 		defer b.bufAllocator.put(bufWhen)
-		err = args[j].VecEvalInt(b.ctx, input, bufWhen)
-		afterWarns := sc.WarningCount()
-		if err != nil || afterWarns > beforeWarns {
-			if afterWarns > beforeWarns {
-				sc.TruncateWarnings(int(beforeWarns))
+		if err := args[j].VecEvalInt(b.ctx, input, bufWhen); err != nil {
+			return err
+		}
+		whens := bufWhen.Int64s()
+		matched = matched[:0]
+		k := 0
+		for i, row := range remaining {
+			if bufWhen.IsNull(i) || whens[i] == 0 {
+				remaining[k] = row
+				k++
+				continue
 			}
-			return b.fallbackEvalString(input, result)
+			clauses[row] = len(results)
+			offsets[row] = len(matched)
+			matched = append(matched, row)
+		}
+		remaining = remaining[:k]
+		if len(matched) == 0 {
+			continue
 		}
-		whens[j/2] = bufWhen
-		whensSlice[j/2] = bufWhen.Int64s()
 
-		bufThen, err := b.bufAllocator.get(types.ETString, n)
+		sel.selectRows(matched)
+		bufThen, err := b.bufAllocator.get(types.ETString, len(matched))
 		if err != nil {
 			return err
 		}
 		defer b.bufAllocator.put(bufThen)
-		err = args[j+1].VecEvalString(b.ctx, input, bufThen)
-		afterWarns = sc.WarningCount()
-		if err != nil || afterWarns > beforeWarns {
-			if afterWarns > beforeWarns {
-				sc.TruncateWarnings(int(beforeWarns))
-			}
-			return b.fallbackEvalString(input, result)
+		if err := args[j+1].VecEvalString(b.ctx, input, bufThen); err != nil {
+			return err
 		}
-		thens[j/2] = bufThen
+		results = append(results, bufThen)
 	}
-	// when clause(condition, result) -> args[i], args[i+1]; (i >= 0 && i+1 < l-1)
-	// else clause -> args[l-1]
-	// If case clause has else clause, l%2 == 1.
-	if l%2 == 1 {
-		bufElse, err := b.bufAllocator.get(types.ETString, n)
+	if l%2 == 1 && len(remaining) > 0 {
+		for i, row := range remaining {
+			clauses[row] = len(results)
+			offsets[row] = i
+		}
+		sel.selectRows(remaining)
+		bufElse, err := b.bufAllocator.get(types.ETString, len(remaining))
 		if err != nil {
 			return err
 		}
 		defer b.bufAllocator.put(bufElse)
-		err = args[l-1].VecEvalString(b.ctx, input, bufElse)
-		afterWarns := sc.WarningCount()
-		if err != nil || afterWarns > beforeWarns {
-			if afterWarns > beforeWarns {
-				sc.TruncateWarnings(int(beforeWarns))
-			}
-			return b.fallbackEvalString(input, result)
+		if err := args[l-1].VecEvalString(b.ctx, input, bufElse); err != nil {
+			return err
 		}
-		eLse = bufElse
+		results = append(results, bufElse)
 	}
 	result.ReserveString(n)
-ROW:
 	for i := 0; i < n; i++ {
-		for j := 0; j < l/2; j++ {
-			if whens[j].IsNull(i) || whensSlice[j][i] == 0 {
-				continue
-			}
-			if thens[j].IsNull(i) {
-				result.AppendNull()
-			} else {
-				result.AppendString(thens[j].GetString(i))
-			}
-			continue ROW
-		}
-		if eLse != nil {
-			if eLse.IsNull(i) {
-				result.AppendNull()
-			} else {
-				result.AppendString(eLse.GetString(i))
-			}
-		} else {
+		j := clauses[i]
+		if j < 0 || results[j].IsNull(offsets[i]) {
 			result.AppendNull()
+			continue
 		}
+		result.AppendString(results[j].GetString(offsets[i]))
 	}
 	return nil
 }
@@ -468,109 +412,96 @@ func (b *builtinCaseWhenStringSig) vectorized() bool {
 	return true
 }
 
-func (b *builtinCaseWhenTimeSig) fallbackEvalTime(input *chunk.Chunk, result *chunk.Column) error {
-	n := input.NumRows()
-	result.ResizeTime(n, false)
-	x := result.Times()
-	for i := 0; i < n; i++ {
-		res, isNull, err := b.evalTime(input.GetRow(i))
-		if err != nil {
-			return err
-		}
-		result.SetNull(i, isNull)
-		if isNull {
-			continue
-		}
-
-		x[i] = res
-
-	}
-	return nil
-}
-
 func (b *builtinCaseWhenTimeSig) vecEvalTime(input *chunk.Chunk, result *chunk.Column) error {
 	n := input.NumRows()
 	args, l := b.getArgs(), len(b.getArgs())
-	whens := make([]*chunk.Column, l/2)
-	whensSlice := make([][]int64, l/2)
-	thens := make([]*chunk.Column, l/2)
-	var eLse *chunk.Column
-	thensSlice := make([][]types.Time, l/2)
-	var eLseSlice []types.Time
-	sc := b.ctx.GetSessionVars().StmtCtx
-	beforeWarns := sc.WarningCount()
+	// clauses[i] is the index in results of the clause which the i-th row takes its result from, -1 means NULL.
+	// offsets[i] is the position of the i-th row in the result of its clause.
+	clauses := make([]int, n)
+	offsets := make([]int, n)
+	results := make([]*chunk.Column, 0, l/2+1)
+	// remaining are the rows not matched by the evaluated when clauses.
+	remaining := make([]int, n)
+	for i := 0; i < n; i++ {
+		clauses[i] = -1
+		remaining[i] = i
+	}
+	matched := make([]int, 0, n)
+	sel := newVecSelection(input)
+	defer sel.restore()
 
-	for j := 0; j < l-1; j += 2 {
-		bufWhen, err := b.bufAllocator.get(types.ETInt, n)
+	// when clause(condition, result) -> args[i], args[i+1]; (i >= 0 && i+1 < l-1)
+	// else clause -> args[l-1]
+	// If case clause has else clause, l%2 == 1.
+	for j := 0; j < l-1 && len(remaining) > 0; j += 2 {
+		sel.selectRows(remaining)
+		bufWhen, err := b.bufAllocator.get(types.ETInt, len(remaining))
 		if err != nil {
 			return err
 		}
 		defer b.bufAllocator.put(bufWhen)
-		err = args[j].VecEvalInt(b.ctx, input, bufWhen)
-		afterWarns := sc.WarningCount()
-		if err != nil || afterWarns > beforeWarns {
-			if afterWarns > beforeWarns {
-				sc.TruncateWarnings(int(beforeWarns))
+		if err := args[j].VecEvalInt(b.ctx, input, bufWhen); err != nil {
+			return err
+		}
+		whens := bufWhen.Int64s()
+		matched = matched[:0]
+		k := 0
+		for i, row := range remaining {
+			if bufWhen.IsNull(i) || whens[i] == 0 {
+				remaining[k] = row
+				k++
+				continue
 			}
-			return b.fallbackEvalTime(input, result)
+			clauses[row] = len(results)
+			offsets[row] = len(matched)
+			matched = append(matched, row)
+		}
+		remaining = remaining[:k]
+		if len(matched) == 0 {
+			continue
 		}
-		whens[j/2] = bufWhen
-		whensSlice[j/2] = bufWhen.Int64s()
 
-		bufThen, err := b.bufAllocator.get(types.ETDatetime, n)
+		sel.selectRows(matched)
+		bufThen, err := b.bufAllocator.get(types.ETDatetime, len(matched))
 		if err != nil {
 			return err
 		}
 		defer b.bufAllocator.put(bufThen)
-		err = args[j+1].VecEvalTime(b.ctx, input, bufThen)
-		afterWarns = sc.WarningCount()
-		if err != nil || afterWarns > beforeWarns {
-			if afterWarns > beforeWarns {
-				sc.TruncateWarnings(int(beforeWarns))
-			}
-			return b.fallbackEvalTime(input, result)
+		if err := args[j+1].VecEvalTime(b.ctx, input, bufThen); err != nil {
+			return err
 		}
-		thens[j/2] = bufThen
-		thensSlice[j/2] = bufThen.Times()
+		results = append(results, bufThen)
 	}
-	// when clause(condition, result) -> args[i], args[i+1]; (i >= 0 && i+1 < l-1)
-	// else clause -> args[l-1]
-	// If case clause has else clause, l%2 == 1.
-	if l%2 == 1 {
-		bufElse, err := b.bufAllocator.get(types.ETDatetime, n)
+	if l%2 == 1 && len(remaining) > 0 {
+		for i, row := range remaining {
+			clauses[row] = len(results)
+			offsets[row] = i
+		}
+		sel.selectRows(remaining)
+		bufElse, err := b.bufAllocator.get(types.ETDatetime, len(remaining))
 		if err != nil {
 			return err
 		}
 		defer b.bufAllocator.put(bufElse)
-		err = args[l-1].VecEvalTime(b.ctx, input, bufElse)
-		afterWarns := sc.WarningCount()
-		if err != nil || afterWarns > beforeWarns {
-			if afterWarns > beforeWarns {
-				sc.TruncateWarnings(int(beforeWarns))
-			}
-			return b.fallbackEvalTime(input, result)
+		if err := args[l-1].VecEvalTime(b.ctx, input, bufElse); err != nil {
+			return err
 		}
-		eLse = bufElse
-		eLseSlice = bufElse.Times()
+		results = append(results, bufElse)
+	}
+	resultsSlice := make([][]types.Time, len(results))
+	for j := range results {
+		resultsSlice[j] = results[j].Times()
 	}
 	result.ResizeTime(n, false)
 	resultSlice := result.Times()
-ROW:
 	for i := 0; i < n; i++ {
-		for j := 0; j < l/2; j++ {
-			if whens[j].IsNull(i) || whensSlice[j][i] == 0 {
-				continue
-			}
-			resultSlice[i] = thensSlice[j][i]
-			result.SetNull(i, thens[j].IsNull(i))
-			continue ROW
-		}
-		if eLse != nil {
-			resultSlice[i] = eLseSlice[i]
-			result.SetNull(i, eLse.IsNull(i))
-		} else {
+		j := clauses[i]
+		if j < 0 {
 			result.SetNull(i, true)
+			continue
 		}
+		resultSlice[i] = resultsSlice[j][offsets[i]]
+		result.SetNull(i, results[j].IsNull(offsets[i]))
 	}
 	return nil
 }
@@ -579,109 +510,96 @@ func (b *builtinCaseWhenTimeSig) vectorized() bool {
 	return true
 }
 
-func (b *builtinCaseWhenDurationSig) fallbackEvalDuration(input *chunk.Chunk, result *chunk.Column) error {
-	n := input.NumRows()
-	result.ResizeGoDuration(n, false)
-	x := result.GoDurations()
-	for i := 0; i < n; i++ {
-		res, isNull, err := b.evalDuration(input.GetRow(i))
-		if err != nil {
-			return err
-		}
-		result.SetNull(i, isNull)
-		if isNull {
-			continue
-		}
-
-		x[i] = res.Duration
-
-	}
-	return nil
-}
-
 func (b *builtinCaseWhenDurationSig) vecEvalDuration(input *chunk.Chunk, result *chunk.Column) error {
 	n := input.NumRows()
 	args, l := b.getArgs(), len(b.getArgs())
-	whens := make([]*chunk.Column, l/2)
-	whensSlice := make([][]int64, l/2)
-	thens := make([]*chunk.Column, l/2)
-	var eLse *chunk.Column
-	thensSlice := make([][]time.Duration, l/2)
-	var eLseSlice []time.Duration
-	sc := b.ctx.GetSessionVars().StmtCtx
-	beforeWarns := sc.WarningCount()
+	// clauses[i] is the index in results of the clause which the i-th row takes its result from, -1 means NULL.
+	// offsets[i] is the position of the i-th row in the result of its clause.
+	clauses := make([]int, n)
+	offsets := make([]int, n)
+	results := make([]*chunk.Column, 0, l/2+1)
+	// remaining are the rows not matched by the evaluated when clauses.
+	remaining := make([]int, n)
+	for i := 0; i < n; i++ {
+		clauses[i] = -1
+		remaining[i] = i
+	}
+	matched := make([]int, 0, n)
+	sel := newVecSelection(input)
+	defer sel.restore()
 
-	for j := 0; j < l-1; j += 2 {
-		bufWhen, err := b.bufAllocator.get(types.ETInt, n)
+	// when clause(condition, result) -> args[i], args[i+1]; (i >= 0 && i+1 < l-1)
+	// else clause -> args[l-1]
+	// If case clause has else clause, l%2 == 1.
+	for j := 0; j < l-1 && len(remaining) > 0; j += 2 {
+		sel.selectRows(remaining)
+		bufWhen, err := b.bufAllocator.get(types.ETInt, len(remaining))
 		if err != nil {
 			return err
 		}
 		defer b.bufAllocator.put(bufWhen)
-		err = args[j].VecEvalInt(b.ctx, input, bufWhen)
-		afterWarns := sc.WarningCount()
-		if err != nil || afterWarns > beforeWarns {
-			if afterWarns > beforeWarns {
-				sc.TruncateWarnings(int(beforeWarns))
+		if err := args[j].VecEvalInt(b.ctx, input, bufWhen); err != nil {
+			return err
+		}
+		whens := bufWhen.Int64s()
+		matched = matched[:0]
+		k := 0
+		for i, row := range remaining {
+			if bufWhen.IsNull(i) || whens[i] == 0 {
+				remaining[k] = row
+				k++
+				continue
 			}
-			return b.fallbackEvalDuration(input, result)
+			clauses[row] = len(results)
+			offsets[row] = len(matched)
+			matched = append(matched, row)
+		}
+		remaining = remaining[:k]
+		if len(matched) == 0 {
+			continue
 		}
-		whens[j/2] = bufWhen
-		whensSlice[j/2] = bufWhen.Int64s()
 
-		bufThen, err := b.bufAllocator.get(types.ETDuration, n)
+		sel.selectRows(matched)
+		bufThen, err := b.bufAllocator.get(types.ETDuration, len(matched))
 		if err != nil {
 			return err
 		}
 		defer b.bufAllocator.put(bufThen)
-		err = args[j+1].VecEvalDuration(b.ctx, input, bufThen)
-		afterWarns = sc.WarningCount()
-		if err != nil || afterWarns > beforeWarns {
-			if afterWarns > beforeWarns {
-				sc.TruncateWarnings(int(beforeWarns))
-			}
-			return b.fallbackEvalDuration(input, result)
+		if err := args[j+1].VecEvalDuration(b.ctx, input, bufThen); err != nil {
+			return err
 		}
-		thens[j/2] = bufThen
-		thensSlice[j/2] = bufThen.GoDurations()
+		results = append(results, bufThen)
 	}
-	// when clause(condition, result) -> args[i], args[i+1]; (i >= 0 && i+1 < l-1)
-	// else clause -> args[l-1]
-	// If case clause has else clause, l%2 == 1.
-	if l%2 == 1 {
-		bufElse, err := b.bufAllocator.get(types.ETDuration, n)
+	if l%2 == 1 && len(remaining) > 0 {
+		for i, row := range remaining {
+			clauses[row] = len(results)
+			offsets[row] = i
+		}
+		sel.selectRows(remaining)
+		bufElse, err := b.bufAllocator.get(types.ETDuration, len(remaining))
 		if err != nil {
 			return err
 		}
 		defer b.bufAllocator.put(bufElse)
-		err = args[l-1].VecEvalDuration(b.ctx, input, bufElse)
-		afterWarns := sc.WarningCount()
-		if err != nil || afterWarns > beforeWarns {
-			if afterWarns > beforeWarns {
-				sc.TruncateWarnings(int(beforeWarns))
-			}
-			return b.fallbackEvalDuration(input, result)
+		if err := args[l-1].VecEvalDuration(b.ctx, input, bufElse); err != nil {
+			return err
 		}
-		eLse = bufElse
-		eLseSlice = bufElse.GoDurations()
+		results = append(results, bufElse)
+	}
+	resultsSlice := make([][]time.Duration, len(results))
+	for j := range results {
+		resultsSlice[j] = results[j].GoDurations()
 	}
 	result.ResizeGoDuration(n, false)
 	resultSlice := result.GoDurations()
-ROW:
 	for i := 0; i < n; i++ {
-		for j := 0; j < l/2; j++ {
-			if whens[j].IsNull(i) || whensSlice[j][i] == 0 {
-				continue
-			}
-			resultSlice[i] = thensSlice[j][i]
-			result.SetNull(i, thens[j].IsNull(i))
-			continue ROW
-		}
-		if eLse != nil {
-			resultSlice[i] = eLseSlice[i]
-			result.SetNull(i, eLse.IsNull(i))
-		} else {
+		j := clauses[i]
+		if j < 0 {
 			result.SetNull(i, true)
+			continue
 		}
+		resultSlice[i] = resultsSlice[j][offsets[i]]
+		result.SetNull(i, results[j].IsNull(offsets[i]))
 	}
 	return nil
 }
@@ -690,107 +608,90 @@ func (b *builtinCaseWhenDurationSig) vectorized() bool {
 	return true
 }
 
-func (b *builtinCaseWhenJSONSig) fallbackEvalJSON(input *chunk.Chunk, result *chunk.Column) error {
-	n := input.NumRows()
-	result.ReserveJSON(n)
-	for i := 0; i < n; i++ {
-		res, isNull, err := b.evalJSON(input.GetRow(i))
-		if err != nil {
-			return err
-		}
-		if isNull {
-			result.AppendNull()
-			continue
-		}
-		result.AppendJSON(res)
-	}
-	return nil
-}
-
 func (b *builtinCaseWhenJSONSig) vecEvalJSON(input *chunk.Chunk, result *chunk.Column) error {
 	n := input.NumRows()
 	args, l := b.getArgs(), len(b.getArgs())
-	whens := make([]*chunk.Column, l/2)
-	whensSlice := make([][]int64, l/2)
-	thens := make([]*chunk.Column, l/2)
-	var eLse *chunk.Column
-	sc := b.ctx.GetSessionVars().StmtCtx
-	beforeWarns := sc.WarningCount()
+	// clauses[i] is the index in results of the clause which the i-th row takes its result from, -1 means NULL.
+	// offsets[i] is the position of the i-th row in the result of its clause.
+	clauses := make([]int, n)
+	offsets := make([]int, n)
+	results := make([]*chunk.Column, 0, l/2+1)
+	// remaining are the rows not matched by the evaluated when clauses.
+	remaining := make([]int, n)
+	for i := 0; i < n; i++ {
+		clauses[i] = -1
+		remaining[i] = i
+	}
+	matched := make([]int, 0, n)
+	sel := newVecSelection(input)
+	defer sel.restore()
 
-	for j := 0; j < l-1; j += 2 {
-		bufWhen, err := b.bufAllocator.get(types.ETInt, n)
+	// when clause(condition, result) -> args[i], args[i+1]; (i >= 0 && i+1 < l-1)
+	// else clause -> args[l-1]
+	// If case clause has else clause, l%2 == 1.
+	for j := 0; j < l-1 && len(remaining) > 0; j += 2 {
+		sel.selectRows(remaining)
+		bufWhen, err := b.bufAllocator.get(types.ETInt, len(remaining))
 		if err != nil {
 			return err
 		}
 		defer b.bufAllocator.put(bufWhen)
-		err = args[j].VecEvalInt(b.ctx, input, bufWhen)
-		afterWarns := sc.WarningCount()
-		if err != nil || afterWarns > beforeWarns {
-			if afterWarns > beforeWarns {
-				sc.TruncateWarnings(int(beforeWarns))
+		if err := args[j].VecEvalInt(b.ctx, input, bufWhen); err != nil {
+			return err
+		}
+		whens := bufWhen.Int64s()
+		matched = matched[:0]
+		k := 0
+		for i, row := range remaining {
+			if bufWhen.IsNull(i) || whens[i] == 0 {
+				remaining[k] = row
+				k++
+				continue
 			}
-			return b.fallbackEvalJSON(input, result)
+			clauses[row] = len(results)
+			offsets[row] = len(matched)
+			matched = append(matched, row)
+		}
+		remaining = remaining[:k]
+		if len(matched) == 0 {
+			continue
 		}
-		whens[j/2] = bufWhen
-		whensSlice[j/2] = bufWhen.Int64s()
 
-		bufThen, err := b.bufAllocator.get(types.ETJson, n)
+		sel.selectRows(matched)
+		bufThen, err := b.bufAllocator.get(types.ETJson, len(matched))
 		if err != nil {
 			return err
 		}
 		defer b.bufAllocator.put(bufThen)
-		err = args[j+1].VecEvalJSON(b.ctx, input, bufThen)
-		afterWarns = sc.WarningCount()
-		if err != nil || afterWarns > beforeWarns {
-			if afterWarns > beforeWarns {
-				sc.TruncateWarnings(int(beforeWarns))
-			}
-			return b.fallbackEvalJSON(input, result)
+		if err := args[j+1].VecEvalJSON(b.ctx, input, bufThen); err != nil {
+			return err
 		}
-		thens[j/2] = bufThen
+		results = append(results, bufThen)
 	}
-	// when clause(condition, result) -> args[i], args[i+1]; (i >= 0 && i+1 < l-1)
-	// else clause -> args[l-1]
-	// If case clause has else clause, l%2 == 1.
-	if l%2 == 1 {
-		bufElse, err := b.bufAllocator.get(types.ETJson, n)
+	if l%2 == 1 && len(remaining) > 0 {
+		for i, row := range remaining {
+			clauses[row] = len(results)
+			offsets[row] = i
+		}
+		sel.selectRows(remaining)
+		bufElse, err := b.bufAllocator.get(types.ETJson, len(remaining))
 		if err != nil {
 			return err
 		}
 		defer b.bufAllocator.put(bufElse)
-		err = args[l-1].VecEvalJSON(b.ctx, input, bufElse)
-		afterWarns := sc.WarningCount()
-		if err != nil || afterWarns > beforeWarns {
-			if afterWarns > beforeWarns {
-				sc.TruncateWarnings(int(beforeWarns))
-			}
-			return b.fallbackEvalJSON(input, result)
+		if err := args[l-1].VecEvalJSON(b.ctx, input, bufElse); err != nil {
+			return err
 		}
-		eLse = bufElse
+		results = append(results, bufElse)
 	}
 	result.ReserveJSON(n)
-ROW:
 	for i := 0; i < n; i++ {
-		for j := 0; j < l/2; j++ {
-			if whens[j].IsNull(i) || whensSlice[j][i] == 0 {
-				continue
-			}
-			if thens[j].IsNull(i) {
-				result.AppendNull()
-			} else {
-				result.AppendJSON(thens[j].GetJSON(i))
-			}
-			continue ROW
-		}
-		if eLse != nil {
-			if eLse.IsNull(i) {
-				result.AppendNull()
-			} else {
-				result.AppendJSON(eLse.GetJSON(i))
-			}
-		} else {
+		j := clauses[i]
+		if j < 0 || results[j].IsNull(offsets[i]) {
 			result.AppendNull()
+			continue
 		}
+		result.AppendJSON(results[j].GetJSON(offsets[i]))
 	}
 	return nil
 }
@@ -799,51 +700,39 @@ func (b *builtinCaseWhenJSONSig) vectorized() bool {
 	return true
 }
 
-func (b *builtinIfNullIntSig) fallbackEvalInt(input *chunk.Chunk, result *chunk.Column) error {
-	n := input.NumRows()
-	x := result.Int64s()
-	for i := 0; i < n; i++ {
-		res, isNull, err := b.evalInt(input.GetRow(i))
-		if err != nil {
-			return err
-		}
-		result.SetNull(i, isNull)
-		if isNull {
-			continue
-		}
-
-		x[i] = res
-
-	}
-	return nil
-}
-
 func (b *builtinIfNullIntSig) vecEvalInt(input *chunk.Chunk, result *chunk.Column) error {
 	n := input.NumRows()
 	if err := b.args[0].VecEvalInt(b.ctx, input, result); err != nil {
 		return err
 	}
-	buf1, err := b.bufAllocator.get(types.ETInt, n)
+	buf0 := result
+	// The second argument is evaluated only on the rows whose first argument is NULL.
+	nullRows := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		if buf0.IsNull(i) {
+			nullRows = append(nullRows, i)
+		}
+	}
+	buf1, err := b.bufAllocator.get(types.ETInt, len(nullRows))
 	if err != nil {
 		return err
 	}
 	defer b.bufAllocator.put(buf1)
-	sc := b.ctx.GetSessionVars().StmtCtx
-	beforeWarns := sc.WarningCount()
-	err = b.args[1].VecEvalInt(b.ctx, input, buf1)
-	afterWarns := sc.WarningCount()
-	if err != nil || afterWarns > beforeWarns {
-		if afterWarns > beforeWarns {
-			sc.TruncateWarnings(int(beforeWarns))
+	if len(nullRows) > 0 {
+		sel := newVecSelection(input)
+		sel.selectRows(nullRows)
+		err = b.args[1].VecEvalInt(b.ctx, input, buf1)
+		sel.restore()
+		if err != nil {
+			return err
 		}
-		return b.fallbackEvalInt(input, result)
 	}
 	arg0 := result.Int64s()
 	arg1 := buf1.Int64s()
-	for i := 0; i < n; i++ {
-		if result.IsNull(i) && !buf1.IsNull(i) {
+	for k, i := range nullRows {
+		if !buf1.IsNull(k) {
 			result.SetNull(i, false)
-			arg0[i] = arg1[i]
+			arg0[i] = arg1[k]
 		}
 	}
 	return nil
@@ -853,51 +742,39 @@ func (b *builtinIfNullIntSig) vectorized() bool {
 	return true
 }
 
-func (b *builtinIfNullRealSig) fallbackEvalReal(input *chunk.Chunk, result *chunk.Column) error {
-	n := input.NumRows()
-	x := result.Float64s()
-	for i := 0; i < n; i++ {
-		res, isNull, err := b.evalReal(input.GetRow(i))
-		if err != nil {
-			return err
-		}
-		result.SetNull(i, isNull)
-		if isNull {
-			continue
-		}
-
-		x[i] = res
-
-	}
-	return nil
-}
-
 func (b *builtinIfNullRealSig) vecEvalReal(input *chunk.Chunk, result *chunk.Column) error {
 	n := input.NumRows()
 	if err := b.args[0].VecEvalReal(b.ctx, input, result); err != nil {
 		return err
 	}
-	buf1, err := b.bufAllocator.get(types.ETReal, n)
+	buf0 := result
+	// The second argument is evaluated only on the rows whose first argument is NULL.
+	nullRows := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		if buf0.IsNull(i) {
+			nullRows = append(nullRows, i)
+		}
+	}
+	buf1, err := b.bufAllocator.get(types.ETReal, len(nullRows))
 	if err != nil {
 		return err
 	}
 	defer b.bufAllocator.put(buf1)
-	sc := b.ctx.GetSessionVars().StmtCtx
-	beforeWarns := sc.WarningCount()
-	err = b.args[1].VecEvalReal(b.ctx, input, buf1)
-	afterWarns := sc.WarningCount()
-	if err != nil || afterWarns > beforeWarns {
-		if afterWarns > beforeWarns {
-			sc.TruncateWarnings(int(beforeWarns))
+	if len(nullRows) > 0 {
+		sel := newVecSelection(input)
+		sel.selectRows(nullRows)
+		err = b.args[1].VecEvalReal(b.ctx, input, buf1)
+		sel.restore()
+		if err != nil {
+			return err
 		}
-		return b.fallbackEvalReal(input, result)
 	}
 	arg0 := result.Float64s()
 	arg1 := buf1.Float64s()
-	for i := 0; i < n; i++ {
-		if result.IsNull(i) && !buf1.IsNull(i) {
+	for k, i := range nullRows {
+		if !buf1.IsNull(k) {
 			result.SetNull(i, false)
-			arg0[i] = arg1[i]
+			arg0[i] = arg1[k]
 		}
 	}
 	return nil
@@ -907,51 +784,39 @@ func (b *builtinIfNullRealSig) vectorized() bool {
 	return true
 }
 
-func (b *builtinIfNullDecimalSig) fallbackEvalDecimal(input *chunk.Chunk, result *chunk.Column) error {
-	n := input.NumRows()
-	x := result.Decimals()
-	for i := 0; i < n; i++ {
-		res, isNull, err := b.evalDecimal(input.GetRow(i))
-		if err != nil {
-			return err
-		}
-		result.SetNull(i, isNull)
-		if isNull {
-			continue
-		}
-
-		x[i] = *res
-
-	}
-	return nil
-}
-
 func (b *builtinIfNullDecimalSig) vecEvalDecimal(input *chunk.Chunk, result *chunk.Column) error {
 	n := input.NumRows()
 	if err := b.args[0].VecEvalDecimal(b.ctx, input, result); err != nil {
 		return err
 	}
-	buf1, err := b.bufAllocator.get(types.ETDecimal, n)
+	buf0 := result
+	// The second argument is evaluated only on the rows whose first argument is NULL.
+	nullRows := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		if buf0.IsNull(i) {
+			nullRows = append(nullRows, i)
+		}
+	}
+	buf1, err := b.bufAllocator.get(types.ETDecimal, len(nullRows))
 	if err != nil {
 		return err
 	}
 	defer b.bufAllocator.put(buf1)
-	sc := b.ctx.GetSessionVars().StmtCtx
-	beforeWarns := sc.WarningCount()
-	err = b.args[1].VecEvalDecimal(b.ctx, input, buf1)
-	afterWarns := sc.WarningCount()
-	if err != nil || afterWarns > beforeWarns {
-		if afterWarns > beforeWarns {
-			sc.TruncateWarnings(int(beforeWarns))
+	if len(nullRows) > 0 {
+		sel := newVecSelection(input)
+		sel.selectRows(nullRows)
+		err = b.args[1].VecEvalDecimal(b.ctx, input, buf1)
+		sel.restore()
+		if err != nil {
+			return err
 		}
-		return b.fallbackEvalDecimal(input, result)
 	}
 	arg0 := result.Decimals()
 	arg1 := buf1.Decimals()
-	for i := 0; i < n; i++ {
-		if result.IsNull(i) && !buf1.IsNull(i) {
+	for k, i := range nullRows {
+		if !buf1.IsNull(k) {
 			result.SetNull(i, false)
-			arg0[i] = arg1[i]
+			arg0[i] = arg1[k]
 		}
 	}
 	return nil
@@ -961,23 +826,6 @@ func (b *builtinIfNullDecimalSig) vectorized() bool {
 	return true
 }
 
-func (b *builtinIfNullStringSig) fallbackEvalString(input *chunk.Chunk, result *chunk.Column) error {
-	n := input.NumRows()
-	result.ReserveString(n)
-	for i := 0; i < n; i++ {
-		res, isNull, err := b.evalString(input.GetRow(i))
-		if err != nil {
-			return err
-		}
-		if isNull {
-			result.AppendNull()
-			continue
-		}
-		result.AppendString(res)
-	}
-	return nil
-}
-
 func (b *builtinIfNullStringSig) vecEvalString(input *chunk.Chunk, result *chunk.Column) error {
 	n := input.NumRows()
 	buf0, err := b.bufAllocator.get(types.ETString, n)
@@ -988,31 +836,40 @@ func (b *builtinIfNullStringSig) vecEvalString(input *chunk.Chunk, result *chunk
 	if err := b.args[0].VecEvalString(b.ctx, input, buf0); err != nil {
 		return err
 	}
-	buf1, err := b.bufAllocator.get(types.ETString, n)
+	// The second argument is evaluated only on the rows whose first argument is NULL.
+	nullRows := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		if buf0.IsNull(i) {
+			nullRows = append(nullRows, i)
+		}
+	}
+	buf1, err := b.bufAllocator.get(types.ETString, len(nullRows))
 	if err != nil {
 		return err
 	}
 	defer b.bufAllocator.put(buf1)
-	sc := b.ctx.GetSessionVars().StmtCtx
-	beforeWarns := sc.WarningCount()
-	err = b.args[1].VecEvalString(b.ctx, input, buf1)
-	afterWarns := sc.WarningCount()
-	if err != nil || afterWarns > beforeWarns {
-		if afterWarns > beforeWarns {
-			sc.TruncateWarnings(int(beforeWarns))
+	if len(nullRows) > 0 {
+		sel := newVecSelection(input)
+		sel.selectRows(nullRows)
+		err = b.args[1].VecEvalString(b.ctx, input, buf1)
+		sel.restore()
+		if err != nil {
+			return err
 		}
-		return b.fallbackEvalString(input, result)
 	}
-
 	result.ReserveString(n)
+	k := 0
 	for i := 0; i < n; i++ {
 		if !buf0.IsNull(i) {
 			result.AppendString(buf0.GetString(i))
-		} else if !buf1.IsNull(i) {
-			result.AppendString(buf1.GetString(i))
+			continue
+		}
+		if !buf1.IsNull(k) {
+			result.AppendString(buf1.GetString(k))
 		} else {
 			result.AppendNull()
 		}
+		k++
 	}
 	return nil
 }
@@ -1021,51 +878,39 @@ func (b *builtinIfNullStringSig) vectorized() bool {
 	return true
 }
 
-func (b *builtinIfNullTimeSig) fallbackEvalTime(input *chunk.Chunk, result *chunk.Column) error {
-	n := input.NumRows()
-	x := result.Times()
-	for i := 0; i < n; i++ {
-		res, isNull, err := b.evalTime(input.GetRow(i))
-		if err != nil {
-			return err
-		}
-		result.SetNull(i, isNull)
-		if isNull {
-			continue
-		}
-
-		x[i] = res
-
-	}
-	return nil
-}
-
 func (b *builtinIfNullTimeSig) vecEvalTime(input *chunk.Chunk, result *chunk.Column) error {
 	n := input.NumRows()
 	if err := b.args[0].VecEvalTime(b.ctx, input, result); err != nil {
 		return err
 	}
-	buf1, err := b.bufAllocator.get(types.ETDatetime, n)
+	buf0 := result
+	// The second argument is evaluated only on the rows whose first argument is NULL.
+	nullRows := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		if buf0.IsNull(i) {
+			nullRows = append(nullRows, i)
+		}
+	}
+	buf1, err := b.bufAllocator.get(types.ETDatetime, len(nullRows))
 	if err != nil {
 		return err
 	}
 	defer b.bufAllocator.put(buf1)
-	sc := b.ctx.GetSessionVars().StmtCtx
-	beforeWarns := sc.WarningCount()
-	err = b.args[1].VecEvalTime(b.ctx, input, buf1)
-	afterWarns := sc.WarningCount()
-	if err != nil || afterWarns > beforeWarns {
-		if afterWarns > beforeWarns {
-			sc.TruncateWarnings(int(beforeWarns))
+	if len(nullRows) > 0 {
+		sel := newVecSelection(input)
+		sel.selectRows(nullRows)
+		err = b.args[1].VecEvalTime(b.ctx, input, buf1)
+		sel.restore()
+		if err != nil {
+			return err
 		}
-		return b.fallbackEvalTime(input, result)
 	}
 	arg0 := result.Times()
 	arg1 := buf1.Times()
-	for i := 0; i < n; i++ {
-		if result.IsNull(i) && !buf1.IsNull(i) {
+	for k, i := range nullRows {
+		if !buf1.IsNull(k) {
 			result.SetNull(i, false)
-			arg0[i] = arg1[i]
+			arg0[i] = arg1[k]
 		}
 	}
 	return nil
@@ -1075,51 +920,39 @@ func (b *builtinIfNullTimeSig) vectorized() bool {
 	return true
 }
 
-func (b *builtinIfNullDurationSig) fallbackEvalDuration(input *chunk.Chunk, result *chunk.Column) error {
-	n := input.NumRows()
-	x := result.GoDurations()
-	for i := 0; i < n; i++ {
-		res, isNull, err := b.evalDuration(input.GetRow(i))
-		if err != nil {
-			return err
-		}
-		result.SetNull(i, isNull)
-		if isNull {
-			continue
-		}
-
-		x[i] = res.Duration
-
-	}
-	return nil
-}
-
 func (b *builtinIfNullDurationSig) vecEvalDuration(input *chunk.Chunk, result *chunk.Column) error {
 	n := input.NumRows()
 	if err := b.args[0].VecEvalDuration(b.ctx, input, result); err != nil {
 		return err
 	}
-	buf1, err := b.bufAllocator.get(types.ETDuration, n)
+	buf0 := result
+	// The second argument is evaluated only on the rows whose first argument is NULL.
+	nullRows := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		if buf0.IsNull(i) {
+			nullRows = append(nullRows, i)
+		}
+	}
+	buf1, err := b.bufAllocator.get(types.ETDuration, len(nullRows))
 	if err != nil {
 		return err
 	}
 	defer b.bufAllocator.put(buf1)
-	sc := b.ctx.GetSessionVars().StmtCtx
-	beforeWarns := sc.WarningCount()
-	err = b.args[1].VecEvalDuration(b.ctx, input, buf1)
-	afterWarns := sc.WarningCount()
-	if err != nil || afterWarns > beforeWarns {
-		if afterWarns > beforeWarns {
-			sc.TruncateWarnings(int(beforeWarns))
+	if len(nullRows) > 0 {
+		sel := newVecSelection(input)
+		sel.selectRows(nullRows)
+		err = b.args[1].VecEvalDuration(b.ctx, input, buf1)
+		sel.restore()
+		if err != nil {
+			return err
 		}
-		return b.fallbackEvalDuration(input, result)
 	}
 	arg0 := result.GoDurations()
 	arg1 := buf1.GoDurations()
-	for i := 0; i < n; i++ {
-		if result.IsNull(i) && !buf1.IsNull(i) {
+	for k, i := range nullRows {
+		if !buf1.IsNull(k) {
 			result.SetNull(i, false)
-			arg0[i] = arg1[i]
+			arg0[i] = arg1[k]
 		}
 	}
 	return nil
@@ -1129,23 +962,6 @@ func (b *builtinIfNullDurationSig) vectorized() bool {
 	return true
 }
 
-func (b *builtinIfNullJSONSig) fallbackEvalJSON(input *chunk.Chunk, result *chunk.Column) error {
-	n := input.NumRows()
-	result.ReserveJSON(n)
-	for i := 0; i < n; i++ {
-		res, isNull, err := b.evalJSON(input.GetRow(i))
-		if err != nil {
-			return err
-		}
-		if isNull {
-			result.AppendNull()
-			continue
-		}
-		result.AppendJSON(res)
-	}
-	return nil
-}
-
 func (b *builtinIfNullJSONSig) vecEvalJSON(input *chunk.Chunk, result *chunk.Column) error {
 	n := input.NumRows()
 	buf0, err := b.bufAllocator.get(types.ETJson, n)
@@ -1156,31 +972,40 @@ func (b *builtinIfNullJSONSig) vecEvalJSON(input *chunk.Chunk, result *chunk.Col
 	if err := b.args[0].VecEvalJSON(b.ctx, input, buf0); err != nil {
 		return err
 	}
-	buf1, err := b.bufAllocator.get(types.ETJson, n)
+	// The second argument is evaluated only on the rows whose first argument is NULL.
+	nullRows := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		if buf0.IsNull(i) {
+			nullRows = append(nullRows, i)
+		}
+	}
+	buf1, err := b.bufAllocator.get(types.ETJson, len(nullRows))
 	if err != nil {
 		return err
 	}
 	defer b.bufAllocator.put(buf1)
-	sc := b.ctx.GetSessionVars().StmtCtx
-	beforeWarns := sc.WarningCount()
-	err = b.args[1].VecEvalJSON(b.ctx, input, buf1)
-	afterWarns := sc.WarningCount()
-	if err != nil || afterWarns > beforeWarns {
-		if afterWarns > beforeWarns {
-			sc.TruncateWarnings(int(beforeWarns))
+	if len(nullRows) > 0 {
+		sel := newVecSelection(input)
+		sel.selectRows(nullRows)
+		err = b.args[1].VecEvalJSON(b.ctx, input, buf1)
+		sel.restore()
+		if err != nil {
+			return err
 		}
-		return b.fallbackEvalJSON(input, result)
 	}
-
 	result.ReserveJSON(n)
+	k := 0
 	for i := 0; i < n; i++ {
 		if !buf0.IsNull(i) {
 			result.AppendJSON(buf0.GetJSON(i))
-		} else if !buf1.IsNull(i) {
-			result.AppendJSON(buf1.GetJSON(i))
+			continue
+		}
+		if !buf1.IsNull(k) {
+			result.AppendJSON(buf1.GetJSON(k))
 		} else {
 			result.AppendNull()
 		}
+		k++
 	}
 	return nil
 }
@@ -1189,25 +1014,6 @@ func (b *builtinIfNullJSONSig) vectorized() bool {
 	return true
 }
 
-func (b *builtinIfIntSig) fallbackEvalInt(input *chunk.Chunk, result *chunk.Column) error {
-	n := input.NumRows()
-	x := result.Int64s()
-	for i := 0; i < n; i++ {
-		res, isNull, err := b.evalInt(input.GetRow(i))
-		if err != nil {
-			return err
-		}
-		result.SetNull(i, isNull)
-		if isNull {
-			continue
-		}
-
-		x[i] = res
-
-	}
-	return nil
-}
-
 func (b *builtinIfIntSig) vecEvalInt(input *chunk.Chunk, result *chunk.Column) error {
 	n := input.NumRows()
 	buf0, err := b.bufAllocator.get(types.ETInt, n)
@@ -1218,48 +1024,53 @@ func (b *builtinIfIntSig) vecEvalInt(input *chunk.Chunk, result *chunk.Column) e
 	if err := b.args[0].VecEvalInt(b.ctx, input, buf0); err != nil {
 		return err
 	}
-	sc := b.ctx.GetSessionVars().StmtCtx
-	beforeWarns := sc.WarningCount()
-	err = b.args[1].VecEvalInt(b.ctx, input, result)
-	afterWarns := sc.WarningCount()
-	if err != nil || afterWarns > beforeWarns {
-		if afterWarns > beforeWarns {
-			sc.TruncateWarnings(int(beforeWarns))
+	// The second argument is evaluated only on the rows whose condition is true, and the third one on the others.
+	arg0 := buf0.Int64s()
+	trueRows := make([]int, 0, n)
+	falseRows := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		if !buf0.IsNull(i) && arg0[i] != 0 {
+			trueRows = append(trueRows, i)
+		} else {
+			falseRows = append(falseRows, i)
 		}
-		return b.fallbackEvalInt(input, result)
 	}
-
-	buf2, err := b.bufAllocator.get(types.ETInt, n)
+	buf1, err := b.bufAllocator.get(types.ETInt, len(trueRows))
+	if err != nil {
+		return err
+	}
+	defer b.bufAllocator.put(buf1)
+	buf2, err := b.bufAllocator.get(types.ETInt, len(falseRows))
 	if err != nil {
 		return err
 	}
 	defer b.bufAllocator.put(buf2)
-	err = b.args[2].VecEvalInt(b.ctx, input, buf2)
-	afterWarns = sc.WarningCount()
-	if err != nil || afterWarns > beforeWarns {
-		if afterWarns > beforeWarns {
-			sc.TruncateWarnings(int(beforeWarns))
+	sel := newVecSelection(input)
+	defer sel.restore()
+	if len(trueRows) > 0 {
+		sel.selectRows(trueRows)
+		if err := b.args[1].VecEvalInt(b.ctx, input, buf1); err != nil {
+			return err
 		}
-		return b.fallbackEvalInt(input, result)
 	}
-
-	arg0 := buf0.Int64s()
-	arg2 := buf2.Int64s()
-	rs := result.Int64s()
-	for i := 0; i < n; i++ {
-		arg := arg0[i]
-		isNull0 := buf0.IsNull(i)
-		switch {
-		case isNull0 || arg == 0:
-			if buf2.IsNull(i) {
-				result.SetNull(i, true)
-			} else {
-				result.SetNull(i, false)
-				rs[i] = arg2[i]
-			}
-		case arg != 0:
+	if len(falseRows) > 0 {
+		sel.selectRows(falseRows)
+		if err := b.args[2].VecEvalInt(b.ctx, input, buf2); err != nil {
+			return err
 		}
 	}
+	result.ResizeInt64(n, false)
+	rs := result.Int64s()
+	arg1 := buf1.Int64s()
+	for k, i := range trueRows {
+		result.SetNull(i, buf1.IsNull(k))
+		rs[i] = arg1[k]
+	}
+	arg2 := buf2.Int64s()
+	for k, i := range falseRows {
+		result.SetNull(i, buf2.IsNull(k))
+		rs[i] = arg2[k]
+	}
 	return nil
 }
 
@@ -1267,25 +1078,6 @@ func (b *builtinIfIntSig) vectorized() bool {
 	return true
 }
 
-func (b *builtinIfRealSig) fallbackEvalReal(input *chunk.Chunk, result *chunk.Column) error {
-	n := input.NumRows()
-	x := result.Float64s()
-	for i := 0; i < n; i++ {
-		res, isNull, err := b.evalReal(input.GetRow(i))
-		if err != nil {
-			return err
-		}
-		result.SetNull(i, isNull)
-		if isNull {
-			continue
-		}
-
-		x[i] = res
-
-	}
-	return nil
-}
-
 func (b *builtinIfRealSig) vecEvalReal(input *chunk.Chunk, result *chunk.Column) error {
 	n := input.NumRows()
 	buf0, err := b.bufAllocator.get(types.ETInt, n)
@@ -1296,48 +1088,53 @@ func (b *builtinIfRealSig) vecEvalReal(input *chunk.Chunk, result *chunk.Column)
 	if err := b.args[0].VecEvalInt(b.ctx, input, buf0); err != nil {
 		return err
 	}
-	sc := b.ctx.GetSessionVars().StmtCtx
-	beforeWarns := sc.WarningCount()
-	err = b.args[1].VecEvalReal(b.ctx, input, result)
-	afterWarns := sc.WarningCount()
-	if err != nil || afterWarns > beforeWarns {
-		if afterWarns > beforeWarns {
-			sc.TruncateWarnings(int(beforeWarns))
+	// The second argument is evaluated only on the rows whose condition is true, and the third one on the others.
+	arg0 := buf0.Int64s()
+	trueRows := make([]int, 0, n)
+	falseRows := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		if !buf0.IsNull(i) && arg0[i] != 0 {
+			trueRows = append(trueRows, i)
+		} else {
+			falseRows = append(falseRows, i)
 		}
-		return b.fallbackEvalReal(input, result)
 	}
-
-	buf2, err := b.bufAllocator.get(types.ETReal, n)
+	buf1, err := b.bufAllocator.get(types.ETReal, len(trueRows))
+	if err != nil {
+		return err
+	}
+	defer b.bufAllocator.put(buf1)
+	buf2, err := b.bufAllocator.get(types.ETReal, len(falseRows))
 	if err != nil {
 		return err
 	}
 	defer b.bufAllocator.put(buf2)
-	err = b.args[2].VecEvalReal(b.ctx, input, buf2)
-	afterWarns = sc.WarningCount()
-	if err != nil || afterWarns > beforeWarns {
-		if afterWarns > beforeWarns {
-			sc.TruncateWarnings(int(beforeWarns))
+	sel := newVecSelection(input)
+	defer sel.restore()
+	if len(trueRows) > 0 {
+		sel.selectRows(trueRows)
+		if err := b.args[1].VecEvalReal(b.ctx, input, buf1); err != nil {
+			return err
 		}
-		return b.fallbackEvalReal(input, result)
 	}
-
-	arg0 := buf0.Int64s()
-	arg2 := buf2.Float64s()
-	rs := result.Float64s()
-	for i := 0; i < n; i++ {
-		arg := arg0[i]
-		isNull0 := buf0.IsNull(i)
-		switch {
-		case isNull0 || arg == 0:
-			if buf2.IsNull(i) {
-				result.SetNull(i, true)
-			} else {
-				result.SetNull(i, false)
-				rs[i] = arg2[i]
-			}
-		case arg != 0:
+	if len(falseRows) > 0 {
+		sel.selectRows(falseRows)
+		if err := b.args[2].VecEvalReal(b.ctx, input, buf2); err != nil {
+			return err
 		}
 	}
+	result.ResizeFloat64(n, false)
+	rs := result.Float64s()
+	arg1 := buf1.Float64s()
+	for k, i := range trueRows {
+		result.SetNull(i, buf1.IsNull(k))
+		rs[i] = arg1[k]
+	}
+	arg2 := buf2.Float64s()
+	for k, i := range falseRows {
+		result.SetNull(i, buf2.IsNull(k))
+		rs[i] = arg2[k]
+	}
 	return nil
 }
 
@@ -1345,25 +1142,6 @@ func (b *builtinIfRealSig) vectorized() bool {
 	return true
 }
 
-func (b *builtinIfDecimalSig) fallbackEvalDecimal(input *chunk.Chunk, result *chunk.Column) error {
-	n := input.NumRows()
-	x := result.Decimals()
-	for i := 0; i < n; i++ {
-		res, isNull, err := b.evalDecimal(input.GetRow(i))
-		if err != nil {
-			return err
-		}
-		result.SetNull(i, isNull)
-		if isNull {
-			continue
-		}
-
-		x[i] = *res
-
-	}
-	return nil
-}
-
 func (b *builtinIfDecimalSig) vecEvalDecimal(input *chunk.Chunk, result *chunk.Column) error {
 	n := input.NumRows()
 	buf0, err := b.bufAllocator.get(types.ETInt, n)
@@ -1374,48 +1152,53 @@ func (b *builtinIfDecimalSig) vecEvalDecimal(input *chunk.Chunk, result *chunk.C
 	if err := b.args[0].VecEvalInt(b.ctx, input, buf0); err != nil {
 		return err
 	}
-	sc := b.ctx.GetSessionVars().StmtCtx
-	beforeWarns := sc.WarningCount()
-	err = b.args[1].VecEvalDecimal(b.ctx, input, result)
-	afterWarns := sc.WarningCount()
-	if err != nil || afterWarns > beforeWarns {
-		if afterWarns > beforeWarns {
-			sc.TruncateWarnings(int(beforeWarns))
+	// The second argument is evaluated only on the rows whose condition is true, and the third one on the others.
+	arg0 := buf0.Int64s()
+	trueRows := make([]int, 0, n)
+	falseRows := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		if !buf0.IsNull(i) && arg0[i] != 0 {
+			trueRows = append(trueRows, i)
+		} else {
+			falseRows = append(falseRows, i)
 		}
-		return b.fallbackEvalDecimal(input, result)
 	}
-
-	buf2, err := b.bufAllocator.get(types.ETDecimal, n)
+	buf1, err := b.bufAllocator.get(types.ETDecimal, len(trueRows))
+	if err != nil {
+		return err
+	}
+	defer b.bufAllocator.put(buf1)
+	buf2, err := b.bufAllocator.get(types.ETDecimal, len(falseRows))
 	if err != nil {
 		return err
 	}
 	defer b.bufAllocator.put(buf2)
-	err = b.args[2].VecEvalDecimal(b.ctx, input, buf2)
-	afterWarns = sc.WarningCount()
-	if err != nil || afterWarns > beforeWarns {
-		if afterWarns > beforeWarns {
-			sc.TruncateWarnings(int(beforeWarns))
+	sel := newVecSelection(input)
+	defer sel.restore()
+	if len(trueRows) > 0 {
+		sel.selectRows(trueRows)
+		if err := b.args[1].VecEvalDecimal(b.ctx, input, buf1); err != nil {
+			return err
 		}
-		return b.fallbackEvalDecimal(input, result)
 	}
-
-	arg0 := buf0.Int64s()
-	arg2 := buf2.Decimals()
-	rs := result.Decimals()
-	for i := 0; i < n; i++ {
-		arg := arg0[i]
-		isNull0 := buf0.IsNull(i)
-		switch {
-		case isNull0 || arg == 0:
-			if buf2.IsNull(i) {
-				result.SetNull(i, true)
-			} else {
-				result.SetNull(i, false)
-				rs[i] = arg2[i]
-			}
-		case arg != 0:
+	if len(falseRows) > 0 {
+		sel.selectRows(falseRows)
+		if err := b.args[2].VecEvalDecimal(b.ctx, input, buf2); err != nil {
+			return err
 		}
 	}
+	result.ResizeDecimal(n, false)
+	rs := result.Decimals()
+	arg1 := buf1.Decimals()
+	for k, i := range trueRows {
+		result.SetNull(i, buf1.IsNull(k))
+		rs[i] = arg1[k]
+	}
+	arg2 := buf2.Decimals()
+	for k, i := range falseRows {
+		result.SetNull(i, buf2.IsNull(k))
+		rs[i] = arg2[k]
+	}
 	return nil
 }
 
@@ -1423,23 +1206,6 @@ func (b *builtinIfDecimalSig) vectorized() bool {
 	return true
 }
 
-func (b *builtinIfStringSig) fallbackEvalString(input *chunk.Chunk, result *chunk.Column) error {
-	n := input.NumRows()
-	result.ReserveString(n)
-	for i := 0; i < n; i++ {
-		res, isNull, err := b.evalString(input.GetRow(i))
-		if err != nil {
-			return err
-		}
-		if isNull {
-			result.AppendNull()
-			continue
-		}
-		result.AppendString(res)
-	}
-	return nil
-}
-
 func (b *builtinIfStringSig) vecEvalString(input *chunk.Chunk, result *chunk.Column) error {
 	n := input.NumRows()
 	buf0, err := b.bufAllocator.get(types.ETInt, n)
@@ -1450,54 +1216,55 @@ func (b *builtinIfStringSig) vecEvalString(input *chunk.Chunk, result *chunk.Col
 	if err := b.args[0].VecEvalInt(b.ctx, input, buf0); err != nil {
 		return err
 	}
-	sc := b.ctx.GetSessionVars().StmtCtx
-	beforeWarns := sc.WarningCount()
-	buf1, err := b.bufAllocator.get(types.ETString, n)
+	// The second argument is evaluated only on the rows whose condition is true, and the third one on the others.
+	arg0 := buf0.Int64s()
+	trueRows := make([]int, 0, n)
+	falseRows := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		if !buf0.IsNull(i) && arg0[i] != 0 {
+			trueRows = append(trueRows, i)
+		} else {
+			falseRows = append(falseRows, i)
+		}
+	}
+	buf1, err := b.bufAllocator.get(types.ETString, len(trueRows))
 	if err != nil {
 		return err
 	}
 	defer b.bufAllocator.put(buf1)
-	err = b.args[1].VecEvalString(b.ctx, input, buf1)
-	afterWarns := sc.WarningCount()
-	if err != nil || afterWarns > beforeWarns {
-		if afterWarns > beforeWarns {
-			sc.TruncateWarnings(int(beforeWarns))
-		}
-		return b.fallbackEvalString(input, result)
-	}
-
-	buf2, err := b.bufAllocator.get(types.ETString, n)
+	buf2, err := b.bufAllocator.get(types.ETString, len(falseRows))
 	if err != nil {
 		return err
 	}
 	defer b.bufAllocator.put(buf2)
-	err = b.args[2].VecEvalString(b.ctx, input, buf2)
-	afterWarns = sc.WarningCount()
-	if err != nil || afterWarns > beforeWarns {
-		if afterWarns > beforeWarns {
-			sc.TruncateWarnings(int(beforeWarns))
+	sel := newVecSelection(input)
+	defer sel.restore()
+	if len(trueRows) > 0 {
+		sel.selectRows(trueRows)
+		if err := b.args[1].VecEvalString(b.ctx, input, buf1); err != nil {
+			return err
+		}
+	}
+	if len(falseRows) > 0 {
+		sel.selectRows(falseRows)
+		if err := b.args[2].VecEvalString(b.ctx, input, buf2); err != nil {
+			return err
 		}
-		return b.fallbackEvalString(input, result)
 	}
-
 	result.ReserveString(n)
-	arg0 := buf0.Int64s()
+	t, f := 0, 0
 	for i := 0; i < n; i++ {
-		arg := arg0[i]
-		isNull0 := buf0.IsNull(i)
-		switch {
-		case isNull0 || arg == 0:
-			if buf2.IsNull(i) {
-				result.AppendNull()
-			} else {
-				result.AppendString(buf2.GetString(i))
-			}
-		case arg != 0:
-			if buf1.IsNull(i) {
-				result.AppendNull()
-			} else {
-				result.AppendString(buf1.GetString(i))
-			}
+		buf, k := buf2, f
+		if t < len(trueRows) && trueRows[t] == i {
+			buf, k = buf1, t
+			t++
+		} else {
+			f++
+		}
+		if buf.IsNull(k) {
+			result.AppendNull()
+		} else {
+			result.AppendString(buf.GetString(k))
 		}
 	}
 	return nil
@@ -1507,25 +1274,6 @@ func (b *builtinIfStringSig) vectorized() bool {
 	return true
 }
 
-func (b *builtinIfTimeSig) fallbackEvalTime(input *chunk.Chunk, result *chunk.Column) error {
-	n := input.NumRows()
-	x := result.Times()
-	for i := 0; i < n; i++ {
-		res, isNull, err := b.evalTime(input.GetRow(i))
-		if err != nil {
-			return err
-		}
-		result.SetNull(i, isNull)
-		if isNull {
-			continue
-		}
-
-		x[i] = res
-
-	}
-	return nil
-}
-
 func (b *builtinIfTimeSig) vecEvalTime(input *chunk.Chunk, result *chunk.Column) error {
 	n := input.NumRows()
 	buf0, err := b.bufAllocator.get(types.ETInt, n)
@@ -1536,48 +1284,53 @@ func (b *builtinIfTimeSig) vecEvalTime(input *chunk.Chunk, result *chunk.Column)
 	if err := b.args[0].VecEvalInt(b.ctx, input, buf0); err != nil {
 		return err
 	}
-	sc := b.ctx.GetSessionVars().StmtCtx
-	beforeWarns := sc.WarningCount()
-	err = b.args[1].VecEvalTime(b.ctx, input, result)
-	afterWarns := sc.WarningCount()
-	if err != nil || afterWarns > beforeWarns {
-		if afterWarns > beforeWarns {
-			sc.TruncateWarnings(int(beforeWarns))
+	// The second argument is evaluated only on the rows whose condition is true, and the third one on the others.
+	arg0 := buf0.Int64s()
+	trueRows := make([]int, 0, n)
+	falseRows := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		if !buf0.IsNull(i) && arg0[i] != 0 {
+			trueRows = append(trueRows, i)
+		} else {
+			falseRows = append(falseRows, i)
 		}
-		return b.fallbackEvalTime(input, result)
 	}
-
-	buf2, err := b.bufAllocator.get(types.ETDatetime, n)
+	buf1, err := b.bufAllocator.get(types.ETDatetime, len(trueRows))
+	if err != nil {
+		return err
+	}
+	defer b.bufAllocator.put(buf1)
+	buf2, err := b.bufAllocator.get(types.ETDatetime, len(falseRows))
 	if err != nil {
 		return err
 	}
 	defer b.bufAllocator.put(buf2)
-	err = b.args[2].VecEvalTime(b.ctx, input, buf2)
-	afterWarns = sc.WarningCount()
-	if err != nil || afterWarns > beforeWarns {
-		if afterWarns > beforeWarns {
-			sc.TruncateWarnings(int(beforeWarns))
+	sel := newVecSelection(input)
+	defer sel.restore()
+	if len(trueRows) > 0 {
+		sel.selectRows(trueRows)
+		if err := b.args[1].VecEvalTime(b.ctx, input, buf1); err != nil {
+			return err
 		}
-		return b.fallbackEvalTime(input, result)
 	}
-
-	arg0 := buf0.Int64s()
-	arg2 := buf2.Times()
-	rs := result.Times()
-	for i := 0; i < n; i++ {
-		arg := arg0[i]
-		isNull0 := buf0.IsNull(i)
-		switch {
-		case isNull0 || arg == 0:
-			if buf2.IsNull(i) {
-				result.SetNull(i, true)
-			} else {
-				result.SetNull(i, false)
-				rs[i] = arg2[i]
-			}
-		case arg != 0:
+	if len(falseRows) > 0 {
+		sel.selectRows(falseRows)
+		if err := b.args[2].VecEvalTime(b.ctx, input, buf2); err != nil {
+			return err
 		}
 	}
+	result.ResizeTime(n, false)
+	rs := result.Times()
+	arg1 := buf1.Times()
+	for k, i := range trueRows {
+		result.SetNull(i, buf1.IsNull(k))
+		rs[i] = arg1[k]
+	}
+	arg2 := buf2.Times()
+	for k, i := range falseRows {
+		result.SetNull(i, buf2.IsNull(k))
+		rs[i] = arg2[k]
+	}
 	return nil
 }
 
@@ -1585,25 +1338,6 @@ func (b *builtinIfTimeSig) vectorized() bool {
 	return true
 }
 
-func (b *builtinIfDurationSig) fallbackEvalDuration(input *chunk.Chunk, result *chunk.Column) error {
-	n := input.NumRows()
-	x := result.GoDurations()
-	for i := 0; i < n; i++ {
-		res, isNull, err := b.evalDuration(input.GetRow(i))
-		if err != nil {
-			return err
-		}
-		result.SetNull(i, isNull)
-		if isNull {
-			continue
-		}
-
-		x[i] = res.Duration
-
-	}
-	return nil
-}
-
 func (b *builtinIfDurationSig) vecEvalDuration(input *chunk.Chunk, result *chunk.Column) error {
 	n := input.NumRows()
 	buf0, err := b.bufAllocator.get(types.ETInt, n)
@@ -1614,48 +1348,53 @@ func (b *builtinIfDurationSig) vecEvalDuration(input *chunk.Chunk, result *chunk
 	if err := b.args[0].VecEvalInt(b.ctx, input, buf0); err != nil {
 		return err
 	}
-	sc := b.ctx.GetSessionVars().StmtCtx
-	beforeWarns := sc.WarningCount()
-	err = b.args[1].VecEvalDuration(b.ctx, input, result)
-	afterWarns := sc.WarningCount()
-	if err != nil || afterWarns > beforeWarns {
-		if afterWarns > beforeWarns {
-			sc.TruncateWarnings(int(beforeWarns))
+	// The second argument is evaluated only on the rows whose condition is true, and the third one on the others.
+	arg0 := buf0.Int64s()
+	trueRows := make([]int, 0, n)
+	falseRows := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		if !buf0.IsNull(i) && arg0[i] != 0 {
+			trueRows = append(trueRows, i)
+		} else {
+			falseRows = append(falseRows, i)
 		}
-		return b.fallbackEvalDuration(input, result)
 	}
-
-	buf2, err := b.bufAllocator.get(types.ETDuration, n)
+	buf1, err := b.bufAllocator.get(types.ETDuration, len(trueRows))
+	if err != nil {
+		return err
+	}
+	defer b.bufAllocator.put(buf1)
+	buf2, err := b.bufAllocator.get(types.ETDuration, len(falseRows))
 	if err != nil {
 		return err
 	}
 	defer b.bufAllocator.put(buf2)
-	err = b.args[2].VecEvalDuration(b.ctx, input, buf2)
-	afterWarns = sc.WarningCount()
-	if err != nil || afterWarns > beforeWarns {
-		if afterWarns > beforeWarns {
-			sc.TruncateWarnings(int(beforeWarns))
+	sel := newVecSelection(input)
+	defer sel.restore()
+	if len(trueRows) > 0 {
+		sel.selectRows(trueRows)
+		if err := b.args[1].VecEvalDuration(b.ctx, input, buf1); err != nil {
+			return err
 		}
-		return b.fallbackEvalDuration(input, result)
 	}
-
-	arg0 := buf0.Int64s()
-	arg2 := buf2.GoDurations()
-	rs := result.GoDurations()
-	for i := 0; i < n; i++ {
-		arg := arg0[i]
-		isNull0 := buf0.IsNull(i)
-		switch {
-		case isNull0 || arg == 0:
-			if buf2.IsNull(i) {
-				result.SetNull(i, true)
-			} else {
-				result.SetNull(i, false)
-				rs[i] = arg2[i]
-			}
-		case arg != 0:
+	if len(falseRows) > 0 {
+		sel.selectRows(falseRows)
+		if err := b.args[2].VecEvalDuration(b.ctx, input, buf2); err != nil {
+			return err
 		}
 	}
+	result.ResizeGoDuration(n, false)
+	rs := result.GoDurations()
+	arg1 := buf1.GoDurations()
+	for k, i := range trueRows {
+		result.SetNull(i, buf1.IsNull(k))
+		rs[i] = arg1[k]
+	}
+	arg2 := buf2.GoDurations()
+	for k, i := range falseRows {
+		result.SetNull(i, buf2.IsNull(k))
+		rs[i] = arg2[k]
+	}
 	return nil
 }
 
@@ -1663,23 +1402,6 @@ func (b *builtinIfDurationSig) vectorized() bool {
 	return true
 }
 
-func (b *builtinIfJSONSig) fallbackEvalJSON(input *chunk.Chunk, result *chunk.Column) error {
-	n := input.NumRows()
-	result.ReserveJSON(n)
-	for i := 0; i < n; i++ {
-		res, isNull, err := b.evalJSON(input.GetRow(i))
-		if err != nil {
-			return err
-		}
-		if isNull {
-			result.AppendNull()
-			continue
-		}
-		result.AppendJSON(res)
-	}
-	return nil
-}
-
 func (b *builtinIfJSONSig) vecEvalJSON(input *chunk.Chunk, result *chunk.Column) error {
 	n := input.NumRows()
 	buf0, err := b.bufAllocator.get(types.ETInt, n)
@@ -1690,54 +1412,55 @@ func (b *builtinIfJSONSig) vecEvalJSON(input *chunk.Chunk, result *chunk.Column)
 	if err := b.args[0].VecEvalInt(b.ctx, input, buf0); err != nil {
 		return err
 	}
-	sc := b.ctx.GetSessionVars().StmtCtx
-	beforeWarns := sc.WarningCount()
-	buf1, err := b.bufAllocator.get(types.ETJson, n)
+	// The second argument is evaluated only on the rows whose condition is true, and the third one on the others.
+	arg0 := buf0.Int64s()
+	trueRows := make([]int, 0, n)
+	falseRows := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		if !buf0.IsNull(i) && arg0[i] != 0 {
+			trueRows = append(trueRows, i)
+		} else {
+			falseRows = append(falseRows, i)
+		}
+	}
+	buf1, err := b.bufAllocator.get(types.ETJson, len(trueRows))
 	if err != nil {
 		return err
 	}
 	defer b.bufAllocator.put(buf1)
-	err = b.args[1].VecEvalJSON(b.ctx, input, buf1)
-	afterWarns := sc.WarningCount()
-	if err != nil || afterWarns > beforeWarns {
-		if afterWarns > beforeWarns {
-			sc.TruncateWarnings(int(beforeWarns))
-		}
-		return b.fallbackEvalJSON(input, result)
-	}
-
-	buf2, err := b.bufAllocator.get(types.ETJson, n)
+	buf2, err := b.bufAllocator.get(types.ETJson, len(falseRows))
 	if err != nil {
 		return err
 	}
 	defer b.bufAllocator.put(buf2)
-	err = b.args[2].VecEvalJSON(b.ctx, input, buf2)
-	afterWarns = sc.WarningCount()
-	if err != nil || afterWarns > beforeWarns {
-		if afterWarns > beforeWarns {
-			sc.TruncateWarnings(int(beforeWarns))
+	sel := newVecSelection(input)
+	defer sel.restore()
+	if len(trueRows) > 0 {
+		sel.selectRows(trueRows)
+		if err := b.args[1].VecEvalJSON(b.ctx, input, buf1); err != nil {
+			return err
+		}
+	}
+	if len(falseRows) > 0 {
+		sel.selectRows(falseRows)
+		if err := b.args[2].VecEvalJSON(b.ctx, input, buf2); err != nil {
+			return err
 		}
-		return b.fallbackEvalJSON(input, result)
 	}
-
 	result.ReserveJSON(n)
-	arg0 := buf0.Int64s()
+	t, f := 0, 0
 	for i := 0; i < n; i++ {
-		arg := arg0[i]
-		isNull0 := buf0.IsNull(i)
-		switch {
-		case isNull0 || arg == 0:
-			if buf2.IsNull(i) {
-				result.AppendNull()
-			} else {
-				result.AppendJSON(buf2.GetJSON(i))
-			}
-		case arg != 0:
-			if buf1.IsNull(i) {
-				result.AppendNull()
-			} else {
-				result.AppendJSON(buf1.GetJSON(i))
-			}
+		buf, k := buf2, f
+		if t < len(trueRows) && trueRows[t] == i {
+			buf, k = buf1, t
+			t++
+		} else {
+			f++
+		}
+		if buf.IsNull(k) {
+			result.AppendNull()
+		} else {
+			result.AppendJSON(buf.GetJSON(k))
 		}
 	}
 	return nil
@@ -821,6 +821,14 @@ func (b *builtinJSONExtractSig) vecEvalJSON(input *chunk.Chunk, result *chunk.Co
 	}
 
 	result.ReserveJSON(nr)
+	sc := b.ctx.GetSessionVars().StmtCtx
+	constPaths := nr > 0
+	for _, arg := range pathArgs {
+		constPaths = constPaths && arg.ConstItem(sc)
+	}
+	if constPaths {
+		return vecExtractConstPaths(jsonBuf, pathBuffers, nr, result)
+	}
 	for i := 0; i < nr; i++ {
 		if jsonBuf.IsNull(i) {
 			result.AppendNull()
@@ -855,6 +863,45 @@ func (b *builtinJSONExtractSig) vecEvalJSON(input *chunk.Chunk, result *chunk.Co
 	return nil
 }
 
+// vecExtractConstPaths extracts the constant paths from the column of JSONs, the paths are parsed only once and
+// extracted by the vectorized kernel of json.PathExtractor.
+func vecExtractConstPaths(jsonBuf *chunk.Column, pathBuffers []*chunk.Column, nr int, result *chunk.Column) error {
+	pathExprs := make([]json.PathExpression, len(pathBuffers))
+	for k, pathBuf := range pathBuffers {
+		if pathBuf.IsNull(0) {
+			for i := 0; i < nr; i++ {
+				result.AppendNull()
+			}
+			return nil
+		}
+		var err error
+		if pathExprs[k], err = json.ParseJSONPathExpr(pathBuf.GetString(0)); err != nil {
+			return err
+		}
+	}
+	bjs := make([]json.BinaryJSON, 0, nr)
+	for i := 0; i < nr; i++ {
+		if !jsonBuf.IsNull(i) {
+			bjs = append(bjs, jsonBuf.GetJSON(i))
+		}
+	}
+	rets, found := json.NewPathExtractor(pathExprs).ExtractColumn(bjs, make([]json.BinaryJSON, 0, len(bjs)), make([]bool, 0, len(bjs)))
+	j := 0
+	for i := 0; i < nr; i++ {
+		if jsonBuf.IsNull(i) {
+			result.AppendNull()
+			continue
+		}
+		if found[j] {
+			result.AppendJSON(rets[j])
+		} else {
+			result.AppendNull()
+		}
+		j++
+	}
+	return nil
+}
+
 func (b *builtinJSONRemoveSig) vectorized() bool {
 	return true
 }
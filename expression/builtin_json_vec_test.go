@@ -18,6 +18,7 @@ import (
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/mysql"
 	"github.com/pingcap/tidb/types"
 )
 
@@ -55,6 +56,12 @@ var vecBuiltinJSONCases = map[string][]vecExprBenchCase{
 	ast.JSONExtract: {
 		{retEvalType: types.ETJson, childrenTypes: []types.EvalType{types.ETJson, types.ETString}, geners: []dataGenerator{nil, &constStrGener{"$.key"}}},
 		{retEvalType: types.ETJson, childrenTypes: []types.EvalType{types.ETJson, types.ETString, types.ETString}, geners: []dataGenerator{nil, &constStrGener{"$.key"}, &constStrGener{"$[0]"}}},
+		{retEvalType: types.ETJson, childrenTypes: []types.EvalType{types.ETJson, types.ETString},
+			constants: []*Constant{nil, {Value: types.NewStringDatum("$.key"), RetType: types.NewFieldType(mysql.TypeVarString)}}},
+		{retEvalType: types.ETJson, childrenTypes: []types.EvalType{types.ETJson, types.ETString, types.ETString},
+			constants: []*Constant{nil, {Value: types.NewStringDatum("$[*]"), RetType: types.NewFieldType(mysql.TypeVarString)}, {Value: types.NewStringDatum("$**.key"), RetType: types.NewFieldType(mysql.TypeVarString)}}},
+		{retEvalType: types.ETJson, childrenTypes: []types.EvalType{types.ETJson, types.ETString},
+			constants: []*Constant{nil, {Value: types.NewDatum(nil), RetType: types.NewFieldType(mysql.TypeVarString)}}},
 	},
 	ast.JSONLength: {
 		{retEvalType: types.ETInt, childrenTypes: []types.EvalType{types.ETJson}},
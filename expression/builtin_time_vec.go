@@ -29,6 +29,34 @@ import (
 	"github.com/tikv/client-go/v2/oracle"
 )
 
+// dateFormatLayoutCache keeps the last compiled layout of DATE_FORMAT, since the layout is
+// usually the same for all the rows.
+type dateFormatLayoutCache struct {
+	layout string
+	result *types.DateFormatLayout
+}
+
+func (c *dateFormatLayoutCache) get(layout string) *types.DateFormatLayout {
+	if c.result == nil || c.layout != layout {
+		c.layout, c.result = layout, types.CompileDateFormat(layout)
+	}
+	return c.result
+}
+
+// strToDateFormatCache keeps the last compiled format of STR_TO_DATE, since the format is
+// usually the same for all the rows.
+type strToDateFormatCache struct {
+	format string
+	result *types.StrToDateFormat
+}
+
+func (c *strToDateFormatCache) get(format string) *types.StrToDateFormat {
+	if c.result == nil || c.format != format {
+		c.format, c.result = format, types.CompileStrToDateFormat(format)
+	}
+	return c.result
+}
+
 func (b *builtinMonthSig) vecEvalInt(input *chunk.Chunk, result *chunk.Column) error {
 	n := input.NumRows()
 	buf, err := b.bufAllocator.get(types.ETDatetime, n)
@@ -162,6 +190,7 @@ func (b *builtinFromUnixTime2ArgSig) vecEvalString(input *chunk.Chunk, result *c
 	result.ReserveString(n)
 	ds := buf1.Decimals()
 	fsp := int8(b.tp.Decimal)
+	var layouts dateFormatLayoutCache
 	for i := 0; i < n; i++ {
 		if buf1.IsNull(i) || buf2.IsNull(i) {
 			result.AppendNull()
@@ -175,7 +204,7 @@ func (b *builtinFromUnixTime2ArgSig) vecEvalString(input *chunk.Chunk, result *c
 			result.AppendNull()
 			continue
 		}
-		res, err := t.DateFormat(buf2.GetString(i))
+		res, err := t.DateFormatByLayout(layouts.get(buf2.GetString(i)))
 		if err != nil {
 			return err
 		}
@@ -755,12 +784,13 @@ func (b *builtinStrToDateDateSig) vecEvalTime(input *chunk.Chunk, result *chunk.
 	result.MergeNulls(bufStrings, bufFormats)
 	times := result.Times()
 	sc := b.ctx.GetSessionVars().StmtCtx
+	var formats strToDateFormatCache
 	for i := 0; i < n; i++ {
 		if result.IsNull(i) {
 			continue
 		}
 		var t types.Time
-		succ := t.StrToDate(sc, bufStrings.GetString(i), bufFormats.GetString(i))
+		succ := t.StrToDateByFormat(sc, bufStrings.GetString(i), formats.get(bufFormats.GetString(i)))
 		if !succ {
 			if err := handleInvalidTimeError(b.ctx, types.ErrWrongValue.GenWithStackByArgs(types.DateTimeStr, t.String())); err != nil {
 				return err
@@ -1177,12 +1207,13 @@ func (b *builtinStrToDateDurationSig) vecEvalDuration(input *chunk.Chunk, result
 	d64s := result.GoDurations()
 	sc := b.ctx.GetSessionVars().StmtCtx
 	hasNoZeroDateMode := b.ctx.GetSessionVars().SQLMode.HasNoZeroDateMode()
+	var formats strToDateFormatCache
 	for i := 0; i < n; i++ {
 		if result.IsNull(i) {
 			continue
 		}
 		var t types.Time
-		succ := t.StrToDate(sc, bufStrings.GetString(i), bufFormats.GetString(i))
+		succ := t.StrToDateByFormat(sc, bufStrings.GetString(i), formats.get(bufFormats.GetString(i)))
 		if !succ {
 			if err := handleInvalidTimeError(b.ctx, types.ErrWrongValue.GenWithStackByArgs(types.DateTimeStr, t.String())); err != nil {
 				return err
@@ -1545,13 +1576,14 @@ func (b *builtinStrToDateDatetimeSig) vecEvalTime(input *chunk.Chunk, result *ch
 	sc := b.ctx.GetSessionVars().StmtCtx
 	hasNoZeroDateMode := b.ctx.GetSessionVars().SQLMode.HasNoZeroDateMode()
 	fsp := int8(b.tp.Decimal)
+	var formats strToDateFormatCache
 
 	for i := 0; i < n; i++ {
 		if result.IsNull(i) {
 			continue
 		}
 		var t types.Time
-		succ := t.StrToDate(sc, dateBuf.GetString(i), formatBuf.GetString(i))
+		succ := t.StrToDateByFormat(sc, dateBuf.GetString(i), formats.get(formatBuf.GetString(i)))
 		if !succ {
 			if err = handleInvalidTimeError(b.ctx, types.ErrWrongValue.GenWithStackByArgs(types.DateTimeStr, t.String())); err != nil {
 				return err
@@ -1883,6 +1915,7 @@ func (b *builtinDateFormatSig) vecEvalString(input *chunk.Chunk, result *chunk.C
 
 	result.ReserveString(n)
 
+	var layouts dateFormatLayoutCache
 	for i := range times {
 		t := times[i]
 		if dateBuf.IsNull(i) || formatBuf.IsNull(i) {
@@ -1915,7 +1948,7 @@ func (b *builtinDateFormatSig) vecEvalString(input *chunk.Chunk, result *chunk.C
 			}
 			continue
 		}
-		res, err := t.DateFormat(formatMask)
+		res, err := t.DateFormatByLayout(layouts.get(formatMask))
 		if err != nil {
 			return err
 		}
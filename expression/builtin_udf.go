@@ -0,0 +1,294 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/chunk"
+)
+
+// DefUDFTimeout is the default time limit of a call of a user-defined function.
+const DefUDFTimeout = time.Second
+
+// UDF is a user-defined scalar function. The functions are provided by the UDF plugins and called by their
+// names like the builtin functions.
+type UDF struct {
+	// Name is the name of the function, it can't be the same as any builtin function.
+	Name string
+	// ArgTypes are the types of the arguments, the arguments are cast to these types before the calls.
+	// Only ETInt, ETReal and ETString are supported.
+	ArgTypes []types.EvalType
+	// RetType is the type of the result, only ETInt, ETReal and ETString are supported.
+	RetType types.EvalType
+	// Timeout limits the time of each call, DefUDFTimeout is used if it's 0. A timed out call fails the statement,
+	// but its goroutine can't be stopped and keeps running until VecEval returns, so it's leaked if VecEval ignores
+	// ctx.
+	Timeout time.Duration
+	// MaxResultSize limits the size of the result of each call in bytes, it's unlimited if it's 0.
+	MaxResultSize int64
+	// VecEval evaluates the function on a batch of rows, args[i] holds the i-th argument of the rows and the
+	// results of the numRows rows should be appended to result in order. The arguments are private copies of
+	// the call. ctx is canceled when the call times out, the call should return as soon as possible then.
+	VecEval func(ctx context.Context, args []*chunk.Column, numRows int, result *chunk.Column) error
+}
+
+var udfs = struct {
+	sync.RWMutex
+	m map[string]*UDF
+}{m: make(map[string]*UDF)}
+
+func isSupportedUDFType(et types.EvalType) bool {
+	return et == types.ETInt || et == types.ETReal || et == types.ETString
+}
+
+// RegisterUDF registers a user-defined function.
+func RegisterUDF(udf *UDF) error {
+	name := strings.ToLower(udf.Name)
+	if _, ok := funcs[name]; ok {
+		return errors.Errorf("user-defined function %s conflicts with the builtin function", udf.Name)
+	}
+	if udf.VecEval == nil {
+		return errors.Errorf("user-defined function %s has no implementation", udf.Name)
+	}
+	for _, et := range append([]types.EvalType{udf.RetType}, udf.ArgTypes...) {
+		if !isSupportedUDFType(et) {
+			return errors.Errorf("user-defined function %s uses unsupported type %v", udf.Name, et)
+		}
+	}
+	udfs.Lock()
+	defer udfs.Unlock()
+	if _, ok := udfs.m[name]; ok {
+		return errors.Errorf("user-defined function %s is already registered", udf.Name)
+	}
+	udfs.m[name] = udf
+	return nil
+}
+
+// UnregisterUDF removes a user-defined function, the statements being executed can still call it.
+func UnregisterUDF(name string) {
+	udfs.Lock()
+	delete(udfs.m, strings.ToLower(name))
+	udfs.Unlock()
+}
+
+func getUDF(name string) *UDF {
+	udfs.RLock()
+	defer udfs.RUnlock()
+	return udfs.m[strings.ToLower(name)]
+}
+
+type udfFunctionClass struct {
+	baseFunctionClass
+	udf *UDF
+}
+
+func newUDFFunctionClass(udf *UDF) *udfFunctionClass {
+	return &udfFunctionClass{baseFunctionClass{udf.Name, len(udf.ArgTypes), len(udf.ArgTypes)}, udf}
+}
+
+func (c *udfFunctionClass) getFunction(ctx sessionctx.Context, args []Expression) (builtinFunc, error) {
+	if err := c.verifyArgs(args); err != nil {
+		return nil, err
+	}
+	bf, err := newBaseBuiltinFuncWithTp(ctx, c.funcName, args, c.udf.RetType, c.udf.ArgTypes...)
+	if err != nil {
+		return nil, err
+	}
+	if c.udf.RetType == types.ETString {
+		bf.tp.Flen = mysql.MaxBlobWidth
+	}
+	return &builtinUDFSig{bf, c.udf}, nil
+}
+
+// builtinUDFSig calls a user-defined function. Each call runs in its own goroutine with the time limit and
+// the result size limit of the function, and the panics of the function are returned as errors. Starting the
+// goroutine and the timer is only cheap enough for a batch of rows, so the function is always vectorized.
+type builtinUDFSig struct {
+	baseBuiltinFunc
+	udf *UDF
+}
+
+func (b *builtinUDFSig) Clone() builtinFunc {
+	newSig := &builtinUDFSig{udf: b.udf}
+	newSig.cloneFrom(&b.baseBuiltinFunc)
+	return newSig
+}
+
+func (b *builtinUDFSig) vectorized() bool {
+	return true
+}
+
+// isChildrenVectorized is true even if some arguments can't be vectorized, since vecEval evaluates the arguments by
+// EvalExpr, which falls back to the row-based evaluation for them. So the function is called once per chunk unless
+// its parent can't be vectorized or tidb_enable_vectorized_expression is off.
+func (b *builtinUDFSig) isChildrenVectorized() bool {
+	return true
+}
+
+// call calls the function on the arguments of numRows rows and returns the results. If the call times out, it
+// returns at once and leaves the goroutine running VecEval behind.
+func (b *builtinUDFSig) call(args []*chunk.Column, numRows int) (*chunk.Column, error) {
+	timeout := b.udf.Timeout
+	if timeout <= 0 {
+		timeout = DefUDFTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	// The results are written into a new chunk, since a timed out call may still write them.
+	out := chunk.NewChunkWithCapacity([]*types.FieldType{b.tp}, numRows)
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- errors.Errorf("panic: %v", r)
+			}
+		}()
+		done <- b.udf.VecEval(ctx, args, numRows, out.Column(0))
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+	}
+	if ctx.Err() != nil {
+		return nil, errUDFFailed.GenWithStackByArgs(b.udf.Name, fmt.Sprintf("timeout after %v", timeout))
+	}
+	if err != nil {
+		return nil, errUDFFailed.GenWithStackByArgs(b.udf.Name, err.Error())
+	}
+	if out.NumRows() != numRows {
+		return nil, errUDFFailed.GenWithStackByArgs(b.udf.Name, fmt.Sprintf("%d results are returned for %d rows", out.NumRows(), numRows))
+	}
+	if b.udf.MaxResultSize > 0 && udfResultSize(out.Column(0), b.udf.RetType, numRows) > b.udf.MaxResultSize {
+		return nil, errUDFFailed.GenWithStackByArgs(b.udf.Name, fmt.Sprintf("the result exceeds %d bytes", b.udf.MaxResultSize))
+	}
+	return out.Column(0), nil
+}
+
+// udfResultSize returns the size of the values of a result, each fixed length value takes 8 bytes.
+func udfResultSize(col *chunk.Column, et types.EvalType, numRows int) int64 {
+	if et != types.ETString {
+		return int64(numRows) * 8
+	}
+	var size int64
+	for i := 0; i < numRows; i++ {
+		size += int64(len(col.GetBytes(i)))
+	}
+	return size
+}
+
+func (b *builtinUDFSig) vecEval(input *chunk.Chunk, result *chunk.Column) error {
+	n := input.NumRows()
+	args := make([]*chunk.Column, len(b.args))
+	for i, arg := range b.args {
+		// The arguments are not taken from the bufAllocator, since a timed out call may still read them.
+		buf, err := newBuffer(b.udf.ArgTypes[i], n)
+		if err != nil {
+			return err
+		}
+		if err := EvalExpr(b.ctx, arg, b.udf.ArgTypes[i], input, buf); err != nil {
+			return err
+		}
+		args[i] = buf
+	}
+	out, err := b.call(args, n)
+	if err != nil {
+		return err
+	}
+	out.CopyConstruct(result)
+	return nil
+}
+
+func (b *builtinUDFSig) vecEvalInt(input *chunk.Chunk, result *chunk.Column) error {
+	return b.vecEval(input, result)
+}
+
+func (b *builtinUDFSig) vecEvalReal(input *chunk.Chunk, result *chunk.Column) error {
+	return b.vecEval(input, result)
+}
+
+func (b *builtinUDFSig) vecEvalString(input *chunk.Chunk, result *chunk.Column) error {
+	return b.vecEval(input, result)
+}
+
+// evalRow calls the function on a single row. It starts a goroutine and a timer for the row, so it's only used when
+// the function can't be evaluated by vecEval, see isChildrenVectorized.
+func (b *builtinUDFSig) evalRow(row chunk.Row) (*chunk.Column, error) {
+	args := make([]*chunk.Column, len(b.args))
+	for i, arg := range b.args {
+		buf, err := newBuffer(b.udf.ArgTypes[i], 1)
+		if err != nil {
+			return nil, err
+		}
+		var isNull bool
+		switch b.udf.ArgTypes[i] {
+		case types.ETInt:
+			var val int64
+			if val, isNull, err = arg.EvalInt(b.ctx, row); err == nil && !isNull {
+				buf.AppendInt64(val)
+			}
+		case types.ETReal:
+			var val float64
+			if val, isNull, err = arg.EvalReal(b.ctx, row); err == nil && !isNull {
+				buf.AppendFloat64(val)
+			}
+		default:
+			var val string
+			if val, isNull, err = arg.EvalString(b.ctx, row); err == nil && !isNull {
+				buf.AppendString(val)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if isNull {
+			buf.AppendNull()
+		}
+		args[i] = buf
+	}
+	return b.call(args, 1)
+}
+
+func (b *builtinUDFSig) evalInt(row chunk.Row) (int64, bool, error) {
+	out, err := b.evalRow(row)
+	if err != nil || out.IsNull(0) {
+		return 0, true, err
+	}
+	return out.GetInt64(0), false, nil
+}
+
+func (b *builtinUDFSig) evalReal(row chunk.Row) (float64, bool, error) {
+	out, err := b.evalRow(row)
+	if err != nil || out.IsNull(0) {
+		return 0, true, err
+	}
+	return out.GetFloat64(0), false, nil
+}
+
+func (b *builtinUDFSig) evalString(row chunk.Row) (string, bool, error) {
+	out, err := b.evalRow(row)
+	if err != nil || out.IsNull(0) {
+		return "", true, err
+	}
+	return out.GetString(0), false, nil
+}
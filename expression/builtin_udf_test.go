@@ -0,0 +1,142 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"context"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/chunk"
+)
+
+func (s *testEvaluatorSuite) TestUDF(c *C) {
+	intTp := types.NewFieldType(mysql.TypeLonglong)
+	add := &UDF{
+		Name:     "udf_add",
+		ArgTypes: []types.EvalType{types.ETInt, types.ETInt},
+		RetType:  types.ETInt,
+		VecEval: func(ctx context.Context, args []*chunk.Column, numRows int, result *chunk.Column) error {
+			for i := 0; i < numRows; i++ {
+				if args[0].IsNull(i) || args[1].IsNull(i) {
+					result.AppendNull()
+				} else {
+					result.AppendInt64(args[0].GetInt64(i) + args[1].GetInt64(i))
+				}
+			}
+			return nil
+		},
+	}
+	c.Assert(RegisterUDF(add), IsNil)
+	defer UnregisterUDF(add.Name)
+	c.Assert(RegisterUDF(add), NotNil)
+	c.Assert(RegisterUDF(&UDF{Name: "abs", RetType: types.ETInt, VecEval: add.VecEval}), NotNil)
+	c.Assert(RegisterUDF(&UDF{Name: "udf_json", RetType: types.ETJson, VecEval: add.VecEval}), NotNil)
+
+	col0 := &Column{RetType: intTp, Index: 0}
+	col1 := &Column{RetType: intTp, Index: 1}
+	f, err := NewFunction(s.ctx, "UDF_ADD", intTp, col0, col1)
+	c.Assert(err, IsNil)
+	_, err = NewFunction(s.ctx, "udf_add", intTp, col0)
+	c.Assert(ErrIncorrectParameterCount.Equal(err), IsTrue)
+
+	chk := chunk.NewChunkWithCapacity([]*types.FieldType{intTp, intTp}, 1024)
+	for i := 0; i < 1024; i++ {
+		chk.AppendInt64(0, int64(i))
+		if i%10 == 0 {
+			chk.AppendNull(1)
+		} else {
+			chk.AppendInt64(1, int64(i))
+		}
+	}
+	result := chunk.NewColumn(intTp, 1024)
+	c.Assert(f.VecEvalInt(s.ctx, chk, result), IsNil)
+	for i := 0; i < 1024; i++ {
+		val, isNull, err := f.EvalInt(s.ctx, chk.GetRow(i))
+		c.Assert(err, IsNil)
+		c.Assert(isNull, Equals, i%10 == 0)
+		c.Assert(result.IsNull(i), Equals, isNull)
+		if !isNull {
+			c.Assert(val, Equals, int64(2*i))
+			c.Assert(result.GetInt64(i), Equals, val)
+		}
+	}
+
+	// The function is vectorized even if its arguments aren't.
+	conv, err := NewFunction(s.ctx, ast.Conv, types.NewFieldType(mysql.TypeVarString), col0,
+		&Constant{Value: types.NewIntDatum(10), RetType: intTp}, &Constant{Value: types.NewIntDatum(2), RetType: intTp})
+	c.Assert(err, IsNil)
+	c.Assert(conv.Vectorized(), IsFalse)
+	f, err = NewFunction(s.ctx, "udf_add", intTp, conv, col1)
+	c.Assert(err, IsNil)
+	c.Assert(f.Vectorized(), IsTrue)
+	c.Assert(f.VecEvalInt(s.ctx, chk, result), IsNil)
+	c.Assert(result.IsNull(10), IsTrue)
+	c.Assert(result.GetInt64(5), Equals, int64(101+5))
+
+	// The calls on constants are not folded.
+	f, err = NewFunction(s.ctx, "udf_add", intTp, &Constant{Value: types.NewIntDatum(1), RetType: intTp}, &Constant{Value: types.NewIntDatum(2), RetType: intTp})
+	c.Assert(err, IsNil)
+	_, ok := f.(*ScalarFunction)
+	c.Assert(ok, IsTrue)
+
+	// The failures of the calls are returned as errors.
+	failures := []struct {
+		udf *UDF
+		err string
+	}{
+		{
+			udf: &UDF{Name: "udf_sleep", RetType: types.ETInt, Timeout: 10 * time.Millisecond, VecEval: func(ctx context.Context, args []*chunk.Column, numRows int, result *chunk.Column) error {
+				<-ctx.Done()
+				return ctx.Err()
+			}},
+			err: ".*user-defined function udf_sleep failed: timeout after 10ms",
+		},
+		{
+			udf: &UDF{Name: "udf_panic", RetType: types.ETInt, VecEval: func(ctx context.Context, args []*chunk.Column, numRows int, result *chunk.Column) error {
+				panic("oops")
+			}},
+			err: ".*user-defined function udf_panic failed: panic: oops",
+		},
+		{
+			udf: &UDF{Name: "udf_empty", RetType: types.ETString, VecEval: func(ctx context.Context, args []*chunk.Column, numRows int, result *chunk.Column) error {
+				return nil
+			}},
+			err: ".*user-defined function udf_empty failed: 0 results are returned for 1 rows",
+		},
+		{
+			udf: &UDF{Name: "udf_large", RetType: types.ETString, MaxResultSize: 64, VecEval: func(ctx context.Context, args []*chunk.Column, numRows int, result *chunk.Column) error {
+				result.AppendString(string(make([]byte, 1024)))
+				return nil
+			}},
+			err: ".*user-defined function udf_large failed: the result exceeds 64 bytes",
+		},
+	}
+	for _, failure := range failures {
+		c.Assert(RegisterUDF(failure.udf), IsNil)
+		f, err = NewFunction(s.ctx, failure.udf.Name, intTp)
+		c.Assert(err, IsNil)
+		_, err = f.Eval(chunk.Row{})
+		c.Assert(err, ErrorMatches, failure.err)
+		UnregisterUDF(failure.udf.Name)
+	}
+
+	UnregisterUDF(add.Name)
+	s.ctx.GetSessionVars().CurrentDB = "test"
+	_, err = NewFunction(s.ctx, "udf_add", intTp, col0, col1)
+	c.Assert(err, ErrorMatches, ".*FUNCTION test.udf_add does not exist")
+}
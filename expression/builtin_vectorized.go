@@ -146,3 +146,34 @@ func vecEvalStringByRows(sig builtinFunc, input *chunk.Chunk, result *chunk.Colu
 	}
 	return nil
 }
+
+// vecSelection narrows the rows of a chunk by its selection vector, so the vectorized expressions evaluated
+// on it only compute the selected rows instead of computing all the rows and filtering the results.
+// The selected rows are the logical rows of the chunk, i.e. the indexes in the original selection vector if any.
+type vecSelection struct {
+	input   *chunk.Chunk
+	origSel []int
+	sel     []int
+}
+
+func newVecSelection(input *chunk.Chunk) *vecSelection {
+	return &vecSelection{input: input, origSel: input.Sel(), sel: make([]int, 0, input.NumRows())}
+}
+
+// selectRows makes the chunk only expose the logical rows in rows, the results of the expressions
+// evaluated on it are compacted in the order of rows.
+func (s *vecSelection) selectRows(rows []int) {
+	s.sel = s.sel[:0]
+	for _, row := range rows {
+		if s.origSel != nil {
+			row = s.origSel[row]
+		}
+		s.sel = append(s.sel, row)
+	}
+	s.input.SetSel(s.sel)
+}
+
+// restore restores the original selection vector of the chunk.
+func (s *vecSelection) restore() {
+	s.input.SetSel(s.origSel)
+}
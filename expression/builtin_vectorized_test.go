@@ -787,6 +787,30 @@ func (s *testEvaluatorSuite) TestFloat32ColVec(c *C) {
 	c.Assert(col.VecEvalReal(ctx, chk, result), IsNil)
 }
 
+func (s *testEvaluatorSuite) TestVecSelection(c *C) {
+	ft := types.NewFieldType(mysql.TypeLonglong)
+	chk := chunk.NewChunkWithCapacity([]*types.FieldType{ft}, 10)
+	for i := 0; i < 10; i++ {
+		chk.AppendInt64(0, int64(i))
+	}
+	origSel := []int{1, 3, 5, 7, 9}
+	chk.SetSel(origSel)
+	col := &Column{RetType: ft, Index: 0}
+	ctx := mock.NewContext()
+	result := chunk.NewColumn(ft, 10)
+
+	sel := newVecSelection(chk)
+	// The selected rows are the logical rows of the chunk.
+	sel.selectRows([]int{0, 2, 4})
+	c.Assert(chk.NumRows(), Equals, 3)
+	c.Assert(col.VecEvalInt(ctx, chk, result), IsNil)
+	c.Assert(result.Int64s(), DeepEquals, []int64{1, 5, 9})
+	sel.selectRows([]int{})
+	c.Assert(chk.NumRows(), Equals, 0)
+	sel.restore()
+	c.Assert(chk.Sel(), DeepEquals, origSel)
+}
+
 func BenchmarkFloat32ColRow(b *testing.B) {
 	col, chk, _ := genFloat32Col()
 	ctx := mock.NewContext()
@@ -391,53 +391,32 @@ func VectorizedFilterConsiderNull(ctx sessionctx.Context, filters []Expression,
 		}
 	}
 
-	input := iterator.GetChunk()
-	sel := input.Sel()
-	var err error
+	// When the input.Sel() != nil, only the rows in it are evaluated and the others are not selected.
 	if canVectorized && ctx.GetSessionVars().EnableVectorizedExpression {
-		selected, isNull, err = vectorizedFilter(ctx, filters, iterator, selected, isNull)
-	} else {
-		selected, isNull, err = rowBasedFilter(ctx, filters, iterator, selected, isNull)
+		return vectorizedFilter(ctx, filters, iterator, selected, isNull)
 	}
-	if err != nil || sel == nil {
-		return selected, isNull, err
-	}
-
-	// When the input.Sel() != nil, we need to handle the selected slice and input.Sel()
-	// Get the index which is not appeared in input.Sel() and set the selected[index] = false
-	selectedLength := len(selected)
-	unselected := allocZeroSlice(selectedLength)
-	defer deallocateZeroSlice(unselected)
-	// unselected[i] == 1 means that the i-th row is not selected
-	for i := 0; i < selectedLength; i++ {
-		unselected[i] = 1
-	}
-	for _, ind := range sel {
-		unselected[ind] = 0
-	}
-	for i := 0; i < selectedLength; i++ {
-		if selected[i] && unselected[i] == 1 {
-			selected[i] = false
-		}
-	}
-	return selected, isNull, err
+	return rowBasedFilter(ctx, filters, iterator, selected, isNull)
 }
 
 // rowBasedFilter filters by row.
 func rowBasedFilter(ctx sessionctx.Context, filters []Expression, iterator *chunk.Iterator4Chunk, selected []bool, isNull []bool) ([]bool, []bool, error) {
-	// If input.Sel() != nil, we will call input.SetSel(nil) to clear the sel slice in input chunk.
+	// If input.Sel() != nil, we will call input.SetSel(nil) to clear the sel slice in input chunk,
+	// and only the rows in it are selected before the filters are evaluated.
 	// After the function finished, then we reset the sel in input chunk.
-	// Then the caller will handle the input.sel and selected slices.
 	input := iterator.GetChunk()
-	if input.Sel() != nil {
-		defer input.SetSel(input.Sel())
+	sel := input.Sel()
+	if sel != nil {
+		defer input.SetSel(sel)
 		input.SetSel(nil)
 		iterator = chunk.NewIterator4Chunk(input)
 	}
 
 	selected = selected[:0]
 	for i, numRows := 0, iterator.Len(); i < numRows; i++ {
-		selected = append(selected, true)
+		selected = append(selected, sel == nil)
+	}
+	for _, i := range sel {
+		selected[i] = true
 	}
 	if isNull != nil {
 		isNull = isNull[:0]
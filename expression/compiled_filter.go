@@ -0,0 +1,329 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/chunk"
+)
+
+// filterOpcode is the opcode of the instructions of a compiled filter.
+type filterOpcode byte
+
+const (
+	// opLoadColumn pushes the value of a column of the row.
+	opLoadColumn filterOpcode = iota
+	// opLoadConst pushes a constant.
+	opLoadConst
+	opLT
+	opLE
+	opGT
+	opGE
+	opEQ
+	opNE
+	opPlus
+	opMinus
+	opAnd
+	opOr
+	opNot
+	opIsNull
+	opIsTrue
+	opIsTrueKeepNull
+)
+
+// filterInstr is an instruction of a compiled filter. The operators pop their arguments from the stack
+// and push their results.
+type filterInstr struct {
+	op filterOpcode
+	// colIdx is the column loaded by opLoadColumn.
+	colIdx int
+	// val and isNull are the constant loaded by opLoadConst.
+	val    int64
+	isNull bool
+}
+
+// CompiledFilter is a CNF filter whose predicates are compiled into the programs of a stack based bytecode.
+// The programs evaluate the whole predicate trees on a row in one pass, which avoids materializing the
+// results of every node of the trees in columns like the vectorized evaluation.
+// Only the comparison, arithmetic and logic functions on signed integers are supported now.
+// A CompiledFilter is not safe for concurrent use.
+type CompiledFilter struct {
+	progs [][]filterInstr
+	// vals and nulls are the stack of the programs.
+	vals  []int64
+	nulls []bool
+	cols  []*chunk.Column
+}
+
+// CompileFilter compiles a CNF filter, it returns nil if any of the predicates can't be compiled.
+func CompileFilter(ctx sessionctx.Context, filters []Expression) *CompiledFilter {
+	f := &CompiledFilter{progs: make([][]filterInstr, 0, len(filters))}
+	maxDepth := 0
+	for _, filter := range filters {
+		c := &filterCompiler{ctx: ctx}
+		if !c.compile(filter) {
+			return nil
+		}
+		f.progs = append(f.progs, c.prog)
+		if c.maxDepth > maxDepth {
+			maxDepth = c.maxDepth
+		}
+	}
+	f.vals = make([]int64, 0, maxDepth)
+	f.nulls = make([]bool, 0, maxDepth)
+	return f
+}
+
+type filterCompiler struct {
+	ctx      sessionctx.Context
+	prog     []filterInstr
+	depth    int
+	maxDepth int
+}
+
+func (c *filterCompiler) emit(instr filterInstr, pushed int) {
+	c.prog = append(c.prog, instr)
+	c.depth += pushed
+	if c.depth > c.maxDepth {
+		c.maxDepth = c.depth
+	}
+}
+
+func (c *filterCompiler) compile(expr Expression) bool {
+	tp := expr.GetType()
+	if tp.EvalType() != types.ETInt || mysql.HasUnsignedFlag(tp.Flag) {
+		return false
+	}
+	switch x := expr.(type) {
+	case *Column:
+		switch tp.Tp {
+		case mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLong, mysql.TypeLonglong:
+		default:
+			return false
+		}
+		c.emit(filterInstr{op: opLoadColumn, colIdx: x.Index}, 1)
+	case *Constant:
+		if x.DeferredExpr != nil || x.ParamMarker != nil {
+			return false
+		}
+		val, isNull, err := x.EvalInt(c.ctx, chunk.Row{})
+		if err != nil {
+			return false
+		}
+		c.emit(filterInstr{op: opLoadConst, val: val, isNull: isNull}, 1)
+	case *ScalarFunction:
+		op, ok := filterOpcodeOf(x.Function)
+		if !ok {
+			return false
+		}
+		args := x.GetArgs()
+		for _, arg := range args {
+			if !c.compile(arg) {
+				return false
+			}
+		}
+		c.emit(filterInstr{op: op}, 1-len(args))
+	default:
+		return false
+	}
+	return true
+}
+
+func filterOpcodeOf(f builtinFunc) (filterOpcode, bool) {
+	switch sig := f.(type) {
+	case *builtinLTIntSig:
+		return opLT, true
+	case *builtinLEIntSig:
+		return opLE, true
+	case *builtinGTIntSig:
+		return opGT, true
+	case *builtinGEIntSig:
+		return opGE, true
+	case *builtinEQIntSig:
+		return opEQ, true
+	case *builtinNEIntSig:
+		return opNE, true
+	case *builtinArithmeticPlusIntSig:
+		return opPlus, true
+	case *builtinArithmeticMinusIntSig:
+		return opMinus, true
+	case *builtinLogicAndSig:
+		return opAnd, true
+	case *builtinLogicOrSig:
+		return opOr, true
+	case *builtinUnaryNotIntSig:
+		return opNot, true
+	case *builtinIntIsNullSig:
+		return opIsNull, true
+	case *builtinIntIsTrueSig:
+		if sig.keepNull {
+			return opIsTrueKeepNull, true
+		}
+		return opIsTrue, true
+	}
+	return 0, false
+}
+
+// Filter evaluates the filter on the rows of input like VectorizedFilter, only the rows in input.Sel() are
+// evaluated if it's not nil. It returns false if the compiled programs can't evaluate the chunk, e.g. an
+// arithmetic overflow happens, then the caller should evaluate the filter by VectorizedFilter to get the error.
+func (f *CompiledFilter) Filter(input *chunk.Chunk, selected []bool) ([]bool, bool) {
+	sel := input.Sel()
+	input.SetSel(nil)
+	n := input.NumRows()
+	input.SetSel(sel)
+
+	f.cols = f.cols[:0]
+	for i := 0; i < input.NumCols(); i++ {
+		f.cols = append(f.cols, input.Column(i))
+	}
+	selected = selected[:0]
+	for i := 0; i < n; i++ {
+		selected = append(selected, false)
+	}
+	if sel != nil {
+		for _, i := range sel {
+			passed, ok := f.evalRow(i)
+			if !ok {
+				return selected, false
+			}
+			selected[i] = passed
+		}
+		return selected, true
+	}
+	for i := 0; i < n; i++ {
+		passed, ok := f.evalRow(i)
+		if !ok {
+			return selected, false
+		}
+		selected[i] = passed
+	}
+	return selected, true
+}
+
+// evalRow returns whether the row passes all the predicates.
+func (f *CompiledFilter) evalRow(row int) (passed bool, evaluated bool) {
+	for _, prog := range f.progs {
+		val, isNull, ok := f.run(prog, row)
+		if !ok {
+			return false, false
+		}
+		if isNull || val == 0 {
+			return false, true
+		}
+	}
+	return true, true
+}
+
+func (f *CompiledFilter) run(prog []filterInstr, row int) (val int64, isNull bool, ok bool) {
+	vals, nulls := f.vals[:0], f.nulls[:0]
+	for _, instr := range prog {
+		switch instr.op {
+		case opLoadColumn:
+			col := f.cols[instr.colIdx]
+			if col.IsNull(row) {
+				vals, nulls = append(vals, 0), append(nulls, true)
+			} else {
+				vals, nulls = append(vals, col.GetInt64(row)), append(nulls, false)
+			}
+			continue
+		case opLoadConst:
+			vals, nulls = append(vals, instr.val), append(nulls, instr.isNull)
+			continue
+		case opNot, opIsNull, opIsTrue, opIsTrueKeepNull:
+			top := len(vals) - 1
+			vals[top], nulls[top] = evalUnaryFilterOp(instr.op, vals[top], nulls[top])
+			continue
+		}
+		// The binary operators.
+		top := len(vals) - 2
+		a, b, nullA, nullB := vals[top], vals[top+1], nulls[top], nulls[top+1]
+		vals, nulls = vals[:top+1], nulls[:top+1]
+		var res int64
+		var resNull bool
+		switch instr.op {
+		case opAnd:
+			switch {
+			case (!nullA && a == 0) || (!nullB && b == 0):
+			case nullA || nullB:
+				resNull = true
+			default:
+				res = 1
+			}
+		case opOr:
+			switch {
+			case (!nullA && a != 0) || (!nullB && b != 0):
+				res = 1
+			case nullA || nullB:
+				resNull = true
+			}
+		default:
+			if nullA || nullB {
+				resNull = true
+				break
+			}
+			switch instr.op {
+			case opPlus:
+				res = a + b
+				if (a >= 0) == (b >= 0) && (res >= 0) != (a >= 0) {
+					return 0, false, false
+				}
+			case opMinus:
+				res = a - b
+				if (a >= 0) != (b >= 0) && (res >= 0) != (a >= 0) {
+					return 0, false, false
+				}
+			default:
+				res = boolToInt64(compareFilterOp(instr.op, a, b))
+			}
+		}
+		vals[top], nulls[top] = res, resNull
+	}
+	return vals[0], nulls[0], true
+}
+
+func evalUnaryFilterOp(op filterOpcode, val int64, isNull bool) (int64, bool) {
+	switch op {
+	case opNot:
+		if isNull {
+			return 0, true
+		}
+		return boolToInt64(val == 0), false
+	case opIsNull:
+		return boolToInt64(isNull), false
+	case opIsTrueKeepNull:
+		if isNull {
+			return 0, true
+		}
+	}
+	return boolToInt64(!isNull && val != 0), false
+}
+
+func compareFilterOp(op filterOpcode, a, b int64) bool {
+	switch op {
+	case opLT:
+		return a < b
+	case opLE:
+		return a <= b
+	case opGT:
+		return a > b
+	case opGE:
+		return a >= b
+	case opEQ:
+		return a == b
+	}
+	return a != b
+}
@@ -0,0 +1,96 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"math"
+	"math/rand"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/chunk"
+)
+
+func (s *testEvaluatorSuite) TestCompiledFilter(c *C) {
+	intTp := types.NewFieldType(mysql.TypeLonglong)
+	realTp := types.NewFieldType(mysql.TypeDouble)
+	col0 := &Column{RetType: intTp, Index: 0}
+	col1 := &Column{RetType: intTp, Index: 1}
+	col2 := &Column{RetType: realTp, Index: 2}
+	intConst := func(v int64) *Constant {
+		return &Constant{Value: types.NewIntDatum(v), RetType: intTp}
+	}
+
+	// (col0 + 1 > col1 or col1 is null) and not(col0 - col1 = 3)
+	filters := []Expression{
+		NewFunctionInternal(s.ctx, ast.LogicOr, intTp,
+			NewFunctionInternal(s.ctx, ast.GT, intTp, NewFunctionInternal(s.ctx, ast.Plus, intTp, col0, intConst(1)), col1),
+			NewFunctionInternal(s.ctx, ast.IsNull, intTp, col1)),
+		NewFunctionInternal(s.ctx, ast.UnaryNot, intTp,
+			NewFunctionInternal(s.ctx, ast.EQ, intTp, NewFunctionInternal(s.ctx, ast.Minus, intTp, col0, col1), intConst(3))),
+	}
+	compiled := CompileFilter(s.ctx, filters)
+	c.Assert(compiled, NotNil)
+
+	chk := chunk.NewChunkWithCapacity([]*types.FieldType{intTp, intTp, realTp}, 1024)
+	for i := 0; i < 1024; i++ {
+		if rand.Intn(10) == 0 {
+			chk.AppendNull(0)
+		} else {
+			chk.AppendInt64(0, rand.Int63n(20)-10)
+		}
+		if rand.Intn(10) == 0 {
+			chk.AppendNull(1)
+		} else {
+			chk.AppendInt64(1, rand.Int63n(20)-10)
+		}
+		chk.AppendFloat64(2, rand.Float64())
+	}
+	it := chunk.NewIterator4Chunk(chk)
+	expected, err := VectorizedFilter(s.ctx, filters, it, nil)
+	c.Assert(err, IsNil)
+	selected, ok := compiled.Filter(chk, nil)
+	c.Assert(ok, IsTrue)
+	c.Assert(selected, DeepEquals, expected)
+
+	// Only the rows in the selection vector are evaluated.
+	sel := make([]int, 0, 512)
+	for i := 0; i < 1024; i += 2 {
+		sel = append(sel, i)
+	}
+	chk.SetSel(sel)
+	expected, err = VectorizedFilter(s.ctx, filters, it, nil)
+	c.Assert(err, IsNil)
+	selected, ok = compiled.Filter(chk, nil)
+	c.Assert(ok, IsTrue)
+	c.Assert(selected, DeepEquals, expected)
+	c.Assert(chk.Sel(), DeepEquals, sel)
+	chk.SetSel(nil)
+
+	// The predicates on other types are not compiled.
+	c.Assert(CompileFilter(s.ctx, append(filters, NewFunctionInternal(s.ctx, ast.GT, intTp, col2, intConst(0)))), IsNil)
+
+	// The compiled filter gives up the chunk when the arithmetic overflows.
+	overflow := []Expression{NewFunctionInternal(s.ctx, ast.GT, intTp, NewFunctionInternal(s.ctx, ast.Plus, intTp, col0, intConst(math.MaxInt64)), intConst(0))}
+	compiled = CompileFilter(s.ctx, overflow)
+	c.Assert(compiled, NotNil)
+	chk.Reset()
+	chk.AppendInt64(0, 1)
+	chk.AppendInt64(1, 0)
+	chk.AppendFloat64(2, 0)
+	_, ok = compiled.Filter(chk, nil)
+	c.Assert(ok, IsFalse)
+}
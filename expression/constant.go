@@ -16,6 +16,7 @@ package expression
 import (
 	"fmt"
 
+	"github.com/pingcap/parser/ast"
 	"github.com/pingcap/parser/mysql"
 	"github.com/pingcap/parser/terror"
 	"github.com/pingcap/tidb/sessionctx"
@@ -119,7 +120,7 @@ func (c *Constant) GetType() *types.FieldType {
 
 // VecEvalInt evaluates this expression in a vectorized manner.
 func (c *Constant) VecEvalInt(ctx sessionctx.Context, input *chunk.Chunk, result *chunk.Column) error {
-	if c.DeferredExpr == nil {
+	if c.DeferredExpr == nil || c.memoizable() {
 		return genVecFromConstExpr(ctx, c, types.ETInt, input, result)
 	}
 	return c.DeferredExpr.VecEvalInt(ctx, input, result)
@@ -127,7 +128,7 @@ func (c *Constant) VecEvalInt(ctx sessionctx.Context, input *chunk.Chunk, result
 
 // VecEvalReal evaluates this expression in a vectorized manner.
 func (c *Constant) VecEvalReal(ctx sessionctx.Context, input *chunk.Chunk, result *chunk.Column) error {
-	if c.DeferredExpr == nil {
+	if c.DeferredExpr == nil || c.memoizable() {
 		return genVecFromConstExpr(ctx, c, types.ETReal, input, result)
 	}
 	return c.DeferredExpr.VecEvalReal(ctx, input, result)
@@ -135,7 +136,7 @@ func (c *Constant) VecEvalReal(ctx sessionctx.Context, input *chunk.Chunk, resul
 
 // VecEvalString evaluates this expression in a vectorized manner.
 func (c *Constant) VecEvalString(ctx sessionctx.Context, input *chunk.Chunk, result *chunk.Column) error {
-	if c.DeferredExpr == nil {
+	if c.DeferredExpr == nil || c.memoizable() {
 		return genVecFromConstExpr(ctx, c, types.ETString, input, result)
 	}
 	return c.DeferredExpr.VecEvalString(ctx, input, result)
@@ -143,7 +144,7 @@ func (c *Constant) VecEvalString(ctx sessionctx.Context, input *chunk.Chunk, res
 
 // VecEvalDecimal evaluates this expression in a vectorized manner.
 func (c *Constant) VecEvalDecimal(ctx sessionctx.Context, input *chunk.Chunk, result *chunk.Column) error {
-	if c.DeferredExpr == nil {
+	if c.DeferredExpr == nil || c.memoizable() {
 		return genVecFromConstExpr(ctx, c, types.ETDecimal, input, result)
 	}
 	return c.DeferredExpr.VecEvalDecimal(ctx, input, result)
@@ -151,7 +152,7 @@ func (c *Constant) VecEvalDecimal(ctx sessionctx.Context, input *chunk.Chunk, re
 
 // VecEvalTime evaluates this expression in a vectorized manner.
 func (c *Constant) VecEvalTime(ctx sessionctx.Context, input *chunk.Chunk, result *chunk.Column) error {
-	if c.DeferredExpr == nil {
+	if c.DeferredExpr == nil || c.memoizable() {
 		return genVecFromConstExpr(ctx, c, types.ETTimestamp, input, result)
 	}
 	return c.DeferredExpr.VecEvalTime(ctx, input, result)
@@ -159,7 +160,7 @@ func (c *Constant) VecEvalTime(ctx sessionctx.Context, input *chunk.Chunk, resul
 
 // VecEvalDuration evaluates this expression in a vectorized manner.
 func (c *Constant) VecEvalDuration(ctx sessionctx.Context, input *chunk.Chunk, result *chunk.Column) error {
-	if c.DeferredExpr == nil {
+	if c.DeferredExpr == nil || c.memoizable() {
 		return genVecFromConstExpr(ctx, c, types.ETDuration, input, result)
 	}
 	return c.DeferredExpr.VecEvalDuration(ctx, input, result)
@@ -167,7 +168,7 @@ func (c *Constant) VecEvalDuration(ctx sessionctx.Context, input *chunk.Chunk, r
 
 // VecEvalJSON evaluates this expression in a vectorized manner.
 func (c *Constant) VecEvalJSON(ctx sessionctx.Context, input *chunk.Chunk, result *chunk.Column) error {
-	if c.DeferredExpr == nil {
+	if c.DeferredExpr == nil || c.memoizable() {
 		return genVecFromConstExpr(ctx, c, types.ETJson, input, result)
 	}
 	return c.DeferredExpr.VecEvalJSON(ctx, input, result)
@@ -177,12 +178,57 @@ func (c *Constant) getLazyDatum(row chunk.Row) (dt types.Datum, isLazy bool, err
 	if c.ParamMarker != nil {
 		return c.ParamMarker.GetUserVar(), true, nil
 	} else if c.DeferredExpr != nil {
-		dt, err = c.DeferredExpr.Eval(row)
+		dt, err = c.evalDeferredExpr(row)
 		return dt, true, err
 	}
 	return types.Datum{}, false, nil
 }
 
+// evalDeferredExpr evaluates the deferred expression. Its value is constant for the whole statement, so it's
+// memoized in the statement context instead of being evaluated for every row or chunk.
+func (c *Constant) evalDeferredExpr(row chunk.Row) (types.Datum, error) {
+	if !c.memoizable() {
+		return c.DeferredExpr.Eval(row)
+	}
+	sf := c.DeferredExpr.(*ScalarFunction)
+	sc := sf.GetCtx().GetSessionVars().StmtCtx
+	if value, ok := sc.GetConstExprValue(sf); ok {
+		return value.(types.Datum), nil
+	}
+	dt, err := sf.Eval(row)
+	if err != nil {
+		return dt, err
+	}
+	sc.SetConstExprValue(sf, *dt.Clone())
+	return dt, nil
+}
+
+// memoizable checks whether the value of the deferred expression can be memoized. The time functions return the
+// same value in a statement, but random_bytes returns a new value every time.
+func (c *Constant) memoizable() bool {
+	sf, ok := c.DeferredExpr.(*ScalarFunction)
+	return ok && !containsFunc(sf, ast.RandomBytes)
+}
+
+func containsFunc(expr Expression, funcName string) bool {
+	switch x := expr.(type) {
+	case *ScalarFunction:
+		if x.FuncName.L == funcName {
+			return true
+		}
+		for _, arg := range x.GetArgs() {
+			if containsFunc(arg, funcName) {
+				return true
+			}
+		}
+	case *Constant:
+		if x.DeferredExpr != nil {
+			return containsFunc(x.DeferredExpr, funcName)
+		}
+	}
+	return false
+}
+
 // Eval implements Expression interface.
 func (c *Constant) Eval(row chunk.Row) (types.Datum, error) {
 	if dt, lazy, err := c.getLazyDatum(row); lazy {
@@ -151,6 +151,10 @@ func foldConstant(expr Expression) (Expression, bool) {
 		if _, ok := unFoldableFunctions[x.FuncName.L]; ok {
 			return expr, false
 		}
+		// The user-defined functions may be nondeterministic.
+		if _, ok := x.Function.(*builtinUDFSig); ok {
+			return expr, false
+		}
 		if function := specialFoldHandler[x.FuncName.L]; function != nil {
 			return function(x)
 		}
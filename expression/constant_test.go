@@ -22,6 +22,7 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/parser/ast"
 	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/tidb/sessionctx/stmtctx"
 	"github.com/pingcap/tidb/types"
 	"github.com/pingcap/tidb/types/json"
 	"github.com/pingcap/tidb/util/chunk"
@@ -423,3 +424,37 @@ func (*testExpressionSuite) TestGetTypeThreadSafe(c *C) {
 	ft2 := con.GetType()
 	c.Assert(ft1, Not(Equals), ft2)
 }
+
+func (*testExpressionSuite) TestMemoizeDeferredExpr(c *C) {
+	ctx := mock.NewContext()
+	ctx.GetSessionVars().PreparedParams = []types.Datum{types.NewIntDatum(1)}
+	param := &Constant{ParamMarker: &ParamMarker{ctx: ctx, order: 0}, RetType: newIntFieldType()}
+	// The deferred expression isn't folded into a constant.
+	sf, err := NewFunctionBase(ctx, ast.Plus, newIntFieldType(), param, newLonglong(1))
+	c.Assert(err, IsNil)
+	cst := &Constant{DeferredExpr: sf, RetType: newIntFieldType()}
+
+	val, isNull, err := cst.EvalInt(ctx, chunk.Row{})
+	c.Assert(err, IsNil)
+	c.Assert(isNull, IsFalse)
+	c.Assert(val, Equals, int64(2))
+	// The value is memoized in the statement.
+	ctx.GetSessionVars().PreparedParams[0] = types.NewIntDatum(10)
+	val, _, err = cst.EvalInt(ctx, chunk.Row{})
+	c.Assert(err, IsNil)
+	c.Assert(val, Equals, int64(2))
+
+	// The value is evaluated again in a new statement.
+	ctx.GetSessionVars().StmtCtx = &stmtctx.StatementContext{}
+	chk := chunk.NewChunkWithCapacity([]*types.FieldType{newIntFieldType()}, 3)
+	for i := 0; i < 3; i++ {
+		chk.AppendInt64(0, int64(i))
+	}
+	col := chunk.NewColumn(newIntFieldType(), 3)
+	c.Assert(cst.VecEvalInt(ctx, chk, col), IsNil)
+	c.Assert(col.Int64s(), DeepEquals, []int64{11, 11, 11})
+
+	// random_bytes returns a new value every time, so it's not memoized.
+	cst = &Constant{DeferredExpr: NewFunctionInternal(ctx, ast.RandomBytes, newStringFieldType(), newLonglong(8)), RetType: newStringFieldType()}
+	c.Assert(cst.memoizable(), IsFalse)
+}
@@ -47,6 +47,7 @@ var (
 	errTruncatedWrongValue           = dbterror.ClassExpression.NewStd(mysql.ErrTruncatedWrongValue)
 	errUnknownLocale                 = dbterror.ClassExpression.NewStd(mysql.ErrUnknownLocale)
 	errNonUniq                       = dbterror.ClassExpression.NewStd(mysql.ErrNonUniq)
+	errUDFFailed                     = dbterror.ClassExpression.NewStdErr(mysql.ErrUnknown, pmysql.Message("user-defined function %s failed: %s", nil))
 
 	// Sequence usage privilege check.
 	errSequenceAccessDenied      = dbterror.ClassExpression.NewStd(mysql.ErrTableaccessDenied)
@@ -308,11 +308,12 @@ func deallocateZeroSlice(isZero []int8) {
 }
 
 // VecEvalBool does the same thing as EvalBool but it works in a vectorized manner.
+// If input.Sel() != nil, only the rows in it are evaluated, and the other rows are not selected.
 func VecEvalBool(ctx sessionctx.Context, exprList CNFExprs, input *chunk.Chunk, selected, nulls []bool) ([]bool, []bool, error) {
-	// If input.Sel() != nil, we will call input.SetSel(nil) to clear the sel slice in input chunk.
-	// After the function finished, then we reset the input.Sel().
-	// The caller will handle the input.Sel() and selected slices.
-	defer input.SetSel(input.Sel())
+	// The selected and nulls slices are indexed by the physical rows, so we call input.SetSel(nil)
+	// to get the number of them, and then reset the input.Sel() after the function finished.
+	inputSel := input.Sel()
+	defer input.SetSel(inputSel)
 	input.SetSel(nil)
 
 	n := input.NumRows()
@@ -326,8 +327,12 @@ func VecEvalBool(ctx sessionctx.Context, exprList CNFExprs, input *chunk.Chunk,
 	sel := allocSelSlice(n)
 	defer deallocateSelSlice(sel)
 	sel = sel[:0]
-	for i := 0; i < n; i++ {
-		sel = append(sel, i)
+	if inputSel != nil {
+		sel = append(sel, inputSel...)
+	} else {
+		for i := 0; i < n; i++ {
+			sel = append(sel, i)
+		}
 	}
 	input.SetSel(sel)
 
@@ -1160,12 +1165,12 @@ func canScalarFuncPushDown(scalarFunc *ScalarFunction, pc PbConverter, storeType
 
 	// Check whether this function can be pushed.
 	if !canFuncBePushed(scalarFunc, storeType) {
-		if pc.sc.InExplainStmt {
+		if pc.explainPushDown() {
 			storageName := storeType.Name()
 			if storeType == kv.UnSpecified {
 				storageName = "storage layer"
 			}
-			pc.sc.AppendWarning(errors.New("Scalar function '" + scalarFunc.FuncName.L + "'(signature: " + scalarFunc.Function.PbCode().String() + ") can not be pushed to " + storageName))
+			pc.appendPushDownWarning(errors.New("Scalar function '" + scalarFunc.FuncName.L + "'(signature: " + scalarFunc.Function.PbCode().String() + ") can not be pushed to " + storageName))
 		}
 		return false
 	}
@@ -1192,13 +1197,13 @@ func canExprPushDown(expr Expression, pc PbConverter, storeType kv.StoreType) bo
 	if storeType == kv.TiFlash {
 		switch expr.GetType().Tp {
 		case mysql.TypeDuration:
-			if pc.sc.InExplainStmt {
-				pc.sc.AppendWarning(errors.New("Expr '" + expr.String() + "' can not be pushed to TiFlash because it contains Duration type"))
+			if pc.explainPushDown() {
+				pc.appendPushDownWarning(errors.New("Expr '" + expr.String() + "' can not be pushed to TiFlash because it contains Duration type"))
 			}
 			return false
 		case mysql.TypeEnum:
-			if pc.sc.InExplainStmt {
-				pc.sc.AppendWarning(errors.New("Expr '" + expr.String() + "' can not be pushed to TiFlash because it contains Enum type"))
+			if pc.explainPushDown() {
+				pc.appendPushDownWarning(errors.New("Expr '" + expr.String() + "' can not be pushed to TiFlash because it contains Enum type"))
 			}
 			return false
 		default:
@@ -1217,6 +1222,22 @@ func canExprPushDown(expr Expression, pc PbConverter, storeType kv.StoreType) bo
 	return false
 }
 
+// explainPushDown returns whether to tell why an expression can't be pushed down.
+func (pc PbConverter) explainPushDown() bool {
+	return pc.sc.InExplainStmt || pc.sc.EnableOptimizerNotes || pc.sc.EnableOptimizerTrace
+}
+
+// appendPushDownWarning tells why an expression can't be pushed down, it's a warning of EXPLAIN or an optimizer
+// note of the other statements, and it's recorded into the optimizer trace as a note as well.
+func (pc PbConverter) appendPushDownWarning(err error) {
+	if pc.sc.InExplainStmt {
+		pc.sc.AppendWarning(err)
+		pc.sc.AppendOptimizerTrace(stmtctx.TraceTypeNote, "", err.Error())
+	} else {
+		pc.sc.AppendOptimizerNote(err)
+	}
+}
+
 // PushDownExprs split the input exprs into pushed and remained, pushed include all the exprs that can be pushed down
 func PushDownExprs(sc *stmtctx.StatementContext, exprs []Expression, client kv.Client, storeType kv.StoreType) (pushed []Expression, remained []Expression) {
 	pc := PbConverter{sc: sc, client: client}
@@ -51,6 +51,7 @@ const builtinCompareImports = `import (
 	"github.com/pingcap/tidb/types"
 	"github.com/pingcap/tidb/types/json"
 	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/collate"
 )
 `
 
@@ -81,6 +82,9 @@ func (b *builtin{{ .compare.CompareName }}{{ .type.TypeName }}Sig) vecEvalInt(in
 	result.ResizeInt64(n, false)
 	result.MergeNulls(buf0, buf1)
 	i64s := result.Int64s()
+{{- if eq .type.ETName "String" }}
+	compare := collate.GetCompareFunc(b.collation)
+{{- end }}
 	for i := 0; i < n; i++ {
 		if result.IsNull(i) {
 			continue
@@ -90,7 +94,7 @@ func (b *builtin{{ .compare.CompareName }}{{ .type.TypeName }}Sig) vecEvalInt(in
 {{- else if eq .type.ETName "Real" }}
 		val := types.CompareFloat64(arg0[i], arg1[i])
 {{- else if eq .type.ETName "String" }}
-		val := types.CompareString(buf0.GetString(i), buf1.GetString(i), b.collation)
+		val := compare(buf0.GetString(i), buf1.GetString(i))
 {{- else if eq .type.ETName "Duration" }}
 		val := types.CompareDuration(arg0[i], arg1[i])
 {{- else if eq .type.ETName "Datetime" }}
@@ -138,6 +142,9 @@ func (b *builtin{{ .compare.CompareName }}{{ .type.TypeName }}Sig) vecEvalInt(in
 {{- end }}
 	result.ResizeInt64(n, false)
 	i64s := result.Int64s()
+{{- if eq .type.ETName "String" }}
+	compare := collate.GetCompareFunc(b.collation)
+{{- end }}
 	for i := 0; i < n; i++ {
 		isNull0 := buf0.IsNull(i)
 		isNull1 := buf1.IsNull(i)
@@ -151,7 +158,7 @@ func (b *builtin{{ .compare.CompareName }}{{ .type.TypeName }}Sig) vecEvalInt(in
 {{- else if eq .type.ETName "Real" }}
 		case types.CompareFloat64(arg0[i], arg1[i]) == 0:
 {{- else if eq .type.ETName "String" }}
-		case types.CompareString(buf0.GetString(i), buf1.GetString(i), b.collation) == 0:
+		case compare(buf0.GetString(i), buf1.GetString(i)) == 0:
 {{- else if eq .type.ETName "Duration" }}
 		case types.CompareDuration(arg0[i], arg1[i]) == 0:
 {{- else if eq .type.ETName "Datetime" }}
@@ -50,168 +50,118 @@ import (
 	"github.com/pingcap/tidb/util/chunk"
 )
 
-// NOTE: Control expressions optionally evaluate some branches depending on conditions, but vectorization executes all
-// branches, during which the unnecessary branches may return errors or warnings. To avoid this case, when branches 
-// meet errors or warnings, the vectorization falls back the scalar execution.
+// NOTE: Control expressions optionally evaluate some branches depending on conditions. The vectorization evaluates
+// each branch only on the rows which take it by the selection vector of the input chunk, so the unnecessary branches
+// never return errors or warnings, the same as the scalar execution.
 
 `
 
 var builtinCaseWhenVec = template.Must(template.New("builtinCaseWhenVec").Parse(`
 {{ range .Sigs }}{{ with .Arg0 }}
-func (b *builtinCaseWhen{{ .TypeName }}Sig) fallbackEval{{ .TypeName }}(input *chunk.Chunk, result *chunk.Column) error {
-	n := input.NumRows()
-	{{- if .Fixed }}
-	result.Resize{{ .TypeNameInColumn }}(n, false)
-	x := result.{{ .TypeNameInColumn }}s()
-	for i := 0; i < n; i++ {
-		res, isNull, err := b.eval{{ .TypeName }}(input.GetRow(i))
-		if err != nil {
-			return err
-		}
-		result.SetNull(i, isNull)
-		if isNull {
-			continue
-		}
-		{{ if eq .TypeName "Decimal" }}
-			x[i] = *res
-		{{ else if eq .TypeName "Duration" }}
-			x[i] = res.Duration
-		{{ else }}
-			x[i] = res
-		{{ end }}
-	}
-	{{ else }}
-	result.Reserve{{ .TypeNameInColumn }}(n)
-	for i := 0; i < n; i++ {
-		res, isNull, err := b.eval{{ .TypeName }}(input.GetRow(i))
-		if err != nil {
-			return err
-		}
-		if isNull {
-			result.AppendNull()
-			continue
-		}
-		result.Append{{ .TypeNameInColumn }}(res)
-	}
-	{{ end -}}
-	return nil
-}
-
 func (b *builtinCaseWhen{{ .TypeName }}Sig) vecEval{{ .TypeName }}(input *chunk.Chunk, result *chunk.Column) error {
 	n := input.NumRows()
 	args, l := b.getArgs(), len(b.getArgs())
-	whens := make([]*chunk.Column, l/2)
-	whensSlice := make([][]int64, l/2)
-	thens := make([]*chunk.Column, l/2)
-	var eLse *chunk.Column
-	{{- if .Fixed }}
-	thensSlice := make([][]{{.TypeNameGo}}, l/2)
-	var eLseSlice []{{.TypeNameGo}}
-	{{- end }}
-	sc := b.ctx.GetSessionVars().StmtCtx
-	beforeWarns := sc.WarningCount()
+	// clauses[i] is the index in results of the clause which the i-th row takes its result from, -1 means NULL.
+	// offsets[i] is the position of the i-th row in the result of its clause.
+	clauses := make([]int, n)
+	offsets := make([]int, n)
+	results := make([]*chunk.Column, 0, l/2+1)
+	// remaining are the rows not matched by the evaluated when clauses.
+	remaining := make([]int, n)
+	for i := 0; i < n; i++ {
+		clauses[i] = -1
+		remaining[i] = i
+	}
+	matched := make([]int, 0, n)
+	sel := newVecSelection(input)
+	defer sel.restore()
 
-	for j := 0; j < l-1; j+=2 {
-		bufWhen, err := b.bufAllocator.get(types.ETInt, n)
+	// when clause(condition, result) -> args[i], args[i+1]; (i >= 0 && i+1 < l-1)
+	// else clause -> args[l-1]
+	// If case clause has else clause, l%2 == 1.
+	for j := 0; j < l-1 && len(remaining) > 0; j += 2 {
+		sel.selectRows(remaining)
+		bufWhen, err := b.bufAllocator.get(types.ETInt, len(remaining))
 		if err != nil {
 			return err
 		}
 		defer b.bufAllocator.put(bufWhen)
-		err = args[j].VecEvalInt(b.ctx, input, bufWhen)
-		afterWarns := sc.WarningCount()
-		if err != nil || afterWarns > beforeWarns {
-			if afterWarns > beforeWarns {
-				sc.TruncateWarnings(int(beforeWarns))
+		if err := args[j].VecEvalInt(b.ctx, input, bufWhen); err != nil {
+			return err
+		}
+		whens := bufWhen.Int64s()
+		matched = matched[:0]
+		k := 0
+		for i, row := range remaining {
+			if bufWhen.IsNull(i) || whens[i] == 0 {
+				remaining[k] = row
+				k++
+				continue
 			}
-			return b.fallbackEval{{ .TypeName }}(input, result)
+			clauses[row] = len(results)
+			offsets[row] = len(matched)
+			matched = append(matched, row)
+		}
+		remaining = remaining[:k]
+		if len(matched) == 0 {
+			continue
 		}
-		whens[j/2] = bufWhen
-		whensSlice[j/2] = bufWhen.Int64s()
 
-		bufThen, err := b.bufAllocator.get(types.ET{{ .ETName }}, n)
+		sel.selectRows(matched)
+		bufThen, err := b.bufAllocator.get(types.ET{{ .ETName }}, len(matched))
 		if err != nil {
 			return err
 		}
 		defer b.bufAllocator.put(bufThen)
-		err = args[j+1].VecEval{{ .TypeName }}(b.ctx, input, bufThen)
-		afterWarns = sc.WarningCount()
-		if err != nil || afterWarns > beforeWarns {
-			if afterWarns > beforeWarns {
-				sc.TruncateWarnings(int(beforeWarns))
-			}
-			return b.fallbackEval{{ .TypeName }}(input, result)
+		if err := args[j+1].VecEval{{ .TypeName }}(b.ctx, input, bufThen); err != nil {
+			return err
 		}
-		thens[j/2] = bufThen
-		{{- if .Fixed }}
-		thensSlice[j/2] = bufThen.{{ .TypeNameInColumn }}s()
-		{{- end }}
+		results = append(results, bufThen)
 	}
-	// when clause(condition, result) -> args[i], args[i+1]; (i >= 0 && i+1 < l-1)
-	// else clause -> args[l-1]
-	// If case clause has else clause, l%2 == 1.
-	if l%2==1 {
-		bufElse, err := b.bufAllocator.get(types.ET{{ .ETName }}, n)
+	if l%2 == 1 && len(remaining) > 0 {
+		for i, row := range remaining {
+			clauses[row] = len(results)
+			offsets[row] = i
+		}
+		sel.selectRows(remaining)
+		bufElse, err := b.bufAllocator.get(types.ET{{ .ETName }}, len(remaining))
 		if err != nil {
 			return err
 		}
 		defer b.bufAllocator.put(bufElse)
-		err = args[l-1].VecEval{{ .TypeName }}(b.ctx, input, bufElse)
-		afterWarns := sc.WarningCount()
-		if err != nil || afterWarns > beforeWarns {
-			if afterWarns > beforeWarns {
-				sc.TruncateWarnings(int(beforeWarns))
-			}
-			return b.fallbackEval{{ .TypeName }}(input, result)
+		if err := args[l-1].VecEval{{ .TypeName }}(b.ctx, input, bufElse); err != nil {
+			return err
 		}
-		eLse = bufElse
-		{{- if .Fixed }}
-		eLseSlice = bufElse.{{ .TypeNameInColumn }}s()
-		{{- end }}
+		results = append(results, bufElse)
 	}
-	
+
 	{{- if .Fixed }}
+	resultsSlice := make([][]{{ .TypeNameGo }}, len(results))
+	for j := range results {
+		resultsSlice[j] = results[j].{{ .TypeNameInColumn }}s()
+	}
 	result.Resize{{ .TypeNameInColumn }}(n, false)
 	resultSlice := result.{{ .TypeNameInColumn }}s()
+	for i := 0; i < n; i++ {
+		j := clauses[i]
+		if j < 0 {
+			result.SetNull(i, true)
+			continue
+		}
+		resultSlice[i] = resultsSlice[j][offsets[i]]
+		result.SetNull(i, results[j].IsNull(offsets[i]))
+	}
 	{{- else }}
 	result.Reserve{{ .TypeNameInColumn }}(n)
-	{{- end }}		
-ROW:
 	for i := 0; i < n; i++ {
-		for j := 0; j < l/2; j++ {
-			if whens[j].IsNull(i) || whensSlice[j][i] == 0 {
-				continue
-			}
-			{{- if .Fixed }}
-			resultSlice[i] = thensSlice[j][i]
-			result.SetNull(i, thens[j].IsNull(i))
-			{{- else }}
-			if thens[j].IsNull(i) {
-				result.AppendNull()
-			} else {
-				result.Append{{ .TypeNameInColumn }}(thens[j].Get{{ .TypeNameInColumn }}(i))
-			}
-			{{- end }}
-			continue ROW
-		}
-		if eLse != nil {
-			{{- if .Fixed }}
-			resultSlice[i] = eLseSlice[i]
-			result.SetNull(i, eLse.IsNull(i))
-			{{- else }}
-			if eLse.IsNull(i) {
-				result.AppendNull()
-			} else {
-				result.Append{{ .TypeNameInColumn }}(eLse.Get{{ .TypeNameInColumn }}(i))
-			}	
-			{{- end }}
-		} else {
-			{{- if .Fixed }}
-			result.SetNull(i, true)
-			{{- else }}
+		j := clauses[i]
+		if j < 0 || results[j].IsNull(offsets[i]) {
 			result.AppendNull()
-			{{- end }}
+			continue
 		}
+		result.Append{{ .TypeNameInColumn }}(results[j].Get{{ .TypeNameInColumn }}(offsets[i]))
 	}
+	{{- end }}
 	return nil
 }
 
@@ -224,74 +174,14 @@ func (b *builtinCaseWhen{{ .TypeName }}Sig) vectorized() bool {
 
 var builtinIfNullVec = template.Must(template.New("builtinIfNullVec").Parse(`
 {{ range .Sigs }}{{ with .Arg0 }}
-func (b *builtinIfNull{{ .TypeName }}Sig) fallbackEval{{ .TypeName }}(input *chunk.Chunk, result *chunk.Column) error {
-	n := input.NumRows()
-	{{- if .Fixed }}
-	x := result.{{ .TypeNameInColumn }}s()
-	for i := 0; i < n; i++ {
-		res, isNull, err := b.eval{{ .TypeName }}(input.GetRow(i))
-		if err != nil {
-			return err
-		}
-		result.SetNull(i, isNull)
-		if isNull {
-			continue
-		}
-		{{ if eq .TypeName "Decimal" }}
-			x[i] = *res
-		{{ else if eq .TypeName "Duration" }}
-			x[i] = res.Duration
-		{{ else }}
-			x[i] = res
-		{{ end }}
-	}
-	{{ else }}
-	result.Reserve{{ .TypeNameInColumn }}(n)
-	for i := 0; i < n; i++ {
-		res, isNull, err := b.eval{{ .TypeName }}(input.GetRow(i))
-		if err != nil {
-			return err
-		}
-		if isNull {
-			result.AppendNull()
-			continue
-		}
-		result.Append{{ .TypeNameInColumn }}(res)
-	}
-	{{ end -}}
-	return nil
-}
-
 func (b *builtinIfNull{{ .TypeName }}Sig) vecEval{{ .TypeName }}(input *chunk.Chunk, result *chunk.Column) error {
 	n := input.NumRows()
 	{{- if .Fixed }}
 	if err := b.args[0].VecEval{{ .TypeName }}(b.ctx, input, result); err != nil {
 		return err
 	}
-	buf1, err := b.bufAllocator.get(types.ET{{ .ETName }}, n)
-	if err != nil {
-		return err
-	}
-	defer b.bufAllocator.put(buf1)
-	sc := b.ctx.GetSessionVars().StmtCtx
-	beforeWarns := sc.WarningCount()
-	err = b.args[1].VecEval{{ .TypeName }}(b.ctx, input, buf1)
-	afterWarns := sc.WarningCount()
-	if err != nil || afterWarns > beforeWarns {
-		if afterWarns > beforeWarns {
-      		sc.TruncateWarnings(int(beforeWarns))
-		}
-      	return b.fallbackEval{{ .TypeName }}(input, result)
-   	}
-	arg0 := result.{{ .TypeNameInColumn }}s()
-	arg1 := buf1.{{ .TypeNameInColumn }}s()
-	for i := 0; i < n; i++ {
-		if result.IsNull(i) && !buf1.IsNull(i) {
-			result.SetNull(i, false)
-			arg0[i] = arg1[i]
-		}
-	}
-	{{ else }}
+	buf0 := result
+	{{- else }}
 	buf0, err := b.bufAllocator.get(types.ET{{ .ETName }}, n)
 	if err != nil {
 		return err
@@ -300,33 +190,53 @@ func (b *builtinIfNull{{ .TypeName }}Sig) vecEval{{ .TypeName }}(input *chunk.Ch
 	if err := b.args[0].VecEval{{ .TypeName }}(b.ctx, input, buf0); err != nil {
 		return err
 	}
-	buf1, err := b.bufAllocator.get(types.ET{{ .ETName }}, n)
+	{{- end }}
+	// The second argument is evaluated only on the rows whose first argument is NULL.
+	nullRows := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		if buf0.IsNull(i) {
+			nullRows = append(nullRows, i)
+		}
+	}
+	buf1, err := b.bufAllocator.get(types.ET{{ .ETName }}, len(nullRows))
 	if err != nil {
 		return err
 	}
 	defer b.bufAllocator.put(buf1)
-	sc := b.ctx.GetSessionVars().StmtCtx
-	beforeWarns := sc.WarningCount()
-	err = b.args[1].VecEval{{ .TypeName }}(b.ctx, input, buf1)
-	afterWarns := sc.WarningCount()
-	if err != nil || afterWarns > beforeWarns {
-		if afterWarns > beforeWarns {
-      		sc.TruncateWarnings(int(beforeWarns))
+	if len(nullRows) > 0 {
+		sel := newVecSelection(input)
+		sel.selectRows(nullRows)
+		err = b.args[1].VecEval{{ .TypeName }}(b.ctx, input, buf1)
+		sel.restore()
+		if err != nil {
+			return err
 		}
-      	return b.fallbackEval{{ .TypeName }}(input,result)
-   	}
-
+	}
+	{{- if .Fixed }}
+	arg0 := result.{{ .TypeNameInColumn }}s()
+	arg1 := buf1.{{ .TypeNameInColumn }}s()
+	for k, i := range nullRows {
+		if !buf1.IsNull(k) {
+			result.SetNull(i, false)
+			arg0[i] = arg1[k]
+		}
+	}
+	{{- else }}
 	result.Reserve{{ .TypeNameInColumn }}(n)
+	k := 0
 	for i := 0; i < n; i++ {
 		if !buf0.IsNull(i) {
 			result.Append{{ .TypeNameInColumn }}(buf0.Get{{ .TypeNameInColumn }}(i))
-		} else if !buf1.IsNull(i) {
-			result.Append{{ .TypeNameInColumn }}(buf1.Get{{ .TypeNameInColumn }}(i))
+			continue
+		}
+		if !buf1.IsNull(k) {
+			result.Append{{ .TypeNameInColumn }}(buf1.Get{{ .TypeNameInColumn }}(k))
 		} else {
 			result.AppendNull()
 		}
+		k++
 	}
-	{{ end -}}
+	{{- end }}
 	return nil
 }
 
@@ -339,44 +249,6 @@ func (b *builtinIfNull{{ .TypeName }}Sig) vectorized() bool {
 
 var builtinIfVec = template.Must(template.New("builtinIfVec").Parse(`
 {{ range .Sigs }}{{ with .Arg0 }}
-func (b *builtinIf{{ .TypeName }}Sig) fallbackEval{{ .TypeName }}(input *chunk.Chunk, result *chunk.Column) error {
-	n := input.NumRows()
-	{{- if .Fixed }}
-	x := result.{{ .TypeNameInColumn }}s()
-	for i := 0; i < n; i++ {
-		res, isNull, err := b.eval{{ .TypeName }}(input.GetRow(i))
-		if err != nil {
-			return err
-		}
-		result.SetNull(i, isNull)
-		if isNull {
-			continue
-		}
-		{{ if eq .TypeName "Decimal" }}
-			x[i] = *res
-		{{ else if eq .TypeName "Duration" }}
-			x[i] = res.Duration
-		{{ else }}
-			x[i] = res
-		{{ end }}
-	}
-	{{ else }}
-	result.Reserve{{ .TypeNameInColumn }}(n)
-	for i := 0; i < n; i++ {
-		res, isNull, err := b.eval{{ .TypeName }}(input.GetRow(i))
-		if err != nil {
-			return err
-		}
-		if isNull {
-			result.AppendNull()
-			continue
-		}
-		result.Append{{ .TypeNameInColumn }}(res)
-	}
-	{{ end -}}
-	return nil
-}
-
 func (b *builtinIf{{ .TypeName }}Sig) vecEval{{ .TypeName }}(input *chunk.Chunk, result *chunk.Column) error {
 	n := input.NumRows()
 	buf0, err := b.bufAllocator.get(types.ETInt, n)
@@ -387,77 +259,72 @@ func (b *builtinIf{{ .TypeName }}Sig) vecEval{{ .TypeName }}(input *chunk.Chunk,
 	if err := b.args[0].VecEvalInt(b.ctx, input, buf0); err != nil {
 		return err
 	}
-	sc := b.ctx.GetSessionVars().StmtCtx
-	beforeWarns := sc.WarningCount()
-{{- if .Fixed }}
-	err = b.args[1].VecEval{{ .TypeName }}(b.ctx, input, result)
-{{- else }}
-	buf1, err := b.bufAllocator.get(types.ET{{ .ETName }}, n)
+	// The second argument is evaluated only on the rows whose condition is true, and the third one on the others.
+	arg0 := buf0.Int64s()
+	trueRows := make([]int, 0, n)
+	falseRows := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		if !buf0.IsNull(i) && arg0[i] != 0 {
+			trueRows = append(trueRows, i)
+		} else {
+			falseRows = append(falseRows, i)
+		}
+	}
+	buf1, err := b.bufAllocator.get(types.ET{{ .ETName }}, len(trueRows))
 	if err != nil {
 		return err
 	}
 	defer b.bufAllocator.put(buf1)
-	err = b.args[1].VecEval{{ .TypeName }}(b.ctx, input, buf1)
-{{- end }}
-	afterWarns := sc.WarningCount()
-	if err != nil || afterWarns > beforeWarns {
-		if afterWarns > beforeWarns {
-      		sc.TruncateWarnings(int(beforeWarns))
-		}
-      	return b.fallbackEval{{ .TypeName }}(input, result)
-   	}
-	
-	buf2, err := b.bufAllocator.get(types.ET{{ .ETName }}, n)
+	buf2, err := b.bufAllocator.get(types.ET{{ .ETName }}, len(falseRows))
 	if err != nil {
 		return err
 	}
 	defer b.bufAllocator.put(buf2)
-	err = b.args[2].VecEval{{ .TypeName }}(b.ctx, input, buf2)
-	afterWarns = sc.WarningCount()
-	if err != nil || afterWarns > beforeWarns {
-		if afterWarns > beforeWarns {
-      		sc.TruncateWarnings(int(beforeWarns))
+	sel := newVecSelection(input)
+	defer sel.restore()
+	if len(trueRows) > 0 {
+		sel.selectRows(trueRows)
+		if err := b.args[1].VecEval{{ .TypeName }}(b.ctx, input, buf1); err != nil {
+			return err
 		}
-      	return b.fallbackEval{{ .TypeName }}(input, result)
-   	}
-{{ if not .Fixed }}
-	result.Reserve{{ .TypeNameInColumn }}(n)
-{{- end }}
-	arg0 := buf0.Int64s()
+	}
+	if len(falseRows) > 0 {
+		sel.selectRows(falseRows)
+		if err := b.args[2].VecEval{{ .TypeName }}(b.ctx, input, buf2); err != nil {
+			return err
+		}
+	}
 {{- if .Fixed }}
-	arg2 := buf2.{{ .TypeNameInColumn }}s()
+	result.Resize{{ .TypeNameInColumn }}(n, false)
 	rs := result.{{ .TypeNameInColumn }}s()
-{{- end }}
-	for i := 0; i < n; i++ {
-		arg := arg0[i]
-		isNull0 := buf0.IsNull(i)
-		switch {
-		case isNull0 || arg == 0:
-{{- if .Fixed }}
-			if buf2.IsNull(i) {
-				result.SetNull(i, true)
-			} else {
-				result.SetNull(i, false)
-				rs[i] = arg2[i]
-			}
-{{- else }}
-			if buf2.IsNull(i) {
-				result.AppendNull()
-			} else {
-				result.Append{{ .TypeNameInColumn }}(buf2.Get{{ .TypeNameInColumn }}(i))
-			}
-{{- end }}
-		case arg != 0:
-{{- if .Fixed }}
+	arg1 := buf1.{{ .TypeNameInColumn }}s()
+	for k, i := range trueRows {
+		result.SetNull(i, buf1.IsNull(k))
+		rs[i] = arg1[k]
+	}
+	arg2 := buf2.{{ .TypeNameInColumn }}s()
+	for k, i := range falseRows {
+		result.SetNull(i, buf2.IsNull(k))
+		rs[i] = arg2[k]
+	}
 {{- else }}
-			if buf1.IsNull(i) {
-				result.AppendNull()
-			} else {
-				result.Append{{ .TypeNameInColumn }}(buf1.Get{{ .TypeNameInColumn }}(i))
-			}
-{{- end }}
+	result.Reserve{{ .TypeNameInColumn }}(n)
+	t, f := 0, 0
+	for i := 0; i < n; i++ {
+		buf, k := buf2, f
+		if t < len(trueRows) && trueRows[t] == i {
+			buf, k = buf1, t
+			t++
+		} else {
+			f++
+		}
+		if buf.IsNull(k) {
+			result.AppendNull()
+		} else {
+			result.Append{{ .TypeNameInColumn }}(buf.Get{{ .TypeNameInColumn }}(k))
 		}
 	}
+{{- end }}
 	return nil
 }
 
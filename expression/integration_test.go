@@ -3523,6 +3523,17 @@ func (s *testIntegrationSuite) TestControlBuiltin(c *C) {
 
 	result = tk.MustQuery("SELECT -63 + COALESCE ( - 83, - 61 + - + 72 * - CAST( NULL AS SIGNED ) + + 3 );")
 	result.Check(testkit.Rows("-146"))
+
+	// The branches are only evaluated on the rows which take them, the truncation of 'x' never happens.
+	tk.MustExec("drop table if exists t2")
+	tk.MustExec("create table t2(a int, b varchar(10))")
+	tk.MustExec("insert into t2 values(0, 'x'), (1, '12'), (2, '3')")
+	tk.MustQuery("select case when a = 0 then 0 when a = 1 then b + 0 else a end from t2").Check(testkit.Rows("0", "12", "2"))
+	tk.MustQuery("show warnings").Check(testkit.Rows())
+	tk.MustQuery("select if(a = 0, 0, b + 0) from t2").Check(testkit.Rows("0", "12", "3"))
+	tk.MustQuery("show warnings").Check(testkit.Rows())
+	tk.MustQuery("select ifnull(nullif(a, 1), b + 0) from t2").Check(testkit.Rows("0", "12", "2"))
+	tk.MustQuery("show warnings").Check(testkit.Rows())
 }
 
 func (s *testIntegrationSuite) TestArithmeticBuiltin(c *C) {
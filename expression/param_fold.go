@@ -0,0 +1,138 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/logutil"
+	"go.uber.org/zap"
+)
+
+// FoldParams folds the expressions of a cached plan with the values of the parameters bound by the current
+// execution. The parameter markers and the deferred constants are replaced by their values and the
+// subexpressions depending on them are folded again, so they are not evaluated for every row. The expressions
+// which don't depend on the parameters are shared with the cached plan, the others are new copies, so the
+// cached plan is never changed.
+func FoldParams(ctx sessionctx.Context, exprs []Expression) []Expression {
+	folded, _ := foldParamExprs(ctx, exprs)
+	return folded
+}
+
+func foldParamExprs(ctx sessionctx.Context, exprs []Expression) ([]Expression, bool) {
+	var folded []Expression
+	for i, expr := range exprs {
+		newExpr, changed := foldParams(ctx, expr)
+		if !changed {
+			continue
+		}
+		if folded == nil {
+			folded = make([]Expression, len(exprs))
+			copy(folded, exprs)
+		}
+		folded[i] = newExpr
+	}
+	if folded == nil {
+		return exprs, false
+	}
+	return folded, true
+}
+
+// FoldParamConditions works like FoldParams on CNF conditions, and also removes the conditions which are
+// always true and the DNF items which are always false after folding. alwaysFalse is true if any condition
+// is always false or null with the current parameters, no row can pass the conditions then.
+func FoldParamConditions(ctx sessionctx.Context, conds []Expression) (_ []Expression, alwaysFalse bool) {
+	folded, changed := foldParamExprs(ctx, conds)
+	if !changed {
+		return conds, false
+	}
+	sc := ctx.GetSessionVars().StmtCtx
+	result := make([]Expression, 0, len(folded))
+	for _, cnfItem := range folded {
+		for _, cond := range SplitCNFItems(cnfItem) {
+			dnfItems := SplitDNFItems(cond)
+			remained := make([]Expression, 0, len(dnfItems))
+			alwaysTrue := false
+			for _, item := range dnfItems {
+				con, ok := item.(*Constant)
+				if !ok || con.DeferredExpr != nil || con.ParamMarker != nil {
+					remained = append(remained, item)
+					continue
+				}
+				isTrue, err := con.Value.ToBool(sc)
+				if err != nil {
+					remained = append(remained, item)
+					continue
+				}
+				if !con.Value.IsNull() && isTrue != 0 {
+					alwaysTrue = true
+					break
+				}
+			}
+			switch {
+			case alwaysTrue:
+			case len(remained) == 0:
+				return nil, true
+			case len(remained) == len(dnfItems):
+				result = append(result, cond)
+			default:
+				result = append(result, ComposeDNFCondition(ctx, remained...))
+			}
+		}
+	}
+	return result, false
+}
+
+// foldParams returns the folded copy of expr if it depends on the parameters.
+func foldParams(ctx sessionctx.Context, expr Expression) (Expression, bool) {
+	switch x := expr.(type) {
+	case *Constant:
+		if x.ParamMarker == nil && x.DeferredExpr == nil {
+			return expr, false
+		}
+		value, err := x.Eval(chunk.Row{})
+		if err != nil {
+			// Keep the deferred constant to let the error be returned when it's evaluated.
+			logutil.BgLogger().Debug("fold parameters", zap.String("expression", x.ExplainInfo()), zap.Error(err))
+			return expr, false
+		}
+		return &Constant{Value: value, RetType: x.RetType}, true
+	case *ScalarFunction:
+		// The functions like getvar and rand must be evaluated for every row, but their arguments can be folded.
+		args := x.GetArgs()
+		var newArgs []Expression
+		for i, arg := range args {
+			newArg, changed := foldParams(ctx, arg)
+			if !changed {
+				continue
+			}
+			if newArgs == nil {
+				newArgs = make([]Expression, len(args))
+				copy(newArgs, args)
+			}
+			newArgs[i] = newArg
+		}
+		if newArgs == nil {
+			return expr, false
+		}
+		newExpr, err := NewFunction(ctx, x.FuncName.L, x.RetType.Clone(), newArgs...)
+		if err != nil {
+			logutil.BgLogger().Debug("fold parameters", zap.String("expression", x.ExplainInfo()), zap.Error(err))
+			return expr, false
+		}
+		newExpr.SetCoercibility(x.Coercibility())
+		return newExpr, true
+	}
+	return expr, false
+}
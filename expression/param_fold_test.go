@@ -0,0 +1,73 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"fmt"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/tidb/util/mock"
+)
+
+func (*testExpressionSuite) TestFoldParams(c *C) {
+	ctx := mock.NewContext()
+	deferred := func(v int64) *Constant {
+		return &Constant{Value: newLonglong(v).Value, RetType: newIntFieldType(), DeferredExpr: newLonglong(v)}
+	}
+
+	// The expressions without parameters are not copied.
+	exprs := []Expression{newFunction(ast.Plus, newColumn(0), newLonglong(1))}
+	folded := FoldParams(ctx, exprs)
+	c.Assert(&folded[0], Equals, &exprs[0])
+
+	exprs = []Expression{newFunction(ast.Plus, newColumn(0), newFunction(ast.Plus, deferred(1), deferred(2)))}
+	folded = FoldParams(ctx, exprs)
+	c.Assert(fmt.Sprint(folded), Equals, "[plus(Column#0, 3)]")
+	c.Assert(exprs[0].(*ScalarFunction).GetArgs()[1].(*Constant).DeferredExpr, NotNil)
+
+	tests := []struct {
+		conds       []Expression
+		result      string
+		alwaysFalse bool
+	}{
+		{
+			conds: []Expression{
+				newFunction(ast.LogicOr, newFunction(ast.GT, newColumn(0), deferred(2)), newFunction(ast.EQ, deferred(1), newLonglong(1))),
+			},
+			result: "[]",
+		},
+		{
+			conds: []Expression{
+				newFunction(ast.GT, newColumn(0), deferred(2)),
+				newFunction(ast.LogicOr, newFunction(ast.EQ, deferred(1), newLonglong(0)), newFunction(ast.LT, newColumn(1), deferred(3))),
+			},
+			result: "[gt(Column#0, 2) lt(Column#1, 3)]",
+		},
+		{
+			conds: []Expression{
+				newFunction(ast.GT, newColumn(0), deferred(2)),
+				newFunction(ast.EQ, deferred(1), newLonglong(0)),
+			},
+			alwaysFalse: true,
+		},
+	}
+	for _, t := range tests {
+		conds, alwaysFalse := FoldParamConditions(ctx, t.conds)
+		c.Assert(alwaysFalse, Equals, t.alwaysFalse)
+		if !alwaysFalse {
+			c.Assert(fmt.Sprint(conds), Equals, t.result)
+		}
+	}
+}
@@ -189,6 +189,11 @@ func newFunctionImpl(ctx sessionctx.Context, fold int, funcName string, retType
 		return BuildGetVarFunction(ctx, args[0], retType)
 	}
 	fc, ok := funcs[funcName]
+	if !ok {
+		if udf := getUDF(funcName); udf != nil {
+			fc, ok = newUDFFunctionClass(udf), true
+		}
+	}
 	if !ok {
 		db := ctx.GetSessionVars().CurrentDB
 		if db == "" {
@@ -247,6 +247,21 @@ var MetricTableMap = map[string]MetricTableDef{
 		PromQL:  "sum(rate(tidb_executor_expensive_total{$LABEL_CONDITIONS}[$RANGE_DURATION])) by (type,instance)",
 		Labels:  []string{"instance", "type"},
 	},
+	"tidb_operator_ops": {
+		Comment: "The executions of the TiDB operators per second",
+		PromQL:  "sum(rate(tidb_executor_operator_total{$LABEL_CONDITIONS}[$RANGE_DURATION])) by (type,instance)",
+		Labels:  []string{"instance", "type"},
+	},
+	"tidb_operator_rows": {
+		Comment: "The rows returned by the TiDB operators per second",
+		PromQL:  "sum(rate(tidb_executor_operator_rows_total{$LABEL_CONDITIONS}[$RANGE_DURATION])) by (type,instance)",
+		Labels:  []string{"instance", "type"},
+	},
+	"tidb_operator_duration": {
+		Comment: "The time spent by the TiDB operators per second, which is estimated by sampling(second)",
+		PromQL:  "sum(rate(tidb_executor_operator_duration_seconds_total{$LABEL_CONDITIONS}[$RANGE_DURATION])) by (type,instance)",
+		Labels:  []string{"instance", "type"},
+	},
 	"tidb_query_using_plan_cache_ops": {
 		PromQL:  "sum(rate(tidb_server_plan_cache_total{$LABEL_CONDITIONS}[$RANGE_DURATION])) by (type,instance)",
 		Labels:  []string{"instance", "type"},
@@ -31,6 +31,9 @@ var perfSchemaTables = []string{
 	tableStagesCurrent,
 	tableStagesHistory,
 	tableStagesHistoryLong,
+	tableWaitsCurrent,
+	tableWaitsHistory,
+	tableWaitsHistoryLong,
 	tableEventsStatementsSummaryByDigest,
 	tableTiDBProfileCPU,
 	tableTiDBProfileMemory,
@@ -381,6 +384,72 @@ const tableStagesHistoryLong = "CREATE TABLE if not exists performance_schema."
 	"NESTING_EVENT_ID		BIGINT(20) UNSIGNED," +
 	"NESTING_EVENT_TYPE		ENUM('TRANSACTION','STATEMENT','STAGE'));"
 
+// tableWaitsCurrent contains the column name definitions for table events_waits_current, same as MySQL.
+const tableWaitsCurrent = "CREATE TABLE if not exists performance_schema." + tableNameEventsWaitsCurrent + " (" +
+	"THREAD_ID		BIGINT(20) UNSIGNED NOT NULL," +
+	"EVENT_ID		BIGINT(20) UNSIGNED NOT NULL," +
+	"END_EVENT_ID	BIGINT(20) UNSIGNED," +
+	"EVENT_NAME		VARCHAR(128) NOT NULL," +
+	"SOURCE			VARCHAR(64)," +
+	"TIMER_START		BIGINT(20) UNSIGNED," +
+	"TIMER_END		BIGINT(20) UNSIGNED," +
+	"TIMER_WAIT		BIGINT(20) UNSIGNED," +
+	"SPINS			INT(10) UNSIGNED," +
+	"OBJECT_SCHEMA	VARCHAR(64)," +
+	"OBJECT_NAME		VARCHAR(512)," +
+	"INDEX_NAME		VARCHAR(64)," +
+	"OBJECT_TYPE		VARCHAR(64)," +
+	"OBJECT_INSTANCE_BEGIN	BIGINT(20) UNSIGNED NOT NULL," +
+	"NESTING_EVENT_ID		BIGINT(20) UNSIGNED," +
+	"NESTING_EVENT_TYPE		ENUM('TRANSACTION','STATEMENT','STAGE','WAIT')," +
+	"OPERATION		VARCHAR(32) NOT NULL," +
+	"NUMBER_OF_BYTES	BIGINT(20)," +
+	"FLAGS			INT(10) UNSIGNED);"
+
+// tableWaitsHistory contains the column name definitions for table events_waits_history, same as MySQL.
+const tableWaitsHistory = "CREATE TABLE if not exists performance_schema." + tableNameEventsWaitsHistory + " (" +
+	"THREAD_ID		BIGINT(20) UNSIGNED NOT NULL," +
+	"EVENT_ID		BIGINT(20) UNSIGNED NOT NULL," +
+	"END_EVENT_ID	BIGINT(20) UNSIGNED," +
+	"EVENT_NAME		VARCHAR(128) NOT NULL," +
+	"SOURCE			VARCHAR(64)," +
+	"TIMER_START		BIGINT(20) UNSIGNED," +
+	"TIMER_END		BIGINT(20) UNSIGNED," +
+	"TIMER_WAIT		BIGINT(20) UNSIGNED," +
+	"SPINS			INT(10) UNSIGNED," +
+	"OBJECT_SCHEMA	VARCHAR(64)," +
+	"OBJECT_NAME		VARCHAR(512)," +
+	"INDEX_NAME		VARCHAR(64)," +
+	"OBJECT_TYPE		VARCHAR(64)," +
+	"OBJECT_INSTANCE_BEGIN	BIGINT(20) UNSIGNED NOT NULL," +
+	"NESTING_EVENT_ID		BIGINT(20) UNSIGNED," +
+	"NESTING_EVENT_TYPE		ENUM('TRANSACTION','STATEMENT','STAGE','WAIT')," +
+	"OPERATION		VARCHAR(32) NOT NULL," +
+	"NUMBER_OF_BYTES	BIGINT(20)," +
+	"FLAGS			INT(10) UNSIGNED);"
+
+// tableWaitsHistoryLong contains the column name definitions for table events_waits_history_long, same as MySQL.
+const tableWaitsHistoryLong = "CREATE TABLE if not exists performance_schema." + tableNameEventsWaitsHistoryLong + " (" +
+	"THREAD_ID		BIGINT(20) UNSIGNED NOT NULL," +
+	"EVENT_ID		BIGINT(20) UNSIGNED NOT NULL," +
+	"END_EVENT_ID	BIGINT(20) UNSIGNED," +
+	"EVENT_NAME		VARCHAR(128) NOT NULL," +
+	"SOURCE			VARCHAR(64)," +
+	"TIMER_START		BIGINT(20) UNSIGNED," +
+	"TIMER_END		BIGINT(20) UNSIGNED," +
+	"TIMER_WAIT		BIGINT(20) UNSIGNED," +
+	"SPINS			INT(10) UNSIGNED," +
+	"OBJECT_SCHEMA	VARCHAR(64)," +
+	"OBJECT_NAME		VARCHAR(512)," +
+	"INDEX_NAME		VARCHAR(64)," +
+	"OBJECT_TYPE		VARCHAR(64)," +
+	"OBJECT_INSTANCE_BEGIN	BIGINT(20) UNSIGNED NOT NULL," +
+	"NESTING_EVENT_ID		BIGINT(20) UNSIGNED," +
+	"NESTING_EVENT_TYPE		ENUM('TRANSACTION','STATEMENT','STAGE','WAIT')," +
+	"OPERATION		VARCHAR(32) NOT NULL," +
+	"NUMBER_OF_BYTES	BIGINT(20)," +
+	"FLAGS			INT(10) UNSIGNED);"
+
 // tableEventsStatementsSummaryByDigest contains the column name definitions for table
 // events_statements_summary_by_digest, same as MySQL.
 const tableEventsStatementsSummaryByDigest = "CREATE TABLE if not exists performance_schema." + tableNameEventsStatementsSummaryByDigest + " (" +
@@ -0,0 +1,321 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package perfschema
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/tidb/privilege"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/types"
+)
+
+const (
+	// eventsHistorySize is the number of events kept for each thread in the events_*_history tables,
+	// same as the default performance_schema_events_statements_history_size of MySQL.
+	eventsHistorySize = 10
+	// eventsHistoryLongSize is the number of statements kept in the events_*_history_long tables,
+	// same as the default performance_schema_events_statements_history_long_size of MySQL.
+	eventsHistoryLongSize = 10000
+)
+
+// Stage names of the statements, the same as the instruments of MySQL.
+const (
+	StageParsing    = "stage/sql/parsing"
+	StageOptimizing = "stage/sql/optimizing"
+	StageExecuting  = "stage/sql/executing"
+)
+
+// Wait names of the statements. TiDB doesn't instrument the waits, so they are emulated by the time the statement
+// spends on locking keys, backing off and waiting in the queues of TiKV.
+const (
+	WaitLockKeys      = "wait/lock/tidb/lock_keys"
+	WaitBackoffPrefix = "wait/io/tikv/backoff/"
+	WaitTiKVQueue     = "wait/io/tikv/queue"
+)
+
+// serverStartTime is the base of the event timers, the timers are in picoseconds since the server starts like MySQL.
+var serverStartTime = time.Now()
+
+// eventIDAllocator allocates the event ids of the statements and stages.
+var eventIDAllocator uint64
+
+// StatementEvent is a finished statement shown in the events_statements_* tables.
+type StatementEvent struct {
+	// ThreadID is the connection id of the session which runs the statement.
+	ThreadID uint64
+	// User is the user of the session, the statements of the other users are only visible with the PROCESS privilege.
+	User          string
+	EventName     string
+	StartTime     time.Time
+	EndTime       time.Time
+	SQLText       string
+	Digest        string
+	DigestText    string
+	CurrentSchema string
+	ErrNo         uint16
+	SQLState      string
+	Message       string
+	Errors        uint64
+	Warnings      uint64
+	RowsAffected  uint64
+	RowsSent      uint64
+	RowsExamined  uint64
+	// Stages are the stages of the statement in order, they are shown in the events_stages_* tables.
+	Stages []StageEvent
+	// Waits are the waits of the statement nested in its last stage, they are shown in the events_waits_* tables.
+	Waits []WaitEvent
+
+	eventID uint64
+}
+
+// StageEvent is a stage of a statement.
+type StageEvent struct {
+	EventName string
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// WaitEvent is a wait of a statement.
+type WaitEvent struct {
+	EventName string
+	Operation string
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// AddWait adds a wait lasting for d. The waits are not timed when they happen, so the wait is put at the end of the
+// last stage of the statement.
+func (e *StatementEvent) AddWait(eventName, operation string, d time.Duration) {
+	if d <= 0 || len(e.Stages) == 0 {
+		return
+	}
+	stage := e.Stages[len(e.Stages)-1]
+	startTime := stage.EndTime.Add(-d)
+	if startTime.Before(stage.StartTime) {
+		startTime = stage.StartTime
+	}
+	e.Waits = append(e.Waits, WaitEvent{EventName: eventName, Operation: operation, StartTime: startTime, EndTime: stage.EndTime})
+}
+
+// nestedEvents returns the number of the events nested in the statement.
+func (e *StatementEvent) nestedEvents() uint64 {
+	return uint64(len(e.Stages) + len(e.Waits))
+}
+
+// endEventID returns the id of the last event nested in the statement.
+func (e *StatementEvent) endEventID() uint64 {
+	return e.eventID + e.nestedEvents()
+}
+
+// eventHistory is a ring buffer of the latest statement events.
+type eventHistory struct {
+	sync.RWMutex
+	events []*StatementEvent
+	// next is the position of the next event, it wraps around when the buffer is full.
+	next int
+	full bool
+}
+
+var stmtEvents = newEventHistory(eventsHistoryLongSize)
+
+func newEventHistory(capacity int) *eventHistory {
+	return &eventHistory{events: make([]*StatementEvent, capacity)}
+}
+
+// RecordStatementEvent records a finished statement.
+func RecordStatementEvent(e *StatementEvent) {
+	stmtEvents.add(e)
+}
+
+func (h *eventHistory) add(e *StatementEvent) {
+	// The statement, its stages and its waits take consecutive ids.
+	e.eventID = atomic.AddUint64(&eventIDAllocator, e.nestedEvents()+1) - e.nestedEvents()
+	h.Lock()
+	h.events[h.next] = e
+	h.next++
+	if h.next == len(h.events) {
+		h.next, h.full = 0, true
+	}
+	h.Unlock()
+}
+
+// visibleStatementEvents returns the statement events visible to the user from the oldest to the latest, the
+// statements of the other users are only visible to the users with the PROCESS privilege.
+func visibleStatementEvents(ctx sessionctx.Context) []*StatementEvent {
+	events := stmtEvents.all()
+	loginUser := ctx.GetSessionVars().User
+	if loginUser == nil {
+		return events
+	}
+	if pm := privilege.GetPrivilegeManager(ctx); pm != nil && pm.RequestVerification(ctx.GetSessionVars().ActiveRoles, "", "", "", mysql.ProcessPriv) {
+		return events
+	}
+	visible := events[:0]
+	for _, e := range events {
+		if e.User == loginUser.Username {
+			visible = append(visible, e)
+		}
+	}
+	return visible
+}
+
+// all returns the events from the oldest to the latest.
+func (h *eventHistory) all() []*StatementEvent {
+	h.RLock()
+	defer h.RUnlock()
+	if !h.full {
+		return append([]*StatementEvent(nil), h.events[:h.next]...)
+	}
+	events := make([]*StatementEvent, 0, len(h.events))
+	events = append(events, h.events[h.next:]...)
+	return append(events, h.events[:h.next]...)
+}
+
+// latestOfThreads returns the latest n events of each thread, from the oldest to the latest.
+func latestOfThreads(events []*StatementEvent, n int) []*StatementEvent {
+	counts := make(map[uint64]int)
+	picked := make([]*StatementEvent, 0, len(events))
+	for i := len(events) - 1; i >= 0; i-- {
+		if counts[events[i].ThreadID] < n {
+			counts[events[i].ThreadID]++
+			picked = append(picked, events[i])
+		}
+	}
+	for i, j := 0, len(picked)-1; i < j; i, j = i+1, j-1 {
+		picked[i], picked[j] = picked[j], picked[i]
+	}
+	return picked
+}
+
+func eventTimer(t time.Time) types.Datum {
+	return types.NewUintDatum(uint64(t.Sub(serverStartTime).Nanoseconds()) * 1000)
+}
+
+func nullableStringDatum(s string) types.Datum {
+	if len(s) == 0 {
+		return types.Datum{}
+	}
+	return types.NewStringDatum(s)
+}
+
+// statementRows builds the rows of the events_statements_* tables.
+func statementRows(cols []*table.Column, events []*StatementEvent) [][]types.Datum {
+	rows := make([][]types.Datum, 0, len(events))
+	for _, e := range events {
+		values := map[string]types.Datum{
+			"thread_id":      types.NewUintDatum(e.ThreadID),
+			"event_id":       types.NewUintDatum(e.eventID),
+			"end_event_id":   types.NewUintDatum(e.endEventID()),
+			"event_name":     types.NewStringDatum(e.EventName),
+			"timer_start":    eventTimer(e.StartTime),
+			"timer_end":      eventTimer(e.EndTime),
+			"timer_wait":     types.NewUintDatum(uint64(e.EndTime.Sub(e.StartTime).Nanoseconds()) * 1000),
+			"sql_text":       types.NewStringDatum(e.SQLText),
+			"digest":         nullableStringDatum(e.Digest),
+			"digest_text":    nullableStringDatum(e.DigestText),
+			"current_schema": nullableStringDatum(e.CurrentSchema),
+			"errors":         types.NewUintDatum(e.Errors),
+			"warnings":       types.NewUintDatum(e.Warnings),
+			"rows_affected":  types.NewUintDatum(e.RowsAffected),
+			"rows_sent":      types.NewUintDatum(e.RowsSent),
+			"rows_examined":  types.NewUintDatum(e.RowsExamined),
+		}
+		if e.ErrNo > 0 {
+			values["mysql_errno"] = types.NewIntDatum(int64(e.ErrNo))
+			values["returned_sqlstate"] = types.NewStringDatum(e.SQLState)
+			values["message_text"] = types.NewStringDatum(e.Message)
+		}
+		rows = append(rows, eventRow(cols, values))
+	}
+	return rows
+}
+
+// stageRows builds the rows of the events_stages_* tables, only the latest n stages of each thread are kept.
+func stageRows(cols []*table.Column, events []*StatementEvent, n int) [][]types.Datum {
+	counts := make(map[uint64]int)
+	var rows [][]types.Datum
+	for i := len(events) - 1; i >= 0; i-- {
+		e := events[i]
+		for j := len(e.Stages) - 1; j >= 0 && counts[e.ThreadID] < n; j-- {
+			counts[e.ThreadID]++
+			stage := e.Stages[j]
+			eventID := e.eventID + uint64(j) + 1
+			rows = append(rows, eventRow(cols, map[string]types.Datum{
+				"thread_id":          types.NewUintDatum(e.ThreadID),
+				"event_id":           types.NewUintDatum(eventID),
+				"end_event_id":       types.NewUintDatum(eventID),
+				"event_name":         types.NewStringDatum(stage.EventName),
+				"timer_start":        eventTimer(stage.StartTime),
+				"timer_end":          eventTimer(stage.EndTime),
+				"timer_wait":         types.NewUintDatum(uint64(stage.EndTime.Sub(stage.StartTime).Nanoseconds()) * 1000),
+				"nesting_event_id":   types.NewUintDatum(e.eventID),
+				"nesting_event_type": types.NewMysqlEnumDatum(types.Enum{Name: "STATEMENT", Value: 2}),
+			}))
+		}
+	}
+	for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+		rows[i], rows[j] = rows[j], rows[i]
+	}
+	return rows
+}
+
+// waitRows builds the rows of the events_waits_* tables, only the latest n waits of each thread are kept.
+func waitRows(cols []*table.Column, events []*StatementEvent, n int) [][]types.Datum {
+	counts := make(map[uint64]int)
+	var rows [][]types.Datum
+	for i := len(events) - 1; i >= 0; i-- {
+		e := events[i]
+		// The waits are nested in the last stage.
+		stageEventID := e.eventID + uint64(len(e.Stages))
+		for j := len(e.Waits) - 1; j >= 0 && counts[e.ThreadID] < n; j-- {
+			counts[e.ThreadID]++
+			wait := e.Waits[j]
+			eventID := stageEventID + uint64(j) + 1
+			rows = append(rows, eventRow(cols, map[string]types.Datum{
+				"thread_id":          types.NewUintDatum(e.ThreadID),
+				"event_id":           types.NewUintDatum(eventID),
+				"end_event_id":       types.NewUintDatum(eventID),
+				"event_name":         types.NewStringDatum(wait.EventName),
+				"timer_start":        eventTimer(wait.StartTime),
+				"timer_end":          eventTimer(wait.EndTime),
+				"timer_wait":         types.NewUintDatum(uint64(wait.EndTime.Sub(wait.StartTime).Nanoseconds()) * 1000),
+				"nesting_event_id":   types.NewUintDatum(stageEventID),
+				"nesting_event_type": types.NewMysqlEnumDatum(types.Enum{Name: "STAGE", Value: 3}),
+				"operation":          types.NewStringDatum(wait.Operation),
+			}))
+		}
+	}
+	for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+		rows[i], rows[j] = rows[j], rows[i]
+	}
+	return rows
+}
+
+// eventRow fills the row in the order of the columns, the counters missing in values are 0 and others are NULL.
+func eventRow(cols []*table.Column, values map[string]types.Datum) []types.Datum {
+	row := make([]types.Datum, len(cols))
+	for i, col := range cols {
+		if v, ok := values[col.Name.L]; ok {
+			row[i] = v
+		} else if col.Tp == mysql.TypeLonglong && mysql.HasNotNullFlag(col.Flag) {
+			row[i] = types.NewUintDatum(0)
+		}
+	}
+	return row
+}
@@ -67,6 +67,9 @@ const (
 	tableNamePDProfileAllocs                 = "pd_profile_allocs"
 	tableNamePDProfileBlock                  = "pd_profile_block"
 	tableNamePDProfileGoroutines             = "pd_profile_goroutines"
+	tableNameEventsWaitsCurrent              = "events_waits_current"
+	tableNameEventsWaitsHistory              = "events_waits_history"
+	tableNameEventsWaitsHistoryLong          = "events_waits_history_long"
 )
 
 var tableIDMap = map[string]int64{
@@ -100,6 +103,9 @@ var tableIDMap = map[string]int64{
 	tableNamePDProfileAllocs:                 autoid.PerformanceSchemaDBID + 28,
 	tableNamePDProfileBlock:                  autoid.PerformanceSchemaDBID + 29,
 	tableNamePDProfileGoroutines:             autoid.PerformanceSchemaDBID + 30,
+	tableNameEventsWaitsCurrent:              autoid.PerformanceSchemaDBID + 31,
+	tableNameEventsWaitsHistory:              autoid.PerformanceSchemaDBID + 32,
+	tableNameEventsWaitsHistoryLong:          autoid.PerformanceSchemaDBID + 33,
 }
 
 // perfSchemaTable stands for the fake table all its data is in the memory.
@@ -213,6 +219,24 @@ func initTableIndices(t *perfSchemaTable) error {
 
 func (vt *perfSchemaTable) getRows(ctx sessionctx.Context, cols []*table.Column) (fullRows [][]types.Datum, err error) {
 	switch vt.meta.Name.O {
+	case tableNameEventsStatementsCurrent:
+		fullRows = statementRows(vt.cols, latestOfThreads(visibleStatementEvents(ctx), 1))
+	case tableNameEventsStatementsHistory:
+		fullRows = statementRows(vt.cols, latestOfThreads(visibleStatementEvents(ctx), eventsHistorySize))
+	case tableNameEventsStatementsHistoryLong:
+		fullRows = statementRows(vt.cols, visibleStatementEvents(ctx))
+	case tableNameEventsStagesCurrent:
+		fullRows = stageRows(vt.cols, visibleStatementEvents(ctx), 1)
+	case tableNameEventsStagesHistory:
+		fullRows = stageRows(vt.cols, visibleStatementEvents(ctx), eventsHistorySize)
+	case tableNameEventsStagesHistoryLong:
+		fullRows = stageRows(vt.cols, visibleStatementEvents(ctx), eventsHistoryLongSize)
+	case tableNameEventsWaitsCurrent:
+		fullRows = waitRows(vt.cols, visibleStatementEvents(ctx), 1)
+	case tableNameEventsWaitsHistory:
+		fullRows = waitRows(vt.cols, visibleStatementEvents(ctx), eventsHistorySize)
+	case tableNameEventsWaitsHistoryLong:
+		fullRows = waitRows(vt.cols, visibleStatementEvents(ctx), eventsHistoryLongSize)
 	case tableNameTiDBProfileCPU:
 		fullRows, err = (&profile.Collector{}).ProfileGraph("cpu")
 	case tableNameTiDBProfileMemory:
@@ -24,9 +24,11 @@ import (
 	"runtime/pprof"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/failpoint"
+	"github.com/pingcap/parser/auth"
 	"github.com/pingcap/parser/terror"
 	"github.com/pingcap/tidb/domain"
 	"github.com/pingcap/tidb/infoschema/perfschema"
@@ -79,6 +81,79 @@ func (s *testTableSuite) TestPerfSchemaTables(c *C) {
 	tk.MustQuery("select * from session_status where variable_name = 'Ssl_verify_mode'").Check(testkit.Rows())
 	tk.MustQuery("select * from setup_actors").Check(testkit.Rows())
 	tk.MustQuery("select * from events_stages_history_long").Check(testkit.Rows())
+	tk.MustQuery("select * from events_waits_history_long").Check(testkit.Rows())
+}
+
+func (s *testTableSuite) TestStmtEvents(c *C) {
+	tk := testkit.NewTestKitWithInit(c, s.store)
+	c.Assert(tk.Se.Auth(&auth.UserIdentity{Username: "root", Hostname: "%"}, nil, nil), IsTrue)
+	tk.Se.GetSessionVars().ConnectionID = 12345
+	tk.MustExec("set global tidb_enable_stmt_events = 1")
+	defer tk.MustExec("set global tidb_enable_stmt_events = 0")
+
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t_stmt_events")
+	tk.MustExec("create table t_stmt_events(a int primary key)")
+	tk.MustExec("insert into t_stmt_events values (1), (2)")
+	tk.MustQuery("select * from t_stmt_events").Check(testkit.Rows("1", "2"))
+	_, err := tk.Exec("insert into t_stmt_events values (1)")
+	c.Assert(err, NotNil)
+
+	tk.MustQuery("select event_name, rows_affected, rows_sent, mysql_errno from performance_schema.events_statements_history where thread_id = 12345").Check(testkit.Rows(
+		"statement/sql/set 0 0 <nil>",
+		"statement/sql/use 0 0 <nil>",
+		"statement/sql/droptable 0 0 <nil>",
+		"statement/sql/createtable 0 0 <nil>",
+		"statement/sql/insert 2 0 <nil>",
+		"statement/sql/select 0 2 <nil>",
+		"statement/sql/insert 0 0 1062",
+	))
+	tk.MustQuery("select event_name from performance_schema.events_statements_current where thread_id = 12345").Check(testkit.Rows(
+		"statement/sql/select",
+	))
+	tk.MustQuery("select s.event_name from performance_schema.events_stages_current s where s.thread_id = 12345").Check(testkit.Rows(
+		"stage/sql/executing",
+	))
+	tk.MustQuery("select count(*) from performance_schema.events_stages_history where thread_id = 12345 and nesting_event_type = 'STATEMENT'").Check(testkit.Rows(
+		"10",
+	))
+
+	// The time waiting for the lock of the other transaction is shown as a wait nested in the executing stage.
+	tk2 := testkit.NewTestKitWithInit(c, s.store)
+	tk2.MustExec("begin pessimistic")
+	tk2.MustQuery("select * from test.t_stmt_events where a = 1 for update").Check(testkit.Rows("1"))
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		tk2.MustExec("commit")
+	}()
+	tk.MustExec("begin pessimistic")
+	tk.MustQuery("select * from t_stmt_events where a = 1 for update").Check(testkit.Rows("1"))
+	tk.MustExec("commit")
+	tk.MustQuery(`select w.event_name, w.operation, w.timer_wait > 0, w.timer_end = s.timer_end, w.event_id <= t.end_event_id
+		from performance_schema.events_waits_history w
+		join performance_schema.events_stages_history s on w.nesting_event_id = s.event_id and s.thread_id = 12345
+		join performance_schema.events_statements_history t on s.nesting_event_id = t.event_id and t.thread_id = 12345
+		where w.thread_id = 12345 and w.nesting_event_type = 'STAGE' and t.sql_text like 'select % for update'`).Check(testkit.Rows(
+		"wait/lock/tidb/lock_keys lock 1 1 1",
+	))
+	tk.MustQuery("select event_name from performance_schema.events_waits_current where thread_id = 12345").Check(testkit.Rows(
+		"wait/lock/tidb/lock_keys",
+	))
+
+	// The statements of the other users are only visible with the PROCESS privilege.
+	tk.MustExec("drop user if exists 'stmt_events_user'@'%'")
+	tk.MustExec("create user 'stmt_events_user'@'%'")
+	defer tk.MustExec("drop user 'stmt_events_user'@'%'")
+	tk1 := testkit.NewTestKitWithInit(c, s.store)
+	c.Assert(tk1.Se.Auth(&auth.UserIdentity{Username: "stmt_events_user", Hostname: "%"}, nil, nil), IsTrue)
+	tk1.MustQuery("select count(*) from performance_schema.events_statements_history_long where thread_id = 12345").Check(testkit.Rows("0"))
+	tk1.MustQuery("select count(*) from performance_schema.events_stages_history_long where thread_id = 12345").Check(testkit.Rows("0"))
+	tk1.MustQuery("select count(*) from performance_schema.events_waits_history_long where thread_id = 12345").Check(testkit.Rows("0"))
+	tk1.MustQuery("select count(*) > 0 from performance_schema.events_statements_history_long where sql_text like 'select count(*) from performance_schema.events_statements%'").Check(testkit.Rows("1"))
+	tk.MustExec("grant process on *.* to 'stmt_events_user'@'%'")
+	tk1.MustQuery("select count(*) > 0 from performance_schema.events_statements_history_long where thread_id = 12345").Check(testkit.Rows("1"))
+	tk1.MustQuery("select count(*) > 0 from performance_schema.events_stages_history_long where thread_id = 12345").Check(testkit.Rows("1"))
+	tk1.MustQuery("select count(*) > 0 from performance_schema.events_waits_history_long where thread_id = 12345").Check(testkit.Rows("1"))
 }
 
 func currentSourceDir() string {
@@ -93,8 +93,9 @@ const (
 	tableGlobalStatus    = "GLOBAL_STATUS"
 	tableGlobalVariables = "GLOBAL_VARIABLES"
 	tableSessionStatus   = "SESSION_STATUS"
-	tableOptimizerTrace  = "OPTIMIZER_TRACE"
-	tableTableSpaces     = "TABLESPACES"
+	// TableOptimizerTrace is the string constant of the optimizer trace table.
+	TableOptimizerTrace = "OPTIMIZER_TRACE"
+	tableTableSpaces    = "TABLESPACES"
 	// TableCollationCharacterSetApplicability is the string constant of infoschema memory table.
 	TableCollationCharacterSetApplicability = "COLLATION_CHARACTER_SET_APPLICABILITY"
 	// TableProcesslist is the string constant of infoschema table.
@@ -171,6 +172,27 @@ const (
 	TableDeadlocks = "DEADLOCKS"
 	// TableDataLockWaits is current lock waiting status table.
 	TableDataLockWaits = "DATA_LOCK_WAITS"
+	// TableDataLockWaitHistory is the table of the recent lock waits ended on the TiDB node.
+	TableDataLockWaitHistory = "DATA_LOCK_WAIT_HISTORY"
+	// TablePlanCaptures is the string constant of the captured plan replayer bundles table.
+	TablePlanCaptures = "PLAN_CAPTURES"
+	// TableTableTraffic is the string constant of the per-table traffic table.
+	TableTableTraffic = "TABLE_TRAFFIC"
+	// TableAutoAnalyzeQueue is the string constant of the auto analyze queue table.
+	TableAutoAnalyzeQueue = "AUTO_ANALYZE_QUEUE"
+	// TableStatsJSON is the string constant of the table statistics dumped as JSON.
+	TableStatsJSON = "STATS_JSON"
+	// TableRunawayQueries is the string constant of the table of the actions taken on the runaway queries.
+	TableRunawayQueries = "RUNAWAY_QUERIES"
+	// TableSessionConnectAttrs is the string constant of the table of the connection attributes of the sessions.
+	TableSessionConnectAttrs = "SESSION_CONNECT_ATTRS"
+	// TableClusterMemoryUsage is the string constant of the table of the memory usage of the TiDB instances.
+	TableClusterMemoryUsage = "CLUSTER_MEMORY_USAGE"
+	// TableClusterThreads is the string constant of the table of the goroutines and threads of the TiDB instances.
+	TableClusterThreads = "CLUSTER_THREADS"
+	// TableClusterRunningTasks is the string constant of the table of the cop and MPP tasks being handled by the TiDB
+	// instances.
+	TableClusterRunningTasks = "CLUSTER_RUNNING_TASKS"
 )
 
 var tableIDMap = map[string]int64{
@@ -203,7 +225,7 @@ var tableIDMap = map[string]int64{
 	tableGlobalStatus:                       autoid.InformationSchemaDBID + 27,
 	tableGlobalVariables:                    autoid.InformationSchemaDBID + 28,
 	tableSessionStatus:                      autoid.InformationSchemaDBID + 29,
-	tableOptimizerTrace:                     autoid.InformationSchemaDBID + 30,
+	TableOptimizerTrace:                     autoid.InformationSchemaDBID + 30,
 	tableTableSpaces:                        autoid.InformationSchemaDBID + 31,
 	TableCollationCharacterSetApplicability: autoid.InformationSchemaDBID + 32,
 	TableProcesslist:                        autoid.InformationSchemaDBID + 33,
@@ -249,6 +271,16 @@ var tableIDMap = map[string]int64{
 	ClusterTableDeadlocks:                   autoid.InformationSchemaDBID + 73,
 	TableDataLockWaits:                      autoid.InformationSchemaDBID + 74,
 	TableStatementsSummaryEvicted:           autoid.InformationSchemaDBID + 75,
+	TablePlanCaptures:                       autoid.InformationSchemaDBID + 76,
+	TableTableTraffic:                       autoid.InformationSchemaDBID + 77,
+	TableAutoAnalyzeQueue:                   autoid.InformationSchemaDBID + 79,
+	TableStatsJSON:                          autoid.InformationSchemaDBID + 80,
+	TableRunawayQueries:                     autoid.InformationSchemaDBID + 81,
+	TableSessionConnectAttrs:                autoid.InformationSchemaDBID + 82,
+	TableDataLockWaitHistory:                autoid.InformationSchemaDBID + 83,
+	TableClusterMemoryUsage:                 autoid.InformationSchemaDBID + 84,
+	TableClusterThreads:                     autoid.InformationSchemaDBID + 85,
+	TableClusterRunningTasks:                autoid.InformationSchemaDBID + 86,
 }
 
 type columnInfo struct {
@@ -941,6 +973,28 @@ var tableClusterConfigCols = []columnInfo{
 	{name: "VALUE", tp: mysql.TypeVarchar, size: 128},
 }
 
+var tableClusterMemoryUsageCols = []columnInfo{
+	{name: "TYPE", tp: mysql.TypeVarchar, size: 64},
+	{name: "INSTANCE", tp: mysql.TypeVarchar, size: 64},
+	{name: "NAME", tp: mysql.TypeVarchar, size: 64},
+	{name: "BYTES", tp: mysql.TypeLonglong, size: 21, flag: mysql.UnsignedFlag},
+}
+
+var tableClusterThreadsCols = []columnInfo{
+	{name: "TYPE", tp: mysql.TypeVarchar, size: 64},
+	{name: "INSTANCE", tp: mysql.TypeVarchar, size: 64},
+	{name: "GOROUTINES", tp: mysql.TypeLonglong, size: 21},
+	{name: "THREADS", tp: mysql.TypeLonglong, size: 21},
+	{name: "GOMAXPROCS", tp: mysql.TypeLonglong, size: 21},
+}
+
+var tableClusterRunningTasksCols = []columnInfo{
+	{name: "TYPE", tp: mysql.TypeVarchar, size: 64},
+	{name: "INSTANCE", tp: mysql.TypeVarchar, size: 64},
+	{name: "TASK_TYPE", tp: mysql.TypeVarchar, size: 16},
+	{name: "COUNT", tp: mysql.TypeLonglong, size: 21},
+}
+
 var tableClusterLogCols = []columnInfo{
 	{name: "TIME", tp: mysql.TypeVarchar, size: 32},
 	{name: "TYPE", tp: mysql.TypeVarchar, size: 64},
@@ -1216,6 +1270,13 @@ var tableStatementsSummaryCols = []columnInfo{
 	{name: "PREV_SAMPLE_TEXT", tp: mysql.TypeBlob, size: types.UnspecifiedLength, comment: "The previous statement before commit"},
 	{name: "PLAN_DIGEST", tp: mysql.TypeVarchar, size: 64, comment: "Digest of its execution plan"},
 	{name: "PLAN", tp: mysql.TypeBlob, size: types.UnspecifiedLength, comment: "Sampled execution plan"},
+	{name: "P50_LATENCY", tp: mysql.TypeLonglong, size: 20, flag: mysql.NotNullFlag | mysql.UnsignedFlag, comment: "Estimated median latency of these statements"},
+	{name: "P95_LATENCY", tp: mysql.TypeLonglong, size: 20, flag: mysql.NotNullFlag | mysql.UnsignedFlag, comment: "Estimated 95th percentile latency of these statements"},
+	{name: "P99_LATENCY", tp: mysql.TypeLonglong, size: 20, flag: mysql.NotNullFlag | mysql.UnsignedFlag, comment: "Estimated 99th percentile latency of these statements"},
+	{name: "AVG_COP_TIME", tp: mysql.TypeLonglong, size: 20, flag: mysql.NotNullFlag | mysql.UnsignedFlag, comment: "Average time of waiting for coprocessor responses in TiDB"},
+	{name: "MAX_COP_TIME", tp: mysql.TypeLonglong, size: 20, flag: mysql.NotNullFlag | mysql.UnsignedFlag, comment: "Max time of waiting for coprocessor responses in TiDB"},
+	{name: "AVG_MPP_TIME", tp: mysql.TypeLonglong, size: 20, flag: mysql.NotNullFlag | mysql.UnsignedFlag, comment: "Average time of waiting for MPP responses in TiDB"},
+	{name: "MAX_MPP_TIME", tp: mysql.TypeLonglong, size: 20, flag: mysql.NotNullFlag | mysql.UnsignedFlag, comment: "Max time of waiting for MPP responses in TiDB"},
 }
 
 var tableStorageStatsCols = []columnInfo{
@@ -1383,6 +1444,70 @@ var tableDataLockWaitsCols = []columnInfo{
 	{name: "SQL_DIGEST", tp: mysql.TypeVarchar, size: 64, comment: "Digest of the SQL that's trying to acquire the lock"},
 }
 
+var tableDataLockWaitHistoryCols = []columnInfo{
+	{name: "OCCUR_TIME", tp: mysql.TypeTimestamp, decimal: 6, size: 26, comment: "The physical time when the lock wait ends"},
+	{name: "WAIT_TIME", tp: mysql.TypeDouble, size: 22, flag: mysql.NotNullFlag, comment: "The time waiting for the lock in seconds"},
+	{name: "TRX_ID", tp: mysql.TypeLonglong, size: 21, flag: mysql.NotNullFlag | mysql.UnsignedFlag, comment: "The transaction that waited for the lock"},
+	{name: "SQL_DIGEST", tp: mysql.TypeVarchar, size: 64, comment: "Digest of the SQL that tried to acquire the lock"},
+	{name: "KEY", tp: mysql.TypeBlob, size: types.UnspecifiedLength, comment: "The key waited on, only known if the wait ends with a deadlock"},
+	{name: "TRX_HOLDING_LOCK", tp: mysql.TypeLonglong, size: 21, flag: mysql.UnsignedFlag, comment: "The transaction holding the lock, only known if the wait ends with a deadlock"},
+	{name: "RESULT", tp: mysql.TypeVarchar, size: 16, flag: mysql.NotNullFlag, comment: "ACQUIRED, TIMEOUT, DEADLOCK or ERROR"},
+}
+
+var tablePlanCapturesCols = []columnInfo{
+	{name: "DIGEST", tp: mysql.TypeVarchar, size: 64, flag: mysql.NotNullFlag, comment: "Digest of the SQL flagged for capture"},
+	{name: "STATUS", tp: mysql.TypeVarchar, size: 16, flag: mysql.NotNullFlag, comment: "PENDING if the SQL is not executed yet, otherwise CAPTURED"},
+	{name: "FILE_NAME", tp: mysql.TypeVarchar, size: 256, comment: "File name of the bundle in the capture directory"},
+	{name: "CAPTURE_TIME", tp: mysql.TypeDatetime, size: 26, decimal: 6, comment: "Time when the bundle is captured"},
+	{name: "SIZE", tp: mysql.TypeLonglong, size: 21, comment: "Size of the bundle in bytes"},
+}
+
+var tableTableTrafficCols = []columnInfo{
+	{name: "TABLE_SCHEMA", tp: mysql.TypeVarchar, size: 64},
+	{name: "TABLE_NAME", tp: mysql.TypeVarchar, size: 64},
+	{name: "PARTITION_NAME", tp: mysql.TypeVarchar, size: 64},
+	{name: "TABLE_ID", tp: mysql.TypeLonglong, size: 21},
+	{name: "READ_KEYS", tp: mysql.TypeLonglong, size: 21, comment: "Keys read by the coprocessor requests since TiDB started"},
+	{name: "READ_BYTES", tp: mysql.TypeLonglong, size: 21, comment: "Bytes returned by the coprocessor requests since TiDB started"},
+	{name: "WRITE_KEYS", tp: mysql.TypeLonglong, size: 21, comment: "Keys written by the committed transactions since TiDB started"},
+	{name: "WRITE_BYTES", tp: mysql.TypeLonglong, size: 21, comment: "Bytes of the keys and values written by the committed transactions since TiDB started"},
+}
+
+var tableAutoAnalyzeQueueCols = []columnInfo{
+	{name: "TABLE_SCHEMA", tp: mysql.TypeVarchar, size: 64},
+	{name: "TABLE_NAME", tp: mysql.TypeVarchar, size: 64},
+	{name: "PARTITION_NAME", tp: mysql.TypeVarchar, size: 256, comment: "Comma separated names of the partitions to be analyzed"},
+	{name: "STATE", tp: mysql.TypeVarchar, size: 16, comment: "pending or running"},
+	{name: "PRIORITY", tp: mysql.TypeDouble, size: 22, comment: "The jobs with higher priority are executed first"},
+	{name: "TABLE_ROWS", tp: mysql.TypeLonglong, size: 21},
+	{name: "MODIFY_COUNT", tp: mysql.TypeLonglong, size: 21},
+	{name: "READ_KEYS", tp: mysql.TypeLonglong, size: 21, comment: "Keys read from the table since TiDB started"},
+	{name: "REASON", tp: mysql.TypeVarchar, size: 256},
+}
+
+var tableStatsJSONCols = []columnInfo{
+	{name: "TABLE_SCHEMA", tp: mysql.TypeVarchar, size: 64},
+	{name: "TABLE_NAME", tp: mysql.TypeVarchar, size: 64},
+	{name: "STATS_JSON", tp: mysql.TypeLongBlob, size: types.UnspecifiedLength, comment: "The statistics of the table in the format of LOAD STATS"},
+}
+
+var tableRunawayQueriesCols = []columnInfo{
+	{name: "TIME", tp: mysql.TypeDatetime, size: 26, decimal: 6, flag: mysql.NotNullFlag, comment: "Time when the action is taken"},
+	{name: "CONN_ID", tp: mysql.TypeLonglong, size: 21, flag: mysql.UnsignedFlag},
+	{name: "USER", tp: mysql.TypeVarchar, size: 64},
+	{name: "DB", tp: mysql.TypeVarchar, size: 64},
+	{name: "RESOURCE_GROUP", tp: mysql.TypeVarchar, size: 64},
+	{name: "RULE", tp: mysql.TypeVarchar, size: 128, comment: "The broken rule"},
+	{name: "ACTION", tp: mysql.TypeVarchar, size: 16, comment: "log, deprioritize or kill"},
+	{name: "QUERY", tp: mysql.TypeLongBlob, size: types.UnspecifiedLength},
+}
+
+var tableSessionConnectAttrsCols = []columnInfo{
+	{name: "PROCESSLIST_ID", tp: mysql.TypeLonglong, size: 21, flag: mysql.NotNullFlag | mysql.UnsignedFlag},
+	{name: "ATTR_NAME", tp: mysql.TypeVarchar, size: 32, flag: mysql.NotNullFlag},
+	{name: "ATTR_VALUE", tp: mysql.TypeVarchar, size: 1024},
+}
+
 var tableStatementsSummaryEvictedCols = []columnInfo{
 	{name: "BEGIN_TIME", tp: mysql.TypeTimestamp, size: 26},
 	{name: "END_TIME", tp: mysql.TypeTimestamp, size: 26},
@@ -1722,7 +1847,7 @@ var tableNameToColumns = map[string][]columnInfo{
 	tableGlobalStatus:                       tableGlobalStatusCols,
 	tableGlobalVariables:                    tableGlobalVariablesCols,
 	tableSessionStatus:                      tableSessionStatusCols,
-	tableOptimizerTrace:                     tableOptimizerTraceCols,
+	TableOptimizerTrace:                     tableOptimizerTraceCols,
 	tableTableSpaces:                        tableTableSpacesCols,
 	TableCollationCharacterSetApplicability: tableCollationCharacterSetApplicabilityCols,
 	TableProcesslist:                        tableProcesslistCols,
@@ -1762,6 +1887,16 @@ var tableNameToColumns = map[string][]columnInfo{
 	TableTiDBTrx:                            tableTiDBTrxCols,
 	TableDeadlocks:                          tableDeadlocksCols,
 	TableDataLockWaits:                      tableDataLockWaitsCols,
+	TableDataLockWaitHistory:                tableDataLockWaitHistoryCols,
+	TablePlanCaptures:                       tablePlanCapturesCols,
+	TableTableTraffic:                       tableTableTrafficCols,
+	TableAutoAnalyzeQueue:                   tableAutoAnalyzeQueueCols,
+	TableStatsJSON:                          tableStatsJSONCols,
+	TableRunawayQueries:                     tableRunawayQueriesCols,
+	TableSessionConnectAttrs:                tableSessionConnectAttrsCols,
+	TableClusterMemoryUsage:                 tableClusterMemoryUsageCols,
+	TableClusterThreads:                     tableClusterThreadsCols,
+	TableClusterRunningTasks:                tableClusterRunningTasksCols,
 }
 
 func createInfoSchemaTable(_ autoid.Allocators, meta *model.TableInfo) (table.Table, error) {
@@ -1815,7 +1950,7 @@ func (it *infoschemaTable) getRows(ctx sessionctx.Context, cols []*table.Column)
 	case tableGlobalStatus:
 	case tableGlobalVariables:
 	case tableSessionStatus:
-	case tableOptimizerTrace:
+	case TableOptimizerTrace:
 	case tableTableSpaces:
 	}
 	if err != nil {
@@ -53,6 +53,7 @@ import (
 	"github.com/pingcap/tidb/store/mockstore/unistore"
 	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/kvcache"
+	"github.com/pingcap/tidb/util/lockwaithistory"
 	"github.com/pingcap/tidb/util/pdapi"
 	"github.com/pingcap/tidb/util/resourcegrouptag"
 	"github.com/pingcap/tidb/util/set"
@@ -1668,6 +1669,40 @@ func (s *testTableSuite) TestInfoschemaDeadlockPrivilege(c *C) {
 	_ = tk.MustQuery("select * from information_schema.deadlocks")
 }
 
+func (s *testTableSuite) TestDataLockWaitHistory(c *C) {
+	lockwaithistory.GlobalLockWaitHistory.Resize(2)
+	defer func() {
+		lockwaithistory.GlobalLockWaitHistory.Clear()
+		lockwaithistory.GlobalLockWaitHistory.Resize(0)
+	}()
+	_, digest := parser.NormalizeDigest("select * from t1 for update;")
+	occurTime := time.Date(2021, 05, 20, 13, 18, 30, 123456000, time.Local)
+	lockwaithistory.GlobalLockWaitHistory.Push(&lockwaithistory.LockWaitRecord{
+		OccurTime: occurTime,
+		WaitTime:  1500 * time.Millisecond,
+		TrxID:     1,
+		SQLDigest: digest.String(),
+		Result:    lockwaithistory.ResultAcquired,
+	})
+	lockwaithistory.GlobalLockWaitHistory.Push(&lockwaithistory.LockWaitRecord{
+		OccurTime:      occurTime,
+		WaitTime:       time.Second,
+		TrxID:          3,
+		Key:            []byte("a"),
+		TrxHoldingLock: 4,
+		Result:         lockwaithistory.ResultDeadlock,
+	})
+	tk := s.newTestKitWithRoot(c)
+	tk.MustQuery("select * from information_schema.DATA_LOCK_WAIT_HISTORY").Check(testkit.Rows(
+		"2021-05-20 13:18:30.123456 1.5 1 "+digest.String()+" <nil> <nil> ACQUIRED",
+		"2021-05-20 13:18:30.123456 1 3 <nil> 61 4 DEADLOCK"))
+
+	tk.MustExec("create user 'lockwaituser'@'localhost'")
+	c.Assert(tk.Se.Auth(&auth.UserIdentity{Username: "lockwaituser", Hostname: "localhost"}, nil, nil), IsTrue)
+	err := tk.QueryToErr("select * from information_schema.DATA_LOCK_WAIT_HISTORY")
+	c.Assert(err.Error(), Equals, "[planner:1227]Access denied; you need (at least one of) the PROCESS privilege(s) for this operation")
+}
+
 func (s *testDataLockWaitSuite) SetUpSuite(c *C) {
 	testleak.BeforeTest()
 
@@ -291,6 +291,21 @@ type Request struct {
 	MatchStoreLabels []*metapb.StoreLabel
 	// ResourceGroupTag indicates the kv request task group.
 	ResourceGroupTag []byte
+	// Backpressure indicates the number of in-flight cop tasks should adapt to the drain rate
+	// of the consumer instead of always being Concurrency.
+	Backpressure bool
+	// RetryBudget is the total backoff time all the cop tasks of this request may sleep on retries,
+	// 0 means every region uses its own default limit.
+	RetryBudget time.Duration
+	// Paging indicates the streaming cop tasks fetch the results page by page, the size of the page grows
+	// adaptively so a task which is closed early doesn't scan the whole region. The unary cop tasks are never paged,
+	// since only the stream responses carry the range to resume from.
+	Paging bool
+	// MaxRangesPerTask limits the number of the ranges of a region sent in one cop task, 0 means the default limit.
+	MaxRangesPerTask int
+	// KeepRanges indicates the adjacent ranges must not be coalesced, since the results are mapped to the ranges by
+	// position, e.g. the row count of every range collected for the query feedback.
+	KeepRanges bool
 }
 
 // ResultSubset represents a result subset from a single storage unit.
@@ -25,6 +25,16 @@ type MPPTaskMeta interface {
 	GetAddress() string
 }
 
+// MPPTableTaskMeta is the meta of a mpp task which only reads one physical table.
+// The metas returned for the request carrying PartitionIDAndRanges implement it.
+type MPPTableTaskMeta interface {
+	MPPTaskMeta
+	// GetPhysicalTableID returns the id of the physical table read by the task.
+	GetPhysicalTableID() int64
+	// GetScanID returns the ScanID of the ranges read by the task.
+	GetScanID() int
+}
+
 // MPPTask means the minimum execution unit of a mpp computation job.
 type MPPTask struct {
 	Meta    MPPTaskMeta // on which store this task will execute
@@ -70,6 +80,8 @@ type MPPDispatchRequest struct {
 	StartTs   uint64
 	ID        int64 // identify a single task
 	State     MppTaskStates
+	// ResourceGroupTag tags the request with the SQL digest and the plan digest of the statement.
+	ResourceGroupTag []byte
 }
 
 // MPPClient accepts and processes mpp requests.
@@ -87,4 +99,18 @@ type MPPClient interface {
 type MPPBuildTasksRequest struct {
 	KeyRanges []KeyRange
 	StartTS   uint64
+	// PartitionIDAndRanges carries the ranges of several physical tables, which may
+	// be read by several table scans. The tasks of all the tables are built in one
+	// request if it is not empty, and KeyRanges is ignored.
+	PartitionIDAndRanges []PartitionIDAndRanges
+}
+
+// PartitionIDAndRanges represents the key ranges of a physical table.
+type PartitionIDAndRanges struct {
+	ID        int64
+	KeyRanges []KeyRange
+	// ScanID tells the ranges of the different table scans apart, the ranges of
+	// the same table read by two scans may overlap. Every task only reads the
+	// ranges of one scan.
+	ScanID int
 }
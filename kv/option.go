@@ -61,6 +61,11 @@ const (
 	MatchStoreLabels
 	// ResourceGroupTag indicates the resource group of the kv request.
 	ResourceGroupTag
+	// TxnSizeLimit overrides TxnTotalSizeLimit for the transaction, it caps the total size of the mutations.
+	TxnSizeLimit
+	// AsyncCommitRegionsLimit is the max number of regions the keys of a transaction can span to use async commit
+	// or one-phase commit, the transaction falls back to 2PC if its keys span more regions. 0 means no limit.
+	AsyncCommitRegionsLimit
 )
 
 // ReplicaReadType is the type of replica to read data from
@@ -73,9 +78,31 @@ const (
 	ReplicaReadFollower
 	// ReplicaReadMixed stands for 'read from leader and follower and learner'.
 	ReplicaReadMixed
+	// ReplicaReadLearner stands for 'read from learner', which are the TiFlash replicas. The tables without the
+	// TiFlash replicas are read from the leader.
+	ReplicaReadLearner
+	// ReplicaReadClosest stands for 'read from the replica in the same zone as the TiDB server'.
+	ReplicaReadClosest
 )
 
 // IsFollowerRead checks if follower is going to be used to read data.
 func (r ReplicaReadType) IsFollowerRead() bool {
-	return r != ReplicaReadLeader
+	return r != ReplicaReadLeader && r != ReplicaReadLearner
+}
+
+// String implements fmt.Stringer interface.
+func (r ReplicaReadType) String() string {
+	switch r {
+	case ReplicaReadLeader:
+		return "leader"
+	case ReplicaReadFollower:
+		return "follower"
+	case ReplicaReadMixed:
+		return "leader-and-follower"
+	case ReplicaReadLearner:
+		return "learner"
+	case ReplicaReadClosest:
+		return "closest-replicas"
+	}
+	return "unknown"
 }
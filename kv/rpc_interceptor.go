@@ -0,0 +1,79 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"context"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/errorpb"
+)
+
+// RPCInfo describes an RPC sent by the kv client.
+type RPCInfo struct {
+	// Type is the type of the request, such as Get, Cop and Prewrite.
+	Type string
+	// Addr is the address of the store which the request is sent to.
+	Addr string
+	// RegionID is the region which the request is sent to, it's 0 if the request is not bound to a region.
+	RegionID uint64
+	// Key is the first key accessed by the request, it's nil if it's unknown.
+	Key []byte
+}
+
+// RegionError can be returned by RPCInterceptor.BeforeRPC to make the RPC fail with a region error, the kv client
+// handles it like the region errors returned by the stores, such as backing off and retrying the request.
+type RegionError struct {
+	Err *errorpb.Error
+}
+
+// Error implements the error interface.
+func (e *RegionError) Error() string {
+	return "injected region error: " + e.Err.String()
+}
+
+// RPCInterceptor intercepts the RPCs sent by the kv client, it can be used to implement custom tracing,
+// chaos injection or throttling without patching the store client.
+type RPCInterceptor interface {
+	// BeforeRPC is called before the RPC is sent. If an error is returned, the RPC is aborted
+	// and the error is returned to the sender.
+	BeforeRPC(ctx context.Context, info *RPCInfo) error
+	// AfterRPC is called after the RPC returns or is aborted, with its duration and error.
+	AfterRPC(ctx context.Context, info *RPCInfo, duration time.Duration, err error)
+}
+
+// RPCInterceptorChain is a chain of RPCInterceptors. The BeforeRPC of the interceptors are called in order,
+// and the AfterRPC are called in the reverse order.
+type RPCInterceptorChain []RPCInterceptor
+
+// Intercept runs rpc with the interceptors of the chain around it.
+func (c RPCInterceptorChain) Intercept(ctx context.Context, info *RPCInfo, rpc func() error) error {
+	for i, interceptor := range c {
+		if err := interceptor.BeforeRPC(ctx, info); err != nil {
+			// Only the interceptors which have seen the RPC are notified.
+			c[:i].afterRPC(ctx, info, 0, err)
+			return err
+		}
+	}
+	start := time.Now()
+	err := rpc()
+	c.afterRPC(ctx, info, time.Since(start), err)
+	return err
+}
+
+func (c RPCInterceptorChain) afterRPC(ctx context.Context, info *RPCInfo, duration time.Duration, err error) {
+	for i := len(c) - 1; i >= 0; i-- {
+		c[i].AfterRPC(ctx, info, duration, err)
+	}
+}
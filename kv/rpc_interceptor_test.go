@@ -0,0 +1,70 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/errors"
+)
+
+var _ = Suite(&testRPCInterceptorSuite{})
+
+type testRPCInterceptorSuite struct {
+}
+
+type recordInterceptor struct {
+	name      string
+	records   *[]string
+	beforeErr error
+}
+
+func (r *recordInterceptor) BeforeRPC(ctx context.Context, info *RPCInfo) error {
+	*r.records = append(*r.records, fmt.Sprintf("%s before %s %d", r.name, info.Type, info.RegionID))
+	return r.beforeErr
+}
+
+func (r *recordInterceptor) AfterRPC(ctx context.Context, info *RPCInfo, duration time.Duration, err error) {
+	*r.records = append(*r.records, fmt.Sprintf("%s after %v", r.name, err))
+}
+
+func (s *testRPCInterceptorSuite) TestInterceptorChain(c *C) {
+	var records []string
+	chain := RPCInterceptorChain{
+		&recordInterceptor{name: "a", records: &records},
+		&recordInterceptor{name: "b", records: &records},
+	}
+	info := &RPCInfo{Type: "Get", Addr: "store1", RegionID: 2}
+	rpcErr := errors.New("rpc failed")
+	err := chain.Intercept(context.Background(), info, func() error {
+		records = append(records, "rpc")
+		return rpcErr
+	})
+	c.Assert(err, Equals, rpcErr)
+	c.Assert(records, DeepEquals, []string{"a before Get 2", "b before Get 2", "rpc", "b after rpc failed", "a after rpc failed"})
+
+	// The RPC is aborted by the interceptor.
+	records = records[:0]
+	abortErr := errors.New("throttled")
+	chain[1].(*recordInterceptor).beforeErr = abortErr
+	err = chain.Intercept(context.Background(), info, func() error {
+		records = append(records, "rpc")
+		return nil
+	})
+	c.Assert(err, Equals, abortErr)
+	c.Assert(records, DeepEquals, []string{"a before Get 2", "b before Get 2", "a after throttled"})
+}
@@ -0,0 +1,39 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"time"
+
+	"github.com/tikv/client-go/v2/oracle"
+)
+
+// ResolveBoundedStalenessTS resolves a read timestamp which is at most maxStaleness older than now.
+// The min safe ts of the stores in txnScope is the freshest ts that every replica can serve, so it is
+// used when it falls in [now - maxStaleness, now]. Otherwise it's clamped into the range, and a replica
+// which hasn't caught up with the lower bound yet will fall back to the leader.
+func ResolveBoundedStalenessTS(store Storage, txnScope string, now time.Time, maxStaleness time.Duration) uint64 {
+	minTS := oracle.GoTimeToTS(now.Add(-maxStaleness))
+	maxTS := oracle.GoTimeToTS(now)
+	var safeTS uint64
+	if store != nil {
+		safeTS = store.GetMinSafeTS(txnScope)
+	}
+	if safeTS < minTS {
+		return minTS
+	} else if safeTS > maxTS {
+		return maxTS
+	}
+	return safeTS
+}
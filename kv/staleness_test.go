@@ -0,0 +1,52 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/client-go/v2/oracle"
+)
+
+var _ = Suite(&testStalenessSuite{})
+
+type testStalenessSuite struct {
+}
+
+type safeTSStorage struct {
+	mockStorage
+	safeTS uint64
+}
+
+func (s *safeTSStorage) GetMinSafeTS(txnScope string) uint64 {
+	return s.safeTS
+}
+
+func (s *testStalenessSuite) TestResolveBoundedStalenessTS(c *C) {
+	now := time.Now()
+	maxStaleness := 10 * time.Second
+	lower := oracle.GoTimeToTS(now.Add(-maxStaleness))
+	upper := oracle.GoTimeToTS(now)
+
+	store := &safeTSStorage{}
+	c.Assert(ResolveBoundedStalenessTS(store, GlobalTxnScope, now, maxStaleness), Equals, lower)
+	c.Assert(ResolveBoundedStalenessTS(nil, GlobalTxnScope, now, maxStaleness), Equals, lower)
+
+	store.safeTS = oracle.GoTimeToTS(now.Add(-3 * time.Second))
+	c.Assert(ResolveBoundedStalenessTS(store, GlobalTxnScope, now, maxStaleness), Equals, store.safeTS)
+
+	store.safeTS = oracle.GoTimeToTS(now.Add(time.Second))
+	c.Assert(ResolveBoundedStalenessTS(store, GlobalTxnScope, now, maxStaleness), Equals, upper)
+}
@@ -19,6 +19,7 @@ import (
 
 var (
 	// ExecutorCounter records the number of expensive executors.
+	// Deprecated: use OperatorCounter, which records the executors of all the types.
 	ExecutorCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "tidb",
@@ -28,6 +29,42 @@ var (
 		}, []string{LblType},
 	)
 
+	// OperatorCounter records the number of the executed operators of each type.
+	OperatorCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "tidb",
+			Subsystem: "executor",
+			Name:      "operator_total",
+			Help:      "Counter of executed operators.",
+		}, []string{LblType})
+
+	// OperatorRowsCounter records the number of the rows returned by the operators of each type.
+	OperatorRowsCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "tidb",
+			Subsystem: "executor",
+			Name:      "operator_rows_total",
+			Help:      "Counter of rows returned by operators.",
+		}, []string{LblType})
+
+	// OperatorChunksCounter records the number of the chunks returned by the operators of each type.
+	OperatorChunksCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "tidb",
+			Subsystem: "executor",
+			Name:      "operator_chunks_total",
+			Help:      "Counter of chunks returned by operators.",
+		}, []string{LblType})
+
+	// OperatorDurationCounter records the time spent by the operators of each type, including their children.
+	OperatorDurationCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "tidb",
+			Subsystem: "executor",
+			Name:      "operator_duration_seconds_total",
+			Help:      "Counter of time (s) spent by operators, including their children.",
+		}, []string{LblType})
+
 	// StmtNodeCounter records the number of statement with the same type.
 	StmtNodeCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -45,4 +82,33 @@ var (
 			Name:      "statement_db_total",
 			Help:      "Counter of StmtNode by Database.",
 		}, []string{LblDb, LblType})
+
+	// ResourceGroupStmtCounter records the number of statements in each resource group.
+	ResourceGroupStmtCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "tidb",
+			Subsystem: "executor",
+			Name:      "resource_group_statement_total",
+			Help:      "Counter of statements by resource group.",
+		}, []string{LblResGroup, LblResult})
+
+	// ResourceGroupStmtDuration records the duration of the statements in each resource group.
+	ResourceGroupStmtDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "tidb",
+			Subsystem: "executor",
+			Name:      "resource_group_statement_duration_seconds",
+			Help:      "Bucketed histogram of processing time (s) of statements by resource group.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 29), // 0.5ms ~ 1.5days
+		}, []string{LblResGroup})
+
+	// ResourceGroupStmtMemory records the max memory consumed by the statements in each resource group.
+	ResourceGroupStmtMemory = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "tidb",
+			Subsystem: "executor",
+			Name:      "resource_group_statement_memory_bytes",
+			Help:      "Bucketed histogram of max memory consumed by statements by resource group.",
+			Buckets:   prometheus.ExponentialBuckets(1024, 4, 14), // 1KB ~ 64GB
+		}, []string{LblResGroup})
 )
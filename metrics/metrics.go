@@ -90,6 +90,10 @@ func RegisterMetrics() {
 	prometheus.MustRegister(DumpFeedbackCounter)
 	prometheus.MustRegister(ExecuteErrorCounter)
 	prometheus.MustRegister(ExecutorCounter)
+	prometheus.MustRegister(OperatorCounter)
+	prometheus.MustRegister(OperatorRowsCounter)
+	prometheus.MustRegister(OperatorChunksCounter)
+	prometheus.MustRegister(OperatorDurationCounter)
 	prometheus.MustRegister(GetTokenDurationHistogram)
 	prometheus.MustRegister(HandShakeErrorCounter)
 	prometheus.MustRegister(HandleJobHistogram)
@@ -108,6 +112,8 @@ func RegisterMetrics() {
 	prometheus.MustRegister(PlanCacheCounter)
 	prometheus.MustRegister(PseudoEstimation)
 	prometheus.MustRegister(PacketIOHistogram)
+	prometheus.MustRegister(CompressionBytesCounter)
+	prometheus.MustRegister(ConnCompressionRatioHistogram)
 	prometheus.MustRegister(QueryDurationHistogram)
 	prometheus.MustRegister(QueryTotalCounter)
 	prometheus.MustRegister(SchemaLeaseErrorCounter)
@@ -152,6 +158,9 @@ func RegisterMetrics() {
 	prometheus.MustRegister(SmallTxnWriteDuration)
 	prometheus.MustRegister(TxnWriteThroughput)
 	prometheus.MustRegister(LoadSysVarCacheCounter)
+	prometheus.MustRegister(ResourceGroupStmtCounter)
+	prometheus.MustRegister(ResourceGroupStmtDuration)
+	prometheus.MustRegister(ResourceGroupStmtMemory)
 
 	tikvmetrics.InitMetrics(TiDB, TiKVClient)
 	tikvmetrics.RegisterMetrics()
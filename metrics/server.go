@@ -93,6 +93,7 @@ var (
 
 	EventStart        = "start"
 	EventGracefulDown = "graceful_shutdown"
+	EventDrain        = "drain"
 	// Eventkill occurs when the server.Kill() function is called.
 	EventKill          = "kill"
 	EventClose         = "close"
@@ -228,6 +229,23 @@ var (
 			Name:      "tiflash_query_total",
 			Help:      "Counter of TiFlash queries.",
 		}, []string{LblType, LblResult})
+
+	CompressionBytesCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "tidb",
+			Subsystem: "server",
+			Name:      "compression_bytes",
+			Help:      "Counter of the bytes read and written by the compressed protocol, and the bytes before compression.",
+		}, []string{LblType, LblAlgorithm})
+
+	ConnCompressionRatioHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "tidb",
+			Subsystem: "server",
+			Name:      "conn_compression_ratio",
+			Help:      "Bucketed histogram of the compression ratio of the closed connections using the compressed protocol.",
+			Buckets:   prometheus.ExponentialBuckets(0.5, 1.5, 16), // 0.5 ~ 218
+		}, []string{LblAlgorithm})
 )
 
 // ExecuteErrorToLabel converts an execute error to label.
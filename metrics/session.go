@@ -147,4 +147,6 @@ const (
 	LblVersion     = "version"
 	LblHash        = "hash"
 	LblCTEType     = "cte_type"
+	LblResGroup    = "resource_group"
+	LblAlgorithm   = "algorithm"
 )
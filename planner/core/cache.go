@@ -15,6 +15,8 @@ package core
 
 import (
 	"math"
+	"sort"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -22,13 +24,19 @@ import (
 	"github.com/pingcap/parser/ast"
 	"github.com/pingcap/parser/model"
 	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/tidb/bindinfo"
+	"github.com/pingcap/tidb/domain"
 	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/privilege"
 	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/sessionctx/stmtctx"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/statistics"
 	"github.com/pingcap/tidb/types"
 	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/hack"
 	"github.com/pingcap/tidb/util/kvcache"
+	utilparser "github.com/pingcap/tidb/util/parser"
 	atomic2 "go.uber.org/atomic"
 )
 
@@ -47,6 +55,10 @@ var (
 const (
 	preparedPlanCacheEnabled = 1
 	preparedPlanCacheUnable  = 0
+
+	// statsCountChangeRatio is the ratio of the change of the row count of a table, with which the cached plans using
+	// the statistics of the table are invalidated.
+	statsCountChangeRatio = 0.5
 )
 
 // SetPreparedPlanCache sets isEnabled to true, then prepared plan cache is enabled.
@@ -73,6 +85,7 @@ type pstmtPlanCacheKey struct {
 	timezoneOffset       int
 	isolationReadEngines map[kv.StoreType]struct{}
 	selectLimit          uint64
+	useInvisibleIndexes  bool
 
 	hash []byte
 }
@@ -103,6 +116,9 @@ func (key *pstmtPlanCacheKey) Hash() []byte {
 			key.hash = append(key.hash, kv.TiFlash.Name()...)
 		}
 		key.hash = codec.EncodeInt(key.hash, int64(key.selectLimit))
+		if key.useInvisibleIndexes {
+			key.hash = append(key.hash, '1')
+		}
 	}
 	return key.hash
 }
@@ -138,6 +154,7 @@ func NewPSTMTPlanCacheKey(sessionVars *variable.SessionVars, pstmtID uint32, sch
 		timezoneOffset:       timezoneOffset,
 		isolationReadEngines: make(map[kv.StoreType]struct{}),
 		selectLimit:          sessionVars.SelectLimit,
+		useInvisibleIndexes:  sessionVars.OptimizerUseInvisibleIndexes,
 	}
 	for k, v := range sessionVars.IsolationReadEngines {
 		key.isolationReadEngines[k] = v
@@ -173,10 +190,16 @@ type PSTMTPlanCacheValue struct {
 	OutPutNames       []*types.FieldName
 	TblInfo2UnionScan map[*model.TableInfo]bool
 	UserVarTypes      FieldSlice
+	// BindSQL, StatsDeps and SecurityPolicyDep are the bindings, the statistics and the row-level security policies
+	// the plan depends on, the plan is invalidated if they change.
+	BindSQL           string
+	StatsDeps         map[int64]stmtctx.StatsDep
+	SecurityPolicyDep SecurityPolicyDep
 }
 
 // NewPSTMTPlanCacheValue creates a SQLCacheValue.
-func NewPSTMTPlanCacheValue(plan Plan, names []*types.FieldName, srcMap map[*model.TableInfo]bool, userVarTps []*types.FieldType) *PSTMTPlanCacheValue {
+func NewPSTMTPlanCacheValue(plan Plan, names []*types.FieldName, srcMap map[*model.TableInfo]bool, userVarTps []*types.FieldType,
+	bindSQL string, statsDeps map[int64]stmtctx.StatsDep, securityPolicyDep SecurityPolicyDep) *PSTMTPlanCacheValue {
 	dstMap := make(map[*model.TableInfo]bool)
 	for k, v := range srcMap {
 		dstMap[k] = v
@@ -190,7 +213,111 @@ func NewPSTMTPlanCacheValue(plan Plan, names []*types.FieldName, srcMap map[*mod
 		OutPutNames:       names,
 		TblInfo2UnionScan: dstMap,
 		UserVarTypes:      userVarTypes,
+		BindSQL:           bindSQL,
+		StatsDeps:         statsDeps,
+		SecurityPolicyDep: securityPolicyDep,
+	}
+}
+
+// recordStatsDep records the statistics of the physical table used by the optimizer if the plan may be cached.
+func recordStatsDep(sc *stmtctx.StatementContext, tblInfo *model.TableInfo, physicalID int64, statsTbl *statistics.Table) {
+	if !sc.UseCache {
+		return
+	}
+	if sc.StatsDeps == nil {
+		sc.StatsDeps = make(map[int64]stmtctx.StatsDep)
+	}
+	sc.StatsDeps[physicalID] = stmtctx.StatsDep{
+		TblInfo:        tblInfo,
+		AnalyzeVersion: statsTbl.LastAnalyzeVersion(),
+		Count:          statsTbl.Count,
+		Outdated:       statsTbl.IsOutdated(),
+	}
+}
+
+// changedStatsDep returns the table whose statistics change significantly since the plan is cached, which means the
+// table is analyzed again, the statistics become outdated or not, or the row count changes by more than
+// statsCountChangeRatio. It returns nil if there is no such table.
+func changedStatsDep(sctx sessionctx.Context, deps map[int64]stmtctx.StatsDep) *model.TableInfo {
+	statsHandle := domain.GetDomain(sctx).StatsHandle()
+	if statsHandle == nil || sctx.GetSessionVars().OverriddenStats != nil {
+		return nil
+	}
+	for physicalID, dep := range deps {
+		var statsTbl *statistics.Table
+		if physicalID == dep.TblInfo.ID {
+			statsTbl = statsHandle.GetTableStats(dep.TblInfo)
+		} else {
+			statsTbl = statsHandle.GetPartitionStats(dep.TblInfo, physicalID)
+		}
+		if statsTbl.LastAnalyzeVersion() != dep.AnalyzeVersion || statsTbl.IsOutdated() != dep.Outdated {
+			return dep.TblInfo
+		}
+		if diff := math.Abs(float64(statsTbl.Count - dep.Count)); diff > 0 && diff >= statsCountChangeRatio*float64(dep.Count) {
+			return dep.TblInfo
+		}
+	}
+	return nil
+}
+
+// SecurityPolicyDep is the version of the row-level security policies and the active roles of the session a plan is
+// built with. The policies applied to the plan depend on them, so a plan cached before a policy is created or a role
+// is activated is invalidated.
+type SecurityPolicyDep struct {
+	version     uint64
+	activeRoles string
+}
+
+// GetSecurityPolicyDep returns the SecurityPolicyDep of the session.
+func GetSecurityPolicyDep(sctx sessionctx.Context) SecurityPolicyDep {
+	pm := privilege.GetPrivilegeManager(sctx)
+	if pm == nil {
+		return SecurityPolicyDep{}
+	}
+	activeRoles := sctx.GetSessionVars().ActiveRoles
+	roles := make([]string, 0, len(activeRoles))
+	for _, r := range activeRoles {
+		roles = append(roles, r.String())
+	}
+	sort.Strings(roles)
+	return SecurityPolicyDep{version: pm.SecurityPolicyVersion(), activeRoles: strings.Join(roles, ",")}
+}
+
+// planCacheBindSQL returns the bind SQLs of the bindings used to optimize the prepared statement, which are the same
+// as the ones found by the optimizer. The cached plans built with other bindings are invalidated, so creating or
+// dropping a binding only invalidates the plans of the statements it's for.
+func planCacheBindSQL(sctx sessionctx.Context, preparedStmt *CachedPrepareStmt) string {
+	vars := sctx.GetSessionVars()
+	if !vars.UsePlanBaselines || sctx.Value(bindinfo.SessionBindInfoKeyType) == nil {
+		return ""
+	}
+	stmt := preparedStmt.PreparedAst.Stmt
+	switch stmt.(type) {
+	case *ast.SelectStmt, *ast.SetOprStmt, *ast.DeleteStmt, *ast.UpdateStmt, *ast.InsertStmt:
+	default:
+		return ""
+	}
+	if len(stmt.Text()) == 0 {
+		return ""
+	}
+	// The normalized SQL depends on the current database, it's cached since the database rarely changes.
+	if preparedStmt.bindingDB != vars.CurrentDB || preparedStmt.bindingSQL == "" {
+		normalizedSQL, digest := parser.NormalizeDigest(utilparser.RestoreWithDefaultDB(stmt, vars.CurrentDB, stmt.Text()))
+		preparedStmt.bindingDB, preparedStmt.bindingSQL, preparedStmt.bindingDigest = vars.CurrentDB, normalizedSQL, digest.String()
+	}
+	sessionHandle := sctx.Value(bindinfo.SessionBindInfoKeyType).(*bindinfo.SessionHandle)
+	bindRecord, _ := bindinfo.FindBindRecord(sessionHandle, domain.GetDomain(sctx).BindHandle(), stmt, preparedStmt.bindingSQL, preparedStmt.bindingDigest)
+	if bindRecord == nil {
+		return ""
+	}
+	var sb strings.Builder
+	for _, binding := range bindRecord.Bindings {
+		if binding.Status == bindinfo.Using {
+			sb.WriteString(binding.BindSQL)
+			sb.WriteByte(';')
+		}
 	}
+	return sb.String()
 }
 
 // CachedPrepareStmt store prepared ast from PrepareExec and other related fields
@@ -205,4 +332,13 @@ type CachedPrepareStmt struct {
 	PlanDigest          *parser.Digest
 	ForUpdateRead       bool
 	SnapshotTSEvaluator func(sessionctx.Context) (uint64, error)
+	// UncacheableReason is the reason why the plans of the statement can't be cached.
+	UncacheableReason string
+	// PointPlanSecurityPolicyDep is the SecurityPolicyDep the cached point plan of the statement is built with.
+	PointPlanSecurityPolicyDep SecurityPolicyDep
+	// bindingSQL and bindingDigest are the normalized SQL and its digest to find the bindings of the statement when
+	// the current database is bindingDB.
+	bindingDB     string
+	bindingSQL    string
+	bindingDigest string
 }
@@ -14,6 +14,8 @@
 package core
 
 import (
+	"fmt"
+
 	"github.com/pingcap/parser/ast"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/infoschema"
@@ -26,20 +28,26 @@ import (
 // Handle "ignore_plan_cache()" hint
 // If there are multiple hints, only one will take effect
 func Cacheable(node ast.Node, is infoschema.InfoSchema) bool {
+	cacheable, _ := CacheableWithReason(node, is)
+	return cacheable
+}
+
+// CacheableWithReason checks whether the input ast is cacheable, the reason is returned if it's not cacheable.
+func CacheableWithReason(node ast.Node, is infoschema.InfoSchema) (bool, string) {
 	_, isSelect := node.(*ast.SelectStmt)
 	_, isUpdate := node.(*ast.UpdateStmt)
 	_, isInsert := node.(*ast.InsertStmt)
 	_, isDelete := node.(*ast.DeleteStmt)
 	_, isSetOpr := node.(*ast.SetOprStmt)
 	if !(isSelect || isUpdate || isInsert || isDelete || isSetOpr) {
-		return false
+		return false, "not a SELECT, UPDATE, INSERT, DELETE or set operation statement"
 	}
 	checker := cacheableChecker{
 		cacheable: true,
 		schema:    is,
 	}
 	node.Accept(&checker)
-	return checker.cacheable
+	return checker.cacheable, checker.reason
 }
 
 // cacheableChecker checks whether a query's plan can be cached, querys that:
@@ -49,6 +57,7 @@ func Cacheable(node ast.Node, is infoschema.InfoSchema) bool {
 // NOTE: we can add more rules in the future.
 type cacheableChecker struct {
 	cacheable bool
+	reason    string
 	schema    infoschema.InfoSchema
 }
 
@@ -59,6 +68,7 @@ func (checker *cacheableChecker) Enter(in ast.Node) (out ast.Node, skipChildren
 		for _, hints := range node.TableHints {
 			if hints.HintName.L == HintIgnorePlanCache {
 				checker.cacheable = false
+				checker.reason = "ignore_plan_cache() hint is used"
 				return in, true
 			}
 		}
@@ -66,6 +76,7 @@ func (checker *cacheableChecker) Enter(in ast.Node) (out ast.Node, skipChildren
 		for _, hints := range node.TableHints {
 			if hints.HintName.L == HintIgnorePlanCache {
 				checker.cacheable = false
+				checker.reason = "ignore_plan_cache() hint is used"
 				return in, true
 			}
 		}
@@ -73,21 +84,29 @@ func (checker *cacheableChecker) Enter(in ast.Node) (out ast.Node, skipChildren
 		for _, hints := range node.TableHints {
 			if hints.HintName.L == HintIgnorePlanCache {
 				checker.cacheable = false
+				checker.reason = "ignore_plan_cache() hint is used"
 				return in, true
 			}
 		}
-	case *ast.VariableExpr, *ast.ExistsSubqueryExpr, *ast.SubqueryExpr:
+	case *ast.VariableExpr:
+		checker.cacheable = false
+		checker.reason = "query has variables"
+		return in, true
+	case *ast.ExistsSubqueryExpr, *ast.SubqueryExpr:
 		checker.cacheable = false
+		checker.reason = "query has sub-queries"
 		return in, true
 	case *ast.FuncCallExpr:
 		if _, found := expression.UnCacheableFunctions[node.FnName.L]; found {
 			checker.cacheable = false
+			checker.reason = fmt.Sprintf("query has '%v' function", node.FnName.L)
 			return in, true
 		}
 	case *ast.OrderByClause:
 		for _, item := range node.Items {
 			if _, isParamMarker := item.Expr.(*driver.ParamMarkerExpr); isParamMarker {
 				checker.cacheable = false
+				checker.reason = "query has 'order by ?'"
 				return in, true
 			}
 		}
@@ -95,6 +114,7 @@ func (checker *cacheableChecker) Enter(in ast.Node) (out ast.Node, skipChildren
 		for _, item := range node.Items {
 			if _, isParamMarker := item.Expr.(*driver.ParamMarkerExpr); isParamMarker {
 				checker.cacheable = false
+				checker.reason = "query has 'group by ?'"
 				return in, true
 			}
 		}
@@ -102,24 +122,28 @@ func (checker *cacheableChecker) Enter(in ast.Node) (out ast.Node, skipChildren
 		if node.Count != nil {
 			if _, isParamMarker := node.Count.(*driver.ParamMarkerExpr); isParamMarker {
 				checker.cacheable = false
+				checker.reason = "query has 'limit ?'"
 				return in, true
 			}
 		}
 		if node.Offset != nil {
 			if _, isParamMarker := node.Offset.(*driver.ParamMarkerExpr); isParamMarker {
 				checker.cacheable = false
+				checker.reason = "query has 'limit ?, ?'"
 				return in, true
 			}
 		}
 	case *ast.FrameBound:
 		if _, ok := node.Expr.(*driver.ParamMarkerExpr); ok {
 			checker.cacheable = false
+			checker.reason = "query has parameters in the window frame"
 			return in, true
 		}
 	case *ast.TableName:
 		if checker.schema != nil {
 			if checker.isPartitionTable(node) {
 				checker.cacheable = false
+				checker.reason = "query accesses partitioned tables"
 				return in, true
 			}
 		}
@@ -16,6 +16,7 @@ package core
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -323,8 +324,12 @@ func (e *Execute) getPhysicalPlan(ctx context.Context, sctx sessionctx.Context,
 	prepared := preparedStmt.PreparedAst
 	stmtCtx.UseCache = prepared.UseCache
 	var cacheKey kvcache.Key
+	var bindSQL string
 	if prepared.UseCache {
 		cacheKey = NewPSTMTPlanCacheKey(sctx.GetSessionVars(), e.ExecID, prepared.SchemaVersion)
+		bindSQL = planCacheBindSQL(sctx, preparedStmt)
+	} else if preparedStmt.UncacheableReason != "" {
+		stmtCtx.AppendOptimizerNote(errors.New("skip plan-cache: " + preparedStmt.UncacheableReason))
 	}
 	tps := make([]*types.FieldType, len(e.UsingVars))
 	for i, param := range e.UsingVars {
@@ -334,6 +339,11 @@ func (e *Execute) getPhysicalPlan(ctx context.Context, sctx sessionctx.Context,
 			tps[i] = types.NewFieldType(mysql.TypeNull)
 		}
 	}
+	securityPolicyDep := GetSecurityPolicyDep(sctx)
+	if prepared.CachedPlan != nil && preparedStmt.PointPlanSecurityPolicyDep != securityPolicyDep {
+		stmtCtx.AppendOptimizerNote(errors.New("skip plan-cache: the security policies or the active roles are changed"))
+		prepared.CachedPlan = nil
+	}
 	if prepared.CachedPlan != nil {
 		// Rewriting the expression in the select.where condition  will convert its
 		// type from "paramMarker" to "Constant".When Point Select queries are executed,
@@ -344,6 +354,7 @@ func (e *Execute) getPhysicalPlan(ctx context.Context, sctx sessionctx.Context,
 		err := e.rebuildRange(plan)
 		if err != nil {
 			logutil.BgLogger().Debug("rebuild range failed", zap.Error(err))
+			stmtCtx.AppendOptimizerNote(errors.Errorf("skip plan-cache: the ranges of the cached plan can't be rebuilt: %v", err))
 			goto REBUILD
 		}
 		if metrics.ResettablePlanCacheCounterFortTest {
@@ -374,16 +385,35 @@ func (e *Execute) getPhysicalPlan(ctx context.Context, sctx sessionctx.Context,
 				for tblInfo, unionScan := range cachedVal.TblInfo2UnionScan {
 					if !unionScan && tableHasDirtyContent(sctx, tblInfo) {
 						planValid = false
+						stmtCtx.AppendOptimizerNote(errors.Errorf("skip plan-cache: table `%s` is modified in the transaction", tblInfo.Name.O))
 						// TODO we can inject UnionScan into cached plan to avoid invalidating it, though
 						// rebuilding the filters in UnionScan is pretty trivial.
 						sctx.PreparedPlanCache().Delete(cacheKey)
 						break
 					}
 				}
+				if planValid && cachedVal.BindSQL != bindSQL {
+					planValid = false
+					stmtCtx.AppendOptimizerNote(errors.New("skip plan-cache: the bindings of the statement are changed"))
+					sctx.PreparedPlanCache().Delete(cacheKey)
+				}
+				if planValid && cachedVal.SecurityPolicyDep != securityPolicyDep {
+					planValid = false
+					stmtCtx.AppendOptimizerNote(errors.New("skip plan-cache: the security policies or the active roles are changed"))
+					sctx.PreparedPlanCache().Delete(cacheKey)
+				}
+				if planValid {
+					if tblInfo := changedStatsDep(sctx, cachedVal.StatsDeps); tblInfo != nil {
+						planValid = false
+						stmtCtx.AppendOptimizerNote(errors.Errorf("skip plan-cache: the statistics of table `%s` are changed", tblInfo.Name.O))
+						sctx.PreparedPlanCache().Delete(cacheKey)
+					}
+				}
 				if planValid {
 					err := e.rebuildRange(cachedVal.Plan)
 					if err != nil {
 						logutil.BgLogger().Debug("rebuild range failed", zap.Error(err))
+						stmtCtx.AppendOptimizerNote(errors.Errorf("skip plan-cache: the ranges of the cached plan can't be rebuilt: %v", err))
 						goto REBUILD
 					}
 					err = e.setFoundInPlanCache(sctx, true)
@@ -418,14 +448,23 @@ REBUILD:
 	e.names = names
 	e.Plan = p
 	_, isTableDual := p.(*PhysicalTableDual)
-	if !isTableDual && prepared.UseCache && !stmtCtx.OptimDependOnMutableConst {
+	if prepared.UseCache {
+		if isTableDual {
+			stmtCtx.AppendOptimizerNote(errors.New("skip plan-cache: get a TableDual plan"))
+		} else if stmtCtx.OptimDependOnMutableConst {
+			stmtCtx.AppendOptimizerNote(errors.New("skip plan-cache: the plan depends on the values of the parameters"))
+		} else if stmtCtx.HasSecurityPolicy {
+			stmtCtx.AppendOptimizerNote(errors.New("skip plan-cache: the plan has the row-level security policies"))
+		}
+	}
+	if !isTableDual && prepared.UseCache && !stmtCtx.OptimDependOnMutableConst && !stmtCtx.HasSecurityPolicy {
 		// rebuild key to exclude kv.TiFlash when stmt is not read only
 		if _, isolationReadContainTiFlash := sessVars.IsolationReadEngines[kv.TiFlash]; isolationReadContainTiFlash && !IsReadOnly(stmt, sessVars) {
 			delete(sessVars.IsolationReadEngines, kv.TiFlash)
 			cacheKey = NewPSTMTPlanCacheKey(sctx.GetSessionVars(), e.ExecID, prepared.SchemaVersion)
 			sessVars.IsolationReadEngines[kv.TiFlash] = struct{}{}
 		}
-		cached := NewPSTMTPlanCacheValue(p, names, stmtCtx.TblInfo2UnionScan, tps)
+		cached := NewPSTMTPlanCacheValue(p, names, stmtCtx.TblInfo2UnionScan, tps, bindSQL, stmtCtx.StatsDeps, securityPolicyDep)
 		preparedStmt.NormalizedPlan, preparedStmt.PlanDigest = NormalizePlan(p)
 		stmtCtx.SetPlanDigest(preparedStmt.NormalizedPlan, preparedStmt.PlanDigest)
 		if cacheVals, exists := sctx.PreparedPlanCache().Get(cacheKey); exists {
@@ -474,6 +513,7 @@ func (e *Execute) tryCachePointPlan(ctx context.Context, sctx sessionctx.Context
 		// just cache point plan now
 		prepared.CachedPlan = p
 		prepared.CachedNames = names
+		preparedStmt.PointPlanSecurityPolicyDep = GetSecurityPolicyDep(sctx)
 		preparedStmt.NormalizedPlan, preparedStmt.PlanDigest = NormalizePlan(p)
 		sctx.GetSessionVars().StmtCtx.SetPlanDigest(preparedStmt.NormalizedPlan, preparedStmt.PlanDigest)
 	}
@@ -774,6 +814,10 @@ type Insert struct {
 
 	IsReplace bool
 
+	// SecurityPolicyConds are the conditions of the row-level security policies for the current user, the duplicate
+	// rows which don't satisfy them are invisible to the INSERT ... ON DUPLICATE KEY UPDATE and REPLACE statements.
+	SecurityPolicyConds []expression.Expression
+
 	// NeedFillDefaultValue is true when expr in value list reference other column.
 	NeedFillDefaultValue bool
 
@@ -962,6 +1006,10 @@ type SelectInto struct {
 	IntoOpt    *ast.SelectIntoOption
 }
 
+// ExplainFormatTrace is the explain format which shows the optimizer trace of the statement, see
+// StatementContext.AppendOptimizerTrace.
+const ExplainFormatTrace = "trace"
+
 // Explain represents a explain plan.
 type Explain struct {
 	baseSchemaProducer
@@ -976,9 +1024,39 @@ type Explain struct {
 	ExplainRows    [][]string
 	explainedPlans map[int]bool
 
+	// Nodes are the root operators of the plan in the tree form, they're built along with Rows if buildNodes is set.
+	Nodes      []*ExplainNode
+	buildNodes bool
+	nodeStack  []*ExplainNode
+
 	ctes []*PhysicalCTE
 }
 
+// ExplainNode is an operator of the plan in the tree form, it's used to output the plan in JSON.
+type ExplainNode struct {
+	ID            string `json:"id"`
+	EstRows       string `json:"estRows"`
+	EstCost       string `json:"estCost,omitempty"`
+	ActRows       string `json:"actRows,omitempty"`
+	TaskType      string `json:"taskType"`
+	AccessObject  string `json:"accessObject,omitempty"`
+	ExecutionInfo string `json:"executionInfo,omitempty"`
+	OperatorInfo  string `json:"operatorInfo,omitempty"`
+	Memory        string `json:"memory,omitempty"`
+	Disk          string `json:"disk,omitempty"`
+	// Exchange is only set for the ExchangeSender, which is the root of an MPP fragment.
+	Exchange *ExplainExchange `json:"exchange,omitempty"`
+	Children []*ExplainNode   `json:"children,omitempty"`
+}
+
+// ExplainExchange is how an MPP fragment sends its data to the upstream fragment.
+type ExplainExchange struct {
+	Type     string   `json:"type"`
+	HashCols []string `json:"hashCols,omitempty"`
+	// Tasks are the IDs of the MPP tasks of the fragment, they're only known after the fragment is dispatched.
+	Tasks []int64 `json:"tasks,omitempty"`
+}
+
 // GetExplainRowsForPlan get explain rows for plan.
 func GetExplainRowsForPlan(plan Plan) (rows [][]string) {
 	explain := &Explain{
@@ -992,6 +1070,21 @@ func GetExplainRowsForPlan(plan Plan) (rows [][]string) {
 	return explain.Rows
 }
 
+// GetExplainNodesForPlan gets the plan in the tree form, with the runtime stats if they're collected by the statement.
+func GetExplainNodesForPlan(sctx sessionctx.Context, plan Plan) []*ExplainNode {
+	explain := &Explain{
+		TargetPlan:       plan,
+		Format:           ast.ExplainFormatROW,
+		RuntimeStatsColl: sctx.GetSessionVars().StmtCtx.RuntimeStatsColl,
+		buildNodes:       true,
+	}
+	explain.ctx = sctx
+	if err := explain.RenderResult(); err != nil {
+		return nil
+	}
+	return explain.Nodes
+}
+
 // prepareSchema prepares explain's result schema.
 func (e *Explain) prepareSchema() error {
 	var fieldNames []string
@@ -1006,8 +1099,12 @@ func (e *Explain) prepareSchema() error {
 		fieldNames = []string{"id", "estRows", "actRows", "task", "access object", "execution info", "operator info", "memory", "disk"}
 	case format == ast.ExplainFormatDOT:
 		fieldNames = []string{"dot contents"}
+	case format == ast.ExplainFormatJSON:
+		fieldNames = []string{"json contents"}
 	case format == ast.ExplainFormatHint:
 		fieldNames = []string{"hint"}
+	case format == ExplainFormatTrace:
+		fieldNames = []string{"step", "type", "operator", "detail"}
 	default:
 		return errors.Errorf("explain format '%s' is not supported now", e.Format)
 	}
@@ -1047,16 +1144,47 @@ func (e *Explain) RenderResult() error {
 		if physicalPlan, ok := e.TargetPlan.(PhysicalPlan); ok {
 			e.prepareDotInfo(physicalPlan)
 		}
+	case ast.ExplainFormatJSON:
+		if err := e.explainPlanInJSONFormat(); err != nil {
+			return err
+		}
 	case ast.ExplainFormatHint:
 		hints := GenHintsFromPhysicalPlan(e.TargetPlan)
 		hints = append(hints, hint.ExtractTableHintsFromStmtNode(e.ExecStmt, nil)...)
 		e.Rows = append(e.Rows, []string{hint.RestoreOptimizerHints(hints)})
+	case ExplainFormatTrace:
+		for i, step := range e.ctx.GetSessionVars().StmtCtx.GetOptimizerTrace() {
+			e.Rows = append(e.Rows, []string{strconv.Itoa(i + 1), step.Type, step.Operator, step.Detail})
+		}
 	default:
 		return errors.Errorf("explain format '%s' is not supported now", e.Format)
 	}
 	return nil
 }
 
+// explainPlanInJSONFormat generates the plan tree in JSON. The operators have the same information as the row format
+// with their estimated costs, and the ExchangeSenders have the exchange info of the MPP fragments.
+func (e *Explain) explainPlanInJSONFormat() error {
+	e.explainedPlans = map[int]bool{}
+	e.buildNodes, e.Nodes = true, nil
+	if err := e.explainPlanInRowFormat(e.TargetPlan, "root", "", "", true); err != nil {
+		return err
+	}
+	if err := e.explainPlanInRowFormatCTE(); err != nil {
+		return err
+	}
+	// The expressions like `a->b` in the operator info are kept as they are rather than escaped.
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(e.Nodes); err != nil {
+		return errors.Trace(err)
+	}
+	e.Rows = [][]string{{strings.TrimSuffix(buf.String(), "\n")}}
+	return nil
+}
+
 func (e *Explain) explainPlanInRowFormatCTE() (err error) {
 	explainedCTEPlan := make(map[int]struct{})
 	for i := 0; i < len(e.ctes); i++ {
@@ -1065,12 +1193,14 @@ func (e *Explain) explainPlanInRowFormatCTE() (err error) {
 		if _, ok := explainedCTEPlan[x.CTE.IDForStorage]; ok {
 			continue
 		}
-		e.prepareOperatorInfo(x, "root", "", "", true)
+		node := e.prepareOperatorInfo(x, "root", "", "", true)
+		e.pushNode(node)
 		childIndent := texttree.Indent4Child("", true)
 		err = e.explainPlanInRowFormat(x.SeedPlan, "root", "(Seed Part)", childIndent, x.RecurPlan == nil)
 		if x.RecurPlan != nil {
 			err = e.explainPlanInRowFormat(x.RecurPlan, "root", "(Recursive Part)", childIndent, true)
 		}
+		e.popNode(node)
 		explainedCTEPlan[x.CTE.IDForStorage] = struct{}{}
 	}
 
@@ -1079,8 +1209,10 @@ func (e *Explain) explainPlanInRowFormatCTE() (err error) {
 
 // explainPlanInRowFormat generates explain information for root-tasks.
 func (e *Explain) explainPlanInRowFormat(p Plan, taskType, driverSide, indent string, isLastChild bool) (err error) {
-	e.prepareOperatorInfo(p, taskType, driverSide, indent, isLastChild)
+	node := e.prepareOperatorInfo(p, taskType, driverSide, indent, isLastChild)
 	e.explainedPlans[p.ID()] = true
+	e.pushNode(node)
+	defer e.popNode(node)
 
 	// For every child we create a new sub-tree rooted by it.
 	childIndent := texttree.Indent4Child(indent, isLastChild)
@@ -1228,20 +1360,20 @@ func getRuntimeInfo(ctx sessionctx.Context, p Plan, runtimeStatsColl *execdetail
 
 // prepareOperatorInfo generates the following information for every plan:
 // operator id, estimated rows, task type, access object and other operator info.
-func (e *Explain) prepareOperatorInfo(p Plan, taskType, driverSide, indent string, isLastChild bool) {
+// The node of the plan is also returned if the plan is built in the tree form.
+func (e *Explain) prepareOperatorInfo(p Plan, taskType, driverSide, indent string, isLastChild bool) *ExplainNode {
 	if p.ExplainID().String() == "_0" {
-		return
+		return nil
 	}
 
 	id := texttree.PrettyIdentifier(p.ExplainID().String()+driverSide, indent, isLastChild)
 	estRows, estCost, accessObject, operatorInfo := e.getOperatorInfo(p, id)
 
 	var row []string
-	if e.Analyze {
-		actRows, analyzeInfo, memoryInfo, diskInfo := getRuntimeInfo(e.ctx, p, nil)
-		row = []string{id, estRows, actRows, taskType, accessObject, analyzeInfo, operatorInfo, memoryInfo, diskInfo}
-	} else if e.RuntimeStatsColl != nil {
-		actRows, analyzeInfo, memoryInfo, diskInfo := getRuntimeInfo(e.ctx, p, e.RuntimeStatsColl)
+	var actRows, analyzeInfo, memoryInfo, diskInfo string
+	if e.Analyze || e.RuntimeStatsColl != nil {
+		// The runtime stats of the statement itself are used if analyzed, in which case e.RuntimeStatsColl is nil.
+		actRows, analyzeInfo, memoryInfo, diskInfo = getRuntimeInfo(e.ctx, p, e.RuntimeStatsColl)
 		row = []string{id, estRows, actRows, taskType, accessObject, analyzeInfo, operatorInfo, memoryInfo, diskInfo}
 	} else {
 		row = []string{id, estRows}
@@ -1251,6 +1383,50 @@ func (e *Explain) prepareOperatorInfo(p Plan, taskType, driverSide, indent strin
 		row = append(row, taskType, accessObject, operatorInfo)
 	}
 	e.Rows = append(e.Rows, row)
+	if !e.buildNodes {
+		return nil
+	}
+	node := &ExplainNode{
+		ID:            p.ExplainID().String() + driverSide,
+		EstRows:       estRows,
+		EstCost:       estCost,
+		ActRows:       actRows,
+		TaskType:      taskType,
+		AccessObject:  accessObject,
+		ExecutionInfo: analyzeInfo,
+		OperatorInfo:  operatorInfo,
+		Memory:        memoryInfo,
+		Disk:          diskInfo,
+	}
+	if sender, ok := p.(*PhysicalExchangeSender); ok {
+		node.Exchange = &ExplainExchange{Type: sender.exchangeTypeName()}
+		for _, col := range sender.HashCols {
+			node.Exchange.HashCols = append(node.Exchange.HashCols, col.ExplainInfo())
+		}
+		for _, task := range sender.Tasks {
+			node.Exchange.Tasks = append(node.Exchange.Tasks, task.ID)
+		}
+	}
+	if len(e.nodeStack) == 0 {
+		e.Nodes = append(e.Nodes, node)
+	} else {
+		parent := e.nodeStack[len(e.nodeStack)-1]
+		parent.Children = append(parent.Children, node)
+	}
+	return node
+}
+
+// pushNode makes the node the parent of the nodes prepared next, until it's popped.
+func (e *Explain) pushNode(node *ExplainNode) {
+	if node != nil {
+		e.nodeStack = append(e.nodeStack, node)
+	}
+}
+
+func (e *Explain) popNode(node *ExplainNode) {
+	if node != nil {
+		e.nodeStack = e.nodeStack[:len(e.nodeStack)-1]
+	}
 }
 
 func (e *Explain) getOperatorInfo(p Plan, id string) (string, string, string, string) {
@@ -1293,6 +1469,9 @@ func (e *Explain) prepareDotInfo(p PhysicalPlan) {
 	e.Rows = append(e.Rows, []string{buffer.String()})
 }
 
+// prepareTaskDot generates the DOT subgraph of a task. The tasks read by the readers and the MPP fragments received by
+// the ExchangeReceivers are generated as their own subgraphs, and the operators are labeled with their runtime stats if
+// they're collected.
 func (e *Explain) prepareTaskDot(p PhysicalPlan, taskTp string, buffer *bytes.Buffer) {
 	fmt.Fprintf(buffer, "subgraph cluster%v{\n", p.ID())
 	buffer.WriteString("node [style=filled, color=lightgrey]\n")
@@ -1300,39 +1479,57 @@ func (e *Explain) prepareTaskDot(p PhysicalPlan, taskTp string, buffer *bytes.Bu
 	fmt.Fprintf(buffer, "label = \"%s\"\n", taskTp)
 
 	if len(p.Children()) == 0 {
-		if taskTp == "cop" {
-			fmt.Fprintf(buffer, "\"%s\"\n}\n", p.ExplainID())
+		buffer.WriteString(e.dotNode(p))
+		if taskTp != "root" {
+			buffer.WriteString("}\n")
 			return
 		}
-		fmt.Fprintf(buffer, "\"%s\"\n", p.ExplainID())
 	}
 
-	var copTasks []PhysicalPlan
+	type dotTask struct {
+		p      PhysicalPlan
+		taskTp string
+	}
+	var copTasks []dotTask
 	var pipelines []string
 
 	for planQueue := []PhysicalPlan{p}; len(planQueue) > 0; planQueue = planQueue[1:] {
 		curPlan := planQueue[0]
+		if len(p.Children()) > 0 && (e.Analyze || e.RuntimeStatsColl != nil) {
+			buffer.WriteString(e.dotNode(curPlan))
+		}
 		switch copPlan := curPlan.(type) {
 		case *PhysicalTableReader:
+			if sender, ok := copPlan.tablePlan.(*PhysicalExchangeSender); ok {
+				pipelines = append(pipelines, dotExchangeEdge(copPlan, sender))
+				copTasks = append(copTasks, dotTask{sender, "mpp"})
+				break
+			}
 			pipelines = append(pipelines, fmt.Sprintf("\"%s\" -> \"%s\"\n", copPlan.ExplainID(), copPlan.tablePlan.ExplainID()))
-			copTasks = append(copTasks, copPlan.tablePlan)
+			copTasks = append(copTasks, dotTask{copPlan.tablePlan, "cop"})
 		case *PhysicalIndexReader:
 			pipelines = append(pipelines, fmt.Sprintf("\"%s\" -> \"%s\"\n", copPlan.ExplainID(), copPlan.indexPlan.ExplainID()))
-			copTasks = append(copTasks, copPlan.indexPlan)
+			copTasks = append(copTasks, dotTask{copPlan.indexPlan, "cop"})
 		case *PhysicalIndexLookUpReader:
 			pipelines = append(pipelines, fmt.Sprintf("\"%s\" -> \"%s\"\n", copPlan.ExplainID(), copPlan.tablePlan.ExplainID()))
 			pipelines = append(pipelines, fmt.Sprintf("\"%s\" -> \"%s\"\n", copPlan.ExplainID(), copPlan.indexPlan.ExplainID()))
-			copTasks = append(copTasks, copPlan.tablePlan)
-			copTasks = append(copTasks, copPlan.indexPlan)
+			copTasks = append(copTasks, dotTask{copPlan.tablePlan, "cop"})
+			copTasks = append(copTasks, dotTask{copPlan.indexPlan, "cop"})
 		case *PhysicalIndexMergeReader:
 			for i := 0; i < len(copPlan.partialPlans); i++ {
 				pipelines = append(pipelines, fmt.Sprintf("\"%s\" -> \"%s\"\n", copPlan.ExplainID(), copPlan.partialPlans[i].ExplainID()))
-				copTasks = append(copTasks, copPlan.partialPlans[i])
+				copTasks = append(copTasks, dotTask{copPlan.partialPlans[i], "cop"})
 			}
 			if copPlan.tablePlan != nil {
 				pipelines = append(pipelines, fmt.Sprintf("\"%s\" -> \"%s\"\n", copPlan.ExplainID(), copPlan.tablePlan.ExplainID()))
-				copTasks = append(copTasks, copPlan.tablePlan)
+				copTasks = append(copTasks, dotTask{copPlan.tablePlan, "cop"})
 			}
+		case *PhysicalExchangeReceiver:
+			// The sender is the root of another MPP fragment.
+			sender := copPlan.GetExchangeSender()
+			pipelines = append(pipelines, dotExchangeEdge(copPlan, sender))
+			copTasks = append(copTasks, dotTask{sender, "mpp"})
+			continue
 		}
 		for _, child := range curPlan.Children() {
 			fmt.Fprintf(buffer, "\"%s\" -> \"%s\"\n", curPlan.ExplainID(), child.ExplainID())
@@ -1342,7 +1539,7 @@ func (e *Explain) prepareTaskDot(p PhysicalPlan, taskTp string, buffer *bytes.Bu
 	buffer.WriteString("}\n")
 
 	for _, cop := range copTasks {
-		e.prepareTaskDot(cop.(PhysicalPlan), "cop", buffer)
+		e.prepareTaskDot(cop.p, cop.taskTp, buffer)
 	}
 
 	for i := range pipelines {
@@ -1350,6 +1547,24 @@ func (e *Explain) prepareTaskDot(p PhysicalPlan, taskTp string, buffer *bytes.Bu
 	}
 }
 
+// dotNode returns the node of the plan in the DOT graph, which is labeled with the runtime stats if they're collected.
+func (e *Explain) dotNode(p Plan) string {
+	if !e.Analyze && e.RuntimeStatsColl == nil {
+		return fmt.Sprintf("\"%s\"\n", p.ExplainID())
+	}
+	actRows, analyzeInfo, memoryInfo, diskInfo := getRuntimeInfo(e.ctx, p, e.RuntimeStatsColl)
+	label := fmt.Sprintf("%s\nactRows: %s\nmemory: %s, disk: %s", p.ExplainID(), actRows, memoryInfo, diskInfo)
+	if len(analyzeInfo) > 0 {
+		label += "\n" + analyzeInfo
+	}
+	return fmt.Sprintf("\"%s\" [label=%s]\n", p.ExplainID(), strconv.Quote(label))
+}
+
+// dotExchangeEdge returns the edge from the plan to the ExchangeSender of the MPP fragment it receives.
+func dotExchangeEdge(p Plan, sender *PhysicalExchangeSender) string {
+	return fmt.Sprintf("\"%s\" -> \"%s\" [label=\"%s\"]\n", p.ExplainID(), sender.ExplainID(), sender.exchangeTypeName())
+}
+
 // IsPointGetWithPKOrUniqueKeyByAutoCommit returns true when meets following conditions:
 //  1. ctx is auto commit tagged
 //  2. session is not InTxn
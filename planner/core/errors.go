@@ -101,4 +101,6 @@ var (
 	ErrOptOnTemporaryTable = dbterror.ClassOptimizer.NewStd(mysql.ErrOptOnTemporaryTable)
 	// ErrPartitionNoTemporary returns when partition at temporary mode
 	ErrPartitionNoTemporary = dbterror.ClassOptimizer.NewStd(mysql.ErrPartitionNoTemporary)
+	// ErrInvalidSecurityPolicy returns when the predicate of a row-level security policy can't be applied to its table
+	ErrInvalidSecurityPolicy = dbterror.ClassOptimizer.NewStd(mysql.ErrInvalidSecurityPolicy)
 )
@@ -22,6 +22,7 @@ import (
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/parser/ast"
 	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/expression/aggregation"
 	"github.com/pingcap/tidb/kv"
@@ -222,12 +223,28 @@ func (p *LogicalJoin) GetMergeJoin(prop *property.PhysicalProperty, schema *expr
 	// If TiDB_SMJ hint is existed, it should consider enforce merge join,
 	// because we can't trust lhsChildProperty completely.
 	if (p.preferJoinType & preferMergeJoin) > 0 {
-		joins = append(joins, p.getEnforcedMergeJoin(prop, schema, statsInfo)...)
+		joins = append(joins, p.getEnforcedMergeJoin(prop, schema, statsInfo, false)...)
+	} else if p.hashJoinWouldSpill(leftStatsInfo, rightStatsInfo) {
+		// The merge join sorting its children externally may be cheaper than the hash join for the huge children.
+		joins = append(joins, p.getEnforcedMergeJoin(prop, schema, statsInfo, true)...)
 	}
 
 	return joins
 }
 
+// hashJoinWouldSpill returns whether both the children are estimated to be larger than the memory quota, so the
+// hash join would spill whichever child builds the hash table.
+func (p *LogicalJoin) hashJoinWouldSpill(leftStatsInfo, rightStatsInfo *property.StatsInfo) bool {
+	memQuota := p.ctx.GetSessionVars().StmtCtx.MemTracker.GetBytesLimit()
+	// The children are not kept by the cascades planner.
+	if !config.GetGlobalConfig().OOMUseTmpStorage || memQuota <= 0 || len(p.children) != 2 {
+		return false
+	}
+	leftSize := getAvgRowSize(leftStatsInfo, p.children[0].Schema()) * leftStatsInfo.RowCount
+	rightSize := getAvgRowSize(rightStatsInfo, p.children[1].Schema()) * rightStatsInfo.RowCount
+	return math.Min(leftSize, rightSize) > float64(memQuota)
+}
+
 // Change JoinKeys order, by offsets array
 // offsets array is generate by prop check
 func getNewJoinKeysByOffsets(oldJoinKeys []*expression.Column, offsets []int) []*expression.Column {
@@ -270,7 +287,9 @@ func getNewNullEQByOffsets(oldNullEQ []bool, offsets []int) []bool {
 	return newNullEQ
 }
 
-func (p *LogicalJoin) getEnforcedMergeJoin(prop *property.PhysicalProperty, schema *expression.Schema, statsInfo *property.StatsInfo) []PhysicalPlan {
+// getEnforcedMergeJoin gets the merge join whose children are sorted by the join keys. The children are sorted by the
+// enforced Sort, or by the merge join itself if sortChildren is true.
+func (p *LogicalJoin) getEnforcedMergeJoin(prop *property.PhysicalProperty, schema *expression.Schema, statsInfo *property.StatsInfo, sortChildren bool) []PhysicalPlan {
 	// Check whether SMJ can satisfy the required property
 	leftJoinKeys, rightJoinKeys, isNullEQ, hasNullEQ := p.GetJoinKeys()
 	// TODO: support null equal join keys for merge join
@@ -327,6 +346,14 @@ func (p *LogicalJoin) getEnforcedMergeJoin(prop *property.PhysicalProperty, sche
 	}
 	lProp := property.NewPhysicalProperty(property.RootTaskType, leftKeys, desc, math.MaxFloat64, true)
 	rProp := property.NewPhysicalProperty(property.RootTaskType, rightKeys, desc, math.MaxFloat64, true)
+	if sortChildren {
+		// Sorting the children is useless without the join keys.
+		if len(leftKeys) == 0 {
+			return nil
+		}
+		lProp = &property.PhysicalProperty{TaskTp: property.RootTaskType, ExpectedCnt: math.MaxFloat64}
+		rProp = &property.PhysicalProperty{TaskTp: property.RootTaskType, ExpectedCnt: math.MaxFloat64}
+	}
 	baseJoin := basePhysicalJoin{
 		JoinType:        p.JoinType,
 		LeftConditions:  p.LeftConditions,
@@ -337,7 +364,7 @@ func (p *LogicalJoin) getEnforcedMergeJoin(prop *property.PhysicalProperty, sche
 		IsNullEQ:        newNullEQ,
 		OtherConditions: otherConditions,
 	}
-	enforcedPhysicalMergeJoin := PhysicalMergeJoin{basePhysicalJoin: baseJoin, Desc: desc}.Init(p.ctx, statsInfo.ScaleByExpectCnt(prop.ExpectedCnt), p.blockOffset)
+	enforcedPhysicalMergeJoin := PhysicalMergeJoin{basePhysicalJoin: baseJoin, Desc: desc, SortChildren: sortChildren}.Init(p.ctx, statsInfo.ScaleByExpectCnt(prop.ExpectedCnt), p.blockOffset)
 	enforcedPhysicalMergeJoin.SetSchema(schema)
 	enforcedPhysicalMergeJoin.childrenReqProps = []*property.PhysicalProperty{lProp, rProp}
 	enforcedPhysicalMergeJoin.initCompareFuncs()
@@ -1689,7 +1716,7 @@ func (p *LogicalJoin) exhaustPhysicalPlans(prop *property.PhysicalProperty) ([]P
 		}
 	})
 
-	if prop.IsFlashProp() && ((p.preferJoinType&preferBCJoin) == 0 && p.preferJoinType > 0) {
+	if prop.IsFlashProp() && ((p.preferJoinType&(preferBCJoin|preferShuffleJoin)) == 0 && p.preferJoinType > 0) {
 		return nil, false, nil
 	}
 	if prop.MPPPartitionTp == property.BroadcastType {
@@ -1698,7 +1725,13 @@ func (p *LogicalJoin) exhaustPhysicalPlans(prop *property.PhysicalProperty) ([]P
 	joins := make([]PhysicalPlan, 0, 8)
 	canPushToTiFlash := p.canPushToCop(kv.TiFlash)
 	if p.ctx.GetSessionVars().IsMPPAllowed() && canPushToTiFlash {
-		if p.shouldUseMPPBCJ() {
+		if (p.preferJoinType & preferShuffleJoin) > 0 {
+			if mppJoins := p.tryToGetMppHashJoin(prop, false); len(mppJoins) > 0 {
+				return mppJoins, true, nil
+			}
+		}
+		// The broadcast join hint forces the broadcast join even if the children are larger than the thresholds.
+		if p.shouldUseMPPBCJ() || (p.preferJoinType&preferBCJoin) > 0 {
 			mppJoins := p.tryToGetMppHashJoin(prop, true)
 			if (p.preferJoinType & preferBCJoin) > 0 {
 				return mppJoins, true, nil
@@ -1714,6 +1747,9 @@ func (p *LogicalJoin) exhaustPhysicalPlans(prop *property.PhysicalProperty) ([]P
 			return broadCastJoins, true, nil
 		}
 		joins = append(joins, broadCastJoins...)
+		p.appendInapplicableMPPJoinHintWarning(prop, canPushToTiFlash)
+	} else {
+		p.appendInapplicableMPPJoinHintWarning(prop, canPushToTiFlash)
 	}
 	if prop.IsFlashProp() {
 		return joins, true, nil
@@ -1746,6 +1782,31 @@ func (p *LogicalJoin) exhaustPhysicalPlans(prop *property.PhysicalProperty) ([]P
 	return joins, true, nil
 }
 
+// appendInapplicableMPPJoinHintWarning warns the broadcast join and shuffle join hints which can't be applied since the
+// join can't be executed in the MPP mode. Like the index join hints, the warning is only generated when the required
+// property is empty, since the hints are tried again with the property enforced.
+func (p *LogicalJoin) appendInapplicableMPPJoinHintWarning(prop *property.PhysicalProperty, canPushToTiFlash bool) {
+	if !prop.IsEmpty() || p.hintInfo == nil {
+		return
+	}
+	var errMsg string
+	switch {
+	case (p.preferJoinType&preferBCJoin) > 0 && !(canPushToTiFlash && p.ctx.GetSessionVars().AllowBCJ):
+		errMsg = fmt.Sprintf("Optimizer Hint %s or %s is inapplicable", restore2JoinHint(HintBCJ, p.hintInfo.broadcastJoinTables),
+			restore2JoinHint(TiDBBroadCastJoin, p.hintInfo.broadcastJoinTables))
+	case (p.preferJoinType & preferShuffleJoin) > 0:
+		errMsg = fmt.Sprintf("Optimizer Hint %s is inapplicable", restore2JoinHint(HintShuffleJoin, p.hintInfo.shuffleJoinTables))
+	default:
+		return
+	}
+	if !canPushToTiFlash {
+		errMsg += ", the join can't be pushed down to TiFlash"
+	} else {
+		errMsg += ", the MPP mode is not allowed"
+	}
+	p.ctx.GetSessionVars().StmtCtx.AppendWarning(ErrInternal.GenWithStack(errMsg))
+}
+
 func canExprsInJoinPushdown(p *LogicalJoin, storeType kv.StoreType) bool {
 	equalExprs := make([]expression.Expression, 0, len(p.EqualConditions))
 	for _, eqCondition := range p.EqualConditions {
@@ -656,6 +656,9 @@ func (p *PhysicalMergeJoin) explainInfo(normalized bool) string {
 		fmt.Fprintf(buffer, ", other cond:%s",
 			sortedExplainExpressionList(p.OtherConditions))
 	}
+	if p.SortChildren {
+		buffer.WriteString(", sort children")
+	}
 	return buffer.String()
 }
 
@@ -858,16 +861,24 @@ func (ds *DataSource) ExplainInfo() string {
 	return buffer.String()
 }
 
-// ExplainInfo implements Plan interface.
-func (p *PhysicalExchangeSender) ExplainInfo() string {
-	buffer := bytes.NewBufferString("ExchangeType: ")
+// exchangeTypeName returns the name of the exchange type shown in the explain results.
+func (p *PhysicalExchangeSender) exchangeTypeName() string {
 	switch p.ExchangeType {
 	case tipb.ExchangeType_PassThrough:
-		fmt.Fprintf(buffer, "PassThrough")
+		return "PassThrough"
 	case tipb.ExchangeType_Broadcast:
-		fmt.Fprintf(buffer, "Broadcast")
+		return "Broadcast"
 	case tipb.ExchangeType_Hash:
-		fmt.Fprintf(buffer, "HashPartition")
+		return "HashPartition"
+	}
+	return ""
+}
+
+// ExplainInfo implements Plan interface.
+func (p *PhysicalExchangeSender) ExplainInfo() string {
+	buffer := bytes.NewBufferString("ExchangeType: ")
+	buffer.WriteString(p.exchangeTypeName())
+	if p.ExchangeType == tipb.ExchangeType_Hash {
 		fmt.Fprintf(buffer, ", Hash Cols: %s", expression.ExplainColumnList(p.HashCols))
 	}
 	if len(p.Tasks) > 0 {
@@ -287,29 +287,37 @@ func (er *expressionRewriter) constructBinaryOpFunction(l expression.Expression,
 		}
 		return expression.ComposeCNFCondition(er.sctx, funcs...), nil
 	default:
+		// `(a, b) > (1, 2)` is rewritten to `a > 1 or (a = 1 and b > 2)`, which has the same result as the row
+		// comparison even if some of the values are NULL, and the index ranges can be built from it by the ranger.
+		strictOp := op
+		switch op {
+		case ast.GE:
+			strictOp = ast.GT
+		case ast.LE:
+			strictOp = ast.LT
+		}
 		larg0, rarg0 := expression.GetFuncArg(l, 0), expression.GetFuncArg(r, 0)
-		var expr1, expr2, expr3, expr4, expr5 expression.Expression
-		expr1 = expression.NewFunctionInternal(er.sctx, ast.NE, types.NewFieldType(mysql.TypeTiny), larg0, rarg0)
-		expr2 = expression.NewFunctionInternal(er.sctx, op, types.NewFieldType(mysql.TypeTiny), larg0, rarg0)
-		expr3 = expression.NewFunctionInternal(er.sctx, ast.IsNull, types.NewFieldType(mysql.TypeTiny), expr1)
-		var err error
-		l, err = expression.PopRowFirstArg(er.sctx, l)
+		expr1, err := er.constructBinaryOpFunction(larg0, rarg0, strictOp)
 		if err != nil {
 			return nil, err
 		}
-		r, err = expression.PopRowFirstArg(er.sctx, r)
+		expr2, err := er.constructBinaryOpFunction(larg0, rarg0, ast.EQ)
+		if err != nil {
+			return nil, err
+		}
+		l, err = expression.PopRowFirstArg(er.sctx, l)
 		if err != nil {
 			return nil, err
 		}
-		expr4, err = er.constructBinaryOpFunction(l, r, op)
+		r, err = expression.PopRowFirstArg(er.sctx, r)
 		if err != nil {
 			return nil, err
 		}
-		expr5, err = er.newFunction(ast.If, types.NewFieldType(mysql.TypeTiny), expr3, expression.NewNull(), expr4)
+		expr3, err := er.constructBinaryOpFunction(l, r, op)
 		if err != nil {
 			return nil, err
 		}
-		return er.newFunction(ast.If, types.NewFieldType(mysql.TypeTiny), expr1, expr2, expr5)
+		return expression.ComposeDNFCondition(er.sctx, expr1, expression.ComposeCNFCondition(er.sctx, expr2, expr3)), nil
 	}
 }
 
@@ -15,6 +15,7 @@ package core
 
 import (
 	"context"
+	"fmt"
 	"math"
 
 	"github.com/pingcap/errors"
@@ -264,6 +265,7 @@ func (p *baseLogicalPlan) enumeratePhysicalPlans4Task(physicalPlans []PhysicalPl
 			// Currently, we do not regard shuffled plan as a new plan.
 			curTask = optimizeByShuffle(curTask, p.basePlan.ctx)
 		}
+		traceCandidateTask(p.self, curTask, prop)
 
 		cntPlan += curCntPlan
 		planCounter.Dec(curCntPlan)
@@ -280,6 +282,25 @@ func (p *baseLogicalPlan) enumeratePhysicalPlans4Task(physicalPlans []PhysicalPl
 	return bestTask, cntPlan, nil
 }
 
+// traceCandidateTask records the cost of a candidate task of the logical plan p into the optimizer trace.
+func traceCandidateTask(p LogicalPlan, t task, prop *property.PhysicalProperty) {
+	sc := p.SCtx().GetSessionVars().StmtCtx
+	if !sc.EnableOptimizerTrace || t.invalid() || t.plan() == nil {
+		return
+	}
+	var taskTp string
+	switch t.(type) {
+	case *copTask:
+		taskTp = "cop"
+	case *mppTask:
+		taskTp = "mpp"
+	default:
+		taskTp = "root"
+	}
+	sc.AppendOptimizerTrace(stmtctx.TraceTypeCandidate, p.ExplainID().String(), fmt.Sprintf("%s task %s with cost %v for the required property %s",
+		taskTp, ToString(t.plan()), t.cost(), prop.String()))
+}
+
 // findBestTask implements LogicalPlan interface.
 func (p *baseLogicalPlan) findBestTask(prop *property.PhysicalProperty, planCounter *PlanCounterTp) (bestTask task, cntPlan int64, err error) {
 	// If p is an inner plan in an IndexJoin, the IndexJoin will generate an inner plan by itself,
@@ -478,7 +499,7 @@ func (ds *DataSource) getTableCandidate(path *util.AccessPath, prop *property.Ph
 	candidate := &candidatePath{path: path}
 	if path.IsIntHandlePath {
 		pkCol := ds.getPKIsHandleCol()
-		if len(prop.SortItems) == 1 && pkCol != nil {
+		if len(prop.SortItems) == 1 && pkCol != nil && !path.ForceNoKeepOrder {
 			candidate.isMatchProp = prop.SortItems[0].Col.Equal(nil, pkCol)
 			if path.StoreType == kv.TiFlash {
 				candidate.isMatchProp = candidate.isMatchProp && !prop.SortItems[0].Desc
@@ -488,7 +509,7 @@ func (ds *DataSource) getTableCandidate(path *util.AccessPath, prop *property.Ph
 		all, _ := prop.AllSameOrder()
 		// When the prop is empty or `all` is false, `isMatchProp` is better to be `false` because
 		// it needs not to keep order for index scan.
-		if !prop.IsEmpty() && all {
+		if !prop.IsEmpty() && all && !path.ForceNoKeepOrder {
 			for i, col := range path.IdxCols {
 				if col.Equal(nil, prop.SortItems[0].Col) {
 					candidate.isMatchProp = matchIndicesProp(path.IdxCols[i:], path.IdxColLens[i:], prop.SortItems)
@@ -509,7 +530,7 @@ func (ds *DataSource) getIndexCandidate(path *util.AccessPath, prop *property.Ph
 	all, _ := prop.AllSameOrder()
 	// When the prop is empty or `all` is false, `isMatchProp` is better to be `false` because
 	// it needs not to keep order for index scan.
-	if !prop.IsEmpty() && all {
+	if !prop.IsEmpty() && all && !path.ForceNoKeepOrder {
 		for i, col := range path.IdxCols {
 			if col.Equal(nil, prop.SortItems[0].Col) {
 				candidate.isMatchProp = matchIndicesProp(path.IdxCols[i:], path.IdxColLens[i:], prop.SortItems)
@@ -583,9 +604,11 @@ func (ds *DataSource) skylinePruning(prop *property.PhysicalProperty) []*candida
 			result := compareCandidates(candidates[i], currentCandidate)
 			if result == 1 {
 				pruned = true
+				ds.tracePrunedPath(currentCandidate, candidates[i], prop)
 				// We can break here because the current candidate cannot prune others anymore.
 				break
 			} else if result == -1 {
+				ds.tracePrunedPath(candidates[i], currentCandidate, prop)
 				candidates = append(candidates[:i], candidates[i+1:]...)
 			}
 		}
@@ -609,6 +632,30 @@ func (ds *DataSource) skylinePruning(prop *property.PhysicalProperty) []*candida
 	return candidates
 }
 
+// tracePrunedPath records that the access path pruned is pruned by the access path by into the optimizer trace.
+func (ds *DataSource) tracePrunedPath(pruned, by *candidatePath, prop *property.PhysicalProperty) {
+	sc := ds.ctx.GetSessionVars().StmtCtx
+	if !sc.EnableOptimizerTrace {
+		return
+	}
+	sc.AppendOptimizerTrace(stmtctx.TraceTypePruned, ds.ExplainID().String(), fmt.Sprintf("%s is pruned by %s for the required property %s",
+		accessPathName(ds.tableInfo, pruned.path), accessPathName(ds.tableInfo, by.path), prop.String()))
+}
+
+// accessPathName returns the name of the access path shown in the optimizer trace.
+func accessPathName(tblInfo *model.TableInfo, path *util.AccessPath) string {
+	switch {
+	case path.PartialIndexPaths != nil:
+		return "the index merge path of table " + tblInfo.Name.O
+	case path.IsTablePath() && path.StoreType == kv.TiFlash:
+		return "the tiflash table path of table " + tblInfo.Name.O
+	case path.IsTablePath():
+		return "the table path of table " + tblInfo.Name.O
+	default:
+		return "the index path " + path.Index.Name.O + " of table " + tblInfo.Name.O
+	}
+}
+
 // findBestTask implements the PhysicalPlan interface.
 // It will enumerate all the available indices and choose a plan with least cost.
 func (ds *DataSource) findBestTask(prop *property.PhysicalProperty, planCounter *PlanCounterTp) (t task, cntPlan int64, err error) {
@@ -625,6 +672,19 @@ func (ds *DataSource) findBestTask(prop *property.PhysicalProperty, planCounter
 		planCounter.Dec(1)
 		return
 	}
+	if len(ds.keepOrderItems) > 0 && prop.IsEmpty() && prop.MPPPartitionTp == property.AnyType {
+		// The hinted paths are scanned in the order required by the ORDER BY even if the order isn't required
+		// here, so the sort on this plan is never cheaper than the plan providing the order.
+		orderedProp := &property.PhysicalProperty{TaskTp: prop.TaskTp, ExpectedCnt: prop.ExpectedCnt, SortItems: ds.keepOrderItems}
+		t, cntPlan, err = ds.findBestTask(orderedProp, planCounter)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !t.invalid() {
+			ds.storeTask(prop, t)
+			return
+		}
+	}
 	var cnt int64
 	// If prop.enforced is true, the prop.cols need to be set nil for ds.findBestTask.
 	// Before function return, reset it for enforcing task prop and storing map<prop,task>.
@@ -683,6 +743,7 @@ func (ds *DataSource) findBestTask(prop *property.PhysicalProperty, planCounter
 			if err != nil {
 				return nil, 0, err
 			}
+			traceCandidateTask(ds, idxMergeTask, prop)
 			if !idxMergeTask.invalid() {
 				cntPlan += 1
 				planCounter.Dec(1)
@@ -749,6 +810,7 @@ func (ds *DataSource) findBestTask(prop *property.PhysicalProperty, planCounter
 				} else {
 					pointGetTask = ds.convertToBatchPointGet(prop, candidate, hashPartColName)
 				}
+				traceCandidateTask(ds, pointGetTask, prop)
 				if !pointGetTask.invalid() {
 					cntPlan += 1
 					planCounter.Dec(1)
@@ -778,6 +840,7 @@ func (ds *DataSource) findBestTask(prop *property.PhysicalProperty, planCounter
 			if err != nil {
 				return nil, 0, err
 			}
+			traceCandidateTask(ds, tblTask, prop)
 			if !tblTask.invalid() {
 				cntPlan += 1
 				planCounter.Dec(1)
@@ -798,6 +861,7 @@ func (ds *DataSource) findBestTask(prop *property.PhysicalProperty, planCounter
 		if err != nil {
 			return nil, 0, err
 		}
+		traceCandidateTask(ds, idxTask, prop)
 		if !idxTask.invalid() {
 			cntPlan += 1
 			planCounter.Dec(1)
@@ -875,7 +939,7 @@ func (ds *DataSource) convertToPartialIndexScan(prop *property.PhysicalProperty,
 		rowCount = is.stats.RowCount * selectivity
 		stats := &property.StatsInfo{RowCount: rowCount}
 		stats.StatsVersion = ds.statisticTable.Version
-		if ds.statisticTable.Pseudo {
+		if ds.statisticTable.Pseudo && !ds.liteStats {
 			stats.StatsVersion = statistics.PseudoVersion
 		}
 		indexPlan := PhysicalSelection{Conditions: indexConds}.Init(is.ctx, stats, ds.blockOffset)
@@ -973,7 +1037,7 @@ func (ds *DataSource) buildIndexMergeTableScan(prop *property.PhysicalProperty,
 	rowSize := ds.TblColHists.GetTableAvgRowSize(ds.ctx, ds.TblCols, ts.StoreType, true)
 	partialCost += totalRowCount * rowSize * sessVars.GetScanFactor(ds.tableInfo)
 	ts.stats = ds.tableStats.ScaleByExpectCnt(totalRowCount)
-	if ds.statisticTable.Pseudo {
+	if ds.statisticTable.Pseudo && !ds.liteStats {
 		ts.stats.StatsVersion = statistics.PseudoVersion
 	}
 	if len(tableFilters) > 0 {
@@ -53,15 +53,21 @@ type mppTaskGenerator struct {
 	is      infoschema.InfoSchema
 	frags   []*Fragment
 	cache   map[int]tasksAndFrags
+	// builtFrags are the fragments built for the exchange senders.
+	builtFrags map[int][]*Fragment
+	// scanTasks are the tasks of the table scans, which are built in one request.
+	scanTasks map[*PhysicalTableScan][]*kv.MPPTask
 }
 
 // GenerateRootMPPTasks generate all mpp tasks and return root ones.
 func GenerateRootMPPTasks(ctx sessionctx.Context, startTs uint64, sender *PhysicalExchangeSender, is infoschema.InfoSchema) ([]*Fragment, error) {
 	g := &mppTaskGenerator{
-		ctx:     ctx,
-		startTS: startTs,
-		is:      is,
-		cache:   make(map[int]tasksAndFrags),
+		ctx:        ctx,
+		startTS:    startTs,
+		is:         is,
+		cache:      make(map[int]tasksAndFrags),
+		builtFrags: make(map[int][]*Fragment),
+		scanTasks:  make(map[*PhysicalTableScan][]*kv.MPPTask),
 	}
 	return g.generateMPPTasks(sender)
 }
@@ -72,6 +78,9 @@ func (e *mppTaskGenerator) generateMPPTasks(s *PhysicalExchangeSender) ([]*Fragm
 		StartTs: e.startTS,
 		ID:      -1,
 	}
+	if err := e.constructMPPTasksForTableScans(context.Background(), s); err != nil {
+		return nil, errors.Trace(err)
+	}
 	_, frags, err := e.generateMPPTasksForExchangeSender(s)
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -215,7 +224,7 @@ func (e *mppTaskGenerator) generateMPPTasksForExchangeSender(s *PhysicalExchange
 	if cached, ok := e.cache[s.ID()]; ok {
 		return cached.tasks, cached.frags, nil
 	}
-	frags, err := buildFragments(s)
+	frags, err := e.getFragments(s)
 	if err != nil {
 		return nil, nil, errors.Trace(err)
 	}
@@ -240,7 +249,7 @@ func (e *mppTaskGenerator) generateMPPTasksForFragment(f *Fragment) (tasks []*kv
 		}
 	}
 	if f.TableScan != nil {
-		tasks, err = e.constructMPPTasksImpl(context.Background(), f.TableScan)
+		tasks = e.scanTasks[f.TableScan]
 	} else {
 		childrenTasks := make([]*kv.MPPTask, 0)
 		for _, r := range f.ExchangeReceivers {
@@ -292,8 +301,83 @@ func partitionPruning(ctx sessionctx.Context, tbl table.PartitionedTable, conds
 	return ret, nil
 }
 
-// single physical table means a table without partitions or a single partition in a partition table.
-func (e *mppTaskGenerator) constructMPPTasksImpl(ctx context.Context, ts *PhysicalTableScan) ([]*kv.MPPTask, error) {
+// getFragments returns the fragments of the exchange sender, which are only built once.
+func (e *mppTaskGenerator) getFragments(s *PhysicalExchangeSender) ([]*Fragment, error) {
+	if frags, ok := e.builtFrags[s.ID()]; ok {
+		return frags, nil
+	}
+	frags, err := buildFragments(s)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	e.builtFrags[s.ID()] = frags
+	return frags, nil
+}
+
+// collectTableScans collects the table scans of the fragments of the exchange sender and its children senders.
+func (e *mppTaskGenerator) collectTableScans(s *PhysicalExchangeSender, visited map[int]struct{}, scans []*PhysicalTableScan) ([]*PhysicalTableScan, error) {
+	if _, ok := visited[s.ID()]; ok {
+		return scans, nil
+	}
+	visited[s.ID()] = struct{}{}
+	frags, err := e.getFragments(s)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, f := range frags {
+		if f.TableScan != nil {
+			scans = append(scans, f.TableScan)
+		}
+		for _, r := range f.ExchangeReceivers {
+			scans, err = e.collectTableScans(r.GetExchangeSender(), visited, scans)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+	}
+	return scans, nil
+}
+
+// constructMPPTasksForTableScans builds the tasks of all the table scans in the fragment tree of the exchange sender
+// in one request, rather than a request for each table scan.
+func (e *mppTaskGenerator) constructMPPTasksForTableScans(ctx context.Context, s *PhysicalExchangeSender) error {
+	scans, err := e.collectTableScans(s, make(map[int]struct{}), nil)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(scans) == 0 {
+		return nil
+	}
+	var partitionIDAndRanges []kv.PartitionIDAndRanges
+	for i, ts := range scans {
+		scanRanges, err := e.getPartitionIDAndRanges(ts)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		for j := range scanRanges {
+			scanRanges[j].ScanID = i
+		}
+		partitionIDAndRanges = append(partitionIDAndRanges, scanRanges...)
+	}
+	req := &kv.MPPBuildTasksRequest{StartTS: e.startTS, PartitionIDAndRanges: partitionIDAndRanges}
+	metas, err := e.ctx.GetMPPClient().ConstructMPPTasks(ctx, req)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, meta := range metas {
+		tableMeta, ok := meta.(kv.MPPTableTaskMeta)
+		if !ok || tableMeta.GetScanID() < 0 || tableMeta.GetScanID() >= len(scans) {
+			return errors.New("the mpp task doesn't specify the table scan to read")
+		}
+		ts := scans[tableMeta.GetScanID()]
+		e.scanTasks[ts] = append(e.scanTasks[ts], &kv.MPPTask{Meta: meta, ID: e.ctx.GetSessionVars().AllocMPPTaskID(e.startTS), StartTs: e.startTS, TableID: tableMeta.GetPhysicalTableID()})
+	}
+	return nil
+}
+
+// getPartitionIDAndRanges returns the ranges of the physical tables read by the table scan, a table without partitions
+// is one physical table.
+func (e *mppTaskGenerator) getPartitionIDAndRanges(ts *PhysicalTableScan) ([]kv.PartitionIDAndRanges, error) {
 	// update ranges according to correlated columns in access conditions like in the Open() of TableReaderExecutor
 	for _, cond := range ts.AccessCondition {
 		if len(expression.ExtractCorColumns(cond)) > 0 {
@@ -313,7 +397,7 @@ func (e *mppTaskGenerator) constructMPPTasksImpl(ctx context.Context, ts *Physic
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
-		var ret []*kv.MPPTask
+		partitionIDAndRanges := make([]kv.PartitionIDAndRanges, 0, len(partitions))
 		for _, p := range partitions {
 			pid := p.GetPhysicalID()
 			meta := p.Meta()
@@ -321,31 +405,14 @@ func (e *mppTaskGenerator) constructMPPTasksImpl(ctx context.Context, ts *Physic
 			if err != nil {
 				return nil, errors.Trace(err)
 			}
-			tasks, err := e.constructMPPTasksForSinglePartitionTable(ctx, kvRanges, pid)
-			if err != nil {
-				return nil, errors.Trace(err)
-			}
-			ret = append(ret, tasks...)
+			partitionIDAndRanges = append(partitionIDAndRanges, kv.PartitionIDAndRanges{ID: pid, KeyRanges: kvRanges})
 		}
-		return ret, nil
+		return partitionIDAndRanges, nil
 	}
 
 	kvRanges, err := distsql.TableHandleRangesToKVRanges(e.ctx.GetSessionVars().StmtCtx, []int64{ts.Table.ID}, ts.Table.IsCommonHandle, splitedRanges, nil)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	return e.constructMPPTasksForSinglePartitionTable(ctx, kvRanges, ts.Table.ID)
-}
-
-func (e *mppTaskGenerator) constructMPPTasksForSinglePartitionTable(ctx context.Context, kvRanges []kv.KeyRange, tableID int64) ([]*kv.MPPTask, error) {
-	req := &kv.MPPBuildTasksRequest{KeyRanges: kvRanges}
-	metas, err := e.ctx.GetMPPClient().ConstructMPPTasks(ctx, req)
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
-	tasks := make([]*kv.MPPTask, 0, len(metas))
-	for _, meta := range metas {
-		tasks = append(tasks, &kv.MPPTask{Meta: meta, ID: e.ctx.GetSessionVars().AllocMPPTaskID(e.startTS), StartTs: e.startTS, TableID: tableID})
-	}
-	return tasks, nil
+	return []kv.PartitionIDAndRanges{{ID: ts.Table.ID, KeyRanges: kvRanges}}, nil
 }
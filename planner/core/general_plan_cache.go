@@ -0,0 +1,288 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/format"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/tidb/config"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/types"
+	driver "github.com/pingcap/tidb/types/parser_driver"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/hack"
+	"github.com/pingcap/tidb/util/hint"
+	"github.com/pingcap/tidb/util/kvcache"
+	"github.com/pingcap/tidb/util/stringutil"
+)
+
+// generalPlanCacheKey is used to get the general plan cache of the session.
+const generalPlanCacheKey = stringutil.StringerStr("generalPlanCacheKey")
+
+// generalPlanCache keeps the statements parameterized from the non-prepared statements of a session, the plans of
+// them are cached in the prepared plan cache like the prepared statements. The least recently used statement is
+// evicted when the number of the statements exceeds the capacity of the prepared plan cache.
+type generalPlanCache struct {
+	stmts *kvcache.SimpleLRUCache
+}
+
+// generalStmtKey identifies a parameterized statement. The statements which only differ in the literals of the WHERE
+// clause have the same key if the types of the literals are the same.
+type generalStmtKey struct {
+	db         string
+	paramSQL   string
+	paramTypes string
+	hash       []byte
+}
+
+// Hash implements Key interface.
+func (key *generalStmtKey) Hash() []byte {
+	if len(key.hash) == 0 {
+		key.hash = make([]byte, 0, len(key.db)+len(key.paramSQL)+len(key.paramTypes)+3*binary.MaxVarintLen64)
+		key.hash = codec.EncodeCompactBytes(key.hash, hack.Slice(key.db))
+		key.hash = codec.EncodeCompactBytes(key.hash, hack.Slice(key.paramSQL))
+		key.hash = codec.EncodeCompactBytes(key.hash, hack.Slice(key.paramTypes))
+	}
+	return key.hash
+}
+
+// generalStmt is a parameterized statement, it works like a prepared statement whose parameters are the literals
+// of the WHERE clause.
+type generalStmt struct {
+	id       uint32
+	prepared *CachedPrepareStmt
+}
+
+// OptimizeByGeneralPlanCache optimizes the non-prepared SELECT statement by the general plan cache. The literals of
+// the WHERE clause are extracted as the parameters, so the statements only differ in them share the same cached plan.
+// The plans are cached and checked like the prepared statements, so the plans depending on the values of the
+// parameters, such as the plans whose ranges can't be rebuilt, are never reused. ok is false if the statement can't
+// use the general plan cache, then it should be optimized as usual.
+func OptimizeByGeneralPlanCache(ctx context.Context, sctx sessionctx.Context, stmt *ast.SelectStmt, is infoschema.InfoSchema) (p Plan, names types.NameSlice, ok bool, err error) {
+	vars := sctx.GetSessionVars()
+	if !PreparedPlanCacheEnabled() || vars.StmtCtx.UseCache || vars.StmtCtx.InExplainStmt || vars.InRestrictedSQL {
+		return nil, nil, false, nil
+	}
+	if stmt.Kind != ast.SelectStmtKindSelect || stmt.SelectIntoOpt != nil || stmt.With != nil || hasParamMarker(stmt) {
+		return nil, nil, false, nil
+	}
+	if cacheable, reason := CacheableWithReason(stmt, cacheableSchema(sctx, is)); !cacheable {
+		vars.StmtCtx.AppendOptimizerNote(errors.New("skip general plan-cache: " + reason))
+		return nil, nil, false, nil
+	}
+	paramSQL, values, err := parameterize(stmt)
+	if err != nil {
+		return nil, nil, false, nil
+	}
+	key := &generalStmtKey{db: vars.CurrentDB, paramSQL: paramSQL, paramTypes: paramTypesString(values)}
+	cache, _ := sctx.Value(generalPlanCacheKey).(*generalPlanCache)
+	if cache == nil {
+		// The plans of the evicted statements are evicted by the LRU of the prepared plan cache later.
+		cache = &generalPlanCache{stmts: kvcache.NewSimpleLRUCache(config.GetGlobalConfig().PreparedPlanCache.Capacity, 0, 0)}
+		sctx.SetValue(generalPlanCacheKey, cache)
+	}
+	var gs *generalStmt
+	if value, exists := cache.stmts.Get(key); exists {
+		gs = value.(*generalStmt)
+	}
+	if gs == nil || gs.prepared.PreparedAst.SchemaVersion != is.SchemaMetaVersion() {
+		gs, err = newGeneralStmt(ctx, sctx, paramSQL, len(values), is)
+		if err != nil {
+			// The statement is optimized as usual, so the error is reported by the normal optimization if any.
+			return nil, nil, false, nil
+		}
+		cache.stmts.Put(key, gs)
+	}
+	if !gs.prepared.PreparedAst.UseCache {
+		return nil, nil, false, nil
+	}
+
+	prepared := gs.prepared.PreparedAst
+	vars.PreparedParams = vars.PreparedParams[:0]
+	for i, value := range values {
+		param := prepared.Params[i].(*driver.ParamMarkerExpr)
+		param.Datum = value.Datum
+		param.InExecute = true
+		vars.PreparedParams = append(vars.PreparedParams, value.Datum)
+	}
+	e := &Execute{ExecID: gs.id, PrepareParams: vars.PreparedParams}
+	if err := e.getPhysicalPlan(ctx, sctx, is, gs.prepared); err != nil {
+		return nil, nil, false, err
+	}
+	return e.Plan, e.names, true, nil
+}
+
+// parameterize returns the SQL text of the statement whose literals in the WHERE clause are replaced with the
+// parameter markers, and the replaced literals in the order of visiting. The statement itself is not changed.
+func parameterize(stmt *ast.SelectStmt) (string, []*driver.ValueExpr, error) {
+	if stmt.Where == nil {
+		var sb strings.Builder
+		err := stmt.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb))
+		return sb.String(), nil, err
+	}
+	where := stmt.Where
+	defer func() {
+		stmt.Where = where
+	}()
+	replacer := &paramReplacer{}
+	newWhere, _ := where.Accept(replacer)
+	stmt.Where = newWhere.(ast.ExprNode)
+	var sb strings.Builder
+	err := stmt.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb))
+	// Put the literals back.
+	replacer.restore = true
+	newWhere.Accept(replacer)
+	return sb.String(), replacer.values, err
+}
+
+// paramReplacer replaces the literals with the parameter markers, and puts the literals back if restore is true.
+type paramReplacer struct {
+	restore bool
+	values  []*driver.ValueExpr
+	offset  int
+}
+
+// Enter implements Visitor interface.
+func (r *paramReplacer) Enter(in ast.Node) (ast.Node, bool) {
+	return in, false
+}
+
+// Leave implements Visitor interface.
+func (r *paramReplacer) Leave(in ast.Node) (ast.Node, bool) {
+	if r.restore {
+		if _, ok := in.(*driver.ParamMarkerExpr); ok {
+			r.offset++
+			return r.values[r.offset-1], true
+		}
+		return in, true
+	}
+	if v, ok := in.(*driver.ValueExpr); ok {
+		r.values = append(r.values, v)
+		return &driver.ParamMarkerExpr{}, true
+	}
+	return in, true
+}
+
+// paramTypesString returns the types of the literals, the statements whose literals have different types are cached
+// separately since the types of the parameters decide the types of the expressions in the plans.
+func paramTypesString(values []*driver.ValueExpr) string {
+	var sb strings.Builder
+	for _, v := range values {
+		tp := v.GetType()
+		fmt.Fprintf(&sb, "%d,%d,%s", tp.Tp, tp.Flag&mysql.UnsignedFlag, tp.Collate)
+		if tp.Tp == mysql.TypeNewDecimal {
+			fmt.Fprintf(&sb, ",%d", tp.Decimal)
+		}
+		sb.WriteByte(';')
+	}
+	return sb.String()
+}
+
+// newGeneralStmt prepares the parameterized SQL like the PREPARE statement.
+func newGeneralStmt(ctx context.Context, sctx sessionctx.Context, paramSQL string, paramCount int, is infoschema.InfoSchema) (*generalStmt, error) {
+	vars := sctx.GetSessionVars()
+	charset, collation := vars.GetCharsetInfo()
+	p := parser.New()
+	p.SetParserConfig(vars.BuildParserConfig())
+	stmt, err := p.ParseOneStmt(paramSQL, charset, collation)
+	if err != nil {
+		return nil, err
+	}
+	var collector paramMarkerCollector
+	stmt.Accept(&collector)
+	if len(collector.markers) != paramCount {
+		return nil, errors.Errorf("the parameterized statement has %d parameters, expected %d", len(collector.markers), paramCount)
+	}
+	// The parameters are ordered in the visiting order, which is the order of the extracted literals.
+	for i, marker := range collector.markers {
+		marker.SetOrder(i)
+	}
+	ret := &PreprocessorReturn{InfoSchema: is}
+	if err := Preprocess(sctx, stmt, InPrepare, WithPreprocessorReturn(ret)); err != nil {
+		return nil, err
+	}
+	if ret.SnapshotTSEvaluator != nil {
+		return nil, errors.New("the stale read statement can't use the general plan cache")
+	}
+	prepared := &ast.Prepared{
+		Stmt:          stmt,
+		StmtType:      "Select",
+		Params:        collector.markers,
+		SchemaVersion: is.SchemaMetaVersion(),
+	}
+	prepared.UseCache = Cacheable(stmt, cacheableSchema(sctx, is))
+	// Build the plan to collect the privileges to check when the cached plan is used.
+	for _, marker := range collector.markers {
+		param := marker.(*driver.ParamMarkerExpr)
+		param.Datum.SetNull()
+		param.InExecute = false
+	}
+	builder, _ := NewPlanBuilder(sctx, is, &hint.BlockHintProcessor{})
+	if _, err := builder.Build(ctx, stmt); err != nil {
+		return nil, err
+	}
+	normalizedSQL, digest := parser.NormalizeDigest(paramSQL)
+	return &generalStmt{
+		id: vars.GetNextPreparedStmtID(),
+		prepared: &CachedPrepareStmt{
+			PreparedAst:   prepared,
+			VisitInfos:    builder.GetVisitInfo(),
+			NormalizedSQL: normalizedSQL,
+			SQLDigest:     digest,
+			ForUpdateRead: builder.GetIsForUpdateRead(),
+		},
+	}, nil
+}
+
+// cacheableSchema returns the schema to check whether the plans of a statement can be cached, the partitioned tables
+// are allowed when the dynamic partition pruning is used, the same as the PREPARE statement.
+func cacheableSchema(sctx sessionctx.Context, is infoschema.InfoSchema) infoschema.InfoSchema {
+	if sctx.GetSessionVars().UseDynamicPartitionPrune() {
+		return nil
+	}
+	return is
+}
+
+// paramMarkerCollector collects the parameter markers in the visiting order.
+type paramMarkerCollector struct {
+	markers []ast.ParamMarkerExpr
+}
+
+// Enter implements Visitor interface.
+func (c *paramMarkerCollector) Enter(in ast.Node) (ast.Node, bool) {
+	if marker, ok := in.(*driver.ParamMarkerExpr); ok {
+		c.markers = append(c.markers, marker)
+	}
+	return in, false
+}
+
+// Leave implements Visitor interface.
+func (c *paramMarkerCollector) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
+
+func hasParamMarker(stmt ast.Node) bool {
+	var collector paramMarkerCollector
+	stmt.Accept(&collector)
+	return len(collector.markers) > 0
+}
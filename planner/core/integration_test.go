@@ -15,6 +15,7 @@ package core_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -413,6 +414,52 @@ func (s *testIntegrationSerialSuite) TestSelPushDownTiFlash(c *C) {
 	}
 }
 
+func (s *testIntegrationSerialSuite) TestLearnerReplicaRead(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t, t2")
+	tk.MustExec("create table t(a int primary key, b int, index idx(b))")
+	tk.MustExec("create table t2(a int primary key, b int, index idx(b))")
+
+	// Create virtual tiflash replica info.
+	dom := domain.GetDomain(tk.Se)
+	is := dom.InfoSchema()
+	db, exists := is.SchemaByName(model.NewCIStr("test"))
+	c.Assert(exists, IsTrue)
+	for _, tblInfo := range db.Tables {
+		if tblInfo.Name.L == "t" {
+			tblInfo.TiFlashReplica = &model.TiFlashReplicaInfo{
+				Count:     1,
+				Available: true,
+			}
+		}
+	}
+
+	tk.MustExec("set @@session.tidb_allow_mpp = 0")
+	// readFrom returns the store read by the cop tasks of the plan.
+	readFrom := func(sql string) string {
+		store := ""
+		for _, row := range tk.MustQuery("explain format = 'brief' " + sql).Rows() {
+			if task := row[2].(string); strings.HasPrefix(task, "cop[") {
+				c.Assert(store == "" || store == task, IsTrue)
+				store = task
+			}
+		}
+		return store
+	}
+	c.Assert(readFrom("select * from t where b = 1"), Equals, "cop[tikv]")
+	tk.MustExec("set @@session.tidb_replica_read = 'learner'")
+	defer tk.MustExec("set @@session.tidb_replica_read = 'leader'")
+	c.Assert(readFrom("select * from t where b = 1"), Equals, "cop[tiflash]")
+	// The tables without the TiFlash replicas read the leader.
+	c.Assert(readFrom("select * from t2 where b = 1"), Equals, "cop[tikv]")
+	// The storage hints take precedence.
+	c.Assert(readFrom("select /*+ read_from_storage(tikv[t]) */ * from t where b = 1"), Equals, "cop[tikv]")
+	// The reads for the writes don't read the learners.
+	c.Assert(readFrom("select * from t where b = 1 for update"), Equals, "cop[tikv]")
+	c.Assert(readFrom("update t set b = 1 where b = 1"), Equals, "cop[tikv]")
+}
+
 func (s *testIntegrationSerialSuite) TestVerboseExplain(c *C) {
 	tk := testkit.NewTestKit(c, s.store)
 	tk.MustExec("use test")
@@ -746,6 +793,80 @@ func (s *testIntegrationSerialSuite) TestMPPShuffledJoin(c *C) {
 	}
 }
 
+func (s *testIntegrationSerialSuite) TestExplainJSONAndDotFormat(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t1, t2")
+	tk.MustExec("create table t1(a int, b int)")
+	tk.MustExec("create table t2(a int, b int)")
+
+	rows := tk.MustQuery("explain format='json' select * from t1 where a > 1").Rows()
+	c.Assert(rows, HasLen, 1)
+	var nodes []*core.ExplainNode
+	c.Assert(json.Unmarshal([]byte(rows[0][0].(string)), &nodes), IsNil)
+	c.Assert(nodes, HasLen, 1)
+	c.Assert(strings.HasPrefix(nodes[0].ID, "TableReader"), IsTrue)
+	c.Assert(nodes[0].TaskType, Equals, "root")
+	c.Assert(nodes[0].EstRows, Equals, "3333.33")
+	c.Assert(nodes[0].ActRows, Equals, "")
+	c.Assert(nodes[0].Children, HasLen, 1)
+	c.Assert(strings.HasPrefix(nodes[0].Children[0].ID, "Selection"), IsTrue)
+	c.Assert(nodes[0].Children[0].TaskType, Equals, "cop[tikv]")
+	c.Assert(nodes[0].Children[0].OperatorInfo, Equals, "gt(test.t1.a, 1)")
+	c.Assert(nodes[0].Children[0].Children[0].AccessObject, Equals, "table:t1")
+
+	// Create virtual tiflash replica info.
+	dom := domain.GetDomain(tk.Se)
+	is := dom.InfoSchema()
+	db, exists := is.SchemaByName(model.NewCIStr("test"))
+	c.Assert(exists, IsTrue)
+	for _, tblInfo := range db.Tables {
+		if tblInfo.Name.L == "t1" || tblInfo.Name.L == "t2" {
+			tblInfo.TiFlashReplica = &model.TiFlashReplicaInfo{
+				Count:     1,
+				Available: true,
+			}
+		}
+	}
+	tk.MustExec("set @@session.tidb_isolation_read_engines = 'tiflash'")
+	tk.MustExec("set @@session.tidb_allow_mpp = 1")
+	tk.MustExec("set @@session.tidb_broadcast_join_threshold_size = 0")
+	tk.MustExec("set @@session.tidb_broadcast_join_threshold_count = 0")
+
+	sql := "select count(*) from t1 join t2 on t1.a = t2.a"
+	rows = tk.MustQuery("explain format='json' " + sql).Rows()
+	c.Assert(rows, HasLen, 1)
+	nodes = nil
+	c.Assert(json.Unmarshal([]byte(rows[0][0].(string)), &nodes), IsNil)
+	// The operator info isn't HTML escaped.
+	c.Assert(strings.Contains(rows[0][0].(string), `"operatorInfo": "funcs:count(Column#8)->Column#7"`), IsTrue)
+	var exchanges []*core.ExplainExchange
+	var walk func(nodes []*core.ExplainNode)
+	walk = func(nodes []*core.ExplainNode) {
+		for _, node := range nodes {
+			c.Assert(node.EstCost, Not(Equals), "")
+			if node.Exchange != nil {
+				c.Assert(strings.HasPrefix(node.ID, "ExchangeSender"), IsTrue)
+				exchanges = append(exchanges, node.Exchange)
+			}
+			walk(node.Children)
+		}
+	}
+	walk(nodes)
+	hashPartitioned := 0
+	for _, exchange := range exchanges {
+		if exchange.Type == "HashPartition" {
+			c.Assert(exchange.HashCols, HasLen, 1)
+			hashPartitioned++
+		}
+	}
+	c.Assert(hashPartitioned, Equals, 2)
+
+	dot := tk.MustQuery("explain format='dot' " + sql).Rows()[0][0].(string)
+	c.Assert(strings.Contains(dot, `label = "mpp"`), IsTrue, Commentf("%s", dot))
+	c.Assert(strings.Contains(dot, `[label="HashPartition"]`), IsTrue, Commentf("%s", dot))
+}
+
 func (s *testIntegrationSerialSuite) TestJoinNotSupportedByTiFlash(c *C) {
 	tk := testkit.NewTestKit(c, s.store)
 	tk.MustExec("use test")
@@ -901,6 +1022,58 @@ func (s *testIntegrationSerialSuite) TestMPPWithBroadcastExchangeUnderNewCollati
 	}
 }
 
+func (s *testIntegrationSerialSuite) TestMPPJoinHints(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t1, t2")
+	tk.MustExec("create table t1(a int, b int)")
+	tk.MustExec("create table t2(a int, b int)")
+
+	// Create virtual tiflash replica info.
+	dom := domain.GetDomain(tk.Se)
+	is := dom.InfoSchema()
+	db, exists := is.SchemaByName(model.NewCIStr("test"))
+	c.Assert(exists, IsTrue)
+	for _, tblInfo := range db.Tables {
+		if tblInfo.Name.L == "t1" || tblInfo.Name.L == "t2" {
+			tblInfo.TiFlashReplica = &model.TiFlashReplicaInfo{
+				Count:     1,
+				Available: true,
+			}
+		}
+	}
+
+	hasExchangeType := func(sql, exchangeType string) bool {
+		for _, row := range tk.MustQuery(sql).Rows() {
+			if strings.Contains(fmt.Sprintf("%v", row), "ExchangeType: "+exchangeType) {
+				return true
+			}
+		}
+		return false
+	}
+	hasWarning := func(msg string) bool {
+		for _, warn := range tk.Se.GetSessionVars().StmtCtx.GetWarnings() {
+			if warn.Err.Error() == msg {
+				return true
+			}
+		}
+		return false
+	}
+
+	tk.MustExec("set @@session.tidb_isolation_read_engines = 'tiflash'")
+	tk.MustExec("set @@session.tidb_allow_mpp = 1")
+	tk.MustExec("set @@session.tidb_broadcast_join_threshold_size = 1")
+	tk.MustExec("set @@session.tidb_broadcast_join_threshold_count = 1")
+	// The children exceed the thresholds, so the shuffle join is used without the hint.
+	c.Assert(hasExchangeType("explain format = 'brief' select * from t1 join t2 on t1.a = t2.a", "Broadcast"), IsFalse)
+	// The broadcast join hint forces the broadcast join.
+	c.Assert(hasExchangeType("explain format = 'brief' select /*+ broadcast_join(t1, t2) */ * from t1 join t2 on t1.a = t2.a", "Broadcast"), IsTrue)
+
+	tk.MustExec("set @@session.tidb_isolation_read_engines = 'tikv'")
+	tk.MustQuery("explain format = 'brief' select /*+ broadcast_join(t1, t2) */ * from t1 join t2 on t1.a = t2.a")
+	c.Assert(hasWarning("[planner:1815]Optimizer Hint /*+ BROADCAST_JOIN(t1, t2) */ or /*+ TIDB_BCJ(t1, t2) */ is inapplicable, the join can't be pushed down to TiFlash"), IsTrue)
+}
+
 func (s *testIntegrationSerialSuite) TestPartitionTableDynamicModeUnderNewCollation(c *C) {
 	collate.SetNewCollationEnabledForTest(true)
 	defer collate.SetNewCollationEnabledForTest(false)
@@ -1464,6 +1637,137 @@ func (s *testIntegrationSuite) TestInvisibleIndex(c *C) {
 
 	tk.MustExec("admin check table t")
 	tk.MustExec("admin check index t i_a")
+	// The admin check doesn't leak the invisible indexes to the later statements.
+	c.Check(tk.MustUseIndex("select a from t where a > 1", "i_a"), IsFalse)
+
+	// The optimizer can use the invisible indexes if the session opts in.
+	tk.MustExec("set @@tidb_opt_use_invisible_indexes = on")
+	c.Check(tk.MustUseIndex("select a from t where a > 1", "i_a"), IsTrue)
+	c.Check(tk.MustUseIndex("select a from t where a = 1", "i_a"), IsTrue)
+	tk.MustQuery("select * from t use index(i_a) where a = 1").Check(testkit.Rows("1 2"))
+	tk.MustExec("set @@tidb_opt_use_invisible_indexes = off")
+	c.Check(tk.MustUseIndex("select a from t where a = 1", "i_a"), IsFalse)
+}
+
+func (s *testIntegrationSuite) TestOptimizerNotes(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int, b int)")
+	tk.MustQuery("select * from t where a > 1")
+	tk.MustQuery("show warnings").Check(testkit.Rows())
+
+	tk.MustExec("set @@tidb_enable_optimizer_notes = 1")
+	tk.MustQuery("select * from t t1, t t2 where t1.a > 1 and t1.a = t2.a")
+	tk.MustQuery("show warnings").Check(testkit.Rows(
+		"Note 1105 Table `t` has no statistics, the pseudo statistics are used",
+		"Note 1105 Join reorder: the join order (t1, t2) of 2 tables is chosen by the greedy algorithm since the group has more tables than tidb_opt_join_reorder_threshold(0)"))
+
+	tk.MustExec("set @@tidb_opt_join_reorder_threshold = 3")
+	tk.MustQuery("select * from t t1, t t2, t t3 where t1.a = t2.a and t2.b = t3.b and t3.a > 1")
+	tk.MustQuery("show warnings").Check(testkit.Rows(
+		"Note 1105 Table `t` has no statistics, the pseudo statistics are used",
+		"Note 1105 Join reorder: the join order (t1, (t2, t3)) of 3 tables is chosen by the dynamic programming algorithm"))
+}
+
+func (s *testIntegrationSuite) TestOptimizerTrace(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int, b int, index ia(a))")
+	steps := make(map[string][]string)
+	for _, row := range tk.MustQuery("explain format = 'trace' select * from t where a > 1 and b = 1 limit 1").Rows() {
+		tp := row[1].(string)
+		steps[tp] = append(steps[tp], row[3].(string))
+	}
+	contains := func(details []string, substr string) bool {
+		for _, detail := range details {
+			if strings.Contains(detail, substr) {
+				return true
+			}
+		}
+		return false
+	}
+	c.Assert(contains(steps["rule"], "predicate_push_down changes the plan"), IsTrue, Commentf("%v", steps))
+	c.Assert(contains(steps["rule"], "column_prune doesn't change the plan"), IsTrue, Commentf("%v", steps))
+	c.Assert(contains(steps["candidate"], "cop task"), IsTrue, Commentf("%v", steps))
+	c.Assert(contains(steps["candidate"], "root task"), IsTrue, Commentf("%v", steps))
+	c.Assert(steps["chosen"], HasLen, 1)
+	c.Assert(contains(steps["pushdown"], "is pushed down to tikv by coprocessor"), IsTrue, Commentf("%v", steps))
+	c.Assert(contains(steps["note"], "Table `t` has no statistics"), IsTrue, Commentf("%v", steps))
+
+	// The trace isn't recorded by default.
+	tk.MustQuery("select * from t where a > 1")
+	tk.MustQuery("select count(*) from information_schema.optimizer_trace").Check(testkit.Rows("0"))
+	tk.MustExec("set @@tidb_enable_optimizer_trace = 1")
+	tk.MustQuery("select * from t where a > 1")
+	tk.MustQuery("select query, missing_bytes_beyond_max_mem_size, insufficient_privileges from information_schema.optimizer_trace").Check(
+		testkit.Rows("select * from t where a > 1 0 0"))
+	// The statement reading the trace isn't traced, so the trace of the last statement is kept.
+	tk.MustQuery("select query from information_schema.optimizer_trace").Check(testkit.Rows("select * from t where a > 1"))
+	tk.MustQuery("select json_valid(trace), json_contains(json_extract(trace, '$[*].type'), '\"chosen\"') from information_schema.optimizer_trace").Check(
+		testkit.Rows("1 1"))
+	tk.MustExec("set @@tidb_enable_optimizer_trace = 0")
+}
+
+func (s *testIntegrationSuite) TestRowComparisonRange(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int, b int, c int, index iab(a, b))")
+	tk.MustExec("insert into t values (1, 1, 1), (1, 2, 2), (1, 3, 3), (2, 1, 4), (2, NULL, 5), (NULL, 1, 6), (3, 3, 7)")
+
+	// The keyset pagination reads the index from the last key.
+	sql := "select a, b from t where (a, b) > (1, 2) order by a, b limit 3"
+	plan := fmt.Sprint(tk.MustQuery("explain format = 'brief' " + sql).Rows())
+	c.Assert(strings.Contains(plan, "range:(1 2,1 +inf], (1,+inf]"), IsTrue, Commentf("%s", plan))
+	tk.MustQuery(sql).Check(testkit.Rows("1 3", "2 <nil>", "2 1"))
+	tk.MustQuery("select c from t where (a, b) >= (2, 1) and c > 3 order by c").Check(testkit.Rows("4", "7"))
+	tk.MustQuery("select c from t where (a, b) <= (1, 2) order by c").Check(testkit.Rows("1", "2"))
+	tk.MustQuery("select c from t where (a, b) in ((1, 3), (2, 1), (3, 4)) order by c").Check(testkit.Rows("3", "4"))
+	plan = fmt.Sprint(tk.MustQuery("explain format = 'brief' select * from t where (a, b) in ((1, 3), (2, 1))").Rows())
+	c.Assert(strings.Contains(plan, "range:[1 3,1 3], [2 1,2 1]"), IsTrue, Commentf("%s", plan))
+	// The NULL values are compared like the row comparison.
+	tk.MustQuery("select (1, 2) < (1, NULL), (1, 2) < (2, NULL), (NULL, 1) > (1, 1), (1, 2, 3) >= (1, 2, 3)").Check(testkit.Rows("<nil> 1 <nil> 1"))
+}
+
+func (s *testIntegrationSuite) TestIndexHintForOrderBy(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int, b int, c int, index ia(a), index ib(b))")
+	tk.MustExec("insert into t values (1, 3, 1), (2, 2, 2), (3, 1, 3)")
+	explain := func(sql string) string {
+		return fmt.Sprint(tk.MustQuery("explain format = 'brief' " + sql).Rows())
+	}
+
+	// The index hinted for ORDER BY is scanned in order instead of sorting the rows.
+	sql := "select * from t use index for order by (ib) where a > 1 order by b"
+	plan := explain(sql)
+	c.Assert(strings.Contains(plan, "index:ib(b)"), IsTrue, Commentf("%s", plan))
+	c.Assert(strings.Contains(plan, "keep order:true"), IsTrue, Commentf("%s", plan))
+	c.Assert(strings.Contains(plan, "Sort"), IsFalse, Commentf("%s", plan))
+	tk.MustQuery(sql).Check(testkit.Rows("3 1 3", "2 2 2"))
+	plan = explain("select * from t use index for order by (ib) where a > 1 order by b limit 1")
+	c.Assert(strings.Contains(plan, "keep order:true"), IsTrue, Commentf("%s", plan))
+	c.Assert(strings.Contains(plan, "TopN"), IsFalse, Commentf("%s", plan))
+
+	// The index ignored for ORDER BY is never scanned in order.
+	sql = "select b from t ignore index for order by (ib) order by b"
+	plan = explain(sql)
+	c.Assert(strings.Contains(plan, "keep order:true"), IsFalse, Commentf("%s", plan))
+	c.Assert(strings.Contains(plan, "Sort"), IsTrue, Commentf("%s", plan))
+	tk.MustQuery(sql).Check(testkit.Rows("1", "2", "3"))
+	plan = explain("select b from t ignore index for order by (ib) where b > 1")
+	c.Assert(strings.Contains(plan, "index:ib(b)"), IsTrue, Commentf("%s", plan))
+
+	// The hint doesn't matter if the order isn't required.
+	tk.MustQuery("select c from t use index for order by (ib) where a = 2").Check(testkit.Rows("2"))
+	tk.MustGetErrMsg("select * from t use index for order by (ic) order by b", "[planner:1176]Key 'ic' doesn't exist in table 't'")
 }
 
 // for issue #14822
@@ -3889,3 +4193,99 @@ func (s *testIntegrationSerialSuite) TestMergeContinuousSelections(c *C) {
 		res.Check(testkit.Rows(output[i].Plan...))
 	}
 }
+
+func (s *testIntegrationSuite) TestPartitionWiseJoin(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t1, t2, t3")
+	tk.MustExec("create table t1(a int, b int) partition by hash(a) partitions 4")
+	tk.MustExec("create table t2(a int, b int) partition by hash(a) partitions 4")
+	tk.MustExec("create table t3(a int, b int) partition by hash(a) partitions 3")
+	tk.MustExec("insert into t1 values (1, 1), (2, 2), (3, 3), (4, 4), (5, 5), (null, 6)")
+	tk.MustExec("insert into t2 values (1, 1), (3, 3), (5, 5), (7, 7), (null, 6)")
+	tk.MustExec("insert into t3 values (1, 1), (3, 3), (5, 5), (7, 7), (null, 6)")
+	tk.MustExec("set @@tidb_partition_prune_mode = 'static'")
+	tk.MustExec("set @@tidb_opt_partition_wise_join = 1")
+
+	countJoins := func(sql string) int {
+		count := 0
+		for _, row := range tk.MustQuery("explain format = 'brief' " + sql).Rows() {
+			if strings.Contains(row[0].(string), "HashJoin") {
+				count++
+			}
+		}
+		return count
+	}
+	// The partition pairs are joined independently.
+	sql := "select /*+ hash_join(t1, t2) */ t1.b, t2.b from t1 join t2 on t1.a = t2.a"
+	c.Assert(countJoins(sql), Equals, 4)
+	tk.MustQuery(sql).Sort().Check(testkit.Rows("1 1", "3 3", "5 5"))
+	// The partition pairs whose partition of one side is pruned are skipped.
+	sql = "select /*+ hash_join(t1, t2) */ t1.b, t2.b from t1 join t2 on t1.a = t2.a where t1.a in (1, 2) and t2.a in (1, 3)"
+	c.Assert(countJoins(sql), Equals, 1)
+	tk.MustQuery(sql).Check(testkit.Rows("1 1"))
+	// The tables are partitioned in different ways.
+	sql = "select /*+ hash_join(t1, t3) */ t1.b, t3.b from t1 join t3 on t1.a = t3.a"
+	c.Assert(countJoins(sql), Equals, 1)
+	tk.MustQuery(sql).Sort().Check(testkit.Rows("1 1", "3 3", "5 5"))
+	// The tables are not joined by the partition key.
+	sql = "select /*+ hash_join(t1, t2) */ t1.b, t2.b from t1 join t2 on t1.a = t2.b"
+	c.Assert(countJoins(sql), Equals, 1)
+	// The outer joins are not rewritten.
+	sql = "select /*+ hash_join(t1, t2) */ t1.b, t2.b from t1 left join t2 on t1.a = t2.a"
+	c.Assert(countJoins(sql), Equals, 1)
+
+	tk.MustExec("set @@tidb_opt_partition_wise_join = 0")
+	sql = "select /*+ hash_join(t1, t2) */ t1.b, t2.b from t1 join t2 on t1.a = t2.a"
+	c.Assert(countJoins(sql), Equals, 1)
+}
+
+func (s *testIntegrationSuite) TestSelectivityFeedback(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int primary key, b int)")
+	for i := 1; i <= 100; i++ {
+		tk.MustExec(fmt.Sprintf("insert into t values (%d, %d)", i, i))
+	}
+	tk.MustExec("analyze table t")
+	tk.MustExec("set @@tidb_enable_selectivity_feedback = 1")
+
+	// b * 2 > 180 can't be estimated by the statistics, and the selectivity observed on the range a > 50 is
+	// 10 rows out of the 100 rows of the table rather than 10 out of the 50 scanned rows.
+	sql := "select * from t where a > 50 and b * 2 > 180"
+	tk.MustQuery(sql).Sort().Check(testkit.Rows("100 100", "91 91", "92 92", "93 93", "94 94", "95 95", "96 96", "97 97", "98 98", "99 99"))
+	rows := tk.MustQuery("explain format = 'brief' " + sql).Rows()
+	c.Assert(rows[0][0], Equals, "TableReader")
+	c.Assert(rows[0][1], Equals, "10.00")
+}
+
+func (s *testIntegrationSuite) TestMergeJoinSortingChildrenWhenHashJoinSpills(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t1, t2")
+	tk.MustExec("create table t1(a int, b int)")
+	tk.MustExec("create table t2(a int, b int)")
+	for i := 0; i < 100; i++ {
+		tk.MustExec(fmt.Sprintf("insert into t1 values (%d, %d)", i%2, i))
+		tk.MustExec(fmt.Sprintf("insert into t2 values (%d, %d)", i%2, i))
+	}
+	tk.MustExec("analyze table t1, t2")
+	sql := "explain format = 'brief' select * from t1 join t2 on t1.a = t2.a"
+
+	// The hash join is chosen if the children fit in the memory.
+	rows := tk.MustQuery(sql).Rows()
+	c.Assert(rows[0][0], Equals, "HashJoin")
+
+	// Both the children exceed the memory quota, the hash join would spill and the merge join sorting the children is
+	// cheaper since the keys have many duplicates.
+	tk.MustExec("set @@tidb_mem_quota_query=100")
+	tk.MustQuery(sql).Check(testkit.Rows(
+		"MergeJoin 5000.00 root  inner join, left key:test.t1.a, right key:test.t2.a, sort children",
+		"├─TableReader(Build) 100.00 root  data:Selection",
+		"│ └─Selection 100.00 cop[tikv]  not(isnull(test.t2.a))",
+		"│   └─TableFullScan 100.00 cop[tikv] table:t2 keep order:false",
+		"└─TableReader(Probe) 100.00 root  data:Selection",
+		"  └─Selection 100.00 cop[tikv]  not(isnull(test.t1.a))",
+		"    └─TableFullScan 100.00 cop[tikv] table:t1 keep order:false"))
+}
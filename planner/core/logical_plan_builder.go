@@ -69,6 +69,8 @@ const (
 	HintBCJ = "broadcast_join"
 	// HintBCJPreferLocal specifies the preferred local read table
 	HintBCJPreferLocal = "broadcast_join_local"
+	// HintShuffleJoin indicates applying shuffle hash join in MPP mode by force.
+	HintShuffleJoin = "shuffle_join"
 
 	// TiDBIndexNestedLoopJoin is hint enforce index nested loop join.
 	TiDBIndexNestedLoopJoin = "tidb_inlj"
@@ -90,6 +92,10 @@ const (
 	HintUseIndex = "use_index"
 	// HintIgnoreIndex is hint enforce ignoring some indexes.
 	HintIgnoreIndex = "ignore_index"
+	// HintOrderIndex is hint enforce using some indexes and keeping the index's order.
+	HintOrderIndex = "order_index"
+	// HintNoOrderIndex is hint enforce not keeping the index's order.
+	HintNoOrderIndex = "no_order_index"
 	// HintAggToCop is hint enforce pushing aggregation to coprocessor.
 	HintAggToCop = "agg_to_cop"
 	// HintReadFromStorage is hint enforce some tables read from specific type of storage.
@@ -561,6 +567,9 @@ func (p *LogicalJoin) setPreferredJoinType(hintInfo *tableHintInfo) {
 	if hintInfo.ifPreferBroadcastJoin(lhsAlias, rhsAlias) {
 		p.preferJoinType |= preferBCJoin
 	}
+	if hintInfo.ifPreferShuffleJoin(lhsAlias, rhsAlias) {
+		p.preferJoinType |= preferShuffleJoin
+	}
 	if hintInfo.ifPreferHashJoin(lhsAlias, rhsAlias) {
 		p.preferJoinType |= preferHashJoin
 	}
@@ -651,6 +660,21 @@ func (ds *DataSource) setPreferredStoreType(hintInfo *tableHintInfo) {
 	}
 }
 
+// setPreferredStoreTypeByReplicaRead prefers the TiFlash replicas, which are the learners, if the learners are read by
+// tidb_replica_read. The storage hints take precedence, and the tables without the TiFlash replicas read the leader.
+func (ds *DataSource) setPreferredStoreTypeByReplicaRead(isForUpdateRead bool) {
+	sessVars := ds.ctx.GetSessionVars()
+	if ds.preferStoreType != 0 || isForUpdateRead || !sessVars.StmtCtx.InSelectStmt || sessVars.GetReplicaRead() != kv.ReplicaReadLearner {
+		return
+	}
+	for _, path := range ds.possibleAccessPaths {
+		if path.StoreType == kv.TiFlash {
+			ds.preferStoreType |= preferTiFlash
+			return
+		}
+	}
+}
+
 func resetNotNullFlag(schema *expression.Schema, start, end int) {
 	for i := start; i < end; i++ {
 		col := *schema.Columns[i]
@@ -1395,7 +1419,7 @@ func (b *PlanBuilder) buildSetOpr(ctx context.Context, setOpr *ast.SetOprStmt) (
 		defer func() {
 			b.outerCTEs = b.outerCTEs[:l]
 		}()
-		err := b.buildWith(ctx, setOpr.With)
+		err := b.buildWith(ctx, setOpr.With, setOpr)
 		if err != nil {
 			return nil, err
 		}
@@ -1698,9 +1722,45 @@ func (b *PlanBuilder) buildSortWithCheck(ctx context.Context, p LogicalPlan, byI
 	}
 	sort.ByItems = exprs
 	sort.SetChildren(p)
+	setKeepOrderItems(sort)
 	return sort, nil
 }
 
+// setKeepOrderItems passes the order of the sort down to the DataSource below it, if the sort items are the
+// columns of the DataSource and some of its access paths are hinted to keep the order.
+func setKeepOrderItems(sort *LogicalSort) {
+	prop, canPass := GetPropByOrderByItems(sort.ByItems)
+	if !canPass {
+		return
+	}
+	sortItems := prop.SortItems
+	p := sort.children[0]
+	for {
+		switch x := p.(type) {
+		case *LogicalSelection:
+		case *LogicalProjection:
+			for i, item := range sortItems {
+				col, ok := expression.ColumnSubstitute(item.Col, x.schema, x.Exprs).(*expression.Column)
+				if !ok {
+					return
+				}
+				sortItems[i].Col = col
+			}
+		case *DataSource:
+			for _, path := range x.possibleAccessPaths {
+				if path.ForceKeepOrder {
+					x.keepOrderItems = sortItems
+					return
+				}
+			}
+			return
+		default:
+			return
+		}
+		p = p.Children()[0]
+	}
+}
+
 // checkOrderByInDistinct checks whether ORDER BY has conflicts with DISTINCT, see #12442
 func (b *PlanBuilder) checkOrderByInDistinct(byItem *ast.ByItem, idx int, expr expression.Expression, p LogicalPlan, originalExprs []expression.Expression, length int) error {
 	// Check if expressions in ORDER BY whole match some fields in DISTINCT.
@@ -2292,7 +2352,7 @@ func (r *correlatedAggregateResolver) resolveSelect(sel *ast.SelectStmt) (err er
 		defer func() {
 			r.b.outerCTEs = r.b.outerCTEs[:l]
 		}()
-		err := r.b.buildWith(r.ctx, sel.With)
+		err := r.b.buildWith(r.ctx, sel.With, sel)
 		if err != nil {
 			return err
 		}
@@ -3149,6 +3209,7 @@ func (b *PlanBuilder) pushTableHints(hints []*ast.TableOptimizerHint, currentLev
 		sortMergeTables, INLJTables, INLHJTables, INLMJTables, hashJoinTables, BCTables, BCJPreferLocalTables []hintTableInfo
 		indexHintList, indexMergeHintList                                                                     []indexHintInfo
 		tiflashTables, tikvTables                                                                             []hintTableInfo
+		shuffleJoinTables                                                                                     []hintTableInfo
 		aggHints                                                                                              aggHintInfo
 		timeRangeHint                                                                                         ast.HintTimeRange
 		limitHints                                                                                            limitHintInfo
@@ -3157,7 +3218,7 @@ func (b *PlanBuilder) pushTableHints(hints []*ast.TableOptimizerHint, currentLev
 		// Set warning for the hint that requires the table name.
 		switch hint.HintName.L {
 		case TiDBMergeJoin, HintSMJ, TiDBIndexNestedLoopJoin, HintINLJ, HintINLHJ, HintINLMJ,
-			TiDBHashJoin, HintHJ, HintUseIndex, HintIgnoreIndex, HintIndexMerge:
+			TiDBHashJoin, HintHJ, HintUseIndex, HintIgnoreIndex, HintIndexMerge, HintOrderIndex, HintNoOrderIndex:
 			if len(hint.Tables) == 0 {
 				b.pushHintWithoutTableWarning(hint)
 				continue
@@ -3171,6 +3232,8 @@ func (b *PlanBuilder) pushTableHints(hints []*ast.TableOptimizerHint, currentLev
 			BCTables = append(BCTables, tableNames2HintTableInfo(b.ctx, hint.HintName.L, hint.Tables, b.hintProcessor, currentLevel)...)
 		case HintBCJPreferLocal:
 			BCJPreferLocalTables = append(BCJPreferLocalTables, tableNames2HintTableInfo(b.ctx, hint.HintName.L, hint.Tables, b.hintProcessor, currentLevel)...)
+		case HintShuffleJoin:
+			shuffleJoinTables = append(shuffleJoinTables, tableNames2HintTableInfo(b.ctx, hint.HintName.L, hint.Tables, b.hintProcessor, currentLevel)...)
 		case TiDBIndexNestedLoopJoin, HintINLJ:
 			INLJTables = append(INLJTables, tableNames2HintTableInfo(b.ctx, hint.HintName.L, hint.Tables, b.hintProcessor, currentLevel)...)
 		case HintINLHJ:
@@ -3215,6 +3278,25 @@ func (b *PlanBuilder) pushTableHints(hints []*ast.TableOptimizerHint, currentLev
 					HintScope:  ast.HintForScan,
 				},
 			})
+		case HintOrderIndex, HintNoOrderIndex:
+			dbName := hint.Tables[0].DBName
+			if dbName.L == "" {
+				dbName = model.NewCIStr(b.ctx.GetSessionVars().CurrentDB)
+			}
+			hintType := ast.HintUse
+			if hint.HintName.L == HintNoOrderIndex {
+				hintType = ast.HintIgnore
+			}
+			indexHintList = append(indexHintList, indexHintInfo{
+				dbName:     dbName,
+				tblName:    hint.Tables[0].TableName,
+				partitions: hint.Tables[0].PartitionList,
+				indexHint: &ast.IndexHint{
+					IndexNames: hint.Indexes,
+					HintType:   hintType,
+					HintScope:  ast.HintForOrderBy,
+				},
+			})
 		case HintReadFromStorage:
 			switch hint.HintData.(model.CIStr).L {
 			case HintTiFlash:
@@ -3249,6 +3331,7 @@ func (b *PlanBuilder) pushTableHints(hints []*ast.TableOptimizerHint, currentLev
 		sortMergeJoinTables:         sortMergeTables,
 		broadcastJoinTables:         BCTables,
 		broadcastJoinPreferredLocal: BCJPreferLocalTables,
+		shuffleJoinTables:           shuffleJoinTables,
 		indexNestedLoopJoinTables:   indexNestedLoopJoinTables{INLJTables, INLHJTables, INLMJTables},
 		hashJoinTables:              hashJoinTables,
 		indexHintList:               indexHintList,
@@ -3278,6 +3361,7 @@ func (b *PlanBuilder) popTableHints() {
 	b.appendUnmatchedJoinHintWarning(HintSMJ, TiDBMergeJoin, hintInfo.sortMergeJoinTables)
 	b.appendUnmatchedJoinHintWarning(HintBCJ, TiDBBroadCastJoin, hintInfo.broadcastJoinTables)
 	b.appendUnmatchedJoinHintWarning(HintBCJPreferLocal, "", hintInfo.broadcastJoinPreferredLocal)
+	b.appendUnmatchedJoinHintWarning(HintShuffleJoin, "", hintInfo.shuffleJoinTables)
 	b.appendUnmatchedJoinHintWarning(HintHJ, TiDBHashJoin, hintInfo.hashJoinTables)
 	b.appendUnmatchedStorageHintWarning(hintInfo.tiflashTables, hintInfo.tikvTables)
 	b.tableHintInfo = b.tableHintInfo[:len(b.tableHintInfo)-1]
@@ -3345,6 +3429,13 @@ func (b *PlanBuilder) buildSelect(ctx context.Context, sel *ast.SelectStmt) (p L
 		// table hints are only visible in the current SELECT statement.
 		b.popTableHints()
 	}()
+	if aggregation.HasUserDefinedAggFuncs() {
+		rewriter := &userDefinedAggFuncRewriter{}
+		sel.Accept(rewriter)
+		if rewriter.rewritten {
+			ast.SetFlag(sel)
+		}
+	}
 	if b.buildingRecursivePartForCTE {
 		if sel.Distinct || sel.OrderBy != nil || sel.Limit != nil {
 			return nil, ErrNotSupportedYet.GenWithStackByArgs("ORDER BY / LIMIT / SELECT DISTINCT in recursive query block of Common Table Expression")
@@ -3383,7 +3474,7 @@ func (b *PlanBuilder) buildSelect(ctx context.Context, sel *ast.SelectStmt) (p L
 		defer func() {
 			b.outerCTEs = b.outerCTEs[:l]
 		}()
-		err = b.buildWith(ctx, sel.With)
+		err = b.buildWith(ctx, sel.With, sel)
 		if err != nil {
 			return nil, err
 		}
@@ -3618,6 +3709,17 @@ var (
 // 2. table row count from statistics is zero.
 // 3. statistics is outdated.
 func getStatsTable(ctx sessionctx.Context, tblInfo *model.TableInfo, pid int64) *statistics.Table {
+	// 0. statistics is overridden by LOAD STATS in this session.
+	if overridden := ctx.GetSessionVars().OverriddenStats; overridden != nil {
+		physicalID := pid
+		if ctx.GetSessionVars().UseDynamicPartitionPrune() {
+			physicalID = tblInfo.ID
+		}
+		if statsTbl, ok := overridden[physicalID]; ok {
+			return statsTbl.(*statistics.Table)
+		}
+	}
+
 	statsHandle := domain.GetDomain(ctx).StatsHandle()
 
 	// 1. tidb-server started and statistics handle has not been initialized.
@@ -3628,13 +3730,16 @@ func getStatsTable(ctx sessionctx.Context, tblInfo *model.TableInfo, pid int64)
 	var statsTbl *statistics.Table
 	if pid == tblInfo.ID || ctx.GetSessionVars().UseDynamicPartitionPrune() {
 		statsTbl = statsHandle.GetTableStats(tblInfo)
+		recordStatsDep(ctx.GetSessionVars().StmtCtx, tblInfo, tblInfo.ID, statsTbl)
 	} else {
 		statsTbl = statsHandle.GetPartitionStats(tblInfo, pid)
+		recordStatsDep(ctx.GetSessionVars().StmtCtx, tblInfo, pid, statsTbl)
 	}
 
 	// 2. table row count from statistics is zero.
 	if statsTbl.Count == 0 {
 		pseudoEstimationNotAvailable.Inc()
+		ctx.GetSessionVars().StmtCtx.AppendOptimizerNote(errors.Errorf("Table `%s` has no statistics, the pseudo statistics are used", tblInfo.Name.O))
 		return statistics.PseudoTable(tblInfo)
 	}
 
@@ -3644,7 +3749,50 @@ func getStatsTable(ctx sessionctx.Context, tblInfo *model.TableInfo, pid int64)
 		tbl.Pseudo = true
 		statsTbl = &tbl
 		pseudoEstimationOutdate.Inc()
+		ctx.GetSessionVars().StmtCtx.AppendOptimizerNote(errors.Errorf("The statistics of table `%s` are outdated, the pseudo statistics are used", tblInfo.Name.O))
+	} else if statsTbl.Pseudo {
+		ctx.GetSessionVars().StmtCtx.AppendOptimizerNote(errors.Errorf("Table `%s` has no statistics, the pseudo statistics are used", tblInfo.Name.O))
+	}
+	return statsTbl
+}
+
+// getPartitionLiteStatsTable returns a pseudo statistics table whose row count is aggregated from the
+// lightweight statistics of the partitions selected by the PARTITION clause, or of all the partitions if
+// there is none. It's used in the dynamic prune mode when the global statistics are not available, nil is
+// returned if it can't be used. Like the global statistics, it describes the table before the filters, the
+// conditions are estimated against it afterwards.
+func (ds *DataSource) getPartitionLiteStatsTable() *statistics.Table {
+	if !ds.ctx.GetSessionVars().UseDynamicPartitionPrune() || !ds.statisticTable.Pseudo {
+		return nil
+	}
+	// The pseudo tables which are not built from the outdated statistics have no version. The row count of the
+	// outdated ones covers all the partitions, so it's kept unless some partitions are selected.
+	if ds.statisticTable.Version != 0 && len(ds.partitionNames) == 0 {
+		return nil
+	}
+	pi := ds.tableInfo.GetPartitionInfo()
+	statsHandle := domain.GetDomain(ds.ctx).StatsHandle()
+	if pi == nil || statsHandle == nil {
+		return nil
+	}
+	var pids []int64
+	if len(ds.partitionNames) > 0 {
+		s := partitionProcessor{}
+		pids = make([]int64, 0, len(ds.partitionNames))
+		for _, def := range pi.Definitions {
+			if s.findByName(ds.partitionNames, def.Name.L) {
+				pids = append(pids, def.ID)
+			}
+		}
 	}
+	liteStats := statsHandle.GetLiteStats(ds.tableInfo, pids)
+	if liteStats.Count == 0 {
+		return nil
+	}
+	statsTbl := statistics.PseudoTable(ds.tableInfo)
+	statsTbl.Count = liteStats.Count
+	statsTbl.ModifyCount = liteStats.ModifyCount
+	statsTbl.Version = liteStats.Version
 	return statsTbl
 }
 
@@ -3675,6 +3823,10 @@ func (b *PlanBuilder) tryBuildCTE(ctx context.Context, tn *ast.TableName, asName
 				return p, nil
 			}
 
+			if cte.isInline {
+				return b.buildInlineCTE(ctx, i, asName)
+			}
+
 			b.handleHelper.pushMap(nil)
 
 			hasLimit := false
@@ -3769,6 +3921,9 @@ func (b *PlanBuilder) buildDataSource(ctx context.Context, tn *ast.TableName, as
 		// Use the new partition implementation, clean up the code here when it's full implemented.
 		if !b.ctx.GetSessionVars().UseDynamicPartitionPrune() {
 			b.optFlag = b.optFlag | flagPartitionProcessor
+			if b.ctx.GetSessionVars().PartitionWiseJoin {
+				b.optFlag = b.optFlag | flagPartitionWiseJoin
+			}
 		}
 
 		pt := tbl.(table.PartitionedTable)
@@ -3950,6 +4105,7 @@ func (b *PlanBuilder) buildDataSource(ctx context.Context, tn *ast.TableName, as
 	ds.SetSchema(schema)
 	ds.names = names
 	ds.setPreferredStoreType(b.TableHints())
+	ds.setPreferredStoreTypeByReplicaRead(b.isForUpdateRead)
 	ds.SampleInfo = NewTableSampleInfo(tn.TableSample, schema.Clone(), b.partitionedTable)
 	b.isSampling = ds.SampleInfo != nil
 
@@ -3990,7 +4146,7 @@ func (b *PlanBuilder) buildDataSource(ctx context.Context, tn *ast.TableName, as
 		}
 	}
 
-	return result, nil
+	return b.buildSecurityPolicies(ctx, result, ds)
 }
 
 func (b *PlanBuilder) timeRangeForSummaryTable() QueryTimeRange {
@@ -4074,7 +4230,8 @@ func (b *PlanBuilder) buildMemTable(_ context.Context, dbName model.CIStr, table
 		p.Extractor = newMetricTableExtractor()
 	case util2.InformationSchemaName.L:
 		switch strings.ToUpper(tableInfo.Name.O) {
-		case infoschema.TableClusterConfig, infoschema.TableClusterLoad, infoschema.TableClusterHardware, infoschema.TableClusterSystemInfo:
+		case infoschema.TableClusterConfig, infoschema.TableClusterLoad, infoschema.TableClusterHardware, infoschema.TableClusterSystemInfo,
+			infoschema.TableClusterMemoryUsage, infoschema.TableClusterThreads, infoschema.TableClusterRunningTasks:
 			p.Extractor = &ClusterTableExtractor{}
 		case infoschema.TableClusterLog:
 			p.Extractor = &ClusterLogTableExtractor{}
@@ -4091,7 +4248,7 @@ func (b *PlanBuilder) buildMemTable(_ context.Context, dbName model.CIStr, table
 			p.QueryTimeRange = b.timeRangeForSummaryTable()
 		case infoschema.TableSlowQuery:
 			p.Extractor = &SlowQueryExtractor{}
-		case infoschema.TableStorageStats:
+		case infoschema.TableStorageStats, infoschema.TableStatsJSON:
 			p.Extractor = &TableStorageStatsExtractor{}
 		case infoschema.TableTiFlashTables, infoschema.TableTiFlashSegments:
 			p.Extractor = &TiFlashSystemTableExtractor{}
@@ -4412,7 +4569,7 @@ func (b *PlanBuilder) buildUpdate(ctx context.Context, update *ast.UpdateStmt) (
 		defer func() {
 			b.outerCTEs = b.outerCTEs[:l]
 		}()
-		err := b.buildWith(ctx, update.With)
+		err := b.buildWith(ctx, update.With, update)
 		if err != nil {
 			return nil, err
 		}
@@ -4771,7 +4928,7 @@ func (b *PlanBuilder) buildDelete(ctx context.Context, delete *ast.DeleteStmt) (
 		defer func() {
 			b.outerCTEs = b.outerCTEs[:l]
 		}()
-		err := b.buildWith(ctx, delete.With)
+		err := b.buildWith(ctx, delete.With, delete)
 		if err != nil {
 			return nil, err
 		}
@@ -5890,7 +6047,7 @@ func (b *PlanBuilder) buildRecursiveCTE(ctx context.Context, cte ast.ResultSetNo
 				sw := x.With
 				x.With = sw
 			}()
-			err := b.buildWith(ctx, x.With)
+			err := b.buildWith(ctx, x.With, x)
 			if err != nil {
 				return err
 			}
@@ -6088,7 +6245,8 @@ func (b *PlanBuilder) genCTETableNameForError() string {
 	return name
 }
 
-func (b *PlanBuilder) buildWith(ctx context.Context, w *ast.WithClause) error {
+// buildWith builds the CTEs of the WITH clause of stmt.
+func (b *PlanBuilder) buildWith(ctx context.Context, w *ast.WithClause, stmt ast.Node) error {
 	// Check CTE name must be unique.
 	nameMap := make(map[string]struct{})
 	for _, cte := range w.CTEs {
@@ -6097,6 +6255,14 @@ func (b *PlanBuilder) buildWith(ctx context.Context, w *ast.WithClause) error {
 		}
 		nameMap[cte.Name.L] = struct{}{}
 	}
+	var refCounter *cteRefCounter
+	if b.ctx.GetSessionVars().InlineCTE && !w.IsRecursive {
+		refCounter = &cteRefCounter{counts: make(map[string]int, len(w.CTEs))}
+		for _, cte := range w.CTEs {
+			refCounter.counts[cte.Name.L] = 0
+		}
+		stmt.Accept(refCounter)
+	}
 	for _, cte := range w.CTEs {
 		b.outerCTEs = append(b.outerCTEs, &cteInfo{def: cte, nonRecursive: !w.IsRecursive, isBuilding: true, storageID: b.allocIDForCTEStorage})
 		b.allocIDForCTEStorage++
@@ -6109,11 +6275,62 @@ func (b *PlanBuilder) buildWith(ctx context.Context, w *ast.WithClause) error {
 		}
 		b.outerCTEs[len(b.outerCTEs)-1].optFlag = b.optFlag
 		b.outerCTEs[len(b.outerCTEs)-1].isBuilding = false
+		// The CTE referenced only once is inlined, since there is nothing to share by materializing it, and the
+		// inlined CTE can be optimized together with the outer query.
+		b.outerCTEs[len(b.outerCTEs)-1].isInline = refCounter != nil && refCounter.counts[cte.Name.L] == 1
 		b.optFlag = saveFlag
 	}
 	return nil
 }
 
+// cteRefCounter counts the references of the CTEs by their names. The references of the CTEs shadowed by the inner
+// ones with the same names are counted too, which only makes the CTEs materialized instead of being inlined.
+type cteRefCounter struct {
+	counts map[string]int
+}
+
+// Enter implements Visitor interface.
+func (c *cteRefCounter) Enter(in ast.Node) (ast.Node, bool) {
+	if tn, ok := in.(*ast.TableName); ok && tn.Schema.L == "" {
+		if _, ok := c.counts[tn.Name.L]; ok {
+			c.counts[tn.Name.L]++
+		}
+	}
+	return in, false
+}
+
+// Leave implements Visitor interface.
+func (c *cteRefCounter) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
+
+// buildInlineCTE builds the definition of the idx-th CTE again as a derived table where it's referenced, so the CTE is
+// optimized together with the outer query instead of being materialized.
+func (b *PlanBuilder) buildInlineCTE(ctx context.Context, idx int, asName *model.CIStr) (LogicalPlan, error) {
+	cte := b.outerCTEs[idx]
+	// Only the CTEs defined before it are visible in its definition. The capacity is limited so the CTEs defined in
+	// the definition don't overwrite the ones after it.
+	saveCTEs := b.outerCTEs
+	b.outerCTEs = saveCTEs[:idx:idx]
+	defer func() {
+		b.outerCTEs = saveCTEs
+	}()
+	p, err := b.buildResultSetNode(ctx, cte.def.Query.Query)
+	if err != nil {
+		return nil, err
+	}
+	p, err = b.adjustCTEPlanOutputName(p, cte.def)
+	if err != nil {
+		return nil, err
+	}
+	if asName.L != "" {
+		for _, name := range p.OutputNames() {
+			name.TblName = *asName
+		}
+	}
+	return p, nil
+}
+
 func (b *PlanBuilder) buildProjection4CTEUnion(ctx context.Context, seed LogicalPlan, recur LogicalPlan) (LogicalPlan, error) {
 	if seed.Schema().Len() != recur.Schema().Len() {
 		return nil, ErrWrongNumberOfColumnsInSelect.GenWithStackByArgs()
@@ -108,6 +108,7 @@ const (
 	preferHashJoin
 	preferMergeJoin
 	preferBCJoin
+	preferShuffleJoin
 	preferHashAgg
 	preferStreamAgg
 )
@@ -490,9 +491,15 @@ type DataSource struct {
 
 	statisticTable *statistics.Table
 	tableStats     *property.StatsInfo
+	// liteStats indicates the statisticTable is built from the lite stats of the partitions, its row count is
+	// real though its histograms are pseudo, so the estimations based on it are not labelled as pseudo.
+	liteStats bool
 
 	// possibleAccessPaths stores all the possible access path for physical plan, including table scan.
 	possibleAccessPaths []*util.AccessPath
+	// keepOrderItems is the order required by the ORDER BY above if some access paths are hinted to keep the
+	// order, the best plan of the empty property scans them in this order then.
+	keepOrderItems []property.SortItem
 
 	// The data source may be a partition, rather than a real table.
 	isPartition     bool
@@ -678,6 +685,7 @@ func (ds *DataSource) deriveCommonHandleTablePathStats(path *util.AccessPath, co
 		if err != nil {
 			return false, err
 		}
+		path.CountAfterAccess = ds.refineCountByFeedback(path.Index.ID, path.Ranges, path.CountAfterAccess)
 	} else {
 		path.TableFilters = conds
 	}
@@ -800,6 +808,7 @@ func (ds *DataSource) deriveTablePathStats(path *util.AccessPath, conds []expres
 		return false, err
 	}
 	path.CountAfterAccess, err = ds.statisticTable.GetRowCountByIntColumnRanges(sc, pkCol.ID, path.Ranges)
+	path.CountAfterAccess = ds.refineCountByFeedback(0, path.Ranges, path.CountAfterAccess)
 	// If the `CountAfterAccess` is less than `stats.RowCount`, there must be some inconsistent stats info.
 	// We prefer the `stats.RowCount` because it could use more stats info to calculate the selectivity.
 	if path.CountAfterAccess < ds.stats.RowCount && !isIm {
@@ -816,6 +825,16 @@ func (ds *DataSource) deriveTablePathStats(path *util.AccessPath, conds []expres
 	return noIntervalRange, err
 }
 
+// refineCountByFeedback refines the estimated row count of the ranges on the index with the row count observed in
+// execution, idxID is 0 for the ranges on the integer handle.
+func (ds *DataSource) refineCountByFeedback(idxID int64, ranges []*ranger.Range, count float64) float64 {
+	if !ds.ctx.GetSessionVars().EnableCardinalityFeedback || ds.statisticTable.Count <= 0 {
+		return count
+	}
+	total := float64(ds.statisticTable.Count)
+	return statistics.GlobalSelectivityFeedback.EstimateRanges(ds.physicalTableID, idxID, ranges, count/total) * total
+}
+
 func (ds *DataSource) fillIndexPath(path *util.AccessPath, conds []expression.Expression) error {
 	sc := ds.ctx.GetSessionVars().StmtCtx
 	path.Ranges = ranger.FullRange()
@@ -853,6 +872,7 @@ func (ds *DataSource) fillIndexPath(path *util.AccessPath, conds []expression.Ex
 		if err != nil {
 			return err
 		}
+		path.CountAfterAccess = ds.refineCountByFeedback(path.Index.ID, path.Ranges, path.CountAfterAccess)
 	} else {
 		path.TableFilters = conds
 	}
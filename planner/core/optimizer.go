@@ -15,6 +15,7 @@ package core
 
 import (
 	"context"
+	"fmt"
 	"math"
 
 	"github.com/pingcap/errors"
@@ -29,6 +30,7 @@ import (
 	"github.com/pingcap/tidb/planner/property"
 	"github.com/pingcap/tidb/privilege"
 	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/sessionctx/stmtctx"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/types"
 	utilhint "github.com/pingcap/tidb/util/hint"
@@ -59,6 +61,7 @@ const (
 	flagPushDownAgg
 	flagPushDownTopN
 	flagJoinReOrder
+	flagPartitionWiseJoin
 	flagPrunColumnsAgain
 )
 
@@ -76,6 +79,7 @@ var optRuleList = []logicalOptRule{
 	&aggregationPushDownSolver{},
 	&pushDownTopNOptimizer{},
 	&joinReOrderSolver{},
+	&partitionWiseJoinSolver{},
 	&columnPruner{}, // column pruning again at last, note it will mess up the results of buildKeySolver
 }
 
@@ -222,17 +226,35 @@ func enableParallelApply(sctx sessionctx.Context, plan PhysicalPlan) PhysicalPla
 
 func logicalOptimize(ctx context.Context, flag uint64, logic LogicalPlan) (LogicalPlan, error) {
 	var err error
+	sc := logic.SCtx().GetSessionVars().StmtCtx
 	for i, rule := range optRuleList {
 		// The order of flags is same as the order of optRule in the list.
 		// We use a bitmask to record which opt rules should be used. If the i-th bit is 1, it means we should
 		// apply i-th optimizing rule.
-		if flag&(1<<uint(i)) == 0 || isLogicalRuleDisabled(rule) {
+		if flag&(1<<uint(i)) == 0 {
 			continue
 		}
+		if isLogicalRuleDisabled(rule) {
+			sc.AppendOptimizerTrace(stmtctx.TraceTypeRule, "", rule.name()+" is skipped since it's in mysql.opt_rule_blacklist")
+			continue
+		}
+		if !sc.EnableOptimizerTrace {
+			logic, err = rule.optimize(ctx, logic)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		before := ToString(logic)
 		logic, err = rule.optimize(ctx, logic)
 		if err != nil {
 			return nil, err
 		}
+		if after := ToString(logic); after != before {
+			sc.AppendOptimizerTrace(stmtctx.TraceTypeRule, "", rule.name()+" changes the plan to "+after)
+		} else {
+			sc.AppendOptimizerTrace(stmtctx.TraceTypeRule, "", rule.name()+" doesn't change the plan")
+		}
 	}
 	return logic, err
 }
@@ -266,10 +288,44 @@ func physicalOptimize(logic LogicalPlan, planCounter *PlanCounterTp) (PhysicalPl
 		return nil, 0, ErrInternal.GenWithStackByArgs("Can't find a proper physical plan for this query")
 	}
 
+	if sc := logic.SCtx().GetSessionVars().StmtCtx; sc.EnableOptimizerTrace {
+		sc.AppendOptimizerTrace(stmtctx.TraceTypeChosen, t.plan().ExplainID().String(),
+			fmt.Sprintf("the physical plan %s with cost %v is chosen", ToString(t.plan()), t.cost()))
+		tracePushDown(sc, t.plan())
+	}
 	err = t.plan().ResolveIndices()
 	return t.plan(), t.cost(), err
 }
 
+// tracePushDown records the plans which are pushed down to the storage by the readers of p into the optimizer trace.
+func tracePushDown(sc *stmtctx.StatementContext, p PhysicalPlan) {
+	pushDown := func(reader PhysicalPlan, storeType kv.StoreType, by string, pushed PhysicalPlan) {
+		sc.AppendOptimizerTrace(stmtctx.TraceTypePushDown, reader.ExplainID().String(),
+			fmt.Sprintf("%s is pushed down to %s by %s", ToString(pushed), storeType.Name(), by))
+	}
+	switch x := p.(type) {
+	case *PhysicalTableReader:
+		if _, ok := x.tablePlan.(*PhysicalExchangeSender); ok {
+			pushDown(x, x.StoreType, "MPP", x.tablePlan)
+		} else {
+			pushDown(x, x.StoreType, "coprocessor", x.tablePlan)
+		}
+	case *PhysicalIndexReader:
+		pushDown(x, kv.TiKV, "coprocessor", x.indexPlan)
+	case *PhysicalIndexLookUpReader:
+		pushDown(x, kv.TiKV, "coprocessor", x.indexPlan)
+		pushDown(x, kv.TiKV, "coprocessor", x.tablePlan)
+	case *PhysicalIndexMergeReader:
+		for _, partialPlan := range x.partialPlans {
+			pushDown(x, kv.TiKV, "coprocessor", partialPlan)
+		}
+		pushDown(x, kv.TiKV, "coprocessor", x.tablePlan)
+	}
+	for _, child := range p.Children() {
+		tracePushDown(sc, child)
+	}
+}
+
 // eliminateUnionScanAndLock set lock property for PointGet and BatchPointGet and eliminates UnionScan and Lock.
 func eliminateUnionScanAndLock(sctx sessionctx.Context, p PhysicalPlan) PhysicalPlan {
 	var pointGet *PointGetPlan
@@ -882,6 +882,8 @@ type PhysicalMergeJoin struct {
 	CompareFuncs []expression.CompareFunc
 	// Desc means whether inner child keep desc order.
 	Desc bool
+	// SortChildren means the children are not sorted by the join keys, so the executor sorts them externally.
+	SortChildren bool
 }
 
 // PhysicalExchangeReceiver accepts connection and receives data passively.
@@ -942,6 +944,7 @@ func (p *PhysicalMergeJoin) Clone() (PhysicalPlan, error) {
 	cloned.basePhysicalJoin = *base
 	cloned.CompareFuncs = append(cloned.CompareFuncs, p.CompareFuncs...)
 	cloned.Desc = p.Desc
+	cloned.SortChildren = p.SortChildren
 	return cloned, nil
 }
 
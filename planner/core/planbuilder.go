@@ -29,6 +29,7 @@ import (
 	"github.com/pingcap/parser/model"
 	"github.com/pingcap/parser/mysql"
 	"github.com/pingcap/parser/opcode"
+	"github.com/pingcap/tidb/bindinfo"
 	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/ddl"
 	"github.com/pingcap/tidb/domain"
@@ -84,6 +85,7 @@ type tableHintInfo struct {
 	sortMergeJoinTables         []hintTableInfo
 	broadcastJoinTables         []hintTableInfo
 	broadcastJoinPreferredLocal []hintTableInfo
+	shuffleJoinTables           []hintTableInfo
 	hashJoinTables              []hintTableInfo
 	indexHintList               []indexHintInfo
 	tiflashTables               []hintTableInfo
@@ -119,6 +121,12 @@ type indexHintInfo struct {
 }
 
 func (hint *indexHintInfo) hintTypeString() string {
+	if hint.indexHint.HintScope == ast.HintForOrderBy {
+		if hint.indexHint.HintType == ast.HintIgnore {
+			return "no_order_index"
+		}
+		return "order_index"
+	}
 	switch hint.indexHint.HintType {
 	case ast.HintUse:
 		return "use_index"
@@ -217,6 +225,10 @@ func (info *tableHintInfo) ifPreferBroadcastJoin(tableNames ...*hintTableInfo) b
 	return info.matchTableName(tableNames, info.broadcastJoinTables)
 }
 
+func (info *tableHintInfo) ifPreferShuffleJoin(tableNames ...*hintTableInfo) bool {
+	return info.matchTableName(tableNames, info.shuffleJoinTables)
+}
+
 func (info *tableHintInfo) ifPreferHashJoin(tableNames ...*hintTableInfo) bool {
 	return info.matchTableName(tableNames, info.hashJoinTables)
 }
@@ -433,6 +445,8 @@ type cteInfo struct {
 	enterSubquery bool
 	recursiveRef  bool
 	limitLP       LogicalPlan
+	// isInline indicates the CTE is built as a derived table where it's referenced instead of being materialized.
+	isInline bool
 }
 
 // PlanBuilder builds Plan from an ast.Node.
@@ -797,7 +811,15 @@ func (b *PlanBuilder) buildDropBindPlan(v *ast.DropBindingStmt) (Plan, error) {
 	if v.HintedNode != nil {
 		p.BindSQL = utilparser.RestoreWithDefaultDB(v.HintedNode, b.ctx.GetSessionVars().CurrentDB, v.HintedNode.Text())
 	}
-	b.visitInfo = appendVisitInfo(b.visitInfo, mysql.SuperPriv, "", "", "", nil)
+	wildcard, err := bindinfo.CheckWildcardTables(v.OriginNode)
+	if err != nil {
+		return nil, err
+	}
+	if wildcard {
+		p.NormdOrigSQL, _ = bindinfo.NormalizeWildcard(v.OriginNode)
+		p.Db = bindinfo.WildcardDB
+	}
+	b.appendBindingAdminVisitInfo()
 	return p, nil
 }
 
@@ -842,7 +864,24 @@ func (b *PlanBuilder) buildCreateBindPlan(v *ast.CreateBindingStmt) (Plan, error
 		Charset:      charSet,
 		Collation:    collation,
 	}
-	b.visitInfo = appendVisitInfo(b.visitInfo, mysql.SuperPriv, "", "", "", nil)
+	// A cross-database binding is created if the tables are all in the wildcard database, the tables of both the
+	// original SQL and the bind SQL must be in it.
+	wildcard, err := bindinfo.CheckWildcardTables(v.OriginNode)
+	if err != nil {
+		return nil, err
+	}
+	hintedWildcard, err := bindinfo.CheckWildcardTables(v.HintedNode)
+	if err != nil {
+		return nil, err
+	}
+	if wildcard != hintedWildcard {
+		return nil, errors.New("the tables of both the original SQL and the bind SQL of a cross-database binding must be in the database `*`")
+	}
+	if wildcard {
+		p.NormdOrigSQL, _ = bindinfo.NormalizeWildcard(v.OriginNode)
+		p.Db = bindinfo.WildcardDB
+	}
+	b.appendBindingAdminVisitInfo()
 	return p, nil
 }
 
@@ -979,6 +1018,7 @@ func getPossibleAccessPaths(ctx sessionctx.Context, tableHints *tableHintInfo, i
 	} else if !tblInfo.TiFlashReplica.Available {
 		ctx.GetSessionVars().RaiseWarningWhenMPPEnforced("MPP mode may be blocked because tiflash replicas of table `" + tblInfo.Name.O + "` not ready.")
 	} else {
+		ctx.GetSessionVars().StmtCtx.HasTiFlashReplica = true
 		publicPaths = append(publicPaths, genTiFlashPath(tblInfo, false))
 		publicPaths = append(publicPaths, genTiFlashPath(tblInfo, true))
 	}
@@ -1030,7 +1070,7 @@ func getPossibleAccessPaths(ctx sessionctx.Context, tableHints *tableHintInfo, i
 
 	_, isolationReadEnginesHasTiKV := ctx.GetSessionVars().GetIsolationReadEngines()[kv.TiKV]
 	for i, hint := range indexHints {
-		if hint.HintScope != ast.HintForScan {
+		if hint.HintScope != ast.HintForScan && hint.HintScope != ast.HintForOrderBy {
 			continue
 		}
 
@@ -1050,7 +1090,7 @@ func getPossibleAccessPaths(ctx sessionctx.Context, tableHints *tableHintInfo, i
 		// It is syntactically valid to omit index_list for USE INDEX, which means “use no indexes”.
 		// Omitting index_list for FORCE INDEX or IGNORE INDEX is a syntax error.
 		// See https://dev.mysql.com/doc/refman/8.0/en/index-hints.html.
-		if hint.IndexNames == nil && hint.HintType != ast.HintIgnore {
+		if hint.IndexNames == nil && hint.HintType != ast.HintIgnore && hint.HintScope == ast.HintForScan {
 			if path := getTablePath(publicPaths); path != nil {
 				hasUseOrForce = true
 				path.Forced = true
@@ -1068,6 +1108,15 @@ func getPossibleAccessPaths(ctx sessionctx.Context, tableHints *tableHintInfo, i
 				ctx.GetSessionVars().StmtCtx.AppendWarning(err)
 				continue
 			}
+			if hint.HintScope == ast.HintForOrderBy {
+				// The index hinted for ORDER BY is used like the USE INDEX hint and scanned in order, or it's
+				// kept available but never scanned in order if it's ignored for ORDER BY.
+				if hint.HintType == ast.HintIgnore {
+					path.ForceNoKeepOrder = true
+					continue
+				}
+				path.ForceKeepOrder = true
+			}
 			if hint.HintType == ast.HintIgnore {
 				// Collect all the ignored index hints.
 				ignored = append(ignored, path)
@@ -1244,13 +1293,13 @@ func (b *PlanBuilder) buildAdmin(ctx context.Context, as *ast.AdminStmt) (Plan,
 	case ast.AdminPluginDisable:
 		return &AdminPlugins{Action: Disable, Plugins: as.Plugins}, nil
 	case ast.AdminFlushBindings:
-		return &SQLBindPlan{SQLBindOp: OpFlushBindings}, nil
+		return b.buildAdminBindings(OpFlushBindings), nil
 	case ast.AdminCaptureBindings:
-		return &SQLBindPlan{SQLBindOp: OpCaptureBindings}, nil
+		return b.buildAdminBindings(OpCaptureBindings), nil
 	case ast.AdminEvolveBindings:
-		return &SQLBindPlan{SQLBindOp: OpEvolveBindings}, nil
+		return b.buildAdminBindings(OpEvolveBindings), nil
 	case ast.AdminReloadBindings:
-		return &SQLBindPlan{SQLBindOp: OpReloadBindings}, nil
+		return b.buildAdminBindings(OpReloadBindings), nil
 	case ast.AdminShowTelemetry:
 		p := &AdminShowTelemetry{}
 		p.setSchemaAndNames(buildShowTelemetrySchema())
@@ -1268,6 +1317,18 @@ func (b *PlanBuilder) buildAdmin(ctx context.Context, as *ast.AdminStmt) (Plan,
 	return ret, nil
 }
 
+// buildAdminBindings builds the admin commands on the SQL bindings, which are executed by the users with the SUPER or
+// BINDING_ADMIN privilege.
+func (b *PlanBuilder) buildAdminBindings(op SQLBindOpType) Plan {
+	b.appendBindingAdminVisitInfo()
+	return &SQLBindPlan{SQLBindOp: op}
+}
+
+func (b *PlanBuilder) appendBindingAdminVisitInfo() {
+	err := ErrSpecificAccessDenied.GenWithStackByArgs("SUPER or BINDING_ADMIN")
+	b.visitInfo = appendDynamicVisitInfo(b.visitInfo, "BINDING_ADMIN", false, err)
+}
+
 // FindColumnInfoByID finds ColumnInfo in cols by ID.
 func FindColumnInfoByID(colInfos []*model.ColumnInfo, id int64) *model.ColumnInfo {
 	for _, info := range colInfos {
@@ -2448,6 +2509,17 @@ func calculateTsExpr(sctx sessionctx.Context, asOfClause *ast.AsOfClause) (uint6
 	return oracle.GoTimeToTS(tsTime), nil
 }
 
+// calculateBoundedStalenessTS resolves the freshest StartTS which is at most staleness older than now. It's never
+// smaller than the commit ts of the transactions committed by the session, so the session reads its own writes.
+func calculateBoundedStalenessTS(sctx sessionctx.Context, staleness time.Duration) uint64 {
+	sessVars := sctx.GetSessionVars()
+	ts := kv.ResolveBoundedStalenessTS(sctx.GetStore(), sessVars.CheckAndGetTxnScope(), time.Now(), staleness)
+	if ts < sessVars.LastCommitTS {
+		ts = sessVars.LastCommitTS
+	}
+	return ts
+}
+
 func collectVisitInfoFromRevokeStmt(sctx sessionctx.Context, vi []visitInfo, stmt *ast.RevokeStmt) []visitInfo {
 	// To use REVOKE, you must have the GRANT OPTION privilege,
 	// and you must have the privileges that you are granting.
@@ -2762,6 +2834,12 @@ func (b *PlanBuilder) buildInsert(ctx context.Context, insert *ast.InsertStmt) (
 		return nil, err
 	}
 
+	if insert.IsReplace || len(insert.OnDuplicate) > 0 {
+		if err = b.buildInsertSecurityPolicies(ctx, insertPlan, mockTablePlan, tn.DBInfo.Name); err != nil {
+			return nil, err
+		}
+	}
+
 	err = insertPlan.ResolveIndices()
 	return insertPlan, err
 }
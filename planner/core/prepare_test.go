@@ -25,6 +25,7 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/parser/auth"
 	"github.com/pingcap/parser/terror"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/executor"
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/kv"
@@ -1506,3 +1507,151 @@ func (s *testPlanSerialSuite) TestPartitionWithVariedDatasources(c *C) {
 		}
 	}
 }
+
+func (s *testPrepareSerialSuite) TestGeneralPlanCache(c *C) {
+	defer testleak.AfterTest(c)()
+	store, dom, err := newStoreWithBootstrap()
+	c.Assert(err, IsNil)
+	tk := testkit.NewTestKit(c, store)
+	orgEnable := core.PreparedPlanCacheEnabled()
+	defer func() {
+		dom.Close()
+		err = store.Close()
+		c.Assert(err, IsNil)
+		core.SetPreparedPlanCache(orgEnable)
+	}()
+	core.SetPreparedPlanCache(true)
+	tk.Se, err = session.CreateSession4TestWithOpt(store, &session.Opt{
+		PreparedPlanCache: kvcache.NewSimpleLRUCache(100, 0.1, math.MaxUint64),
+	})
+	c.Assert(err, IsNil)
+
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int, b int, c varchar(10), key(a))")
+	tk.MustExec("insert into t values(1, 1, 'a'), (2, 2, 'b'), (3, 3, 'c'), (4, 4, 'd')")
+	tk.MustExec("set @@tidb_enable_general_plan_cache = 0")
+	tk.MustQuery("select b from t where a > 1 and a < 3").Check(testkit.Rows("2"))
+	tk.MustQuery("select b from t where a > 1 and a < 3").Check(testkit.Rows("2"))
+	tk.MustQuery("select @@last_plan_from_cache").Check(testkit.Rows("0"))
+
+	tk.MustExec("set @@tidb_enable_general_plan_cache = 1")
+	// The range of the first statement isn't a point, otherwise its plan depends on the literals and isn't cached.
+	tk.MustQuery("select b from t where a > 1 and a < 4").Sort().Check(testkit.Rows("2", "3"))
+	tk.MustQuery("select @@last_plan_from_cache").Check(testkit.Rows("0"))
+	// The statements only differ in the literals share the cached plan, whose ranges are rebuilt by the literals.
+	tk.MustQuery("select b from t where a > 2 and a < 5").Sort().Check(testkit.Rows("3", "4"))
+	tk.MustQuery("select @@last_plan_from_cache").Check(testkit.Rows("1"))
+	tk.MustQuery("select b from t where a > 0 and a < 3").Sort().Check(testkit.Rows("1", "2"))
+	tk.MustQuery("select @@last_plan_from_cache").Check(testkit.Rows("1"))
+	tk.MustQuery("select b from t where a > 1 and a < 3").Check(testkit.Rows("2"))
+	tk.MustQuery("select @@last_plan_from_cache").Check(testkit.Rows("1"))
+	// The literals of different types don't share the cached plan.
+	tk.MustQuery("select b from t where a > 1.5 and a < 3").Check(testkit.Rows("2"))
+	tk.MustQuery("select @@last_plan_from_cache").Check(testkit.Rows("0"))
+	tk.MustQuery("select b from t where c = 'c'").Check(testkit.Rows("3"))
+	tk.MustQuery("select b from t where c = 'd'").Check(testkit.Rows("4"))
+	tk.MustQuery("select @@last_plan_from_cache").Check(testkit.Rows("1"))
+
+	// The cached plans are dropped after the schema is changed.
+	tk.MustExec("alter table t add index idx_c(c)")
+	tk.MustQuery("select b from t where c = 'a'").Check(testkit.Rows("1"))
+	tk.MustQuery("select @@last_plan_from_cache").Check(testkit.Rows("0"))
+	tk.MustQuery("select b from t where c = 'b'").Check(testkit.Rows("2"))
+	tk.MustQuery("select @@last_plan_from_cache").Check(testkit.Rows("1"))
+
+	// The statements which can't be cached are optimized as usual.
+	tk.MustQuery("select /*+ ignore_plan_cache() */ b from t where a = 1").Check(testkit.Rows("1"))
+	tk.MustQuery("select /*+ ignore_plan_cache() */ b from t where a = 2").Check(testkit.Rows("2"))
+	tk.MustQuery("select @@last_plan_from_cache").Check(testkit.Rows("0"))
+	tk.MustQuery("select b from t where a = (select max(a) from t)").Check(testkit.Rows("4"))
+	tk.MustQuery("select b from t where a = (select max(a) from t)").Check(testkit.Rows("4"))
+	tk.MustQuery("select @@last_plan_from_cache").Check(testkit.Rows("0"))
+}
+
+func (s *testPrepareSerialSuite) TestGeneralPlanCacheEviction(c *C) {
+	defer testleak.AfterTest(c)()
+	store, dom, err := newStoreWithBootstrap()
+	c.Assert(err, IsNil)
+	tk := testkit.NewTestKit(c, store)
+	orgEnable := core.PreparedPlanCacheEnabled()
+	defer func() {
+		dom.Close()
+		err = store.Close()
+		c.Assert(err, IsNil)
+		core.SetPreparedPlanCache(orgEnable)
+	}()
+	defer config.RestoreFunc()()
+	config.UpdateGlobal(func(conf *config.Config) {
+		conf.PreparedPlanCache.Capacity = 2
+	})
+	core.SetPreparedPlanCache(true)
+	tk.Se, err = session.CreateSession4TestWithOpt(store, &session.Opt{
+		PreparedPlanCache: kvcache.NewSimpleLRUCache(100, 0.1, math.MaxUint64),
+	})
+	c.Assert(err, IsNil)
+
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int, b int)")
+	tk.MustExec("insert into t values(1, 1), (2, 2)")
+	tk.MustExec("set @@tidb_enable_general_plan_cache = 1")
+	tk.MustQuery("select b from t where a = 1").Check(testkit.Rows("1"))
+	tk.MustQuery("select a from t where b = 1").Check(testkit.Rows("1"))
+	// Use the first statement, so the second one is the least recently used.
+	tk.MustQuery("select b from t where a = 2").Check(testkit.Rows("2"))
+	tk.MustQuery("select @@last_plan_from_cache").Check(testkit.Rows("1"))
+	tk.MustQuery("select a, b from t where a = 1").Check(testkit.Rows("1 1"))
+	tk.MustQuery("select b from t where a = 1").Check(testkit.Rows("1"))
+	tk.MustQuery("select @@last_plan_from_cache").Check(testkit.Rows("1"))
+	tk.MustQuery("select a from t where b = 2").Check(testkit.Rows("2"))
+	tk.MustQuery("select @@last_plan_from_cache").Check(testkit.Rows("0"))
+}
+
+func (s *testPrepareSerialSuite) TestPlanCacheInvalidation(c *C) {
+	defer testleak.AfterTest(c)()
+	store, dom, err := newStoreWithBootstrap()
+	c.Assert(err, IsNil)
+	tk := testkit.NewTestKit(c, store)
+	orgEnable := core.PreparedPlanCacheEnabled()
+	defer func() {
+		dom.Close()
+		err = store.Close()
+		c.Assert(err, IsNil)
+		core.SetPreparedPlanCache(orgEnable)
+	}()
+	core.SetPreparedPlanCache(true)
+	tk.Se, err = session.CreateSession4TestWithOpt(store, &session.Opt{
+		PreparedPlanCache: kvcache.NewSimpleLRUCache(100, 0.1, math.MaxUint64),
+	})
+	c.Assert(err, IsNil)
+
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int, b int, key(a))")
+	tk.MustExec("insert into t values(1, 1), (2, 2), (3, 3)")
+	tk.MustExec(`prepare stmt from "select b from t where a > ?"`)
+	tk.MustExec("set @a = 1")
+	tk.MustQuery("execute stmt using @a").Sort().Check(testkit.Rows("2", "3"))
+	tk.MustQuery("execute stmt using @a").Sort().Check(testkit.Rows("2", "3"))
+	tk.MustQuery("select @@last_plan_from_cache").Check(testkit.Rows("1"))
+
+	// Creating or dropping a binding of the statement invalidates the cached plan.
+	tk.MustExec("create session binding for select b from t where a > 1 using select b from t use index() where a > 1")
+	tk.MustQuery("execute stmt using @a").Sort().Check(testkit.Rows("2", "3"))
+	tk.MustQuery("select @@last_plan_from_cache").Check(testkit.Rows("0"))
+	tk.MustQuery("execute stmt using @a").Sort().Check(testkit.Rows("2", "3"))
+	tk.MustQuery("select @@last_plan_from_cache").Check(testkit.Rows("1"))
+	tk.MustExec("drop session binding for select b from t where a > 1")
+	tk.MustQuery("execute stmt using @a").Sort().Check(testkit.Rows("2", "3"))
+	tk.MustQuery("select @@last_plan_from_cache").Check(testkit.Rows("0"))
+
+	// Analyzing the table invalidates the cached plan.
+	tk.MustQuery("execute stmt using @a").Sort().Check(testkit.Rows("2", "3"))
+	tk.MustQuery("select @@last_plan_from_cache").Check(testkit.Rows("1"))
+	tk.MustExec("analyze table t")
+	tk.MustQuery("execute stmt using @a").Sort().Check(testkit.Rows("2", "3"))
+	tk.MustQuery("select @@last_plan_from_cache").Check(testkit.Rows("0"))
+	tk.MustQuery("execute stmt using @a").Sort().Check(testkit.Rows("2", "3"))
+	tk.MustQuery("select @@last_plan_from_cache").Check(testkit.Rows("1"))
+}
@@ -101,6 +101,7 @@ func Preprocess(ctx sessionctx.Context, node ast.Node, preprocessOpt ...Preproce
 	if v.PreprocessorReturn == nil {
 		v.PreprocessorReturn = &PreprocessorReturn{}
 	}
+	v.staleReadable = v.flag&inPrepare == 0 && isStaleReadableStmt(node)
 	node.Accept(&v)
 	// InfoSchema must be non-nil after preprocessing
 	v.ensureInfoSchema()
@@ -148,6 +149,11 @@ type preprocessor struct {
 	tableAliasInJoin []map[string]interface{}
 	withName         map[string]interface{}
 
+	// staleReadable indicates whether the statement can read with the bounded staleness of tidb_read_staleness.
+	staleReadable bool
+	// boundedStaleness is set when the snapshot ts is resolved with tidb_read_staleness.
+	boundedStaleness bool
+
 	// values that may be returned
 	*PreprocessorReturn
 	err error
@@ -446,7 +452,7 @@ func (p *preprocessor) Leave(in ast.Node) (out ast.Node, ok bool) {
 		if _, ok := x.Stmt.(*ast.ShowStmt); ok {
 			break
 		}
-		valid := false
+		valid := strings.ToLower(x.Format) == ExplainFormatTrace
 		for i, length := 0, len(ast.ExplainFormats); i < length; i++ {
 			if strings.ToLower(x.Format) == ast.ExplainFormats[i] {
 				valid = true
@@ -1490,6 +1496,22 @@ func (p *preprocessor) handleAsOfAndReadTS(node *ast.AsOfClause) {
 			p.ExplicitStaleness = true
 		}
 	}
+	if ts == 0 && node == nil && p.staleReadable {
+		sessVars := p.ctx.GetSessionVars()
+		if staleness := sessVars.ReadStaleness; staleness > 0 && !sessVars.InTxn() {
+			if !p.initedLastSnapshotTS {
+				ts = calculateBoundedStalenessTS(p.ctx, staleness)
+				p.SnapshotTSEvaluator = func(ctx sessionctx.Context) (uint64, error) {
+					return calculateBoundedStalenessTS(ctx, staleness), nil
+				}
+				p.LastSnapshotTS = ts
+				p.ExplicitStaleness = true
+				p.boundedStaleness = true
+			} else if p.boundedStaleness {
+				ts = p.LastSnapshotTS
+			}
+		}
+	}
 	if p.LastSnapshotTS != ts {
 		p.err = ErrAsOf.GenWithStack("can not set different time in the as of")
 		return
@@ -1504,6 +1526,16 @@ func (p *preprocessor) handleAsOfAndReadTS(node *ast.AsOfClause) {
 	p.initedLastSnapshotTS = true
 }
 
+// isStaleReadableStmt checks whether the statement is a read-only query which can be served
+// by a bounded stale snapshot.
+func isStaleReadableStmt(node ast.Node) bool {
+	switch node.(type) {
+	case *ast.SelectStmt, *ast.SetOprStmt:
+		return ast.IsReadOnly(node)
+	}
+	return false
+}
+
 // ensureInfoSchema get the infoschema from the preprecessor.
 // there some situations:
 //    - the stmt specifies the schema version.
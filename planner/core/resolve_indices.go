@@ -609,6 +609,12 @@ func (p *Insert) ResolveIndices() (err error) {
 			return err
 		}
 	}
+	for i, cond := range p.SecurityPolicyConds {
+		p.SecurityPolicyConds[i], err = cond.ResolveIndices(p.tableSchema)
+		if err != nil {
+			return err
+		}
+	}
 	for _, asgn := range p.GenCols.OnDuplicates {
 		newCol, err := asgn.Col.ResolveIndices(p.tableSchema)
 		if err != nil {
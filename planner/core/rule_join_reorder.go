@@ -15,7 +15,9 @@ package core
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/pingcap/errors"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/sessionctx"
 )
@@ -74,22 +76,30 @@ func (s *joinReOrderSolver) optimizeRecursive(ctx sessionctx.Context, p LogicalP
 			otherConds: otherConds,
 		}
 		originalSchema := p.Schema()
-		if len(curJoinGroup) > ctx.GetSessionVars().TiDBOptJoinReorderThreshold {
+		threshold := ctx.GetSessionVars().TiDBOptJoinReorderThreshold
+		var algorithm string
+		if len(curJoinGroup) > threshold {
 			groupSolver := &joinReorderGreedySolver{
 				baseSingleGroupJoinOrderSolver: baseGroupSolver,
 				eqEdges:                        eqEdges,
 			}
 			p, err = groupSolver.solve(curJoinGroup)
+			algorithm = fmt.Sprintf("the greedy algorithm since the group has more tables than tidb_opt_join_reorder_threshold(%d)", threshold)
 		} else {
 			dpSolver := &joinReorderDPSolver{
 				baseSingleGroupJoinOrderSolver: baseGroupSolver,
 			}
 			dpSolver.newJoin = dpSolver.newJoinWithEdges
 			p, err = dpSolver.solve(curJoinGroup, expression.ScalarFuncs2Exprs(eqEdges))
+			algorithm = "the dynamic programming algorithm"
 		}
 		if err != nil {
 			return nil, err
 		}
+		if sc := ctx.GetSessionVars().StmtCtx; sc.EnableOptimizerNotes || sc.EnableOptimizerTrace {
+			sc.AppendOptimizerNote(errors.Errorf("Join reorder: the join order %s of %d tables is chosen by %s",
+				joinOrderString(p), len(curJoinGroup), algorithm))
+		}
 		schemaChanged := false
 		if len(p.Schema().Columns) != len(originalSchema.Columns) {
 			schemaChanged = true
@@ -123,6 +133,22 @@ func (s *joinReOrderSolver) optimizeRecursive(ctx sessionctx.Context, p LogicalP
 	return p, nil
 }
 
+// joinOrderString describes the join order of the reordered joins, such as ((t1, t2), t3).
+func joinOrderString(p LogicalPlan) string {
+	switch x := p.(type) {
+	case *LogicalJoin:
+		if x.reordered {
+			return "(" + joinOrderString(x.children[0]) + ", " + joinOrderString(x.children[1]) + ")"
+		}
+	case *DataSource:
+		return x.TableAsName.O
+	}
+	if len(p.Children()) == 1 {
+		return joinOrderString(p.Children()[0])
+	}
+	return p.ExplainID().String()
+}
+
 // nolint:structcheck
 type baseSingleGroupJoinOrderSolver struct {
 	ctx          sessionctx.Context
@@ -118,8 +118,15 @@ func (s *joinReorderDPSolver) solve(joinGroup []LogicalPlan, eqConds []expressio
 			subNonEqEdges = append(subNonEqEdges, totalNonEqEdges[i])
 			totalNonEqEdges = append(totalNonEqEdges[:i], totalNonEqEdges[i+1:]...)
 		}
+		// The visit IDs of the nodes in different sub graphs overlap, so only the edges in this sub graph are used.
+		var subEqEdges []joinGroupEqEdge
+		for _, edge := range totalEqEdges {
+			if nodeIDMask&(1<<uint(edge.nodeIDs[0])) > 0 {
+				subEqEdges = append(subEqEdges, edge)
+			}
+		}
 		// Do DP on each sub graph.
-		join, err := s.dpGraph(visitID2NodeID, nodeID2VisitID, joinGroup, totalEqEdges, subNonEqEdges)
+		join, err := s.dpGraph(visitID2NodeID, nodeID2VisitID, joinGroup, subEqEdges, subNonEqEdges)
 		if err != nil {
 			return nil, err
 		}
@@ -155,11 +162,21 @@ func (s *joinReorderDPSolver) bfsGraph(startNode int, visited []bool, adjacents
 }
 
 // dpGraph is the core part of this algorithm.
-// It implements the traditional join reorder algorithm: DP by subset using the following formula:
-//   bestPlan[S:set of node] = the best one among Join(bestPlan[S1:subset of S], bestPlan[S2: S/S1])
+// It implements the DPsube join reorder algorithm, the best plan of a set of nodes S is the best one among
+// Join(bestPlan[S1], bestPlan[S2]), where S1 and S2 divide S into two connected sets which are connected by
+// some equal conditions. Only the connected sets have their best plans, so the sets which are not connected
+// are skipped by checking the bitmaps of the adjacent nodes, without enumerating any of their subsets.
 func (s *joinReorderDPSolver) dpGraph(visitID2NodeID, nodeID2VisitID []int, joinGroup []LogicalPlan,
 	totalEqEdges []joinGroupEqEdge, totalNonEqEdges []joinGroupNonEqEdge) (LogicalPlan, error) {
 	nodeCnt := uint(len(visitID2NodeID))
+	// adjacents[i] is the bitmap of the nodes connected with the node i by the equal conditions.
+	adjacents := make([]uint, nodeCnt)
+	for _, edge := range totalEqEdges {
+		lIdx := uint(nodeID2VisitID[edge.nodeIDs[0]])
+		rIdx := uint(nodeID2VisitID[edge.nodeIDs[1]])
+		adjacents[lIdx] |= 1 << rIdx
+		adjacents[rIdx] |= 1 << lIdx
+	}
 	bestPlan := make([]*jrNode, 1<<nodeCnt)
 	// bestPlan[s] is nil can be treated as bestCost[s] = +inf.
 	for i := uint(0); i < nodeCnt; i++ {
@@ -167,19 +184,20 @@ func (s *joinReorderDPSolver) dpGraph(visitID2NodeID, nodeID2VisitID []int, join
 	}
 	// Enumerate the nodeBitmap from small to big, make sure that S1 must be enumerated before S2 if S1 belongs to S2.
 	for nodeBitmap := uint(1); nodeBitmap < (1 << nodeCnt); nodeBitmap++ {
-		if bits.OnesCount(nodeBitmap) == 1 {
+		if bits.OnesCount(nodeBitmap) == 1 || !isConnectedNodes(nodeBitmap, adjacents) {
 			continue
 		}
-		// This loop can iterate all its subset.
-		for sub := (nodeBitmap - 1) & nodeBitmap; sub > 0; sub = (sub - 1) & nodeBitmap {
+		// The subset without the highest node is used as the left side, so each division is enumerated once.
+		lowerNodes := nodeBitmap ^ (1 << uint(bits.Len(nodeBitmap)-1))
+		for sub := lowerNodes; sub > 0; sub = (sub - 1) & lowerNodes {
 			remain := nodeBitmap ^ sub
-			if sub > remain {
-				continue
-			}
 			// If this subset is not connected skip it.
 			if bestPlan[sub] == nil || bestPlan[remain] == nil {
 				continue
 			}
+			if adjacentNodes(sub, adjacents)&remain == 0 {
+				continue
+			}
 			// Get the edge connecting the two parts.
 			usedEdges, otherConds := s.nodesAreConnected(sub, remain, nodeID2VisitID, totalEqEdges, totalNonEqEdges)
 			// Here we only check equal condition currently.
@@ -205,6 +223,27 @@ func (s *joinReorderDPSolver) dpGraph(visitID2NodeID, nodeID2VisitID []int, join
 	return bestPlan[(1<<nodeCnt)-1].p, nil
 }
 
+// adjacentNodes returns the bitmap of the nodes adjacent to any node in nodeBitmap.
+func adjacentNodes(nodeBitmap uint, adjacents []uint) uint {
+	result := uint(0)
+	for ; nodeBitmap > 0; nodeBitmap &= nodeBitmap - 1 {
+		result |= adjacents[bits.TrailingZeros(nodeBitmap)]
+	}
+	return result
+}
+
+// isConnectedNodes returns whether the nodes in nodeBitmap are connected by the edges among them.
+func isConnectedNodes(nodeBitmap uint, adjacents []uint) bool {
+	reached := nodeBitmap & -nodeBitmap
+	for {
+		next := (reached | adjacentNodes(reached, adjacents)) & nodeBitmap
+		if next == reached {
+			return reached == nodeBitmap
+		}
+		reached = next
+	}
+}
+
 func (s *joinReorderDPSolver) nodesAreConnected(leftMask, rightMask uint, oldPos2NewPos []int,
 	totalEqEdges []joinGroupEqEdge, totalNonEqEdges []joinGroupNonEqEdge) ([]joinGroupEqEdge, []expression.Expression) {
 	var (
@@ -199,6 +199,37 @@ func (s *testJoinReorderDPSuite) TestDPReorderTPCHQ5(c *C) {
 	c.Assert(s.planToString(result), Equals, "MockJoin{supplier, MockJoin{lineitem, MockJoin{orders, MockJoin{customer, MockJoin{nation, region}}}}}")
 }
 
+func (s *testJoinReorderDPSuite) TestDPReorderDisconnectedGraphs(c *C) {
+	s.statsMap = make(map[int]*property.StatsInfo)
+	// a -> 0, b -> 1, c -> 2, d -> 3, the sub graphs {a, d} and {b, c} have the same visit IDs.
+	s.mockStatsInfo(9, 100)
+	s.mockStatsInfo(6, 100)
+	joinGroup := make([]LogicalPlan, 0, 4)
+	joinGroup = append(joinGroup, s.newDataSource("a", 100))
+	joinGroup = append(joinGroup, s.newDataSource("b", 100))
+	joinGroup = append(joinGroup, s.newDataSource("c", 100))
+	joinGroup = append(joinGroup, s.newDataSource("d", 100))
+	var eqConds []expression.Expression
+	eqConds = append(eqConds, expression.NewFunctionInternal(s.ctx, ast.EQ, types.NewFieldType(mysql.TypeTiny), joinGroup[0].Schema().Columns[0], joinGroup[3].Schema().Columns[0]))
+	eqConds = append(eqConds, expression.NewFunctionInternal(s.ctx, ast.EQ, types.NewFieldType(mysql.TypeTiny), joinGroup[1].Schema().Columns[0], joinGroup[2].Schema().Columns[0]))
+	solver := &joinReorderDPSolver{
+		baseSingleGroupJoinOrderSolver: &baseSingleGroupJoinOrderSolver{
+			ctx: s.ctx,
+		},
+	}
+	solver.newJoin = func(lChild, rChild LogicalPlan, eqConds []*expression.ScalarFunction, otherConds []expression.Expression) LogicalPlan {
+		// The equal conditions must be built from the two sides of the join.
+		for _, cond := range eqConds {
+			c.Assert(lChild.Schema().Contains(cond.GetArgs()[0].(*expression.Column)), IsTrue)
+			c.Assert(rChild.Schema().Contains(cond.GetArgs()[1].(*expression.Column)), IsTrue)
+		}
+		return s.newMockJoin(lChild, rChild, eqConds, otherConds)
+	}
+	result, err := solver.solve(joinGroup, eqConds)
+	c.Assert(err, IsNil)
+	c.Assert(s.planToString(result), Equals, "MockJoin{MockJoin{a, d}, MockJoin{b, c}}")
+}
+
 func (s *testJoinReorderDPSuite) TestDPReorderAllCartesian(c *C) {
 	joinGroup := make([]LogicalPlan, 0, 4)
 	joinGroup = append(joinGroup, s.newDataSource("a", 100))
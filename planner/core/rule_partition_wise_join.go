@@ -0,0 +1,182 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/types"
+)
+
+// partitionWiseJoinSolver rewrites the inner join of two partitioned tables into the union of the joins of their
+// partition pairs, if both tables are partitioned by the join key in the same way. Rows with the same join key are
+// always located in the partitions of the same position, so the partition pairs can be joined independently, and
+// the union executes them concurrently without building the whole table on one side.
+//
+// It only works in the static partition prune mode, in which the partitioned tables have been expanded to
+// LogicalPartitionUnionAll by the partitionProcessor.
+type partitionWiseJoinSolver struct {
+}
+
+func (s *partitionWiseJoinSolver) optimize(ctx context.Context, p LogicalPlan) (LogicalPlan, error) {
+	return s.rewrite(p), nil
+}
+
+func (s *partitionWiseJoinSolver) rewrite(p LogicalPlan) LogicalPlan {
+	for i, child := range p.Children() {
+		p.SetChild(i, s.rewrite(child))
+	}
+	join, ok := p.(*LogicalJoin)
+	if !ok || join.JoinType != InnerJoin {
+		return p
+	}
+	lUnion, lOK := join.children[0].(*LogicalPartitionUnionAll)
+	rUnion, rOK := join.children[1].(*LogicalPartitionUnionAll)
+	if !lOK || !rOK {
+		return p
+	}
+	lParts, lPartCol, lOK := s.partitionsOf(lUnion)
+	rParts, rPartCol, rOK := s.partitionsOf(rUnion)
+	if !lOK || !rOK || !samePartitioning(lParts[0].tableInfo.Partition, rParts[0].tableInfo.Partition) ||
+		!joinedByColumns(join, lPartCol, rPartCol) {
+		return p
+	}
+
+	rPartByPos := make(map[int]*DataSource, len(rParts))
+	for _, ds := range rParts {
+		rPartByPos[partitionPos(ds)] = ds
+	}
+	joins := make([]LogicalPlan, 0, len(lParts))
+	for _, lDS := range lParts {
+		rDS, ok := rPartByPos[partitionPos(lDS)]
+		if !ok {
+			// The rows of the partition can't match any row since the partition of the other side has been pruned.
+			continue
+		}
+		joins = append(joins, s.newPartitionJoin(join, lDS, rDS))
+	}
+	if len(joins) == 0 {
+		tableDual := LogicalTableDual{RowCount: 0}.Init(join.SCtx(), join.blockOffset)
+		tableDual.SetSchema(join.Schema())
+		return tableDual
+	}
+	if len(joins) == 1 {
+		return joins[0]
+	}
+	unionAll := LogicalPartitionUnionAll{}.Init(join.SCtx(), join.blockOffset)
+	unionAll.SetChildren(joins...)
+	unionAll.SetSchema(join.Schema().Clone())
+	return unionAll
+}
+
+// partitionsOf returns the partitions under the union and the partition column of the table, ok is false if the
+// children of the union are not the partitions of a table partitioned by a single integer column.
+func (s *partitionWiseJoinSolver) partitionsOf(union *LogicalPartitionUnionAll) (parts []*DataSource, partCol *expression.Column, ok bool) {
+	parts = make([]*DataSource, 0, len(union.children))
+	for _, child := range union.children {
+		ds, ok := child.(*DataSource)
+		if !ok || !ds.isPartition || (len(parts) > 0 && ds.tableInfo.ID != parts[0].tableInfo.ID) {
+			return nil, nil, false
+		}
+		parts = append(parts, ds)
+	}
+	if len(parts) == 0 {
+		return nil, nil, false
+	}
+	ds := parts[0]
+	pi := ds.tableInfo.GetPartitionInfo()
+	if pi == nil || len(pi.Columns) > 0 || (pi.Type != model.PartitionTypeHash && pi.Type != model.PartitionTypeRange) {
+		return nil, nil, false
+	}
+	names, err := (&partitionProcessor{}).reconstructTableColNames(ds)
+	if err != nil {
+		return nil, nil, false
+	}
+	expr, err := generateHashPartitionExpr(ds.SCtx(), pi, ds.TblCols, names)
+	if err != nil {
+		return nil, nil, false
+	}
+	partCol, ok = expr.(*expression.Column)
+	if !ok || partCol.RetType.EvalType() != types.ETInt {
+		return nil, nil, false
+	}
+	return parts, partCol, true
+}
+
+// samePartitioning checks whether the rows with the same partition key are always located in the partitions of
+// the same position of the two tables.
+func samePartitioning(lPi, rPi *model.PartitionInfo) bool {
+	if lPi.Type != rPi.Type || len(lPi.Definitions) != len(rPi.Definitions) {
+		return false
+	}
+	if lPi.Type == model.PartitionTypeHash {
+		return lPi.Num == rPi.Num
+	}
+	for i := range lPi.Definitions {
+		lBounds, rBounds := lPi.Definitions[i].LessThan, rPi.Definitions[i].LessThan
+		if len(lBounds) != len(rBounds) {
+			return false
+		}
+		for j := range lBounds {
+			if lBounds[j] != rBounds[j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// joinedByColumns checks whether the join has the equal condition on the two integer columns which have the same
+// signedness, so the equal values are located in the same partition of the same partitioning.
+func joinedByColumns(join *LogicalJoin, lCol, rCol *expression.Column) bool {
+	if mysql.HasUnsignedFlag(lCol.RetType.Flag) != mysql.HasUnsignedFlag(rCol.RetType.Flag) {
+		return false
+	}
+	lKeys, rKeys, _, _ := join.GetJoinKeys()
+	for i := range lKeys {
+		if lKeys[i].UniqueID == lCol.UniqueID && rKeys[i].UniqueID == rCol.UniqueID {
+			return true
+		}
+	}
+	return false
+}
+
+// partitionPos returns the position of the partition read by the data source in the partition definitions.
+func partitionPos(ds *DataSource) int {
+	for i, def := range ds.tableInfo.Partition.Definitions {
+		if def.ID == ds.physicalTableID {
+			return i
+		}
+	}
+	return -1
+}
+
+// newPartitionJoin copies the join to join the partition pair.
+func (s *partitionWiseJoinSolver) newPartitionJoin(join *LogicalJoin, lDS, rDS *DataSource) *LogicalJoin {
+	newJoin := join.Shallow()
+	newJoin.SetSchema(join.Schema().Clone())
+	newJoin.EqualConditions = append([]*expression.ScalarFunction(nil), join.EqualConditions...)
+	newJoin.LeftConditions = append(expression.CNFExprs(nil), join.LeftConditions...)
+	newJoin.RightConditions = append(expression.CNFExprs(nil), join.RightConditions...)
+	newJoin.OtherConditions = append(expression.CNFExprs(nil), join.OtherConditions...)
+	newJoin.SetChildren(lDS, rDS)
+	return newJoin
+}
+
+func (*partitionWiseJoinSolver) name() string {
+	return "partition_wise_join"
+}
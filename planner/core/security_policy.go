@@ -0,0 +1,160 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/parser"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/privilege"
+)
+
+// securityPolicyKey identifies the predicate of a security policy on a table.
+type securityPolicyKey struct {
+	tableID   int64
+	predicate string
+}
+
+// securityPolicyCache caches the parsed predicates of the security policies. The predicates are checked against the
+// columns of their tables, so they're only valid in the schema version they're cached in. Like the expressions of
+// the generated columns, the cached predicates are shared by the sessions and rewritten by each plan.
+var securityPolicyCache = struct {
+	sync.Mutex
+	schemaVersion int64
+	exprs         map[securityPolicyKey]ast.ExprNode
+}{}
+
+// buildSecurityPolicies AND-s the predicates of the row-level security policies on the table for the current user into
+// a selection on top of the data source. The rows filtered out are invisible to the SELECT, UPDATE and DELETE
+// statements of the user.
+func (b *PlanBuilder) buildSecurityPolicies(ctx context.Context, p LogicalPlan, ds *DataSource) (LogicalPlan, error) {
+	conds, err := b.securityPolicyConds(ctx, p, ds.DBName, ds.tableInfo)
+	if err != nil || len(conds) == 0 {
+		return p, err
+	}
+	selection := LogicalSelection{Conditions: conds}.Init(b.ctx, b.getSelectOffset())
+	selection.SetChildren(p)
+	return selection, nil
+}
+
+// buildInsertSecurityPolicies builds the conditions of the row-level security policies on the table of the INSERT ...
+// ON DUPLICATE KEY UPDATE or REPLACE statement, which are evaluated on the duplicate rows. The invisible duplicate
+// rows are neither updated nor replaced.
+func (b *PlanBuilder) buildInsertSecurityPolicies(ctx context.Context, insertPlan *Insert, mockTablePlan LogicalPlan, dbName model.CIStr) error {
+	conds, err := b.securityPolicyConds(ctx, mockTablePlan, dbName, insertPlan.Table.Meta())
+	if err != nil {
+		return err
+	}
+	insertPlan.SecurityPolicyConds = conds
+	return nil
+}
+
+// securityPolicyConds rewrites the predicates of the row-level security policies on the table for the current user
+// and its active roles against the schema of p.
+func (b *PlanBuilder) securityPolicyConds(ctx context.Context, p LogicalPlan, dbName model.CIStr, tableInfo *model.TableInfo) ([]expression.Expression, error) {
+	pm := privilege.GetPrivilegeManager(b.ctx)
+	if pm == nil {
+		return nil, nil
+	}
+	predicates := pm.SecurityPolicies(b.ctx.GetSessionVars().ActiveRoles, dbName.L, tableInfo.Name.L)
+	if len(predicates) == 0 {
+		return nil, nil
+	}
+	b.ctx.GetSessionVars().StmtCtx.HasSecurityPolicy = true
+	conds := make([]expression.Expression, 0, len(predicates))
+	for _, predicate := range predicates {
+		expr, err := getSecurityPolicyExpr(b.is.SchemaMetaVersion(), tableInfo, predicate)
+		if err != nil {
+			return nil, ErrInvalidSecurityPolicy.GenWithStackByArgs(dbName.O, tableInfo.Name.O, err.Error())
+		}
+		cond, _, err := b.rewrite(ctx, expr, p, nil, true)
+		if err != nil {
+			return nil, ErrInvalidSecurityPolicy.GenWithStackByArgs(dbName.O, tableInfo.Name.O, err.Error())
+		}
+		conds = append(conds, expression.SplitCNFItems(cond)...)
+	}
+	return conds, nil
+}
+
+// getSecurityPolicyExpr gets the parsed predicate of the security policy from the cache, or parses and caches it.
+func getSecurityPolicyExpr(schemaVersion int64, tableInfo *model.TableInfo, predicate string) (ast.ExprNode, error) {
+	key := securityPolicyKey{tableID: tableInfo.ID, predicate: predicate}
+	securityPolicyCache.Lock()
+	defer securityPolicyCache.Unlock()
+	if securityPolicyCache.schemaVersion != schemaVersion {
+		securityPolicyCache.schemaVersion = schemaVersion
+		securityPolicyCache.exprs = make(map[securityPolicyKey]ast.ExprNode)
+	}
+	if expr, ok := securityPolicyCache.exprs[key]; ok {
+		return expr, nil
+	}
+	expr, err := parseSecurityPolicy(tableInfo, predicate)
+	if err != nil {
+		return nil, err
+	}
+	securityPolicyCache.exprs[key] = expr
+	return expr, nil
+}
+
+// CheckSecurityPolicy checks that the predicate of the security policy can be applied to its table.
+func CheckSecurityPolicy(tableInfo *model.TableInfo, predicate string) error {
+	_, err := parseSecurityPolicy(tableInfo, predicate)
+	return err
+}
+
+// parseSecurityPolicy parses the predicate of the security policy, which can only reference the columns of its table.
+func parseSecurityPolicy(tableInfo *model.TableInfo, predicate string) (ast.ExprNode, error) {
+	stmt, err := parser.New().ParseOneStmt("select "+predicate, "", "")
+	if err != nil {
+		return nil, err
+	}
+	expr := stmt.(*ast.SelectStmt).Fields.Fields[0].Expr
+	checker := &securityPolicyChecker{tableInfo: tableInfo}
+	expr.Accept(checker)
+	return expr, checker.err
+}
+
+type securityPolicyChecker struct {
+	tableInfo *model.TableInfo
+	err       error
+}
+
+// Enter implements Visitor interface.
+func (c *securityPolicyChecker) Enter(in ast.Node) (ast.Node, bool) {
+	switch x := in.(type) {
+	case *ast.SubqueryExpr, *ast.ExistsSubqueryExpr:
+		c.err = errors.New("subqueries are not supported")
+		return in, true
+	case *ast.ColumnName:
+		if x.Table.L != "" && x.Table.L != c.tableInfo.Name.L {
+			c.err = errors.Errorf("unknown table `%s`", x.Table.O)
+			return in, true
+		}
+		if model.FindColumnInfo(c.tableInfo.Cols(), x.Name.L) == nil {
+			c.err = errors.Errorf("unknown column `%s`", x.Name.O)
+			return in, true
+		}
+	}
+	return in, false
+}
+
+// Leave implements Visitor interface.
+func (c *securityPolicyChecker) Leave(in ast.Node) (ast.Node, bool) {
+	return in, c.err == nil
+}
@@ -0,0 +1,202 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/statistics"
+	"github.com/pingcap/tidb/table/tables"
+	"github.com/pingcap/tidb/util/execdetails"
+	"github.com/pingcap/tidb/util/ranger"
+)
+
+// CollectSelectivityFeedback feeds the selectivities of the pushed down selections observed in
+// the runtime stats back to statistics.GlobalSelectivityFeedback.
+func CollectSelectivityFeedback(sctx sessionctx.Context, p Plan) {
+	statsColl := sctx.GetSessionVars().StmtCtx.RuntimeStatsColl
+	if explain, ok := p.(*Explain); ok {
+		if !explain.Analyze {
+			return
+		}
+		statsColl, p = explain.RuntimeStatsColl, explain.TargetPlan
+	}
+	physicalPlan, ok := p.(PhysicalPlan)
+	if !ok || statsColl == nil {
+		return
+	}
+	forEachFullyReadReader(physicalPlan, func(reader PhysicalPlan) {
+		switch x := reader.(type) {
+		case *PhysicalTableReader:
+			collectCopSelectivityFeedback(sctx, statsColl, x.TablePlans, nil)
+		case *PhysicalIndexReader:
+			collectCopSelectivityFeedback(sctx, statsColl, x.IndexPlans, nil)
+		case *PhysicalIndexLookUpReader:
+			if conds, ok := collectCopSelectivityFeedback(sctx, statsColl, x.IndexPlans, nil); ok {
+				collectCopSelectivityFeedback(sctx, statsColl, x.TablePlans, conds)
+			}
+		case *PhysicalIndexMergeReader:
+			// The table side reads the union of the rows of the partial plans, so only the partial plans are observed.
+			for _, partialPlans := range x.PartialPlans {
+				collectCopSelectivityFeedback(sctx, statsColl, partialPlans, nil)
+			}
+		}
+	})
+}
+
+// collectCopSelectivityFeedback observes the selectivities of the selections above the scan. The selectivity is the
+// ratio of the output rows of the selection to the rows of the table, which is what the statistics estimate, so it's
+// keyed by all the conditions applied to the rows, including the access conditions of the scan and conds, which are
+// applied before the plans, such as the conditions of the index side of IndexLookUp. It returns all the conditions
+// applied by the plans, and false if the plans may not read all the rows.
+func collectCopSelectivityFeedback(sctx sessionctx.Context, statsColl *execdetails.RuntimeStatsColl, plans []PhysicalPlan, conds []expression.Expression) ([]expression.Expression, bool) {
+	if len(plans) == 0 {
+		return nil, false
+	}
+	for _, p := range plans {
+		if _, ok := p.(*PhysicalLimit); ok {
+			return nil, false
+		}
+	}
+	conds = append([]expression.Expression(nil), conds...)
+	var (
+		tblInfo    *model.TableInfo
+		physicalID int64
+	)
+	switch x := plans[0].(type) {
+	case *PhysicalTableScan:
+		tblInfo, physicalID = x.Table, x.Table.ID
+		if x.isPartition {
+			physicalID = x.physicalTableID
+		}
+		conds = append(conds, x.AccessCondition...)
+	case *PhysicalIndexScan:
+		tblInfo, physicalID = x.Table, x.Table.ID
+		if x.isPartition {
+			physicalID = x.physicalTableID
+		}
+		conds = append(conds, x.AccessCondition...)
+	default:
+		return nil, false
+	}
+	statsTbl := getStatsTable(sctx, tblInfo, physicalID)
+	for _, p := range plans[1:] {
+		sel, ok := p.(*PhysicalSelection)
+		if !ok {
+			continue
+		}
+		conds = append(conds, sel.Conditions...)
+		selStats := statsColl.GetCopStats(sel.ID())
+		if selStats == nil || statsTbl.Count <= 0 {
+			continue
+		}
+		statistics.GlobalSelectivityFeedback.Update(physicalID, conds, float64(selStats.GetActRows())/float64(statsTbl.Count))
+	}
+	return conds, true
+}
+
+// CollectCardinalityFeedback feeds the row counts of the index and table ranges observed in the runtime
+// stats back to statistics.GlobalSelectivityFeedback.
+func CollectCardinalityFeedback(sctx sessionctx.Context, p Plan) {
+	statsColl := sctx.GetSessionVars().StmtCtx.RuntimeStatsColl
+	if explain, ok := p.(*Explain); ok {
+		if !explain.Analyze {
+			return
+		}
+		statsColl, p = explain.RuntimeStatsColl, explain.TargetPlan
+	}
+	physicalPlan, ok := p.(PhysicalPlan)
+	if !ok || statsColl == nil {
+		return
+	}
+	forEachFullyReadReader(physicalPlan, func(reader PhysicalPlan) {
+		switch x := reader.(type) {
+		case *PhysicalTableReader:
+			collectCopCardinalityFeedback(sctx, statsColl, x.TablePlans)
+		case *PhysicalIndexReader:
+			collectCopCardinalityFeedback(sctx, statsColl, x.IndexPlans)
+		case *PhysicalIndexLookUpReader:
+			collectCopCardinalityFeedback(sctx, statsColl, x.IndexPlans)
+		case *PhysicalIndexMergeReader:
+			for _, partialPlans := range x.PartialPlans {
+				collectCopCardinalityFeedback(sctx, statsColl, partialPlans)
+			}
+		}
+	})
+}
+
+// forEachFullyReadReader calls f on the readers which read all their rows. It only goes through the operators which
+// always read all the rows of their children, since the scans under the others, such as the probe side of joins or
+// the children of limits, may stop early, and the scans on the inner side of index joins are executed with different
+// ranges.
+func forEachFullyReadReader(p PhysicalPlan, f func(reader PhysicalPlan)) {
+	switch x := p.(type) {
+	case *PhysicalTableReader, *PhysicalIndexReader, *PhysicalIndexLookUpReader, *PhysicalIndexMergeReader:
+		f(p)
+	case *PhysicalHashJoin:
+		buildSide := x.InnerChildIdx
+		if x.UseOuterToBuild {
+			buildSide = 1 - x.InnerChildIdx
+		}
+		forEachFullyReadReader(x.children[buildSide], f)
+	case *PhysicalProjection, *PhysicalSelection, *PhysicalSort, *PhysicalTopN, *PhysicalHashAgg, *PhysicalStreamAgg,
+		*PhysicalWindow, *PhysicalUnionAll:
+		for _, child := range p.Children() {
+			forEachFullyReadReader(child, f)
+		}
+	}
+}
+
+// collectCopCardinalityFeedback observes the row count of the scan at the bottom of the coprocessor plans.
+func collectCopCardinalityFeedback(sctx sessionctx.Context, statsColl *execdetails.RuntimeStatsColl, plans []PhysicalPlan) {
+	if len(plans) == 0 {
+		return
+	}
+	for _, p := range plans {
+		if _, ok := p.(*PhysicalLimit); ok {
+			return
+		}
+	}
+	var (
+		tblInfo    *model.TableInfo
+		physicalID int64
+		idxID      int64
+		ranges     []*ranger.Range
+	)
+	switch x := plans[0].(type) {
+	case *PhysicalTableScan:
+		tblInfo, physicalID, ranges = x.Table, x.physicalTableID, x.Ranges
+		if x.Table.IsCommonHandle {
+			pkIdx := tables.FindPrimaryIndex(x.Table)
+			if pkIdx == nil {
+				return
+			}
+			idxID = pkIdx.ID
+		}
+	case *PhysicalIndexScan:
+		tblInfo, physicalID, idxID, ranges = x.Table, x.physicalTableID, x.Index.ID, x.Ranges
+	default:
+		return
+	}
+	scanStats := statsColl.GetCopStats(plans[0].ID())
+	if scanStats == nil {
+		return
+	}
+	statsTbl := getStatsTable(sctx, tblInfo, physicalID)
+	if statsTbl.Count <= 0 {
+		return
+	}
+	statistics.GlobalSelectivityFeedback.UpdateRanges(physicalID, idxID, ranges, float64(scanStats.GetActRows())/float64(statsTbl.Count))
+}
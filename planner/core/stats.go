@@ -219,13 +219,17 @@ func (ds *DataSource) initStats(colGroups [][]*expression.Column) {
 	if ds.statisticTable == nil {
 		ds.statisticTable = getStatsTable(ds.ctx, ds.tableInfo, ds.table.Meta().ID)
 	}
+	if statsTbl := ds.getPartitionLiteStatsTable(); statsTbl != nil {
+		ds.statisticTable = statsTbl
+		ds.liteStats = true
+	}
 	tableStats := &property.StatsInfo{
 		RowCount:     float64(ds.statisticTable.Count),
 		Cardinality:  make(map[int64]float64, ds.schema.Len()),
 		HistColl:     ds.statisticTable.GenerateHistCollFromColumnInfo(ds.Columns, ds.schema.Columns),
 		StatsVersion: ds.statisticTable.Version,
 	}
-	if ds.statisticTable.Pseudo {
+	if ds.statisticTable.Pseudo && !ds.liteStats {
 		tableStats.StatsVersion = statistics.PseudoVersion
 	}
 	for _, col := range ds.schema.Columns {
@@ -879,6 +883,7 @@ func (p *LogicalJoin) DeriveStats(childStats []*property.StatsInfo, selfSchema *
 		rightJoinKeys: rightJoinKeys,
 		leftSchema:    childSchema[0],
 		rightSchema:   childSchema[1],
+		useBucketNDV:  p.ctx.GetSessionVars().EnableJoinEstByBucketNDV,
 	}
 	p.equalCondOutCnt = helper.estimate()
 	if p.JoinType == SemiJoin || p.JoinType == AntiSemiJoin {
@@ -958,18 +963,73 @@ type fullJoinRowCountHelper struct {
 	rightJoinKeys []*expression.Column
 	leftSchema    *expression.Schema
 	rightSchema   *expression.Schema
+	// useBucketNDV indicates whether to estimate the single column equal join by the bucket NDVs of the indexes.
+	useBucketNDV bool
 }
 
 func (h *fullJoinRowCountHelper) estimate() float64 {
 	if h.cartesian {
 		return h.leftProfile.RowCount * h.rightProfile.RowCount
 	}
+	if h.useBucketNDV && len(h.leftJoinKeys) == 1 {
+		if count, ok := h.estimateByBucketNDV(); ok {
+			return count
+		}
+	}
 	leftKeyCardinality := getCardinality(h.leftJoinKeys, h.leftSchema, h.leftProfile)
 	rightKeyCardinality := getCardinality(h.rightJoinKeys, h.rightSchema, h.rightProfile)
 	count := h.leftProfile.RowCount * h.rightProfile.RowCount / math.Max(leftKeyCardinality, rightKeyCardinality)
 	return count
 }
 
+// estimateByBucketNDV estimates the row count of the single column equal join by the statistics of the single column
+// indexes on the join keys. The statistics are of the whole tables, so the estimation is scaled by the row counts of
+// the children.
+func (h *fullJoinRowCountHelper) estimateByBucketNDV() (float64, bool) {
+	lCol, rCol := h.leftJoinKeys[0], h.rightJoinKeys[0]
+	if !sameIndexEncoding(lCol.RetType, rCol.RetType) {
+		return 0, false
+	}
+	lIdx, rIdx := singleColumnIndexStats(lCol, h.leftProfile), singleColumnIndexStats(rCol, h.rightProfile)
+	if lIdx == nil || rIdx == nil || lIdx.TotalRowCount() <= 0 || rIdx.TotalRowCount() <= 0 {
+		return 0, false
+	}
+	count, ok := statistics.EqualJoinRowCount(lIdx, rIdx)
+	if !ok {
+		return 0, false
+	}
+	return count * (h.leftProfile.RowCount / lIdx.TotalRowCount()) * (h.rightProfile.RowCount / rIdx.TotalRowCount()), true
+}
+
+// singleColumnIndexStats returns the statistics of the index which only consists of the whole column.
+func singleColumnIndexStats(col *expression.Column, profile *property.StatsInfo) *statistics.Index {
+	coll := profile.HistColl
+	if coll == nil || coll.Pseudo {
+		return nil
+	}
+	idxID, ok := coll.ColID2IdxID[col.UniqueID]
+	if !ok {
+		return nil
+	}
+	idx := coll.Indices[idxID]
+	if idx == nil || idx.Info == nil || len(idx.Info.Columns) != 1 || idx.Info.Columns[0].Length != types.UnspecifiedLength {
+		return nil
+	}
+	return idx
+}
+
+// sameIndexEncoding checks whether the values of the two types are encoded in the same way in the indexes, so their
+// encoded values can be compared directly.
+func sameIndexEncoding(a, b *types.FieldType) bool {
+	if a.Tp != b.Tp || a.Collate != b.Collate || mysql.HasUnsignedFlag(a.Flag) != mysql.HasUnsignedFlag(b.Flag) {
+		return false
+	}
+	if a.Tp == mysql.TypeNewDecimal {
+		return a.Flen == b.Flen && a.Decimal == b.Decimal
+	}
+	return true
+}
+
 func (la *LogicalApply) getGroupNDVs(colGroups [][]*expression.Column, childStats []*property.StatsInfo) []property.GroupNDV {
 	if len(colGroups) > 0 && (la.JoinType == LeftOuterSemiJoin || la.JoinType == AntiLeftOuterSemiJoin || la.JoinType == LeftOuterJoin) {
 		return childStats[0].GroupNDVs
@@ -511,6 +511,7 @@ func (p *PhysicalHashJoin) GetCost(lCnt, rCnt float64) float64 {
 		rightJoinKeys: p.RightJoinKeys,
 		leftSchema:    p.children[0].Schema(),
 		rightSchema:   p.children[1].Schema(),
+		useBucketNDV:  p.ctx.GetSessionVars().EnableJoinEstByBucketNDV,
 	}
 	numPairs := helper.estimate()
 	// For semi-join class, if `OtherConditions` is empty, we already know
@@ -828,6 +829,7 @@ func (p *PhysicalMergeJoin) GetCost(lCnt, rCnt float64) float64 {
 		rightJoinKeys: p.RightJoinKeys,
 		leftSchema:    p.children[0].Schema(),
 		rightSchema:   p.children[1].Schema(),
+		useBucketNDV:  p.ctx.GetSessionVars().EnableJoinEstByBucketNDV,
 	}
 	numPairs := helper.estimate()
 	if p.JoinType == SemiJoin || p.JoinType == AntiSemiJoin ||
@@ -851,7 +853,12 @@ func (p *PhysicalMergeJoin) GetCost(lCnt, rCnt float64) float64 {
 	// we compute average memory cost using estimated group size.
 	NDV := getCardinality(innerKeys, innerSchema, innerStats)
 	memoryCost := (innerStats.RowCount / NDV) * sessVars.MemoryFactor
-	return cpuCost + memoryCost
+	cost := cpuCost + memoryCost
+	if p.SortChildren {
+		cost += getSortCost(p.ctx, p.children[0].statsInfo(), p.children[0].Schema(), lCnt)
+		cost += getSortCost(p.ctx, p.children[1].statsInfo(), p.children[1].Schema(), rCnt)
+	}
+	return cost
 }
 
 func (p *PhysicalMergeJoin) attach2Task(tasks ...task) task {
@@ -1207,16 +1214,21 @@ func (p *PhysicalTopN) allColsFromSchema(schema *expression.Schema) bool {
 
 // GetCost computes the cost of in memory sort.
 func (p *PhysicalSort) GetCost(count float64, schema *expression.Schema) float64 {
+	return getSortCost(p.ctx, p.statsInfo(), schema, count)
+}
+
+// getSortCost computes the cost of sorting the rows, including the cost of spilling them to the disk.
+func getSortCost(ctx sessionctx.Context, stats *property.StatsInfo, schema *expression.Schema, count float64) float64 {
 	if count < 2.0 {
 		count = 2.0
 	}
-	sessVars := p.ctx.GetSessionVars()
+	sessVars := ctx.GetSessionVars()
 	cpuCost := count * math.Log2(count) * sessVars.CPUFactor
 	memoryCost := count * sessVars.MemoryFactor
 
 	oomUseTmpStorage := config.GetGlobalConfig().OOMUseTmpStorage
 	memQuota := sessVars.StmtCtx.MemTracker.GetBytesLimit() // sessVars.MemQuotaQuery && hint
-	rowSize := getAvgRowSize(p.statsInfo(), schema)
+	rowSize := getAvgRowSize(stats, schema)
 	spill := oomUseTmpStorage && memQuota > 0 && rowSize*count > float64(memQuota)
 	diskCost := count * sessVars.DiskFactor * rowSize
 	if !spill {
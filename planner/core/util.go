@@ -21,6 +21,7 @@ import (
 	"github.com/pingcap/parser/ast"
 	"github.com/pingcap/parser/model"
 	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/expression/aggregation"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/types"
 	"github.com/pingcap/tidb/util/ranger"
@@ -57,6 +58,32 @@ func (a *AggregateFuncExtractor) Leave(n ast.Node) (ast.Node, bool) {
 	return n, true
 }
 
+// userDefinedAggFuncRewriter converts the calls of the user defined aggregate functions, which are parsed as scalar
+// functions, to aggregate functions.
+type userDefinedAggFuncRewriter struct {
+	rewritten bool
+}
+
+// Enter implements Visitor interface.
+func (r *userDefinedAggFuncRewriter) Enter(n ast.Node) (ast.Node, bool) {
+	return n, false
+}
+
+// Leave implements Visitor interface.
+func (r *userDefinedAggFuncRewriter) Leave(n ast.Node) (ast.Node, bool) {
+	v, ok := n.(*ast.FuncCallExpr)
+	if !ok {
+		return n, true
+	}
+	if _, ok := aggregation.GetUserDefinedAggFunc(v.FnName.L); !ok {
+		return n, true
+	}
+	r.rewritten = true
+	aggFunc := &ast.AggregateFuncExpr{F: v.FnName.L, Args: v.Args}
+	aggFunc.SetText(v.Text())
+	return aggFunc, true
+}
+
 // WindowFuncExtractor visits Expr tree.
 // It converts ColunmNameExpr to WindowFuncExpr and collects WindowFuncExpr.
 type WindowFuncExtractor struct {
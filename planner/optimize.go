@@ -121,6 +121,13 @@ func Optimize(ctx context.Context, sctx sessionctx.Context, node ast.Node, is in
 
 	sctx.PrepareTSFuture(ctx)
 
+	if selectStmt, ok := node.(*ast.SelectStmt); ok && sessVars.EnableGeneralPlanCache {
+		p, names, ok, err := optimizeByGeneralPlanCache(ctx, sctx, selectStmt, is)
+		if err != nil || ok {
+			return p, names, err
+		}
+	}
+
 	bestPlan, names, _, err := optimize(ctx, sctx, node, is)
 	if err != nil {
 		return nil, nil, err
@@ -206,7 +213,8 @@ func Optimize(ctx context.Context, sctx sessionctx.Context, node ast.Node, is in
 	// 2. If there is already a evolution task, we do not need to handle it again.
 	// 3. If the origin binding contain `read_from_storage` hint, we should ignore the evolve task.
 	// 4. If the best plan contain TiFlash hint, we should ignore the evolve task.
-	if _, ok := stmtNode.(*ast.SelectStmt); ok &&
+	// 5. If it is a cross-database binding, which is not bound to a database to evolve in.
+	if _, ok := stmtNode.(*ast.SelectStmt); ok && !bindRecord.IsWildcard() &&
 		sctx.GetSessionVars().EvolvePlanBaselines && binding == nil &&
 		!originHints.ContainTableHint(plannercore.HintReadFromStorage) &&
 		!bindRecord.Bindings[0].Hint.ContainTableHint(plannercore.HintReadFromStorage) {
@@ -215,6 +223,9 @@ func Optimize(ctx context.Context, sctx sessionctx.Context, node ast.Node, is in
 	// Restore the hint to avoid changing the stmt node.
 	hint.BindHint(stmtNode, originHints)
 	if sctx.GetSessionVars().UsePlanBaselines && bestPlanAmongHints != nil {
+		if len(tableHints) > 0 {
+			sessVars.StmtCtx.AppendOptimizerNote(errors.New("The optimizer hints of the statement are ignored since a plan of its binding is used"))
+		}
 		return bestPlanAmongHints, names, nil
 	}
 	return bestPlan, names, nil
@@ -340,6 +351,18 @@ func extractSelectAndNormalizeDigest(stmtNode ast.StmtNode, specifiledDB string)
 	return nil, "", "", nil
 }
 
+// optimizeByGeneralPlanCache optimizes the statement by the general plan cache if there's no binding for it, since the
+// plans of the statements with bindings are decided by the bindings.
+func optimizeByGeneralPlanCache(ctx context.Context, sctx sessionctx.Context, stmt *ast.SelectStmt, is infoschema.InfoSchema) (plannercore.Plan, types.NameSlice, bool, error) {
+	if sctx.GetSessionVars().UsePlanBaselines {
+		bindRecord, _, err := getBindRecord(sctx, stmt)
+		if err != nil || bindRecord != nil {
+			return nil, nil, false, nil
+		}
+	}
+	return plannercore.OptimizeByGeneralPlanCache(ctx, sctx, stmt, is)
+}
+
 func getBindRecord(ctx sessionctx.Context, stmt ast.StmtNode) (*bindinfo.BindRecord, string, error) {
 	// When the domain is initializing, the bind will be nil.
 	if ctx.Value(bindinfo.SessionBindInfoKeyType) == nil {
@@ -350,19 +373,8 @@ func getBindRecord(ctx sessionctx.Context, stmt ast.StmtNode) (*bindinfo.BindRec
 		return nil, "", err
 	}
 	sessionHandle := ctx.Value(bindinfo.SessionBindInfoKeyType).(*bindinfo.SessionHandle)
-	bindRecord := sessionHandle.GetBindRecord(normalizedSQL, "")
-	if bindRecord != nil {
-		if bindRecord.HasUsingBinding() {
-			return bindRecord, metrics.ScopeSession, nil
-		}
-		return nil, "", nil
-	}
-	globalHandle := domain.GetDomain(ctx).BindHandle()
-	if globalHandle == nil {
-		return nil, "", nil
-	}
-	bindRecord = globalHandle.GetBindRecord(hash, normalizedSQL, "")
-	return bindRecord, metrics.ScopeGlobal, nil
+	bindRecord, scope := bindinfo.FindBindRecord(sessionHandle, domain.GetDomain(ctx).BindHandle(), stmtNode, normalizedSQL, hash)
+	return bindRecord, scope, nil
 }
 
 func handleInvalidBindRecord(ctx context.Context, sctx sessionctx.Context, level string, bindRecord bindinfo.BindRecord) {
@@ -59,6 +59,12 @@ type AccessPath struct {
 	IsCommonHandlePath bool
 	// Forced means this path is generated by `use/force index()`.
 	Forced bool
+	// ForceKeepOrder means this path is generated by `order_index()` or `use index for order by()`, the order
+	// required by the ORDER BY should be provided by scanning this path in order.
+	ForceKeepOrder bool
+	// ForceNoKeepOrder means this path is hinted by `no_order_index()` or `ignore index for order by()`, it can
+	// still be scanned but it's never scanned in order to provide the required order.
+	ForceNoKeepOrder bool
 }
 
 // IsTablePath returns true if it's IntHandlePath or CommonHandlePath.
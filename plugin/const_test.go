@@ -24,6 +24,7 @@ func TestConstToString(t *testing.T) {
 		Authentication:            "Authentication",
 		Schema:                    "Schema",
 		Daemon:                    "Daemon",
+		UDF:                       "UDF",
 		Uninitialized:             "Uninitialized",
 		Ready:                     "Ready",
 		Dying:                     "Dying",
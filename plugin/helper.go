@@ -38,6 +38,11 @@ func DeclareDaemonManifest(m *Manifest) *DaemonManifest {
 	return (*DaemonManifest)(unsafe.Pointer(m))
 }
 
+// DeclareUDFManifest declares manifest as UDFManifest.
+func DeclareUDFManifest(m *Manifest) *UDFManifest {
+	return (*UDFManifest)(unsafe.Pointer(m))
+}
+
 // ID present plugin identity.
 type ID string
 
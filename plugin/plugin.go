@@ -24,6 +24,7 @@ import (
 
 	"github.com/pingcap/errors"
 	"github.com/pingcap/tidb/domain"
+	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/logutil"
@@ -223,7 +224,11 @@ func Init(ctx context.Context, cfg Config) (err error) {
 	for kind := range tiPlugins.plugins {
 		for i := range tiPlugins.plugins[kind] {
 			p := tiPlugins.plugins[kind][i]
-			if err = p.OnInit(ctx, p.Manifest); err != nil {
+			err = p.OnInit(ctx, p.Manifest)
+			if err == nil && kind == UDF {
+				err = registerUDFs(DeclareUDFManifest(p.Manifest))
+			}
+			if err != nil {
 				if cfg.SkipWhenFail {
 					logutil.Logger(ctx).Warn("call Plugin OnInit failure, err: %v",
 						zap.String("plugin", p.Name), zap.Error(err))
@@ -359,6 +364,24 @@ func loadManifestByGoPlugin(plugin *Plugin, dir string, pluginID ID) (manifest f
 	return
 }
 
+func registerUDFs(m *UDFManifest) error {
+	for i, udf := range m.Functions {
+		if err := expression.RegisterUDF(udf); err != nil {
+			for _, registered := range m.Functions[:i] {
+				expression.UnregisterUDF(registered.Name)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func unregisterUDFs(m *UDFManifest) {
+	for _, udf := range m.Functions {
+		expression.UnregisterUDF(udf.Name)
+	}
+}
+
 // Shutdown cleanups all plugin resources.
 // Notice: it just cleanups the resource of plugin, but cannot unload plugins(limited by go plugin).
 func Shutdown(ctx context.Context) {
@@ -369,6 +392,10 @@ func Shutdown(ctx context.Context) {
 		}
 		for _, plugins := range tiPlugins.plugins {
 			for _, p := range plugins {
+				// The functions of the plugins failed to initialize are not registered.
+				if p.Kind == UDF && p.State == Ready {
+					unregisterUDFs(DeclareUDFManifest(p.Manifest))
+				}
 				p.State = Dying
 				if p.flushWatcher != nil {
 					p.flushWatcher.cancel()
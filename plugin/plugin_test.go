@@ -20,7 +20,10 @@ import (
 	"testing"
 
 	"github.com/pingcap/check"
+	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/chunk"
 )
 
 func TestT(t *testing.T) {
@@ -283,3 +286,59 @@ func TestPluginsClone(t *testing.T) {
 		t.Errorf("clone plugins failure")
 	}
 }
+
+func TestLoadUDFPlugin(t *testing.T) {
+	ctx := context.Background()
+	pluginName := "tudf"
+	pluginVersion := uint16(1)
+	pluginSign := pluginName + "-" + strconv.Itoa(int(pluginVersion))
+	udf := &expression.UDF{
+		Name:     "tudf_double",
+		ArgTypes: []types.EvalType{types.ETInt},
+		RetType:  types.ETInt,
+		VecEval: func(ctx context.Context, args []*chunk.Column, numRows int, result *chunk.Column) error {
+			return nil
+		},
+	}
+
+	testHook = &struct{ loadOne loadFn }{loadOne: func(plugin *Plugin, dir string, pluginID ID) (manifest func() *Manifest, err error) {
+		return func() *Manifest {
+			m := &UDFManifest{
+				Manifest: Manifest{
+					Kind:    UDF,
+					Name:    pluginName,
+					Version: pluginVersion,
+					OnInit: func(ctx context.Context, manifest *Manifest) error {
+						return nil
+					},
+					Validate: func(ctx context.Context, manifest *Manifest) error {
+						return nil
+					},
+				},
+				Functions: []*expression.UDF{udf},
+			}
+			return ExportManifest(m)
+		}, nil
+	}}
+	defer func() {
+		testHook = nil
+	}()
+
+	cfg := Config{Plugins: []string{pluginSign}}
+	if err := Load(ctx, cfg); err != nil {
+		t.Fatalf("load plugin [%s] fail: %v", pluginSign, err)
+	}
+	if err := Init(ctx, cfg); err != nil {
+		t.Fatalf("init plugin [%s] fail: %v", pluginSign, err)
+	}
+	// The function is registered by the plugin.
+	if err := expression.RegisterUDF(udf); err == nil {
+		t.Errorf("function of the plugin is not registered")
+	}
+	Shutdown(ctx)
+	// The function is unregistered after the plugin shuts down.
+	if err := expression.RegisterUDF(udf); err != nil {
+		t.Errorf("function of the plugin is not unregistered: %v", err)
+	}
+	expression.UnregisterUDF(udf.Name)
+}
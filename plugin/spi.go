@@ -18,6 +18,7 @@ import (
 	"reflect"
 	"unsafe"
 
+	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/sessionctx/variable"
 )
 
@@ -82,3 +83,10 @@ type SchemaManifest struct {
 type DaemonManifest struct {
 	Manifest
 }
+
+// UDFManifest presents a sub-manifest that every UDF plugins must provide.
+type UDFManifest struct {
+	Manifest
+	// Functions are registered after OnInit and unregistered when the plugin shuts down.
+	Functions []*expression.UDF
+}
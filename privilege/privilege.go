@@ -80,6 +80,13 @@ type Manager interface {
 
 	// IsDynamicPrivilege returns if a privilege is in the list of privileges.
 	IsDynamicPrivilege(privNameInUpper string) bool
+
+	// SecurityPolicies returns the predicates of the row-level security policies on the table for current user.
+	SecurityPolicies(activeRoles []*auth.RoleIdentity, db, table string) []string
+
+	// SecurityPolicyVersion returns the version of the row-level security policies, which changes when the policies
+	// or the role graph are changed.
+	SecurityPolicyVersion() uint64
 }
 
 const key keyType = 0
@@ -19,8 +19,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"reflect"
 	"sort"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -64,7 +66,8 @@ const (
 	Create_role_priv,Drop_role_priv,Create_tmp_table_priv,Lock_tables_priv,Create_routine_priv,
 	Alter_routine_priv,Event_priv,Shutdown_priv,Reload_priv,File_priv,Config_priv,Repl_client_priv,Repl_slave_priv,
 	account_locked FROM mysql.user`
-	sqlLoadGlobalGrantsTable = `SELECT HIGH_PRIORITY Host,User,Priv,With_Grant_Option FROM mysql.global_grants`
+	sqlLoadGlobalGrantsTable   = `SELECT HIGH_PRIORITY Host,User,Priv,With_Grant_Option FROM mysql.global_grants`
+	sqlLoadSecurityPolicyTable = `SELECT HIGH_PRIORITY Host,User,Name,Table_schema,Table_name,Predicate FROM mysql.security_policy ORDER BY Name`
 )
 
 func computePrivMask(privs []mysql.PrivilegeType) mysql.PrivilegeType {
@@ -213,6 +216,16 @@ type columnsPrivRecord struct {
 	ColumnPriv mysql.PrivilegeType
 }
 
+// securityPolicyRecord is used to cache mysql.security_policy.
+type securityPolicyRecord struct {
+	baseRecord
+
+	Name      string
+	DB        string
+	TableName string
+	Predicate string
+}
+
 // defaultRoleRecord is used to cache mysql.default_roles
 type defaultRoleRecord struct {
 	baseRecord
@@ -253,17 +266,21 @@ type MySQLPrivilege struct {
 
 	// This helps in the case that there are a number of users with
 	// non-full privileges (i.e. user.db entries).
-	User          []UserRecord
-	UserMap       map[string][]UserRecord // Accelerate User searching
-	Global        map[string][]globalPrivRecord
-	Dynamic       map[string][]dynamicPrivRecord
-	DB            []dbRecord
-	DBMap         map[string][]dbRecord // Accelerate DB searching
-	TablesPriv    []tablesPrivRecord
-	TablesPrivMap map[string][]tablesPrivRecord // Accelerate TablesPriv searching
-	ColumnsPriv   []columnsPrivRecord
-	DefaultRoles  []defaultRoleRecord
-	RoleGraph     map[string]roleGraphEdgesTable
+	User           []UserRecord
+	UserMap        map[string][]UserRecord // Accelerate User searching
+	Global         map[string][]globalPrivRecord
+	Dynamic        map[string][]dynamicPrivRecord
+	DB             []dbRecord
+	DBMap          map[string][]dbRecord // Accelerate DB searching
+	TablesPriv     []tablesPrivRecord
+	TablesPrivMap  map[string][]tablesPrivRecord // Accelerate TablesPriv searching
+	ColumnsPriv    []columnsPrivRecord
+	DefaultRoles   []defaultRoleRecord
+	SecurityPolicy []securityPolicyRecord
+	RoleGraph      map[string]roleGraphEdgesTable
+
+	// securityPolicyVersion is increased when the security policies or the role graph are changed by reloading.
+	securityPolicyVersion uint64
 }
 
 // FindAllRole is used to find all roles grant to this user.
@@ -364,6 +381,15 @@ func (p *MySQLPrivilege) LoadAll(ctx sessionctx.Context) error {
 		}
 		logutil.BgLogger().Warn("mysql.role_edges missing")
 	}
+
+	err = p.LoadSecurityPolicyTable(ctx)
+	if err != nil {
+		if !noSuchTable(err) {
+			logutil.BgLogger().Warn("load mysql.security_policy", zap.Error(err))
+			return errLoadPrivilege.FastGen("mysql.security_policy")
+		}
+		logutil.BgLogger().Warn("mysql.security_policy missing")
+	}
 	return nil
 }
 
@@ -549,6 +575,11 @@ func (p *MySQLPrivilege) LoadDefaultRoles(ctx sessionctx.Context) error {
 	return p.loadTable(ctx, sqlLoadDefaultRoles, p.decodeDefaultRoleTableRow)
 }
 
+// LoadSecurityPolicyTable loads the mysql.security_policy table from database.
+func (p *MySQLPrivilege) LoadSecurityPolicyTable(ctx sessionctx.Context) error {
+	return p.loadTable(ctx, sqlLoadSecurityPolicyTable, p.decodeSecurityPolicyTableRow)
+}
+
 func (p *MySQLPrivilege) loadTable(sctx sessionctx.Context, sql string,
 	decodeTableRow func(chunk.Row, []*ast.ResultField) error) error {
 	ctx := context.Background()
@@ -750,6 +781,26 @@ func (p *MySQLPrivilege) decodeTablesPrivTableRow(row chunk.Row, fs []*ast.Resul
 	return nil
 }
 
+func (p *MySQLPrivilege) decodeSecurityPolicyTableRow(row chunk.Row, fs []*ast.ResultField) error {
+	var value securityPolicyRecord
+	for i, f := range fs {
+		switch {
+		case f.ColumnAsName.L == "name":
+			value.Name = row.GetString(i)
+		case f.ColumnAsName.L == "table_schema":
+			value.DB = row.GetString(i)
+		case f.ColumnAsName.L == "table_name":
+			value.TableName = row.GetString(i)
+		case f.ColumnAsName.L == "predicate":
+			value.Predicate = row.GetString(i)
+		default:
+			value.assignUserOrHost(row, i, f)
+		}
+	}
+	p.SecurityPolicy = append(p.SecurityPolicy, value)
+	return nil
+}
+
 func (p *MySQLPrivilege) decodeRoleEdgesTable(row chunk.Row, fs []*ast.ResultField) error {
 	var fromUser, fromHost, toHost, toUser string
 	for i, f := range fs {
@@ -876,6 +927,14 @@ func (record *columnsPrivRecord) match(user, host, db, table, col string) bool {
 		strings.EqualFold(record.ColumnName, col)
 }
 
+// match checks whether the policy is on the table for the user or role. The policy with the empty user is for all the
+// users.
+func (record *securityPolicyRecord) match(user, host, db, table string) bool {
+	return (record.User == "" || record.baseRecord.match(user, host)) &&
+		strings.EqualFold(record.DB, db) &&
+		strings.EqualFold(record.TableName, table)
+}
+
 // patternMatch matches "%" the same way as ".*" in regular expression, for example,
 // "10.0.%" would match "10.0.1" "10.0.1.118" ...
 func patternMatch(str string, patChars, patTypes []byte) bool {
@@ -956,6 +1015,27 @@ func (p *MySQLPrivilege) matchColumns(user, host, db, table, column string) *col
 	return nil
 }
 
+// SecurityPolicies returns the predicates of the security policies on the table for the user and its active roles,
+// ordered by the policy names.
+func (p *MySQLPrivilege) SecurityPolicies(activeRoles []*auth.RoleIdentity, user, host, db, table string) []string {
+	if len(p.SecurityPolicy) == 0 {
+		return nil
+	}
+	roleList := p.FindAllRole(activeRoles)
+	roleList = append(roleList, &auth.RoleIdentity{Username: user, Hostname: host})
+	var predicates []string
+	for i := range p.SecurityPolicy {
+		record := &p.SecurityPolicy[i]
+		for _, r := range roleList {
+			if record.match(r.Username, r.Hostname, db, table) {
+				predicates = append(predicates, record.Predicate)
+				break
+			}
+		}
+	}
+	return predicates
+}
+
 // RequestDynamicVerification checks all roles for a specific DYNAMIC privilege.
 func (p *MySQLPrivilege) RequestDynamicVerification(activeRoles []*auth.RoleIdentity, user, host, privName string, withGrant bool) bool {
 	privName = strings.ToUpper(privName)
@@ -1489,6 +1569,7 @@ func (p *MySQLPrivilege) getAllRoles(user, host string) []*auth.RoleIdentity {
 
 // Handle wraps MySQLPrivilege providing thread safe access.
 type Handle struct {
+	mu   sync.Mutex
 	priv atomic.Value
 }
 
@@ -1510,6 +1591,15 @@ func (h *Handle) Update(ctx sessionctx.Context) error {
 		return err
 	}
 
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	// The version isn't increased by the reloading without changes, so the cached plans are kept.
+	if old, ok := h.priv.Load().(*MySQLPrivilege); ok {
+		priv.securityPolicyVersion = old.securityPolicyVersion
+		if !reflect.DeepEqual(old.SecurityPolicy, priv.SecurityPolicy) || !reflect.DeepEqual(old.RoleGraph, priv.RoleGraph) {
+			priv.securityPolicyVersion++
+		}
+	}
 	h.priv.Store(&priv)
 	return nil
 }
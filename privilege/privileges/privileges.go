@@ -49,6 +49,8 @@ var dynamicPrivs = []string{
 	"RESTRICTED_VARIABLES_ADMIN",  // Can see all variables when SEM is enabled
 	"RESTRICTED_USER_ADMIN",       // User can not have their access revoked by SUPER users.
 	"RESTRICTED_CONNECTION_ADMIN", // Can not be killed by PROCESS/CONNECTION_ADMIN privilege
+	"BINDING_ADMIN",               // Can manage the SQL bindings without SUPER
+	"RESOURCE_GROUP_ADMIN",        // Can put the statements in the resource groups which don't list the user
 }
 var dynamicPrivLock sync.Mutex
 
@@ -548,6 +550,26 @@ func (p *UserPrivileges) IsDynamicPrivilege(privName string) bool {
 	return false
 }
 
+// SecurityPolicies implements the Manager interface.
+func (p *UserPrivileges) SecurityPolicies(activeRoles []*auth.RoleIdentity, db, table string) []string {
+	if SkipWithGrant {
+		return nil
+	}
+	if p.user == "" && p.host == "" {
+		return nil
+	}
+	mysqlPriv := p.Handle.Get()
+	return mysqlPriv.SecurityPolicies(activeRoles, p.user, p.host, db, table)
+}
+
+// SecurityPolicyVersion implements the Manager interface.
+func (p *UserPrivileges) SecurityPolicyVersion() uint64 {
+	if SkipWithGrant {
+		return 0
+	}
+	return p.Handle.Get().securityPolicyVersion
+}
+
 // RegisterDynamicPrivilege is used by plugins to add new privileges to TiDB
 func RegisterDynamicPrivilege(privName string) error {
 	privNameInUpper := strings.ToUpper(privName)
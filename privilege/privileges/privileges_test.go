@@ -20,6 +20,7 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"fmt"
+	"math"
 	"net/url"
 	"os"
 	"strings"
@@ -37,7 +38,9 @@ import (
 	"github.com/pingcap/tidb/session"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/store/mockstore"
+	"github.com/pingcap/tidb/types"
 	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/kvcache"
 	"github.com/pingcap/tidb/util/sem"
 	"github.com/pingcap/tidb/util/sqlexec"
 	"github.com/pingcap/tidb/util/testkit"
@@ -1316,6 +1319,164 @@ func (s *testPrivilegeSuite) TestDynamicPrivs(c *C) {
 	mustExec(c, se, "SET GLOBAL wait_timeout = 87000")
 }
 
+func (s *testPrivilegeSuite) TestBindingAdminPriv(c *C) {
+	rootSe := newSession(c, s.store, s.dbName)
+	mustExec(c, rootSe, "CREATE USER bindadmin")
+	mustExec(c, rootSe, "CREATE TABLE IF NOT EXISTS bindadmin_t (a int, key(a))")
+	mustExec(c, rootSe, "GRANT SELECT ON *.* TO bindadmin")
+
+	se := newSession(c, s.store, s.dbName)
+	c.Assert(se.Auth(&auth.UserIdentity{Username: "bindadmin", Hostname: "%"}, nil, nil), IsTrue)
+
+	_, err := se.ExecuteInternal(context.Background(), "CREATE GLOBAL BINDING FOR SELECT * FROM bindadmin_t USING SELECT * FROM bindadmin_t USE INDEX(a)")
+	c.Assert(err.Error(), Equals, "[planner:1227]Access denied; you need (at least one of) the SUPER or BINDING_ADMIN privilege(s) for this operation")
+	_, err = se.ExecuteInternal(context.Background(), "ADMIN RELOAD BINDINGS")
+	c.Assert(err.Error(), Equals, "[planner:1227]Access denied; you need (at least one of) the SUPER or BINDING_ADMIN privilege(s) for this operation")
+
+	mustExec(c, rootSe, "GRANT BINDING_ADMIN ON *.* TO bindadmin")
+	mustExec(c, se, "CREATE GLOBAL BINDING FOR SELECT * FROM bindadmin_t USING SELECT * FROM bindadmin_t USE INDEX(a)")
+	mustExec(c, se, "ADMIN RELOAD BINDINGS")
+	mustExec(c, se, "DROP GLOBAL BINDING FOR SELECT * FROM bindadmin_t")
+
+	// BINDING_ADMIN is not a substitute for SUPER in the other admin commands.
+	_, err = se.ExecuteInternal(context.Background(), "ADMIN SHOW DDL JOBS")
+	c.Assert(err, NotNil)
+}
+
+func (s *testPrivilegeSuite) TestSecurityPolicy(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("CREATE TABLE policy_t (id int primary key, owner varchar(32), region int)")
+	tk.MustExec("INSERT INTO policy_t VALUES (1, 'u1', 1), (2, 'u2', 1), (3, 'u1', 2), (4, 'u2', 2)")
+	tk.MustExec("CREATE USER policy_u1, policy_r1")
+	tk.MustExec("GRANT SELECT, UPDATE, DELETE ON test.policy_t TO policy_u1")
+	tk.MustExec("GRANT policy_r1 TO policy_u1")
+	tk.MustExec(`INSERT INTO mysql.security_policy VALUES
+		('policy_u1', '%', 'p_owner', 'test', 'policy_t', "owner = 'u1'"),
+		('policy_r1', '%', 'p_region', 'test', 'policy_t', 'region = 1')`)
+	tk.MustExec("FLUSH PRIVILEGES")
+
+	tk1 := testkit.NewTestKit(c, s.store)
+	tk1.MustExec("use test")
+	c.Assert(tk1.Se.Auth(&auth.UserIdentity{Username: "policy_u1", Hostname: "%"}, nil, nil), IsTrue)
+	tk1.MustQuery("SELECT id FROM policy_t ORDER BY id").Check(testkit.Rows("1", "3"))
+	// The point get plans fallback to the normal plans to filter the rows.
+	tk1.MustQuery("SELECT id FROM policy_t WHERE id = 2").Check(testkit.Rows())
+	tk1.MustQuery("SELECT id FROM policy_t WHERE id = 3").Check(testkit.Rows("3"))
+
+	// The policies of the roles only apply when the roles are active.
+	tk1.MustExec("SET ROLE policy_r1")
+	tk1.MustQuery("SELECT id FROM policy_t ORDER BY id").Check(testkit.Rows("1"))
+	tk1.MustExec("SET ROLE NONE")
+
+	// The rows filtered out can't be updated or deleted either.
+	tk1.MustExec("UPDATE policy_t SET region = 3")
+	tk1.MustExec("DELETE FROM policy_t WHERE id = 2")
+	tk.MustQuery("SELECT * FROM policy_t ORDER BY id").Check(testkit.Rows("1 u1 3", "2 u2 1", "3 u1 3", "4 u2 2"))
+
+	// The plans with the policies are not cached.
+	tk1.MustExec("PREPARE stmt FROM 'SELECT id FROM policy_t WHERE region = ?'")
+	tk1.MustExec("SET @a = 3")
+	tk1.MustQuery("EXECUTE stmt USING @a").Check(testkit.Rows("1", "3"))
+	tk1.MustQuery("EXECUTE stmt USING @a").Check(testkit.Rows("1", "3"))
+	tk1.MustQuery("SELECT @@last_plan_from_cache").Check(testkit.Rows("0"))
+
+	// The invisible rows can't be overwritten by INSERT ... ON DUPLICATE KEY UPDATE or REPLACE.
+	tk.MustExec("GRANT INSERT ON test.policy_t TO policy_u1")
+	_, err := tk1.Exec("INSERT INTO policy_t VALUES (2, 'u1', 1) ON DUPLICATE KEY UPDATE owner = 'u1'")
+	c.Assert(terror.ErrorEqual(err, kv.ErrKeyExists), IsTrue, Commentf("err %v", err))
+	_, err = tk1.Exec("REPLACE INTO policy_t VALUES (4, 'u1', 1)")
+	c.Assert(terror.ErrorEqual(err, kv.ErrKeyExists), IsTrue, Commentf("err %v", err))
+	tk1.MustExec("INSERT IGNORE INTO policy_t VALUES (2, 'u1', 1) ON DUPLICATE KEY UPDATE owner = 'u1'")
+	tk1.MustQuery("SHOW WARNINGS").Check(testkit.Rows("Warning 1062 Duplicate entry '2' for key 'PRIMARY'"))
+	tk1.MustExec("INSERT INTO policy_t VALUES (1, 'u1', 1) ON DUPLICATE KEY UPDATE region = 4")
+	tk1.MustExec("REPLACE INTO policy_t VALUES (3, 'u1', 5)")
+	tk.MustQuery("SELECT * FROM policy_t ORDER BY id").Check(testkit.Rows("1 u1 4", "2 u2 1", "3 u1 5", "4 u2 2"))
+
+	tk.MustExec(`INSERT INTO mysql.security_policy VALUES ('', '', 'p_bad', 'test', 'policy_t', 'no_such_col = 1')`)
+	tk.MustExec(`INSERT INTO mysql.security_policy VALUES ('', '', 'p_missing', 'test', 'no_such_t', 'a = 1')`)
+	// The invalid policies are reported by FLUSH PRIVILEGES.
+	tk.MustExec("FLUSH PRIVILEGES")
+	tk.MustQuery("SHOW WARNINGS").Check(testkit.Rows(
+		"Warning 8136 Invalid security policy on table 'test.policy_t': unknown column `no_such_col`",
+		"Warning 8136 Invalid security policy on table 'test.no_such_t': [schema:1146]Table 'test.no_such_t' doesn't exist"))
+	_, err = tk1.Exec("SELECT * FROM policy_t")
+	c.Assert(err.Error(), Equals, "[planner:8136]Invalid security policy on table 'test.policy_t': unknown column `no_such_col`")
+	tk.MustExec("DELETE FROM mysql.security_policy")
+	tk.MustExec("FLUSH PRIVILEGES")
+	tk1.MustQuery("SELECT count(*) FROM policy_t").Check(testkit.Rows("4"))
+}
+
+func (s *testPrivilegeSuite) TestSecurityPolicyPlanCache(c *C) {
+	orgEnable := core.PreparedPlanCacheEnabled()
+	defer core.SetPreparedPlanCache(orgEnable)
+	core.SetPreparedPlanCache(true)
+
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("CREATE TABLE policy_cache (id int primary key, region int)")
+	tk.MustExec("INSERT INTO policy_cache VALUES (1, 1), (2, 2), (3, 3)")
+	tk.MustExec("CREATE USER policy_cache_u")
+	tk.MustExec("CREATE ROLE policy_cache_r")
+	tk.MustExec("GRANT SELECT ON test.policy_cache TO policy_cache_u")
+	tk.MustExec("GRANT policy_cache_r TO policy_cache_u")
+
+	tk1 := testkit.NewTestKit(c, s.store)
+	var err error
+	tk1.Se, err = session.CreateSession4TestWithOpt(s.store, &session.Opt{
+		PreparedPlanCache: kvcache.NewSimpleLRUCache(100, 0.1, math.MaxUint64),
+	})
+	c.Assert(err, IsNil)
+	tk1.MustExec("use test")
+	c.Assert(tk1.Se.Auth(&auth.UserIdentity{Username: "policy_cache_u", Hostname: "%"}, nil, nil), IsTrue)
+	tk1.MustExec("PREPARE stmt FROM 'SELECT id FROM policy_cache WHERE region > ? ORDER BY id'")
+	tk1.MustExec("SET @a = 0")
+	pointID, _, _, err := tk1.Se.PrepareStmt("SELECT id FROM policy_cache WHERE id = ?")
+	c.Assert(err, IsNil)
+	checkPoint := func(id int64, expected ...string) {
+		rs, err := tk1.Se.ExecutePreparedStmt(context.Background(), pointID, []types.Datum{types.NewIntDatum(id)})
+		c.Assert(err, IsNil)
+		tk1.ResultSetToResult(rs, Commentf("id %d", id)).Check(testkit.Rows(expected...))
+	}
+	tk1.MustQuery("EXECUTE stmt USING @a").Check(testkit.Rows("1", "2", "3"))
+	tk1.MustQuery("EXECUTE stmt USING @a").Check(testkit.Rows("1", "2", "3"))
+	tk1.MustQuery("SELECT @@last_plan_from_cache").Check(testkit.Rows("1"))
+	checkPoint(3, "3")
+	checkPoint(3, "3")
+
+	// The plans cached before the policies are created are invalidated.
+	tk.MustExec(`INSERT INTO mysql.security_policy VALUES
+		('policy_cache_u', '%', 'p_user', 'test', 'policy_cache', 'id < 3'),
+		('policy_cache_r', '%', 'p_role', 'test', 'policy_cache', 'region = 2')`)
+	tk.MustExec("FLUSH PRIVILEGES")
+	tk1.MustQuery("EXECUTE stmt USING @a").Check(testkit.Rows("1", "2"))
+	tk1.MustQuery("SELECT @@last_plan_from_cache").Check(testkit.Rows("0"))
+	checkPoint(3)
+
+	// The plans cached before the roles are activated are invalidated.
+	tk.MustExec("DELETE FROM mysql.security_policy WHERE name = 'p_user'")
+	tk.MustExec("FLUSH PRIVILEGES")
+	tk1.MustQuery("EXECUTE stmt USING @a").Check(testkit.Rows("1", "2", "3"))
+	tk1.MustQuery("EXECUTE stmt USING @a").Check(testkit.Rows("1", "2", "3"))
+	tk1.MustQuery("SELECT @@last_plan_from_cache").Check(testkit.Rows("1"))
+	checkPoint(3, "3")
+	checkPoint(3, "3")
+	tk1.MustExec("SET ROLE policy_cache_r")
+	tk1.MustQuery("EXECUTE stmt USING @a").Check(testkit.Rows("2"))
+	tk1.MustQuery("SELECT @@last_plan_from_cache").Check(testkit.Rows("0"))
+	checkPoint(3)
+	checkPoint(2, "2")
+
+	// Reloading the privileges without changing the policies keeps the cached plans.
+	tk1.MustExec("SET ROLE NONE")
+	tk1.MustQuery("EXECUTE stmt USING @a").Check(testkit.Rows("1", "2", "3"))
+	tk.MustExec("FLUSH PRIVILEGES")
+	tk1.MustQuery("EXECUTE stmt USING @a").Check(testkit.Rows("1", "2", "3"))
+	tk1.MustQuery("SELECT @@last_plan_from_cache").Check(testkit.Rows("1"))
+	tk.MustExec("DELETE FROM mysql.security_policy")
+	tk.MustExec("FLUSH PRIVILEGES")
+}
+
 func (s *testPrivilegeSuite) TestDynamicGrantOption(c *C) {
 	rootSe := newSession(c, s.store, s.dbName)
 	mustExec(c, rootSe, "CREATE USER varuser1")
@@ -0,0 +1,318 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/hack"
+)
+
+// chunkDumper dumps the rows of the chunks in the MySQL protocol column by column. The values of a column are
+// dumped together from the typed slices of the chunk.Column with the type switched once, then the row packets are
+// assembled from the dumped values. The string values are not dumped ahead, they're copied into the packets, or
+// left out of them if they're large, when the rows are assembled.
+type chunkDumper struct {
+	columns []*ColumnInfo
+	binary  bool
+	// values[i] holds the dumped values of column i, ends[i][j] is the end offset of the value of row j in values[i].
+	values [][]byte
+	ends   [][]int
+	tmp    []byte
+}
+
+func newChunkDumper(columns []*ColumnInfo, binary bool) *chunkDumper {
+	return &chunkDumper{
+		columns: columns,
+		binary:  binary,
+		values:  make([][]byte, len(columns)),
+		ends:    make([][]int, len(columns)),
+		tmp:     make([]byte, 0, 20),
+	}
+}
+
+// canDump returns whether the chunk can be dumped column by column, the chunks with selected rows are dumped row
+// by row.
+func (d *chunkDumper) canDump(chk *chunk.Chunk) bool {
+	return chk.Sel() == nil && chk.NumCols() == len(d.columns)
+}
+
+func isStringColumn(tp byte) bool {
+	switch tp {
+	case mysql.TypeString, mysql.TypeVarString, mysql.TypeVarchar, mysql.TypeBit,
+		mysql.TypeTinyBlob, mysql.TypeMediumBlob, mysql.TypeLongBlob, mysql.TypeBlob:
+		return true
+	}
+	return false
+}
+
+// prepare dumps the values of the non-string columns of the chunk.
+func (d *chunkDumper) prepare(chk *chunk.Chunk) error {
+	numRows := chk.NumRows()
+	for i, col := range d.columns {
+		if isStringColumn(col.Type) {
+			continue
+		}
+		var err error
+		if d.binary {
+			d.values[i], d.ends[i], err = d.dumpBinaryColumn(d.values[i][:0], d.ends[i][:0], chk.Column(i), col, numRows)
+		} else {
+			d.values[i], d.ends[i], err = d.dumpTextColumn(d.values[i][:0], d.ends[i][:0], chk.Column(i), col, numRows)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *chunkDumper) dumpBinaryColumn(values []byte, ends []int, column *chunk.Column, col *ColumnInfo, numRows int) ([]byte, []int, error) {
+	switch col.Type {
+	case mysql.TypeTiny:
+		int64s := column.Int64s()
+		for r := 0; r < numRows; r++ {
+			if !column.IsNull(r) {
+				values = append(values, byte(int64s[r]))
+			}
+			ends = append(ends, len(values))
+		}
+	case mysql.TypeShort, mysql.TypeYear:
+		int64s := column.Int64s()
+		for r := 0; r < numRows; r++ {
+			if !column.IsNull(r) {
+				values = dumpUint16(values, uint16(int64s[r]))
+			}
+			ends = append(ends, len(values))
+		}
+	case mysql.TypeInt24, mysql.TypeLong:
+		int64s := column.Int64s()
+		for r := 0; r < numRows; r++ {
+			if !column.IsNull(r) {
+				values = dumpUint32(values, uint32(int64s[r]))
+			}
+			ends = append(ends, len(values))
+		}
+	case mysql.TypeLonglong:
+		uint64s := column.Uint64s()
+		for r := 0; r < numRows; r++ {
+			if !column.IsNull(r) {
+				values = dumpUint64(values, uint64s[r])
+			}
+			ends = append(ends, len(values))
+		}
+	case mysql.TypeFloat:
+		float32s := column.Float32s()
+		for r := 0; r < numRows; r++ {
+			if !column.IsNull(r) {
+				values = dumpUint32(values, math.Float32bits(float32s[r]))
+			}
+			ends = append(ends, len(values))
+		}
+	case mysql.TypeDouble:
+		float64s := column.Float64s()
+		for r := 0; r < numRows; r++ {
+			if !column.IsNull(r) {
+				values = dumpUint64(values, math.Float64bits(float64s[r]))
+			}
+			ends = append(ends, len(values))
+		}
+	case mysql.TypeDate, mysql.TypeDatetime, mysql.TypeTimestamp:
+		times := column.Times()
+		for r := 0; r < numRows; r++ {
+			if !column.IsNull(r) {
+				values = dumpBinaryDateTime(values, times[r])
+			}
+			ends = append(ends, len(values))
+		}
+	case mysql.TypeDuration:
+		durations := column.GoDurations()
+		for r := 0; r < numRows; r++ {
+			if !column.IsNull(r) {
+				values = append(values, dumpBinaryTime(durations[r])...)
+			}
+			ends = append(ends, len(values))
+		}
+	case mysql.TypeNewDecimal, mysql.TypeEnum, mysql.TypeSet, mysql.TypeJSON:
+		// They're dumped as strings in both protocols.
+		return d.dumpTextColumn(values, ends, column, col, numRows)
+	default:
+		return nil, nil, errInvalidType.GenWithStack("invalid type %v", col.Type)
+	}
+	return values, ends, nil
+}
+
+func (d *chunkDumper) dumpTextColumn(values []byte, ends []int, column *chunk.Column, col *ColumnInfo, numRows int) ([]byte, []int, error) {
+	tmp := d.tmp
+	switch col.Type {
+	case mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLong:
+		int64s := column.Int64s()
+		for r := 0; r < numRows; r++ {
+			if !column.IsNull(r) {
+				tmp = strconv.AppendInt(tmp[:0], int64s[r], 10)
+				values = dumpLengthEncodedString(values, tmp)
+			}
+			ends = append(ends, len(values))
+		}
+	case mysql.TypeYear:
+		int64s := column.Int64s()
+		for r := 0; r < numRows; r++ {
+			if !column.IsNull(r) {
+				tmp = tmp[:0]
+				if int64s[r] == 0 {
+					tmp = append(tmp, '0', '0', '0', '0')
+				} else {
+					tmp = strconv.AppendInt(tmp, int64s[r], 10)
+				}
+				values = dumpLengthEncodedString(values, tmp)
+			}
+			ends = append(ends, len(values))
+		}
+	case mysql.TypeLonglong:
+		unsigned := mysql.HasUnsignedFlag(uint(col.Flag))
+		uint64s := column.Uint64s()
+		for r := 0; r < numRows; r++ {
+			if !column.IsNull(r) {
+				if unsigned {
+					tmp = strconv.AppendUint(tmp[:0], uint64s[r], 10)
+				} else {
+					tmp = strconv.AppendInt(tmp[:0], int64(uint64s[r]), 10)
+				}
+				values = dumpLengthEncodedString(values, tmp)
+			}
+			ends = append(ends, len(values))
+		}
+	case mysql.TypeFloat:
+		prec := -1
+		if col.Decimal > 0 && int(col.Decimal) != mysql.NotFixedDec && col.Table == "" {
+			prec = int(col.Decimal)
+		}
+		float32s := column.Float32s()
+		for r := 0; r < numRows; r++ {
+			if !column.IsNull(r) {
+				tmp = appendFormatFloat(tmp[:0], float64(float32s[r]), prec, 32)
+				values = dumpLengthEncodedString(values, tmp)
+			}
+			ends = append(ends, len(values))
+		}
+	case mysql.TypeDouble:
+		prec := types.UnspecifiedLength
+		if col.Decimal > 0 && int(col.Decimal) != mysql.NotFixedDec && col.Table == "" {
+			prec = int(col.Decimal)
+		}
+		float64s := column.Float64s()
+		for r := 0; r < numRows; r++ {
+			if !column.IsNull(r) {
+				tmp = appendFormatFloat(tmp[:0], float64s[r], prec, 64)
+				values = dumpLengthEncodedString(values, tmp)
+			}
+			ends = append(ends, len(values))
+		}
+	case mysql.TypeNewDecimal:
+		decimals := column.Decimals()
+		for r := 0; r < numRows; r++ {
+			if !column.IsNull(r) {
+				values = dumpLengthEncodedString(values, hack.Slice(decimals[r].String()))
+			}
+			ends = append(ends, len(values))
+		}
+	case mysql.TypeDate, mysql.TypeDatetime, mysql.TypeTimestamp:
+		times := column.Times()
+		for r := 0; r < numRows; r++ {
+			if !column.IsNull(r) {
+				values = dumpLengthEncodedString(values, hack.Slice(times[r].String()))
+			}
+			ends = append(ends, len(values))
+		}
+	case mysql.TypeDuration:
+		for r := 0; r < numRows; r++ {
+			if !column.IsNull(r) {
+				values = dumpLengthEncodedString(values, hack.Slice(column.GetDuration(r, int(col.Decimal)).String()))
+			}
+			ends = append(ends, len(values))
+		}
+	case mysql.TypeEnum:
+		for r := 0; r < numRows; r++ {
+			if !column.IsNull(r) {
+				values = dumpLengthEncodedString(values, hack.Slice(column.GetEnum(r).String()))
+			}
+			ends = append(ends, len(values))
+		}
+	case mysql.TypeSet:
+		for r := 0; r < numRows; r++ {
+			if !column.IsNull(r) {
+				values = dumpLengthEncodedString(values, hack.Slice(column.GetSet(r).String()))
+			}
+			ends = append(ends, len(values))
+		}
+	case mysql.TypeJSON:
+		for r := 0; r < numRows; r++ {
+			if !column.IsNull(r) {
+				values = dumpLengthEncodedString(values, hack.Slice(column.GetJSON(r).String()))
+			}
+			ends = append(ends, len(values))
+		}
+	default:
+		return nil, nil, errInvalidType.GenWithStack("invalid type %v", col.Type)
+	}
+	d.tmp = tmp
+	return values, ends, nil
+}
+
+// dumpRow assembles the packet of the row r of the chunk prepared, the large string values are recorded in lv
+// instead of being dumped.
+func (d *chunkDumper) dumpRow(buffer []byte, chk *chunk.Chunk, r int, lv *largeValues) []byte {
+	if !d.binary {
+		for i := range d.columns {
+			column := chk.Column(i)
+			if column.IsNull(r) {
+				buffer = append(buffer, 0xfb)
+				continue
+			}
+			buffer = d.appendValue(buffer, column, i, r, lv)
+		}
+		return buffer
+	}
+	buffer = append(buffer, mysql.OKHeader)
+	nullBitmapOff := len(buffer)
+	numBytes4Null := (len(d.columns) + 7 + 2) / 8
+	for i := 0; i < numBytes4Null; i++ {
+		buffer = append(buffer, 0)
+	}
+	for i := range d.columns {
+		column := chk.Column(i)
+		if column.IsNull(r) {
+			bytePos := (i + 2) / 8
+			bitPos := byte((i + 2) % 8)
+			buffer[nullBitmapOff+bytePos] |= 1 << bitPos
+			continue
+		}
+		buffer = d.appendValue(buffer, column, i, r, lv)
+	}
+	return buffer
+}
+
+func (d *chunkDumper) appendValue(buffer []byte, column *chunk.Column, i, r int, lv *largeValues) []byte {
+	if isStringColumn(d.columns[i].Type) {
+		return lv.dumpLengthEncodedString(buffer, column.GetBytes(r))
+	}
+	start := 0
+	if r > 0 {
+		start = d.ends[i][r-1]
+	}
+	return append(buffer, d.values[i][start:d.ends[i][r]]...)
+}
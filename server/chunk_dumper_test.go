@@ -0,0 +1,129 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"math"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/types/json"
+	"github.com/pingcap/tidb/util/chunk"
+)
+
+func (s *testUtilSuite) TestChunkDumper(c *C) {
+	columns := []*ColumnInfo{
+		{Type: mysql.TypeTiny},
+		{Type: mysql.TypeShort},
+		{Type: mysql.TypeYear},
+		{Type: mysql.TypeLong},
+		{Type: mysql.TypeLonglong, Flag: uint16(mysql.UnsignedFlag)},
+		{Type: mysql.TypeLonglong},
+		{Type: mysql.TypeFloat, Decimal: 2},
+		{Type: mysql.TypeDouble, Decimal: mysql.NotFixedDec},
+		{Type: mysql.TypeNewDecimal},
+		{Type: mysql.TypeDatetime, Decimal: 6},
+		{Type: mysql.TypeDate},
+		{Type: mysql.TypeDuration, Decimal: 3},
+		{Type: mysql.TypeVarchar},
+		{Type: mysql.TypeLongBlob},
+		{Type: mysql.TypeEnum},
+		{Type: mysql.TypeSet},
+		{Type: mysql.TypeJSON},
+	}
+	fieldTypes := make([]*types.FieldType, 0, len(columns))
+	for _, col := range columns {
+		ft := types.NewFieldType(col.Type)
+		ft.Flag = uint(col.Flag)
+		ft.Decimal = int(col.Decimal)
+		fieldTypes = append(fieldTypes, ft)
+	}
+	rows := [][]types.Datum{
+		{
+			types.NewIntDatum(1),
+			types.NewIntDatum(300),
+			types.NewIntDatum(2021),
+			types.NewIntDatum(-5),
+			types.NewUintDatum(math.MaxUint64),
+			types.NewIntDatum(math.MinInt64),
+			types.NewFloat32Datum(1.25),
+			types.NewFloat64Datum(3.5),
+			types.NewDecimalDatum(types.NewDecFromStringForTest("1.23")),
+			types.NewTimeDatum(types.NewTime(types.FromDate(2021, 6, 1, 10, 11, 12, 345000), mysql.TypeDatetime, 6)),
+			types.NewTimeDatum(types.NewTime(types.FromDate(2021, 6, 1, 0, 0, 0, 0), mysql.TypeDate, 0)),
+			types.NewDurationDatum(types.Duration{Duration: time.Hour + 1500*time.Millisecond, Fsp: 3}),
+			types.NewStringDatum("abc"),
+			types.NewBytesDatum(bytes.Repeat([]byte{'a'}, largeValueSize)),
+			types.NewMysqlEnumDatum(types.Enum{Name: "a", Value: 1}),
+			types.NewMysqlSetDatum(types.Set{Name: "a,b", Value: 3}, ""),
+			types.NewJSONDatum(json.CreateBinary("x")),
+		},
+		make([]types.Datum, len(columns)),
+		{
+			types.NewIntDatum(0),
+			types.NewIntDatum(-1),
+			types.NewIntDatum(0),
+			types.NewIntDatum(7),
+			types.NewUintDatum(0),
+			types.NewIntDatum(8),
+			types.NewFloat32Datum(-0.5),
+			types.NewFloat64Datum(1e20),
+			types.NewDecimalDatum(types.NewDecFromStringForTest("-0.001")),
+			types.NewTimeDatum(types.NewTime(types.FromDate(2021, 6, 2, 0, 0, 0, 0), mysql.TypeDatetime, 6)),
+			types.NewTimeDatum(types.NewTime(types.FromDate(2021, 6, 2, 0, 0, 0, 0), mysql.TypeDate, 0)),
+			types.NewDurationDatum(types.Duration{Duration: -time.Minute, Fsp: 3}),
+			types.NewStringDatum(""),
+			types.NewBytesDatum([]byte("small")),
+			types.NewMysqlEnumDatum(types.Enum{Name: "b", Value: 2}),
+			types.NewMysqlSetDatum(types.Set{Name: "", Value: 0}, ""),
+			types.NewJSONDatum(json.CreateBinary(int64(1))),
+		},
+	}
+	chk := chunk.NewChunkWithCapacity(fieldTypes, len(rows))
+	for _, row := range rows {
+		for i := range row {
+			chk.AppendDatum(i, &row[i])
+		}
+	}
+
+	for _, binary := range []bool{false, true} {
+		dumper := newChunkDumper(columns, binary)
+		c.Assert(dumper.canDump(chk), IsTrue)
+		// Prepare twice to check the buffers are reused correctly.
+		for i := 0; i < 2; i++ {
+			c.Assert(dumper.prepare(chk), IsNil)
+			for r := 0; r < chk.NumRows(); r++ {
+				var expected []byte
+				var err error
+				if binary {
+					expected, err = dumpBinaryRow(make([]byte, 4), columns, chk.GetRow(r), nil)
+				} else {
+					expected, err = dumpTextRow(make([]byte, 4), columns, chk.GetRow(r), nil)
+				}
+				c.Assert(err, IsNil)
+				var lv largeValues
+				dumped := dumper.dumpRow(make([]byte, 4), chk, r, &lv)
+				c.Assert(bytes.Join(lv.pieces(dumped), nil), DeepEquals, expected[4:])
+				c.Assert(lv.payloadSize(dumped), Equals, len(expected)-4)
+			}
+		}
+	}
+
+	// The chunks with selected rows are dumped row by row.
+	chk.SetSel([]int{0, 2})
+	c.Assert(newChunkDumper(columns, false).canDump(chk), IsFalse)
+}
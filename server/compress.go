@@ -0,0 +1,314 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/terror"
+	"github.com/pingcap/tidb/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The compression algorithms of the compressed protocol.
+const (
+	compressionNone = iota
+	compressionZlib
+	compressionZstd
+)
+
+// clientZstdCompressionAlgorithm is CLIENT_ZSTD_COMPRESSION_ALGORITHM, with which the client asks for the
+// compressed protocol using zstd, and sends the zstd level at the end of the handshake response.
+const clientZstdCompressionAlgorithm uint32 = 1 << 26
+
+// defaultZstdLevel is the zstd level used if the client doesn't specify one, it's the same as MySQL.
+const defaultZstdLevel = 3
+
+// minCompressLength is the length from which the payloads are compressed, the shorter payloads are sent as they
+// are. It's the same as MIN_COMPRESS_LENGTH of MySQL.
+const minCompressLength = 50
+
+// compressedHeaderLength is the length of the header of the compressed packets, which is the 3-byte length of
+// the payload, the 1-byte sequence and the 3-byte length of the payload before compression. The length before
+// compression is 0 if the payload isn't compressed.
+const compressedHeaderLength = 7
+
+func compressionName(algorithm int) string {
+	switch algorithm {
+	case compressionZlib:
+		return "zlib"
+	case compressionZstd:
+		return "zstd"
+	}
+	return "uncompressed"
+}
+
+// compressedIO reads and writes the packets in the payloads of the compressed packets. The payloads are read and
+// written as a stream, so a packet may span several compressed packets. The written packets are buffered, and
+// compressed when the buffer is large enough or flushed.
+type compressedIO struct {
+	p         *packetIO
+	algorithm int
+
+	zlibWriter  *zlib.Writer
+	zlibReader  io.ReadCloser
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	compressBuf bytes.Buffer
+	compressed  []byte
+
+	// readBuf is the payload read and not consumed yet, writeBuf is the packets written and not compressed yet.
+	readBuf  []byte
+	writeBuf []byte
+	payload  []byte
+
+	stats compressionStats
+}
+
+// compressionStats is the statistics of the compressed protocol of a connection.
+type compressionStats struct {
+	readBytes              int64
+	readUncompressedBytes  int64
+	writeBytes             int64
+	writeUncompressedBytes int64
+
+	readCounter              prometheus.Counter
+	readUncompressedCounter  prometheus.Counter
+	writeCounter             prometheus.Counter
+	writeUncompressedCounter prometheus.Counter
+}
+
+func newCompressedIO(p *packetIO, algorithm, zstdLevel int) (*compressedIO, error) {
+	c := &compressedIO{p: p, algorithm: algorithm}
+	switch algorithm {
+	case compressionZlib:
+		c.zlibWriter = zlib.NewWriter(nil)
+	case compressionZstd:
+		var err error
+		c.zstdEncoder, err = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(zstdLevel)))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		// The length of the decompressed payload never exceeds MaxPayloadLen, so the payloads decompressed to a
+		// larger size are rejected without allocating the memory for them.
+		c.zstdDecoder, err = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(mysql.MaxPayloadLen))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	default:
+		return nil, errors.Errorf("unknown compression algorithm %d", algorithm)
+	}
+	name := compressionName(algorithm)
+	c.stats.readCounter = metrics.CompressionBytesCounter.WithLabelValues("read", name)
+	c.stats.readUncompressedCounter = metrics.CompressionBytesCounter.WithLabelValues("read_uncompressed", name)
+	c.stats.writeCounter = metrics.CompressionBytesCounter.WithLabelValues("write", name)
+	c.stats.writeUncompressedCounter = metrics.CompressionBytesCounter.WithLabelValues("write_uncompressed", name)
+	return c, nil
+}
+
+// Read implements the io.Reader interface.
+func (c *compressedIO) Read(b []byte) (int, error) {
+	for len(c.readBuf) == 0 {
+		if err := c.readCompressedPacket(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(b, c.readBuf)
+	c.readBuf = c.readBuf[n:]
+	return n, nil
+}
+
+func (c *compressedIO) readCompressedPacket() error {
+	var header [compressedHeaderLength]byte
+	if _, err := io.ReadFull(c.p.bufReadConn, header[:]); err != nil {
+		return errors.Trace(err)
+	}
+	sequence := header[3]
+	if sequence != c.p.compressedSequence {
+		return errInvalidSequence.GenWithStack("invalid compressed sequence %d != %d", sequence, c.p.compressedSequence)
+	}
+	c.p.compressedSequence++
+
+	length := int(uint32(header[0]) | uint32(header[1])<<8 | uint32(header[2])<<16)
+	uncompressedLength := int(uint32(header[4]) | uint32(header[5])<<8 | uint32(header[6])<<16)
+	if cap(c.payload) < length {
+		c.payload = make([]byte, length)
+	}
+	payload := c.payload[:length]
+	if _, err := io.ReadFull(c.p.bufReadConn, payload); err != nil {
+		return errors.Trace(err)
+	}
+	if uncompressedLength == 0 {
+		c.stats.addRead(compressedHeaderLength+length, compressedHeaderLength+length)
+		c.readBuf = payload
+		return nil
+	}
+	c.stats.addRead(compressedHeaderLength+length, compressedHeaderLength+uncompressedLength)
+	data, err := c.decompress(payload, uncompressedLength)
+	if err != nil {
+		return err
+	}
+	c.readBuf = data
+	return nil
+}
+
+func (c *compressedIO) decompress(payload []byte, uncompressedLength int) ([]byte, error) {
+	if c.algorithm == compressionZstd {
+		data, err := c.zstdDecoder.DecodeAll(payload, make([]byte, 0, uncompressedLength))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if len(data) != uncompressedLength {
+			return nil, errors.Trace(mysql.ErrMalformPacket)
+		}
+		return data, nil
+	}
+	var err error
+	if c.zlibReader == nil {
+		c.zlibReader, err = zlib.NewReader(bytes.NewReader(payload))
+	} else {
+		err = c.zlibReader.(zlib.Resetter).Reset(bytes.NewReader(payload), nil)
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	data := make([]byte, uncompressedLength)
+	if _, err = io.ReadFull(c.zlibReader, data); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return data, nil
+}
+
+// Write implements the io.Writer interface.
+func (c *compressedIO) Write(b []byte) (int, error) {
+	c.writeBuf = append(c.writeBuf, b...)
+	if len(c.writeBuf) >= defaultWriterSize {
+		if err := c.writeCompressedPackets(); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// writeCompressedPackets compresses the buffered packets into the compressed packets, and writes them to the
+// buffered writer of the connection.
+func (c *compressedIO) writeCompressedPackets() error {
+	data := c.writeBuf
+	for len(data) > 0 {
+		size := len(data)
+		if size > mysql.MaxPayloadLen {
+			size = mysql.MaxPayloadLen
+		}
+		if err := c.writeCompressedPacket(data[:size]); err != nil {
+			return err
+		}
+		data = data[size:]
+	}
+	if cap(c.writeBuf) > mysql.MaxPayloadLen {
+		// Don't hold the buffer of the large packets.
+		c.writeBuf = nil
+	} else {
+		c.writeBuf = c.writeBuf[:0]
+	}
+	return nil
+}
+
+func (c *compressedIO) writeCompressedPacket(data []byte) error {
+	payload, uncompressedLength := data, 0
+	if len(data) >= minCompressLength {
+		compressed, err := c.compress(data)
+		if err != nil {
+			return err
+		}
+		// Send the payload as it is if it can't be compressed, the same as MySQL.
+		if len(compressed) < len(data) {
+			payload, uncompressedLength = compressed, len(data)
+		}
+	}
+	var header [compressedHeaderLength]byte
+	header[0] = byte(len(payload))
+	header[1] = byte(len(payload) >> 8)
+	header[2] = byte(len(payload) >> 16)
+	header[3] = c.p.compressedSequence
+	header[4] = byte(uncompressedLength)
+	header[5] = byte(uncompressedLength >> 8)
+	header[6] = byte(uncompressedLength >> 16)
+	if _, err := c.p.bufWriter.Write(header[:]); err != nil {
+		terror.Log(errors.Trace(err))
+		return errors.Trace(mysql.ErrBadConn)
+	}
+	if _, err := c.p.bufWriter.Write(payload); err != nil {
+		terror.Log(errors.Trace(err))
+		return errors.Trace(mysql.ErrBadConn)
+	}
+	c.p.compressedSequence++
+	c.stats.addWrite(compressedHeaderLength+len(payload), compressedHeaderLength+len(data))
+	return nil
+}
+
+func (c *compressedIO) compress(data []byte) ([]byte, error) {
+	if c.algorithm == compressionZstd {
+		c.compressed = c.zstdEncoder.EncodeAll(data, c.compressed[:0])
+		return c.compressed, nil
+	}
+	c.compressBuf.Reset()
+	c.zlibWriter.Reset(&c.compressBuf)
+	if _, err := c.zlibWriter.Write(data); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := c.zlibWriter.Close(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return c.compressBuf.Bytes(), nil
+}
+
+func (c *compressedIO) close() {
+	if c.zstdEncoder != nil {
+		terror.Log(c.zstdEncoder.Close())
+	}
+	if c.zstdDecoder != nil {
+		c.zstdDecoder.Close()
+	}
+	if ratio, ok := c.stats.ratio(); ok {
+		metrics.ConnCompressionRatioHistogram.WithLabelValues(compressionName(c.algorithm)).Observe(ratio)
+	}
+}
+
+func (s *compressionStats) addRead(bytes, uncompressedBytes int) {
+	s.readBytes += int64(bytes)
+	s.readUncompressedBytes += int64(uncompressedBytes)
+	s.readCounter.Add(float64(bytes))
+	s.readUncompressedCounter.Add(float64(uncompressedBytes))
+}
+
+func (s *compressionStats) addWrite(bytes, uncompressedBytes int) {
+	s.writeBytes += int64(bytes)
+	s.writeUncompressedBytes += int64(uncompressedBytes)
+	s.writeCounter.Add(float64(bytes))
+	s.writeUncompressedCounter.Add(float64(uncompressedBytes))
+}
+
+// ratio returns the ratio of the bytes before compression to the bytes transferred of the connection.
+func (s *compressionStats) ratio() (float64, bool) {
+	transferred := s.readBytes + s.writeBytes
+	if transferred == 0 {
+		return 0, false
+	}
+	return float64(s.readUncompressedBytes+s.writeUncompressedBytes) / float64(transferred), true
+}
@@ -39,6 +39,7 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/binary"
+	"encoding/hex"
 	goerr "errors"
 	"fmt"
 	"io"
@@ -78,11 +79,14 @@ import (
 	"github.com/pingcap/tidb/tablecodec"
 	tidbutil "github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/arena"
+	"github.com/pingcap/tidb/util/audit"
 	"github.com/pingcap/tidb/util/chunk"
 	"github.com/pingcap/tidb/util/execdetails"
 	"github.com/pingcap/tidb/util/hack"
 	"github.com/pingcap/tidb/util/logutil"
 	"github.com/pingcap/tidb/util/memory"
+	"github.com/pingcap/tidb/util/proxyprotocol"
+	"github.com/pingcap/tidb/util/topsql/tracecpu"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/tikv/client-go/v2/util"
 	"go.uber.org/zap"
@@ -176,14 +180,18 @@ type clientConn struct {
 	alloc        arena.Allocator   // an memory allocator for reducing memory allocation.
 	lastPacket   []byte            // latest sql query string, currently used for logging error.
 	ctx          *TiDBContext      // an interface to execute sql statements.
-	attrs        map[string]string // attributes parsed from client handshake response, not used for now.
+	attrs        map[string]string // attributes parsed from client handshake response and PROXY protocol header.
 	peerHost     string            // peer host
 	peerPort     string            // peer port
 	status       int32             // dispatching/reading/shutdown/waitshutdown
 	lastCode     uint16            // last error code
 	collation    uint8             // collation used by client, may be different from the collation used by database.
+	zstdLevel    int               // zstd level of the compressed protocol asked by client.
 	lastActive   time.Time
 
+	// proxyConn is the connection through the PROXY protocol, nil if not.
+	proxyConn *proxyprotocol.Conn
+
 	// mu is used for cancelling the execution of current transaction.
 	mu struct {
 		sync.RWMutex
@@ -286,9 +294,31 @@ func (cc *clientConn) handshake(ctx context.Context) error {
 		logutil.Logger(ctx).Debug("flush response to client failed", zap.Error(err))
 		return err
 	}
+
+	// The compressed protocol is used after the handshake.
+	if err = cc.setCompression(); err != nil {
+		logutil.Logger(ctx).Warn("set compression failed", zap.Error(err))
+	}
 	return err
 }
 
+// setCompression sets the compressed protocol asked by the client. zlib is used if both zlib and zstd are asked.
+func (cc *clientConn) setCompression() error {
+	algorithm, level := compressionNone, 0
+	if cc.capability&mysql.ClientCompress > 0 {
+		algorithm = compressionZlib
+	} else if cc.capability&clientZstdCompressionAlgorithm > 0 {
+		algorithm, level = compressionZstd, cc.zstdLevel
+		if level == 0 {
+			level = defaultZstdLevel
+		}
+	}
+	if algorithm == compressionNone {
+		return nil
+	}
+	return cc.pkt.setCompression(algorithm, level)
+}
+
 func (cc *clientConn) Close() error {
 	cc.server.rwlock.Lock()
 	delete(cc.server.clients, cc.connectionID)
@@ -393,6 +423,7 @@ type handshakeResponse41 struct {
 	Auth       []byte
 	AuthPlugin string
 	Attrs      map[string]string
+	ZstdLevel  int
 }
 
 // parseOldHandshakeResponseHeader parses the old version handshake header HandshakeResponse320
@@ -541,9 +572,14 @@ func parseHandshakeResponseBody(ctx context.Context, packet *handshakeResponse41
 				return nil
 			}
 			packet.Attrs = attrs
+			offset += int(num)
 		}
 	}
 
+	if packet.Capability&clientZstdCompressionAlgorithm > 0 && len(data[offset:]) > 0 {
+		packet.ZstdLevel = int(data[offset])
+	}
+
 	return nil
 }
 
@@ -655,7 +691,8 @@ func (cc *clientConn) readOptionalSSLRequestAndHandshakeResponse(ctx context.Con
 	cc.user = resp.User
 	cc.dbname = resp.DBName
 	cc.collation = resp.Collation
-	cc.attrs = resp.Attrs
+	cc.attrs = cc.connectAttrs(resp.Attrs)
+	cc.zstdLevel = resp.ZstdLevel
 
 	err = cc.openSessionAndDoAuth(resp.Auth)
 	if err != nil {
@@ -664,6 +701,36 @@ func (cc *clientConn) readOptionalSSLRequestAndHandshakeResponse(ctx context.Con
 	return err
 }
 
+// proxyAttrs are the names of the connection attributes of the TLVs of the PROXY protocol header.
+var proxyAttrs = map[byte]string{
+	proxyprotocol.TLVTypeALPN:      "_proxy_alpn",
+	proxyprotocol.TLVTypeAuthority: "_proxy_authority",
+	proxyprotocol.TLVTypeUniqueID:  "_proxy_unique_id",
+}
+
+// connectAttrs returns the attributes sent by the client, and the ones of the TLVs of the PROXY protocol header.
+func (cc *clientConn) connectAttrs(attrs map[string]string) map[string]string {
+	if cc.proxyConn == nil {
+		return attrs
+	}
+	for _, tlv := range cc.proxyConn.TLVs() {
+		name, ok := proxyAttrs[tlv.Type]
+		if !ok {
+			continue
+		}
+		if attrs == nil {
+			attrs = make(map[string]string)
+		}
+		if tlv.Type == proxyprotocol.TLVTypeUniqueID {
+			// The unique ID is opaque bytes.
+			attrs[name] = hex.EncodeToString(tlv.Value)
+		} else {
+			attrs[name] = string(tlv.Value)
+		}
+	}
+	return attrs
+}
+
 func (cc *clientConn) SessionStatusToString() string {
 	status := cc.ctx.Status()
 	inTxn, autoCommit := 0, 0
@@ -689,6 +756,7 @@ func (cc *clientConn) openSessionAndDoAuth(authData []byte) error {
 	if err != nil {
 		return err
 	}
+	cc.ctx.GetSessionVars().ConnectAttrs = cc.attrs
 
 	if err = cc.server.checkConnectionCount(); err != nil {
 		return err
@@ -811,6 +879,8 @@ func (cc *clientConn) Run(ctx context.Context) {
 			err := cc.Close()
 			terror.Log(err)
 		}
+		// It's closed here rather than in Close, which may be called by other goroutines.
+		cc.pkt.close()
 	}()
 	// Usually, client connection status changes between [dispatching] <=> [reading].
 	// When some event happens, server may notify this client connection by setting
@@ -886,7 +956,7 @@ func (cc *clientConn) Run(ctx context.Context) {
 			terror.Log(err1)
 		}
 		cc.addMetrics(data[0], startTime, err)
-		cc.pkt.sequence = 0
+		cc.pkt.resetSequence()
 	}
 }
 
@@ -1019,6 +1089,7 @@ func (cc *clientConn) dispatch(ctx context.Context, data []byte) error {
 	data = data[1:]
 	if variable.TopSQLEnabled() {
 		defer pprof.SetGoroutineLabels(ctx)
+		ctx = tracecpu.CtxWithConnID(ctx, cc.connectionID)
 	}
 	if variable.EnablePProfSQLCPU.Load() {
 		label := getLastStmtInConn{cc}.PProfLabel()
@@ -1851,6 +1922,8 @@ func (cc *clientConn) writeColumnInfo(columns []*ColumnInfo, serverStatus uint16
 // The first return value indicates whether error occurs at the first call of ResultSet.Next.
 func (cc *clientConn) writeChunks(ctx context.Context, rs ResultSet, binary bool, serverStatus uint16) (bool, error) {
 	data := cc.alloc.AllocWithLen(4, 1024)
+	var lv largeValues
+	var dumper *chunkDumper
 	req := rs.NewChunk()
 	gotColumnInfo := false
 	firstNext := true
@@ -1885,6 +1958,7 @@ func (cc *clientConn) writeChunks(ctx context.Context, rs ResultSet, binary bool
 			if err != nil {
 				return false, err
 			}
+			dumper = newChunkDumper(columns, binary)
 			gotColumnInfo = true
 		}
 		rowCount := req.NumRows()
@@ -1893,30 +1967,53 @@ func (cc *clientConn) writeChunks(ctx context.Context, rs ResultSet, binary bool
 		}
 		reg := trace.StartRegion(ctx, "WriteClientConn")
 		start := time.Now()
+		columnWise := dumper.canDump(req)
+		if columnWise {
+			if err = dumper.prepare(req); err != nil {
+				reg.End()
+				return false, err
+			}
+		}
+		var writtenBytes int
 		for i := 0; i < rowCount; i++ {
 			data = data[0:4]
-			if binary {
-				data, err = dumpBinaryRow(data, rs.Columns(), req.GetRow(i))
+			lv.reset()
+			if columnWise {
+				data = dumper.dumpRow(data, req, i, &lv)
+			} else if binary {
+				data, err = dumpBinaryRow(data, rs.Columns(), req.GetRow(i), &lv)
 			} else {
-				data, err = dumpTextRow(data, rs.Columns(), req.GetRow(i))
+				data, err = dumpTextRow(data, rs.Columns(), req.GetRow(i), &lv)
 			}
 			if err != nil {
 				reg.End()
 				return false, err
 			}
-			if err = cc.writePacket(data); err != nil {
+			if err = cc.writeRow(data, &lv); err != nil {
 				reg.End()
 				return false, err
 			}
+			writtenBytes += lv.payloadSize(data)
 		}
 		reg.End()
 		if stmtDetail != nil {
 			stmtDetail.WriteSQLRespDuration += time.Since(start)
+			stmtDetail.WriteSQLRespRows += int64(rowCount)
+			stmtDetail.WriteSQLRespBytes += int64(writtenBytes)
 		}
 	}
 	return false, cc.writeEOF(serverStatus)
 }
 
+// writeRow writes the packet of a row dumped in data, the large values left out of data are written from lv
+// directly without being copied into data.
+func (cc *clientConn) writeRow(data []byte, lv *largeValues) error {
+	if len(lv.values) == 0 {
+		return cc.writePacket(data)
+	}
+	return cc.pkt.writePacketPieces(lv.pieces(data))
+}
+
 // writeChunksWithFetchSize writes data from a Chunk, which filled data by a ResultSet, into a connection.
 // binary specifies the way to dump data. It throws any error while dumping data.
 // serverStatus, a flag bit represents server information.
@@ -1971,18 +2068,24 @@ func (cc *clientConn) writeChunksWithFetchSize(ctx context.Context, rs ResultSet
 	}
 	start := time.Now()
 	var err error
+	var lv largeValues
+	var writtenBytes int
 	for _, row := range curRows {
 		data = data[0:4]
-		data, err = dumpBinaryRow(data, rs.Columns(), row)
+		lv.reset()
+		data, err = dumpBinaryRow(data, rs.Columns(), row, &lv)
 		if err != nil {
 			return err
 		}
-		if err = cc.writePacket(data); err != nil {
+		if err = cc.writeRow(data, &lv); err != nil {
 			return err
 		}
+		writtenBytes += lv.payloadSize(data)
 	}
 	if stmtDetail != nil {
 		stmtDetail.WriteSQLRespDuration += time.Since(start)
+		stmtDetail.WriteSQLRespRows += int64(len(curRows))
+		stmtDetail.WriteSQLRespBytes += int64(writtenBytes)
 	}
 	if cl, ok := rs.(fetchNotifier); ok {
 		cl.OnFetchReturned()
@@ -2053,6 +2156,7 @@ func (cc *clientConn) handleResetConnection(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	cc.ctx.GetSessionVars().ConnectAttrs = cc.attrs
 	if !cc.ctx.AuthWithoutVerification(user) {
 		return errors.New("Could not reset connection")
 	}
@@ -2068,9 +2172,10 @@ func (cc *clientConn) handleResetConnection(ctx context.Context) error {
 }
 
 func (cc *clientConn) handleCommonConnectionReset(ctx context.Context) error {
-	if plugin.IsEnable(plugin.Audit) {
+	if plugin.IsEnable(plugin.Audit) || audit.Enabled() {
 		cc.ctx.GetSessionVars().ConnectionInfo = cc.connectInfo()
 	}
+	audit.LogConnection(audit.EventChangeUser, cc.ctx.GetSessionVars().ConnectionInfo, nil)
 
 	err := plugin.ForeachPlugin(plugin.Audit, func(p *plugin.Plugin) error {
 		authPlugin := plugin.DeclareAuditManifest(p.Manifest)
@@ -149,6 +149,23 @@ func (ts *ConnTestSuite) TestParseHandshakeResponse(c *C) {
 	c.Assert(p.User, Equals, "root")
 }
 
+func (ts *ConnTestSuite) TestParseHandshakeZstdLevel(c *C) {
+	c.Parallel()
+	capability := mysql.ClientProtocol41 | mysql.ClientSecureConnection | clientZstdCompressionAlgorithm
+	data := make([]byte, 0, 64)
+	data = dumpUint32(data, capability)
+	data = append(data, make([]byte, 4+1+23)...)
+	// User root with an empty auth, followed by the zstd level.
+	data = append(data, 'r', 'o', 'o', 't', 0x00, 0x00, 0x07)
+	var p handshakeResponse41
+	offset, err := parseHandshakeResponseHeader(context.Background(), &p, data)
+	c.Assert(err, IsNil)
+	err = parseHandshakeResponseBody(context.Background(), &p, data, offset)
+	c.Assert(err, IsNil)
+	c.Assert(p.User, Equals, "root")
+	c.Assert(p.ZstdLevel, Equals, 7)
+}
+
 func (ts *ConnTestSuite) TestIssue1768(c *C) {
 	c.Parallel()
 	// this data is from captured handshake packet, using mysql client.
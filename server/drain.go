@@ -0,0 +1,222 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/terror"
+	"github.com/pingcap/tidb/metrics"
+	plannercore "github.com/pingcap/tidb/planner/core"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/logutil"
+	"go.uber.org/zap"
+)
+
+// drainState is the state of draining the server. Draining reports unhealthy, stops accepting new connections,
+// closes the idle connections and waits for the active transactions, but keeps the status server running, so the
+// server can be shut down after it's drained during rolling restarts.
+type drainState struct {
+	sync.Mutex
+	startTime     time.Time
+	finishTime    time.Time
+	sessionStates []*sessionState
+}
+
+// sessionState is the state of an idle session closed by draining, with which the client can restore the session on
+// another server after reconnecting.
+type sessionState struct {
+	ConnectionID uint64 `json:"connection_id"`
+	User         string `json:"user"`
+	Host         string `json:"host"`
+	DB           string `json:"db"`
+	// SystemVars are the session variables different from the global ones.
+	SystemVars    map[string]string   `json:"system_vars,omitempty"`
+	UserVars      map[string]string   `json:"user_vars,omitempty"`
+	PreparedStmts []preparedStmtState `json:"prepared_stmts,omitempty"`
+}
+
+// preparedStmtState is the prepared statement of a session, Name is empty if it's prepared by the binary protocol.
+type preparedStmtState struct {
+	ID   uint32 `json:"id"`
+	Name string `json:"name,omitempty"`
+	SQL  string `json:"sql"`
+}
+
+// drainStatus is the response of the drain API.
+type drainStatus struct {
+	Draining      bool            `json:"draining"`
+	Drained       bool            `json:"drained"`
+	StartTime     string          `json:"start_time,omitempty"`
+	Connections   int             `json:"connections"`
+	ActiveTxns    int             `json:"active_txns"`
+	SessionStates []*sessionState `json:"session_states,omitempty"`
+}
+
+// handleDrain starts draining the server on POST, and returns the drain status. Draining the server and reading the
+// saved session states require a client certificate verified by cluster-verify-cn of [security], because the
+// session states contain the user variables and the prepared statements of the users.
+func (s *Server) handleDrain(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodPost {
+		if !s.statusVerifyClient {
+			w.WriteHeader(http.StatusForbidden)
+			_, err := w.Write([]byte("draining the server requires a client certificate verified by cluster-verify-cn"))
+			terror.Log(errors.Trace(err))
+			return
+		}
+		if s.startDrain() {
+			go s.drainConnections()
+		}
+	}
+	writeData(w, s.drainStatus(s.statusVerifyClient))
+}
+
+// startDrain marks the server as draining, it returns false if the server is already draining.
+func (s *Server) startDrain() bool {
+	s.drain.Lock()
+	defer s.drain.Unlock()
+	if !s.drain.startTime.IsZero() {
+		return false
+	}
+	s.drain.startTime = time.Now()
+	return true
+}
+
+func (s *Server) isDraining() bool {
+	s.drain.Lock()
+	defer s.drain.Unlock()
+	return !s.drain.startTime.IsZero()
+}
+
+// drainConnections stops accepting new connections and closes the connections once they're idle, the remaining
+// connections are killed after the timeout of [drain] in the config.
+func (s *Server) drainConnections() {
+	logutil.BgLogger().Info("[server] start draining", zap.Uint("timeout", s.cfg.Drain.Timeout))
+	metrics.ServerEventCounter.WithLabelValues(metrics.EventDrain).Inc()
+	s.startShutdown()
+	s.rwlock.Lock()
+	s.closeListeners()
+	s.rwlock.Unlock()
+	s.TryGracefulDown()
+
+	s.drain.Lock()
+	s.drain.finishTime = time.Now()
+	duration := s.drain.finishTime.Sub(s.drain.startTime)
+	sessions := len(s.drain.sessionStates)
+	s.drain.Unlock()
+	logutil.BgLogger().Info("[server] drained", zap.Duration("duration", duration), zap.Int("saved sessions", sessions))
+}
+
+// drainStatus returns the drain status, the saved session states are returned only if withSessionStates is true.
+func (s *Server) drainStatus(withSessionStates bool) *drainStatus {
+	st := &drainStatus{}
+	s.rwlock.RLock()
+	st.Connections = len(s.clients)
+	for _, cc := range s.clients {
+		if cc.ctx.Status()&mysql.ServerStatusInTrans > 0 {
+			st.ActiveTxns++
+		}
+	}
+	s.rwlock.RUnlock()
+
+	s.drain.Lock()
+	defer s.drain.Unlock()
+	if s.drain.startTime.IsZero() {
+		return st
+	}
+	st.Draining = true
+	st.Drained = !s.drain.finishTime.IsZero()
+	st.StartTime = s.drain.startTime.Format(time.RFC3339)
+	if withSessionStates {
+		st.SessionStates = s.drain.sessionStates
+	}
+	return st
+}
+
+// saveSessionState saves the state of an idle connection before it's closed by draining. It must be called after
+// cc.ShutdownOrNotify returns true, so the session isn't used by the connection.
+func (s *Server) saveSessionState(cc *clientConn) {
+	state := cc.sessionState()
+	s.drain.Lock()
+	s.drain.sessionStates = append(s.drain.sessionStates, state)
+	s.drain.Unlock()
+}
+
+func (cc *clientConn) sessionState() *sessionState {
+	vars := cc.ctx.GetSessionVars()
+	state := &sessionState{
+		ConnectionID: cc.connectionID,
+		User:         cc.user,
+		Host:         cc.peerHost,
+		DB:           cc.ctx.CurrentDB(),
+		SystemVars:   make(map[string]string),
+		UserVars:     make(map[string]string),
+	}
+	for name, sv := range variable.GetSysVars() {
+		if !sv.HasSessionScope() || sv.ReadOnly {
+			continue
+		}
+		value, ok := vars.GetSystemVar(name)
+		if !ok {
+			continue
+		}
+		defaultValue := sv.Value
+		if sv.HasGlobalScope() {
+			globalValue, err := vars.GlobalVarsAccessor.GetGlobalSysVar(name)
+			if err != nil {
+				logutil.BgLogger().Warn("get global variable failed", zap.String("variable", name), zap.Error(err))
+				continue
+			}
+			defaultValue = globalValue
+		}
+		if value != defaultValue {
+			state.SystemVars[name] = value
+		}
+	}
+
+	vars.UsersLock.RLock()
+	for name, d := range vars.Users {
+		value, err := d.ToString()
+		if err != nil {
+			continue
+		}
+		state.UserVars[name] = value
+	}
+	vars.UsersLock.RUnlock()
+
+	names := make(map[uint32]string, len(vars.PreparedStmtNameToID))
+	for name, id := range vars.PreparedStmtNameToID {
+		names[id] = name
+	}
+	for id, stmt := range vars.PreparedStmts {
+		prepared, ok := stmt.(*plannercore.CachedPrepareStmt)
+		if !ok {
+			continue
+		}
+		state.PreparedStmts = append(state.PreparedStmts, preparedStmtState{
+			ID:   id,
+			Name: names[id],
+			SQL:  prepared.PreparedAst.Stmt.Text(),
+		})
+	}
+	sort.Slice(state.PreparedStmts, func(i, j int) bool {
+		return state.PreparedStmts[i].ID < state.PreparedStmts[j].ID
+	})
+	return state
+}
@@ -24,6 +24,7 @@ import (
 	"net/http"
 	"net/url"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync/atomic"
@@ -49,6 +50,8 @@ import (
 	"github.com/pingcap/tidb/sessionctx/binloginfo"
 	"github.com/pingcap/tidb/sessionctx/stmtctx"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/store/copr"
+	"github.com/pingcap/tidb/store/driver/interceptor"
 	"github.com/pingcap/tidb/store/gcworker"
 	"github.com/pingcap/tidb/store/helper"
 	"github.com/pingcap/tidb/table"
@@ -60,6 +63,8 @@ import (
 	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/deadlockhistory"
 	"github.com/pingcap/tidb/util/gcutil"
+	"github.com/pingcap/tidb/util/instancestats"
+	"github.com/pingcap/tidb/util/lockwaithistory"
 	"github.com/pingcap/tidb/util/logutil"
 	"github.com/pingcap/tidb/util/pdapi"
 	"github.com/tikv/client-go/v2/tikv"
@@ -336,6 +341,13 @@ type flashReplicaHandler struct {
 	*tikvHandlerTool
 }
 
+// flashReplicaSyncHandler is the handler for pausing and resuming reading the tiflash replicas.
+type flashReplicaSyncHandler struct {
+	*tikvHandlerTool
+	// verifyClient indicates whether the clients of the status server are verified by cluster-verify-cn.
+	verifyClient bool
+}
+
 // regionHandler is the common field for http handler. It contains
 // some common functions for all handlers.
 type regionHandler struct {
@@ -370,6 +382,16 @@ type profileHandler struct {
 	*tikvHandlerTool
 }
 
+// kvChaosHandler is the handler for injecting the chaos into the RPCs sent by the kv client.
+type kvChaosHandler struct {
+	*tikvHandlerTool
+	// verifyClient indicates whether the clients of the status server are verified by cluster-verify-cn.
+	verifyClient bool
+}
+
+// runtimeStatsHandler is the handler for the runtime statistics of the instance.
+type runtimeStatsHandler struct{}
+
 // ddlHookHandler is the handler for use pre-defined ddl callback.
 // It's convenient to provide some APIs for integration tests.
 type ddlHookHandler struct {
@@ -718,6 +740,33 @@ func (h settingsHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			config.StoreGlobalConfig(cfg)
 			deadlockhistory.GlobalDeadlockHistory.Resize(uint(capacity))
 		}
+		if collectRetryable := req.Form.Get("tidb_deadlock_history_collect_retryable"); collectRetryable != "" {
+			cfg := config.GetGlobalConfig()
+			switch collectRetryable {
+			case "0":
+				cfg.PessimisticTxn.DeadlockHistoryCollectRetryable = false
+			case "1":
+				cfg.PessimisticTxn.DeadlockHistoryCollectRetryable = true
+			default:
+				writeError(w, errors.New("illegal argument"))
+				return
+			}
+			config.StoreGlobalConfig(cfg)
+		}
+		if lockWaitHistoryCapacity := req.Form.Get("tidb_lock_wait_history_capacity"); lockWaitHistoryCapacity != "" {
+			capacity, err := strconv.Atoi(lockWaitHistoryCapacity)
+			if err != nil {
+				writeError(w, errors.New("illegal argument"))
+				return
+			} else if capacity < 0 || capacity > 10000 {
+				writeError(w, errors.New("tidb_lock_wait_history_capacity out of range, should be in 0 to 10000"))
+				return
+			}
+			cfg := config.GetGlobalConfig()
+			cfg.PessimisticTxn.LockWaitHistoryCapacity = uint(capacity)
+			config.StoreGlobalConfig(cfg)
+			lockwaithistory.GlobalLockWaitHistory.Resize(uint(capacity))
+		}
 	} else {
 		writeData(w, config.GetGlobalConfig())
 	}
@@ -885,20 +934,19 @@ func (h flashReplicaHandler) handleStatusReport(w http.ResponseWriter, req *http
 		writeError(w, err)
 		return
 	}
-	do, err := session.GetDomain(h.Store)
+	// The progress of the paused replica is ignored, so it isn't read until it's resumed.
+	control, err := infosync.GetTiFlashTableSyncControl(req.Context(), status.ID)
 	if err != nil {
 		writeError(w, err)
 		return
 	}
-	s, err := session.CreateSession(h.Store)
-	if err != nil {
-		writeError(w, err)
+	if control.Paused {
+		logutil.BgLogger().Info("ignore flash replica report of the paused sync", zap.Int64("table ID", status.ID))
 		return
 	}
-	defer s.Close()
 
 	available := status.checkTableFlashReplicaAvailable()
-	err = do.DDL().UpdateTableReplicaInfo(s, status.ID, available)
+	err = h.updateTableReplicaInfo(status.ID, available)
 	if err != nil {
 		writeError(w, err)
 	}
@@ -917,6 +965,103 @@ func (h flashReplicaHandler) handleStatusReport(w http.ResponseWriter, req *http
 		zap.Error(err))
 }
 
+// updateTableReplicaInfo updates the availability of the tiflash replica of the table or partition.
+func (t *tikvHandlerTool) updateTableReplicaInfo(physicalID int64, available bool) error {
+	do, err := session.GetDomain(t.Store)
+	if err != nil {
+		return err
+	}
+	s, err := session.CreateSession(t.Store)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	return do.DDL().UpdateTableReplicaInfo(s, physicalID, available)
+}
+
+type tableFlashReplicaSyncControl struct {
+	ID int64 `json:"id"`
+	infosync.TiFlashSyncControl
+}
+
+// ServeHTTP shows the paused tiflash replicas for GET, and pauses or resumes reading a replica for POST. Like setting
+// the chaos, POST requires a client certificate verified by cluster-verify-cn.
+func (h flashReplicaSyncHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodPost:
+		if !h.verifyClient {
+			w.WriteHeader(http.StatusForbidden)
+			_, err := w.Write([]byte("pausing or resuming the tiflash replicas requires a client certificate verified by cluster-verify-cn"))
+			terror.Log(errors.Trace(err))
+			return
+		}
+	case http.MethodGet:
+		controls, err := infosync.GetTiFlashTableSyncControls(req.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		syncControls := make([]tableFlashReplicaSyncControl, 0, len(controls))
+		for id, control := range controls {
+			syncControls = append(syncControls, tableFlashReplicaSyncControl{ID: id, TiFlashSyncControl: control})
+		}
+		sort.Slice(syncControls, func(i, j int) bool { return syncControls[i].ID < syncControls[j].ID })
+		writeData(w, syncControls)
+		return
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(req.FormValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, errors.Errorf("invalid table ID %q", req.FormValue("id")))
+		return
+	}
+	schema, err := h.schema()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	tbl, ok := schema.TableByID(id)
+	if !ok {
+		tbl, _, _ = schema.FindTableByPartitionID(id)
+	}
+	if tbl == nil || tbl.Meta().TiFlashReplica == nil {
+		writeError(w, errors.Errorf("table or partition %d has no tiflash replica", id))
+		return
+	}
+	var paused *bool
+	if value := req.FormValue("paused"); value != "" {
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			writeError(w, errors.Errorf("invalid paused %q", value))
+			return
+		}
+		paused = &v
+	}
+	control, err := infosync.UpdateTiFlashTableSyncControl(req.Context(), id, func(control *infosync.TiFlashSyncControl) {
+		if paused != nil {
+			control.Paused = *paused
+		}
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	// TiDB doesn't read the paused replica. It becomes available again when TiFlash reports it's synced after it's
+	// resumed.
+	if control.Paused {
+		if err = h.updateTableReplicaInfo(id, false); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	logutil.BgLogger().Info("set flash replica sync control", zap.Int64("table ID", id),
+		zap.Bool("paused", control.Paused))
+	writeData(w, "success!")
+}
+
 // ServeHTTP handles request of list a database or table's schemas.
 func (h schemaHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	schema, err := h.schema()
@@ -1667,6 +1812,21 @@ type serverInfo struct {
 	*infosync.ServerInfo
 }
 
+// ServeHTTP handles request of the runtime statistics of the instance.
+func (h runtimeStatsHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	stats := instancestats.CollectRuntime()
+	stats.Memory = append(stats.Memory, instancestats.MemoryItem{
+		Name:  "tracked",
+		Bytes: uint64(executor.GlobalMemoryUsageTracker.BytesConsumed()),
+	})
+	cop, mpp := copr.RunningTasks()
+	stats.RunningTasks = []instancestats.RunningTask{
+		{Type: instancestats.TaskCop, Count: cop},
+		{Type: instancestats.TaskMPP, Count: mpp},
+	}
+	writeData(w, stats)
+}
+
 // ServeHTTP handles request of ddl server info.
 func (h serverInfoHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	do, err := session.GetDomain(h.Store)
@@ -1930,3 +2090,81 @@ func (h ddlHookHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	ctx := req.Context()
 	logutil.Logger(ctx).Info("change ddl hook success", zap.String("to_ddl_hook", req.FormValue("ddl_hook")))
 }
+
+// ServeHTTP shows the chaos of the kv client for GET, and sets it for POST. The params of POST are db and table
+// for the affected table, percent for the percentage of the affected requests, latency for the injected latency,
+// region_error for injecting the ServerIsBusy region errors, and enabled=false for disabling the chaos. Like draining,
+// setting the chaos requires a client certificate verified by cluster-verify-cn.
+func (h kvChaosHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		writeData(w, interceptor.GetChaos())
+		return
+	}
+	if !h.verifyClient {
+		w.WriteHeader(http.StatusForbidden)
+		_, err := w.Write([]byte("setting the chaos requires a client certificate verified by cluster-verify-cn"))
+		terror.Log(errors.Trace(err))
+		return
+	}
+	if s := req.FormValue("enabled"); s != "" {
+		enabled, err := strconv.ParseBool(s)
+		if err != nil {
+			writeError(w, errors.Errorf("parse enabled(%s) failed", s))
+			return
+		}
+		if !enabled {
+			interceptor.SetChaos(nil)
+			writeData(w, "success!")
+			return
+		}
+	}
+	cfg := &interceptor.ChaosConfig{Percent: 100}
+	if tableName := req.FormValue(pTableName); tableName != "" {
+		schema, err := h.schema()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		tbl, err := schema.TableByName(model.NewCIStr(req.FormValue(pDBName)), model.NewCIStr(tableName))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		tblInfo := tbl.Meta()
+		cfg.TableIDs = append(cfg.TableIDs, tblInfo.ID)
+		if pi := tblInfo.GetPartitionInfo(); pi != nil {
+			for _, def := range pi.Definitions {
+				cfg.TableIDs = append(cfg.TableIDs, def.ID)
+			}
+		}
+	}
+	if s := req.FormValue("percent"); s != "" {
+		percent, err := strconv.Atoi(s)
+		if err != nil || percent < 0 || percent > 100 {
+			writeError(w, errors.Errorf("illegal percent(%s)", s))
+			return
+		}
+		cfg.Percent = percent
+	}
+	if s := req.FormValue("latency"); s != "" {
+		latency, err := time.ParseDuration(s)
+		if err != nil {
+			writeError(w, errors.Errorf("parse latency(%s) failed", s))
+			return
+		}
+		cfg.Latency = latency
+	}
+	if s := req.FormValue("region_error"); s != "" {
+		regionError, err := strconv.ParseBool(s)
+		if err != nil {
+			writeError(w, errors.Errorf("parse region_error(%s) failed", s))
+			return
+		}
+		cfg.RegionError = regionError
+	}
+	interceptor.SetChaos(cfg)
+	writeData(w, "success!")
+
+	logutil.Logger(req.Context()).Info("set the chaos of the kv client", zap.Int64s("tableIDs", cfg.TableIDs),
+		zap.Int("percent", cfg.Percent), zap.Duration("latency", cfg.Latency), zap.Bool("regionError", cfg.RegionError))
+}
@@ -25,10 +25,12 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/http/httputil"
 	"net/url"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -861,6 +863,119 @@ func (ts *HTTPHandlerTestSuite) TestTiFlashReplica(c *C) {
 	checkFunc()
 }
 
+func (ts *HTTPHandlerTestSuite) TestTiFlashReplicaSync(c *C) {
+	ts.startServer(c)
+	ts.prepareData(c)
+	defer ts.stopServer(c)
+
+	db, err := sql.Open("mysql", ts.getDSN())
+	c.Assert(err, IsNil, Commentf("Error connecting"))
+	defer func() {
+		err := db.Close()
+		c.Assert(err, IsNil)
+	}()
+	dbt := &DBTest{c, db}
+
+	c.Assert(failpoint.Enable("github.com/pingcap/tidb/infoschema/mockTiFlashStoreCount", `return(true)`), IsNil)
+	defer func() {
+		err = failpoint.Disable("github.com/pingcap/tidb/infoschema/mockTiFlashStoreCount")
+		c.Assert(err, IsNil)
+	}()
+	// The dropped tables with the tiflash replicas are listed after the GC safe point.
+	dbt.mustExec(`INSERT HIGH_PRIORITY INTO mysql.tidb VALUES ('tikv_gc_safe_point', '20210101-00:00:00 +0000 UTC', '')
+			       ON DUPLICATE KEY UPDATE variable_value = '20210101-00:00:00 +0000 UTC'`)
+	dbt.mustExec("use tidb")
+	tbl, err := ts.domain.InfoSchema().TableByName(model.NewCIStr("tidb"), model.NewCIStr("test"))
+	c.Assert(err, IsNil)
+	id := strconv.FormatInt(tbl.Meta().ID, 10)
+
+	// The status server of the suite doesn't verify the clients, so the controls can't be set through it.
+	resp, err := ts.formStatus("/tiflash/replica/sync", url.Values{"id": {id}, "paused": {"true"}})
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusForbidden)
+	c.Assert(resp.Body.Close(), IsNil)
+	h := flashReplicaSyncHandler{ts.server.newTikvHandlerTool(), true}
+	setControl := func(form url.Values) string {
+		req := httptest.NewRequest(http.MethodPost, "/tiflash/replica/sync", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+	fetchReplicaInfos := func() []tableFlashReplicaInfo {
+		resp, err := ts.fetchStatus("/tiflash/replica")
+		c.Assert(err, IsNil)
+		var data []tableFlashReplicaInfo
+		c.Assert(json.NewDecoder(resp.Body).Decode(&data), IsNil)
+		c.Assert(resp.Body.Close(), IsNil)
+		return data
+	}
+	fetchControls := func() []tableFlashReplicaSyncControl {
+		resp, err := ts.fetchStatus("/tiflash/replica/sync")
+		c.Assert(err, IsNil)
+		var data []tableFlashReplicaSyncControl
+		c.Assert(json.NewDecoder(resp.Body).Decode(&data), IsNil)
+		c.Assert(resp.Body.Close(), IsNil)
+		return data
+	}
+
+	// The sync of the tables without the tiflash replica can't be controlled.
+	c.Assert(setControl(url.Values{"id": {id}, "paused": {"true"}}), Equals, fmt.Sprintf("table or partition %s has no tiflash replica", id))
+	c.Assert(setControl(url.Values{"id": {"x"}}), Equals, `invalid table ID "x"`)
+	c.Assert(fetchControls(), HasLen, 0)
+
+	dbt.mustExec("alter table test set tiflash replica 2 location labels 'a','b';")
+	c.Assert(setControl(url.Values{"id": {id}, "paused": {"yes"}}), Equals, `invalid paused "yes"`)
+	c.Assert(setControl(url.Values{"id": {id}, "paused": {"true"}}), Equals, `"success!"`)
+	c.Assert(fetchReplicaInfos(), HasLen, 1)
+	controls := fetchControls()
+	c.Assert(controls, HasLen, 1)
+	c.Assert(controls[0].ID, Equals, tbl.Meta().ID)
+	c.Assert(controls[0].Paused, IsTrue)
+
+	// The progress of the paused replica is ignored.
+	reportSynced := func() {
+		report := fmt.Sprintf(`{"id":%s,"region_count":3,"flash_region_count":3}`, id)
+		resp, err := ts.postStatus("/tiflash/replica", "application/json", bytes.NewBuffer([]byte(report)))
+		c.Assert(err, IsNil)
+		c.Assert(resp.Body.Close(), IsNil)
+	}
+	reportSynced()
+	c.Assert(fetchReplicaInfos()[0].Available, IsFalse)
+
+	// The control is removed when it's resumed.
+	c.Assert(setControl(url.Values{"id": {id}, "paused": {"false"}}), Equals, `"success!"`)
+	c.Assert(fetchControls(), HasLen, 0)
+	reportSynced()
+	c.Assert(fetchReplicaInfos()[0].Available, IsTrue)
+
+	// Pausing makes the available replica unavailable.
+	c.Assert(setControl(url.Values{"id": {id}, "paused": {"true"}}), Equals, `"success!"`)
+	c.Assert(fetchReplicaInfos()[0].Available, IsFalse)
+	c.Assert(setControl(url.Values{"id": {id}, "paused": {"false"}}), Equals, `"success!"`)
+	c.Assert(fetchControls(), HasLen, 0)
+
+	// The partitions are controlled one by one.
+	dbt.mustExec("alter table pt set tiflash replica 2 location labels 'a','b';")
+	dbt.mustExec("alter table test set tiflash replica 0;")
+	data := fetchReplicaInfos()
+	c.Assert(data, HasLen, 3)
+	c.Assert(setControl(url.Values{"id": {strconv.FormatInt(data[1].ID, 10)}, "paused": {"true"}}), Equals, `"success!"`)
+	controls = fetchControls()
+	c.Assert(controls, HasLen, 1)
+	c.Assert(controls[0].ID, Equals, data[1].ID)
+	c.Assert(setControl(url.Values{"id": {strconv.FormatInt(data[1].ID, 10)}, "paused": {"false"}}), Equals, `"success!"`)
+	c.Assert(fetchControls(), HasLen, 0)
+
+	// Only GET and POST are allowed.
+	req, err := http.NewRequest(http.MethodPut, ts.statusURL("/tiflash/replica/sync"), nil)
+	c.Assert(err, IsNil)
+	resp, err = http.DefaultClient.Do(req)
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusMethodNotAllowed)
+	c.Assert(resp.Body.Close(), IsNil)
+}
+
 func (ts *HTTPHandlerTestSuite) TestDecodeColumnValue(c *C) {
 	ts.startServer(c)
 	ts.prepareData(c)
@@ -41,6 +41,7 @@ import (
 	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/instancestats"
 	"github.com/pingcap/tidb/util/logutil"
 	"github.com/pingcap/tidb/util/printer"
 	"github.com/pingcap/tidb/util/topsql/tracecpu"
@@ -89,6 +90,7 @@ func (s *Server) listenStatusHTTPServer() error {
 		return errors.Trace(err)
 	}
 	tlsConfig = s.setCNChecker(tlsConfig)
+	s.statusVerifyClient = tlsConfig != nil && tlsConfig.ClientAuth == tls.RequireAndVerifyClientCert
 
 	if tlsConfig != nil {
 		// we need to manage TLS here for cmux to distinguish between HTTP and gRPC.
@@ -110,6 +112,10 @@ func (s *Server) startHTTPServer() {
 	router := mux.NewRouter()
 
 	router.HandleFunc("/status", s.handleStatus).Name("Status")
+	// HTTP path for draining the server and getting the drain status.
+	router.HandleFunc("/drain", s.handleDrain).Name("Drain")
+	// HTTP path for killing the connections of the cluster.
+	router.HandleFunc("/kill", s.handleKill).Name("Kill")
 	// HTTP path for prometheus.
 	router.Handle("/metrics", promhttp.Handler()).Name("Metrics")
 
@@ -120,6 +126,7 @@ func (s *Server) startHTTPServer() {
 	tikvHandlerTool := s.newTikvHandlerTool()
 	router.Handle("/settings", settingsHandler{tikvHandlerTool}).Name("Settings")
 	router.Handle("/binlog/recover", binlogRecover{}).Name("BinlogRecover")
+	router.Handle("/chaos/kv", kvChaosHandler{tikvHandlerTool, s.statusVerifyClient}).Name("KVChaos")
 
 	router.Handle("/schema", schemaHandler{tikvHandlerTool}).Name("Schema")
 	router.Handle("/schema/{db}", schemaHandler{tikvHandlerTool})
@@ -136,10 +143,14 @@ func (s *Server) startHTTPServer() {
 	// HTTP path for get server info.
 	router.Handle("/info", serverInfoHandler{tikvHandlerTool}).Name("Info")
 	router.Handle("/info/all", allServerInfoHandler{tikvHandlerTool}).Name("InfoALL")
+	// HTTP path for get the runtime statistics of the instance.
+	router.Handle(instancestats.Path, runtimeStatsHandler{}).Name("InfoRuntime")
 	// HTTP path for get db and table info that is related to the tableID.
 	router.Handle("/db-table/{tableID}", dbTableHandler{tikvHandlerTool})
 	// HTTP path for get table tiflash replica info.
 	router.Handle("/tiflash/replica", flashReplicaHandler{tikvHandlerTool})
+	// HTTP path for pause and resume reading the tiflash replicas.
+	router.Handle("/tiflash/replica/sync", flashReplicaSyncHandler{tikvHandlerTool, s.statusVerifyClient})
 
 	if s.cfg.Store == "tikv" {
 		// HTTP path for tikv.
@@ -186,6 +197,7 @@ func (s *Server) startHTTPServer() {
 	serverMux.HandleFunc("/debug/pprof/", pprof.Index)
 	serverMux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
 	serverMux.HandleFunc("/debug/pprof/profile", tracecpu.ProfileHTTPHandler)
+	serverMux.HandleFunc("/debug/top-sql", tracecpu.TopSQLHTTPHandler)
 	serverMux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
 	serverMux.HandleFunc("/debug/pprof/trace", pprof.Trace)
 	serverMux.HandleFunc("/debug/gogc", func(w http.ResponseWriter, r *http.Request) {
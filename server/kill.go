@@ -0,0 +1,126 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/parser/terror"
+	"github.com/pingcap/tidb/domain/infosync"
+	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/logutil"
+	"go.uber.org/zap"
+)
+
+// handleKill kills the connection given by the conn parameter in the form of 'instance:id' on POST. The instance is
+// the status address of a TiDB instance of the cluster, which is the INSTANCE column of CLUSTER_PROCESSLIST, and the
+// id is the ID of the connection on it. The kill is forwarded to the instance if it's another one, so the connections
+// can be killed through any instance behind a load balancer. Only the running statement is killed if query is true.
+// Like draining, killing the connections requires a client certificate verified by cluster-verify-cn, with which the
+// instances forward the kills to each other.
+func (s *Server) handleKill(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.statusVerifyClient {
+		w.WriteHeader(http.StatusForbidden)
+		_, err := w.Write([]byte("killing the connections requires a client certificate verified by cluster-verify-cn"))
+		terror.Log(errors.Trace(err))
+		return
+	}
+	conn := req.FormValue("conn")
+	pos := strings.LastIndexByte(conn, ':')
+	if pos <= 0 {
+		writeError(w, errors.Errorf("invalid conn %q, expect 'instance:id'", conn))
+		return
+	}
+	instance := conn[:pos]
+	connID, err := strconv.ParseUint(conn[pos+1:], 10, 64)
+	if err != nil {
+		writeError(w, errors.Errorf("invalid connection ID in conn %q", conn))
+		return
+	}
+	query := req.FormValue("query") == "true"
+
+	self, err := infosync.GetServerInfo()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if instance == statusAddr(self) {
+		if _, ok := s.GetProcessInfo(connID); !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, err = w.Write([]byte("unknown connection " + conn))
+			terror.Log(errors.Trace(err))
+			return
+		}
+		s.Kill(connID, query)
+		writeData(w, "success!")
+		return
+	}
+	s.forwardKill(req.Context(), w, instance, conn, query)
+}
+
+// forwardKill forwards the kill to the instance, which must be a TiDB instance of the cluster.
+func (s *Server) forwardKill(ctx context.Context, w http.ResponseWriter, instance, conn string, query bool) {
+	servers, err := infosync.GetAllServerInfo(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	found := false
+	for _, info := range servers {
+		if statusAddr(info) == instance {
+			found = true
+			break
+		}
+	}
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		_, err = w.Write([]byte("unknown instance " + instance))
+		terror.Log(errors.Trace(err))
+		return
+	}
+
+	params := url.Values{"conn": {conn}, "query": {strconv.FormatBool(query)}}
+	forwardURL := util.InternalHTTPSchema() + "://" + instance + "/kill"
+	resp, err := util.InternalHTTPClient().PostForm(forwardURL, params)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		_, err = w.Write([]byte(err.Error()))
+		terror.Log(errors.Trace(err))
+		return
+	}
+	defer func() {
+		terror.Log(resp.Body.Close())
+	}()
+	logutil.BgLogger().Info("forward kill", zap.String("conn", conn), zap.Bool("query", query),
+		zap.Int("status", resp.StatusCode))
+	w.Header().Set(headerContentType, resp.Header.Get(headerContentType))
+	w.WriteHeader(resp.StatusCode)
+	_, err = io.Copy(w, resp.Body)
+	terror.Log(errors.Trace(err))
+}
+
+// statusAddr returns the status address of the TiDB instance.
+func statusAddr(info *infosync.ServerInfo) string {
+	return info.IP + ":" + strconv.FormatUint(uint64(info.StatusPort), 10)
+}
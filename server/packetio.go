@@ -58,6 +58,9 @@ type packetIO struct {
 	bufWriter   *bufio.Writer
 	sequence    uint8
 	readTimeout time.Duration
+	// compressedIO is set if the compressed protocol is used, the packets are read and written through it.
+	compressedIO       *compressedIO
+	compressedSequence uint8
 }
 
 func newPacketIO(bufReadConn *bufferedReadConn) *packetIO {
@@ -75,6 +78,36 @@ func (p *packetIO) setReadTimeout(timeout time.Duration) {
 	p.readTimeout = timeout
 }
 
+// setCompression starts to use the compressed protocol with the compression algorithm.
+func (p *packetIO) setCompression(algorithm, zstdLevel int) error {
+	c, err := newCompressedIO(p, algorithm, zstdLevel)
+	if err != nil {
+		return err
+	}
+	p.compressedIO = c
+	return nil
+}
+
+// resetSequence resets the sequences at the beginning of a command.
+func (p *packetIO) resetSequence() {
+	p.sequence = 0
+	p.compressedSequence = 0
+}
+
+func (p *packetIO) reader() io.Reader {
+	if p.compressedIO != nil {
+		return p.compressedIO
+	}
+	return p.bufReadConn
+}
+
+func (p *packetIO) writer() io.Writer {
+	if p.compressedIO != nil {
+		return p.compressedIO
+	}
+	return p.bufWriter
+}
+
 func (p *packetIO) readOnePacket() ([]byte, error) {
 	var header [4]byte
 	if p.readTimeout > 0 {
@@ -82,17 +115,20 @@ func (p *packetIO) readOnePacket() ([]byte, error) {
 			return nil, err
 		}
 	}
-	if _, err := io.ReadFull(p.bufReadConn, header[:]); err != nil {
+	if _, err := io.ReadFull(p.reader(), header[:]); err != nil {
 		return nil, errors.Trace(err)
 	}
 
-	sequence := header[3]
-	if sequence != p.sequence {
-		return nil, errInvalidSequence.GenWithStack("invalid sequence %d != %d", sequence, p.sequence)
+	// With the compressed protocol, only the sequence of the compressed packets is checked, and the sequence of
+	// the packets is synced to it after the packet is read, which is the same as MySQL.
+	if p.compressedIO == nil {
+		sequence := header[3]
+		if sequence != p.sequence {
+			return nil, errInvalidSequence.GenWithStack("invalid sequence %d != %d", sequence, p.sequence)
+		}
+		p.sequence++
 	}
 
-	p.sequence++
-
 	length := int(uint32(header[0]) | uint32(header[1])<<8 | uint32(header[2])<<16)
 
 	data := make([]byte, length)
@@ -101,9 +137,12 @@ func (p *packetIO) readOnePacket() ([]byte, error) {
 			return nil, err
 		}
 	}
-	if _, err := io.ReadFull(p.bufReadConn, data); err != nil {
+	if _, err := io.ReadFull(p.reader(), data); err != nil {
 		return nil, errors.Trace(err)
 	}
+	if p.compressedIO != nil {
+		p.sequence = p.compressedSequence
+	}
 	return data, nil
 }
 
@@ -153,7 +192,7 @@ func (p *packetIO) writePacket(data []byte) error {
 
 		data[3] = p.sequence
 
-		if n, err := p.bufWriter.Write(data[:4+mysql.MaxPayloadLen]); err != nil {
+		if n, err := p.writer().Write(data[:4+mysql.MaxPayloadLen]); err != nil {
 			return errors.Trace(mysql.ErrBadConn)
 		} else if n != (4 + mysql.MaxPayloadLen) {
 			return errors.Trace(mysql.ErrBadConn)
@@ -169,7 +208,7 @@ func (p *packetIO) writePacket(data []byte) error {
 	data[2] = byte(length >> 16)
 	data[3] = p.sequence
 
-	if n, err := p.bufWriter.Write(data); err != nil {
+	if n, err := p.writer().Write(data); err != nil {
 		terror.Log(errors.Trace(err))
 		return errors.Trace(mysql.ErrBadConn)
 	} else if n != len(data) {
@@ -180,10 +219,71 @@ func (p *packetIO) writePacket(data []byte) error {
 	}
 }
 
+// writePacketPieces writes a packet whose payload is the concatenation of the pieces. Unlike writePacket, the
+// payload doesn't need to be copied into a single buffer, so the large values can be written from where they
+// are. The pieces are consumed by the call.
+func (p *packetIO) writePacketPieces(pieces [][]byte) error {
+	length := 0
+	for _, piece := range pieces {
+		length += len(piece)
+	}
+	writePacketBytes.Observe(float64(length + 4))
+
+	var header [4]byte
+	for {
+		size := length
+		if size > mysql.MaxPayloadLen {
+			size = mysql.MaxPayloadLen
+		}
+		header[0] = byte(size)
+		header[1] = byte(size >> 8)
+		header[2] = byte(size >> 16)
+		header[3] = p.sequence
+		if _, err := p.writer().Write(header[:]); err != nil {
+			terror.Log(errors.Trace(err))
+			return errors.Trace(mysql.ErrBadConn)
+		}
+		for remain := size; remain > 0; {
+			n := len(pieces[0])
+			if n > remain {
+				n = remain
+			}
+			if _, err := p.writer().Write(pieces[0][:n]); err != nil {
+				terror.Log(errors.Trace(err))
+				return errors.Trace(mysql.ErrBadConn)
+			}
+			pieces[0] = pieces[0][n:]
+			if len(pieces[0]) == 0 {
+				pieces = pieces[1:]
+			}
+			remain -= n
+		}
+		p.sequence++
+		length -= size
+		// A payload of MaxPayloadLen bytes is always followed by another packet, which may be empty.
+		if size < mysql.MaxPayloadLen {
+			return nil
+		}
+	}
+}
+
 func (p *packetIO) flush() error {
+	if p.compressedIO != nil {
+		if err := p.compressedIO.writeCompressedPackets(); err != nil {
+			return err
+		}
+		// The sequence of the packets is synced to the sequence of the compressed packets, the same as MySQL.
+		p.sequence = p.compressedSequence
+	}
 	err := p.bufWriter.Flush()
 	if err != nil {
 		return errors.Trace(err)
 	}
 	return err
 }
+
+func (p *packetIO) close() {
+	if p.compressedIO != nil {
+		p.compressedIO.close()
+	}
+}
@@ -19,7 +19,9 @@ import (
 	"net"
 	"time"
 
+	"github.com/klauspost/compress/zstd"
 	. "github.com/pingcap/check"
+	"github.com/pingcap/errors"
 	"github.com/pingcap/parser/mysql"
 )
 
@@ -53,6 +55,33 @@ func (s *PacketIOTestSuite) TestWrite(c *C) {
 	c.Assert(res[3], Equals, byte(0))
 }
 
+func (s *PacketIOTestSuite) TestWritePacketPieces(c *C) {
+	for _, size := range []int{0, 3, mysql.MaxPayloadLen - 1, mysql.MaxPayloadLen, mysql.MaxPayloadLen*2 + 5} {
+		payload := make([]byte, size)
+		for i := range payload {
+			payload[i] = byte(i)
+		}
+		var expected, outBuffer bytes.Buffer
+		pkt := &packetIO{bufWriter: bufio.NewWriter(&expected)}
+		c.Assert(pkt.writePacket(append(make([]byte, 4), payload...)), IsNil)
+		c.Assert(pkt.flush(), IsNil)
+
+		// The payload is split into the pieces which cross the boundaries of the packets.
+		var pieces [][]byte
+		for start := 0; start < size; start += 7 << 20 {
+			end := start + 7<<20
+			if end > size {
+				end = size
+			}
+			pieces = append(pieces, payload[start:end], nil)
+		}
+		pkt = &packetIO{bufWriter: bufio.NewWriter(&outBuffer)}
+		c.Assert(pkt.writePacketPieces(pieces), IsNil)
+		c.Assert(pkt.flush(), IsNil)
+		c.Assert(outBuffer.Bytes(), DeepEquals, expected.Bytes(), Commentf("size %d", size))
+	}
+}
+
 func (s *PacketIOTestSuite) TestRead(c *C) {
 	var inBuffer bytes.Buffer
 	_, err := inBuffer.Write([]byte{0x01, 0x00, 0x00, 0x00, 0x01})
@@ -89,6 +118,63 @@ func (s *PacketIOTestSuite) TestRead(c *C) {
 	c.Assert(bytes[mysql.MaxPayloadLen], DeepEquals, byte(0x0a))
 }
 
+func (s *PacketIOTestSuite) TestCompression(c *C) {
+	for _, algorithm := range []int{compressionZlib, compressionZstd} {
+		// The short payload isn't compressed.
+		var outBuffer bytes.Buffer
+		pkt := &packetIO{bufWriter: bufio.NewWriter(&outBuffer)}
+		c.Assert(pkt.setCompression(algorithm, defaultZstdLevel), IsNil)
+		c.Assert(pkt.writePacket([]byte{0x00, 0x00, 0x00, 0x00, 0x01, 0x02, 0x03}), IsNil)
+		c.Assert(pkt.flush(), IsNil)
+		c.Assert(outBuffer.Bytes(), DeepEquals, []byte{0x07, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, 0x02, 0x03})
+		c.Assert(pkt.sequence, Equals, uint8(1))
+		c.Assert(pkt.compressedSequence, Equals, uint8(1))
+
+		// The packets span the compressed packets.
+		outBuffer.Reset()
+		small := []byte("tidb")
+		large := bytes.Repeat([]byte("tidb"), mysql.MaxPayloadLen/2)
+		pkt = &packetIO{bufWriter: bufio.NewWriter(&outBuffer)}
+		c.Assert(pkt.setCompression(algorithm, defaultZstdLevel), IsNil)
+		c.Assert(pkt.writePacket(append(make([]byte, 4), small...)), IsNil)
+		c.Assert(pkt.writePacket(append(make([]byte, 4), large...)), IsNil)
+		c.Assert(pkt.flush(), IsNil)
+		c.Assert(outBuffer.Len() < len(large)/10, IsTrue)
+		c.Assert(pkt.sequence, Equals, pkt.compressedSequence)
+
+		brc := newBufferedReadConn(&bytesConn{outBuffer})
+		pkt = newPacketIO(brc)
+		c.Assert(pkt.setCompression(algorithm, defaultZstdLevel), IsNil)
+		data, err := pkt.readPacket()
+		c.Assert(err, IsNil)
+		c.Assert(data, DeepEquals, small)
+		data, err = pkt.readPacket()
+		c.Assert(err, IsNil)
+		c.Assert(bytes.Equal(data, large), IsTrue)
+		c.Assert(pkt.sequence, Equals, pkt.compressedSequence)
+		c.Assert(pkt.compressedIO.stats.readUncompressedBytes, Greater, pkt.compressedIO.stats.readBytes)
+		pkt.close()
+	}
+}
+
+func (s *PacketIOTestSuite) TestDecompressionBomb(c *C) {
+	// A small payload which is decompressed to a huge size is rejected.
+	encoder, err := zstd.NewWriter(nil)
+	c.Assert(err, IsNil)
+	payload := encoder.EncodeAll(make([]byte, 4*mysql.MaxPayloadLen), nil)
+	c.Assert(len(payload) < mysql.MaxPayloadLen, IsTrue)
+	header := []byte{byte(len(payload)), byte(len(payload) >> 8), byte(len(payload) >> 16), 0x00, 0x0a, 0x00, 0x00}
+	var inBuffer bytes.Buffer
+	inBuffer.Write(header)
+	inBuffer.Write(payload)
+
+	pkt := newPacketIO(newBufferedReadConn(&bytesConn{inBuffer}))
+	c.Assert(pkt.setCompression(compressionZstd, defaultZstdLevel), IsNil)
+	_, err = pkt.readPacket()
+	c.Assert(errors.Cause(err), Equals, zstd.ErrDecoderSizeExceeded)
+	pkt.close()
+}
+
 type bytesConn struct {
 	b bytes.Buffer
 }
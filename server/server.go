@@ -47,7 +47,6 @@ import (
 	"time"
 	"unsafe"
 
-	"github.com/blacktear23/go-proxyprotocol"
 	"github.com/pingcap/errors"
 	"github.com/pingcap/parser/mysql"
 	"github.com/pingcap/parser/terror"
@@ -60,9 +59,11 @@ import (
 	"github.com/pingcap/tidb/session/txninfo"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/audit"
 	"github.com/pingcap/tidb/util/dbterror"
 	"github.com/pingcap/tidb/util/fastrand"
 	"github.com/pingcap/tidb/util/logutil"
+	"github.com/pingcap/tidb/util/proxyprotocol"
 	"github.com/pingcap/tidb/util/sys/linux"
 	"github.com/pingcap/tidb/util/timeutil"
 	"go.uber.org/zap"
@@ -109,7 +110,8 @@ const defaultCapability = mysql.ClientLongPassword | mysql.ClientLongFlag |
 	mysql.ClientConnectWithDB | mysql.ClientProtocol41 |
 	mysql.ClientTransactions | mysql.ClientSecureConnection | mysql.ClientFoundRows |
 	mysql.ClientMultiStatements | mysql.ClientMultiResults | mysql.ClientLocalFiles |
-	mysql.ClientConnectAtts | mysql.ClientPluginAuth | mysql.ClientInteractive
+	mysql.ClientConnectAtts | mysql.ClientPluginAuth | mysql.ClientInteractive |
+	mysql.ClientCompress | clientZstdCompressionAlgorithm
 
 // Server is the MySQL protocol server
 type Server struct {
@@ -130,6 +132,10 @@ type Server struct {
 	statusServer   *http.Server
 	grpcServer     *grpc.Server
 	inShutdownMode bool
+	// statusVerifyClient indicates whether the clients of the status server are verified by cluster-verify-cn.
+	statusVerifyClient bool
+
+	drain drainState
 }
 
 // ConnectionCount gets current connection count.
@@ -171,7 +177,12 @@ func (s *Server) InitGlobalConnID(serverIDGetter func() uint64) {
 // It allocates a connection ID and random salt data for authentication.
 func (s *Server) newConn(conn net.Conn) *clientConn {
 	cc := newClientConn(s)
-	if tcpConn, ok := conn.(*net.TCPConn); ok {
+	rawConn := conn
+	if ppConn, ok := conn.(*proxyprotocol.Conn); ok {
+		cc.proxyConn = ppConn
+		rawConn = ppConn.Conn
+	}
+	if tcpConn, ok := rawConn.(*net.TCPConn); ok {
 		if err := tcpConn.SetKeepAlive(s.cfg.Performance.TCPKeepAlive); err != nil {
 			logutil.BgLogger().Error("failed to set tcp keep alive option", zap.Error(err))
 		}
@@ -342,40 +353,12 @@ func (s *Server) Run() error {
 				}
 			}
 
-			// If we got PROXY protocol error, we should continue accept.
-			if proxyprotocol.IsProxyProtocolError(err) {
-				logutil.BgLogger().Error("PROXY protocol failed", zap.Error(err))
-				continue
-			}
-
 			logutil.BgLogger().Error("accept failed", zap.Error(err))
 			return errors.Trace(err)
 		}
 
 		clientConn := s.newConn(conn)
 
-		err = plugin.ForeachPlugin(plugin.Audit, func(p *plugin.Plugin) error {
-			authPlugin := plugin.DeclareAuditManifest(p.Manifest)
-			if authPlugin.OnConnectionEvent != nil {
-				host, _, err := clientConn.PeerHost("")
-				if err != nil {
-					logutil.BgLogger().Error("get peer host failed", zap.Error(err))
-					terror.Log(clientConn.Close())
-					return errors.Trace(err)
-				}
-				err = authPlugin.OnConnectionEvent(context.Background(), plugin.PreAuth, &variable.ConnectionInfo{Host: host})
-				if err != nil {
-					logutil.BgLogger().Info("do connection event failed", zap.Error(err))
-					terror.Log(clientConn.Close())
-					return errors.Trace(err)
-				}
-			}
-			return nil
-		})
-		if err != nil {
-			continue
-		}
-
 		if s.dom != nil && s.dom.IsLostConnectionToPD() {
 			logutil.BgLogger().Warn("reject connection due to lost connection to PD")
 			terror.Log(clientConn.Close())
@@ -388,9 +371,14 @@ func (s *Server) Run() error {
 
 func (s *Server) startShutdown() {
 	s.rwlock.RLock()
+	inShutdownMode := s.inShutdownMode
 	logutil.BgLogger().Info("setting tidb-server to report unhealthy (shutting-down)")
 	s.inShutdownMode = true
 	s.rwlock.RUnlock()
+	// The server has reported unhealthy if it's drained.
+	if inShutdownMode {
+		return
+	}
 	// give the load balancer a chance to receive a few unhealthy health reports
 	// before acquiring the s.rwlock and blocking connections.
 	waitTime := time.Duration(s.cfg.GracefulWaitBeforeShutdown) * time.Second
@@ -406,16 +394,7 @@ func (s *Server) Close() {
 	s.rwlock.Lock() // prevent new connections
 	defer s.rwlock.Unlock()
 
-	if s.listener != nil {
-		err := s.listener.Close()
-		terror.Log(errors.Trace(err))
-		s.listener = nil
-	}
-	if s.socket != nil {
-		err := s.socket.Close()
-		terror.Log(errors.Trace(err))
-		s.socket = nil
-	}
+	s.closeListeners()
 	if s.statusServer != nil {
 		err := s.statusServer.Close()
 		terror.Log(errors.Trace(err))
@@ -428,10 +407,52 @@ func (s *Server) Close() {
 	metrics.ServerEventCounter.WithLabelValues(metrics.EventClose).Inc()
 }
 
+// closeListeners stops accepting new connections, s.rwlock must be held.
+func (s *Server) closeListeners() {
+	if s.listener != nil {
+		err := s.listener.Close()
+		terror.Log(errors.Trace(err))
+		s.listener = nil
+	}
+	if s.socket != nil {
+		err := s.socket.Close()
+		terror.Log(errors.Trace(err))
+		s.socket = nil
+	}
+}
+
 // onConn runs in its own goroutine, handles queries from this connection.
 func (s *Server) onConn(conn *clientConn) {
+	// The peer host may be read from the PROXY protocol header, which is read on the first use of the connection,
+	// so the PreAuth event is fired here rather than in the accept loop to not block it on a slow client.
+	err := plugin.ForeachPlugin(plugin.Audit, func(p *plugin.Plugin) error {
+		authPlugin := plugin.DeclareAuditManifest(p.Manifest)
+		if authPlugin.OnConnectionEvent != nil {
+			host, _, err := conn.PeerHost("")
+			if err != nil {
+				logutil.BgLogger().Error("get peer host failed", zap.Error(err))
+				return errors.Trace(err)
+			}
+			err = authPlugin.OnConnectionEvent(context.Background(), plugin.PreAuth, &variable.ConnectionInfo{Host: host})
+			if err != nil {
+				logutil.BgLogger().Info("do connection event failed", zap.Error(err))
+				return errors.Trace(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		terror.Log(conn.Close())
+		return
+	}
+
 	ctx := logutil.WithConnID(context.Background(), conn.connectionID)
 	if err := conn.handshake(ctx); err != nil {
+		// Only record the rejected connections which have sent the handshake response, the keep alive services below
+		// are not interesting.
+		if audit.Enabled() && len(conn.user) > 0 {
+			audit.LogConnection(audit.EventReject, conn.connectInfo(), err)
+		}
 		if plugin.IsEnable(plugin.Audit) && conn.ctx != nil {
 			conn.ctx.GetSessionVars().ConnectionInfo = conn.connectInfo()
 			err = plugin.ForeachPlugin(plugin.Audit, func(p *plugin.Plugin) error {
@@ -464,10 +485,11 @@ func (s *Server) onConn(conn *clientConn) {
 	metrics.ConnGauge.Set(float64(connections))
 
 	sessionVars := conn.ctx.GetSessionVars()
-	if plugin.IsEnable(plugin.Audit) {
+	if plugin.IsEnable(plugin.Audit) || audit.Enabled() {
 		sessionVars.ConnectionInfo = conn.connectInfo()
 	}
-	err := plugin.ForeachPlugin(plugin.Audit, func(p *plugin.Plugin) error {
+	audit.LogConnection(audit.EventConnect, sessionVars.ConnectionInfo, nil)
+	err = plugin.ForeachPlugin(plugin.Audit, func(p *plugin.Plugin) error {
 		authPlugin := plugin.DeclareAuditManifest(p.Manifest)
 		if authPlugin.OnConnectionEvent != nil {
 			return authPlugin.OnConnectionEvent(context.Background(), plugin.Connected, sessionVars.ConnectionInfo)
@@ -481,6 +503,14 @@ func (s *Server) onConn(conn *clientConn) {
 	connectedTime := time.Now()
 	conn.Run(ctx)
 
+	if audit.Enabled() {
+		if sessionVars.ConnectionInfo == nil {
+			sessionVars.ConnectionInfo = conn.connectInfo()
+		}
+		sessionVars.ConnectionInfo.Duration = float64(time.Since(connectedTime)) / float64(time.Millisecond)
+		audit.LogConnection(audit.EventDisconnect, sessionVars.ConnectionInfo, nil)
+	}
+
 	err = plugin.ForeachPlugin(plugin.Audit, func(p *plugin.Plugin) error {
 		// Audit plugin may be disabled before a conn is created, leading no connectionInfo in sessionVars.
 		if sessionVars.ConnectionInfo == nil {
@@ -640,11 +670,10 @@ func (s *Server) KillAllConnections() {
 	}
 }
 
-var gracefulCloseConnectionsTimeout = 15 * time.Second
-
 // TryGracefulDown will try to gracefully close all connection first with timeout. if timeout, will close all connection directly.
+// The timeout is the timeout of [drain] in the config.
 func (s *Server) TryGracefulDown() {
-	ctx, cancel := context.WithTimeout(context.Background(), gracefulCloseConnectionsTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.cfg.Drain.Timeout)*time.Second)
 	defer cancel()
 	done := make(chan struct{})
 	go func() {
@@ -686,6 +715,7 @@ func (s *Server) GracefulDown(ctx context.Context, done chan struct{}) {
 }
 
 func (s *Server) kickIdleConnection() {
+	saveSessionStates := s.cfg.Drain.SaveSessionStates && s.isDraining()
 	var conns []*clientConn
 	s.rwlock.RLock()
 	for _, cc := range s.clients {
@@ -697,6 +727,9 @@ func (s *Server) kickIdleConnection() {
 	s.rwlock.RUnlock()
 
 	for _, cc := range conns {
+		if saveSessionStates {
+			s.saveSessionState(cc)
+		}
 		err := cc.Close()
 		if err != nil {
 			logutil.BgLogger().Error("close connection", zap.Error(err))
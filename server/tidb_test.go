@@ -23,10 +23,13 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"database/sql"
+	"encoding/json"
 	"encoding/pem"
 	"fmt"
 	"math/big"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -41,10 +44,13 @@ import (
 	tmysql "github.com/pingcap/parser/mysql"
 	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/domain"
+	"github.com/pingcap/tidb/domain/infosync"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/metrics"
+	"github.com/pingcap/tidb/plugin"
 	"github.com/pingcap/tidb/session"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/store/driver/interceptor"
 	"github.com/pingcap/tidb/store/mockstore"
 	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/collate"
@@ -375,6 +381,47 @@ func newTLSHttpClient(c *C, caFile, certFile, keyFile string) *http.Client {
 	return &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
 }
 
+// runTestServerWithStatus runs a server with the status server on random ports, and sets the ports to the client.
+func (ts *tidbTestSuite) runTestServerWithStatus(c *C, cfg *config.Config, cli *testServerClient) *Server {
+	cfg.Port = 0
+	cfg.Status.StatusPort = 0
+	cfg.Status.ReportStatus = true
+	server, err := NewServer(cfg, NewTiDBDriver(ts.store))
+	c.Assert(err, IsNil)
+	cli.port = getPortFromTCPAddr(server.listener.Addr())
+	cli.statusPort = getPortFromTCPAddr(server.statusListener.Addr())
+	go func() {
+		err := server.Run()
+		c.Assert(err, IsNil)
+	}()
+	time.Sleep(time.Millisecond * 100)
+	return server
+}
+
+// runVerifiedStatusServer is like runTestServerWithStatus, but the status server only accepts the verified client. The
+// certificates of the CA, the server and the client are generated by the name, and the TLS client of the status
+// server is returned.
+func (ts *tidbTestSuite) runVerifiedStatusServer(c *C, name string, cfg *config.Config, cli *testServerClient) (*Server, *http.Client) {
+	caPath := filepath.Join(os.TempDir(), "ca-cert-"+name+".pem")
+	serverKeyPath := filepath.Join(os.TempDir(), "server-key-"+name+".pem")
+	serverCertPath := filepath.Join(os.TempDir(), "server-cert-"+name+".pem")
+	clientKeyPath := filepath.Join(os.TempDir(), "client-key-"+name+".pem")
+	clientCertPath := filepath.Join(os.TempDir(), "client-cert-"+name+".pem")
+	caCert, caKey, err := generateCert(0, "TiDB CA "+strings.ToUpper(name), nil, nil, filepath.Join(os.TempDir(), "ca-key-"+name+".pem"), caPath)
+	c.Assert(err, IsNil)
+	_, _, err = generateCert(1, "tidb-server-"+name, caCert, caKey, serverKeyPath, serverCertPath)
+	c.Assert(err, IsNil)
+	_, _, err = generateCert(2, "tidb-client-"+name, caCert, caKey, clientKeyPath, clientCertPath)
+	c.Assert(err, IsNil)
+
+	cli.statusScheme = "https"
+	cfg.Security.ClusterSSLCA = caPath
+	cfg.Security.ClusterSSLCert = serverCertPath
+	cfg.Security.ClusterSSLKey = serverKeyPath
+	cfg.Security.ClusterVerifyCN = []string{"tidb-client-" + name}
+	return ts.runTestServerWithStatus(c, cfg, cli), newTLSHttpClient(c, caPath, clientCertPath, clientKeyPath)
+}
+
 func (ts *tidbTestSuite) TestMultiStatements(c *C) {
 	c.Parallel()
 	ts.runFailedTestMultiStatements(c)
@@ -439,6 +486,68 @@ func (ts *tidbTestSuite) TestSocket(c *C) {
 
 }
 
+func (ts *tidbTestSerialSuite) TestPreAuthEventWithProxyProtocol(c *C) {
+	hosts := make(chan string, 1)
+	plugin.SetTestHook(func(p *plugin.Plugin, dir string, pluginID plugin.ID) (func() *plugin.Manifest, error) {
+		return func() *plugin.Manifest {
+			m := &plugin.AuditManifest{
+				Manifest: plugin.Manifest{
+					Kind:    plugin.Audit,
+					Name:    "preauth",
+					Version: 1,
+					OnInit: func(ctx context.Context, manifest *plugin.Manifest) error {
+						return nil
+					},
+					OnShutdown: func(ctx context.Context, manifest *plugin.Manifest) error {
+						return nil
+					},
+				},
+				OnConnectionEvent: func(ctx context.Context, event plugin.ConnectionEvent, info *variable.ConnectionInfo) error {
+					if event == plugin.PreAuth {
+						hosts <- info.Host
+					}
+					return nil
+				},
+			}
+			return plugin.ExportManifest(m)
+		}, nil
+	})
+	pluginCfg := plugin.Config{Plugins: []string{"preauth-1"}, PluginVarNames: &variable.PluginVarNames}
+	c.Assert(plugin.Load(context.Background(), pluginCfg), IsNil)
+	c.Assert(plugin.Init(context.Background(), pluginCfg), IsNil)
+	defer plugin.Shutdown(context.Background())
+
+	cfg := newTestConfig()
+	cfg.Port = 0
+	cfg.Status.ReportStatus = false
+	cfg.ProxyProtocol.Networks = "*"
+	cfg.ProxyProtocol.HeaderTimeout = 10
+	server, err := NewServer(cfg, ts.tidbdrv)
+	c.Assert(err, IsNil)
+	go func() {
+		err := server.Run()
+		c.Assert(err, IsNil)
+	}()
+	defer server.Close()
+	addr := server.listener.Addr().String()
+
+	// The client which doesn't send the PROXY header doesn't block the following clients.
+	slow, err := net.Dial("tcp", addr)
+	c.Assert(err, IsNil)
+	defer slow.Close()
+	conn, err := net.Dial("tcp", addr)
+	c.Assert(err, IsNil)
+	defer conn.Close()
+	_, err = conn.Write([]byte("PROXY TCP4 192.168.1.1 127.0.0.1 5000 4000\r\n"))
+	c.Assert(err, IsNil)
+	select {
+	case host := <-hosts:
+		c.Assert(host, Equals, "192.168.1.1")
+	case <-time.After(5 * time.Second):
+		c.Fatal("the PreAuth event is blocked by the slow client")
+	}
+}
+
 // generateCert generates a private key and a certificate in PEM format based on parameters.
 // If parentCert and parentCertKey is specified, the new certificate will be signed by the parentCert.
 // Otherwise, the new certificate will be self-signed and is a CA.
@@ -1111,6 +1220,223 @@ func (ts *tidbTestSuite) TestGracefulShutdown(c *C) {
 	c.Assert(err, ErrorMatches, ".*connect: connection refused")
 }
 
+func (ts *tidbTestSuite) TestDrain(c *C) {
+	cli := newTestServerClient()
+	cfg := newTestConfig()
+	cfg.Drain.Timeout = 10
+	cfg.Drain.SaveSessionStates = true
+	server, hc := ts.runVerifiedStatusServer(c, "drain", cfg, cli)
+	defer server.Close()
+
+	fetchDrainStatus := func() *drainStatus {
+		resp, err := hc.Get(cli.statusURL("/drain"))
+		c.Assert(err, IsNil)
+		defer resp.Body.Close()
+		st := &drainStatus{}
+		c.Assert(json.NewDecoder(resp.Body).Decode(st), IsNil)
+		return st
+	}
+	// waitDrainStatus polls the drain status until it satisfies the condition or the deadline is exceeded.
+	waitDrainStatus := func(cond func(st *drainStatus) bool) *drainStatus {
+		deadline := time.Now().Add(10 * time.Second)
+		for {
+			st := fetchDrainStatus()
+			if cond(st) || time.Now().After(deadline) {
+				return st
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	c.Assert(fetchDrainStatus().Draining, IsFalse)
+
+	ctx := context.Background()
+	db, err := sql.Open("mysql", cli.getDSN())
+	c.Assert(err, IsNil)
+	defer db.Close()
+	idle, err := db.Conn(ctx)
+	c.Assert(err, IsNil)
+	_, err = idle.ExecContext(ctx, "set @@session.tidb_distsql_scan_concurrency = 5, @a = 'x'")
+	c.Assert(err, IsNil)
+	_, err = idle.ExecContext(ctx, "prepare stmt from 'select ?'")
+	c.Assert(err, IsNil)
+	active, err := db.Conn(ctx)
+	c.Assert(err, IsNil)
+	_, err = active.ExecContext(ctx, "begin")
+	c.Assert(err, IsNil)
+
+	resp, err := hc.Post(cli.statusURL("/drain"), "application/json", nil)
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	c.Assert(resp.Body.Close(), IsNil)
+	st := waitDrainStatus(func(st *drainStatus) bool {
+		return st.Connections == 1 && len(st.SessionStates) == 1
+	})
+	c.Assert(st.Draining, IsTrue)
+	c.Assert(st.Drained, IsFalse)
+	c.Assert(st.Connections, Equals, 1)
+	c.Assert(st.ActiveTxns, Equals, 1)
+	c.Assert(st.SessionStates, HasLen, 1)
+	state := st.SessionStates[0]
+	c.Assert(state.User, Equals, "root")
+	c.Assert(state.DB, Equals, "test")
+	c.Assert(state.SystemVars[variable.TiDBDistSQLScanConcurrency], Equals, "5")
+	c.Assert(state.UserVars["a"], Equals, "x")
+	c.Assert(state.PreparedStmts, HasLen, 1)
+	c.Assert(state.PreparedStmts[0].Name, Equals, "stmt")
+	c.Assert(state.PreparedStmts[0].SQL, Equals, "select ?")
+
+	// The server reports unhealthy and doesn't accept new connections, but the transaction can go on.
+	resp, err = hc.Get(cli.statusURL("/status"))
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusInternalServerError)
+	c.Assert(resp.Body.Close(), IsNil)
+	_, err = db.Conn(ctx)
+	c.Assert(err, NotNil)
+	_, err = active.ExecContext(ctx, "commit")
+	c.Assert(err, IsNil)
+	st = waitDrainStatus(func(st *drainStatus) bool {
+		return st.Drained
+	})
+	c.Assert(st.Drained, IsTrue)
+	c.Assert(st.Connections, Equals, 0)
+}
+
+func (ts *tidbTestSuite) TestDrainWithoutVerifiedClient(c *C) {
+	cli := newTestServerClient()
+	server := ts.runTestServerWithStatus(c, newTestConfig(), cli)
+	defer server.Close()
+
+	// The server can't be drained if the clients of the status server aren't verified.
+	resp, err := cli.postStatus("/drain", "application/json", nil)
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusForbidden)
+	c.Assert(resp.Body.Close(), IsNil)
+	resp, err = cli.fetchStatus("/drain")
+	c.Assert(err, IsNil)
+	st := &drainStatus{}
+	c.Assert(json.NewDecoder(resp.Body).Decode(st), IsNil)
+	c.Assert(resp.Body.Close(), IsNil)
+	c.Assert(st.Draining, IsFalse)
+	c.Assert(server.isDraining(), IsFalse)
+}
+
+func (ts *tidbTestSuite) TestKill(c *C) {
+	cli := newTestServerClient()
+	server, hc := ts.runVerifiedStatusServer(c, "kill", newTestConfig(), cli)
+	defer server.Close()
+
+	ctx := context.Background()
+	db, err := sql.Open("mysql", cli.getDSN())
+	c.Assert(err, IsNil)
+	defer db.Close()
+	conn, err := db.Conn(ctx)
+	c.Assert(err, IsNil)
+	var connID uint64
+	c.Assert(conn.QueryRowContext(ctx, "select connection_id()").Scan(&connID), IsNil)
+	info, err := infosync.GetServerInfo()
+	c.Assert(err, IsNil)
+	instance := statusAddr(info)
+
+	postKill := func(conn string) int {
+		resp, err := hc.PostForm(cli.statusURL("/kill"), url.Values{"conn": {conn}})
+		c.Assert(err, IsNil)
+		c.Assert(resp.Body.Close(), IsNil)
+		return resp.StatusCode
+	}
+	c.Assert(postKill(fmt.Sprintf("%s:%d", instance, connID+1000)), Equals, http.StatusNotFound)
+	c.Assert(postKill(fmt.Sprintf("%s:x", instance)), Equals, http.StatusBadRequest)
+	// The kills aren't forwarded to the instances out of the cluster.
+	c.Assert(postKill(fmt.Sprintf("127.0.0.1:1:%d", connID)), Equals, http.StatusNotFound)
+	resp, err := hc.Get(cli.statusURL("/kill"))
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusMethodNotAllowed)
+	c.Assert(resp.Body.Close(), IsNil)
+	_, err = conn.ExecContext(ctx, "select 1")
+	c.Assert(err, IsNil)
+
+	// The idle connection is closed when it reads the next command.
+	c.Assert(postKill(fmt.Sprintf("%s:%d", instance, connID)), Equals, http.StatusOK)
+	_, err = conn.ExecContext(ctx, "select 1")
+	c.Assert(err, NotNil)
+	conn.Close()
+}
+
+func (ts *tidbTestSuite) TestKillWithoutVerifiedClient(c *C) {
+	cli := newTestServerClient()
+	server := ts.runTestServerWithStatus(c, newTestConfig(), cli)
+	defer server.Close()
+
+	info, err := infosync.GetServerInfo()
+	c.Assert(err, IsNil)
+	resp, err := cli.postStatus("/kill", "application/x-www-form-urlencoded",
+		strings.NewReader(url.Values{"conn": {statusAddr(info) + ":1"}}.Encode()))
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusForbidden)
+	c.Assert(resp.Body.Close(), IsNil)
+}
+
+func (ts *tidbTestSuite) TestKVChaos(c *C) {
+	cli := newTestServerClient()
+	server, hc := ts.runVerifiedStatusServer(c, "chaos", newTestConfig(), cli)
+	defer server.Close()
+	defer interceptor.SetChaos(nil)
+
+	db, err := sql.Open("mysql", cli.getDSN())
+	c.Assert(err, IsNil)
+	defer db.Close()
+	dbt := &DBTest{c, db}
+	dbt.mustExec("create table kv_chaos (a int)")
+	defer dbt.mustExec("drop table kv_chaos")
+
+	postChaos := func(form url.Values) int {
+		resp, err := hc.PostForm(cli.statusURL("/chaos/kv"), form)
+		c.Assert(err, IsNil)
+		c.Assert(resp.Body.Close(), IsNil)
+		return resp.StatusCode
+	}
+	c.Assert(postChaos(url.Values{"db": {"test"}, "table": {"kv_chaos"}, "percent": {"50"}, "latency": {"10ms"}}), Equals, http.StatusOK)
+	cfg := interceptor.GetChaos()
+	c.Assert(cfg, NotNil)
+	c.Assert(cfg.TableIDs, HasLen, 1)
+	c.Assert(cfg.Percent, Equals, 50)
+	c.Assert(cfg.Latency, Equals, 10*time.Millisecond)
+	c.Assert(cfg.RegionError, IsFalse)
+
+	resp, err := hc.Get(cli.statusURL("/chaos/kv"))
+	c.Assert(err, IsNil)
+	var got interceptor.ChaosConfig
+	c.Assert(json.NewDecoder(resp.Body).Decode(&got), IsNil)
+	c.Assert(resp.Body.Close(), IsNil)
+	c.Assert(got, DeepEquals, *cfg)
+
+	// The queries still succeed with the injected latency.
+	rows := dbt.mustQuery("select count(*) from kv_chaos")
+	c.Assert(rows.Next(), IsTrue)
+	c.Assert(rows.Close(), IsNil)
+
+	c.Assert(postChaos(url.Values{"percent": {"101"}}), Equals, http.StatusBadRequest)
+	c.Assert(postChaos(url.Values{"enabled": {"false"}}), Equals, http.StatusOK)
+	c.Assert(interceptor.GetChaos(), IsNil)
+}
+
+func (ts *tidbTestSuite) TestKVChaosWithoutVerifiedClient(c *C) {
+	cli := newTestServerClient()
+	server := ts.runTestServerWithStatus(c, newTestConfig(), cli)
+	defer server.Close()
+
+	// The chaos can't be set if the clients of the status server aren't verified, but it can be shown.
+	resp, err := cli.postStatus("/chaos/kv", "application/x-www-form-urlencoded",
+		strings.NewReader(url.Values{"latency": {"1s"}}.Encode()))
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusForbidden)
+	c.Assert(resp.Body.Close(), IsNil)
+	c.Assert(interceptor.GetChaos(), IsNil)
+	resp, err = cli.fetchStatus("/chaos/kv")
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	c.Assert(resp.Body.Close(), IsNil)
+}
+
 func (ts *tidbTestSerialSuite) TestDefaultCharacterAndCollation(c *C) {
 	// issue #21194
 	collate.SetNewCollationEnabledForTest(true)
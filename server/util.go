@@ -230,7 +230,58 @@ func dumpBinaryDateTime(data []byte, t types.Time) []byte {
 	return data
 }
 
-func dumpBinaryRow(buffer []byte, columns []*ColumnInfo, row chunk.Row) ([]byte, error) {
+// largeValueSize is the size from which the string values are left out of the row buffer and written to the
+// connection directly, so the large values in the chunks are not copied again.
+const largeValueSize = 64 * 1024
+
+// largeValues records the large values left out of a dumped row, values[i] follows buffer[:offsets[i]] in the
+// packet of the row.
+type largeValues struct {
+	offsets []int
+	values  [][]byte
+}
+
+func (lv *largeValues) reset() {
+	lv.offsets = lv.offsets[:0]
+	lv.values = lv.values[:0]
+}
+
+// dumpLengthEncodedString works like the function dumpLengthEncodedString, except that the large value is left
+// out of the buffer and recorded in lv. All the values are dumped into the buffer if lv is nil.
+func (lv *largeValues) dumpLengthEncodedString(buffer []byte, bytes []byte) []byte {
+	if lv == nil || len(bytes) < largeValueSize {
+		return dumpLengthEncodedString(buffer, bytes)
+	}
+	buffer = dumpLengthEncodedInt(buffer, uint64(len(bytes)))
+	lv.offsets = append(lv.offsets, len(buffer))
+	lv.values = append(lv.values, bytes)
+	return buffer
+}
+
+// payloadSize returns the size of the payload of the packet of the row, including the large values.
+func (lv *largeValues) payloadSize(buffer []byte) int {
+	size := len(buffer) - 4
+	for _, value := range lv.values {
+		size += len(value)
+	}
+	return size
+}
+
+// pieces returns the payload of the packet of the row as the pieces of buffer and the large values, buffer[:4]
+// is reserved for the packet header.
+func (lv *largeValues) pieces(buffer []byte) [][]byte {
+	pieces := make([][]byte, 0, 2*len(lv.values)+1)
+	start := 4
+	for i, offset := range lv.offsets {
+		pieces = append(pieces, buffer[start:offset], lv.values[i])
+		start = offset
+	}
+	return append(pieces, buffer[start:])
+}
+
+// dumpBinaryRow dumps the row in the binary protocol, the large string values are recorded in lv instead of
+// being dumped if lv isn't nil.
+func dumpBinaryRow(buffer []byte, columns []*ColumnInfo, row chunk.Row, lv *largeValues) ([]byte, error) {
 	buffer = append(buffer, mysql.OKHeader)
 	nullBitmapOff := len(buffer)
 	numBytes4Null := (len(columns) + 7 + 2) / 8
@@ -261,7 +312,7 @@ func dumpBinaryRow(buffer []byte, columns []*ColumnInfo, row chunk.Row) ([]byte,
 			buffer = dumpLengthEncodedString(buffer, hack.Slice(row.GetMyDecimal(i).String()))
 		case mysql.TypeString, mysql.TypeVarString, mysql.TypeVarchar, mysql.TypeBit,
 			mysql.TypeTinyBlob, mysql.TypeMediumBlob, mysql.TypeLongBlob, mysql.TypeBlob:
-			buffer = dumpLengthEncodedString(buffer, row.GetBytes(i))
+			buffer = lv.dumpLengthEncodedString(buffer, row.GetBytes(i))
 		case mysql.TypeDate, mysql.TypeDatetime, mysql.TypeTimestamp:
 			buffer = dumpBinaryDateTime(buffer, row.GetTime(i))
 		case mysql.TypeDuration:
@@ -279,7 +330,9 @@ func dumpBinaryRow(buffer []byte, columns []*ColumnInfo, row chunk.Row) ([]byte,
 	return buffer, nil
 }
 
-func dumpTextRow(buffer []byte, columns []*ColumnInfo, row chunk.Row) ([]byte, error) {
+// dumpTextRow dumps the row in the text protocol, the large string values are recorded in lv instead of being
+// dumped if lv isn't nil.
+func dumpTextRow(buffer []byte, columns []*ColumnInfo, row chunk.Row, lv *largeValues) ([]byte, error) {
 	tmp := make([]byte, 0, 20)
 	for i, col := range columns {
 		if row.IsNull(i) {
@@ -324,7 +377,7 @@ func dumpTextRow(buffer []byte, columns []*ColumnInfo, row chunk.Row) ([]byte, e
 			buffer = dumpLengthEncodedString(buffer, hack.Slice(row.GetMyDecimal(i).String()))
 		case mysql.TypeString, mysql.TypeVarString, mysql.TypeVarchar, mysql.TypeBit,
 			mysql.TypeTinyBlob, mysql.TypeMediumBlob, mysql.TypeLongBlob, mysql.TypeBlob:
-			buffer = dumpLengthEncodedString(buffer, row.GetBytes(i))
+			buffer = lv.dumpLengthEncodedString(buffer, row.GetBytes(i))
 		case mysql.TypeDate, mysql.TypeDatetime, mysql.TypeTimestamp:
 			buffer = dumpLengthEncodedString(buffer, hack.Slice(row.GetTime(i).String()))
 		case mysql.TypeDuration:
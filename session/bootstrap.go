@@ -177,6 +177,7 @@ const (
 		table_id 		BIGINT(64) NOT NULL,
 		modify_count	BIGINT(64) NOT NULL DEFAULT 0,
 		count 			BIGINT(64) UNSIGNED NOT NULL DEFAULT 0,
+		sample_rate 	DOUBLE NOT NULL DEFAULT 0,
 		INDEX idx_ver(version),
 		UNIQUE INDEX tbl(table_id)
 	);`
@@ -330,6 +331,51 @@ const (
 		LAST_USED_AT timestamp,
 		PRIMARY KEY(TABLE_ID, INDEX_ID)
 	);`
+	// CreateTableTrafficTable stores the per-table traffic accumulated by all the TiDB instances.
+	CreateTableTrafficTable = `CREATE TABLE IF NOT EXISTS mysql.table_traffic (
+		table_id 	BIGINT(64) NOT NULL,
+		read_keys 	BIGINT(64) NOT NULL DEFAULT 0,
+		read_bytes 	BIGINT(64) NOT NULL DEFAULT 0,
+		write_keys 	BIGINT(64) NOT NULL DEFAULT 0,
+		write_bytes 	BIGINT(64) NOT NULL DEFAULT 0,
+		update_time 	TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY(table_id)
+	);`
+	// CreateStmtSummaryHistoryTable stores the statement summaries of the expired intervals persisted by all the
+	// TiDB instances. The times are in nanoseconds, the same as information_schema.statements_summary_history.
+	CreateStmtSummaryHistoryTable = `CREATE TABLE IF NOT EXISTS mysql.statements_summary_history (
+		instance 		VARCHAR(64) NOT NULL,
+		summary_begin_time 	TIMESTAMP NOT NULL,
+		summary_end_time 	TIMESTAMP NOT NULL,
+		stmt_type 		VARCHAR(64) NOT NULL,
+		schema_name 		VARCHAR(64) DEFAULT NULL,
+		digest 			VARCHAR(64) NOT NULL,
+		digest_text 		TEXT NOT NULL,
+		table_names 		TEXT DEFAULT NULL,
+		plan_digest 		VARCHAR(64) DEFAULT NULL,
+		sample_user 		VARCHAR(64) DEFAULT NULL,
+		exec_count 		BIGINT(64) UNSIGNED NOT NULL,
+		sum_errors 		INT(11) UNSIGNED NOT NULL,
+		sum_warnings 		INT(11) UNSIGNED NOT NULL,
+		sum_latency 		BIGINT(64) UNSIGNED NOT NULL,
+		max_latency 		BIGINT(64) UNSIGNED NOT NULL,
+		min_latency 		BIGINT(64) UNSIGNED NOT NULL,
+		p50_latency 		BIGINT(64) UNSIGNED NOT NULL,
+		p95_latency 		BIGINT(64) UNSIGNED NOT NULL,
+		p99_latency 		BIGINT(64) UNSIGNED NOT NULL,
+		sum_cop_time 		BIGINT(64) UNSIGNED NOT NULL,
+		max_cop_time 		BIGINT(64) UNSIGNED NOT NULL,
+		sum_mpp_time 		BIGINT(64) UNSIGNED NOT NULL,
+		max_mpp_time 		BIGINT(64) UNSIGNED NOT NULL,
+		max_mem 		BIGINT(64) UNSIGNED NOT NULL,
+		max_disk 		BIGINT(64) UNSIGNED NOT NULL,
+		sum_affected_rows 	BIGINT(64) UNSIGNED NOT NULL,
+		first_seen 		TIMESTAMP NOT NULL,
+		last_seen 		TIMESTAMP NOT NULL,
+		query_sample_text 	TEXT DEFAULT NULL,
+		INDEX idx_end_time(summary_end_time),
+		INDEX idx_digest(digest, plan_digest)
+	);`
 	// CreateGlobalGrantsTable stores dynamic privs
 	CreateGlobalGrantsTable = `CREATE TABLE IF NOT EXISTS mysql.global_grants (
 		USER char(32) NOT NULL DEFAULT '',
@@ -338,6 +384,17 @@ const (
 		WITH_GRANT_OPTION enum('N','Y') NOT NULL DEFAULT 'N',
 		PRIMARY KEY (USER,HOST,PRIV)
 	  );`
+	// CreateSecurityPolicyTable stores the row-level security policies. The predicate of a policy is AND-ed into the
+	// conditions on its table for the user or role, or for all the users if USER is empty.
+	CreateSecurityPolicyTable = `CREATE TABLE IF NOT EXISTS mysql.security_policy (
+		USER char(32) NOT NULL DEFAULT '',
+		HOST char(255) NOT NULL DEFAULT '',
+		NAME varchar(64) NOT NULL,
+		TABLE_SCHEMA varchar(64) NOT NULL,
+		TABLE_NAME varchar(64) NOT NULL,
+		PREDICATE text NOT NULL,
+		PRIMARY KEY (TABLE_SCHEMA,TABLE_NAME,NAME)
+	  );`
 )
 
 // bootstrap initiates system DB for a store.
@@ -492,11 +549,19 @@ const (
 	version69 = 69
 	// version70 adds mysql.user.plugin to allow multiple authentication plugins
 	version70 = 70
+	// version71 adds mysql.table_traffic to persist the per-table traffic.
+	version71 = 71
+	// version72 adds mysql.statements_summary_history to persist the statement summaries.
+	version72 = 72
+	// version73 adds mysql.security_policy for the row-level security policies.
+	version73 = 73
+	// version74 adds column sample_rate for mysql.stats_meta.
+	version74 = 74
 )
 
 // currentBootstrapVersion is defined as a variable, so we can modify its value for testing.
 // please make sure this is the largest version
-var currentBootstrapVersion int64 = version70
+var currentBootstrapVersion int64 = version74
 
 var (
 	bootstrapVersion = []func(Session, int64){
@@ -570,6 +635,10 @@ var (
 		upgradeToVer68,
 		upgradeToVer69,
 		upgradeToVer70,
+		upgradeToVer71,
+		upgradeToVer72,
+		upgradeToVer73,
+		upgradeToVer74,
 	}
 )
 
@@ -1507,6 +1576,34 @@ func upgradeToVer70(s Session, ver int64) {
 	mustExecute(s, "UPDATE HIGH_PRIORITY mysql.user SET plugin='mysql_native_password'")
 }
 
+func upgradeToVer71(s Session, ver int64) {
+	if ver >= version71 {
+		return
+	}
+	doReentrantDDL(s, CreateTableTrafficTable)
+}
+
+func upgradeToVer72(s Session, ver int64) {
+	if ver >= version72 {
+		return
+	}
+	doReentrantDDL(s, CreateStmtSummaryHistoryTable)
+}
+
+func upgradeToVer73(s Session, ver int64) {
+	if ver >= version73 {
+		return
+	}
+	doReentrantDDL(s, CreateSecurityPolicyTable)
+}
+
+func upgradeToVer74(s Session, ver int64) {
+	if ver >= version74 {
+		return
+	}
+	doReentrantDDL(s, "ALTER TABLE mysql.stats_meta ADD COLUMN `sample_rate` DOUBLE NOT NULL DEFAULT 0 AFTER `count`", infoschema.ErrColumnExists)
+}
+
 func writeOOMAction(s Session) {
 	comment := "oom-action is `log` by default in v3.0.x, `cancel` by default in v4.0.11+"
 	mustExecute(s, `INSERT HIGH_PRIORITY INTO %n.%n VALUES (%?, %?, %?) ON DUPLICATE KEY UPDATE VARIABLE_VALUE= %?`,
@@ -1585,6 +1682,12 @@ func doDDLWorks(s Session) {
 	mustExecute(s, CreateStatsFMSketchTable)
 	// Create global_grants
 	mustExecute(s, CreateGlobalGrantsTable)
+	// Create table_traffic.
+	mustExecute(s, CreateTableTrafficTable)
+	// Create statements_summary_history.
+	mustExecute(s, CreateStmtSummaryHistoryTable)
+	// Create security_policy.
+	mustExecute(s, CreateSecurityPolicyTable)
 }
 
 // doDMLWorks executes DML statements in bootstrap stage.
@@ -1143,6 +1143,23 @@ func (s *testPessimisticSuite) TestPessimisticReadCommitted(c *C) {
 	tk.MustExec("commit;")
 }
 
+func (s *testPessimisticSuite) TestRCTSCacheWithLocalCommits(c *C) {
+	tk := testkit.NewTestKitWithInit(c, s.store)
+	tk1 := testkit.NewTestKitWithInit(c, s.store)
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (id int primary key, v int)")
+	tk.MustExec("set tx_isolation = 'READ-COMMITTED'")
+	tk.MustExec(fmt.Sprintf("set tidb_rc_ts_cache_window = %d", variable.MaxRCTSCacheWindow))
+
+	// The cached TS isn't reused after a transaction is committed by the instance.
+	tk.MustExec("begin pessimistic")
+	tk.MustQuery("select * from t").Check(testkit.Rows())
+	tk.MustQuery("select * from t").Check(testkit.Rows())
+	tk1.MustExec("insert into t values (1, 1)")
+	tk.MustQuery("select * from t").Check(testkit.Rows("1 1"))
+	tk.MustExec("commit")
+}
+
 func (s *testPessimisticSuite) TestPessimisticLockNonExistsKey(c *C) {
 	tk := testkit.NewTestKitWithInit(c, s.store)
 	tk1 := testkit.NewTestKitWithInit(c, s.store)
@@ -2596,3 +2613,47 @@ func (s *testPessimisticSuite) TestAsyncCommitCalTSFail(c *C) {
 	tk2.MustExec("update tk set c2 = c2 + 1")
 	tk2.MustExec("commit")
 }
+
+func (s *testPessimisticSuite) TestPipelinedLock(c *C) {
+	tk := testkit.NewTestKitWithInit(c, s.store)
+	tk2 := testkit.NewTestKitWithInit(c, s.store)
+	tk.MustExec("drop table if exists pipelined")
+	tk.MustExec("create table pipelined (id int primary key, k int, v int, unique key uk(k))")
+	values := make([]string, 0, 100)
+	for i := 0; i < 100; i++ {
+		values = append(values, fmt.Sprintf("(%d, %d, %d)", i, i, i))
+	}
+	tk.MustExec("insert into pipelined values " + strings.Join(values, ","))
+	tk.MustExec("set tidb_enable_pipelined_pessimistic_lock = 1")
+	tk.MustExec("set tidb_max_chunk_size = 32")
+
+	// The rows are locked in several batches, and the unique index keys are locked after the execution.
+	tk.MustExec("begin pessimistic")
+	tk.MustExec("update pipelined set v = v + 1, k = k + 1000")
+	tk2.MustExec("begin pessimistic")
+	for _, id := range []int{0, 50, 99} {
+		err := tk2.ExecToErr(fmt.Sprintf("select * from pipelined where id = %d for update nowait", id))
+		c.Assert(err, NotNil)
+	}
+	err := tk2.ExecToErr("select * from pipelined where k = 1050 for update nowait")
+	c.Assert(err, NotNil)
+	tk2.MustExec("rollback")
+	tk.MustExec("commit")
+	tk.MustQuery("select count(*), sum(v) from pipelined").Check(testkit.Rows("100 5050"))
+
+	// The rows updated by others after the transaction begins are updated based on the latest values.
+	tk.MustExec("begin pessimistic")
+	tk.MustQuery("select sum(v) from pipelined").Check(testkit.Rows("5050"))
+	tk2.MustExec("update pipelined set v = v + 1 where id = 60")
+	tk.MustExec("update pipelined set v = v + 1 where k >= 1000")
+	tk.MustExec("commit")
+	tk.MustQuery("select sum(v) from pipelined").Check(testkit.Rows("5151"))
+
+	// The statement which reads the membuffer without its lock doesn't lock the keys asynchronously.
+	tk.MustExec("begin pessimistic")
+	tk.MustExec("update pipelined set v = 0 where id in (1, 2)")
+	tk2.MustExec("begin pessimistic")
+	c.Assert(tk2.ExecToErr("select * from pipelined where id = 1 for update nowait"), NotNil)
+	tk2.MustExec("rollback")
+	tk.MustExec("rollback")
+}
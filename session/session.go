@@ -41,6 +41,7 @@ import (
 	"github.com/pingcap/parser/model"
 	"github.com/pingcap/parser/mysql"
 	"github.com/pingcap/parser/terror"
+	"github.com/pingcap/tidb/util/audit"
 	"github.com/pingcap/tidb/util/topsql"
 	"github.com/pingcap/tipb/go-binlog"
 	"go.uber.org/zap"
@@ -81,6 +82,7 @@ import (
 	"github.com/pingcap/tidb/util/logutil"
 	"github.com/pingcap/tidb/util/sli"
 	"github.com/pingcap/tidb/util/sqlexec"
+	"github.com/pingcap/tidb/util/tabletraffic"
 	"github.com/pingcap/tidb/util/timeutil"
 	"github.com/tikv/client-go/v2/tikv"
 	tikvutil "github.com/tikv/client-go/v2/util"
@@ -132,6 +134,12 @@ type Session interface {
 	String() string // String is used to debug.
 	CommitTxn(context.Context) error
 	RollbackTxn(context.Context)
+	// Savepoint sets a named savepoint in the current transaction.
+	Savepoint(name string) error
+	// RollbackToSavepoint discards the changes after the named savepoint without ending the current transaction.
+	RollbackToSavepoint(name string) error
+	// ReleaseSavepoint removes the named savepoint and the savepoints set after it.
+	ReleaseSavepoint(name string) error
 	// PrepareStmt executes prepare statement in binary protocol.
 	PrepareStmt(sql string) (stmtID uint32, paramCount int, fields []*ast.ResultField, err error)
 	// ExecutePreparedStmt executes a prepared statement.
@@ -467,6 +475,9 @@ func (s *session) doCommit(ctx context.Context) error {
 		s.txn.changeToInvalid()
 		s.sessionVars.SetInTxn(false)
 	}()
+	if len(s.txn.savepoints) > 0 {
+		s.txn.releaseSavepoint(0)
+	}
 	if s.txn.IsReadOnly() {
 		return nil
 	}
@@ -525,6 +536,7 @@ func (s *session) doCommit(ctx context.Context) error {
 	}
 	s.txn.SetOption(kv.EnableAsyncCommit, s.GetSessionVars().EnableAsyncCommit)
 	s.txn.SetOption(kv.Enable1PC, s.GetSessionVars().Enable1PC)
+	s.txn.SetOption(kv.AsyncCommitRegionsLimit, s.GetSessionVars().AsyncCommitRegionsLimit)
 	// priority of the sysvar is lower than `start transaction with causal consistency only`
 	if val := s.txn.GetOption(kv.GuaranteeLinearizability); val == nil || val.(bool) {
 		// We needn't ask the TiKV client to guarantee linearizability for auto-commit transactions
@@ -536,7 +548,46 @@ func (s *session) doCommit(ctx context.Context) error {
 			s.GetSessionVars().TxnCtx.IsExplicit && s.GetSessionVars().GuaranteeLinearizability)
 	}
 
-	return s.txn.Commit(tikvutil.SetSessionID(ctx, s.GetSessionVars().ConnectionID))
+	traffic, err := s.writeTraffic()
+	if err != nil {
+		return err
+	}
+	if err = s.txn.Commit(tikvutil.SetSessionID(ctx, s.GetSessionVars().ConnectionID)); err != nil {
+		return err
+	}
+	for _, t := range traffic {
+		tabletraffic.RecordWrite(t.TableID, t.WriteKeys, t.WriteBytes)
+	}
+	return nil
+}
+
+// writeTraffic returns the keys and bytes written by the transaction for each physical table if
+// tidb_enable_table_write_traffic is on. The keys in the membuffer are ordered, so the keys of a table are adjacent.
+// The keys out of the tables, like the meta keys, are not counted.
+func (s *session) writeTraffic() ([]tabletraffic.TableTraffic, error) {
+	if !variable.EnableTableWriteTraffic.Load() {
+		return nil, nil
+	}
+	tablePrefix := kv.Key(tablecodec.TablePrefix())
+	iter, err := s.txn.GetMemBuffer().Iter(tablePrefix, tablePrefix.PrefixNext())
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+	var traffic []tabletraffic.TableTraffic
+	for iter.Valid() {
+		tableID := tablecodec.DecodeTableID(iter.Key())
+		if n := len(traffic); n == 0 || traffic[n-1].TableID != tableID {
+			traffic = append(traffic, tabletraffic.TableTraffic{TableID: tableID})
+		}
+		t := &traffic[len(traffic)-1]
+		t.WriteKeys++
+		t.WriteBytes += int64(len(iter.Key()) + len(iter.Value()))
+		if err = iter.Next(); err != nil {
+			return nil, err
+		}
+	}
+	return traffic, nil
 }
 
 // removeTempTableFromBuffer filters out the temporary table key-values.
@@ -662,10 +713,22 @@ func (s *session) CommitTxn(ctx context.Context) error {
 
 	var commitDetail *tikvutil.CommitDetails
 	ctx = context.WithValue(ctx, tikvutil.CommitDetailCtxKey, &commitDetail)
+	lastTxnInfo := s.sessionVars.LastTxnInfo
 	err := s.doCommitWithRetry(ctx)
 	if commitDetail != nil {
 		s.sessionVars.StmtCtx.MergeExecDetails(nil, commitDetail)
 	}
+	// The commit hook updates the LastTxnInfo if the transaction is committed.
+	if s.sessionVars.LastTxnInfo != lastTxnInfo {
+		info := parseCommittedTxnInfo(s.sessionVars.LastTxnInfo)
+		if commitDetail != nil {
+			s.sessionVars.StmtCtx.SetCommitMode(info.TxnCommitMode)
+		}
+		if info.CommitTS > s.sessionVars.LastCommitTS {
+			s.sessionVars.LastCommitTS = info.CommitTS
+		}
+		storeRCCommitTS(info.CommitTS)
+	}
 
 	failpoint.Inject("keepHistory", func(val failpoint.Value) {
 		if val.(bool) {
@@ -677,6 +740,21 @@ func (s *session) CommitTxn(ctx context.Context) error {
 	return err
 }
 
+// committedTxnInfo is the part of the transaction info passed to the commit hook which is used by the session.
+type committedTxnInfo struct {
+	CommitTS      uint64 `json:"commit_ts"`
+	TxnCommitMode string `json:"txn_commit_mode"`
+}
+
+// parseCommittedTxnInfo extracts the commit ts and the commit mode from the transaction info passed to the commit hook.
+func parseCommittedTxnInfo(txnInfo string) committedTxnInfo {
+	var info committedTxnInfo
+	if err := json.Unmarshal([]byte(txnInfo), &info); err != nil {
+		return committedTxnInfo{}
+	}
+	return info
+}
+
 func (s *session) RollbackTxn(ctx context.Context) {
 	if span := opentracing.SpanFromContext(ctx); span != nil && span.Tracer() != nil {
 		span1 := span.Tracer().StartSpan("session.RollbackTxn", opentracing.ChildOf(span.Context()))
@@ -695,6 +773,48 @@ func (s *session) RollbackTxn(ctx context.Context) {
 	s.sessionVars.SetInTxn(false)
 }
 
+// Savepoint implements the Session interface. Like MySQL, it does nothing outside a transaction. The name is case
+// insensitive, and the existing savepoint with the same name is replaced.
+func (s *session) Savepoint(name string) error {
+	if s.sessionVars.BinlogClient != nil {
+		return ErrSavepointNotSupportedWithBinlog
+	}
+	if !s.sessionVars.InTxn() {
+		return nil
+	}
+	if _, err := s.Txn(true); err != nil {
+		return err
+	}
+	s.txn.addSavepoint(strings.ToLower(name), s.sessionVars.TxnCtx.Savepoint())
+	return nil
+}
+
+// RollbackToSavepoint implements the Session interface. The savepoints set after the named one are removed, and the
+// pessimistic locks acquired after it are kept until the transaction ends.
+func (s *session) RollbackToSavepoint(name string) error {
+	name = strings.ToLower(name)
+	i := s.txn.findSavepoint(name)
+	if i < 0 {
+		return ErrSavepointNotExists.GenWithStackByArgs("SAVEPOINT", name)
+	}
+	s.txn.rollbackToSavepoint(i)
+	s.sessionVars.TxnCtx.RollbackToSavepoint(&s.txn.savepoints[i].txnCtx)
+	// The statement history can't replay the rollback, so the transaction can't be retried.
+	s.sessionVars.TxnCtx.CouldRetry = false
+	return nil
+}
+
+// ReleaseSavepoint implements the Session interface.
+func (s *session) ReleaseSavepoint(name string) error {
+	name = strings.ToLower(name)
+	i := s.txn.findSavepoint(name)
+	if i < 0 {
+		return ErrSavepointNotExists.GenWithStackByArgs("SAVEPOINT", name)
+	}
+	s.txn.releaseSavepoint(i)
+	return nil
+}
+
 func (s *session) GetClient() kv.Client {
 	return s.client
 }
@@ -1217,6 +1337,7 @@ func (s *session) SetProcessInfo(sql string, t time.Time, command byte, maxExecu
 		StatsInfo:        plannercore.GetStatsInfo,
 		MaxExecutionTime: maxExecutionTime,
 		RedactSQL:        s.sessionVars.EnableRedactLog,
+		ConnectAttrs:     s.sessionVars.ConnectAttrs,
 	}
 	oldPi := s.ShowProcess()
 	if p == nil {
@@ -1532,6 +1653,9 @@ func (s *session) ExecuteStmt(ctx context.Context, stmtNode ast.StmtNode) (sqlex
 		if !s.sessionVars.InRestrictedSQL {
 			logutil.Logger(ctx).Warn("compile SQL failed", zap.Error(err), zap.String("SQL", stmtNode.Text()))
 		}
+		if audit.Enabled() {
+			audit.LogStmt(s.sessionVars, stmtNode, executor.GetStmtLabel(stmtNode), err)
+		}
 		return nil, err
 	}
 	durCompile := time.Since(s.sessionVars.StartTime)
@@ -1886,6 +2010,11 @@ func (s *session) IsCachedExecOk(ctx context.Context, preparedStmt *plannercore.
 			return false, nil
 		}
 	}
+	// The cached point plan may be built without the row-level security policies for the session now.
+	if preparedStmt.PointPlanSecurityPolicyDep != plannercore.GetSecurityPolicyDep(s) {
+		prepared.CachedPlan = nil
+		return false, nil
+	}
 	// maybe we'd better check cached plan type here, current
 	// only point select/update will be cached, see "getPhysicalPlan" func
 	var ok bool
@@ -1996,6 +2125,9 @@ func (s *session) Txn(active bool) (kv.Transaction, error) {
 		if s.sessionVars.GetReplicaRead().IsFollowerRead() {
 			s.txn.SetOption(kv.ReplicaRead, kv.ReplicaReadFollower)
 		}
+		if limit := s.sessionVars.TxnTotalSizeLimit; limit > 0 {
+			s.txn.SetOption(kv.TxnSizeLimit, limit)
+		}
 	}
 	return &s.txn, nil
 }
@@ -2049,6 +2181,9 @@ func (s *session) NewTxn(ctx context.Context) error {
 	if s.GetSessionVars().GetReplicaRead().IsFollowerRead() {
 		txn.SetOption(kv.ReplicaRead, kv.ReplicaReadFollower)
 	}
+	if limit := s.sessionVars.TxnTotalSizeLimit; limit > 0 {
+		txn.SetOption(kv.TxnSizeLimit, limit)
+	}
 	s.txn.changeInvalidToValid(txn)
 	is := domain.GetDomain(s).InfoSchema()
 	s.sessionVars.TxnCtx = &variable.TransactionContext{
@@ -2499,6 +2634,13 @@ func BootstrapSession(store kv.Storage) (*domain.Domain, error) {
 	if err != nil {
 		return nil, err
 	}
+	if cfg.StmtSummary.EnablePersistent {
+		se8, err := createSession(store)
+		if err != nil {
+			return nil, err
+		}
+		dom.StmtSummaryPersistLoop(se8)
+	}
 	if raw, ok := store.(kv.EtcdBackend); ok {
 		err = raw.StartGCWorker()
 		if err != nil {
@@ -2724,7 +2866,7 @@ func (s *session) PrepareTSFuture(ctx context.Context) {
 		s.txn.changeInvalidToPending(txnFuture)
 	} else if s.txn.Valid() && s.GetSessionVars().IsPessimisticReadConsistency() {
 		// Prepare the statement future if the transaction is valid in RC transactions.
-		s.GetSessionVars().TxnCtx.SetStmtFutureForRC(s.getTxnFuture(ctx).future)
+		s.GetSessionVars().TxnCtx.SetStmtFutureForRC(s.getRCStmtFuture(ctx))
 	}
 }
 
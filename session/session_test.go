@@ -17,6 +17,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"math"
 	"os"
 	"path"
 	"strconv"
@@ -28,6 +29,7 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/errors"
 	"github.com/pingcap/failpoint"
+	"github.com/pingcap/kvproto/pkg/kvrpcpb"
 	"github.com/pingcap/parser"
 	"github.com/pingcap/parser/auth"
 	"github.com/pingcap/parser/format"
@@ -50,6 +52,7 @@ import (
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/store/copr"
 	"github.com/pingcap/tidb/store/driver"
+	"github.com/pingcap/tidb/store/helper"
 	"github.com/pingcap/tidb/store/mockstore"
 	"github.com/pingcap/tidb/store/mockstore/mockcopr"
 	"github.com/pingcap/tidb/table/tables"
@@ -63,6 +66,7 @@ import (
 	tikvmockstore "github.com/tikv/client-go/v2/mockstore"
 	"github.com/tikv/client-go/v2/mockstore/cluster"
 	"github.com/tikv/client-go/v2/tikv"
+	"github.com/tikv/client-go/v2/tikvrpc"
 	"go.etcd.io/etcd/clientv3"
 	"google.golang.org/grpc"
 )
@@ -4590,3 +4594,126 @@ func (s *testSessionSuite) TestTiDBEnableGlobalTemporaryTable(c *C) {
 	tk.MustExec("create global temporary table temp_test(id int primary key auto_increment) on commit delete rows")
 	tk.MustQuery("show tables like 'temp_test'").Check(testkit.Rows("temp_test"))
 }
+
+func (s *testSessionSuite3) TestSavepoint(c *C) {
+	tk := testkit.NewTestKitWithInit(c, s.store)
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int primary key, b int)")
+	for _, mode := range []string{"optimistic", "pessimistic"} {
+		tk.MustExec("delete from t")
+		tk.MustExec("begin " + mode)
+		tk.MustExec("insert into t values (1, 1)")
+		c.Assert(tk.Se.Savepoint("s1"), IsNil)
+		tk.MustExec("insert into t values (2, 2)")
+		tk.MustExec("update t set b = 10 where a = 1")
+		c.Assert(tk.Se.Savepoint("S2"), IsNil)
+		tk.MustExec("insert into t values (3, 3)")
+		c.Assert(tk.Se.RollbackToSavepoint("S1"), IsNil)
+		tk.MustQuery("select * from t").Check(testkit.Rows("1 1"))
+		c.Assert(tk.Se.RollbackToSavepoint("s2"), ErrorMatches, ".*SAVEPOINT s2 does not exist")
+		// The savepoint is kept after rolling back to it.
+		tk.MustExec("insert into t values (4, 4)")
+		c.Assert(tk.Se.RollbackToSavepoint("s1"), IsNil)
+		tk.MustExec("insert into t values (5, 5)")
+		c.Assert(tk.Se.ReleaseSavepoint("s1"), IsNil)
+		c.Assert(tk.Se.ReleaseSavepoint("s1"), ErrorMatches, ".*SAVEPOINT s1 does not exist")
+		tk.MustExec("commit")
+		tk.MustQuery("select * from t").Check(testkit.Rows("1 1", "5 5"))
+	}
+
+	// The changes after the savepoints are committed without releasing them.
+	tk.MustExec("begin")
+	c.Assert(tk.Se.Savepoint("s1"), IsNil)
+	tk.MustExec("insert into t values (6, 6)")
+	tk.MustQuery("select * from t where a = 6").Check(testkit.Rows("6 6"))
+	tk.MustExec("commit")
+	tk.MustQuery("select * from t where a = 6").Check(testkit.Rows("6 6"))
+
+	// There are no savepoints outside a transaction.
+	c.Assert(tk.Se.Savepoint("s1"), IsNil)
+	c.Assert(tk.Se.RollbackToSavepoint("s1"), ErrorMatches, ".*SAVEPOINT s1 does not exist")
+}
+
+func (s *testSessionSuite3) TestSavepointPessimisticLocks(c *C) {
+	tk := testkit.NewTestKitWithInit(c, s.store)
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int primary key, b int)")
+	tk.MustExec("insert into t values (1, 1), (2, 2), (3, 3)")
+	tbl, err := domain.GetDomain(tk.Se).InfoSchema().TableByName(model.NewCIStr("test"), model.NewCIStr("t"))
+	c.Assert(err, IsNil)
+	// countLocks returns the number of the locks of the transaction left on the table.
+	countLocks := func(startTS uint64) int {
+		store := s.store.(helper.Storage)
+		bo := tikv.NewBackofferWithVars(context.Background(), 5000, nil)
+		startKey := tablecodec.GenTablePrefix(tbl.Meta().ID)
+		loc, err := store.GetRegionCache().LocateKey(bo, startKey)
+		c.Assert(err, IsNil)
+		req := tikvrpc.NewRequest(tikvrpc.CmdScanLock, &kvrpcpb.ScanLockRequest{
+			MaxVersion: math.MaxUint64,
+			StartKey:   startKey,
+			EndKey:     startKey.PrefixNext(),
+			Limit:      1024,
+		})
+		resp, err := store.SendReq(bo, req, loc.Region, tikv.ReadTimeoutShort)
+		c.Assert(err, IsNil)
+		c.Assert(resp.Resp, NotNil)
+		cnt := 0
+		for _, lock := range resp.Resp.(*kvrpcpb.ScanLockResponse).GetLocks() {
+			if lock.LockVersion == startTS {
+				cnt++
+			}
+		}
+		return cnt
+	}
+
+	tk.MustExec("begin pessimistic")
+	txn, err := tk.Se.Txn(false)
+	c.Assert(err, IsNil)
+	startTS := txn.StartTS()
+	tk.MustExec("update t set b = 10 where a = 1")
+	c.Assert(tk.Se.Savepoint("s1"), IsNil)
+	tk.MustExec("update t set b = 20 where a = 2")
+	tk.MustQuery("select * from t where a = 3 for update").Check(testkit.Rows("3 3"))
+	c.Assert(tk.Se.RollbackToSavepoint("s1"), IsNil)
+	// The pessimistic locks acquired after the savepoint are still held by the transaction.
+	c.Assert(countLocks(startTS), Equals, 3)
+	tk2 := testkit.NewTestKitWithInit(c, s.store)
+	tk2.MustExec("begin pessimistic")
+	_, err = tk2.Exec("select * from t where a = 2 for update nowait")
+	c.Assert(err, NotNil)
+	tk2.MustExec("rollback")
+	tk.MustExec("commit")
+	// No lock is left after the transaction is committed. The secondary keys are committed asynchronously.
+	for i := 0; i < 100 && countLocks(startTS) > 0; i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+	c.Assert(countLocks(startTS), Equals, 0)
+	tk.MustQuery("select * from t").Check(testkit.Rows("1 10", "2 2", "3 3"))
+	tk2.MustExec("begin pessimistic")
+	tk2.MustQuery("select * from t where a = 2 for update nowait").Check(testkit.Rows("2 2"))
+	tk2.MustExec("commit")
+}
+
+func (s *testSessionSuite3) TestReadYourWritesWithStaleness(c *C) {
+	tk := testkit.NewTestKitWithInit(c, s.store)
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int primary key)")
+	tk.MustExec("insert into t values (1)")
+	commitTS := tk.Se.GetSessionVars().LastCommitTS
+	c.Assert(commitTS, Greater, uint64(0))
+	tk.MustQuery("select json_extract(@@tidb_last_txn_info, '$.commit_ts')").Check(testkit.Rows(strconv.FormatUint(commitTS, 10)))
+
+	// The bounded staleness read never reads before the writes of the session.
+	tk.MustExec("set @@tidb_read_staleness = -600")
+	tk.MustQuery("select * from t").Check(testkit.Rows("1"))
+	tk.MustExec("set @@tidb_read_staleness = 0")
+
+	// The high-water mark is kept by the read-only and the rolled back transactions.
+	tk.MustExec("begin")
+	tk.MustExec("insert into t values (3)")
+	tk.MustExec("rollback")
+	tk.MustExec("begin")
+	tk.MustQuery("select count(*) from t").Check(testkit.Rows("1"))
+	tk.MustExec("commit")
+	c.Assert(tk.Se.GetSessionVars().LastCommitTS, Equals, commitTS)
+}
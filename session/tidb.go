@@ -26,6 +26,7 @@ import (
 	"github.com/pingcap/errors"
 	"github.com/pingcap/parser"
 	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/mysql"
 	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/domain"
 	"github.com/pingcap/tidb/errno"
@@ -353,4 +354,10 @@ func ResultSetToStringSlice(ctx context.Context, s Session, rs sqlexec.RecordSet
 // Session errors.
 var (
 	ErrForUpdateCantRetry = dbterror.ClassSession.NewStd(errno.ErrForUpdateCantRetry)
+	// ErrSavepointNotExists is returned when rolling back to or releasing a savepoint that doesn't exist.
+	ErrSavepointNotExists = dbterror.ClassSession.NewStd(errno.ErrSpDoesNotExist)
+	// ErrSavepointNotSupportedWithBinlog is returned when setting a savepoint with the binlog enabled, because the
+	// binlog of the rolled back changes can't be discarded.
+	ErrSavepointNotSupportedWithBinlog = dbterror.ClassSession.NewStdErr(errno.ErrNotSupportedYet,
+		mysql.Message("This version of TiDB doesn't yet support 'SAVEPOINT with binlog'", nil))
 )
@@ -29,6 +29,7 @@ import (
 	"github.com/pingcap/tidb/domain"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/planner/core"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/store/mockstore"
 	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/types"
@@ -239,3 +240,56 @@ func (s *testMainSuite) TestIndexUsageSyncLease(c *C) {
 	err = store.Close()
 	c.Assert(err, IsNil)
 }
+
+func (s *testMainSuite) TestRCTSCache(c *C) {
+	const txnScope = "test-rc-ts-cache"
+	// The TS in the test is smaller than the commit TS of the other tests.
+	rcTSCache.Lock()
+	commitTS := rcTSCache.commitTS
+	rcTSCache.commitTS = 0
+	rcTSCache.Unlock()
+	defer func() {
+		rcTSCache.Lock()
+		rcTSCache.commitTS = commitTS
+		rcTSCache.Unlock()
+	}()
+	_, ok := getCachedRCTS(s.store, txnScope, time.Minute, 0)
+	c.Assert(ok, IsFalse)
+
+	storeRCTS(txnScope, 100, time.Now())
+	ts, ok := getCachedRCTS(s.store, txnScope, time.Minute, 0)
+	c.Assert(ok, IsTrue)
+	c.Assert(ts, Equals, uint64(100))
+	// The older TS doesn't replace the cached one.
+	storeRCTS(txnScope, 90, time.Now())
+	ts, ok = getCachedRCTS(s.store, txnScope, time.Minute, 100)
+	c.Assert(ok, IsTrue)
+	c.Assert(ts, Equals, uint64(100))
+	// The cached TS can't be older than the for-update TS of the transaction.
+	_, ok = getCachedRCTS(s.store, txnScope, time.Minute, 101)
+	c.Assert(ok, IsFalse)
+
+	// The cached TS expires after the window.
+	storeRCTS(txnScope, 200, time.Now().Add(-time.Second))
+	_, ok = getCachedRCTS(s.store, txnScope, 100*time.Millisecond, 0)
+	c.Assert(ok, IsFalse)
+	ts, ok = getCachedRCTS(s.store, txnScope, time.Minute, 0)
+	c.Assert(ok, IsTrue)
+	c.Assert(ts, Equals, uint64(200))
+
+	// The cached TS can't be older than the transactions committed by the instance.
+	storeRCCommitTS(200)
+	_, ok = getCachedRCTS(s.store, txnScope, time.Minute, 0)
+	c.Assert(ok, IsTrue)
+	storeRCCommitTS(201)
+	_, ok = getCachedRCTS(s.store, txnScope, time.Minute, 0)
+	c.Assert(ok, IsFalse)
+
+	// The entries older than the max window are evicted when a new TS is cached.
+	storeRCTS(txnScope, 300, time.Now().Add(-2*time.Duration(variable.MaxRCTSCacheWindow)*time.Millisecond))
+	storeRCTS(txnScope+"-other", 100, time.Now())
+	rcTSCache.Lock()
+	_, ok = rcTSCache.entries[txnScope]
+	rcTSCache.Unlock()
+	c.Assert(ok, IsFalse)
+}
@@ -16,9 +16,11 @@ package session
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
 	"fmt"
 	"runtime/trace"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 	"unsafe"
@@ -33,10 +35,15 @@ import (
 	"github.com/pingcap/tidb/session/txninfo"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/sessionctx/binloginfo"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	storeerr "github.com/pingcap/tidb/store/driver/error"
 	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/util/lockwaithistory"
 	"github.com/pingcap/tidb/util/logutil"
+	"github.com/pingcap/tidb/util/resourcegrouptag"
 	"github.com/pingcap/tidb/util/sli"
 	"github.com/pingcap/tipb/go-binlog"
+	tikverr "github.com/tikv/client-go/v2/error"
 	"github.com/tikv/client-go/v2/oracle"
 	"github.com/tikv/client-go/v2/tikv"
 	"go.uber.org/zap"
@@ -59,6 +66,10 @@ type LazyTxn struct {
 	mutations     map[int64]*binlog.TableMutation
 	writeSLI      sli.TxnWriteThroughputSLI
 
+	// savepoints are the savepoints of the transaction in the order they are set. Each of them holds a staging buffer
+	// below the statement buffer, so the changes after a savepoint can be discarded by cleaning up its buffer.
+	savepoints []txnSavepoint
+
 	// following atomic fields are used for filling TxnInfo
 	// we need these fields because kv.Transaction provides no thread safety promise
 	// but we hope getting TxnInfo is a thread safe op
@@ -68,6 +79,13 @@ type LazyTxn struct {
 	txnInfo unsafe.Pointer
 }
 
+type txnSavepoint struct {
+	// name is empty if the savepoint is replaced by a later one with the same name.
+	name          string
+	stagingHandle kv.StagingHandle
+	txnCtx        variable.TxnCtxSavepoint
+}
+
 // GetTableInfo returns the cached index name.
 func (txn *LazyTxn) GetTableInfo(id int64) *model.TableInfo {
 	return txn.Transaction.GetTableInfo(id)
@@ -266,6 +284,7 @@ func (txn *LazyTxn) changeToInvalid() {
 		txn.Transaction.GetMemBuffer().Cleanup(txn.stagingHandle)
 	}
 	txn.stagingHandle = kv.InvalidStagingHandle
+	txn.savepoints = nil
 	txn.Transaction = nil
 	txn.txnFuture = nil
 
@@ -375,13 +394,79 @@ func (txn *LazyTxn) Rollback() error {
 	return txn.Transaction.Rollback()
 }
 
+// IsReadOnly overrides the Transaction interface. The changes in the staging buffers of the savepoints are not
+// published to the inner transaction until the savepoints are released.
+func (txn *LazyTxn) IsReadOnly() bool {
+	if len(txn.savepoints) > 0 && txn.Transaction.Len() > 0 {
+		return false
+	}
+	return txn.Transaction.IsReadOnly()
+}
+
+func (txn *LazyTxn) findSavepoint(name string) int {
+	for i := len(txn.savepoints) - 1; i >= 0; i-- {
+		if txn.savepoints[i].name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// addSavepoint sets a savepoint after the executed statements, which replaces the savepoint with the same name.
+func (txn *LazyTxn) addSavepoint(name string, txnCtx variable.TxnCtxSavepoint) {
+	if i := txn.findSavepoint(name); i >= 0 {
+		txn.savepoints[i].name = ""
+	}
+	txn.flushStmtBuf()
+	txn.savepoints = append(txn.savepoints, txnSavepoint{
+		name:          name,
+		stagingHandle: txn.Transaction.GetMemBuffer().Staging(),
+		txnCtx:        txnCtx,
+	})
+	txn.initStmtBuf()
+}
+
+// rollbackToSavepoint discards the changes after the i-th savepoint and the savepoints set after it, the i-th
+// savepoint itself is kept.
+func (txn *LazyTxn) rollbackToSavepoint(i int) {
+	txn.cleanupStmtBuf()
+	buf := txn.Transaction.GetMemBuffer()
+	for j := len(txn.savepoints) - 1; j >= i; j-- {
+		buf.Cleanup(txn.savepoints[j].stagingHandle)
+	}
+	txn.savepoints[i].stagingHandle = buf.Staging()
+	txn.savepoints = txn.savepoints[:i+1]
+	txn.initStmtBuf()
+	txn.UpdateEntriesCountAndSize()
+}
+
+// releaseSavepoint removes the i-th savepoint and the savepoints set after it, the changes after them are published
+// to the upper level buffer.
+func (txn *LazyTxn) releaseSavepoint(i int) {
+	txn.flushStmtBuf()
+	buf := txn.Transaction.GetMemBuffer()
+	for j := len(txn.savepoints) - 1; j >= i; j-- {
+		buf.Release(txn.savepoints[j].stagingHandle)
+	}
+	txn.savepoints = txn.savepoints[:i]
+	txn.initStmtBuf()
+}
+
 // LockKeys Wrap the inner transaction's `LockKeys` to record the status
 func (txn *LazyTxn) LockKeys(ctx context.Context, lockCtx *kv.LockCtx, keys ...kv.Key) error {
 	txnInfo := txn.getTxnInfo()
 	originState := atomic.SwapInt32(&txnInfo.State, txninfo.TxnLockWaiting)
 	t := time.Now()
 	atomic.StorePointer(&txnInfo.BlockStartTime, unsafe.Pointer(&t))
+	var waitedBefore int32
+	if lockCtx.PessimisticLockWaited != nil {
+		waitedBefore = atomic.LoadInt32(lockCtx.PessimisticLockWaited)
+	}
 	err := txn.Transaction.LockKeys(ctx, lockCtx, keys...)
+	waited := lockCtx.PessimisticLockWaited != nil && atomic.LoadInt32(lockCtx.PessimisticLockWaited) > waitedBefore
+	if result := lockWaitResult(err); waited || result == lockwaithistory.ResultDeadlock || result == lockwaithistory.ResultTimeout {
+		txn.recordLockWait(lockCtx, t, result, err)
+	}
 	atomic.StorePointer(&txnInfo.BlockStartTime, unsafe.Pointer(nil))
 	atomic.StoreInt32(&txnInfo.State, originState)
 	atomic.StoreUint64(&txnInfo.EntriesCount, uint64(txn.Transaction.Len()))
@@ -389,6 +474,39 @@ func (txn *LazyTxn) LockKeys(ctx context.Context, lockCtx *kv.LockCtx, keys ...k
 	return err
 }
 
+// recordLockWait records a lock wait started at startTime in the lock wait history.
+func (txn *LazyTxn) recordLockWait(lockCtx *kv.LockCtx, startTime time.Time, result string, err error) {
+	rec := &lockwaithistory.LockWaitRecord{
+		OccurTime: time.Now(),
+		TrxID:     txn.StartTS(),
+		Result:    result,
+	}
+	rec.WaitTime = rec.OccurTime.Sub(startTime)
+	if sqlDigest, decodeErr := resourcegrouptag.DecodeResourceGroupTag(lockCtx.ResourceGroupTag); decodeErr == nil && len(sqlDigest) > 0 {
+		rec.SQLDigest = hex.EncodeToString(sqlDigest)
+	}
+	if deadlock, ok := errors.Cause(err).(*tikverr.ErrDeadlock); ok {
+		rec.Key = deadlock.LockKey
+		rec.TrxHoldingLock = deadlock.LockTs
+	}
+	lockwaithistory.GlobalLockWaitHistory.Push(rec)
+}
+
+// lockWaitResult returns the result of a lock wait ended with err, the errors other than the deadlock and the lock
+// wait timeout may be returned without waiting.
+func lockWaitResult(err error) string {
+	if err == nil {
+		return lockwaithistory.ResultAcquired
+	}
+	if _, ok := errors.Cause(err).(*tikverr.ErrDeadlock); ok {
+		return lockwaithistory.ResultDeadlock
+	}
+	if storeerr.ErrLockWaitTimeout.Equal(err) {
+		return lockwaithistory.ResultTimeout
+	}
+	return lockwaithistory.ResultError
+}
+
 func (txn *LazyTxn) reset() {
 	txn.cleanup()
 	txn.changeToInvalid()
@@ -530,6 +648,107 @@ func (s *session) getTxnFuture(ctx context.Context) *txnFuture {
 	return ret
 }
 
+// rcTSCache caches the latest TS fetched for the statements in the read-committed pessimistic transactions of each
+// txn scope, which is reused by the statements started within tidb_rc_ts_cache_window after it's fetched. The entries
+// older than the max window are never reused, so they're evicted when a new TS is cached.
+var rcTSCache = struct {
+	sync.Mutex
+	entries map[string]rcTSCacheEntry
+	// commitTS is the largest commit TS of the transactions committed by the instance. The cached TS older than it is
+	// never reused, so a statement always sees the transactions committed on the instance before it starts.
+	commitTS uint64
+}{entries: make(map[string]rcTSCacheEntry)}
+
+type rcTSCacheEntry struct {
+	ts uint64
+	// fetchTime is the time when the TS is requested, which is earlier than the time when it's allocated.
+	fetchTime time.Time
+}
+
+func storeRCTS(txnScope string, ts uint64, fetchTime time.Time) {
+	maxWindow := time.Duration(variable.MaxRCTSCacheWindow) * time.Millisecond
+	rcTSCache.Lock()
+	defer rcTSCache.Unlock()
+	for scope, entry := range rcTSCache.entries {
+		if time.Since(entry.fetchTime) > maxWindow {
+			delete(rcTSCache.entries, scope)
+		}
+	}
+	if ts > rcTSCache.entries[txnScope].ts {
+		rcTSCache.entries[txnScope] = rcTSCacheEntry{ts: ts, fetchTime: fetchTime}
+	}
+}
+
+// storeRCCommitTS records the commit TS of a transaction committed by the instance, the cached TS older than it is no
+// longer reused.
+func storeRCCommitTS(commitTS uint64) {
+	rcTSCache.Lock()
+	if commitTS > rcTSCache.commitTS {
+		rcTSCache.commitTS = commitTS
+	}
+	rcTSCache.Unlock()
+}
+
+// getCachedRCTS returns the cached TS if it's fetched within the window and isn't older than minTS or the latest commit
+// TS of the instance. The min safe TS of the stores is returned instead if it's newer, because the data before it is
+// known to be complete.
+func getCachedRCTS(store kv.Storage, txnScope string, window time.Duration, minTS uint64) (uint64, bool) {
+	rcTSCache.Lock()
+	entry, ok := rcTSCache.entries[txnScope]
+	if rcTSCache.commitTS > minTS {
+		minTS = rcTSCache.commitTS
+	}
+	rcTSCache.Unlock()
+	if !ok || time.Since(entry.fetchTime) > window {
+		return 0, false
+	}
+	ts := entry.ts
+	if safeTS := store.GetMinSafeTS(txnScope); safeTS > ts {
+		ts = safeTS
+	}
+	if ts < minTS {
+		return 0, false
+	}
+	return ts, true
+}
+
+// rcTSFuture caches the TS in rcTSCache after it's fetched.
+type rcTSFuture struct {
+	oracle.Future
+	txnScope  string
+	fetchTime time.Time
+}
+
+func (f *rcTSFuture) Wait() (uint64, error) {
+	ts, err := f.Future.Wait()
+	if err == nil {
+		storeRCTS(f.txnScope, ts, f.fetchTime)
+	}
+	return ts, err
+}
+
+type cachedTSFuture uint64
+
+func (f cachedTSFuture) Wait() (uint64, error) {
+	return uint64(f), nil
+}
+
+// getRCStmtFuture returns the future of the TS of a statement in the read-committed pessimistic transaction. The
+// cached TS is reused within tidb_rc_ts_cache_window, so the statement may not see the transactions committed by the
+// other instances in the window, but the conflicts of the writes are still detected by the pessimistic locks, after
+// which the statement is retried with a new TS. The transactions committed by the instance are always seen.
+func (s *session) getRCStmtFuture(ctx context.Context) oracle.Future {
+	sessVars := s.sessionVars
+	if sessVars.RCTSCacheWindow <= 0 || sessVars.LowResolutionTSO {
+		return s.getTxnFuture(ctx).future
+	}
+	txnScope := sessVars.CheckAndGetTxnScope()
+	if ts, ok := getCachedRCTS(s.store, txnScope, sessVars.RCTSCacheWindow, sessVars.TxnCtx.GetForUpdateTS()); ok {
+		return cachedTSFuture(ts)
+	}
+	return &rcTSFuture{Future: s.getTxnFuture(ctx).future, txnScope: txnScope, fetchTime: time.Now()}
+}
+
 // HasDirtyContent checks whether there's dirty update on the given table.
 // Put this function here is to avoid cycle import.
 func (s *session) HasDirtyContent(tid int64) bool {
@@ -55,6 +55,29 @@ type SQLWarn struct {
 	Err   error
 }
 
+// The types of the optimizer trace steps.
+const (
+	// TraceTypeRule is the step of applying a logical optimization rule.
+	TraceTypeRule = "rule"
+	// TraceTypeCandidate is the step of costing a candidate physical plan.
+	TraceTypeCandidate = "candidate"
+	// TraceTypeChosen is the step of choosing the best physical plan of an operator.
+	TraceTypeChosen = "chosen"
+	// TraceTypePruned is the step of pruning an access path.
+	TraceTypePruned = "pruned"
+	// TraceTypePushDown is the step of deciding whether an operator or expression is pushed down to the storage.
+	TraceTypePushDown = "pushdown"
+	// TraceTypeNote is the step of an optimizer note, see AppendOptimizerNote.
+	TraceTypeNote = "note"
+)
+
+// OptimizerTraceStep is a decision of the optimizer recorded in the optimizer trace.
+type OptimizerTraceStep struct {
+	Type     string `json:"type"`
+	Operator string `json:"operator"`
+	Detail   string `json:"detail"`
+}
+
 // StatementContext contains variables for a statement.
 // It should be reset before executing a statement.
 type StatementContext struct {
@@ -86,6 +109,17 @@ type StatementContext struct {
 	IgnoreNoPartition         bool
 	OptimDependOnMutableConst bool
 	IgnoreExplainIDSuffix     bool
+	// EnableOptimizerNotes indicates whether to append the notes explaining the decisions of the optimizer, see
+	// AppendOptimizerNote.
+	EnableOptimizerNotes bool
+	// EnableOptimizerTrace indicates whether to record the decisions of the optimizer into the optimizer trace, see
+	// AppendOptimizerTrace.
+	EnableOptimizerTrace bool
+	// HasTiFlashReplica indicates whether the statement reads some tables which have available tiflash replicas.
+	HasTiFlashReplica bool
+	// HasSecurityPolicy indicates whether the plan has the predicates of the row-level security policies, which depend
+	// on the user and its active roles.
+	HasSecurityPolicy bool
 
 	// mu struct holds variables that change during execution.
 	mu struct {
@@ -118,6 +152,7 @@ type StatementContext struct {
 		histogramsNotLoad bool
 		execDetails       execdetails.ExecDetails
 		allExecDetails    []*execdetails.ExecDetails
+		optimizerTrace    []OptimizerTraceStep
 	}
 	// PrevAffectedRows is the affected-rows value(DDL is 0, DML is the number of affected rows).
 	PrevAffectedRows int64
@@ -160,17 +195,42 @@ type StatementContext struct {
 	PessimisticLockWaited int32
 	LockKeysDuration      int64
 	LockKeysCount         int32
+	PipelinedLockErr      error // the first error of the locks acquired asynchronously during the execution
 	TblInfo2UnionScan     map[*model.TableInfo]bool
 	TaskID                uint64 // unique ID for an execution of a statement
 	TaskMapBakTS          uint64 // counter for
 
+	// StatsDeps are the statistics of the physical tables used by the optimizer, they are only recorded when the plan
+	// may be cached, and the cached plan is invalidated if they change significantly.
+	StatsDeps map[int64]StatsDep
+
 	// stmtCache is used to store some statement-related values.
 	stmtCache map[StmtCacheKey]interface{}
+	// constExprValues memoizes the values of the expressions which are constant for the whole statement, they
+	// may be evaluated by the concurrent workers.
+	constExprValues struct {
+		sync.Mutex
+		values map[interface{}]interface{}
+	}
+	// closeLimiter limits the goroutines closing the executors of the statement concurrently, it's shared by all
+	// the executors of the statement.
+	closeLimiter struct {
+		sync.Once
+		tokens chan struct{}
+	}
 	// resourceGroupTag cache for the current statement resource group tag.
 	resourceGroupTag atomic.Value
 	// Map to store all CTE storages of current SQL.
 	// Will clean up at the end of the execution.
 	CTEStorageMap interface{}
+	// ResourceGroupName is the name of the resource group which limits the statement, it's empty if the statement
+	// is not in any resource group.
+	ResourceGroupName string
+	// CopConcurrencyLimit is the max concurrency of the coprocessor requests of the statement, 0 means no limit.
+	CopConcurrencyLimit int
+	// Deprioritized indicates the statement is found runaway, and its following coprocessor requests are sent
+	// with low priority.
+	Deprioritized atomic2.Bool
 }
 
 // StmtHints are SessionVars related sql hints.
@@ -233,6 +293,33 @@ func (sc *StatementContext) ResetStmtCache() {
 	sc.stmtCache = make(map[StmtCacheKey]interface{})
 }
 
+// GetConstExprValue gets the memoized value of the expression which is constant for the whole statement.
+func (sc *StatementContext) GetConstExprValue(expr interface{}) (interface{}, bool) {
+	sc.constExprValues.Lock()
+	defer sc.constExprValues.Unlock()
+	value, ok := sc.constExprValues.values[expr]
+	return value, ok
+}
+
+// SetConstExprValue memoizes the value of the expression which is constant for the whole statement.
+func (sc *StatementContext) SetConstExprValue(expr interface{}, value interface{}) {
+	sc.constExprValues.Lock()
+	defer sc.constExprValues.Unlock()
+	if sc.constExprValues.values == nil {
+		sc.constExprValues.values = make(map[interface{}]interface{})
+	}
+	sc.constExprValues.values[expr] = value
+}
+
+// GetCloseLimiter gets the tokens of the goroutines closing the executors of the statement concurrently, the
+// capacity is set by the first call.
+func (sc *StatementContext) GetCloseLimiter(capacity int) chan struct{} {
+	sc.closeLimiter.Do(func() {
+		sc.closeLimiter.tokens = make(chan struct{}, capacity)
+	})
+	return sc.closeLimiter.tokens
+}
+
 // SQLDigest gets normalized and digest for provided sql.
 // it will cache result after first calling.
 func (sc *StatementContext) SQLDigest() (normalized string, sqlDigest *parser.Digest) {
@@ -260,6 +347,11 @@ func (sc *StatementContext) GetResourceGroupTag() []byte {
 	if len(tag) > 0 {
 		return tag
 	}
+	// Don't compute the SQL digest before the SQL is known, or the digest of
+	// the empty string is memoized for the whole statement.
+	if len(sc.OriginalSQL) == 0 {
+		return nil
+	}
 	normalized, sqlDigest := sc.SQLDigest()
 	if len(normalized) == 0 {
 		return nil
@@ -273,6 +365,8 @@ func (sc *StatementContext) GetResourceGroupTag() []byte {
 func (sc *StatementContext) SetPlanDigest(normalized string, planDigest *parser.Digest) {
 	if planDigest != nil {
 		sc.planNormalized, sc.planDigest = normalized, planDigest
+		// The cached resource group tag may be built without the plan digest.
+		sc.resourceGroupTag.Store([]byte(nil))
 	}
 }
 
@@ -303,6 +397,14 @@ type TableEntry struct {
 	Table string
 }
 
+// StatsDep is the statistics of a physical table used by the optimizer.
+type StatsDep struct {
+	TblInfo        *model.TableInfo
+	AnalyzeVersion uint64
+	Count          int64
+	Outdated       bool
+}
+
 // AddAffectedRows adds affected rows.
 func (sc *StatementContext) AddAffectedRows(rows uint64) {
 	sc.mu.Lock()
@@ -490,6 +592,68 @@ func (sc *StatementContext) AppendNote(warn error) {
 	sc.mu.Unlock()
 }
 
+// AppendOptimizerNote appends a note with level 'Note' for a decision of the optimizer which may make the statement
+// slower than expected, such as a cached plan which can't be used or an expression which can't be pushed down. The
+// notes are appended only if EnableOptimizerNotes is true, and the same note is appended only once. The note is also
+// recorded into the optimizer trace if EnableOptimizerTrace is true.
+func (sc *StatementContext) AppendOptimizerNote(note error) {
+	if sc.EnableOptimizerTrace {
+		sc.AppendOptimizerTrace(TraceTypeNote, "", note.Error())
+	}
+	if !sc.EnableOptimizerNotes {
+		return
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if len(sc.mu.warnings) >= math.MaxUint16 {
+		return
+	}
+	msg := note.Error()
+	for _, w := range sc.mu.warnings {
+		if w.Level == WarnLevelNote && w.Err.Error() == msg {
+			return
+		}
+	}
+	sc.mu.warnings = append(sc.mu.warnings, SQLWarn{WarnLevelNote, note})
+}
+
+// AppendOptimizerTrace records a step of the optimizer trace if EnableOptimizerTrace is true, operator is the explain
+// ID of the operator which the step is about, it's empty if the step is not about any operator. The same step is
+// recorded only once, since the optimizer may visit the same plan for several times.
+func (sc *StatementContext) AppendOptimizerTrace(tp, operator, detail string) {
+	if !sc.EnableOptimizerTrace {
+		return
+	}
+	step := OptimizerTraceStep{Type: tp, Operator: operator, Detail: detail}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if len(sc.mu.optimizerTrace) >= math.MaxUint16 {
+		return
+	}
+	for _, s := range sc.mu.optimizerTrace {
+		if s == step {
+			return
+		}
+	}
+	sc.mu.optimizerTrace = append(sc.mu.optimizerTrace, step)
+}
+
+// GetOptimizerTrace returns the steps of the optimizer trace.
+func (sc *StatementContext) GetOptimizerTrace() []OptimizerTraceStep {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	trace := make([]OptimizerTraceStep, len(sc.mu.optimizerTrace))
+	copy(trace, sc.mu.optimizerTrace)
+	return trace
+}
+
+// ClearOptimizerTrace clears the steps of the optimizer trace.
+func (sc *StatementContext) ClearOptimizerTrace() {
+	sc.mu.Lock()
+	sc.mu.optimizerTrace = nil
+	sc.mu.Unlock()
+}
+
 // AppendError appends a warning with level 'Error'.
 func (sc *StatementContext) AppendError(warn error) {
 	sc.mu.Lock()
@@ -557,6 +721,7 @@ func (sc *StatementContext) ResetForRetry() {
 	sc.TableIDs = sc.TableIDs[:0]
 	sc.IndexNames = sc.IndexNames[:0]
 	sc.TaskID = AllocateTaskID()
+	sc.PipelinedLockErr = nil
 }
 
 // MergeExecDetails merges a single region execution details into self, used to print
@@ -581,6 +746,32 @@ func (sc *StatementContext) MergeExecDetails(details *execdetails.ExecDetails, c
 	}
 }
 
+// MergeCopTime adds the time spent in waiting for the coprocessor responses, the time of the MPP responses is added
+// to MPPTime instead.
+func (sc *StatementContext) MergeCopTime(d time.Duration, isMPP bool) {
+	sc.mu.Lock()
+	if isMPP {
+		sc.mu.execDetails.MPPTime += d
+	} else {
+		sc.mu.execDetails.CopTime += d
+	}
+	sc.mu.Unlock()
+}
+
+// AddMPPTasks adds the number of the MPP tasks dispatched by the statement.
+func (sc *StatementContext) AddMPPTasks(n int) {
+	sc.mu.Lock()
+	sc.mu.execDetails.MPPTasks += n
+	sc.mu.Unlock()
+}
+
+// SetCommitMode records the protocol used to commit the transaction, it's shown with the commit details.
+func (sc *StatementContext) SetCommitMode(mode string) {
+	sc.mu.Lock()
+	sc.mu.execDetails.CommitMode = mode
+	sc.mu.Unlock()
+}
+
 // MergeScanDetail merges scan details into self.
 func (sc *StatementContext) MergeScanDetail(scanDetail *util.ScanDetail) {
 	// Currently TiFlash cop task does not fill scanDetail, so need to skip it if scanDetail is nil
@@ -19,8 +19,10 @@ import (
 	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
 	"github.com/pingcap/tidb/sessionctx/stmtctx"
 	"github.com/pingcap/tidb/util/execdetails"
+	"github.com/pingcap/tipb/go-tipb"
 	"github.com/tikv/client-go/v2/util"
 )
 
@@ -97,3 +99,22 @@ func (s *stmtctxSuit) TestStatementContextPushDownFLags(c *C) {
 		c.Assert(got, Equals, tt.out, Commentf("get %v, want %v", got, tt.out))
 	}
 }
+
+func (s *stmtctxSuit) TestResourceGroupTag(c *C) {
+	ctx := new(stmtctx.StatementContext)
+	c.Assert(ctx.GetResourceGroupTag(), IsNil)
+	ctx.OriginalSQL = "select * from t where a = 1"
+	_, sqlDigest := ctx.SQLDigest()
+	tag := &tipb.ResourceGroupTag{}
+	c.Assert(tag.Unmarshal(ctx.GetResourceGroupTag()), IsNil)
+	c.Assert(tag.SqlDigest, DeepEquals, sqlDigest.Bytes())
+	c.Assert(tag.PlanDigest, IsNil)
+
+	// The tag is rebuilt with the plan digest set later.
+	_, planDigest := parser.NormalizeDigest("TableReader")
+	ctx.SetPlanDigest("TableReader", planDigest)
+	tag = &tipb.ResourceGroupTag{}
+	c.Assert(tag.Unmarshal(ctx.GetResourceGroupTag()), IsNil)
+	c.Assert(tag.SqlDigest, DeepEquals, sqlDigest.Bytes())
+	c.Assert(tag.PlanDigest, DeepEquals, planDigest.Bytes())
+}
@@ -282,6 +282,55 @@ func (tc *TransactionContext) ClearDelta() {
 	tc.tdmLock.Unlock()
 }
 
+// TxnCtxSavepoint is the state of a TransactionContext at a savepoint, which is restored by rolling back to the
+// savepoint.
+type TxnCtxSavepoint struct {
+	tableDeltaMap        map[int64]TableDelta
+	pessimisticLockCache map[string][]byte
+}
+
+func (sp *TxnCtxSavepoint) clone() TxnCtxSavepoint {
+	var c TxnCtxSavepoint
+	if sp.tableDeltaMap != nil {
+		c.tableDeltaMap = make(map[int64]TableDelta, len(sp.tableDeltaMap))
+		for id, item := range sp.tableDeltaMap {
+			if item.ColSize != nil {
+				colSize := make(map[int64]int64, len(item.ColSize))
+				for key, val := range item.ColSize {
+					colSize[key] = val
+				}
+				item.ColSize = colSize
+			}
+			c.tableDeltaMap[id] = item
+		}
+	}
+	if sp.pessimisticLockCache != nil {
+		c.pessimisticLockCache = make(map[string][]byte, len(sp.pessimisticLockCache))
+		for key, val := range sp.pessimisticLockCache {
+			c.pessimisticLockCache[key] = val
+		}
+	}
+	return c
+}
+
+// Savepoint returns the current state of the transaction context to be restored by RollbackToSavepoint.
+func (tc *TransactionContext) Savepoint() TxnCtxSavepoint {
+	tc.tdmLock.Lock()
+	defer tc.tdmLock.Unlock()
+	sp := TxnCtxSavepoint{tableDeltaMap: tc.TableDeltaMap, pessimisticLockCache: tc.pessimisticLockCache}
+	return sp.clone()
+}
+
+// RollbackToSavepoint restores the state of the transaction context at the savepoint. The keys locked after the
+// savepoint are still locked until the transaction ends, but they're removed from the pessimistic lock cache.
+func (tc *TransactionContext) RollbackToSavepoint(sp *TxnCtxSavepoint) {
+	c := sp.clone()
+	tc.tdmLock.Lock()
+	tc.TableDeltaMap = c.tableDeltaMap
+	tc.tdmLock.Unlock()
+	tc.pessimisticLockCache = c.pessimisticLockCache
+}
+
 // GetForUpdateTS returns the ts for update.
 func (tc *TransactionContext) GetForUpdateTS() uint64 {
 	if tc.forUpdateTS > tc.StartTS {
@@ -370,7 +419,7 @@ type SessionVars struct {
 	MemQuota
 	BatchSize
 	// DMLBatchSize indicates the number of rows batch-committed for a statement.
-	// It will be used when using LOAD DATA or BatchInsert or BatchDelete is on.
+	// It will be used when using LOAD DATA or BatchInsert or BatchDelete or BatchUpdate is on.
 	DMLBatchSize        int
 	RetryLimit          int64
 	DisableTxnAutoRetry bool
@@ -429,6 +478,9 @@ type SessionVars struct {
 	// TLSConnectionState is the TLS connection state (nil if not using TLS).
 	TLSConnectionState *tls.ConnectionState
 
+	// ConnectAttrs are the attributes sent by the client when connecting, and the ones of the PROXY protocol header.
+	ConnectAttrs map[string]string
+
 	// ConnectionID is the connection id of the current session.
 	ConnectionID uint64
 
@@ -466,6 +518,9 @@ type SessionVars struct {
 	// TxnReadTS is used for staleness transaction, it provides next staleness transaction startTS.
 	TxnReadTS *TxnReadTS
 
+	// ReadStaleness is the max staleness of the data read by autocommit read-only statements, 0 means disabled.
+	ReadStaleness time.Duration
+
 	// SnapshotInfoschema is used with SnapshotTS, when the schema version at snapshotTS less than current schema
 	// version, we load an old version schema for query.
 	SnapshotInfoschema interface{}
@@ -591,6 +646,9 @@ type SessionVars struct {
 	// BatchDelete indicates if we should split delete data into multiple batches.
 	BatchDelete bool
 
+	// BatchUpdate indicates if we should split update data into multiple batches.
+	BatchUpdate bool
+
 	// BatchCommit indicates if we should split the transaction into multiple batches.
 	BatchCommit bool
 
@@ -622,6 +680,27 @@ type SessionVars struct {
 	// EnableVectorizedExpression  enables the vectorized expression evaluation.
 	EnableVectorizedExpression bool
 
+	// FilterCompileThreshold is the number of rows a filter evaluates in a statement before it's compiled
+	// into a bytecode program, 0 means the filters are never compiled.
+	FilterCompileThreshold int64
+
+	// EnablePlanCacheParamFolding indicates whether to fold the expressions of the cached plans with the
+	// parameters of each execution.
+	EnablePlanCacheParamFolding bool
+
+	// EnableGeneralPlanCache indicates whether to cache the plans of the non-prepared SELECT statements.
+	EnableGeneralPlanCache bool
+
+	// EnableOptimizerNotes indicates whether to explain the decisions of the optimizer which may slow down the
+	// statements by the notes of SHOW WARNINGS.
+	EnableOptimizerNotes bool
+
+	// EnableOptimizerTrace indicates whether to record the decisions of the optimizer into the optimizer trace.
+	EnableOptimizerTrace bool
+
+	// LastOptimizerTrace is the optimizer trace of the last statement whose optimizer trace is recorded.
+	LastOptimizerTrace *OptimizerTrace
+
 	// DDLReorgPriority is the operation priority of adding indices.
 	DDLReorgPriority int
 
@@ -803,6 +882,11 @@ type SessionVars struct {
 	// LastTxnInfo keeps track the info of last committed transaction.
 	LastTxnInfo string
 
+	// LastCommitTS is the largest commit ts of the transactions committed by the session. The bounded staleness reads
+	// of the session never read at a smaller ts, so a replica serves them only after it applies the writes of the
+	// session.
+	LastCommitTS uint64
+
 	// LastQueryInfo keeps track the info of last query.
 	LastQueryInfo QueryInfo
 
@@ -815,24 +899,98 @@ type SessionVars struct {
 	// EnabledRateLimitAction indicates whether enabled ratelimit action during coprocessor
 	EnabledRateLimitAction bool
 
+	// ExecutorCloseConcurrency is the max number of goroutines closing the executors of a statement.
+	ExecutorCloseConcurrency int
+
+	// EnablePaging indicates whether the cop tasks fetch the results page by page. The paged requests are sent by the
+	// streaming API.
+	EnablePaging bool
+
+	// EnableDistSQLBackpressure indicates whether the number of in-flight cop tasks adapts to the drain rate of the consumer.
+	EnableDistSQLBackpressure bool
+
+	// MaxRangesPerCopTask limits the number of the ranges of a region sent in one cop task.
+	MaxRangesPerCopTask int
+
+	// PointRangesBatchGetSize is the number of the keys of a batched point get reading the point ranges of a table
+	// reader, 0 means the point ranges are read by the cop tasks.
+	PointRangesBatchGetSize int
+
+	// RCTSCacheWindow is the window within which the statements in the read-committed pessimistic transactions reuse
+	// the cached TS.
+	RCTSCacheWindow time.Duration
+
+	// EnablePipelinedLock indicates whether the UPDATE statements in the pessimistic transactions acquire the locks of
+	// the written rows asynchronously.
+	EnablePipelinedLock bool
+
+	// EnableFastTableCheck indicates whether ADMIN CHECK TABLE/INDEX compares the checksums of the table and the
+	// indices first.
+	EnableFastTableCheck bool
+
+	// TxnTotalSizeLimit caps the total size of the mutations of a transaction, 0 means using the config.
+	TxnTotalSizeLimit uint64
+
 	// EnableAsyncCommit indicates whether to enable the async commit feature.
 	EnableAsyncCommit bool
 
 	// Enable1PC indicates whether to enable the one-phase commit feature.
 	Enable1PC bool
 
+	// AsyncCommitRegionsLimit is the max number of regions the keys of a transaction can span to use async commit
+	// or one-phase commit. 0 means no limit.
+	AsyncCommitRegionsLimit int
+
 	// GuaranteeLinearizability indicates whether to guarantee linearizability
 	GuaranteeLinearizability bool
 
 	// AnalyzeVersion indicates how TiDB collect and use analyzed statistics.
 	AnalyzeVersion int
 
+	// AnalyzeSampleRate is the rate of the rows sampled by the analyze of version 2, 0 means it's not used.
+	AnalyzeSampleRate float64
+
+	// EnableSelectivityFeedback indicates whether to refine the low confidence selectivity estimations with
+	// the selectivities observed in execution.
+	EnableSelectivityFeedback bool
+
+	// EnableCardinalityFeedback indicates whether to refine the estimated row counts of the index and table ranges
+	// with the row counts observed in execution.
+	EnableCardinalityFeedback bool
+
+	// EnableJoinEstByBucketNDV indicates whether to estimate the row count of the equal join on the single column
+	// indexes by the NDVs of their buckets.
+	EnableJoinEstByBucketNDV bool
+
+	// InlineCTE indicates whether to inline the non-recursive CTEs which are referenced only once instead of
+	// materializing them.
+	InlineCTE bool
+
+	// PartitionWiseJoin indicates whether to join the partition pairs of the tables which are partitioned by the join
+	// key in the same way independently.
+	PartitionWiseJoin bool
+
+	// LoadStatsInSession indicates whether LOAD STATS loads the statistics into the current session only.
+	LoadStatsInSession bool
+
+	// ResourceGroup is the resource group of the statements in the session.
+	ResourceGroup string
+
+	// OverriddenStats are the statistics loaded into the current session by LOAD STATS, they override
+	// the statistics in storage when planning. The keys are the physical table ids and the values are
+	// *statistics.Table.
+	OverriddenStats map[int64]interface{}
+
 	// EnableIndexMergeJoin indicates whether to enable index merge join.
 	EnableIndexMergeJoin bool
 
 	// TrackAggregateMemoryUsage indicates whether to track the memory usage of aggregate function.
 	TrackAggregateMemoryUsage bool
 
+	// EnableTwoLevelHashAgg indicates whether the parallel hash aggregation merges the heavy hitter group keys
+	// in a second level.
+	EnableTwoLevelHashAgg bool
+
 	// TiDBEnableExchangePartition indicates whether to enable exchange partition
 	TiDBEnableExchangePartition bool
 
@@ -876,12 +1034,15 @@ func (s *SessionVars) IsMPPEnforced() bool {
 	return s.allowMPPExecution && s.enforceMPPExecution
 }
 
-// RaiseWarningWhenMPPEnforced will raise a warning when mpp mode is enforced and executing explain statement.
+// RaiseWarningWhenMPPEnforced will raise a warning when mpp mode is enforced and executing explain statement,
+// otherwise it will raise an optimizer note when mpp mode is allowed and some tables have tiflash replicas.
 // TODO: Confirm whether this function will be inlined and
 // omit the overhead of string construction when calling with false condition.
 func (s *SessionVars) RaiseWarningWhenMPPEnforced(warning string) {
 	if s.IsMPPEnforced() && s.StmtCtx.InExplainStmt {
 		s.StmtCtx.AppendWarning(errors.New(warning))
+	} else if s.IsMPPAllowed() && s.StmtCtx.HasTiFlashReplica {
+		s.StmtCtx.AppendOptimizerNote(errors.New(warning))
 	}
 }
 
@@ -1057,11 +1218,28 @@ func NewSessionVars() *SessionVars {
 		PartitionPruneMode:          *atomic2.NewString(DefTiDBPartitionPruneMode),
 		TxnScope:                    kv.GetTxnScopeVar(),
 		EnabledRateLimitAction:      DefTiDBEnableRateLimitAction,
+		EnableDistSQLBackpressure:   DefTiDBEnableDistSQLBackpressure,
+		ExecutorCloseConcurrency:    DefTiDBExecutorCloseConcurrency,
+		EnablePaging:                DefTiDBEnablePaging,
+		MaxRangesPerCopTask:         DefTiDBMaxRangesPerCopTask,
+		PointRangesBatchGetSize:     DefTiDBPointRangesBatchGetSize,
+		RCTSCacheWindow:             DefTiDBRCTSCacheWindow * time.Millisecond,
+		EnablePipelinedLock:         DefTiDBEnablePipelinedLock,
+		EnableFastTableCheck:        DefTiDBEnableFastTableCheck,
+		TxnTotalSizeLimit:           DefTiDBTxnTotalSizeLimit,
 		EnableAsyncCommit:           DefTiDBEnableAsyncCommit,
 		Enable1PC:                   DefTiDBEnable1PC,
+		AsyncCommitRegionsLimit:     DefTiDBAsyncCommitRegionsLimit,
 		GuaranteeLinearizability:    DefTiDBGuaranteeLinearizability,
 		AnalyzeVersion:              DefTiDBAnalyzeVersion,
+		AnalyzeSampleRate:           DefTiDBAnalyzeSampleRate,
+		EnableSelectivityFeedback:   DefTiDBEnableSelectivityFeedback,
+		EnableCardinalityFeedback:   DefTiDBEnableCardinalityFeedback,
+		EnableJoinEstByBucketNDV:    DefTiDBEnableJoinEstByBucketNDV,
+		InlineCTE:                   DefTiDBOptInlineCTE,
+		PartitionWiseJoin:           DefTiDBOptPartitionWiseJoin,
 		EnableIndexMergeJoin:        DefTiDBEnableIndexMergeJoin,
+		EnableTwoLevelHashAgg:       DefTiDBEnableTwoLevelHashAgg,
 		AllowFallbackToTiKV:         make(map[kv.StoreType]struct{}),
 		CTEMaxRecursionDepth:        DefCTEMaxRecursionDepth,
 		TMPTableSize:                DefTMPTableSize,
@@ -1516,6 +1694,12 @@ type TableDelta struct {
 	TableID  int64
 }
 
+// OptimizerTrace is the optimizer trace of a statement.
+type OptimizerTrace struct {
+	Query string
+	Steps []stmtctx.OptimizerTraceStep
+}
+
 // ConcurrencyUnset means the value the of the concurrency related variable is unset.
 const ConcurrencyUnset = -1
 
@@ -1856,6 +2040,10 @@ const (
 	SlowLogBackoffTotal = "Backoff_total"
 	// SlowLogWriteSQLRespTotal is the total time used to write response to client.
 	SlowLogWriteSQLRespTotal = "Write_sql_response_total"
+	// SlowLogWriteSQLRespRows is the number of the rows written to client.
+	SlowLogWriteSQLRespRows = "Write_sql_response_rows"
+	// SlowLogWriteSQLRespBytes is the number of the bytes of the rows written to client.
+	SlowLogWriteSQLRespBytes = "Write_sql_response_bytes"
 	// SlowLogExecRetryCount is the execution retry count.
 	SlowLogExecRetryCount = "Exec_retry_count"
 	// SlowLogExecRetryTime is the execution retry time.
@@ -1888,12 +2076,15 @@ type SlowQueryLogItems struct {
 	HasMoreResults    bool
 	PrevStmt          string
 	Plan              string
+	PlanTree          interface{}
 	PlanDigest        string
 	RewriteInfo       RewritePhaseInfo
 	KVTotal           time.Duration
 	PDTotal           time.Duration
 	BackoffTotal      time.Duration
 	WriteSQLRespTotal time.Duration
+	WriteSQLRespRows  int64
+	WriteSQLRespBytes int64
 	ExecRetryCount    uint
 	ExecRetryTime     time.Duration
 }
@@ -2058,6 +2249,10 @@ func (s *SessionVars) SlowLogFormat(logItems *SlowQueryLogItems) string {
 	writeSlowLogItem(&buf, SlowLogPDTotal, strconv.FormatFloat(logItems.PDTotal.Seconds(), 'f', -1, 64))
 	writeSlowLogItem(&buf, SlowLogBackoffTotal, strconv.FormatFloat(logItems.BackoffTotal.Seconds(), 'f', -1, 64))
 	writeSlowLogItem(&buf, SlowLogWriteSQLRespTotal, strconv.FormatFloat(logItems.WriteSQLRespTotal.Seconds(), 'f', -1, 64))
+	if logItems.WriteSQLRespRows > 0 {
+		writeSlowLogItem(&buf, SlowLogWriteSQLRespRows, strconv.FormatInt(logItems.WriteSQLRespRows, 10))
+		writeSlowLogItem(&buf, SlowLogWriteSQLRespBytes, strconv.FormatInt(logItems.WriteSQLRespBytes, 10))
+	}
 	writeSlowLogItem(&buf, SlowLogSucc, strconv.FormatBool(logItems.Succ))
 	if len(logItems.Plan) != 0 {
 		writeSlowLogItem(&buf, SlowLogPlan, logItems.Plan)
@@ -14,6 +14,8 @@
 package variable_test
 
 import (
+	"encoding/json"
+	"strings"
 	"time"
 
 	. "github.com/pingcap/check"
@@ -269,6 +271,65 @@ func (*testSessionSuite) TestSlowLogFormat(c *C) {
 	c.Assert(seVar.CurrentDBChanged, IsFalse)
 }
 
+func (*testSessionSuite) TestSlowLogJSONFormat(c *C) {
+	seVar := mock.NewContext().GetSessionVars()
+	seVar.User = &auth.UserIdentity{Username: "root", Hostname: "localhost"}
+	seVar.ConnectionInfo = &variable.ConnectionInfo{ClientIP: "192.168.0.1"}
+	seVar.ConnectionID = 1
+	seVar.CurrentDB = "test"
+	seVar.CurrentDBChanged = true
+	logItems := &variable.SlowQueryLogItems{
+		TxnTS:      406649736972468225,
+		SQL:        "select * from t\nwhere a = 1;",
+		TimeTotal:  time.Second,
+		StatsInfos: map[string]uint64{"t": 0},
+		CopTasks: &stmtctx.CopTasksDetails{
+			NumCopTasks:       2,
+			AvgProcessTime:    time.Second,
+			P90ProcessTime:    time.Second * 2,
+			MaxProcessTime:    time.Second * 2,
+			MaxProcessAddress: "10.6.131.78",
+			TotBackoffTimes:   map[string]int{"regionMiss": 2},
+			TotBackoffTime:    map[string]time.Duration{"regionMiss": time.Millisecond * 200},
+		},
+		ExecDetail: execdetails.ExecDetails{
+			MPPTasks:         4,
+			MPPTime:          time.Second,
+			LockKeysDuration: time.Millisecond * 500,
+			LockKeysDetail:   &util.LockKeysDetails{LockKeys: 10, RegionNum: 2},
+		},
+		Succ:     true,
+		PlanTree: []map[string]string{{"id": "TableReader_5"}},
+	}
+	logString, err := seVar.SlowLogJSONFormat(logItems)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(logString, "\n"), IsFalse)
+	var l map[string]interface{}
+	c.Assert(json.Unmarshal([]byte(logString), &l), IsNil)
+	c.Assert(l["Txn_start_ts"], Equals, float64(406649736972468225))
+	c.Assert(l["User"], Equals, "root")
+	c.Assert(l["Host"], Equals, "192.168.0.1")
+	c.Assert(l["DB"], Equals, "test")
+	c.Assert(l["Query_time"], Equals, float64(1))
+	c.Assert(l["Stats"], DeepEquals, map[string]interface{}{"t": "pseudo"})
+	c.Assert(l["Cop_tasks"], DeepEquals, map[string]interface{}{
+		"Num": float64(2), "Proc_avg": float64(1), "Proc_p90": float64(2), "Proc_max": float64(2),
+		"Proc_addr": "10.6.131.78", "Wait_avg": float64(0), "Wait_p90": float64(0), "Wait_max": float64(0),
+		"Backoff": map[string]interface{}{"regionMiss": map[string]interface{}{
+			"total_times": float64(2), "total_time": 0.2, "max_time": float64(0), "max_addr": "",
+			"avg_time": float64(0), "p90_time": float64(0),
+		}},
+	})
+	c.Assert(l["MPP_tasks"], DeepEquals, map[string]interface{}{"Num": float64(4), "Time": float64(1)})
+	c.Assert(l["Lock_keys"], DeepEquals, map[string]interface{}{"Time": 0.5, "Keys": float64(10), "Regions": float64(2)})
+	c.Assert(l["Plan"], DeepEquals, []interface{}{map[string]interface{}{"id": "TableReader_5"}})
+	c.Assert(l["Succ"], Equals, true)
+	c.Assert(l["Query"], Equals, "select * from t\nwhere a = 1;")
+	_, ok := l["Commit"]
+	c.Assert(ok, IsFalse)
+	c.Assert(seVar.CurrentDBChanged, IsFalse)
+}
+
 func (*testSessionSuite) TestIsolationRead(c *C) {
 	defer config.RestoreFunc()()
 	config.UpdateGlobal(func(conf *config.Config) {
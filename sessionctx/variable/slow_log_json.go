@@ -0,0 +1,274 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package variable
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/util/logutil"
+)
+
+// The formats of the slow log, see tidb_slow_log_format.
+const (
+	// SlowLogFormatText is the format of the slow log which is parsed by information_schema.slow_query, see
+	// SessionVars.SlowLogFormat.
+	SlowLogFormatText = "text"
+	// SlowLogFormatJSON is the format of the slow log in which a slow query is a JSON document in one line, see
+	// SessionVars.SlowLogJSONFormat.
+	SlowLogFormatJSON = "json"
+)
+
+// The keys of the JSON slow log are the same as the field names of the text slow log, and the details of the cop
+// tasks, MPP tasks, lock keys and the plan are grouped into their own objects.
+type slowLogJSON struct {
+	Time              string               `json:"Time"`
+	TxnStartTS        uint64               `json:"Txn_start_ts"`
+	User              string               `json:"User,omitempty"`
+	Host              string               `json:"Host,omitempty"`
+	ConnID            uint64               `json:"Conn_ID,omitempty"`
+	ExecRetryCount    uint                 `json:"Exec_retry_count,omitempty"`
+	ExecRetryTime     float64              `json:"Exec_retry_time,omitempty"`
+	QueryTime         float64              `json:"Query_time"`
+	ParseTime         float64              `json:"Parse_time"`
+	CompileTime       float64              `json:"Compile_time"`
+	RewriteTime       float64              `json:"Rewrite_time"`
+	PreprocSubQueries int                  `json:"Preproc_subqueries,omitempty"`
+	PreprocSubQTime   float64              `json:"Preproc_subqueries_time,omitempty"`
+	OptimizeTime      float64              `json:"Optimize_time"`
+	WaitTSTime        float64              `json:"Wait_TS"`
+	CopTime           float64              `json:"Cop_time,omitempty"`
+	ProcessTime       float64              `json:"Process_time,omitempty"`
+	WaitTime          float64              `json:"Wait_time,omitempty"`
+	BackoffTime       float64              `json:"Backoff_time,omitempty"`
+	RequestCount      int                  `json:"Request_count,omitempty"`
+	ProcessKeys       int64                `json:"Process_keys,omitempty"`
+	TotalKeys         int64                `json:"Total_keys,omitempty"`
+	Commit            *slowLogCommitJSON   `json:"Commit,omitempty"`
+	DB                string               `json:"DB,omitempty"`
+	IndexNames        string               `json:"Index_names,omitempty"`
+	IsInternal        bool                 `json:"Is_internal"`
+	Digest            string               `json:"Digest,omitempty"`
+	Stats             map[string]string    `json:"Stats,omitempty"`
+	CopTasks          *slowLogCopTasksJSON `json:"Cop_tasks,omitempty"`
+	MPPTasks          *slowLogMPPTasksJSON `json:"MPP_tasks,omitempty"`
+	LockKeys          *slowLogLockKeysJSON `json:"Lock_keys,omitempty"`
+	MemMax            int64                `json:"Mem_max,omitempty"`
+	DiskMax           int64                `json:"Disk_max,omitempty"`
+	Prepared          bool                 `json:"Prepared"`
+	PlanFromCache     bool                 `json:"Plan_from_cache"`
+	PlanFromBinding   bool                 `json:"Plan_from_binding"`
+	HasMoreResults    bool                 `json:"Has_more_results"`
+	KVTotal           float64              `json:"KV_total"`
+	PDTotal           float64              `json:"PD_total"`
+	BackoffTotal      float64              `json:"Backoff_total"`
+	WriteSQLRespTotal float64              `json:"Write_sql_response_total"`
+	WriteSQLRespRows  int64                `json:"Write_sql_response_rows,omitempty"`
+	WriteSQLRespBytes int64                `json:"Write_sql_response_bytes,omitempty"`
+	Succ              bool                 `json:"Succ"`
+	Plan              interface{}          `json:"Plan,omitempty"`
+	PlanDigest        string               `json:"Plan_digest,omitempty"`
+	PrevStmt          string               `json:"Prev_stmt,omitempty"`
+	Query             string               `json:"Query"`
+}
+
+type slowLogCommitJSON struct {
+	PrewriteTime    float64 `json:"Prewrite_time,omitempty"`
+	CommitTime      float64 `json:"Commit_time,omitempty"`
+	GetCommitTSTime float64 `json:"Get_commit_ts_time,omitempty"`
+	ResolveLockTime float64 `json:"Resolve_lock_time,omitempty"`
+	LocalLatchTime  float64 `json:"Local_latch_wait_time,omitempty"`
+	WriteKeys       int     `json:"Write_keys,omitempty"`
+	WriteSize       int     `json:"Write_size,omitempty"`
+	PrewriteRegion  int32   `json:"Prewrite_region,omitempty"`
+	TxnRetry        int     `json:"Txn_retry,omitempty"`
+}
+
+type slowLogBackoffJSON struct {
+	TotalTimes int     `json:"total_times"`
+	TotalTime  float64 `json:"total_time"`
+	MaxTime    float64 `json:"max_time"`
+	MaxAddr    string  `json:"max_addr"`
+	AvgTime    float64 `json:"avg_time"`
+	P90Time    float64 `json:"p90_time"`
+}
+
+type slowLogCopTasksJSON struct {
+	Num      int                           `json:"Num"`
+	ProcAvg  float64                       `json:"Proc_avg"`
+	ProcP90  float64                       `json:"Proc_p90"`
+	ProcMax  float64                       `json:"Proc_max"`
+	ProcAddr string                        `json:"Proc_addr,omitempty"`
+	WaitAvg  float64                       `json:"Wait_avg"`
+	WaitP90  float64                       `json:"Wait_p90"`
+	WaitMax  float64                       `json:"Wait_max"`
+	WaitAddr string                        `json:"Wait_addr,omitempty"`
+	Backoffs map[string]slowLogBackoffJSON `json:"Backoff,omitempty"`
+}
+
+type slowLogMPPTasksJSON struct {
+	Num  int     `json:"Num"`
+	Time float64 `json:"Time"`
+}
+
+type slowLogLockKeysJSON struct {
+	Time                  float64  `json:"Time"`
+	Keys                  int32    `json:"Keys"`
+	Regions               int32    `json:"Regions"`
+	ResolveLockTime       float64  `json:"Resolve_lock_time,omitempty"`
+	BackoffTime           float64  `json:"Backoff_time,omitempty"`
+	BackoffTypes          []string `json:"Backoff_types,omitempty"`
+	RPCTime               float64  `json:"RPC_time,omitempty"`
+	RPCCount              int64    `json:"RPC_count,omitempty"`
+	RetryCount            int      `json:"Retry_count,omitempty"`
+	PessimisticLockWaited bool     `json:"Pessimistic_lock_waited,omitempty"`
+}
+
+// SlowLogJSONFormat formats the slow log as a JSON document in one line, for the slow log ingested by machines. It has
+// the same items as SessionVars.SlowLogFormat, and the plan tree is logItems.PlanTree instead of the encoded plan.
+func (s *SessionVars) SlowLogJSONFormat(logItems *SlowQueryLogItems) (string, error) {
+	l := &slowLogJSON{
+		Time:              time.Now().Format(logutil.SlowLogTimeFormat),
+		TxnStartTS:        logItems.TxnTS,
+		ConnID:            s.ConnectionID,
+		ExecRetryCount:    logItems.ExecRetryCount,
+		ExecRetryTime:     logItems.ExecRetryTime.Seconds(),
+		QueryTime:         logItems.TimeTotal.Seconds(),
+		ParseTime:         logItems.TimeParse.Seconds(),
+		CompileTime:       logItems.TimeCompile.Seconds(),
+		RewriteTime:       logItems.RewriteInfo.DurationRewrite.Seconds(),
+		PreprocSubQueries: logItems.RewriteInfo.PreprocessSubQueries,
+		PreprocSubQTime:   logItems.RewriteInfo.DurationPreprocessSubQuery.Seconds(),
+		OptimizeTime:      logItems.TimeOptimize.Seconds(),
+		WaitTSTime:        logItems.TimeWaitTS.Seconds(),
+		DB:                s.CurrentDB,
+		IndexNames:        logItems.IndexNames,
+		IsInternal:        s.InRestrictedSQL,
+		Digest:            logItems.Digest,
+		MemMax:            logItems.MemMax,
+		DiskMax:           logItems.DiskMax,
+		Prepared:          logItems.Prepared,
+		PlanFromCache:     logItems.PlanFromCache,
+		PlanFromBinding:   logItems.PlanFromBinding,
+		HasMoreResults:    logItems.HasMoreResults,
+		KVTotal:           logItems.KVTotal.Seconds(),
+		PDTotal:           logItems.PDTotal.Seconds(),
+		BackoffTotal:      logItems.BackoffTotal.Seconds(),
+		WriteSQLRespTotal: logItems.WriteSQLRespTotal.Seconds(),
+		WriteSQLRespRows:  logItems.WriteSQLRespRows,
+		WriteSQLRespBytes: logItems.WriteSQLRespBytes,
+		Succ:              logItems.Succ,
+		Plan:              logItems.PlanTree,
+		PlanDigest:        logItems.PlanDigest,
+		PrevStmt:          logItems.PrevStmt,
+		Query:             logItems.SQL,
+	}
+	if s.User != nil {
+		l.User, l.Host = s.User.Username, s.User.Hostname
+		if s.ConnectionInfo != nil {
+			l.Host = s.ConnectionInfo.ClientIP
+		}
+	}
+	detail := &logItems.ExecDetail
+	l.CopTime = detail.CopTime.Seconds()
+	l.ProcessTime = detail.TimeDetail.ProcessTime.Seconds()
+	l.WaitTime = detail.TimeDetail.WaitTime.Seconds()
+	l.BackoffTime = detail.BackoffTime.Seconds()
+	l.RequestCount = detail.RequestCount
+	if detail.ScanDetail != nil {
+		l.ProcessKeys, l.TotalKeys = detail.ScanDetail.ProcessedKeys, detail.ScanDetail.TotalKeys
+	}
+	if c := detail.CommitDetail; c != nil {
+		l.Commit = &slowLogCommitJSON{
+			PrewriteTime:    c.PrewriteTime.Seconds(),
+			CommitTime:      c.CommitTime.Seconds(),
+			GetCommitTSTime: c.GetCommitTsTime.Seconds(),
+			ResolveLockTime: time.Duration(atomic.LoadInt64(&c.ResolveLockTime)).Seconds(),
+			LocalLatchTime:  c.LocalLatchTime.Seconds(),
+			WriteKeys:       c.WriteKeys,
+			WriteSize:       c.WriteSize,
+			PrewriteRegion:  atomic.LoadInt32(&c.PrewriteRegionNum),
+			TxnRetry:        c.TxnRetry,
+		}
+	}
+	if len(logItems.StatsInfos) > 0 {
+		l.Stats = make(map[string]string, len(logItems.StatsInfos))
+		for tbl, version := range logItems.StatsInfos {
+			if version == 0 {
+				l.Stats[tbl] = "pseudo"
+			} else {
+				l.Stats[tbl] = strconv.FormatUint(version, 10)
+			}
+		}
+	}
+	if cop := logItems.CopTasks; cop != nil && cop.NumCopTasks > 0 {
+		l.CopTasks = &slowLogCopTasksJSON{
+			Num:      cop.NumCopTasks,
+			ProcAvg:  cop.AvgProcessTime.Seconds(),
+			ProcP90:  cop.P90ProcessTime.Seconds(),
+			ProcMax:  cop.MaxProcessTime.Seconds(),
+			ProcAddr: cop.MaxProcessAddress,
+			WaitAvg:  cop.AvgWaitTime.Seconds(),
+			WaitP90:  cop.P90WaitTime.Seconds(),
+			WaitMax:  cop.MaxWaitTime.Seconds(),
+			WaitAddr: cop.MaxWaitAddress,
+		}
+		if len(cop.TotBackoffTimes) > 0 {
+			l.CopTasks.Backoffs = make(map[string]slowLogBackoffJSON, len(cop.TotBackoffTimes))
+			for backoff, times := range cop.TotBackoffTimes {
+				l.CopTasks.Backoffs[backoff] = slowLogBackoffJSON{
+					TotalTimes: times,
+					TotalTime:  cop.TotBackoffTime[backoff].Seconds(),
+					MaxTime:    cop.MaxBackoffTime[backoff].Seconds(),
+					MaxAddr:    cop.MaxBackoffAddress[backoff],
+					AvgTime:    cop.AvgBackoffTime[backoff].Seconds(),
+					P90Time:    cop.P90BackoffTime[backoff].Seconds(),
+				}
+			}
+		}
+	}
+	if detail.MPPTasks > 0 {
+		l.MPPTasks = &slowLogMPPTasksJSON{Num: detail.MPPTasks, Time: detail.MPPTime.Seconds()}
+	}
+	pessimisticWaited := s.StmtCtx != nil && atomic.LoadInt32(&s.StmtCtx.PessimisticLockWaited) > 0
+	if lock := detail.LockKeysDetail; lock != nil || pessimisticWaited {
+		l.LockKeys = &slowLogLockKeysJSON{
+			Time:                  detail.LockKeysDuration.Seconds(),
+			PessimisticLockWaited: pessimisticWaited,
+		}
+		if lock != nil {
+			l.LockKeys.Keys = lock.LockKeys
+			l.LockKeys.Regions = lock.RegionNum
+			l.LockKeys.ResolveLockTime = time.Duration(atomic.LoadInt64(&lock.ResolveLockTime)).Seconds()
+			l.LockKeys.BackoffTime = time.Duration(atomic.LoadInt64(&lock.BackoffTime)).Seconds()
+			lock.Mu.Lock()
+			l.LockKeys.BackoffTypes = append([]string(nil), lock.Mu.BackoffTypes...)
+			lock.Mu.Unlock()
+			l.LockKeys.RPCTime = time.Duration(atomic.LoadInt64(&lock.LockRPCTime)).Seconds()
+			l.LockKeys.RPCCount = atomic.LoadInt64(&lock.LockRPCCount)
+			l.LockKeys.RetryCount = lock.RetryCount
+		}
+	}
+	// The current database is always recorded, so it's not printed again in the text slow log next time.
+	s.CurrentDBChanged = false
+
+	data, err := json.Marshal(l)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return string(data), nil
+}
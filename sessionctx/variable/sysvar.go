@@ -32,6 +32,7 @@ import (
 	"github.com/pingcap/tidb/types"
 	"github.com/pingcap/tidb/util/collate"
 	"github.com/pingcap/tidb/util/logutil"
+	"github.com/pingcap/tidb/util/plancapture"
 	"github.com/pingcap/tidb/util/stmtsummary"
 	"github.com/pingcap/tidb/util/versioninfo"
 	tikvstore "github.com/tikv/client-go/v2/kv"
@@ -897,6 +898,7 @@ var defaultSysVars = []*SysVar{
 	{Scope: ScopeGlobal, Name: TiDBAutoAnalyzeRatio, Value: strconv.FormatFloat(DefAutoAnalyzeRatio, 'f', -1, 64), Type: TypeFloat, MinValue: 0, MaxValue: math.MaxUint64},
 	{Scope: ScopeGlobal, Name: TiDBAutoAnalyzeStartTime, Value: DefAutoAnalyzeStartTime, Type: TypeTime},
 	{Scope: ScopeGlobal, Name: TiDBAutoAnalyzeEndTime, Value: DefAutoAnalyzeEndTime, Type: TypeTime},
+	{Scope: ScopeGlobal, Name: TiDBAutoAnalyzeConcurrency, Value: strconv.Itoa(DefAutoAnalyzeConcurrency), Type: TypeUnsigned, MinValue: 1, MaxValue: 64},
 	{Scope: ScopeSession, Name: TiDBChecksumTableConcurrency, skipInit: true, Value: strconv.Itoa(DefChecksumTableConcurrency)},
 	{Scope: ScopeGlobal | ScopeSession, Name: TiDBExecutorConcurrency, Value: strconv.Itoa(DefExecutorConcurrency), Type: TypeUnsigned, MinValue: 1, MaxValue: math.MaxInt32, SetSession: func(s *SessionVars, val string) error {
 		s.ExecutorConcurrency = tidbOptPositiveInt32(val, DefExecutorConcurrency)
@@ -1004,6 +1006,10 @@ var defaultSysVars = []*SysVar{
 		s.BatchDelete = TiDBOptOn(val)
 		return nil
 	}},
+	{Scope: ScopeSession, Name: TiDBBatchUpdate, Value: BoolToOnOff(DefBatchUpdate), Type: TypeBool, skipInit: true, SetSession: func(s *SessionVars, val string) error {
+		s.BatchUpdate = TiDBOptOn(val)
+		return nil
+	}},
 	{Scope: ScopeSession, Name: TiDBBatchCommit, Value: BoolToOnOff(DefBatchCommit), Type: TypeBool, skipInit: true, SetSession: func(s *SessionVars, val string) error {
 		s.BatchCommit = TiDBOptOn(val)
 		return nil
@@ -1169,6 +1175,10 @@ var defaultSysVars = []*SysVar{
 		appendDeprecationWarning(vars, TiDBStreamAggConcurrency, TiDBExecutorConcurrency)
 		return normalizedValue, nil
 	}},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBExecutorCloseConcurrency, Value: strconv.Itoa(DefTiDBExecutorCloseConcurrency), Type: TypeUnsigned, MinValue: 1, MaxValue: 256, SetSession: func(s *SessionVars, val string) error {
+		s.ExecutorCloseConcurrency = tidbOptPositiveInt32(val, DefTiDBExecutorCloseConcurrency)
+		return nil
+	}},
 	{Scope: ScopeGlobal | ScopeSession, Name: TiDBEnableParallelApply, Value: BoolToOnOff(DefTiDBEnableParallelApply), Type: TypeBool, SetSession: func(s *SessionVars, val string) error {
 		s.EnableParallelApply = TiDBOptOn(val)
 		return nil
@@ -1231,6 +1241,26 @@ var defaultSysVars = []*SysVar{
 		s.EnableVectorizedExpression = TiDBOptOn(val)
 		return nil
 	}},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBFilterCompileThreshold, Value: strconv.Itoa(DefTiDBFilterCompileThreshold), Type: TypeUnsigned, MinValue: 0, MaxValue: math.MaxInt64, IsHintUpdatable: true, SetSession: func(s *SessionVars, val string) error {
+		s.FilterCompileThreshold = tidbOptInt64(val, DefTiDBFilterCompileThreshold)
+		return nil
+	}},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBEnablePlanCacheParamFolding, Value: BoolToOnOff(DefTiDBEnablePlanCacheParamFolding), Type: TypeBool, IsHintUpdatable: true, SetSession: func(s *SessionVars, val string) error {
+		s.EnablePlanCacheParamFolding = TiDBOptOn(val)
+		return nil
+	}},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBEnableGeneralPlanCache, Value: BoolToOnOff(DefTiDBEnableGeneralPlanCache), Type: TypeBool, SetSession: func(s *SessionVars, val string) error {
+		s.EnableGeneralPlanCache = TiDBOptOn(val)
+		return nil
+	}},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBEnableOptimizerNotes, Value: BoolToOnOff(DefTiDBEnableOptimizerNotes), Type: TypeBool, SetSession: func(s *SessionVars, val string) error {
+		s.EnableOptimizerNotes = TiDBOptOn(val)
+		return nil
+	}},
+	{Scope: ScopeSession, Name: TiDBEnableOptimizerTrace, Value: BoolToOnOff(DefTiDBEnableOptimizerTrace), Type: TypeBool, SetSession: func(s *SessionVars, val string) error {
+		s.EnableOptimizerTrace = TiDBOptOn(val)
+		return nil
+	}},
 	{Scope: ScopeGlobal | ScopeSession, Name: TiDBEnableFastAnalyze, Value: BoolToOnOff(DefTiDBUseFastAnalyze), Type: TypeBool, SetSession: func(s *SessionVars, val string) error {
 		s.EnableFastAnalyze = TiDBOptOn(val)
 		return nil
@@ -1240,6 +1270,34 @@ var defaultSysVars = []*SysVar{
 		s.EnabledRateLimitAction = TiDBOptOn(val)
 		return nil
 	}},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBEnablePaging, Value: BoolToOnOff(DefTiDBEnablePaging), Type: TypeBool, IsHintUpdatable: true, SetSession: func(s *SessionVars, val string) error {
+		s.EnablePaging = TiDBOptOn(val)
+		return nil
+	}},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBEnableDistSQLBackpressure, Value: BoolToOnOff(DefTiDBEnableDistSQLBackpressure), Type: TypeBool, SetSession: func(s *SessionVars, val string) error {
+		s.EnableDistSQLBackpressure = TiDBOptOn(val)
+		return nil
+	}},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBMaxRangesPerCopTask, Value: strconv.Itoa(DefTiDBMaxRangesPerCopTask), Type: TypeUnsigned, MinValue: 1, MaxValue: DefTiDBMaxRangesPerCopTask, IsHintUpdatable: true, SetSession: func(s *SessionVars, val string) error {
+		s.MaxRangesPerCopTask = tidbOptPositiveInt32(val, DefTiDBMaxRangesPerCopTask)
+		return nil
+	}},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBPointRangesBatchGetSize, Value: strconv.Itoa(DefTiDBPointRangesBatchGetSize), Type: TypeUnsigned, MinValue: 0, MaxValue: math.MaxInt32, IsHintUpdatable: true, SetSession: func(s *SessionVars, val string) error {
+		s.PointRangesBatchGetSize = tidbOptInt(val, DefTiDBPointRangesBatchGetSize)
+		return nil
+	}},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBRCTSCacheWindow, Value: strconv.Itoa(DefTiDBRCTSCacheWindow), Type: TypeUnsigned, MinValue: 0, MaxValue: MaxRCTSCacheWindow, SetSession: func(s *SessionVars, val string) error {
+		s.RCTSCacheWindow = time.Duration(tidbOptInt64(val, DefTiDBRCTSCacheWindow)) * time.Millisecond
+		return nil
+	}},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBEnablePipelinedLock, Value: BoolToOnOff(DefTiDBEnablePipelinedLock), Type: TypeBool, SetSession: func(s *SessionVars, val string) error {
+		s.EnablePipelinedLock = TiDBOptOn(val)
+		return nil
+	}},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBEnableFastTableCheck, Value: BoolToOnOff(DefTiDBEnableFastTableCheck), Type: TypeBool, SetSession: func(s *SessionVars, val string) error {
+		s.EnableFastTableCheck = TiDBOptOn(val)
+		return nil
+	}},
 	{Scope: ScopeGlobal | ScopeSession, Name: TiDBAllowFallbackToTiKV, Value: "", Validation: func(vars *SessionVars, normalizedValue string, originalValue string, scope ScopeFlag) (string, error) {
 		if normalizedValue == "" {
 			return "", nil
@@ -1312,6 +1370,12 @@ var defaultSysVars = []*SysVar{
 		SetDDLReorgBatchSize(int32(tidbOptPositiveInt32(val, DefTiDBDDLReorgBatchSize)))
 		return nil
 	}},
+	{Scope: ScopeGlobal, Name: TiDBDDLReorgPausedJobs, Value: "", Validation: func(vars *SessionVars, normalizedValue string, originalValue string, scope ScopeFlag) (string, error) {
+		if _, err := ParseDDLReorgPausedJobs(normalizedValue); err != nil {
+			return normalizedValue, ErrWrongValueForVar.GenWithStackByArgs(TiDBDDLReorgPausedJobs, originalValue)
+		}
+		return normalizedValue, nil
+	}},
 	{Scope: ScopeGlobal, Name: TiDBDDLErrorCountLimit, Value: strconv.Itoa(DefTiDBDDLErrorCountLimit), Type: TypeUnsigned, MinValue: 0, MaxValue: math.MaxInt64, AutoConvertOutOfRange: true, SetSession: func(s *SessionVars, val string) error {
 		SetDDLErrorCountLimit(tidbOptInt64(val, DefTiDBDDLErrorCountLimit))
 		return nil
@@ -1402,16 +1466,24 @@ var defaultSysVars = []*SysVar{
 		s.EnableNoopFuncs = TiDBOptOn(val)
 		return nil
 	}},
-	{Scope: ScopeSession, Name: TiDBReplicaRead, Value: "leader", Type: TypeEnum, PossibleValues: []string{"leader", "follower", "leader-and-follower"}, skipInit: true, SetSession: func(s *SessionVars, val string) error {
+	{Scope: ScopeSession, Name: TiDBReplicaRead, Value: "leader", Type: TypeEnum, PossibleValues: []string{"leader", "follower", "leader-and-follower", "learner", "closest-replicas"}, skipInit: true, IsHintUpdatable: true, SetSession: func(s *SessionVars, val string) error {
 		if strings.EqualFold(val, "follower") {
 			s.SetReplicaRead(kv.ReplicaReadFollower)
 		} else if strings.EqualFold(val, "leader-and-follower") {
 			s.SetReplicaRead(kv.ReplicaReadMixed)
+		} else if strings.EqualFold(val, "learner") {
+			s.SetReplicaRead(kv.ReplicaReadLearner)
+		} else if strings.EqualFold(val, "closest-replicas") {
+			s.SetReplicaRead(kv.ReplicaReadClosest)
 		} else if strings.EqualFold(val, "leader") || len(val) == 0 {
 			s.SetReplicaRead(kv.ReplicaReadLeader)
 		}
 		return nil
 	}},
+	{Scope: ScopeSession, Name: TiDBReadStaleness, Value: "0", Type: TypeInt, MinValue: math.MinInt32, MaxValue: 0, skipInit: true, IsHintUpdatable: true, SetSession: func(s *SessionVars, val string) error {
+		s.ReadStaleness = -time.Duration(tidbOptInt64(val, 0)) * time.Second
+		return nil
+	}},
 	{Scope: ScopeSession, Name: TiDBAllowRemoveAutoInc, Value: BoolToOnOff(DefTiDBAllowRemoveAutoInc), skipInit: true, Type: TypeBool, SetSession: func(s *SessionVars, val string) error {
 		s.AllowRemoveAutoInc = TiDBOptOn(val)
 		return nil
@@ -1617,6 +1689,10 @@ var defaultSysVars = []*SysVar{
 		s.EnableAmendPessimisticTxn = TiDBOptOn(val)
 		return nil
 	}},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBTxnTotalSizeLimit, Value: strconv.Itoa(DefTiDBTxnTotalSizeLimit), Type: TypeUnsigned, MinValue: 0, MaxValue: 10 << 30, SetSession: func(s *SessionVars, val string) error {
+		s.TxnTotalSizeLimit = uint64(tidbOptInt64(val, DefTiDBTxnTotalSizeLimit))
+		return nil
+	}},
 	{Scope: ScopeGlobal | ScopeSession, Name: TiDBEnableAsyncCommit, Value: BoolToOnOff(DefTiDBEnableAsyncCommit), Hidden: true, Type: TypeBool, SetSession: func(s *SessionVars, val string) error {
 		s.EnableAsyncCommit = TiDBOptOn(val)
 		return nil
@@ -1625,6 +1701,10 @@ var defaultSysVars = []*SysVar{
 		s.Enable1PC = TiDBOptOn(val)
 		return nil
 	}},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBAsyncCommitRegionsLimit, Value: strconv.Itoa(DefTiDBAsyncCommitRegionsLimit), Hidden: true, Type: TypeUnsigned, MinValue: 0, MaxValue: math.MaxInt32, SetSession: func(s *SessionVars, val string) error {
+		s.AsyncCommitRegionsLimit = tidbOptInt(val, DefTiDBAsyncCommitRegionsLimit)
+		return nil
+	}},
 	{Scope: ScopeGlobal | ScopeSession, Name: TiDBGuaranteeLinearizability, Value: BoolToOnOff(DefTiDBGuaranteeLinearizability), Hidden: true, Type: TypeBool, SetSession: func(s *SessionVars, val string) error {
 		s.GuaranteeLinearizability = TiDBOptOn(val)
 		return nil
@@ -1649,6 +1729,51 @@ var defaultSysVars = []*SysVar{
 		s.AnalyzeVersion = tidbOptPositiveInt32(val, DefTiDBAnalyzeVersion)
 		return nil
 	}},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBAnalyzeSampleRate, Value: strconv.FormatFloat(DefTiDBAnalyzeSampleRate, 'f', -1, 64), Type: TypeFloat, MinValue: 0, MaxValue: 1, SetSession: func(s *SessionVars, val string) error {
+		s.AnalyzeSampleRate = tidbOptFloat64(val, DefTiDBAnalyzeSampleRate)
+		return nil
+	}},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBEnableSelectivityFeedback, Value: BoolToOnOff(DefTiDBEnableSelectivityFeedback), Type: TypeBool, SetSession: func(s *SessionVars, val string) error {
+		s.EnableSelectivityFeedback = TiDBOptOn(val)
+		return nil
+	}},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBEnableCardinalityFeedback, Value: BoolToOnOff(DefTiDBEnableCardinalityFeedback), Type: TypeBool, SetSession: func(s *SessionVars, val string) error {
+		s.EnableCardinalityFeedback = TiDBOptOn(val)
+		return nil
+	}},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBEnableJoinEstByBucketNDV, Value: BoolToOnOff(DefTiDBEnableJoinEstByBucketNDV), Type: TypeBool, SetSession: func(s *SessionVars, val string) error {
+		s.EnableJoinEstByBucketNDV = TiDBOptOn(val)
+		return nil
+	}},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBOptInlineCTE, Value: BoolToOnOff(DefTiDBOptInlineCTE), Type: TypeBool, SetSession: func(s *SessionVars, val string) error {
+		s.InlineCTE = TiDBOptOn(val)
+		return nil
+	}},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBOptPartitionWiseJoin, Value: BoolToOnOff(DefTiDBOptPartitionWiseJoin), Type: TypeBool, SetSession: func(s *SessionVars, val string) error {
+		s.PartitionWiseJoin = TiDBOptOn(val)
+		return nil
+	}},
+	{Scope: ScopeSession, Name: TiDBOptUseInvisibleIndexes, Value: BoolToOnOff(DefTiDBOptUseInvisibleIndexes), Type: TypeBool, SetSession: func(s *SessionVars, val string) error {
+		s.OptimizerUseInvisibleIndexes = TiDBOptOn(val)
+		return nil
+	}},
+	{Scope: ScopeSession, Name: TiDBLoadStatsInSession, Value: BoolToOnOff(DefTiDBLoadStatsInSession), Type: TypeBool, SetSession: func(s *SessionVars, val string) error {
+		s.LoadStatsInSession = TiDBOptOn(val)
+		// The statistics loaded into the session are dropped when it's turned off.
+		if !s.LoadStatsInSession {
+			s.OverriddenStats = nil
+		}
+		return nil
+	}},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBResourceGroup, Value: "", IsHintUpdatable: true, Validation: func(vars *SessionVars, normalizedValue string, originalValue string, scope ScopeFlag) (string, error) {
+		if len(normalizedValue) > 0 && config.GetGlobalConfig().GetResourceGroup(normalizedValue) == nil {
+			return normalizedValue, ErrWrongValueForVar.GenWithStackByArgs(TiDBResourceGroup, originalValue)
+		}
+		return normalizedValue, nil
+	}, SetSession: func(s *SessionVars, val string) error {
+		s.ResourceGroup = val
+		return nil
+	}},
 	{Scope: ScopeGlobal | ScopeSession, Name: TiDBEnableIndexMergeJoin, Value: BoolToOnOff(DefTiDBEnableIndexMergeJoin), Hidden: true, Type: TypeBool, SetSession: func(s *SessionVars, val string) error {
 		s.EnableIndexMergeJoin = TiDBOptOn(val)
 		return nil
@@ -1657,6 +1782,10 @@ var defaultSysVars = []*SysVar{
 		s.TrackAggregateMemoryUsage = TiDBOptOn(val)
 		return nil
 	}},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBEnableTwoLevelHashAgg, Value: BoolToOnOff(DefTiDBEnableTwoLevelHashAgg), Type: TypeBool, IsHintUpdatable: true, SetSession: func(s *SessionVars, val string) error {
+		s.EnableTwoLevelHashAgg = TiDBOptOn(val)
+		return nil
+	}},
 	{Scope: ScopeGlobal | ScopeSession, Name: TiDBMultiStatementMode, Value: Off, Type: TypeEnum, PossibleValues: []string{Off, On, Warn}, SetSession: func(s *SessionVars, val string) error {
 		s.MultiStatementMode = TiDBOptMultiStmt(val)
 		return nil
@@ -1686,6 +1815,40 @@ var defaultSysVars = []*SysVar{
 		s.TMPTableSize = tidbOptInt64(val, DefTMPTableSize)
 		return nil
 	}},
+	{Scope: ScopeGlobal, Name: TiDBEnableStmtEvents, Value: BoolToOnOff(DefTiDBEnableStmtEvents), Type: TypeBool, GetSession: func(s *SessionVars) (string, error) {
+		return BoolToOnOff(EnableStmtEvents.Load()), nil
+	}, SetGlobal: func(vars *SessionVars, s string) error {
+		EnableStmtEvents.Store(TiDBOptOn(s))
+		return nil
+	}},
+	{Scope: ScopeGlobal, Name: TiDBOperatorMetricsSampleInterval, Value: strconv.Itoa(DefTiDBOperatorMetricsSampleInterval), Type: TypeUnsigned, MinValue: 0, MaxValue: 1024, GetSession: func(s *SessionVars) (string, error) {
+		return strconv.FormatUint(uint64(OperatorMetricsSampleInterval.Load()), 10), nil
+	}, SetGlobal: func(vars *SessionVars, s string) error {
+		OperatorMetricsSampleInterval.Store(uint32(tidbOptInt(s, DefTiDBOperatorMetricsSampleInterval)))
+		return nil
+	}},
+	{Scope: ScopeGlobal, Name: TiDBSlowLogFormat, Value: DefTiDBSlowLogFormat, Type: TypeEnum, PossibleValues: []string{SlowLogFormatText, SlowLogFormatJSON}, GetSession: func(s *SessionVars) (string, error) {
+		return SlowLogFormat.Load(), nil
+	}, SetGlobal: func(vars *SessionVars, s string) error {
+		SlowLogFormat.Store(s)
+		return nil
+	}},
+	{Scope: ScopeGlobal, Name: TiDBEnableTableTrafficPersist, Value: BoolToOnOff(DefTiDBEnableTableTrafficPersist), Type: TypeBool, GetSession: func(s *SessionVars) (string, error) {
+		return BoolToOnOff(PersistTableTraffic.Load()), nil
+	}, SetGlobal: func(vars *SessionVars, s string) error {
+		PersistTableTraffic.Store(TiDBOptOn(s))
+		return nil
+	}},
+	{Scope: ScopeGlobal, Name: TiDBEnableTableWriteTraffic, Value: BoolToOnOff(DefTiDBEnableTableWriteTraffic), Type: TypeBool, GetSession: func(s *SessionVars) (string, error) {
+		return BoolToOnOff(EnableTableWriteTraffic.Load()), nil
+	}, SetGlobal: func(vars *SessionVars, s string) error {
+		EnableTableWriteTraffic.Store(TiDBOptOn(s))
+		return nil
+	}},
+	{Scope: ScopeGlobal, Name: TiDBPlanCaptureDigests, Value: "", AllowEmpty: true, SetGlobal: func(vars *SessionVars, s string) error {
+		plancapture.SetPendingDigests(strings.Split(s, ","))
+		return nil
+	}},
 	// variable for top SQL feature.
 	{Scope: ScopeGlobal, Name: TiDBEnableTopSQL, Value: BoolToOnOff(DefTiDBTopSQLEnable), Type: TypeBool, Hidden: true, AllowEmpty: true, GetSession: func(s *SessionVars) (string, error) {
 		return BoolToOnOff(TopSQLVariable.Enable.Load()), nil
@@ -75,6 +75,9 @@ const (
 	TiDBAutoAnalyzeStartTime = "tidb_auto_analyze_start_time"
 	TiDBAutoAnalyzeEndTime   = "tidb_auto_analyze_end_time"
 
+	// tidb_auto_analyze_concurrency is the number of the auto analyze jobs executed at the same time.
+	TiDBAutoAnalyzeConcurrency = "tidb_auto_analyze_concurrency"
+
 	// tidb_checksum_table_concurrency is used to speed up the ADMIN CHECKSUM TABLE
 	// statement, when a table has multiple indices, those indices can be
 	// scanned concurrently, with the cost of higher system performance impact.
@@ -101,12 +104,20 @@ const (
 	// split data into multiple batches and use a single txn for each batch. This will be helpful when deleting large data.
 	TiDBBatchDelete = "tidb_batch_delete"
 
+	// tidb_batch_update is used to enable/disable auto-split update data. If set this option on, update executor will automatically
+	// split data into multiple batches and use a single txn for each batch. This will be helpful when updating large data.
+	// It only works for the single-table update, and the batches are not atomic, so a failed update may have updated a part of the rows.
+	// The rows are read from one snapshot, the update fails if a row is changed by others after it's read and before its batch.
+	// It's a reduced scope of the non-transactional `BATCH ON col LIMIT n` statement: the batches aren't divided by a column,
+	// and there is no dry run or resumption, because the parser used by this tree has no grammar for the statement.
+	TiDBBatchUpdate = "tidb_batch_update"
+
 	// tidb_batch_commit is used to enable/disable auto-split the transaction.
 	// If set this option on, the transaction will be committed when it reaches stmt-count-limit and starts a new transaction.
 	TiDBBatchCommit = "tidb_batch_commit"
 
 	// tidb_dml_batch_size is used to split the insert/delete data into small batches.
-	// It only takes effort when tidb_batch_insert/tidb_batch_delete is on.
+	// It only takes effort when tidb_batch_insert/tidb_batch_delete/tidb_batch_update is on.
 	// Its default value is 20000. When the row size is large, 20k rows could be larger than 100MB.
 	// User could change it to a smaller one to avoid breaking the transaction size limitation.
 	TiDBDMLBatchSize = "tidb_dml_batch_size"
@@ -169,6 +180,10 @@ const (
 	// TiDBReplicaRead is used for reading data from replicas, followers for example.
 	TiDBReplicaRead = "tidb_replica_read"
 
+	// TiDBReadStaleness indicates the max staleness in seconds of the data read by autocommit read-only statements,
+	// the read ts is resolved with the min safe ts of the stores so that the freshest safe replica can serve it.
+	TiDBReadStaleness = "tidb_read_staleness"
+
 	// TiDBAllowRemoveAutoInc indicates whether a user can drop the auto_increment column attribute or not.
 	TiDBAllowRemoveAutoInc = "tidb_allow_remove_auto_inc"
 
@@ -374,6 +389,11 @@ const (
 	// tidb_ddl_reorg_batch_size defines the transaction batch size of ddl reorg workers.
 	TiDBDDLReorgBatchSize = "tidb_ddl_reorg_batch_size"
 
+	// tidb_ddl_reorg_paused_jobs is the comma separated IDs of the DDL jobs whose reorg are paused.
+	// The backfill of these jobs waits between two batches until they're removed from the list.
+	// The jobs are removed from the list when they're finished or cancelled.
+	TiDBDDLReorgPausedJobs = "tidb_ddl_reorg_paused_jobs"
+
 	// tidb_ddl_error_count_limit defines the count of ddl error limit.
 	TiDBDDLErrorCountLimit = "tidb_ddl_error_count_limit"
 
@@ -423,6 +443,26 @@ const (
 	// tidb_enable_vectorized_expression is used to control whether to enable the vectorized expression evaluation.
 	TiDBEnableVectorizedExpression = "tidb_enable_vectorized_expression"
 
+	// TiDBFilterCompileThreshold is the number of rows a filter evaluates in a statement before it's compiled
+	// into a bytecode program, 0 means the filters are never compiled.
+	TiDBFilterCompileThreshold = "tidb_filter_compile_threshold"
+
+	// TiDBEnablePlanCacheParamFolding indicates whether to fold the expressions of the cached plans with the
+	// parameters of each execution.
+	TiDBEnablePlanCacheParamFolding = "tidb_enable_plan_cache_param_folding"
+
+	// TiDBEnableGeneralPlanCache indicates whether to cache the plans of the non-prepared SELECT statements by
+	// extracting the literals of their WHERE clauses as the parameters.
+	TiDBEnableGeneralPlanCache = "tidb_enable_general_plan_cache"
+
+	// TiDBEnableOptimizerNotes indicates whether to explain the decisions of the optimizer which may slow down the
+	// statements by the notes of SHOW WARNINGS.
+	TiDBEnableOptimizerNotes = "tidb_enable_optimizer_notes"
+
+	// TiDBEnableOptimizerTrace indicates whether to record the decisions of the optimizer into the optimizer trace,
+	// which can be queried by information_schema.optimizer_trace after the statement is executed.
+	TiDBEnableOptimizerTrace = "tidb_enable_optimizer_trace"
+
 	// TiDBOptJoinReorderThreshold defines the threshold less than which
 	// we'll choose a rather time consuming algorithm to calculate the join order.
 	TiDBOptJoinReorderThreshold = "tidb_opt_join_reorder_threshold"
@@ -491,6 +531,12 @@ const (
 	// DefExecutorConcurrency is used for controlling the concurrency of all types of executors.
 	TiDBExecutorConcurrency = "tidb_executor_concurrency"
 
+	// TiDBExecutorCloseConcurrency is used for controlling the max number of goroutines closing the executors of a
+	// statement concurrently, the goroutines are shared by all the executors of the statement.
+	// The executors are closed one by one by default, until the Close of every executor is checked to be safe to run
+	// concurrently with its siblings.
+	TiDBExecutorCloseConcurrency = "tidb_executor_close_concurrency"
+
 	// TiDBEnableClusteredIndex indicates if clustered index feature is enabled.
 	TiDBEnableClusteredIndex = "tidb_enable_clustered_index"
 
@@ -518,24 +564,97 @@ const (
 	// TiDBEnableRateLimitAction indicates whether enabled ratelimit action
 	TiDBEnableRateLimitAction = "tidb_enable_rate_limit_action"
 
+	// TiDBEnablePaging indicates whether the cop tasks fetch the results page by page. Only the DAG requests to TiKV
+	// are paged, they are sent by the streaming API since the unary coprocessor API can't be paged.
+	TiDBEnablePaging = "tidb_enable_paging"
+
+	// TiDBEnableDistSQLBackpressure indicates whether the number of in-flight cop tasks adapts to the consumer.
+	TiDBEnableDistSQLBackpressure = "tidb_enable_distsql_backpressure"
+
+	// TiDBMaxRangesPerCopTask limits the number of the ranges of a region sent in one cop task, the ranges beyond it
+	// are sent in more tasks of the region, which run concurrently.
+	TiDBMaxRangesPerCopTask = "tidb_max_ranges_per_cop_task"
+
+	// TiDBPointRangesBatchGetSize makes the table reader read the ranges which are all handle points by the batched
+	// point gets of this many keys instead of the cop tasks, the keys of a batch are grouped by region. 0 disables it.
+	TiDBPointRangesBatchGetSize = "tidb_point_ranges_batch_get_size"
+
+	// TiDBRCTSCacheWindow is the window in milliseconds, within which the statements in the read-committed pessimistic
+	// transactions reuse the TS fetched by the recent statements instead of fetching a new one from PD, 0 disables it.
+	TiDBRCTSCacheWindow = "tidb_rc_ts_cache_window"
+
+	// TiDBEnablePipelinedLock indicates whether the UPDATE statements in the pessimistic transactions acquire the locks
+	// of the written rows asynchronously while reading the following rows.
+	TiDBEnablePipelinedLock = "tidb_enable_pipelined_pessimistic_lock"
+
+	// TiDBEnableFastTableCheck indicates whether ADMIN CHECK TABLE/INDEX compares the checksums of the table and the
+	// indices computed by the coprocessor first, and checks row by row only if they are inconsistent.
+	TiDBEnableFastTableCheck = "tidb_enable_fast_table_check"
+
+	// TiDBTxnTotalSizeLimit caps the total size of the mutations of a transaction, 0 means using txn-total-size-limit in the config.
+	TiDBTxnTotalSizeLimit = "tidb_txn_total_size_limit"
+
 	// TiDBEnableAsyncCommit indicates whether to enable the async commit feature.
 	TiDBEnableAsyncCommit = "tidb_enable_async_commit"
 
 	// TiDBEnable1PC indicates whether to enable the one-phase commit feature.
 	TiDBEnable1PC = "tidb_enable_1pc"
 
+	// TiDBAsyncCommitRegionsLimit is the max number of regions the keys of a transaction can span to use async commit
+	// or one-phase commit, the transaction falls back to 2PC if its keys span more regions.
+	TiDBAsyncCommitRegionsLimit = "tidb_async_commit_regions_limit"
+
 	// TiDBGuaranteeLinearizability indicates whether to guarantee linearizability.
 	TiDBGuaranteeLinearizability = "tidb_guarantee_linearizability"
 
 	// TiDBAnalyzeVersion indicates the how tidb collects the analyzed statistics and how use to it.
 	TiDBAnalyzeVersion = "tidb_analyze_version"
 
+	// TiDBAnalyzeSampleRate is the rate of the rows sampled by the analyze of version 2, it overrides the number of
+	// the samples by the row count of the table. 0 means the number of the samples is used.
+	TiDBAnalyzeSampleRate = "tidb_analyze_sample_rate"
+
+	// TiDBEnableSelectivityFeedback indicates whether to refine the low confidence selectivity estimations with
+	// the selectivities observed in execution.
+	TiDBEnableSelectivityFeedback = "tidb_enable_selectivity_feedback"
+
+	// TiDBEnableCardinalityFeedback indicates whether to refine the estimated row counts of the index and table ranges
+	// with the row counts observed in execution.
+	TiDBEnableCardinalityFeedback = "tidb_enable_cardinality_feedback"
+
+	// TiDBEnableJoinEstByBucketNDV indicates whether to estimate the row count of the equal join on the single column
+	// indexes by the NDVs of their buckets.
+	TiDBEnableJoinEstByBucketNDV = "tidb_enable_join_est_by_bucket_ndv"
+
+	// TiDBOptInlineCTE indicates whether to inline the non-recursive CTEs which are referenced only once instead of
+	// materializing them.
+	TiDBOptInlineCTE = "tidb_opt_inline_cte"
+
+	// TiDBOptPartitionWiseJoin indicates whether to join the partition pairs of the tables which are partitioned by
+	// the join key in the same way independently, only used in the static partition prune mode.
+	TiDBOptPartitionWiseJoin = "tidb_opt_partition_wise_join"
+
+	// TiDBOptUseInvisibleIndexes indicates whether the optimizer can use the invisible indexes in the session.
+	TiDBOptUseInvisibleIndexes = "tidb_opt_use_invisible_indexes"
+
+	// TiDBLoadStatsInSession indicates whether LOAD STATS loads the statistics into the current session only,
+	// the loaded statistics override the ones in storage when the session plans queries.
+	TiDBLoadStatsInSession = "tidb_load_stats_in_session"
+
+	// TiDBResourceGroup is the resource group of the statements in the session, the statements are in the group
+	// which lists the user of the session in the config if it's empty.
+	TiDBResourceGroup = "tidb_resource_group"
+
 	// TiDBEnableIndexMergeJoin indicates whether to enable index merge join.
 	TiDBEnableIndexMergeJoin = "tidb_enable_index_merge_join"
 
 	// TiDBTrackAggregateMemoryUsage indicates whether track the memory usage of aggregate function.
 	TiDBTrackAggregateMemoryUsage = "tidb_track_aggregate_memory_usage"
 
+	// TiDBEnableTwoLevelHashAgg indicates whether the parallel hash aggregation detects the heavy hitter group keys
+	// and merges them in a second level to avoid overloading a single final worker.
+	TiDBEnableTwoLevelHashAgg = "tidb_enable_two_level_hashagg"
+
 	// TiDBEnableExchangePartition indicates whether to enable exchange partition.
 	TiDBEnableExchangePartition = "tidb_enable_exchange_partition"
 
@@ -560,8 +679,24 @@ const (
 
 	// TiDBTopSQLReportIntervalSeconds indicates the top SQL report interval seconds.
 	TiDBTopSQLReportIntervalSeconds = "tidb_top_sql_report_interval_seconds"
+	// TiDBEnableStmtEvents indicates whether to record the statements and their stages in the
+	// events_statements_* and events_stages_* tables of performance_schema.
+	TiDBEnableStmtEvents = "tidb_enable_stmt_events"
+	// TiDBPlanCaptureDigests is a comma separated list of the SQL digests to capture plan replayer bundles for,
+	// each of them is captured on its next execution and then removed from the global variable.
+	TiDBPlanCaptureDigests = "tidb_plan_capture_digests"
+	// TiDBEnableTableTrafficPersist indicates whether to persist the per-table traffic into mysql.table_traffic.
+	TiDBEnableTableTrafficPersist = "tidb_enable_table_traffic_persist"
+	// TiDBEnableTableWriteTraffic indicates whether to count the keys and bytes written to each table. The membuffer
+	// of a transaction is scanned before it's committed, so it's disabled by default.
+	TiDBEnableTableWriteTraffic = "tidb_enable_table_write_traffic"
 	// TiDBEnableGlobalTemporaryTable indicates whether to enable global temporary table
 	TiDBEnableGlobalTemporaryTable = "tidb_enable_global_temporary_table"
+	// TiDBOperatorMetricsSampleInterval is the interval of the calls of Next to sample the time spent by the operators
+	// for the operator metrics, the metrics are also flushed every interval calls. 0 disables the operator metrics.
+	TiDBOperatorMetricsSampleInterval = "tidb_operator_metrics_sample_interval"
+	// TiDBSlowLogFormat is the format of the slow log, which is either "text" or "json".
+	TiDBSlowLogFormat = "tidb_slow_log_format"
 )
 
 // TiDB vars that have only global scope
@@ -594,6 +729,7 @@ const (
 	DefAutoAnalyzeRatio                = 0.5
 	DefAutoAnalyzeStartTime            = "00:00 +0000"
 	DefAutoAnalyzeEndTime              = "23:59 +0000"
+	DefAutoAnalyzeConcurrency          = 1
 	DefAutoIncrementIncrement          = 1
 	DefAutoIncrementOffset             = 1
 	DefChecksumTableConcurrency        = 4
@@ -620,6 +756,7 @@ const (
 	DefOptPreferRangeScan              = false
 	DefBatchInsert                     = false
 	DefBatchDelete                     = false
+	DefBatchUpdate                     = false
 	DefBatchCommit                     = false
 	DefCurretTS                        = 0
 	DefInitChunkSize                   = 32
@@ -668,6 +805,11 @@ const (
 	DefEnablePipelinedWindowFunction   = true
 	DefEnableStrictDoubleTypeCheck     = true
 	DefEnableVectorizedExpression      = true
+	DefTiDBFilterCompileThreshold      = 0
+	DefTiDBEnablePlanCacheParamFolding = false
+	DefTiDBEnableGeneralPlanCache      = false
+	DefTiDBEnableOptimizerNotes        = false
+	DefTiDBEnableOptimizerTrace        = false
 	DefTiDBOptJoinReorderThreshold     = 0
 	DefTiDBDDLSlowOprThreshold         = 300
 	DefTiDBUseFastAnalyze              = false
@@ -699,12 +841,31 @@ const (
 	DefTiDBEnableAmendPessimisticTxn   = false
 	DefTiDBPartitionPruneMode          = "static"
 	DefTiDBEnableRateLimitAction       = true
+	DefTiDBEnableDistSQLBackpressure   = false
+	DefTiDBExecutorCloseConcurrency    = 1
+	DefTiDBEnablePaging                = false
+	DefTiDBMaxRangesPerCopTask         = 25000
+	DefTiDBPointRangesBatchGetSize     = 0
+	DefTiDBRCTSCacheWindow             = 0
+	DefTiDBEnablePipelinedLock         = false
+	DefTiDBEnableFastTableCheck        = true
+	DefTiDBTxnTotalSizeLimit           = 0
 	DefTiDBEnableAsyncCommit           = false
 	DefTiDBEnable1PC                   = false
+	DefTiDBAsyncCommitRegionsLimit     = 64
 	DefTiDBGuaranteeLinearizability    = true
 	DefTiDBAnalyzeVersion              = 2
+	DefTiDBAnalyzeSampleRate           = 0.0
+	DefTiDBEnableSelectivityFeedback   = false
+	DefTiDBEnableCardinalityFeedback   = false
+	DefTiDBEnableJoinEstByBucketNDV    = false
+	DefTiDBOptInlineCTE                = false
+	DefTiDBOptPartitionWiseJoin        = false
+	DefTiDBOptUseInvisibleIndexes      = false
+	DefTiDBLoadStatsInSession          = false
 	DefTiDBEnableIndexMergeJoin        = false
 	DefTiDBTrackAggregateMemoryUsage   = true
+	DefTiDBEnableTwoLevelHashAgg       = false
 	DefTiDBEnableExchangePartition     = false
 	DefCTEMaxRecursionDepth            = 1000
 	DefTiDBTopSQLEnable                = false
@@ -713,20 +874,29 @@ const (
 	DefTiDBTopSQLMaxStatementCount     = 2000
 	DefTiDBTopSQLMaxCollect            = 10000
 	DefTiDBTopSQLReportIntervalSeconds = 60
+	DefTiDBEnableStmtEvents            = false
+	DefTiDBEnableTableTrafficPersist   = false
+	DefTiDBEnableTableWriteTraffic     = false
 	DefTiDBEnableGlobalTemporaryTable  = false
 	DefTMPTableSize                    = 16777216
+
+	DefTiDBOperatorMetricsSampleInterval = 16
+	DefTiDBSlowLogFormat                 = SlowLogFormatText
 )
 
 // Process global variables.
 var (
-	ProcessGeneralLog            = atomic.NewBool(false)
-	EnablePProfSQLCPU            = atomic.NewBool(false)
-	ddlReorgWorkerCounter  int32 = DefTiDBDDLReorgWorkerCount
-	maxDDLReorgWorkerCount int32 = 128
-	ddlReorgBatchSize      int32 = DefTiDBDDLReorgBatchSize
-	ddlErrorCountlimit     int64 = DefTiDBDDLErrorCountLimit
-	ddlReorgRowFormat      int64 = DefTiDBRowFormatV2
-	maxDeltaSchemaCount    int64 = DefTiDBMaxDeltaSchemaCount
+	ProcessGeneralLog             = atomic.NewBool(false)
+	EnableStmtEvents              = atomic.NewBool(DefTiDBEnableStmtEvents)
+	PersistTableTraffic           = atomic.NewBool(DefTiDBEnableTableTrafficPersist)
+	EnableTableWriteTraffic       = atomic.NewBool(DefTiDBEnableTableWriteTraffic)
+	EnablePProfSQLCPU             = atomic.NewBool(false)
+	ddlReorgWorkerCounter   int32 = DefTiDBDDLReorgWorkerCount
+	maxDDLReorgWorkerCount  int32 = 128
+	ddlReorgBatchSize       int32 = DefTiDBDDLReorgBatchSize
+	ddlErrorCountlimit      int64 = DefTiDBDDLErrorCountLimit
+	ddlReorgRowFormat       int64 = DefTiDBRowFormatV2
+	maxDeltaSchemaCount     int64 = DefTiDBMaxDeltaSchemaCount
 	// Export for testing.
 	MaxDDLReorgBatchSize int32 = 10240
 	MinDDLReorgBatchSize int32 = 32
@@ -746,7 +916,14 @@ var (
 		MaxStatementCount:     atomic.NewInt64(DefTiDBTopSQLMaxStatementCount),
 		MaxCollect:            atomic.NewInt64(DefTiDBTopSQLMaxCollect),
 		ReportIntervalSeconds: atomic.NewInt64(DefTiDBTopSQLReportIntervalSeconds),
+		InstanceProfiling:     atomic.NewInt32(0),
 	}
+	// OperatorMetricsSampleInterval is the value of tidb_operator_metrics_sample_interval.
+	OperatorMetricsSampleInterval = atomic.NewUint32(DefTiDBOperatorMetricsSampleInterval)
+	// SlowLogFormat is the value of tidb_slow_log_format.
+	SlowLogFormat = atomic.NewString(DefTiDBSlowLogFormat)
+	// MaxRCTSCacheWindow is the max value of tidb_rc_ts_cache_window in milliseconds.
+	MaxRCTSCacheWindow uint64 = 1000
 )
 
 // TopSQL is the variable for control top sql feature.
@@ -763,9 +940,12 @@ type TopSQL struct {
 	MaxCollect *atomic.Int64
 	// The report data interval of top-sql.
 	ReportIntervalSeconds *atomic.Int64
+	// InstanceProfiling is the number of the running top-sql requests of the status server, which collect the cpu
+	// time of the statements without the agent.
+	InstanceProfiling *atomic.Int32
 }
 
 // TopSQLEnabled uses to check whether enabled the top SQL feature.
 func TopSQLEnabled() bool {
-	return TopSQLVariable.Enable.Load() && TopSQLVariable.AgentAddress.Load() != ""
+	return (TopSQLVariable.Enable.Load() && TopSQLVariable.AgentAddress.Load() != "") || TopSQLVariable.InstanceProfiling.Load() > 0
 }
@@ -93,6 +93,23 @@ func GetMaxDeltaSchemaCount() int64 {
 	return atomic.LoadInt64(&maxDeltaSchemaCount)
 }
 
+// ParseDDLReorgPausedJobs parses the value of tidb_ddl_reorg_paused_jobs to the set of the job IDs.
+func ParseDDLReorgPausedJobs(val string) (map[int64]struct{}, error) {
+	jobIDs := make(map[int64]struct{})
+	for _, str := range strings.Split(val, ",") {
+		str = strings.TrimSpace(str)
+		if len(str) == 0 {
+			continue
+		}
+		id, err := strconv.ParseInt(str, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		jobIDs[id] = struct{}{}
+	}
+	return jobIDs, nil
+}
+
 // BoolToOnOff returns the string representation of a bool, i.e. "ON/OFF"
 func BoolToOnOff(b bool) string {
 	if b {
@@ -443,6 +443,28 @@ func (s *testVarsutilSuite) TestVarsutil(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(val, Equals, "leader-and-follower")
 	c.Assert(v.GetReplicaRead(), Equals, kv.ReplicaReadMixed)
+	err = SetSessionSystemVar(v, TiDBReplicaRead, "learner")
+	c.Assert(err, IsNil)
+	val, err = GetSessionOrGlobalSystemVar(v, TiDBReplicaRead)
+	c.Assert(err, IsNil)
+	c.Assert(val, Equals, "learner")
+	c.Assert(v.GetReplicaRead(), Equals, kv.ReplicaReadLearner)
+	err = SetSessionSystemVar(v, TiDBReplicaRead, "closest-replicas")
+	c.Assert(err, IsNil)
+	val, err = GetSessionOrGlobalSystemVar(v, TiDBReplicaRead)
+	c.Assert(err, IsNil)
+	c.Assert(val, Equals, "closest-replicas")
+	c.Assert(v.GetReplicaRead(), Equals, kv.ReplicaReadClosest)
+
+	err = SetSessionSystemVar(v, TiDBReadStaleness, "-5")
+	c.Assert(err, IsNil)
+	val, err = GetSessionOrGlobalSystemVar(v, TiDBReadStaleness)
+	c.Assert(err, IsNil)
+	c.Assert(val, Equals, "-5")
+	c.Assert(v.ReadStaleness, Equals, 5*time.Second)
+	err = SetSessionSystemVar(v, TiDBReadStaleness, "0")
+	c.Assert(err, IsNil)
+	c.Assert(v.ReadStaleness, Equals, time.Duration(0))
 
 	err = SetSessionSystemVar(v, TiDBEnableStmtSummary, "ON")
 	c.Assert(err, IsNil)
@@ -552,6 +574,9 @@ func (s *testVarsutilSuite) TestValidate(c *C) {
 		{TiDBEnableTablePartition, "OFF", false},
 		{TiDBEnableTablePartition, "AUTO", false},
 		{TiDBEnableTablePartition, "UN", true},
+		{TiDBDDLReorgPausedJobs, "", false},
+		{TiDBDDLReorgPausedJobs, "1, 2", false},
+		{TiDBDDLReorgPausedJobs, "1,a", true},
 		{TiDBOptCorrelationExpFactor, "a", true},
 		{TiDBOptCorrelationExpFactor, "-10", true},
 		{TiDBOptCorrelationThreshold, "a", true},
@@ -223,6 +223,45 @@ func (h *Handle) LoadStatsFromJSON(is infoschema.InfoSchema, jsonTbl *JSONTable)
 	return errors.Trace(h.Update(is))
 }
 
+// SessionStatsFromJSON builds the statistics of a table and its partitions from JSONTable without saving them
+// to the storage, they are used to override the statistics of one session. The result is keyed by physical ids.
+func SessionStatsFromJSON(is infoschema.InfoSchema, jsonTbl *JSONTable) (map[int64]*statistics.Table, error) {
+	table, err := is.TableByName(model.NewCIStr(jsonTbl.DatabaseName), model.NewCIStr(jsonTbl.TableName))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	tableInfo := table.Meta()
+	tbls := make(map[int64]*statistics.Table)
+	pi := tableInfo.GetPartitionInfo()
+	if pi == nil || jsonTbl.Partitions == nil {
+		tbl, err := TableStatsFromJSON(tableInfo, tableInfo.ID, jsonTbl)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		tbls[tableInfo.ID] = tbl
+		return tbls, nil
+	}
+	for _, def := range pi.Definitions {
+		jsonPart := jsonTbl.Partitions[def.Name.L]
+		if jsonPart == nil {
+			continue
+		}
+		tbl, err := TableStatsFromJSON(tableInfo, def.ID, jsonPart)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		tbls[def.ID] = tbl
+	}
+	if globalStats, ok := jsonTbl.Partitions["global"]; ok {
+		tbl, err := TableStatsFromJSON(tableInfo, tableInfo.ID, globalStats)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		tbls[tableInfo.ID] = tbl
+	}
+	return tbls, nil
+}
+
 func (h *Handle) loadStatsFromJSON(tableInfo *model.TableInfo, physicalID int64, jsonTbl *JSONTable) error {
 	tbl, err := TableStatsFromJSON(tableInfo, physicalID, jsonTbl)
 	if err != nil {
@@ -107,6 +107,12 @@ type Handle struct {
 
 	// idxUsageListHead contains all the index usage collectors required by session.
 	idxUsageListHead *SessionIndexUsageCollector
+
+	// autoAnalyzeQueue is the snapshot of the auto analyze jobs of the last round.
+	autoAnalyzeQueue struct {
+		sync.Mutex
+		jobs []AutoAnalyzeJobInfo
+	}
 }
 
 func (h *Handle) withRestrictedSQLExecutor(ctx context.Context, fn func(context.Context, sqlexec.RestrictedSQLExecutor) ([]chunk.Row, []*ast.ResultField, error)) ([]chunk.Row, []*ast.ResultField, error) {
@@ -1075,6 +1081,16 @@ func (h *Handle) SaveMetaToStorage(tableID, count, modifyCount int64) (err error
 	return err
 }
 
+// SaveSampleRateToStorage saves the rate of the regions scanned by the last analyze to stats_meta.
+func (h *Handle) SaveSampleRateToStorage(tableID int64, sampleRate float64) (err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ctx := context.TODO()
+	exec := h.mu.ctx.(sqlexec.SQLExecutor)
+	_, err = exec.ExecuteInternal(ctx, "update mysql.stats_meta set sample_rate = %? where table_id = %?", sampleRate, tableID)
+	return err
+}
+
 func (h *Handle) histogramFromStorage(reader *statsReader, tableID int64, colID int64, tp *types.FieldType, distinct int64, isIndex int, ver uint64, nullCount int64, totColSize int64, corr float64) (_ *statistics.Histogram, err error) {
 	rows, fields, err := reader.read("select count, repeats, lower_bound, upper_bound, ndv from mysql.stats_buckets where table_id = %? and is_index = %? and hist_id = %? order by bucket_id", tableID, isIndex, colID)
 	if err != nil {
@@ -37,6 +37,7 @@ import (
 	"github.com/pingcap/tidb/types"
 	"github.com/pingcap/tidb/util/collate"
 	"github.com/pingcap/tidb/util/ranger"
+	"github.com/pingcap/tidb/util/tabletraffic"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/tikv/client-go/v2/oracle"
 )
@@ -2189,6 +2190,28 @@ func (s *statsSerialSuite) TestIndexUsageInformation(c *C) {
 	))
 }
 
+func (s *statsSerialSuite) TestDumpTableTraffic(c *C) {
+	defer cleanEnv(c, s.store, s.do)
+	tabletraffic.Reset()
+	defer tabletraffic.Reset()
+	tk := testkit.NewTestKit(c, s.store)
+	h := s.do.StatsHandle()
+	// The traffic of the previous statements is dumped first.
+	c.Assert(h.DumpTableTrafficToKV(), IsNil)
+	tk.MustExec("delete from mysql.table_traffic")
+	tabletraffic.TakeDeltas()
+
+	tabletraffic.RecordRead(1000, 2, 20)
+	tabletraffic.RecordWrite(1001, 1, 10)
+	c.Assert(h.DumpTableTrafficToKV(), IsNil)
+	querySQL := "select table_id, read_keys, read_bytes, write_keys, write_bytes from mysql.table_traffic where table_id in (1000, 1001) order by table_id"
+	tk.MustQuery(querySQL).Check(testkit.Rows("1000 2 20 0 0", "1001 0 0 1 10"))
+	// The traffic is accumulated.
+	tabletraffic.RecordRead(1000, 1, 10)
+	c.Assert(h.DumpTableTrafficToKV(), IsNil)
+	tk.MustQuery(querySQL).Check(testkit.Rows("1000 3 30 0 0", "1001 0 0 1 10"))
+}
+
 func (s *statsSerialSuite) TestGCIndexUsageInformation(c *C) {
 	defer cleanEnv(c, s.store, s.do)
 	session.SetIndexUsageSyncLease(1)
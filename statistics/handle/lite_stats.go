@@ -0,0 +1,75 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handle
+
+import (
+	"github.com/pingcap/parser/model"
+)
+
+// LiteStats is the lightweight statistics of some partitions of a table. It's aggregated from the row counts,
+// the modify counters and the column sizes in the stats meta of the partitions, which are maintained by the
+// stats deltas of the DMLs, so it's reasonably fresh even if the partitions have never been analyzed.
+type LiteStats struct {
+	// Count is the number of the rows.
+	Count int64
+	// ModifyCount is the number of the modified rows since the last analyze.
+	ModifyCount int64
+	// AvgRowSize is the average size of the rows in bytes, it's 0 if the sizes are unknown.
+	AvgRowSize float64
+	// Partitions is the number of the partitions which have the stats meta.
+	Partitions int
+	// Version is the latest version of the stats meta of the partitions.
+	Version uint64
+}
+
+// GetLiteStats aggregates the lightweight statistics of the partitions of a table, all the partitions are
+// aggregated if pids is nil.
+func (h *Handle) GetLiteStats(tblInfo *model.TableInfo, pids []int64) LiteStats {
+	if pids == nil {
+		pi := tblInfo.GetPartitionInfo()
+		if pi == nil {
+			pids = []int64{tblInfo.ID}
+		} else {
+			pids = make([]int64, 0, len(pi.Definitions))
+			for _, def := range pi.Definitions {
+				pids = append(pids, def.ID)
+			}
+		}
+	}
+	statsCache := h.statsCache.Load().(statsCache)
+	var (
+		stats   LiteStats
+		colSize int64
+	)
+	for _, pid := range pids {
+		tbl, ok := statsCache.tables[pid]
+		// The pseudo tables are put into the cache when the partitions without the stats meta are accessed.
+		if !ok || tbl.Pseudo {
+			continue
+		}
+		stats.Count += tbl.Count
+		stats.ModifyCount += tbl.ModifyCount
+		stats.Partitions++
+		if tbl.Version > stats.Version {
+			stats.Version = tbl.Version
+		}
+		for _, col := range tbl.Columns {
+			colSize += col.TotColSize
+		}
+	}
+	if stats.Count > 0 {
+		stats.AvgRowSize = float64(colSize) / float64(stats.Count)
+	}
+	return stats
+}
@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -40,6 +41,7 @@ import (
 	"github.com/pingcap/tidb/util/logutil"
 	"github.com/pingcap/tidb/util/ranger"
 	"github.com/pingcap/tidb/util/sqlexec"
+	"github.com/pingcap/tidb/util/tabletraffic"
 	"github.com/pingcap/tidb/util/timeutil"
 	"github.com/tikv/client-go/v2/oracle"
 	"go.uber.org/zap"
@@ -319,6 +321,23 @@ func (h *Handle) DumpIndexUsageToKV() error {
 	return nil
 }
 
+// DumpTableTrafficToKV adds the per-table traffic since the last dump into mysql.table_traffic. The traffic
+// of all the TiDB instances is accumulated in the same rows.
+func (h *Handle) DumpTableTrafficToKV() error {
+	ctx := context.Background()
+	deltas := tabletraffic.TakeDeltas()
+	for i, delta := range deltas {
+		const sql = `insert into mysql.table_traffic values (%?, %?, %?, %?, %?, now()) on duplicate key update read_keys=read_keys+%?, read_bytes=read_bytes+%?, write_keys=write_keys+%?, write_bytes=write_bytes+%?, update_time=now()`
+		_, _, err := h.execRestrictedSQL(ctx, sql, delta.TableID, delta.ReadKeys, delta.ReadBytes, delta.WriteKeys, delta.WriteBytes, delta.ReadKeys, delta.ReadBytes, delta.WriteKeys, delta.WriteBytes)
+		if err != nil {
+			// The deltas not dumped yet are dumped next time.
+			tabletraffic.RestoreDeltas(deltas[i:])
+			return err
+		}
+	}
+	return nil
+}
+
 // GCIndexUsage will delete the usage information of those indexes that do not exist.
 func (h *Handle) GCIndexUsage() error {
 	// For performance and implementation reasons, mysql.schema_index_usage doesn't handle DDL.
@@ -884,8 +903,9 @@ func NeedAnalyzeTable(tbl *statistics.Table, limit time.Duration, autoAnalyzeRat
 
 func (h *Handle) getAutoAnalyzeParameters() map[string]string {
 	ctx := context.Background()
-	sql := "select variable_name, variable_value from mysql.global_variables where variable_name in (%?, %?, %?)"
-	rows, _, err := h.execRestrictedSQL(ctx, sql, variable.TiDBAutoAnalyzeRatio, variable.TiDBAutoAnalyzeStartTime, variable.TiDBAutoAnalyzeEndTime)
+	sql := "select variable_name, variable_value from mysql.global_variables where variable_name in (%?, %?, %?, %?)"
+	rows, _, err := h.execRestrictedSQL(ctx, sql, variable.TiDBAutoAnalyzeRatio, variable.TiDBAutoAnalyzeStartTime, variable.TiDBAutoAnalyzeEndTime,
+		variable.TiDBAutoAnalyzeConcurrency)
 	if err != nil {
 		return map[string]string{}
 	}
@@ -904,6 +924,14 @@ func parseAutoAnalyzeRatio(ratio string) float64 {
 	return math.Max(autoAnalyzeRatio, 0)
 }
 
+func parseAutoAnalyzeConcurrency(concurrency string) int {
+	c, err := strconv.Atoi(concurrency)
+	if err != nil || c < 1 {
+		return variable.DefAutoAnalyzeConcurrency
+	}
+	return c
+}
+
 func parseAnalyzePeriod(start, end string) (time.Time, time.Time, error) {
 	if start == "" {
 		start = variable.DefAutoAnalyzeStartTime
@@ -919,14 +947,76 @@ func parseAnalyzePeriod(start, end string) (time.Time, time.Time, error) {
 	return s, e, err
 }
 
-// HandleAutoAnalyze analyzes the newly created table or index.
+// AutoAnalyzeJobInfo describes an auto analyze job in the queue.
+type AutoAnalyzeJobInfo struct {
+	DBName        string
+	TableName     string
+	PartitionName string
+	State         string
+	Reason        string
+	// Priority decides the order of the jobs, the jobs with higher priority are executed first.
+	Priority    float64
+	Count       int64
+	ModifyCount int64
+	// ReadKeys is the number of the keys read from the table since TiDB started, it reflects how frequently the
+	// table is queried.
+	ReadKeys int64
+}
+
+const (
+	// AutoAnalyzeJobPending means the job is waiting to be executed.
+	AutoAnalyzeJobPending = "pending"
+	// AutoAnalyzeJobRunning means the job is being executed.
+	AutoAnalyzeJobRunning = "running"
+)
+
+// autoAnalyzeJob is an auto analyze statement waiting to be executed.
+type autoAnalyzeJob struct {
+	AutoAnalyzeJobInfo
+	statsVer int
+	sql      string
+	params   []interface{}
+	// escapedSQL is only used for logging.
+	escapedSQL string
+}
+
+// calcAutoAnalyzePriority calculates the priority of the auto analyze job. The staleness ratio matters most, the
+// size and the query frequency of the table break the ties, they are taken in logarithm so neither a huge table
+// nor a hot table starves the others.
+func calcAutoAnalyzePriority(count, modifyCount, readKeys int64, unanalyzed bool) float64 {
+	staleness := float64(modifyCount) / math.Max(float64(count), 1)
+	if unanalyzed {
+		// The tables or indexes which have never been analyzed are the most stale ones.
+		staleness = math.Max(staleness, 1)
+	}
+	return staleness * (1 + math.Log10(float64(count)+1)) * (1 + math.Log10(float64(readKeys)+1))
+}
+
+// AutoAnalyzeQueue returns the pending and running jobs of the last auto analyze round, ordered by the priority.
+// It's only maintained on the stats owner.
+func (h *Handle) AutoAnalyzeQueue() []AutoAnalyzeJobInfo {
+	h.autoAnalyzeQueue.Lock()
+	defer h.autoAnalyzeQueue.Unlock()
+	return append([]AutoAnalyzeJobInfo(nil), h.autoAnalyzeQueue.jobs...)
+}
+
+func (h *Handle) setAutoAnalyzeQueue(jobs []*autoAnalyzeJob) {
+	infos := make([]AutoAnalyzeJobInfo, 0, len(jobs))
+	for _, job := range jobs {
+		infos = append(infos, job.AutoAnalyzeJobInfo)
+	}
+	h.autoAnalyzeQueue.Lock()
+	h.autoAnalyzeQueue.jobs = infos
+	h.autoAnalyzeQueue.Unlock()
+}
+
+// HandleAutoAnalyze analyzes the tables or indexes which need to be analyzed in the order of the priority.
 func (h *Handle) HandleAutoAnalyze(is infoschema.InfoSchema) (analyzed bool) {
 	err := h.UpdateSessionVar()
 	if err != nil {
 		logutil.BgLogger().Error("[stats] update analyze version for auto analyze session failed", zap.Error(err))
 		return false
 	}
-	dbs := is.AllSchemaNames()
 	parameters := h.getAutoAnalyzeParameters()
 	autoAnalyzeRatio := parseAutoAnalyzeRatio(parameters[variable.TiDBAutoAnalyzeRatio])
 	start, end, err := parseAnalyzePeriod(parameters[variable.TiDBAutoAnalyzeStartTime], parameters[variable.TiDBAutoAnalyzeEndTime])
@@ -934,79 +1024,132 @@ func (h *Handle) HandleAutoAnalyze(is infoschema.InfoSchema) (analyzed bool) {
 		logutil.BgLogger().Error("[stats] parse auto analyze period failed", zap.Error(err))
 		return false
 	}
+	concurrency := parseAutoAnalyzeConcurrency(parameters[variable.TiDBAutoAnalyzeConcurrency])
+	jobs := h.collectAutoAnalyzeJobs(is, start, end, autoAnalyzeRatio)
+	if len(jobs) == 0 {
+		h.setAutoAnalyzeQueue(nil)
+		return false
+	}
+	// Only the jobs of the highest priority are executed in a round to let the others get the freshest parameters,
+	// they are re-evaluated in the next round which is just 3s later.
+	if concurrency > len(jobs) {
+		concurrency = len(jobs)
+	}
+	running := jobs[:concurrency]
+	for _, job := range running {
+		job.State = AutoAnalyzeJobRunning
+	}
+	h.setAutoAnalyzeQueue(jobs)
+	var wg sync.WaitGroup
+	for _, job := range running {
+		wg.Add(1)
+		go func(job *autoAnalyzeJob) {
+			defer wg.Done()
+			logutil.BgLogger().Info("[stats] auto analyze triggered", zap.String("sql", job.escapedSQL), zap.String("reason", job.Reason),
+				zap.Float64("priority", job.Priority))
+			h.execAutoAnalyze(job.statsVer, job.sql, job.params...)
+		}(job)
+	}
+	wg.Wait()
+	h.setAutoAnalyzeQueue(jobs[concurrency:])
+	return true
+}
+
+// collectAutoAnalyzeJobs collects the tables or partitions which need to be analyzed, ordered by the priority.
+func (h *Handle) collectAutoAnalyzeJobs(is infoschema.InfoSchema, start, end time.Time, ratio float64) []*autoAnalyzeJob {
+	readKeys := make(map[int64]int64)
+	for _, traffic := range tabletraffic.Tables() {
+		readKeys[traffic.TableID] = traffic.ReadKeys
+	}
 	pruneMode := h.CurrentPruneMode()
-	for _, db := range dbs {
+	var jobs []*autoAnalyzeJob
+	for _, db := range is.AllSchemaNames() {
 		tbls := is.SchemaTables(model.NewCIStr(db))
 		for _, tbl := range tbls {
 			tblInfo := tbl.Meta()
 			pi := tblInfo.GetPartitionInfo()
 			if pi == nil {
 				statsTbl := h.GetTableStats(tblInfo)
-				sql := "analyze table %n.%n"
-				analyzed := h.autoAnalyzeTable(tblInfo, statsTbl, start, end, autoAnalyzeRatio, sql, db, tblInfo.Name.O)
-				if analyzed {
-					// analyze one table at a time to let it get the freshest parameters.
-					// others will be analyzed next round which is just 3s later.
-					return true
+				if job := h.autoAnalyzeTableJob(tblInfo, statsTbl, db, "", start, end, ratio, readKeys[tblInfo.ID]); job != nil {
+					jobs = append(jobs, job)
 				}
 				continue
 			}
 			if pruneMode == variable.Dynamic {
-				analyzed := h.autoAnalyzePartitionTable(tblInfo, pi, db, start, end, autoAnalyzeRatio)
-				if analyzed {
-					return true
+				if job := h.autoAnalyzePartitionTableJob(tblInfo, pi, db, start, end, ratio, readKeys); job != nil {
+					jobs = append(jobs, job)
 				}
 				continue
 			}
 			for _, def := range pi.Definitions {
-				sql := "analyze table %n.%n partition %n"
 				statsTbl := h.GetPartitionStats(tblInfo, def.ID)
-				analyzed := h.autoAnalyzeTable(tblInfo, statsTbl, start, end, autoAnalyzeRatio, sql, db, tblInfo.Name.O, def.Name.O)
-				if analyzed {
-					return true
+				if job := h.autoAnalyzeTableJob(tblInfo, statsTbl, db, def.Name.O, start, end, ratio, readKeys[def.ID]); job != nil {
+					jobs = append(jobs, job)
 				}
 			}
 		}
 	}
-	return false
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].Priority > jobs[j].Priority
+	})
+	return jobs
+}
+
+// newAutoAnalyzeJob returns nil if the sql can't be escaped.
+func newAutoAnalyzeJob(info AutoAnalyzeJobInfo, statsVer int, sql string, params ...interface{}) *autoAnalyzeJob {
+	escaped, err := sqlexec.EscapeSQL(sql, params...)
+	if err != nil {
+		return nil
+	}
+	info.State = AutoAnalyzeJobPending
+	return &autoAnalyzeJob{AutoAnalyzeJobInfo: info, statsVer: statsVer, sql: sql, params: params, escapedSQL: escaped}
 }
 
-func (h *Handle) autoAnalyzeTable(tblInfo *model.TableInfo, statsTbl *statistics.Table, start, end time.Time, ratio float64, sql string, params ...interface{}) bool {
+func (h *Handle) autoAnalyzeTableJob(tblInfo *model.TableInfo, statsTbl *statistics.Table, db, partitionName string, start, end time.Time, ratio float64, readKeys int64) *autoAnalyzeJob {
 	if statsTbl.Pseudo || statsTbl.Count < AutoAnalyzeMinCnt {
-		return false
+		return nil
+	}
+	sql := "analyze table %n.%n"
+	params := []interface{}{db, tblInfo.Name.O}
+	if partitionName != "" {
+		sql += " partition %n"
+		params = append(params, partitionName)
+	}
+	info := AutoAnalyzeJobInfo{
+		DBName:        db,
+		TableName:     tblInfo.Name.O,
+		PartitionName: partitionName,
+		Count:         statsTbl.Count,
+		ModifyCount:   statsTbl.ModifyCount,
+		ReadKeys:      readKeys,
 	}
 	if needAnalyze, reason := NeedAnalyzeTable(statsTbl, 20*h.Lease(), ratio, start, end, time.Now()); needAnalyze {
-		escaped, err := sqlexec.EscapeSQL(sql, params...)
-		if err != nil {
-			return false
-		}
-		logutil.BgLogger().Info("[stats] auto analyze triggered", zap.String("sql", escaped), zap.String("reason", reason))
+		info.Reason = reason
+		info.Priority = calcAutoAnalyzePriority(statsTbl.Count, statsTbl.ModifyCount, readKeys, !TableAnalyzed(statsTbl))
 		tableStatsVer := h.mu.ctx.GetSessionVars().AnalyzeVersion
 		statistics.CheckAnalyzeVerOnTable(statsTbl, &tableStatsVer)
-		h.execAutoAnalyze(tableStatsVer, sql, params...)
-		return true
+		return newAutoAnalyzeJob(info, tableStatsVer, sql, params...)
 	}
 	for _, idx := range tblInfo.Indices {
 		if _, ok := statsTbl.Indices[idx.ID]; !ok && idx.State == model.StatePublic {
-			sqlWithIdx := sql + "index %n"
-			paramsWithIdx := append(params, idx.Name.O)
-			escaped, err := sqlexec.EscapeSQL(sql, params...)
-			if err != nil {
-				return false
-			}
-			logutil.BgLogger().Info("[stats] auto analyze for unanalyzed", zap.String("sql", escaped))
+			info.Reason = fmt.Sprintf("index %s unanalyzed", idx.Name.O)
+			info.Priority = calcAutoAnalyzePriority(statsTbl.Count, statsTbl.ModifyCount, readKeys, true)
 			tableStatsVer := h.mu.ctx.GetSessionVars().AnalyzeVersion
 			statistics.CheckAnalyzeVerOnTable(statsTbl, &tableStatsVer)
-			h.execAutoAnalyze(tableStatsVer, sqlWithIdx, paramsWithIdx...)
-			return true
+			return newAutoAnalyzeJob(info, tableStatsVer, sql+" index %n", append(params, idx.Name.O)...)
 		}
 	}
-	return false
+	return nil
 }
 
-func (h *Handle) autoAnalyzePartitionTable(tblInfo *model.TableInfo, pi *model.PartitionInfo, db string, start, end time.Time, ratio float64) bool {
+func (h *Handle) autoAnalyzePartitionTableJob(tblInfo *model.TableInfo, pi *model.PartitionInfo, db string, start, end time.Time, ratio float64, readKeys map[int64]int64) *autoAnalyzeJob {
 	tableStatsVer := h.mu.ctx.GetSessionVars().AnalyzeVersion
 	partitionNames := make([]interface{}, 0, len(pi.Definitions))
+	info := AutoAnalyzeJobInfo{DBName: db, TableName: tblInfo.Name.O}
+	for _, def := range pi.Definitions {
+		info.ReadKeys += readKeys[def.ID]
+	}
+	unanalyzed := false
 	for _, def := range pi.Definitions {
 		partitionStatsTbl := h.GetPartitionStats(tblInfo, def.ID)
 		if partitionStatsTbl.Pseudo || partitionStatsTbl.Count < AutoAnalyzeMinCnt {
@@ -1014,6 +1157,9 @@ func (h *Handle) autoAnalyzePartitionTable(tblInfo *model.TableInfo, pi *model.P
 		}
 		if needAnalyze, _ := NeedAnalyzeTable(partitionStatsTbl, 20*h.Lease(), ratio, start, end, time.Now()); needAnalyze {
 			partitionNames = append(partitionNames, def.Name.O)
+			info.Count += partitionStatsTbl.Count
+			info.ModifyCount += partitionStatsTbl.ModifyCount
+			unanalyzed = unanalyzed || !TableAnalyzed(partitionStatsTbl)
 			statistics.CheckAnalyzeVerOnTable(partitionStatsTbl, &tableStatsVer)
 		}
 	}
@@ -1029,14 +1175,25 @@ func (h *Handle) autoAnalyzePartitionTable(tblInfo *model.TableInfo, pi *model.P
 		sqlBuilder.WriteString(suffix)
 		return sqlBuilder.String()
 	}
+	joinNames := func(names []interface{}) string {
+		strs := make([]string, 0, len(names))
+		for _, name := range names {
+			strs = append(strs, name.(string))
+		}
+		return strings.Join(strs, ",")
+	}
 	if len(partitionNames) > 0 {
-		logutil.BgLogger().Info("[stats] auto analyze triggered")
 		sql := getSQL("analyze table %n.%n partition", "", len(partitionNames))
 		params := append([]interface{}{db, tblInfo.Name.O}, partitionNames...)
 		statsTbl := h.GetTableStats(tblInfo)
 		statistics.CheckAnalyzeVerOnTable(statsTbl, &tableStatsVer)
-		h.execAutoAnalyze(tableStatsVer, sql, params...)
-		return true
+		info.PartitionName = joinNames(partitionNames)
+		info.Reason = fmt.Sprintf("too many modifications(%v/%v>%v)", info.ModifyCount, info.Count, ratio)
+		if unanalyzed {
+			info.Reason = "partition unanalyzed"
+		}
+		info.Priority = calcAutoAnalyzePriority(info.Count, info.ModifyCount, info.ReadKeys, unanalyzed)
+		return newAutoAnalyzeJob(info, tableStatsVer, sql, params...)
 	}
 	for _, idx := range tblInfo.Indices {
 		if idx.State != model.StatePublic {
@@ -1046,21 +1203,24 @@ func (h *Handle) autoAnalyzePartitionTable(tblInfo *model.TableInfo, pi *model.P
 			partitionStatsTbl := h.GetPartitionStats(tblInfo, def.ID)
 			if _, ok := partitionStatsTbl.Indices[idx.ID]; !ok {
 				partitionNames = append(partitionNames, def.Name.O)
+				info.Count += partitionStatsTbl.Count
+				info.ModifyCount += partitionStatsTbl.ModifyCount
 				statistics.CheckAnalyzeVerOnTable(partitionStatsTbl, &tableStatsVer)
 			}
 		}
 		if len(partitionNames) > 0 {
-			logutil.BgLogger().Info("[stats] auto analyze for unanalyzed")
 			sql := getSQL("analyze table %n.%n partition", " index %n", len(partitionNames))
 			params := append([]interface{}{db, tblInfo.Name.O}, partitionNames...)
 			params = append(params, idx.Name.O)
 			statsTbl := h.GetTableStats(tblInfo)
 			statistics.CheckAnalyzeVerOnTable(statsTbl, &tableStatsVer)
-			h.execAutoAnalyze(tableStatsVer, sql, params...)
-			return true
+			info.PartitionName = joinNames(partitionNames)
+			info.Reason = fmt.Sprintf("index %s unanalyzed", idx.Name.O)
+			info.Priority = calcAutoAnalyzePriority(info.Count, info.ModifyCount, info.ReadKeys, true)
+			return newAutoAnalyzeJob(info, tableStatsVer, sql, params...)
 		}
 	}
-	return false
+	return nil
 }
 
 var execOptionForAnalyze = map[int]sqlexec.OptionFuncAlias{
@@ -36,6 +36,7 @@ import (
 	"github.com/pingcap/tidb/types"
 	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/ranger"
+	"github.com/pingcap/tidb/util/tabletraffic"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/testleak"
 	dto "github.com/prometheus/client_model/go"
@@ -657,6 +658,98 @@ func (s *testSerialStatsSuite) TestAutoAnalyzeOnChangeAnalyzeVer(c *C) {
 	tk.MustExec("set @@global.tidb_analyze_version = 1")
 }
 
+func (s *testSerialStatsSuite) TestAutoAnalyzeQueue(c *C) {
+	defer cleanEnv(c, s.store, s.do)
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	handle.AutoAnalyzeMinCnt = 0
+	defer func() {
+		handle.AutoAnalyzeMinCnt = 1000
+	}()
+	tabletraffic.Reset()
+	defer tabletraffic.Reset()
+	h := s.do.StatsHandle()
+	tk.MustExec("create table t1(a int)")
+	c.Assert(h.HandleDDLEvent(<-h.DDLEventCh()), IsNil)
+	tk.MustExec("create table t2(a int)")
+	c.Assert(h.HandleDDLEvent(<-h.DDLEventCh()), IsNil)
+	tk.MustExec("insert into t1 values (1), (2)")
+	tk.MustExec("insert into t2 values (1), (2), (3), (4), (5), (6), (7), (8), (9), (10)")
+	c.Assert(h.DumpStatsDeltaToKV(handle.DumpAll), IsNil)
+	is := s.do.InfoSchema()
+	c.Assert(h.Update(is), IsNil)
+
+	// The larger table is analyzed first.
+	c.Assert(h.HandleAutoAnalyze(is), IsTrue)
+	tk.MustQuery("select table_name, state, table_rows, modify_count from information_schema.auto_analyze_queue").Check(
+		testkit.Rows("t1 pending 2 2"))
+	c.Assert(h.Update(is), IsNil)
+	tbl, err := is.TableByName(model.NewCIStr("test"), model.NewCIStr("t2"))
+	c.Assert(err, IsNil)
+	c.Assert(handle.TableAnalyzed(h.GetTableStats(tbl.Meta())), IsTrue)
+
+	c.Assert(h.HandleAutoAnalyze(is), IsTrue)
+	tk.MustQuery("select count(*) from information_schema.auto_analyze_queue").Check(testkit.Rows("0"))
+	c.Assert(h.Update(is), IsNil)
+	c.Assert(h.HandleAutoAnalyze(is), IsFalse)
+
+	// The jobs are executed concurrently.
+	tk.MustExec("set global tidb_auto_analyze_concurrency = 2")
+	defer tk.MustExec("set global tidb_auto_analyze_concurrency = default")
+	tk.MustExec("insert into t1 values (3), (4), (5), (6)")
+	tk.MustExec("insert into t2 values (11), (12), (13), (14), (15), (16), (17), (18), (19), (20), (21), (22)")
+	c.Assert(h.DumpStatsDeltaToKV(handle.DumpAll), IsNil)
+	c.Assert(h.Update(is), IsNil)
+	c.Assert(h.HandleAutoAnalyze(is), IsTrue)
+	c.Assert(h.Update(is), IsNil)
+	c.Assert(h.HandleAutoAnalyze(is), IsFalse)
+}
+
+func (s *testStatsSuite) TestPartitionLiteStats(c *C) {
+	defer cleanEnv(c, s.store, s.do)
+	testKit := testkit.NewTestKit(c, s.store)
+	testKit.MustExec("use test")
+	testKit.MustExec("set @@tidb_partition_prune_mode = 'static'")
+	testKit.MustExec(`create table t (a int, b varchar(10))
+				partition by range (a) (
+				partition p0 values less than (10),
+				partition p1 values less than (20),
+				partition p2 values less than (30))`)
+	h := s.do.StatsHandle()
+	c.Assert(h.HandleDDLEvent(<-h.DDLEventCh()), IsNil)
+	testKit.MustExec("insert into t values (1, 'a'), (2, 'bb'), (11, 'c'), (21, 'd'), (22, 'e'), (23, 'f')")
+	testKit.MustExec("delete from t where a = 23")
+	c.Assert(h.DumpStatsDeltaToKV(handle.DumpAll), IsNil)
+	is := s.do.InfoSchema()
+	c.Assert(h.Update(is), IsNil)
+	tbl, err := is.TableByName(model.NewCIStr("test"), model.NewCIStr("t"))
+	c.Assert(err, IsNil)
+	tableInfo := tbl.Meta()
+
+	liteStats := h.GetLiteStats(tableInfo, nil)
+	c.Assert(liteStats.Count, Equals, int64(5))
+	c.Assert(liteStats.ModifyCount, Equals, int64(7))
+	c.Assert(liteStats.Partitions, Equals, 3)
+	c.Assert(liteStats.AvgRowSize > 0, IsTrue)
+	liteStats = h.GetLiteStats(tableInfo, []int64{tableInfo.GetPartitionInfo().Definitions[0].ID})
+	c.Assert(liteStats.Count, Equals, int64(2))
+	c.Assert(liteStats.Partitions, Equals, 1)
+
+	// There are no global-stats, the row count of the selected partitions is used in the dynamic prune mode.
+	testKit.MustExec("set @@tidb_partition_prune_mode = 'dynamic'")
+	scanRows := func(sql string) string {
+		for _, row := range testKit.MustQuery(sql).Rows() {
+			if strings.Contains(row[0].(string), "TableFullScan") {
+				return row[1].(string)
+			}
+		}
+		return ""
+	}
+	// The conditions don't change the row count of the table, they are estimated against it.
+	c.Assert(scanRows("explain select * from t where a < 10"), Equals, "5.00")
+	c.Assert(scanRows("explain select * from t partition (p1, p2)"), Equals, "3.00")
+}
+
 func (s *testStatsSuite) TestTableAnalyzed(c *C) {
 	defer cleanEnv(c, s.store, s.do)
 	testKit := testkit.NewTestKit(c, s.store)
@@ -281,11 +281,12 @@ func (s *testIntegrationSuite) TestGlobalStats(c *C) {
 		"└─IndexReader 1.00 root  index:IndexRangeScan",
 		"  └─IndexRangeScan 1.00 cop[tikv] table:t, partition:p1, index:a(a) range:(3,+inf], keep order:false"))
 
-	// When we turned on the switch, we found that pseudo-stats will be used in the plan instead of `Union`.
+	// When we turned on the switch, we found that the row count aggregated from the stats meta of the partitions
+	// will be used in the plan instead of `Union`, the conditions are still estimated by the pseudo selectivity.
 	tk.MustExec("set @@tidb_partition_prune_mode = 'dynamic';")
 	tk.MustQuery("explain format = 'brief' select a from t where a > 3;").Check(testkit.Rows(
-		"IndexReader 3333.33 root partition:all index:IndexRangeScan",
-		"└─IndexRangeScan 3333.33 cop[tikv] table:t, index:a(a) range:(3,+inf], keep order:false, stats:pseudo"))
+		"IndexReader 2.08 root partition:all index:IndexRangeScan",
+		"└─IndexRangeScan 2.08 cop[tikv] table:t, index:a(a) range:(3,+inf], keep order:false"))
 
 	// Execute analyze again without error and can generate global-stats.
 	// And when executing related queries, neither Union nor pseudo-stats are used.
@@ -0,0 +1,120 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"math"
+
+	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/codec"
+)
+
+// EqualJoinRowCount estimates the row count of the equal join on the single column indexes whose values are encoded
+// in the same way. The TopN values are matched by their encoded values, and the other values are matched bucket by
+// bucket: the rows of a bucket except the ones of its upper bound are assumed to be evenly distributed over the
+// distinct values of the bucket, so a skewed bucket is estimated by its own density instead of the one of the whole
+// index. ok is false if the indexes don't have the bucket NDVs, which are only collected by the statistics of
+// version 2.
+func EqualJoinRowCount(lIdx, rIdx *Index) (count float64, ok bool) {
+	if !hasBucketNDV(lIdx) || !hasBucketNDV(rIdx) {
+		return 0, false
+	}
+	lHist, rHist := &lIdx.Histogram, &rIdx.Histogram
+	if lIdx.TopN != nil {
+		for _, meta := range lIdx.TopN.TopN {
+			if isNullEncoded(meta.Encoded) {
+				continue
+			}
+			if rCount, ok := rIdx.TopN.QueryTopN(meta.Encoded); ok {
+				count += float64(meta.Count) * float64(rCount)
+				continue
+			}
+			count += float64(meta.Count) * rHist.equalRowCount(types.NewBytesDatum(meta.Encoded), true)
+		}
+	}
+	if rIdx.TopN != nil {
+		for _, meta := range rIdx.TopN.TopN {
+			if isNullEncoded(meta.Encoded) {
+				continue
+			}
+			if _, ok := lIdx.TopN.QueryTopN(meta.Encoded); ok {
+				continue
+			}
+			count += float64(meta.Count) * lHist.equalRowCount(types.NewBytesDatum(meta.Encoded), true)
+		}
+	}
+	return count + lHist.equalJoinRowCount(rHist), true
+}
+
+// hasBucketNDV checks whether all the buckets of the index have the NDVs.
+func hasBucketNDV(idx *Index) bool {
+	if idx == nil || idx.Info == nil || idx.StatsVer < Version2 || len(idx.Info.Columns) != 1 {
+		return false
+	}
+	for _, bkt := range idx.Buckets {
+		if bkt.NDV <= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// isNullEncoded checks whether the encoded index value is NULL, which never matches any value in the equal join.
+func isNullEncoded(encoded []byte) bool {
+	return len(encoded) > 0 && encoded[0] == codec.NilFlag
+}
+
+// equalJoinRowCount estimates the row count of the equal join on the values in the buckets of the two histograms.
+func (hg *Histogram) equalJoinRowCount(other *Histogram) float64 {
+	var count float64
+	for i := 0; i < hg.Len(); i++ {
+		lower, upper := *hg.GetLower(i), *hg.GetUpper(i)
+		if isNullEncoded(upper.GetBytes()) {
+			continue
+		}
+		// The rows of the upper bound are matched by its repeat.
+		count += float64(hg.Buckets[i].Repeat) * other.equalRowCount(upper, true)
+		rows, ndv := float64(hg.bucketCount(i)-hg.Buckets[i].Repeat), float64(hg.Buckets[i].NDV-1)
+		if rows <= 0 || ndv <= 0 {
+			continue
+		}
+		otherRows := other.BetweenRowCount(lower, upper)
+		if otherRows <= 0 {
+			continue
+		}
+		otherNDV := other.betweenNDV(lower, upper, otherRows)
+		count += rows * otherRows / math.Max(math.Max(ndv, otherNDV), 1)
+	}
+	return count
+}
+
+// betweenNDV estimates the NDV of the rows whose values are in [lower, upper) by the densities of the buckets
+// overlapped with the range, rows is the estimated row count of them. The upper bounds of the buckets are excluded
+// from the densities since their rows are counted by the repeats.
+func (hg *Histogram) betweenNDV(lower, upper types.Datum, rows float64) float64 {
+	if hg.Len() == 0 {
+		return 1
+	}
+	_, first := hg.LessRowCountWithBktIdx(lower)
+	_, last := hg.LessRowCountWithBktIdx(upper)
+	var bktRows, bktNDV float64
+	for i := first; i <= last; i++ {
+		bktRows += float64(hg.bucketCount(i) - hg.Buckets[i].Repeat)
+		bktNDV += float64(hg.Buckets[i].NDV - 1)
+	}
+	if bktRows <= 0 || bktNDV <= 0 {
+		return 1
+	}
+	return math.Max(rows*bktNDV/bktRows, 1)
+}
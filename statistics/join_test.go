@@ -0,0 +1,69 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser/model"
+	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/codec"
+)
+
+func (s *testStatisticsSuite) TestEqualJoinRowCount(c *C) {
+	buckets := []*bucket4Test{
+		// 9 distinct values with 2 rows each and the upper bound with 2 rows.
+		{lower: 1, upper: 10, count: 20, repeat: 2, ndv: 10},
+		// 1 distinct value with 18 rows and the upper bound with 2 rows.
+		{lower: 11, upper: 20, count: 40, repeat: 2, ndv: 2},
+	}
+	genIdx := func(topN []topN4Test) *Index {
+		idx := &Index{
+			Histogram: *genHist4Test(c, buckets, 0),
+			Info:      &model.IndexInfo{Columns: []*model.IndexColumn{{Name: model.NewCIStr("a"), Offset: 0}}},
+			StatsVer:  Version2,
+		}
+		idx.PreCalculateScalar()
+		if len(topN) > 0 {
+			idx.TopN = NewTopN(len(topN))
+			for _, meta := range topN {
+				d := types.NewIntDatum(meta.data)
+				if meta.data < 0 {
+					d.SetNull()
+				}
+				encoded, err := codec.EncodeKey(nil, nil, d)
+				c.Assert(err, IsNil)
+				idx.TopN.AppendTopN(encoded, uint64(meta.count))
+			}
+			idx.TopN.Sort()
+		}
+		return idx
+	}
+
+	// The skewed bucket is estimated by its own NDV: 2*2 + 18*18/9 + 2*2 + 18*18/1.
+	count, ok := EqualJoinRowCount(genIdx(nil), genIdx(nil))
+	c.Assert(ok, IsTrue)
+	c.Assert(count, Equals, float64(368))
+
+	// The NULLs never match. The common TopN value matches 50*30 rows, and the TopN value of the right side matches
+	// 7 * (18/9) rows in the histogram of the left side.
+	count, ok = EqualJoinRowCount(genIdx([]topN4Test{{-1, 10}, {100, 50}}), genIdx([]topN4Test{{-1, 10}, {5, 7}, {100, 30}}))
+	c.Assert(ok, IsTrue)
+	c.Assert(count, Equals, float64(368+1500+14))
+
+	// The statistics of version 1 don't have the bucket NDVs.
+	idx := genIdx(nil)
+	idx.StatsVer = Version1
+	_, ok = EqualJoinRowCount(idx, genIdx(nil))
+	c.Assert(ok, IsFalse)
+}
@@ -181,7 +181,7 @@ func (coll *HistColl) Selectivity(ctx sessionctx.Context, exprs []expression.Exp
 	// TODO: If len(exprs) is bigger than 63, we could use bitset structure to replace the int64.
 	// This will simplify some code and speed up if we use this rather than a boolean slice.
 	if len(exprs) > 63 || (len(coll.Columns) == 0 && len(coll.Indices) == 0) {
-		return pseudoSelectivity(coll, exprs), nil, nil
+		return coll.refineByFeedback(ctx, exprs, pseudoSelectivity(coll, exprs)), nil, nil
 	}
 	ret := 1.0
 	var nodes []*StatsNode
@@ -352,9 +352,22 @@ func (coll *HistColl) Selectivity(ctx sessionctx.Context, exprs []expression.Exp
 	if mask > 0 {
 		ret *= selectionFactor
 	}
+	// The estimation is not confident if some conditions are estimated by the default selection factor
+	// or the statistics are pseudo, so try to refine it with the selectivity observed in execution.
+	if mask > 0 || coll.Pseudo {
+		ret = coll.refineByFeedback(ctx, exprs, ret)
+	}
 	return ret, nodes, nil
 }
 
+// refineByFeedback refines the low confidence selectivity with the one observed in execution.
+func (coll *HistColl) refineByFeedback(ctx sessionctx.Context, exprs []expression.Expression, selectivity float64) float64 {
+	if !ctx.GetSessionVars().EnableSelectivityFeedback {
+		return selectivity
+	}
+	return GlobalSelectivityFeedback.Estimate(coll.PhysicalID, exprs, selectivity)
+}
+
 func getMaskAndRanges(ctx sessionctx.Context, exprs []expression.Expression, rangeType ranger.RangeType, lengths []int, cachedPath *planutil.AccessPath, cols ...*expression.Column) (mask int64, ranges []*ranger.Range, partCover bool, err error) {
 	sc := ctx.GetSessionVars().StmtCtx
 	isDNF := false
@@ -0,0 +1,190 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/util/kvcache"
+	"github.com/pingcap/tidb/util/ranger"
+)
+
+const (
+	// defaultSelectivityFeedbackCapacity is the max number of the predicates kept by GlobalSelectivityFeedback.
+	defaultSelectivityFeedbackCapacity = 10000
+	// defaultSelectivityFeedbackHalfLife is the duration after which an observed selectivity loses half of its weight.
+	defaultSelectivityFeedbackHalfLife = 10 * time.Minute
+	// minSelectivityFeedbackWeight is the weight below which an observed selectivity is regarded as expired.
+	minSelectivityFeedbackWeight = 0.05
+)
+
+// GlobalSelectivityFeedback keeps the selectivities of the predicates observed in execution.
+var GlobalSelectivityFeedback = NewSelectivityFeedback(defaultSelectivityFeedbackCapacity, defaultSelectivityFeedbackHalfLife)
+
+// SelectivityFeedback is a bounded in-memory model which maps the digests of predicates to the
+// selectivities observed in execution. It is consulted as a secondary estimator when the histograms
+// can't give a confident estimation, and the weight of an observation decays over time.
+type SelectivityFeedback struct {
+	mu       sync.Mutex
+	cache    *kvcache.SimpleLRUCache
+	halfLife time.Duration
+}
+
+type selectivityFeedbackKey []byte
+
+// Hash implements kvcache.Key interface.
+func (key selectivityFeedbackKey) Hash() []byte {
+	return key
+}
+
+type observedSelectivity struct {
+	selectivity float64
+	updateTime  time.Time
+}
+
+// NewSelectivityFeedback creates a SelectivityFeedback which keeps at most capacity predicates.
+func NewSelectivityFeedback(capacity uint, halfLife time.Duration) *SelectivityFeedback {
+	return &SelectivityFeedback{
+		cache:    kvcache.NewSimpleLRUCache(capacity, 0, 0),
+		halfLife: halfLife,
+	}
+}
+
+// Update records the selectivity of the predicates on the table observed in execution.
+func (f *SelectivityFeedback) Update(physicalID int64, exprs []expression.Expression, selectivity float64) {
+	f.update(newSelectivityFeedbackKey(physicalID, exprs), selectivity, time.Now())
+}
+
+// Estimate refines the estimated selectivity of the predicates on the table with the observed one.
+// The estimated selectivity is returned as it is if there's no valid observation.
+func (f *SelectivityFeedback) Estimate(physicalID int64, exprs []expression.Expression, estimated float64) float64 {
+	return f.estimate(newSelectivityFeedbackKey(physicalID, exprs), estimated, time.Now())
+}
+
+// UpdateRanges records the selectivity of the ranges on the index of the table observed in execution,
+// idxID is 0 for the ranges on the integer handle.
+func (f *SelectivityFeedback) UpdateRanges(physicalID, idxID int64, ranges []*ranger.Range, selectivity float64) {
+	f.update(newRangeFeedbackKey(physicalID, idxID, ranges), selectivity, time.Now())
+}
+
+// EstimateRanges refines the estimated selectivity of the ranges on the index of the table with the observed one.
+// The estimated selectivity is returned as it is if there's no valid observation.
+func (f *SelectivityFeedback) EstimateRanges(physicalID, idxID int64, ranges []*ranger.Range, estimated float64) float64 {
+	return f.estimate(newRangeFeedbackKey(physicalID, idxID, ranges), estimated, time.Now())
+}
+
+func (f *SelectivityFeedback) update(key selectivityFeedbackKey, selectivity float64, now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if v, ok := f.cache.Get(key); ok {
+		// Merge the new observation with the old one, the older the old one is, the less it counts.
+		old := v.(*observedSelectivity)
+		w := f.weight(old, now)
+		selectivity = (old.selectivity*w + selectivity) / (w + 1)
+	}
+	f.cache.Put(key, &observedSelectivity{selectivity: selectivity, updateTime: now})
+}
+
+func (f *SelectivityFeedback) estimate(key selectivityFeedbackKey, estimated float64, now time.Time) float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v, ok := f.cache.Get(key)
+	if !ok {
+		return estimated
+	}
+	observed := v.(*observedSelectivity)
+	w := f.weight(observed, now)
+	if w < minSelectivityFeedbackWeight {
+		f.cache.Delete(key)
+		return estimated
+	}
+	return observed.selectivity*w + estimated*(1-w)
+}
+
+// weight returns the weight of the observation, which halves every halfLife.
+func (f *SelectivityFeedback) weight(observed *observedSelectivity, now time.Time) float64 {
+	age := now.Sub(observed.updateTime)
+	if age <= 0 || f.halfLife <= 0 {
+		return 1
+	}
+	return math.Pow(0.5, float64(age)/float64(f.halfLife))
+}
+
+// newSelectivityFeedbackKey builds the key from the table and the digest of the predicates. The columns
+// are identified by their column IDs so that the same predicates in different statements share the key.
+func newSelectivityFeedbackKey(physicalID int64, exprs []expression.Expression) selectivityFeedbackKey {
+	digests := make([]string, 0, len(exprs))
+	for _, expr := range exprs {
+		var sb strings.Builder
+		writePredicateDigest(&sb, expr)
+		digests = append(digests, sb.String())
+	}
+	// The predicates are in CNF, so their order doesn't matter.
+	sort.Strings(digests)
+	h := fnv.New64a()
+	for _, digest := range digests {
+		h.Write([]byte(digest))
+		h.Write([]byte{0})
+	}
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key, uint64(physicalID))
+	binary.BigEndian.PutUint64(key[8:], h.Sum64())
+	return key
+}
+
+// newRangeFeedbackKey builds the key from the table, the index and the ranges. The ranges are tagged so that
+// they never share the key with the predicates.
+func newRangeFeedbackKey(physicalID, idxID int64, ranges []*ranger.Range) selectivityFeedbackKey {
+	h := fnv.New64a()
+	h.Write([]byte("ranges#"))
+	h.Write([]byte(strconv.FormatInt(idxID, 10)))
+	for _, ran := range ranges {
+		h.Write([]byte{0})
+		h.Write([]byte(ran.String()))
+	}
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key, uint64(physicalID))
+	binary.BigEndian.PutUint64(key[8:], h.Sum64())
+	return key
+}
+
+func writePredicateDigest(sb *strings.Builder, expr expression.Expression) {
+	switch x := expr.(type) {
+	case *expression.CorrelatedColumn:
+		sb.WriteString("?")
+	case *expression.Column:
+		sb.WriteString("col#")
+		sb.WriteString(strconv.FormatInt(x.ID, 10))
+	case *expression.ScalarFunction:
+		sb.WriteString(x.FuncName.L)
+		sb.WriteString("(")
+		for i, arg := range x.GetArgs() {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			writePredicateDigest(sb, arg)
+		}
+		sb.WriteString(")")
+	default:
+		sb.WriteString(expr.String())
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/mock"
+	"github.com/pingcap/tidb/util/ranger"
+)
+
+var _ = Suite(&testSelectivityFeedbackSuite{})
+
+type testSelectivityFeedbackSuite struct {
+}
+
+func (s *testSelectivityFeedbackSuite) TestSelectivityFeedbackKey(c *C) {
+	ctx := mock.NewContext()
+	newCol := func(id int64, uniqueID int64) *expression.Column {
+		return &expression.Column{ID: id, UniqueID: uniqueID, RetType: types.NewFieldType(mysql.TypeLonglong)}
+	}
+	gt := func(col *expression.Column, val int64) expression.Expression {
+		return expression.NewFunctionInternal(ctx, ast.GT, types.NewFieldType(mysql.TypeTiny), col, &expression.Constant{Value: types.NewIntDatum(val), RetType: types.NewFieldType(mysql.TypeLonglong)})
+	}
+
+	// The unique ids of the columns and the order of the CNF items don't matter.
+	key1 := newSelectivityFeedbackKey(1, []expression.Expression{gt(newCol(1, 10), 5), gt(newCol(2, 11), 6)})
+	key2 := newSelectivityFeedbackKey(1, []expression.Expression{gt(newCol(2, 21), 6), gt(newCol(1, 20), 5)})
+	c.Assert(key1, DeepEquals, key2)
+	// The constants, the columns and the table do.
+	c.Assert(newSelectivityFeedbackKey(1, []expression.Expression{gt(newCol(1, 10), 5), gt(newCol(2, 11), 7)}), Not(DeepEquals), key1)
+	c.Assert(newSelectivityFeedbackKey(1, []expression.Expression{gt(newCol(1, 10), 5), gt(newCol(3, 11), 6)}), Not(DeepEquals), key1)
+	c.Assert(newSelectivityFeedbackKey(2, []expression.Expression{gt(newCol(1, 10), 5), gt(newCol(2, 11), 6)}), Not(DeepEquals), key1)
+}
+
+func (s *testSelectivityFeedbackSuite) TestRangeFeedbackKey(c *C) {
+	newRanges := func(vals ...int64) []*ranger.Range {
+		ranges := make([]*ranger.Range, 0, len(vals))
+		for _, val := range vals {
+			ranges = append(ranges, &ranger.Range{LowVal: []types.Datum{types.NewIntDatum(val)}, HighVal: []types.Datum{types.NewIntDatum(val)}})
+		}
+		return ranges
+	}
+
+	key := newRangeFeedbackKey(1, 2, newRanges(1, 3))
+	c.Assert(newRangeFeedbackKey(1, 2, newRanges(1, 3)), DeepEquals, key)
+	// The ranges, the index and the table are all in the key.
+	c.Assert(newRangeFeedbackKey(1, 2, newRanges(1, 4)), Not(DeepEquals), key)
+	c.Assert(newRangeFeedbackKey(1, 3, newRanges(1, 3)), Not(DeepEquals), key)
+	c.Assert(newRangeFeedbackKey(2, 2, newRanges(1, 3)), Not(DeepEquals), key)
+	// The ranges never share the key with the predicates.
+	c.Assert(newRangeFeedbackKey(1, 0, nil), Not(DeepEquals), newSelectivityFeedbackKey(1, nil))
+}
+
+func (s *testSelectivityFeedbackSuite) TestSelectivityFeedbackDecay(c *C) {
+	halfLife := time.Minute
+	f := NewSelectivityFeedback(2, halfLife)
+	key1, key2, key3 := selectivityFeedbackKey("k1"), selectivityFeedbackKey("k2"), selectivityFeedbackKey("k3")
+	now := time.Now()
+
+	// No observation, the estimation is kept.
+	c.Assert(f.estimate(key1, 0.8, now), Equals, 0.8)
+
+	f.update(key1, 0.1, now)
+	c.Assert(f.estimate(key1, 0.8, now), Equals, 0.1)
+	// The observation loses half of its weight after a half life.
+	c.Assert(f.estimate(key1, 0.8, now.Add(halfLife)), Equals, 0.45)
+	// A fresh observation is merged with the old one.
+	f.update(key1, 0.3, now)
+	c.Assert(f.estimate(key1, 0.8, now), Equals, 0.2)
+	// The expired observation is dropped.
+	c.Assert(f.estimate(key1, 0.8, now.Add(10*halfLife)), Equals, 0.8)
+	c.Assert(f.cache.Size(), Equals, 0)
+
+	// The model is bounded.
+	f.update(key1, 0.1, now)
+	f.update(key2, 0.2, now)
+	f.update(key3, 0.3, now)
+	c.Assert(f.cache.Size(), Equals, 2)
+	c.Assert(f.estimate(key1, 0.8, now), Equals, 0.8)
+	c.Assert(f.estimate(key3, 0.8, now), Equals, 0.3)
+}
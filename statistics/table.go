@@ -258,6 +258,23 @@ func (t *Table) IsOutdated() bool {
 	return false
 }
 
+// LastAnalyzeVersion returns the version of the latest updated histogram of the table, which changes when the table
+// is analyzed.
+func (t *Table) LastAnalyzeVersion() uint64 {
+	var version uint64
+	for _, col := range t.Columns {
+		if col.LastUpdateVersion > version {
+			version = col.LastUpdateVersion
+		}
+	}
+	for _, idx := range t.Indices {
+		if idx.LastUpdateVersion > version {
+			version = idx.LastUpdateVersion
+		}
+	}
+	return version
+}
+
 // ColumnGreaterRowCount estimates the row count where the column greater than value.
 func (t *Table) ColumnGreaterRowCount(sc *stmtctx.StatementContext, value types.Datum, colID int64) float64 {
 	c, ok := t.Columns[colID]
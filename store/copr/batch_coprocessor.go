@@ -45,6 +45,12 @@ type batchCopTask struct {
 	ctx       *tikv.RPCContext
 
 	regionInfos []RegionInfo
+	// tableID is the physical table read by the task, it's only set for the
+	// mpp tasks built for several tables.
+	tableID int64
+	// scanID is the table scan which the ranges of the task belong to, it's
+	// only set for the mpp tasks built for several tables.
+	scanID int
 }
 
 type batchCopResponse struct {
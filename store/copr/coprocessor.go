@@ -30,6 +30,7 @@ import (
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/kvproto/pkg/coprocessor"
 	"github.com/pingcap/kvproto/pkg/kvrpcpb"
+	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/parser/terror"
 	"github.com/pingcap/tidb/domain/infosync"
 	"github.com/pingcap/tidb/errno"
@@ -38,8 +39,11 @@ import (
 	"github.com/pingcap/tidb/store/driver/backoff"
 	derr "github.com/pingcap/tidb/store/driver/error"
 	"github.com/pingcap/tidb/store/driver/options"
+	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/util/execdetails"
 	"github.com/pingcap/tidb/util/memory"
+	"github.com/pingcap/tidb/util/tabletraffic"
+	"github.com/pingcap/tidb/util/topsql/tracecpu"
 	"github.com/pingcap/tipb/go-tipb"
 	"github.com/tikv/client-go/v2/logutil"
 	"github.com/tikv/client-go/v2/metrics"
@@ -51,6 +55,14 @@ import (
 
 var coprCacheHistogramEvict = tidbmetrics.DistSQLCoprCacheHistogram.WithLabelValues("evict")
 
+// runningCopTasks and runningMPPTasks are the numbers of the cop tasks and the MPP tasks being handled by the instance.
+var runningCopTasks, runningMPPTasks int64
+
+// RunningTasks returns the numbers of the cop tasks and the MPP tasks being handled by the instance.
+func RunningTasks() (cop, mpp int64) {
+	return atomic.LoadInt64(&runningCopTasks), atomic.LoadInt64(&runningMPPTasks)
+}
+
 // Maximum total sleep time(in ms) for kv/cop commands.
 const (
 	copBuildTaskMaxBackoff = 5000
@@ -92,6 +104,9 @@ func (c *CopClient) Send(ctx context.Context, req *kv.Request, variables interfa
 		rpcCancel:       tikv.NewRPCanceller(),
 		resolvedLocks:   util.NewTSSet(5),
 	}
+	if req.RetryBudget > 0 {
+		it.retryBudget = newRetryBudget(req.RetryBudget)
+	}
 	it.tasks = tasks
 	if it.concurrency > len(tasks) {
 		it.concurrency = len(tasks)
@@ -116,6 +131,9 @@ func (c *CopClient) Send(ctx context.Context, req *kv.Request, variables interfa
 		it.sendRate = util.NewRateLimit(it.concurrency)
 	}
 	it.actionOnExceed = newRateLimitAction(uint(it.sendRate.GetCapacity()))
+	if it.req.Backpressure {
+		it.backpressure = newBackpressureController(it.sendRate.GetCapacity(), it.actionOnExceed)
+	}
 	if sessionMemTracker != nil {
 		sessionMemTracker.FallbackOldAndSetNewAction(it.actionOnExceed)
 	}
@@ -136,14 +154,25 @@ type copTask struct {
 	storeAddr string
 	cmdType   tikvrpc.CmdType
 	storeType kv.StoreType
+
+	// pagingSize is the count of stream responses fetched in one page, 0 means the task is not paged.
+	pagingSize int
 }
 
+// The paging size of a task starts from minPagingSize and is doubled on every page until maxPagingSize.
+// The size of a stream response is decided by TiKV, which is 128 rows by default.
+const (
+	minPagingSize = 1
+	maxPagingSize = 64
+)
+
 func (r *copTask) String() string {
 	return fmt.Sprintf("region(%d %d %d) ranges(%d) store(%s)",
 		r.region.GetID(), r.region.GetConfVer(), r.region.GetVer(), r.ranges.Len(), r.storeAddr)
 }
 
-// rangesPerTask limits the length of the ranges slice sent in one copTask.
+// rangesPerTask limits the length of the ranges slice sent in one copTask by default, it's also the upper bound of
+// kv.Request.MaxRangesPerTask.
 const rangesPerTask = 25000
 
 func buildCopTasks(bo *Backoffer, cache *RegionCache, ranges *KeyRanges, req *kv.Request) ([]*copTask, error) {
@@ -156,9 +185,21 @@ func buildCopTasks(bo *Backoffer, cache *RegionCache, ranges *KeyRanges, req *kv
 	if req.StoreType == kv.TiDB {
 		return buildTiDBMemCopTasks(ranges, req)
 	}
+	pagingSize := 0
+	// Only the stream responses carry the range to resume from, so the unary tasks can't be paged.
+	if req.Paging && cmdType == tikvrpc.CmdCopStream {
+		pagingSize = minPagingSize
+	}
 
 	rangesLen := ranges.Len()
+	maxRanges := rangesPerTask
+	if req.MaxRangesPerTask > 0 && req.MaxRangesPerTask < maxRanges {
+		maxRanges = req.MaxRangesPerTask
+	}
 
+	if !req.KeepRanges {
+		ranges = ranges.coalesce()
+	}
 	locs, err := cache.SplitKeyRangesByLocations(bo, ranges)
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -170,15 +211,16 @@ func buildCopTasks(bo *Backoffer, cache *RegionCache, ranges *KeyRanges, req *kv
 		// to make sure the message can be sent successfully.
 		rLen := loc.Ranges.Len()
 		for i := 0; i < rLen; {
-			nextI := mathutil.Min(i+rangesPerTask, rLen)
+			nextI := mathutil.Min(i+maxRanges, rLen)
 			tasks = append(tasks, &copTask{
 				region: loc.Location.Region,
 				ranges: loc.Ranges.Slice(i, nextI),
 				// Channel buffer is 2 for handling region split.
 				// In a common case, two region split tasks will not be blocked.
-				respChan:  make(chan *copResponse, 2),
-				cmdType:   cmdType,
-				storeType: req.StoreType,
+				respChan:   make(chan *copResponse, 2),
+				cmdType:    cmdType,
+				storeType:  req.StoreType,
+				pagingSize: pagingSize,
 			})
 			i = nextI
 		}
@@ -265,6 +307,13 @@ type copIterator struct {
 	resolvedLocks *util.TSSet
 
 	actionOnExceed *rateLimitAction
+
+	// backpressure adapts the count of in-flight tasks to the drain rate of the consumer,
+	// it is nil if the request doesn't enable backpressure.
+	backpressure *backpressureController
+
+	// retryBudget limits the total backoff time of all tasks, it is nil if the request has no budget.
+	retryBudget *retryBudget
 }
 
 // copIteratorWorker receives tasks from copIteratorTaskSender, handles tasks and sends the copResponse to respChan.
@@ -283,6 +332,8 @@ type copIteratorWorker struct {
 	replicaReadSeed uint32
 
 	actionOnExceed *rateLimitAction
+
+	retryBudget *retryBudget
 }
 
 // copIteratorTaskSender sends tasks to taskCh then wait for the workers to exit.
@@ -359,6 +410,7 @@ func init() {
 // run is a worker function that get a copTask from channel, handle it and
 // send the result back.
 func (worker *copIteratorWorker) run(ctx context.Context) {
+	tracecpu.SetGoroutineLabels(ctx)
 	defer func() {
 		failpoint.Inject("ticase-4169", func(val failpoint.Value) {
 			if val.(bool) {
@@ -409,6 +461,7 @@ func (it *copIterator) open(ctx context.Context, enabledRateLimitAction bool) {
 			memTracker:      it.memTracker,
 			replicaReadSeed: it.replicaReadSeed,
 			actionOnExceed:  it.actionOnExceed,
+			retryBudget:     it.retryBudget,
 		}
 		go worker.run(ctx)
 	}
@@ -558,6 +611,7 @@ func (it *copIterator) Next(ctx context.Context) (kv.ResultSubset, error) {
 	// If data order matters, response should be returned in the same order as copTask slice.
 	// Otherwise all responses are returned from a single channel.
 	if it.respChan != nil {
+		it.releaseBackpressure(it.respChan)
 		// Get next fetched resp from chan
 		resp, ok, closed = it.recvFromRespCh(ctx, it.respChan)
 		if !ok || closed {
@@ -565,9 +619,7 @@ func (it *copIterator) Next(ctx context.Context) (kv.ResultSubset, error) {
 			return nil, nil
 		}
 		if resp == finCopResp {
-			it.actionOnExceed.destroyTokenIfNeeded(func() {
-				it.sendRate.PutToken()
-			})
+			it.actionOnExceed.destroyTokenIfNeeded(it.returnToken)
 			return it.Next(ctx)
 		}
 	} else {
@@ -578,6 +630,7 @@ func (it *copIterator) Next(ctx context.Context) (kv.ResultSubset, error) {
 				return nil, nil
 			}
 			task := it.tasks[it.curr]
+			it.releaseBackpressure(task.respChan)
 			resp, ok, closed = it.recvFromRespCh(ctx, task.respChan)
 			if closed {
 				// Close() is already called, so Next() is invalid.
@@ -586,12 +639,10 @@ func (it *copIterator) Next(ctx context.Context) (kv.ResultSubset, error) {
 			if ok {
 				break
 			}
-			it.actionOnExceed.destroyTokenIfNeeded(func() {
-				it.sendRate.PutToken()
-			})
 			// Switch to next task.
 			it.tasks[it.curr] = nil
 			it.curr++
+			it.actionOnExceed.destroyTokenIfNeeded(it.returnToken)
 		}
 	}
 
@@ -606,6 +657,40 @@ func (it *copIterator) Next(ctx context.Context) (kv.ResultSubset, error) {
 	return resp, nil
 }
 
+// returnToken gives the token of a finished task back to the sender. If backpressure is enabled
+// and the piled up responses show the consumer is slower than the workers, the token is withheld
+// to reduce the in-flight tasks.
+func (it *copIterator) returnToken() {
+	if it.backpressure == nil {
+		it.sendRate.PutToken()
+		return
+	}
+	it.backpressure.onTaskFinished(it.bufferedRespNum(), it.sendRate.PutToken)
+}
+
+// bufferedRespNum returns the count of responses which are fetched but not consumed yet.
+func (it *copIterator) bufferedRespNum() int {
+	if it.respChan != nil {
+		return len(it.respChan)
+	}
+	// Only the tasks holding a token can have buffered responses.
+	buffered := 0
+	end := mathutil.Min(it.curr+it.sendRate.GetCapacity(), len(it.tasks))
+	for i := it.curr; i < end; i++ {
+		buffered += len(it.tasks[i].respChan)
+	}
+	return buffered
+}
+
+// releaseBackpressure gives one withheld token back to the sender if the consumer is going to
+// wait on an empty respCh, which means the workers can't keep up with the consumer.
+func (it *copIterator) releaseBackpressure(respCh chan *copResponse) {
+	if it.backpressure == nil || respCh == nil || len(respCh) > 0 {
+		return
+	}
+	it.backpressure.onConsumerStarved(it.sendRate.PutToken)
+}
+
 // Associate each region with an independent backoffer. In this way, when multiple regions are
 // unavailable, TiDB can execute very quickly without blocking
 func chooseBackoffer(ctx context.Context, backoffermap map[uint64]*Backoffer, task *copTask, worker *copIteratorWorker) *Backoffer {
@@ -613,13 +698,22 @@ func chooseBackoffer(ctx context.Context, backoffermap map[uint64]*Backoffer, ta
 	if ok {
 		return bo
 	}
-	newbo := backoff.NewBackofferWithVars(ctx, copNextMaxBackoff, worker.vars)
+	if worker.retryBudget == nil {
+		newbo := backoff.NewBackofferWithVars(ctx, copNextMaxBackoff, worker.vars)
+		backoffermap[task.region.GetID()] = newbo
+		return newbo
+	}
+	// The backoffs of TiKV client with the TiKVBackoffer are charged after they're done, so the max sleep also
+	// limits them by the remaining budget.
+	newbo := backoff.NewBackofferWithBudget(ctx, worker.retryBudget.maxSleep(copNextMaxBackoff), worker.vars, worker.retryBudget)
 	backoffermap[task.region.GetID()] = newbo
 	return newbo
 }
 
 // handleTask handles single copTask, sends the result to channel, retry automatically on error.
 func (worker *copIteratorWorker) handleTask(ctx context.Context, task *copTask, respCh chan<- *copResponse) {
+	atomic.AddInt64(&runningCopTasks, 1)
+	defer atomic.AddInt64(&runningCopTasks, -1)
 	defer func() {
 		r := recover()
 		if r != nil {
@@ -637,6 +731,12 @@ func (worker *copIteratorWorker) handleTask(ctx context.Context, task *copTask,
 		curTask := remainTasks[0]
 		bo := chooseBackoffer(ctx, backoffermap, curTask, worker)
 		tasks, err := worker.handleTaskOnce(bo, curTask, respCh)
+		if worker.retryBudget != nil {
+			bo.ChargeBudget()
+			if err != nil && worker.retryBudget.exhausted() {
+				err = errors.Annotatef(err, "coprocessor retry budget %v exhausted", worker.retryBudget.total())
+			}
+		}
 		if err != nil {
 			resp := &copResponse{err: errors.Trace(err)}
 			worker.sendToRespCh(resp, respCh, true)
@@ -646,6 +746,14 @@ func (worker *copIteratorWorker) handleTask(ctx context.Context, task *copTask,
 		if vars := bo.GetVars(); vars != nil && vars.Killed != nil && atomic.LoadUint32(vars.Killed) == 1 {
 			return
 		}
+		// The iterator may be closed before the next page is fetched, e.g. the LIMIT is satisfied.
+		if curTask.pagingSize > 0 && len(tasks) > 0 {
+			select {
+			case <-worker.finishCh:
+				return
+			default:
+			}
+		}
 
 		if len(tasks) > 0 {
 			remainTasks = append(tasks, remainTasks[1:]...)
@@ -824,11 +932,18 @@ func (worker *copIteratorWorker) handleCopStreamResult(bo *Backoffer, rpcCtx *ti
 		// streaming request returns io.EOF, so the first Response is nil.
 		return nil, nil
 	}
+	handled := 0
 	for {
 		remainedTasks, err := worker.handleCopResponse(bo, rpcCtx, &copResponse{pbResp: resp}, nil, nil, task, ch, lastRange, costTime)
 		if err != nil || len(remainedTasks) != 0 {
 			return remainedTasks, errors.Trace(err)
 		}
+		handled++
+		if task.pagingSize > 0 && handled >= task.pagingSize && resp.Range != nil {
+			// The page is full, stop the stream so TiKV won't scan the rest of the region until
+			// the next page is requested.
+			return worker.buildNextPageTasks(bo, resp.Range, task)
+		}
 		resp, err = stream.Recv()
 		if err != nil {
 			if errors.Cause(err) == io.EOF {
@@ -924,6 +1039,14 @@ func (worker *copIteratorWorker) handleCopResponse(bo *Backoffer, rpcCtx *tikv.R
 	}
 	if rpcCtx != nil {
 		resp.detail.CalleeAddress = rpcCtx.Addr
+	} else {
+		resp.detail.CalleeAddress = task.storeAddr
+	}
+	resp.detail.RegionID = task.region.GetID()
+	resp.detail.ServedReplica = worker.servedReplica(rpcCtx, task)
+	if worker.retryBudget != nil {
+		resp.detail.RetryBudget = worker.retryBudget.total()
+		resp.detail.RetryBudgetRemaining = worker.retryBudget.remaining()
 	}
 	resp.respTime = costTime
 	sd := &util.ScanDetail{}
@@ -959,6 +1082,8 @@ func (worker *copIteratorWorker) handleCopResponse(bo *Backoffer, rpcCtx *tikv.R
 		resp.pbResp.Data = data
 		resp.detail.CoprCacheHit = true
 	} else {
+		// The traffic is only recorded when the data is read from the store rather than the cache.
+		tabletraffic.RecordRead(tablecodec.DecodeTableID(resp.startKey), sd.ProcessedKeys, int64(len(resp.pbResp.Data)))
 		// Cache not hit or cache hit but not valid: update the cache if the response can be cached.
 		if cacheKey != nil && resp.pbResp.CanBeCached && resp.pbResp.CacheLastVersion > 0 {
 			if worker.store.coprCache.CheckResponseAdmission(resp.pbResp.Data.Size(), resp.detail.TimeDetail.ProcessTime) {
@@ -985,6 +1110,44 @@ type CopRuntimeStats struct {
 	tikv.RegionRequestRuntimeStats
 
 	CoprCacheHit bool
+	// RetryBudget is the total backoff time shared by all tasks of the request, 0 means no budget.
+	RetryBudget time.Duration
+	// RetryBudgetRemaining is the backoff time left in the budget when the response is received.
+	RetryBudgetRemaining time.Duration
+	// ServedReplica is the kind of the replica which served the task, such as "leader", "follower" or "learner".
+	ServedReplica string
+	// RegionID is the region of the task, it's 0 if the task is not bound to a single region.
+	RegionID uint64
+}
+
+const (
+	servedByLeader   = "leader"
+	servedByFollower = "follower"
+	servedByLearner  = "learner"
+)
+
+// servedReplica returns the kind of the replica which the task is sent to.
+func (worker *copIteratorWorker) servedReplica(rpcCtx *tikv.RPCContext, task *copTask) string {
+	switch task.storeType {
+	case kv.TiFlash:
+		return servedByLearner
+	case kv.TiDB:
+		return ""
+	}
+	if rpcCtx == nil || rpcCtx.Peer == nil {
+		return ""
+	}
+	if rpcCtx.Peer.GetRole() == metapb.PeerRole_Learner {
+		return servedByLearner
+	}
+	if !worker.req.ReplicaRead.IsFollowerRead() {
+		return servedByLeader
+	}
+	region := worker.store.GetRegionCache().GetCachedRegionWithRLock(rpcCtx.Region)
+	if region != nil && region.GetLeaderPeerID() != rpcCtx.Peer.GetId() {
+		return servedByFollower
+	}
+	return servedByLeader
 }
 
 func (worker *copIteratorWorker) handleTiDBSendReqErr(err error, task *copTask, ch chan<- *copResponse) error {
@@ -1028,6 +1191,24 @@ func (worker *copIteratorWorker) buildCopTasksFromRemain(bo *Backoffer, lastRang
 	return buildCopTasks(bo, worker.store.GetRegionCache(), remainedRanges, worker.req)
 }
 
+// buildNextPageTasks builds the tasks fetching the rest of the ranges after a page ends at pageRange,
+// the paging size of the new tasks is doubled until it reaches maxPagingSize.
+func (worker *copIteratorWorker) buildNextPageTasks(bo *Backoffer, pageRange *coprocessor.KeyRange, task *copTask) ([]*copTask, error) {
+	tasks, err := worker.buildCopTasksFromRemain(bo, pageRange, task)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	pagingSize := nextPagingSize(task.pagingSize)
+	for _, t := range tasks {
+		t.pagingSize = pagingSize
+	}
+	return tasks, nil
+}
+
+func nextPagingSize(pagingSize int) int {
+	return mathutil.Min(pagingSize*2, maxPagingSize)
+}
+
 // calculateRemain splits the input ranges into two, and take one of them according to desc flag.
 // It's used in streaming API, to calculate which range is consumed and what needs to be retry.
 // For example:
@@ -1172,6 +1353,12 @@ func (e *rateLimitAction) destroyTokenIfNeeded(returnToken func()) {
 	e.cond.once = sync.Once{}
 }
 
+func (e *rateLimitAction) getRemainingTokenNum() uint {
+	e.conditionLock()
+	defer e.conditionUnlock()
+	return e.cond.remainingTokenNum
+}
+
 func (e *rateLimitAction) conditionLock() {
 	e.cond.Lock()
 }
@@ -1202,6 +1389,61 @@ func (e *rateLimitAction) isEnabled() bool {
 	return atomic.LoadUint32(&e.enabled) > 0
 }
 
+// backpressureController adapts the number of in-flight cop tasks to the drain rate of the consumer.
+// The token of a finished task is withheld when responses pile up in the response channel, and the
+// withheld tokens are returned one by one when the consumer finds the response channel empty.
+// It is only accessed by the goroutine calling copIterator.Next, so it needs no lock.
+type backpressureController struct {
+	// capacity is the token number of the sendRate.
+	capacity int
+	// highWatermark is the count of buffered responses above which the consumer is regarded as slow.
+	highWatermark int
+	// withheld is the count of tokens held back from the sender.
+	withheld int
+	// rateLimit is used to make sure the tokens destroyed by the OOM action and the tokens
+	// withheld here never add up to all of the tokens.
+	rateLimit *rateLimitAction
+}
+
+func newBackpressureController(capacity int, rateLimit *rateLimitAction) *backpressureController {
+	highWatermark := capacity / 2
+	if highWatermark < 1 {
+		highWatermark = 1
+	}
+	return &backpressureController{
+		capacity:      capacity,
+		highWatermark: highWatermark,
+		rateLimit:     rateLimit,
+	}
+}
+
+// onTaskFinished is called when a task is finished by the consumer, buffered is the count of
+// responses which are already fetched but not consumed yet.
+func (c *backpressureController) onTaskFinished(buffered int, returnToken func()) {
+	if buffered >= c.highWatermark && c.withheld+1 < c.availableTokenNum() {
+		c.withheld++
+		return
+	}
+	returnToken()
+}
+
+// onConsumerStarved is called when the consumer is going to wait for a response.
+func (c *backpressureController) onConsumerStarved(returnToken func()) {
+	if c.withheld == 0 {
+		return
+	}
+	c.withheld--
+	returnToken()
+}
+
+// availableTokenNum returns the count of tokens which are not destroyed by the OOM action.
+func (c *backpressureController) availableTokenNum() int {
+	if c.rateLimit == nil {
+		return c.capacity
+	}
+	return int(c.rateLimit.getRemainingTokenNum())
+}
+
 // priorityToPB converts priority type to wire type.
 func priorityToPB(pri int) kvrpcpb.CommandPri {
 	switch pri {
@@ -29,9 +29,10 @@ var _ = Suite(&testCoprocessorCacheSuite{})
 
 func (s *testCoprocessorSuite) TestBuildCacheKey(c *C) {
 	req := coprocessor.Request{
-		Tp:      0xAB,
-		StartTs: 0xAABBCC,
-		Data:    []uint8{0x18, 0x0, 0x20, 0x0, 0x40, 0x0, 0x5a, 0x0},
+		Tp:        0xAB,
+		StartTs:   0xAABBCC,
+		SchemaVer: 0x0102,
+		Data:      []uint8{0x18, 0x0, 0x20, 0x0, 0x40, 0x0, 0x5a, 0x0},
 		Ranges: []*coprocessor.KeyRange{
 			{
 				Start: kv.Key{0x01},
@@ -60,6 +61,13 @@ func (s *testCoprocessorSuite) TestBuildCacheKey(c *C) {
 	expectKey += "\x01\x01\x03"                     // EndKey
 	c.Assert(key, DeepEquals, []byte(expectKey))
 
+	// The schema version changes with the DDL on any table, so it's not a part of the key. The ranges and the DAG
+	// request already change with the table and its columns.
+	req.SchemaVer++
+	key2, err := coprCacheBuildKey(&req)
+	c.Assert(err, IsNil)
+	c.Assert(key2, DeepEquals, key)
+
 	req = coprocessor.Request{
 		Tp:      0xABCC, // Tp too big
 		StartTs: 0xAABBCC,
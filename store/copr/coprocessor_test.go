@@ -15,9 +15,12 @@ package copr
 
 import (
 	"context"
+	"sync"
 	"testing"
+	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/errors"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/store/driver/backoff"
 	"github.com/tikv/client-go/v2/mockstore/mocktikv"
@@ -109,12 +112,14 @@ func (s *testCoprocessorSuite) TestBuildTasks(c *C) {
 	tasks, err = buildCopTasks(bo, cache, buildCopRanges("a", "b", "b", "c"), req)
 	c.Assert(err, IsNil)
 	c.Assert(tasks, HasLen, 1)
-	s.taskEqual(c, tasks[0], regionIDs[0], "a", "b", "b", "c")
+	c.Assert(tasks[0].ranges.Len(), Equals, 1)
+	s.taskEqual(c, tasks[0], regionIDs[0], "a", "c")
 
 	tasks, err = buildCopTasks(bo, cache, buildCopRanges("a", "b", "b", "c"), flashReq)
 	c.Assert(err, IsNil)
 	c.Assert(tasks, HasLen, 1)
-	s.taskEqual(c, tasks[0], regionIDs[0], "a", "b", "b", "c")
+	c.Assert(tasks[0].ranges.Len(), Equals, 1)
+	s.taskEqual(c, tasks[0], regionIDs[0], "a", "c")
 
 	tasks, err = buildCopTasks(bo, cache, buildCopRanges("a", "b", "e", "f"), req)
 	c.Assert(err, IsNil)
@@ -204,6 +209,43 @@ func (s *testCoprocessorSuite) TestSplitRegionRanges(c *C) {
 	s.rangeEqual(c, ranges, "a", "g", "g", "n", "n", "t", "t", "z")
 }
 
+func (s *testCoprocessorSuite) TestCoalesceRanges(c *C) {
+	// nil --- 'm' --- nil
+	// <-  0  -> <- 1 ->
+	cluster := mocktikv.NewCluster(mocktikv.MustNewMVCCStore())
+	_, regionIDs, _ := mocktikv.BootstrapWithMultiRegions(cluster, []byte("m"))
+	pdCli := &tikv.CodecPDClient{Client: mocktikv.NewPDClient(cluster)}
+	cache := NewRegionCache(tikv.NewRegionCache(pdCli))
+	defer cache.Close()
+	bo := backoff.NewBackofferWithVars(context.Background(), 3000, nil)
+
+	// The ranges crossing the regions after coalesced are split again.
+	ranges := buildCopRanges("a", "b", "c", "d", "d", "e", "e", "f", "h", "i", "l", "m", "m", "n", "n", "o")
+	req := &kv.Request{}
+	tasks, err := buildCopTasks(bo, cache, ranges, req)
+	c.Assert(err, IsNil)
+	c.Assert(tasks, HasLen, 2)
+	s.taskEqual(c, tasks[0], regionIDs[0], "a", "b", "c", "f", "h", "i", "l", "m")
+	s.taskEqual(c, tasks[1], regionIDs[1], "m", "o")
+
+	req.MaxRangesPerTask = 3
+	tasks, err = buildCopTasks(bo, cache, ranges, req)
+	c.Assert(err, IsNil)
+	c.Assert(tasks, HasLen, 3)
+	s.taskEqual(c, tasks[0], regionIDs[0], "a", "b", "c", "f", "h", "i")
+	s.taskEqual(c, tasks[1], regionIDs[0], "l", "m")
+	s.taskEqual(c, tasks[2], regionIDs[1], "m", "o")
+
+	// The ranges are kept if the results are mapped to them by position.
+	req = &kv.Request{KeepRanges: true}
+	tasks, err = buildCopTasks(bo, cache, ranges, req)
+	c.Assert(err, IsNil)
+	c.Assert(tasks, HasLen, 2)
+	c.Assert(tasks[0].ranges.Len(), Equals, 6)
+	s.taskEqual(c, tasks[0], regionIDs[0], "a", "b", "c", "d", "d", "e", "e", "f", "h", "i", "l", "m")
+	s.taskEqual(c, tasks[1], regionIDs[1], "m", "n", "n", "o")
+}
+
 func (s *testCoprocessorSuite) TestRebuild(c *C) {
 	// nil --- 'm' --- nil
 	// <-  0  -> <- 1 ->
@@ -268,3 +310,189 @@ func (s *testCoprocessorSuite) rangeEqual(c *C, ranges []kv.KeyRange, keys ...st
 		c.Assert(string(r.EndKey), Equals, keys[2*i+1])
 	}
 }
+
+func (s *testCoprocessorSuite) TestBackpressureController(c *C) {
+	returned := 0
+	returnToken := func() { returned++ }
+
+	ctl := newBackpressureController(4, newRateLimitAction(4))
+	// The consumer keeps up with the workers, tokens are returned.
+	ctl.onTaskFinished(0, returnToken)
+	ctl.onTaskFinished(1, returnToken)
+	c.Assert(returned, Equals, 2)
+	c.Assert(ctl.withheld, Equals, 0)
+
+	// The responses pile up, tokens are withheld but at least one token is kept in flight.
+	for i := 0; i < 4; i++ {
+		ctl.onTaskFinished(4, returnToken)
+	}
+	c.Assert(returned, Equals, 3)
+	c.Assert(ctl.withheld, Equals, 3)
+
+	// The consumer is starved, withheld tokens are returned one by one.
+	ctl.onConsumerStarved(returnToken)
+	c.Assert(returned, Equals, 4)
+	c.Assert(ctl.withheld, Equals, 2)
+	ctl.onConsumerStarved(returnToken)
+	ctl.onConsumerStarved(returnToken)
+	ctl.onConsumerStarved(returnToken)
+	c.Assert(returned, Equals, 6)
+	c.Assert(ctl.withheld, Equals, 0)
+
+	// The tokens destroyed by the OOM action are taken into account.
+	action := newRateLimitAction(4)
+	action.cond.remainingTokenNum = 2
+	ctl = newBackpressureController(4, action)
+	returned = 0
+	ctl.onTaskFinished(4, returnToken)
+	ctl.onTaskFinished(4, returnToken)
+	c.Assert(ctl.withheld, Equals, 1)
+	c.Assert(returned, Equals, 1)
+}
+
+func (s *testCoprocessorSuite) TestRetryBudget(c *C) {
+	budget := newRetryBudget(3 * time.Second)
+	c.Assert(budget.maxSleep(copNextMaxBackoff), Equals, 3000)
+	// A backoff reserves at most a slice of the budget.
+	c.Assert(budget.Acquire(-1), Equals, retryBudgetSlice)
+	c.Assert(budget.Acquire(2000), Equals, retryBudgetSlice)
+	c.Assert(budget.Acquire(100), Equals, 100)
+	budget.Release(100)
+	c.Assert(budget.maxSleep(1000), Equals, 1000)
+	c.Assert(budget.maxSleep(copNextMaxBackoff), Equals, 2000)
+	c.Assert(budget.remaining(), Equals, 2*time.Second)
+	c.Assert(budget.exhausted(), IsFalse)
+
+	drained := 0
+	for i := 0; i < 4; i++ {
+		drained += budget.Acquire(-1)
+	}
+	c.Assert(drained, Equals, 2000)
+	c.Assert(budget.exhausted(), IsTrue)
+	c.Assert(budget.remaining(), Equals, time.Duration(0))
+	c.Assert(budget.Acquire(100), Equals, 0)
+	// A backoffer with zero max sleep never stops, so at least 1ms is returned.
+	c.Assert(budget.maxSleep(copNextMaxBackoff), Equals, 1)
+	c.Assert(budget.total(), Equals, 3*time.Second)
+
+	c.Assert(newRetryBudget(time.Microsecond).total(), Equals, time.Millisecond)
+}
+
+func (s *testCoprocessorSuite) TestRetryBudgetWithConcurrentWorkers(c *C) {
+	// The backoffers of the workers are allowed to sleep much longer than the budget, but they stop retrying
+	// once the budget shared by them is exhausted.
+	budget := newRetryBudget(300 * time.Millisecond)
+	var wg sync.WaitGroup
+	slept := make([]int, 8)
+	errs := make([]error, len(slept))
+	for i := range slept {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			bo := backoff.NewBackofferWithBudget(context.Background(), 100000, nil, budget)
+			for errs[i] == nil {
+				errs[i] = bo.Backoff(tikv.BoRegionMiss(), errors.New("region miss"))
+			}
+			slept[i] = bo.GetTotalSleep()
+		}(i)
+	}
+	wg.Wait()
+	total := 0
+	for i := range slept {
+		c.Assert(errs[i], ErrorMatches, ".*region miss.*")
+		total += slept[i]
+	}
+	c.Assert(total, Equals, 300)
+	c.Assert(budget.exhausted(), IsTrue)
+}
+
+func (s *testCoprocessorSuite) TestChargeRetryBudget(c *C) {
+	// The backoff of the TiKV client sleeps longer than a slice of the budget, it's charged in full afterwards.
+	budget := newRetryBudget(3 * time.Second)
+	vars := &kv.Variables{BackoffLockFast: 1200, BackOffWeight: 2}
+	bo := backoff.NewBackofferWithBudget(context.Background(), 100000, vars, budget)
+	c.Assert(bo.TiKVBackoffer().BackoffWithMaxSleepTxnLockFast(700, errors.New("lock")), IsNil)
+	slept := bo.GetTotalSleep()
+	c.Assert(slept > retryBudgetSlice, IsTrue, Commentf("slept %d", slept))
+	bo.ChargeBudget()
+	c.Assert(budget.remaining(), Equals, time.Duration(3000-slept)*time.Millisecond)
+	// The sleep time is charged only once.
+	bo.ChargeBudget()
+	c.Assert(budget.remaining(), Equals, time.Duration(3000-slept)*time.Millisecond)
+}
+
+func (s *testCoprocessorSuite) TestBuildPagingTasks(c *C) {
+	// nil --- 'g' --- 'n' --- 't' --- nil
+	// <-  0  -> <- 1 -> <- 2 -> <- 3 ->
+	cluster := mocktikv.NewCluster(mocktikv.MustNewMVCCStore())
+	_, regionIDs, _ := mocktikv.BootstrapWithMultiRegions(cluster, []byte("g"), []byte("n"), []byte("t"))
+	pdCli := &tikv.CodecPDClient{Client: mocktikv.NewPDClient(cluster)}
+	cache := NewRegionCache(tikv.NewRegionCache(pdCli))
+	defer cache.Close()
+
+	bo := backoff.NewBackofferWithVars(context.Background(), 3000, nil)
+
+	req := &kv.Request{Paging: true}
+	tasks, err := buildCopTasks(bo, cache, buildCopRanges("a", "z"), req)
+	c.Assert(err, IsNil)
+	c.Assert(tasks, HasLen, 4)
+	// Only the streaming tasks are paged.
+	for _, task := range tasks {
+		c.Assert(task.pagingSize, Equals, 0)
+	}
+
+	req.Streaming = true
+	tasks, err = buildCopTasks(bo, cache, buildCopRanges("a", "z"), req)
+	c.Assert(err, IsNil)
+	c.Assert(tasks, HasLen, 4)
+	for _, task := range tasks {
+		c.Assert(task.pagingSize, Equals, minPagingSize)
+	}
+
+	s.taskEqual(c, tasks[1], regionIDs[1], "g", "n")
+	pagingSize := tasks[1].pagingSize
+	for _, expected := range []int{2, 4, 8, 16, 32, 64, 64} {
+		pagingSize = nextPagingSize(pagingSize)
+		c.Assert(pagingSize, Equals, expected)
+	}
+}
+
+func (s *testCoprocessorSuite) TestSplitBatchCopTasksByTable(c *C) {
+	owners := []rangeOwner{
+		{startKey: kv.Key("a"), tableID: 1},
+		{startKey: kv.Key("m"), tableID: 2},
+	}
+	tasks := []*batchCopTask{
+		{
+			storeAddr: "store1",
+			regionInfos: []RegionInfo{
+				{Ranges: buildCopRanges("a", "c", "e", "g")},
+				{Ranges: buildCopRanges("g", "h", "m", "p")},
+			},
+		},
+		{
+			storeAddr: "store2",
+			regionInfos: []RegionInfo{
+				{Ranges: buildCopRanges("p", "z")},
+			},
+		},
+	}
+	tasks = splitBatchCopTasksByTable(tasks, owners)
+	c.Assert(tasks, HasLen, 3)
+
+	c.Assert(tasks[0].GetAddress(), Equals, "store1")
+	c.Assert(tasks[0].GetPhysicalTableID(), Equals, int64(1))
+	c.Assert(tasks[0].regionInfos, HasLen, 2)
+	s.rangeEqual(c, tasks[0].regionInfos[0].Ranges.mid, "a", "c", "e", "g")
+	s.rangeEqual(c, tasks[0].regionInfos[1].Ranges.mid, "g", "h")
+
+	c.Assert(tasks[1].GetAddress(), Equals, "store1")
+	c.Assert(tasks[1].GetPhysicalTableID(), Equals, int64(2))
+	c.Assert(tasks[1].regionInfos, HasLen, 1)
+	s.rangeEqual(c, tasks[1].regionInfos[0].Ranges.mid, "m", "p")
+
+	c.Assert(tasks[2].GetAddress(), Equals, "store2")
+	c.Assert(tasks[2].GetPhysicalTableID(), Equals, int64(2))
+	c.Assert(tasks[2].regionInfos, HasLen, 1)
+	s.rangeEqual(c, tasks[2].regionInfos[0].Ranges.mid, "p", "z")
+}
@@ -70,6 +70,35 @@ func (r *KeyRanges) At(i int) kv.KeyRange {
 	return *r.last
 }
 
+// coalesce merges the adjacent ranges, where the end key of a range is the start key of the next one, like the point
+// ranges of the consecutive integer handles. So a huge IN list is sent in fewer ranges and cop tasks. It returns r
+// itself if no range can be merged.
+func (r *KeyRanges) coalesce() *KeyRanges {
+	n := r.Len()
+	i := 1
+	for ; i < n; i++ {
+		if adjacentKeyRanges(r.At(i-1), r.At(i)) {
+			break
+		}
+	}
+	if i >= n {
+		return r
+	}
+	ranges := make([]kv.KeyRange, 0, n-1)
+	r.Do(func(ran *kv.KeyRange) {
+		if last := len(ranges) - 1; last >= 0 && adjacentKeyRanges(ranges[last], *ran) {
+			ranges[last].EndKey = ran.EndKey
+			return
+		}
+		ranges = append(ranges, *ran)
+	})
+	return NewKeyRanges(ranges)
+}
+
+func adjacentKeyRanges(prev, next kv.KeyRange) bool {
+	return len(prev.EndKey) > 0 && bytes.Equal(prev.EndKey, next.StartKey)
+}
+
 // Slice returns the sub ranges [from, to).
 func (r *KeyRanges) Slice(from, to int) *KeyRanges {
 	var ran KeyRanges
@@ -96,6 +96,22 @@ func (s *testKeyRangesSuite) TestCopRangeSplit(c *C) {
 	)
 }
 
+func (s *testKeyRangesSuite) TestCoalesce(c *C) {
+	first := &kv.KeyRange{StartKey: []byte("a"), EndKey: []byte("c")}
+	mid := buildKeyRanges("c", "d", "e", "g", "g", "h")
+	last := &kv.KeyRange{StartKey: []byte("h"), EndKey: []byte("")}
+
+	ranges := &KeyRanges{mid: mid}
+	s.checkEqual(c, ranges.coalesce(), buildKeyRanges("c", "d", "e", "h"), true)
+	ranges = &KeyRanges{first: first, mid: mid, last: last}
+	s.checkEqual(c, ranges.coalesce(), buildKeyRanges("a", "d", "e", ""), true)
+	// The original ranges are not changed.
+	s.checkEqual(c, ranges, append(append(buildKeyRanges("a", "c"), mid...), *last), false)
+
+	ranges = buildCopRanges("a", "b", "c", "d")
+	c.Assert(ranges.coalesce(), Equals, ranges)
+}
+
 func (s *testKeyRangesSuite) checkEqual(c *C, copRanges *KeyRanges, ranges []kv.KeyRange, slice bool) {
 	c.Assert(copRanges.Len(), Equals, len(ranges))
 	for i := range ranges {
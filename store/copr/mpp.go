@@ -14,8 +14,10 @@
 package copr
 
 import (
+	"bytes"
 	"context"
 	"io"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -29,6 +31,7 @@ import (
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/store/driver/backoff"
 	derr "github.com/pingcap/tidb/store/driver/error"
+	"github.com/pingcap/tidb/util/topsql/tracecpu"
 	"github.com/tikv/client-go/v2/logutil"
 	"github.com/tikv/client-go/v2/tikv"
 	"github.com/tikv/client-go/v2/tikvrpc"
@@ -45,6 +48,16 @@ func (c *batchCopTask) GetAddress() string {
 	return c.storeAddr
 }
 
+// GetPhysicalTableID returns the id of the physical table read by the task.
+func (c *batchCopTask) GetPhysicalTableID() int64 {
+	return c.tableID
+}
+
+// GetScanID returns the table scan which the ranges of the task belong to.
+func (c *batchCopTask) GetScanID() int {
+	return c.scanID
+}
+
 func (c *MPPClient) selectAllTiFlashStore() []kv.MPPTaskMeta {
 	resultTasks := make([]kv.MPPTaskMeta, 0)
 	for _, addr := range c.store.GetRegionCache().GetTiFlashStoreAddrs() {
@@ -58,6 +71,14 @@ func (c *MPPClient) selectAllTiFlashStore() []kv.MPPTaskMeta {
 func (c *MPPClient) ConstructMPPTasks(ctx context.Context, req *kv.MPPBuildTasksRequest) ([]kv.MPPTaskMeta, error) {
 	ctx = context.WithValue(ctx, tikv.TxnStartKey(), req.StartTS)
 	bo := backoff.NewBackofferWithVars(ctx, copBuildTaskMaxBackoff, nil)
+	failpoint.Inject("mppBuildTasksRequestError", func(val failpoint.Value) {
+		if val.(bool) {
+			failpoint.Return(nil, errors.New("mock mpp build tasks request error"))
+		}
+	})
+	if len(req.PartitionIDAndRanges) > 0 {
+		return c.constructMPPTasksForTables(bo, req.PartitionIDAndRanges)
+	}
 	if req.KeyRanges == nil {
 		return c.selectAllTiFlashStore(), nil
 	}
@@ -73,6 +94,125 @@ func (c *MPPClient) ConstructMPPTasks(ctx context.Context, req *kv.MPPBuildTasks
 	return mppTasks, nil
 }
 
+// constructMPPTasksForTables builds the tasks of the physical tables read by
+// several table scans. The tasks of every scan are built separately, and the
+// tasks on the same store are put together.
+func (c *MPPClient) constructMPPTasksForTables(bo *Backoffer, partitions []kv.PartitionIDAndRanges) ([]kv.MPPTaskMeta, error) {
+	var scanIDs []int
+	scans := make(map[int][]kv.PartitionIDAndRanges)
+	for _, p := range partitions {
+		if _, ok := scans[p.ScanID]; !ok {
+			scanIDs = append(scanIDs, p.ScanID)
+		}
+		scans[p.ScanID] = append(scans[p.ScanID], p)
+	}
+	var tasks []*batchCopTask
+	for _, scanID := range scanIDs {
+		scanTasks, err := c.constructMPPTasksForScan(bo, scans[scanID])
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, task := range scanTasks {
+			task.scanID = scanID
+		}
+		tasks = append(tasks, scanTasks...)
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].storeAddr < tasks[j].storeAddr
+	})
+	mppTasks := make([]kv.MPPTaskMeta, 0, len(tasks))
+	for _, task := range tasks {
+		mppTasks = append(mppTasks, task)
+	}
+	return mppTasks, nil
+}
+
+// constructMPPTasksForScan builds the tasks of the physical tables read by one
+// table scan. The regions of all the tables are located together, then the
+// tasks are split so that every task only reads one table.
+func (c *MPPClient) constructMPPTasksForScan(bo *Backoffer, partitions []kv.PartitionIDAndRanges) ([]*batchCopTask, error) {
+	var allRanges []kv.KeyRange
+	owners := make([]rangeOwner, 0, len(partitions))
+	for _, p := range partitions {
+		for _, r := range p.KeyRanges {
+			allRanges = append(allRanges, r)
+			owners = append(owners, rangeOwner{startKey: r.StartKey, tableID: p.ID})
+		}
+	}
+	sort.Slice(allRanges, func(i, j int) bool {
+		return bytes.Compare(allRanges[i].StartKey, allRanges[j].StartKey) < 0
+	})
+	sort.Slice(owners, func(i, j int) bool {
+		return bytes.Compare(owners[i].startKey, owners[j].startKey) < 0
+	})
+	tasks, err := buildBatchCopTasks(bo, c.store.GetRegionCache(), NewKeyRanges(allRanges), kv.TiFlash)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return splitBatchCopTasksByTable(tasks, owners), nil
+}
+
+// rangeOwner records the physical table which a key range belongs to.
+type rangeOwner struct {
+	startKey kv.Key
+	tableID  int64
+}
+
+// splitBatchCopTasksByTable splits every task into the tasks of the tables it
+// reads. The owners should be sorted by the start key.
+func splitBatchCopTasksByTable(tasks []*batchCopTask, owners []rangeOwner) []*batchCopTask {
+	ownerOf := func(key kv.Key) int64 {
+		// The ranges are split by regions, so a range is always in one of the
+		// original ranges, which is the last one starting before it.
+		i := sort.Search(len(owners), func(i int) bool {
+			return bytes.Compare(owners[i].startKey, key) > 0
+		})
+		if i == 0 {
+			return owners[0].tableID
+		}
+		return owners[i-1].tableID
+	}
+	result := make([]*batchCopTask, 0, len(tasks))
+	for _, task := range tasks {
+		var tableIDs []int64
+		tableTasks := make(map[int64]*batchCopTask)
+		for _, ri := range task.regionInfos {
+			var regionTableIDs []int64
+			tableRanges := make(map[int64][]kv.KeyRange)
+			ri.Ranges.Do(func(r *kv.KeyRange) {
+				tableID := ownerOf(r.StartKey)
+				if _, ok := tableRanges[tableID]; !ok {
+					regionTableIDs = append(regionTableIDs, tableID)
+				}
+				tableRanges[tableID] = append(tableRanges[tableID], *r)
+			})
+			for _, tableID := range regionTableIDs {
+				tableTask, ok := tableTasks[tableID]
+				if !ok {
+					tableTask = &batchCopTask{
+						storeAddr: task.storeAddr,
+						cmdType:   task.cmdType,
+						ctx:       task.ctx,
+						tableID:   tableID,
+					}
+					tableTasks[tableID] = tableTask
+					tableIDs = append(tableIDs, tableID)
+				}
+				tableTask.regionInfos = append(tableTask.regionInfos, RegionInfo{
+					Region:    ri.Region,
+					Meta:      ri.Meta,
+					Ranges:    NewKeyRanges(tableRanges[tableID]),
+					AllStores: ri.AllStores,
+				})
+			}
+		}
+		for _, tableID := range tableIDs {
+			result = append(result, tableTasks[tableID])
+		}
+	}
+	return result
+}
+
 // mppResponse wraps mpp data packet.
 type mppResponse struct {
 	pbResp   *mpp.MPPDataPacket
@@ -139,6 +279,7 @@ type mppIterator struct {
 }
 
 func (m *mppIterator) run(ctx context.Context) {
+	tracecpu.SetGoroutineLabels(ctx)
 	for _, task := range m.tasks {
 		if atomic.LoadUint32(&m.closed) == 1 {
 			break
@@ -174,7 +315,11 @@ func (m *mppIterator) sendToRespCh(resp *mppResponse) (exit bool) {
 // - dispatch all tasks at once, and connect tasks at second.
 // - dispatch tasks and establish connection at the same time.
 func (m *mppIterator) handleDispatchReq(ctx context.Context, bo *Backoffer, req *kv.MPPDispatchRequest) {
+	// The receiver of the task also runs in this goroutine.
+	tracecpu.SetGoroutineLabels(ctx)
+	atomic.AddInt64(&runningMPPTasks, 1)
 	defer func() {
+		atomic.AddInt64(&runningMPPTasks, -1)
 		m.wg.Done()
 	}()
 	var regionInfos []*coprocessor.RegionInfo
@@ -204,7 +349,7 @@ func (m *mppIterator) handleDispatchReq(ctx context.Context, bo *Backoffer, req
 		Regions:   regionInfos,
 	}
 
-	wrappedReq := tikvrpc.NewRequest(tikvrpc.CmdMPPTask, mppReq, kvrpcpb.Context{})
+	wrappedReq := tikvrpc.NewRequest(tikvrpc.CmdMPPTask, mppReq, kvrpcpb.Context{ResourceGroupTag: req.ResourceGroupTag})
 	wrappedReq.StoreTp = tikvrpc.TiFlash
 
 	// TODO: Handle dispatch task response correctly, including retry logic and cancel logic.
@@ -306,7 +451,7 @@ func (m *mppIterator) establishMPPConns(bo *Backoffer, req *kv.MPPDispatchReques
 		},
 	}
 
-	wrappedReq := tikvrpc.NewRequest(tikvrpc.CmdMPPConn, connReq, kvrpcpb.Context{})
+	wrappedReq := tikvrpc.NewRequest(tikvrpc.CmdMPPConn, connReq, kvrpcpb.Context{ResourceGroupTag: req.ResourceGroupTag})
 	wrappedReq.StoreTp = tikvrpc.TiFlash
 
 	// Drain result from root task.
@@ -0,0 +1,95 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package copr
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// retryBudget is the backoff time shared by all the cop tasks of a request. Every region has its own
+// backoffer, so without a shared budget the retries of many unavailable regions can add up to minutes
+// even if the statement is only allowed to run for a few seconds. Every backoff of the backoffers is
+// charged to the budget, and fails once the budget is exhausted.
+type retryBudget struct {
+	totalMs     int64
+	remainingMs int64
+}
+
+// retryBudgetSlice is the max time(in ms) reserved from the retry budget for a backoff.
+const retryBudgetSlice = 500
+
+func newRetryBudget(budget time.Duration) *retryBudget {
+	ms := budget.Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+	return &retryBudget{totalMs: ms, remainingMs: ms}
+}
+
+// maxSleep returns the max sleep time(in ms) of a new backoffer, it never exceeds defaultMaxSleep.
+func (b *retryBudget) maxSleep(defaultMaxSleep int) int {
+	remaining := atomic.LoadInt64(&b.remainingMs)
+	if remaining >= int64(defaultMaxSleep) {
+		return defaultMaxSleep
+	}
+	// A backoffer with zero max sleep never stops retrying, so at least 1ms is kept.
+	if remaining < 1 {
+		return 1
+	}
+	return int(remaining)
+}
+
+// Acquire implements the backoff.Budget interface. A backoff reserves at most retryBudgetSlice, so a sleeping
+// worker doesn't hold the whole budget and fail the backoffs of the other workers.
+func (b *retryBudget) Acquire(ms int) int {
+	if ms < 0 || ms > retryBudgetSlice {
+		ms = retryBudgetSlice
+	}
+	for {
+		remaining := atomic.LoadInt64(&b.remainingMs)
+		if remaining <= 0 {
+			return 0
+		}
+		if remaining < int64(ms) {
+			ms = int(remaining)
+		}
+		if atomic.CompareAndSwapInt64(&b.remainingMs, remaining, remaining-int64(ms)) {
+			return ms
+		}
+	}
+}
+
+// Release implements the backoff.Budget interface.
+func (b *retryBudget) Release(ms int) {
+	if ms > 0 {
+		atomic.AddInt64(&b.remainingMs, int64(ms))
+	}
+}
+
+func (b *retryBudget) exhausted() bool {
+	return atomic.LoadInt64(&b.remainingMs) <= 0
+}
+
+func (b *retryBudget) total() time.Duration {
+	return time.Duration(b.totalMs) * time.Millisecond
+}
+
+func (b *retryBudget) remaining() time.Duration {
+	remaining := atomic.LoadInt64(&b.remainingMs)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return time.Duration(remaining) * time.Millisecond
+}
@@ -16,6 +16,7 @@ package backoff
 import (
 	"context"
 
+	"github.com/pingcap/errors"
 	"github.com/pingcap/tidb/kv"
 	derr "github.com/pingcap/tidb/store/driver/error"
 	"github.com/tikv/client-go/v2/tikv"
@@ -24,6 +25,21 @@ import (
 // Backoffer wraps tikv.Backoffer and converts the error which returns by the functions of tikv.Backoffer to tidb error.
 type Backoffer struct {
 	b *tikv.Backoffer
+
+	// budget is shared with other backoffers, every backoff is charged to it if it's not nil.
+	budget Budget
+	// charged is the sleep time(in ms) of the backoffer which has been charged to the budget.
+	charged int
+}
+
+// Budget is the backoff time shared by several backoffers, so their total sleep time is limited even if they back
+// off concurrently.
+type Budget interface {
+	// Acquire reserves at most ms milliseconds for a backoff, ms < 0 means no limit. It returns the reserved
+	// milliseconds, 0 means the budget is exhausted.
+	Acquire(ms int) int
+	// Release gives the reserved milliseconds which are not slept back to the budget.
+	Release(ms int)
 }
 
 // NewBackofferWithVars creates a Backoffer with maximum sleep time(in ms) and kv.Variables.
@@ -38,6 +54,14 @@ func NewBackoffer(ctx context.Context, maxSleep int) *Backoffer {
 	return &Backoffer{b: b}
 }
 
+// NewBackofferWithBudget creates a Backoffer with maximum sleep time(in ms) and kv.Variables, every backoff of which
+// is charged to the budget and fails once the budget is exhausted.
+func NewBackofferWithBudget(ctx context.Context, maxSleep int, vars *kv.Variables, budget Budget) *Backoffer {
+	b := NewBackofferWithVars(ctx, maxSleep, vars)
+	b.budget = budget
+	return b
+}
+
 // TiKVBackoffer returns tikv.Backoffer.
 func (b *Backoffer) TiKVBackoffer() *tikv.Backoffer {
 	return b.b
@@ -46,6 +70,11 @@ func (b *Backoffer) TiKVBackoffer() *tikv.Backoffer {
 // Backoff sleeps a while base on the BackoffConfig and records the error message.
 // It returns a retryable error if total sleep time exceeds maxSleep.
 func (b *Backoffer) Backoff(cfg *tikv.BackoffConfig, err error) error {
+	if b.budget != nil {
+		return b.backoffWithBudget(-1, func(maxSleepMs int) error {
+			return b.b.BackoffWithCfgAndMaxSleep(cfg, maxSleepMs, err)
+		}, err)
+	}
 	e := b.b.Backoff(cfg, err)
 	return derr.ToTiDBErr(e)
 }
@@ -53,10 +82,48 @@ func (b *Backoffer) Backoff(cfg *tikv.BackoffConfig, err error) error {
 // BackoffWithMaxSleepTxnLockFast sleeps a while for the operation TxnLockFast and records the error message
 // and never sleep more than maxSleepMs for each sleep.
 func (b *Backoffer) BackoffWithMaxSleepTxnLockFast(maxSleepMs int, err error) error {
+	if b.budget != nil {
+		return b.backoffWithBudget(maxSleepMs, func(maxSleepMs int) error {
+			return b.b.BackoffWithMaxSleepTxnLockFast(maxSleepMs, err)
+		}, err)
+	}
 	e := b.b.BackoffWithMaxSleepTxnLockFast(maxSleepMs, err)
 	return derr.ToTiDBErr(e)
 }
 
+// backoffWithBudget reserves the sleep time from the budget before backing off, and gives the time not slept back
+// after it. It returns err if the budget is exhausted.
+func (b *Backoffer) backoffWithBudget(maxSleepMs int, backoff func(maxSleepMs int) error, err error) error {
+	b.ChargeBudget()
+	reserved := b.budget.Acquire(maxSleepMs)
+	if reserved <= 0 {
+		return errors.Trace(derr.ToTiDBErr(err))
+	}
+	sleptBefore := b.b.GetTotalSleep()
+	e := backoff(reserved)
+	slept := b.b.GetTotalSleep() - sleptBefore
+	b.charged += slept
+	b.budget.Release(reserved - slept)
+	return derr.ToTiDBErr(e)
+}
+
+// ChargeBudget charges the sleep time which isn't charged to the budget yet, which is slept by the backoffs of TiKV
+// client with the TiKVBackoffer. The budget may grant less than asked for each time, so it's charged until the whole
+// sleep time is charged or the budget is exhausted, and only the granted time is counted as charged.
+func (b *Backoffer) ChargeBudget() {
+	if b.budget == nil {
+		return
+	}
+	for uncharged := b.b.GetTotalSleep() - b.charged; uncharged > 0; {
+		granted := b.budget.Acquire(uncharged)
+		if granted <= 0 {
+			return
+		}
+		b.charged += granted
+		uncharged -= granted
+	}
+}
+
 // GetBackoffTimes returns a map contains backoff time count by type.
 func (b *Backoffer) GetBackoffTimes() map[string]int {
 	return b.b.GetBackoffTimes()
@@ -0,0 +1,100 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptor
+
+import (
+	"context"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/errorpb"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/tablecodec"
+)
+
+// ChaosConfig is the config of the chaos injected into the RPCs sent by the kv client.
+type ChaosConfig struct {
+	// TableIDs are the physical tables whose requests are affected, the requests of all tables are affected if
+	// it's empty.
+	TableIDs []int64 `json:"table_ids"`
+	// Percent is the percentage of the requests which are affected.
+	Percent int `json:"percent"`
+	// Latency is injected before the affected requests are sent.
+	Latency time.Duration `json:"latency"`
+	// RegionError makes the affected requests fail with the ServerIsBusy region error.
+	RegionError bool `json:"region_error"`
+}
+
+var chaosConfig atomic.Value
+
+// SetChaos enables the chaos with the config, the chaos is disabled if cfg is nil.
+func SetChaos(cfg *ChaosConfig) {
+	chaosConfig.Store(cfg)
+}
+
+// GetChaos returns the config of the enabled chaos, it's nil if the chaos is disabled.
+func GetChaos() *ChaosConfig {
+	cfg, _ := chaosConfig.Load().(*ChaosConfig)
+	return cfg
+}
+
+// Chaos is the interceptor which injects the chaos set by SetChaos, it's installed into the kv clients of
+// the stores, so the chaos can be enabled at runtime to test how the failures are handled.
+var Chaos kv.RPCInterceptor = chaosInterceptor{}
+
+type chaosInterceptor struct{}
+
+// BeforeRPC implements the kv.RPCInterceptor interface.
+func (chaosInterceptor) BeforeRPC(ctx context.Context, info *kv.RPCInfo) error {
+	cfg := GetChaos()
+	if cfg == nil || !cfg.affects(info) {
+		return nil
+	}
+	if cfg.Latency > 0 {
+		select {
+		case <-time.After(cfg.Latency):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if cfg.RegionError {
+		return &kv.RegionError{Err: &errorpb.Error{
+			Message:      "injected by chaos",
+			ServerIsBusy: &errorpb.ServerIsBusy{Reason: "injected by chaos"},
+		}}
+	}
+	return nil
+}
+
+// AfterRPC implements the kv.RPCInterceptor interface.
+func (chaosInterceptor) AfterRPC(ctx context.Context, info *kv.RPCInfo, duration time.Duration, err error) {
+}
+
+func (cfg *ChaosConfig) affects(info *kv.RPCInfo) bool {
+	if len(cfg.TableIDs) > 0 {
+		tableID := tablecodec.DecodeTableID(info.Key)
+		found := false
+		for _, id := range cfg.TableIDs {
+			if id == tableID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return cfg.Percent >= 100 || rand.Intn(100) < cfg.Percent
+}
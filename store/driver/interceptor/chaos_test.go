@@ -0,0 +1,94 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/kvrpcpb"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/tikv/client-go/v2/tikv"
+	"github.com/tikv/client-go/v2/tikvrpc"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = SerialSuites(&testChaosSuite{})
+
+type testChaosSuite struct {
+}
+
+type mockClient struct {
+	tikv.Client
+	sent int
+}
+
+func (c *mockClient) SendRequest(ctx context.Context, addr string, req *tikvrpc.Request, timeout time.Duration) (*tikvrpc.Response, error) {
+	c.sent++
+	return &tikvrpc.Response{Resp: &kvrpcpb.GetResponse{}}, nil
+}
+
+func (s *testChaosSuite) TestChaos(c *C) {
+	defer SetChaos(nil)
+	mock := &mockClient{}
+	client := NewClient(mock, kv.RPCInterceptorChain{Chaos})
+	get := func(ctx context.Context, tableID int64) *tikvrpc.Response {
+		key := tablecodec.EncodeRowKeyWithHandle(tableID, kv.IntHandle(1))
+		resp, err := client.SendRequest(ctx, "store1", tikvrpc.NewRequest(tikvrpc.CmdGet, &kvrpcpb.GetRequest{Key: key}), time.Second)
+		c.Assert(err, IsNil)
+		return resp
+	}
+
+	// The chaos is disabled by default.
+	resp := get(context.Background(), 1)
+	regionErr, err := resp.GetRegionError()
+	c.Assert(err, IsNil)
+	c.Assert(regionErr, IsNil)
+	c.Assert(mock.sent, Equals, 1)
+
+	SetChaos(&ChaosConfig{TableIDs: []int64{1}, Percent: 100, RegionError: true})
+	resp = get(context.Background(), 1)
+	regionErr, err = resp.GetRegionError()
+	c.Assert(err, IsNil)
+	c.Assert(regionErr.GetServerIsBusy(), NotNil)
+	c.Assert(mock.sent, Equals, 1)
+	// The requests of the other tables are not affected.
+	resp = get(context.Background(), 2)
+	regionErr, err = resp.GetRegionError()
+	c.Assert(err, IsNil)
+	c.Assert(regionErr, IsNil)
+	c.Assert(mock.sent, Equals, 2)
+
+	SetChaos(&ChaosConfig{Percent: 0, RegionError: true})
+	resp = get(context.Background(), 1)
+	regionErr, err = resp.GetRegionError()
+	c.Assert(err, IsNil)
+	c.Assert(regionErr, IsNil)
+	c.Assert(mock.sent, Equals, 3)
+
+	// The injected latency is interrupted by the context.
+	SetChaos(&ChaosConfig{Percent: 100, Latency: time.Hour})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	key := tablecodec.EncodeRowKeyWithHandle(1, kv.IntHandle(1))
+	_, err = client.SendRequest(ctx, "store1", tikvrpc.NewRequest(tikvrpc.CmdGet, &kvrpcpb.GetRequest{Key: key}), time.Second)
+	c.Assert(err, Equals, context.DeadlineExceeded)
+	c.Assert(mock.sent, Equals, 3)
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptor
+
+import (
+	"context"
+	"time"
+
+	"github.com/pingcap/tidb/kv"
+	"github.com/tikv/client-go/v2/tikv"
+	"github.com/tikv/client-go/v2/tikvrpc"
+)
+
+// NewClient wraps the client to run the interceptors around each request sent by it.
+func NewClient(client tikv.Client, interceptors kv.RPCInterceptorChain) tikv.Client {
+	return interceptedClient{Client: client, interceptors: interceptors}
+}
+
+// interceptedClient runs the RPC interceptors around each request sent by the wrapped client.
+type interceptedClient struct {
+	tikv.Client
+	interceptors kv.RPCInterceptorChain
+}
+
+// SendRequest implements the tikv.Client interface.
+func (c interceptedClient) SendRequest(ctx context.Context, addr string, req *tikvrpc.Request, timeout time.Duration) (*tikvrpc.Response, error) {
+	info := &kv.RPCInfo{
+		Type:     req.Type.String(),
+		Addr:     addr,
+		RegionID: req.RegionId,
+		Key:      firstKey(req),
+	}
+	var resp *tikvrpc.Response
+	err := c.interceptors.Intercept(ctx, info, func() error {
+		var err error
+		resp, err = c.Client.SendRequest(ctx, addr, req, timeout)
+		return err
+	})
+	if regionErr, ok := err.(*kv.RegionError); ok {
+		return tikvrpc.GenRegionErrorResp(req, regionErr.Err)
+	}
+	return resp, err
+}
+
+// firstKey returns the first key accessed by the request, it only knows the common requests of the transactions
+// and the coprocessor.
+func firstKey(req *tikvrpc.Request) []byte {
+	switch req.Type {
+	case tikvrpc.CmdGet:
+		return req.Get().Key
+	case tikvrpc.CmdBatchGet:
+		if keys := req.BatchGet().Keys; len(keys) > 0 {
+			return keys[0]
+		}
+	case tikvrpc.CmdScan:
+		return req.Scan().StartKey
+	case tikvrpc.CmdCop:
+		if ranges := req.Cop().Ranges; len(ranges) > 0 {
+			return ranges[0].Start
+		}
+	case tikvrpc.CmdPrewrite:
+		if mutations := req.Prewrite().Mutations; len(mutations) > 0 {
+			return mutations[0].Key
+		}
+	case tikvrpc.CmdCommit:
+		if keys := req.Commit().Keys; len(keys) > 0 {
+			return keys[0]
+		}
+	case tikvrpc.CmdPessimisticLock:
+		if mutations := req.PessimisticLock().Mutations; len(mutations) > 0 {
+			return mutations[0].Key
+		}
+	}
+	return nil
+}
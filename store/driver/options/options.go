@@ -27,6 +27,14 @@ func GetTiKVReplicaReadType(t kv.ReplicaReadType) storekv.ReplicaReadType {
 		return storekv.ReplicaReadFollower
 	case kv.ReplicaReadMixed:
 		return storekv.ReplicaReadMixed
+	case kv.ReplicaReadLearner:
+		// The learners are the TiFlash replicas, which are chosen by the
+		// planner. The requests sent to TiKV read the leader.
+		return storekv.ReplicaReadLeader
+	case kv.ReplicaReadClosest:
+		// The replica is picked among all the peers, and restricted by the
+		// zone label of the store if any.
+		return storekv.ReplicaReadMixed
 	}
 	return 0
 }
@@ -29,6 +29,7 @@ import (
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/store/copr"
 	derr "github.com/pingcap/tidb/store/driver/error"
+	"github.com/pingcap/tidb/store/driver/interceptor"
 	txn_driver "github.com/pingcap/tidb/store/driver/txn"
 	"github.com/pingcap/tidb/store/gcworker"
 	"github.com/pingcap/tidb/util/logutil"
@@ -85,12 +86,20 @@ func WithPDClientConfig(client config.PDClient) Option {
 	}
 }
 
+// WithRPCInterceptors sets the interceptors called around each RPC sent to the stores.
+func WithRPCInterceptors(interceptors ...kv.RPCInterceptor) Option {
+	return func(c *TiKVDriver) {
+		c.rpcInterceptors = interceptors
+	}
+}
+
 // TiKVDriver implements engine TiKV.
 type TiKVDriver struct {
 	pdConfig        config.PDClient
 	security        config.Security
 	tikvConfig      config.TiKVClient
 	txnLocalLatches config.TxnLocalLatches
+	rpcInterceptors kv.RPCInterceptorChain
 }
 
 // Open opens or creates an TiKV storage with given path using global config.
@@ -157,7 +166,12 @@ func (d TiKVDriver) OpenWithOptions(path string, options ...Option) (kv.Storage,
 	}
 
 	pdClient := tikv.CodecPDClient{Client: pdCli}
-	s, err := tikv.NewKVStore(uuid, &pdClient, spkv, tikv.NewRPCClient(d.security))
+	// The chaos is the last interceptor, so the other interceptors see the injected failures like the real ones.
+	interceptors := make(kv.RPCInterceptorChain, 0, len(d.rpcInterceptors)+1)
+	interceptors = append(interceptors, d.rpcInterceptors...)
+	interceptors = append(interceptors, interceptor.Chaos)
+	rpcClient := interceptor.NewClient(tikv.NewRPCClient(d.security), interceptors)
+	s, err := tikv.NewKVStore(uuid, &pdClient, spkv, rpcClient)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -306,7 +320,7 @@ func (s *tikvStore) Begin() (kv.Transaction, error) {
 	if err != nil {
 		return nil, derr.ToTiDBErr(err)
 	}
-	return txn_driver.NewTiKVTxn(txn), err
+	return txn_driver.NewTiKVTxn(txn, s.GetRegionCache()), err
 }
 
 // BeginWithOption begins a transaction with given option
@@ -315,7 +329,7 @@ func (s *tikvStore) BeginWithOption(option tikv.StartTSOption) (kv.Transaction,
 	if err != nil {
 		return nil, derr.ToTiDBErr(err)
 	}
-	return txn_driver.NewTiKVTxn(txn), err
+	return txn_driver.NewTiKVTxn(txn, s.GetRegionCache()), err
 }
 
 // GetSnapshot gets a snapshot that is able to read any data which data is <= ver.
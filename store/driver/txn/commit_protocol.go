@@ -0,0 +1,68 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txn
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/pingcap/tidb/config"
+	"github.com/pingcap/tidb/util/logutil"
+	"github.com/tikv/client-go/v2/tikv"
+	"go.uber.org/zap"
+)
+
+// locateRegionMaxBackoff is the max backoff time in milliseconds to locate the regions of the keys.
+const locateRegionMaxBackoff = 1000
+
+// spanTooManyRegions checks whether the keys of the transaction span more regions than the limit of async commit.
+// The secondary keys in too many regions make the async commit locks expensive to resolve, so such transactions
+// should fall back to 2PC.
+func (txn *tikvTxn) spanTooManyRegions(ctx context.Context) bool {
+	if !txn.asyncCommit || txn.asyncCommitRegionsLimit <= 0 || txn.regionCache == nil {
+		return false
+	}
+	// The async commit falls back by itself if there are too many keys, so only a few keys need to be checked here.
+	if uint(txn.Len()) > config.GetGlobalConfig().TiKVClient.AsyncCommit.KeysLimit {
+		return false
+	}
+	it, err := txn.KVTxn.GetMemBuffer().Iter(nil, nil)
+	if err != nil {
+		return false
+	}
+	defer it.Close()
+	bo := tikv.NewBackofferWithVars(ctx, locateRegionMaxBackoff, txn.KVTxn.GetVars())
+	regions := 0
+	var regionEnd []byte
+	for it.Valid() {
+		key := it.Key()
+		// Locate the region of the key only if it's out of the last located region.
+		if regions == 0 || (len(regionEnd) > 0 && bytes.Compare(key, regionEnd) >= 0) {
+			loc, err := txn.regionCache.LocateKey(bo, key)
+			if err != nil {
+				logutil.Logger(ctx).Warn("locate region for async commit failed", zap.Error(err))
+				return false
+			}
+			regions++
+			if regions > txn.asyncCommitRegionsLimit {
+				return true
+			}
+			regionEnd = loc.EndKey
+		}
+		if err := it.Next(); err != nil {
+			return false
+		}
+	}
+	return false
+}
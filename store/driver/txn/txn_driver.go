@@ -34,17 +34,21 @@ import (
 type tikvTxn struct {
 	*tikv.KVTxn
 	idxNameCache map[int64]*model.TableInfo
+	// regionCache is used to check the regions of the keys before committing, it may be nil.
+	regionCache             *tikv.RegionCache
+	asyncCommit             bool
+	asyncCommitRegionsLimit int
 }
 
 // NewTiKVTxn returns a new Transaction.
-func NewTiKVTxn(txn *tikv.KVTxn) kv.Transaction {
+func NewTiKVTxn(txn *tikv.KVTxn, regionCache *tikv.RegionCache) kv.Transaction {
 	txn.SetKVFilter(TiDBKVFilter{})
 
 	entryLimit := atomic.LoadUint64(&kv.TxnEntrySizeLimit)
 	totalLimit := atomic.LoadUint64(&kv.TxnTotalSizeLimit)
 	txn.GetUnionStore().SetEntrySizeLimit(entryLimit, totalLimit)
 
-	return &tikvTxn{txn, make(map[int64]*model.TableInfo)}
+	return &tikvTxn{KVTxn: txn, idxNameCache: make(map[int64]*model.TableInfo), regionCache: regionCache}
 }
 
 func (txn *tikvTxn) GetTableInfo(id int64) *model.TableInfo {
@@ -63,6 +67,10 @@ func (txn *tikvTxn) LockKeys(ctx context.Context, lockCtx *kv.LockCtx, keysInput
 }
 
 func (txn *tikvTxn) Commit(ctx context.Context) error {
+	if txn.spanTooManyRegions(ctx) {
+		txn.KVTxn.SetEnableAsyncCommit(false)
+		txn.KVTxn.SetEnable1PC(false)
+	}
 	err := txn.KVTxn.Commit(ctx)
 	return txn.extractKeyErr(err)
 }
@@ -163,6 +171,7 @@ func (txn *tikvTxn) SetOption(opt int, val interface{}) {
 	case kv.CommitHook:
 		txn.SetCommitCallback(val.(func(string, error)))
 	case kv.EnableAsyncCommit:
+		txn.asyncCommit = val.(bool)
 		txn.SetEnableAsyncCommit(val.(bool))
 	case kv.Enable1PC:
 		txn.SetEnable1PC(val.(bool))
@@ -176,6 +185,10 @@ func (txn *tikvTxn) SetOption(opt int, val interface{}) {
 		txn.KVTxn.GetSnapshot().SetMatchStoreLabels(val.([]*metapb.StoreLabel))
 	case kv.ResourceGroupTag:
 		txn.KVTxn.SetResourceGroupTag(val.([]byte))
+	case kv.TxnSizeLimit:
+		txn.GetUnionStore().SetEntrySizeLimit(atomic.LoadUint64(&kv.TxnEntrySizeLimit), val.(uint64))
+	case kv.AsyncCommitRegionsLimit:
+		txn.asyncCommitRegionsLimit = val.(int)
 	}
 }
 
@@ -81,7 +81,7 @@ func (s *mockStorage) Describe() string {
 // Begin a global transaction.
 func (s *mockStorage) Begin() (kv.Transaction, error) {
 	txn, err := s.KVStore.Begin()
-	return newTiKVTxn(txn, err)
+	return s.newTiKVTxn(txn, err)
 }
 
 // ShowStatus returns the specified status of the storage
@@ -91,7 +91,7 @@ func (s *mockStorage) ShowStatus(ctx context.Context, key string) (interface{},
 
 // BeginWithOption begins a transaction with given option
 func (s *mockStorage) BeginWithOption(option tikv.StartTSOption) (kv.Transaction, error) {
-	return newTiKVTxn(s.KVStore.BeginWithOption(option))
+	return s.newTiKVTxn(s.KVStore.BeginWithOption(option))
 }
 
 // GetSnapshot gets a snapshot that is able to read any data which data is <= ver.
@@ -111,11 +111,11 @@ func (s *mockStorage) GetMinSafeTS(txnScope string) uint64 {
 	return 0
 }
 
-func newTiKVTxn(txn *tikv.KVTxn, err error) (kv.Transaction, error) {
+func (s *mockStorage) newTiKVTxn(txn *tikv.KVTxn, err error) (kv.Transaction, error) {
 	if err != nil {
 		return nil, err
 	}
-	return driver.NewTiKVTxn(txn), nil
+	return driver.NewTiKVTxn(txn, s.GetRegionCache()), nil
 }
 
 func (s *mockStorage) GetLockWaits() ([]*deadlockpb.WaitForEntry, error) {
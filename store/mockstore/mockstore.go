@@ -20,6 +20,7 @@ import (
 	"github.com/pingcap/errors"
 	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/store/driver/interceptor"
 	"github.com/pingcap/tidb/store/mockstore/unistore"
 	"github.com/tikv/client-go/v2/mockstore/cluster"
 	"github.com/tikv/client-go/v2/mockstore/mocktikv"
@@ -87,6 +88,7 @@ type mockOptions struct {
 	clusterInspector func(cluster.Cluster)
 	clientHijacker   func(tikv.Client) tikv.Client
 	pdClientHijacker func(pd.Client) pd.Client
+	rpcInterceptors  kv.RPCInterceptorChain
 	path             string
 	txnLocalLatches  uint
 	storeType        StoreType
@@ -111,6 +113,13 @@ func WithPDClientHijacker(hijacker func(pd.Client) pd.Client) MockTiKVStoreOptio
 	}
 }
 
+// WithRPCInterceptors sets the interceptors called around each RPC sent to the mock stores.
+func WithRPCInterceptors(interceptors ...kv.RPCInterceptor) MockTiKVStoreOption {
+	return func(c *mockOptions) {
+		c.rpcInterceptors = interceptors
+	}
+}
+
 // WithClusterInspector lets user to inspect the mock cluster handler.
 func WithClusterInspector(inspector func(cluster.Cluster)) MockTiKVStoreOption {
 	return func(c *mockOptions) {
@@ -151,6 +160,17 @@ func NewMockStore(options ...MockTiKVStoreOption) (kv.Storage, error) {
 	for _, f := range options {
 		f(&opt)
 	}
+	// The chaos is the last interceptor like the TiKV driver, so the tests can inject failures by interceptor.SetChaos.
+	interceptors := make(kv.RPCInterceptorChain, 0, len(opt.rpcInterceptors)+1)
+	interceptors = append(interceptors, opt.rpcInterceptors...)
+	interceptors = append(interceptors, interceptor.Chaos)
+	hijacker := opt.clientHijacker
+	opt.clientHijacker = func(client tikv.Client) tikv.Client {
+		if hijacker != nil {
+			client = hijacker(client)
+		}
+		return interceptor.NewClient(client, interceptors)
+	}
 
 	switch opt.storeType {
 	case MockTiKV:
@@ -101,7 +101,7 @@ func s08d(prefix string, n int) string {
 }
 
 func toTiDBTxn(txn *tikv.TxnProbe) kv.Transaction {
-	return txndriver.NewTiKVTxn(txn.KVTxn)
+	return txndriver.NewTiKVTxn(txn.KVTxn, nil)
 }
 
 func toTiDBKeys(keys [][]byte) []kv.Key {
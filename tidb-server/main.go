@@ -50,10 +50,12 @@ import (
 	"github.com/pingcap/tidb/store/driver"
 	"github.com/pingcap/tidb/store/mockstore"
 	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/audit"
 	"github.com/pingcap/tidb/util/deadlockhistory"
 	"github.com/pingcap/tidb/util/disk"
 	"github.com/pingcap/tidb/util/domainutil"
 	"github.com/pingcap/tidb/util/kvcache"
+	"github.com/pingcap/tidb/util/lockwaithistory"
 	"github.com/pingcap/tidb/util/logutil"
 	"github.com/pingcap/tidb/util/memory"
 	"github.com/pingcap/tidb/util/printer"
@@ -173,6 +175,7 @@ func main() {
 	setGlobalVars()
 	setCPUAffinity()
 	setupLog()
+	setupAudit()
 	setHeapProfileTracker()
 	setupTracing() // Should before createServer and after setup config.
 	printInfo()
@@ -584,6 +587,7 @@ func setGlobalVars() {
 	tikv.SetStoreLivenessTimeout(t)
 	parsertypes.TiDBStrictIntegerDisplayWidth = cfg.DeprecateIntegerDisplayWidth
 	deadlockhistory.GlobalDeadlockHistory.Resize(cfg.PessimisticTxn.DeadlockHistoryCapacity)
+	lockwaithistory.GlobalLockWaitHistory.Resize(cfg.PessimisticTxn.LockWaitHistoryCapacity)
 }
 
 func setupLog() {
@@ -595,6 +599,12 @@ func setupLog() {
 	util.InternalHTTPClient()
 }
 
+func setupAudit() {
+	cfg := config.GetGlobalConfig()
+	err := audit.Setup(&cfg.Audit)
+	terror.MustNil(err)
+}
+
 func printInfo() {
 	// Make sure the TiDB info is always printed.
 	level := log.GetLevel()
@@ -659,12 +669,15 @@ func closeDomainAndStorage(storage kv.Storage, dom *domain.Domain) {
 }
 
 func cleanup(svr *server.Server, storage kv.Storage, dom *domain.Domain, graceful bool) {
+	// Hand off the owners before waiting for the connections, so other servers don't stall until the leases expire.
+	dom.HandoffOwners()
 	if graceful {
 		svr.GracefulDown(context.Background(), nil)
 	} else {
 		svr.TryGracefulDown()
 	}
 	plugin.Shutdown(context.Background())
+	audit.Close()
 	closeDomainAndStorage(storage, dom)
 	disk.CleanUp()
 	topsql.Close()
@@ -145,3 +145,28 @@ func (s *testTimeSuite) TestStrToDate(c *C) {
 		c.Assert(t.StrToDate(sc, tt.input, tt.format), IsFalse, Commentf("no.%d failed", i))
 	}
 }
+
+func (s *testTimeSuite) TestCompiledFormat(c *C) {
+	sc := mock.NewContext().GetSessionVars().StmtCtx
+	sc.IgnoreZeroInDate = true
+
+	layout := types.CompileDateFormat(`abc%Y-%m-%d %H:%i:%s %%%z%`)
+	for _, input := range []string{"2010-01-07 23:12:34", "2012-12-21 03:04:05"} {
+		tm, err := types.ParseTime(sc, input, mysql.TypeDatetime, 0)
+		c.Assert(err, IsNil)
+		str, err := tm.DateFormatByLayout(layout)
+		c.Assert(err, IsNil)
+		c.Assert(str, Equals, "abc"+input+" %z")
+	}
+
+	format := types.CompileStrToDateFormat(` %Y/%m/%d abc`)
+	var t types.Time
+	c.Assert(t.StrToDateByFormat(sc, `2018/10/22 abc`, format), IsTrue)
+	c.Assert(t.CoreTime(), Equals, types.FromDate(2018, 10, 22, 0, 0, 0, 0))
+	c.Assert(t.StrToDateByFormat(sc, ` 2004/4/30abc`, format), IsTrue)
+	c.Assert(t.CoreTime(), Equals, types.FromDate(2004, 4, 30, 0, 0, 0, 0))
+	c.Assert(t.StrToDateByFormat(sc, `2004/4/30 abd`, format), IsFalse)
+
+	// The format ending with a single '%' is invalid.
+	c.Assert(t.StrToDateByFormat(sc, `2018`, types.CompileStrToDateFormat(`%Y%`)), IsFalse)
+}
@@ -0,0 +1,211 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package json
+
+import (
+	"github.com/pingcap/tidb/util/hack"
+)
+
+// PathIterator iterates the values matching a path expression in a binary JSON, in the same order as
+// extractTo. The values are the slices of the binary JSON, and the pending values are kept in a stack instead
+// of being collected, so the path is evaluated directly over the binary representation without materializing
+// any intermediate values. A PathIterator can be reused by Reset to avoid the allocations of the stack.
+type PathIterator struct {
+	legs  []pathLeg
+	stack []pathIterFrame
+}
+
+// pathIterFrame is a value which is matching the legs from leg. next is the progress of the wildcard legs, it's
+// the next element to visit for `[*]` and `.*`, and for `**`, 0 means the value itself is not visited yet and
+// i > 0 means the (i-1)-th element is the next to visit.
+type pathIterFrame struct {
+	bj   BinaryJSON
+	leg  int
+	next int
+}
+
+// Reset makes the iterator iterate the values matching pathExpr in bj.
+func (it *PathIterator) Reset(bj BinaryJSON, pathExpr PathExpression) {
+	it.legs = pathExpr.legs
+	it.stack = append(it.stack[:0], pathIterFrame{bj: bj})
+}
+
+// Next returns the next value matching the path expression, ok is false if there are no more values.
+func (it *PathIterator) Next() (bj BinaryJSON, ok bool) {
+	for len(it.stack) > 0 {
+		top := &it.stack[len(it.stack)-1]
+		cur, leg := top.bj, top.leg
+		if leg == len(it.legs) {
+			it.pop()
+			return cur, true
+		}
+		currentLeg := &it.legs[leg]
+		switch currentLeg.typ {
+		case pathLegIndex:
+			if cur.TypeCode != TypeCodeArray {
+				// A scalar or an object is treated as an array containing only itself.
+				it.pop()
+				if currentLeg.arrayIndex == 0 {
+					it.push(cur, leg+1)
+				}
+				continue
+			}
+			elemCount := cur.GetElemCount()
+			if currentLeg.arrayIndex == arrayIndexAsterisk {
+				if top.next < elemCount {
+					top.next++
+					it.push(cur.arrayGetElem(top.next-1), leg+1)
+				} else {
+					it.pop()
+				}
+				continue
+			}
+			it.pop()
+			if currentLeg.arrayIndex < elemCount {
+				it.push(cur.arrayGetElem(currentLeg.arrayIndex), leg+1)
+			}
+		case pathLegKey:
+			if cur.TypeCode != TypeCodeObject {
+				it.pop()
+				continue
+			}
+			if currentLeg.dotKey == "*" {
+				if top.next < cur.GetElemCount() {
+					top.next++
+					it.push(cur.objectGetVal(top.next-1), leg+1)
+				} else {
+					it.pop()
+				}
+				continue
+			}
+			it.pop()
+			if child, found := cur.objectSearchKey(hack.Slice(currentLeg.dotKey)); found {
+				it.push(child, leg+1)
+			}
+		case pathLegDoubleAsterisk:
+			if top.next == 0 {
+				top.next++
+				it.push(cur, leg+1)
+				continue
+			}
+			var elemCount int
+			if cur.TypeCode == TypeCodeArray || cur.TypeCode == TypeCodeObject {
+				elemCount = cur.GetElemCount()
+			}
+			if top.next > elemCount {
+				it.pop()
+				continue
+			}
+			top.next++
+			if cur.TypeCode == TypeCodeArray {
+				it.push(cur.arrayGetElem(top.next-2), leg)
+			} else {
+				it.push(cur.objectGetVal(top.next-2), leg)
+			}
+		default:
+			it.pop()
+		}
+	}
+	return BinaryJSON{}, false
+}
+
+func (it *PathIterator) push(bj BinaryJSON, leg int) {
+	it.stack = append(it.stack, pathIterFrame{bj: bj, leg: leg})
+}
+
+func (it *PathIterator) pop() {
+	it.stack = it.stack[:len(it.stack)-1]
+}
+
+// extractOne returns the only value matching the path expression without any asterisks.
+func (bj BinaryJSON) extractOne(pathExpr PathExpression) (BinaryJSON, bool) {
+	for i := range pathExpr.legs {
+		currentLeg := &pathExpr.legs[i]
+		switch {
+		case currentLeg.typ == pathLegIndex && bj.TypeCode == TypeCodeArray:
+			if currentLeg.arrayIndex >= bj.GetElemCount() {
+				return BinaryJSON{}, false
+			}
+			bj = bj.arrayGetElem(currentLeg.arrayIndex)
+		case currentLeg.typ == pathLegIndex:
+			// A scalar or an object is treated as an array containing only itself.
+			if currentLeg.arrayIndex != 0 {
+				return BinaryJSON{}, false
+			}
+		case currentLeg.typ == pathLegKey && bj.TypeCode == TypeCodeObject:
+			child, found := bj.objectSearchKey(hack.Slice(currentLeg.dotKey))
+			if !found {
+				return BinaryJSON{}, false
+			}
+			bj = child
+		default:
+			return BinaryJSON{}, false
+		}
+	}
+	return bj, true
+}
+
+// PathExtractor extracts the values matching the same path expressions from many binary JSONs, like Extract.
+// The iterator and the buffer of the matched values are reused between the JSONs, so extracting a path from a
+// column of JSONs allocates nothing unless the matched values are wrapped into arrays.
+type PathExtractor struct {
+	pathExprs []PathExpression
+	iter      PathIterator
+	buf       []BinaryJSON
+}
+
+// NewPathExtractor creates a PathExtractor for the path expressions.
+func NewPathExtractor(pathExprs []PathExpression) *PathExtractor {
+	return &PathExtractor{pathExprs: pathExprs}
+}
+
+// Extract works like BinaryJSON.Extract with the path expressions of the extractor. The result may be a slice
+// of bj, so it should be copied if it's kept after bj is changed.
+func (e *PathExtractor) Extract(bj BinaryJSON) (ret BinaryJSON, found bool) {
+	if len(e.pathExprs) == 1 && !e.pathExprs[0].ContainsAnyAsterisk() {
+		return bj.extractOne(e.pathExprs[0])
+	}
+	e.buf = e.buf[:0]
+	for _, pathExpr := range e.pathExprs {
+		e.iter.Reset(bj, pathExpr)
+		for {
+			val, ok := e.iter.Next()
+			if !ok {
+				break
+			}
+			e.buf = append(e.buf, val)
+		}
+	}
+	switch {
+	case len(e.buf) == 0:
+		return BinaryJSON{}, false
+	case len(e.pathExprs) == 1 && len(e.buf) == 1:
+		// If pathExpr contains asterisks, len(buf) won't be 1 even if len(pathExprs) equals to 1.
+		return e.buf[0], true
+	default:
+		return buildBinaryArray(e.buf), true
+	}
+}
+
+// ExtractColumn extracts the values matching the path expressions of the extractor from each of bjs, which is
+// the vectorized version of Extract. The results are appended to rets, and found[i] tells whether any value of
+// bjs[i] matches the path expressions, the result of the unmatched JSON is an empty BinaryJSON.
+func (e *PathExtractor) ExtractColumn(bjs []BinaryJSON, rets []BinaryJSON, found []bool) ([]BinaryJSON, []bool) {
+	for _, bj := range bjs {
+		ret, ok := e.Extract(bj)
+		rets = append(rets, ret)
+		found = append(found, ok)
+	}
+	return rets, found
+}
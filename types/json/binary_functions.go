@@ -209,22 +209,11 @@ func quoteString(s string) string {
 //  ret: target JSON matched any path expressions. maybe autowrapped as an array.
 //  found: true if any path expressions matched.
 func (bj BinaryJSON) Extract(pathExprList []PathExpression) (ret BinaryJSON, found bool) {
-	buf := make([]BinaryJSON, 0, 1)
-	for _, pathExpr := range pathExprList {
-		buf = bj.extractTo(buf, pathExpr)
-	}
-	if len(buf) == 0 {
-		found = false
-	} else if len(pathExprList) == 1 && len(buf) == 1 {
-		// If pathExpr contains asterisks, len(elemList) won't be 1
-		// even if len(pathExprList) equals to 1.
-		found = true
-		ret = buf[0]
-	} else {
-		found = true
-		ret = buildBinaryArray(buf)
+	if len(pathExprList) == 1 && !pathExprList[0].ContainsAnyAsterisk() {
+		return bj.extractOne(pathExprList[0])
 	}
-	return
+	e := PathExtractor{pathExprs: pathExprList}
+	return e.Extract(bj)
 }
 
 func (bj BinaryJSON) extractTo(buf []BinaryJSON, pathExpr PathExpression) []BinaryJSON {
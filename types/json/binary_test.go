@@ -489,6 +489,81 @@ func (s *testJSONSuite) TestFunctions(c *C) {
 	c.Assert(err, ErrorMatches, "Cant peek from empty bytes")
 }
 
+func (s *testJSONSuite) TestPathIterator(c *C) {
+	c.Parallel()
+	bj := mustParseBinaryFromString(c, `{"a": [1, {"a": 2}, [3]], "b": {"a": 4}, "c": 5}`)
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"$", []string{`{"a": [1, {"a": 2}, [3]], "b": {"a": 4}, "c": 5}`}},
+		{"$.a[1].a", []string{"2"}},
+		{"$.c[0]", []string{"5"}},
+		{"$.c[1]", nil},
+		{"$.a[*]", []string{"1", `{"a": 2}`, "[3]"}},
+		{"$.*", []string{`[1, {"a": 2}, [3]]`, `{"a": 4}`, "5"}},
+		{"$**.a", []string{`[1, {"a": 2}, [3]]`, "2", "4"}},
+	}
+	var it PathIterator
+	for _, tt := range tests {
+		pathExpr, err := ParseJSONPathExpr(tt.path)
+		c.Assert(err, IsNil)
+		var expected []BinaryJSON
+		expected = bj.extractTo(expected, pathExpr)
+		var vals []string
+		it.Reset(bj, pathExpr)
+		for i := 0; ; i++ {
+			val, ok := it.Next()
+			if !ok {
+				break
+			}
+			c.Assert(i < len(expected), IsTrue, Commentf("%s", tt.path))
+			c.Assert(val.String(), Equals, expected[i].String())
+			vals = append(vals, val.String())
+		}
+		c.Assert(vals, DeepEquals, tt.expected, Commentf("%s", tt.path))
+	}
+}
+
+func (s *testJSONSuite) TestPathExtractor(c *C) {
+	c.Parallel()
+	bjs := []BinaryJSON{
+		mustParseBinaryFromString(c, `{"a": 1, "b": [2, 3]}`),
+		mustParseBinaryFromString(c, `{"b": 4}`),
+		mustParseBinaryFromString(c, `[{"a": 5}]`),
+	}
+	tests := []struct {
+		paths    []string
+		expected []string
+	}{
+		{[]string{"$.a"}, []string{"1", "", ""}},
+		{[]string{"$[0].a"}, []string{"1", "", "5"}},
+		{[]string{"$.b[*]"}, []string{"[2, 3]", "", ""}},
+		{[]string{"$**.a"}, []string{"1", "", "5"}},
+		{[]string{"$.a", "$.b"}, []string{"[1, [2, 3]]", "[4]", ""}},
+	}
+	for _, tt := range tests {
+		pathExprs := make([]PathExpression, 0, len(tt.paths))
+		for _, path := range tt.paths {
+			pathExpr, err := ParseJSONPathExpr(path)
+			c.Assert(err, IsNil)
+			pathExprs = append(pathExprs, pathExpr)
+		}
+		rets, found := NewPathExtractor(pathExprs).ExtractColumn(bjs, nil, nil)
+		c.Assert(rets, HasLen, len(bjs))
+		for i, bj := range bjs {
+			expected, ok := bj.Extract(pathExprs)
+			c.Assert(found[i], Equals, ok)
+			if !ok {
+				c.Assert(tt.expected[i], Equals, "")
+				continue
+			}
+			c.Assert(rets[i].String(), Equals, expected.String())
+			c.Assert(rets[i].String(), Equals, tt.expected[i], Commentf("%v", tt.paths))
+		}
+	}
+}
+
 func (s *testJSONSuite) TestBinaryJSONExtractCallback(c *C) {
 	bj1 := mustParseBinaryFromString(c, `{"\"hello\"": "world", "a": [1, "2", {"aa": "bb"}, 4.0, {"aa": "cc"}], "b": true, "c": ["d"]}`)
 	bj2 := mustParseBinaryFromString(c, `[{"a": 1, "b": true}, 3, 3.5, "hello, world", null, true]`)
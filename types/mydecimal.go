@@ -1590,6 +1590,9 @@ func validateArgs(f1, f2, to *MyDecimal) (*MyDecimal, *MyDecimal, *MyDecimal) {
 }
 
 func doSub(from1, from2, to *MyDecimal) (cmp int, err error) {
+	if cmp, ok := doSubFast(from1, from2, to); ok {
+		return cmp, nil
+	}
 	var (
 		wordsInt1   = digitsToWords(int(from1.digitsInt))
 		wordsFrac1  = digitsToWords(int(from1.digitsFrac))
@@ -1755,6 +1758,9 @@ func doSub(from1, from2, to *MyDecimal) (cmp int, err error) {
 }
 
 func doAdd(from1, from2, to *MyDecimal) error {
+	if doAddFast(from1, from2, to) {
+		return nil
+	}
 	var (
 		err         error
 		wordsInt1   = digitsToWords(int(from1.digitsInt))
@@ -1925,6 +1931,9 @@ func DecimalMul(from1, from2, to *MyDecimal) error {
 		tmp2        = wordsFracTo
 	)
 	to.resultFrac = myMinInt8(from1.resultFrac+from2.resultFrac, mysql.MaxDecimalScale)
+	if decimalMulFast(from1, from2, to) {
+		return nil
+	}
 	wordsIntTo, wordsFracTo, err = fixWordCntError(wordsIntTo, wordsFracTo)
 	to.negative = from1.negative != from2.negative
 	to.digitsFrac = from1.digitsFrac + from2.digitsFrac
@@ -1994,41 +2003,45 @@ func DecimalMul(from1, from2, to *MyDecimal) error {
 		}
 		startTo--
 	}
+	to.normalizeProduct(wordsIntTo, wordsFracTo)
+	return err
+}
 
+// normalizeProduct turns the negative zero product into zero and removes the leading zero words of the product.
+func (d *MyDecimal) normalizeProduct(wordsIntTo, wordsFracTo int) {
 	/* Now we have to check for -0.000 case */
-	if to.negative {
+	if d.negative {
 		idx := 0
 		end := wordsIntTo + wordsFracTo
 		for {
-			if to.wordBuf[idx] != 0 {
+			if d.wordBuf[idx] != 0 {
 				break
 			}
 			idx++
 			/* We got decimal zero */
 			if idx == end {
-				*to = zeroMyDecimalWithFrac(to.resultFrac)
+				*d = zeroMyDecimalWithFrac(d.resultFrac)
 				break
 			}
 		}
 	}
 
-	idxTo = 0
-	dToMove := wordsIntTo + digitsToWords(int(to.digitsFrac))
-	for to.wordBuf[idxTo] == 0 && to.digitsInt > digitsPerWord {
+	idxTo := 0
+	dToMove := wordsIntTo + digitsToWords(int(d.digitsFrac))
+	for d.wordBuf[idxTo] == 0 && d.digitsInt > digitsPerWord {
 		idxTo++
-		to.digitsInt -= digitsPerWord
+		d.digitsInt -= digitsPerWord
 		dToMove--
 	}
 	if idxTo > 0 {
 		curIdx := 0
 		for dToMove > 0 {
-			to.wordBuf[curIdx] = to.wordBuf[idxTo]
+			d.wordBuf[curIdx] = d.wordBuf[idxTo]
 			curIdx++
 			idxTo++
 			dToMove--
 		}
 	}
-	return err
 }
 
 // DecimalDiv does division of two decimals.
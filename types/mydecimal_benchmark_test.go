@@ -110,3 +110,26 @@ func BenchmarkToFloat64(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkShortArithmetic(b *testing.B) {
+	var x, y, to MyDecimal
+	if err := x.FromString([]byte("12345.6789")); err != nil {
+		b.Fatal(err)
+	}
+	if err := y.FromString([]byte("-987.654321")); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := DecimalAdd(&x, &y, &to); err != nil {
+			b.Fatal(err)
+		}
+		if err := DecimalSub(&x, &y, &to); err != nil {
+			b.Fatal(err)
+		}
+		if err := DecimalMul(&x, &y, &to); err != nil {
+			b.Fatal(err)
+		}
+		x.Compare(&y)
+	}
+}
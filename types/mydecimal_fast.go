@@ -0,0 +1,164 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"math/bits"
+)
+
+// The fast path of the decimal arithmetic on the short decimals. The integer part and the fraction part of a
+// short decimal take at most 2 words in total, so its words form an integer less than 10^18, and the value of
+// the decimal is the integer divided by wordBase^wordsFrac. The additions, subtractions, multiplications and
+// comparisons of the short decimals are done on these integers with the 128 bits arithmetic, the results are
+// written into the words in the same layout as the general algorithm, so they are exactly the same.
+
+// toShort returns the integer formed by the words of d and the number of its fraction words, ok is false if d
+// isn't a short decimal.
+func (d *MyDecimal) toShort() (v uint64, wordsFrac int, ok bool) {
+	wordsFrac = digitsToWords(int(d.digitsFrac))
+	switch digitsToWords(int(d.digitsInt)) + wordsFrac {
+	case 0:
+		return 0, 0, true
+	case 1:
+		return uint64(d.wordBuf[0]), wordsFrac, true
+	case 2:
+		return uint64(d.wordBuf[0])*wordBase + uint64(d.wordBuf[1]), wordsFrac, true
+	}
+	return 0, 0, false
+}
+
+// scaleShort returns v*wordBase^words as a 128 bits integer, words is at most 2.
+func scaleShort(v uint64, words int) (hi, lo uint64) {
+	switch words {
+	case 0:
+		return 0, v
+	case 1:
+		return bits.Mul64(v, wordBase)
+	default:
+		return bits.Mul64(v, wordBase*wordBase)
+	}
+}
+
+// setWords writes the 128 bits integer hi*2^64+lo into the first n words, the integer must be less than
+// wordBase^n.
+func (d *MyDecimal) setWords(hi, lo uint64, n int) {
+	for i := n - 1; i >= 0; i-- {
+		if hi == 0 {
+			d.wordBuf[i] = int32(lo % wordBase)
+			lo /= wordBase
+			continue
+		}
+		var r uint64
+		hi, r = hi/wordBase, hi%wordBase
+		lo, r = bits.Div64(r, lo, wordBase)
+		d.wordBuf[i] = int32(r)
+	}
+}
+
+// doAddFast is the fast path of doAdd, ok is false if the decimals are not short.
+func doAddFast(from1, from2, to *MyDecimal) (ok bool) {
+	v1, wordsFrac1, ok1 := from1.toShort()
+	v2, wordsFrac2, ok2 := from2.toShort()
+	if !ok1 || !ok2 {
+		return false
+	}
+	wordsInt1 := digitsToWords(int(from1.digitsInt))
+	wordsInt2 := digitsToWords(int(from2.digitsInt))
+	wordsIntTo := myMax(wordsInt1, wordsInt2)
+	wordsFracTo := myMax(wordsFrac1, wordsFrac2)
+	// Reserve the word for the carry in the same way as doAdd.
+	var x int32
+	if wordsInt1 > wordsInt2 {
+		x = from1.wordBuf[0]
+	} else if wordsInt2 > wordsInt1 {
+		x = from2.wordBuf[0]
+	} else {
+		x = from1.wordBuf[0] + from2.wordBuf[0]
+	}
+	if x > wordMax-1 {
+		wordsIntTo++
+	}
+	hi1, lo1 := scaleShort(v1, wordsFracTo-wordsFrac1)
+	hi2, lo2 := scaleShort(v2, wordsFracTo-wordsFrac2)
+	lo, carry := bits.Add64(lo1, lo2, 0)
+	hi, _ := bits.Add64(hi1, hi2, carry)
+	to.negative = from1.negative
+	to.digitsInt = int8(wordsIntTo * digitsPerWord)
+	to.digitsFrac = myMaxInt8(from1.digitsFrac, from2.digitsFrac)
+	to.setWords(hi, lo, wordsIntTo+wordsFracTo)
+	return true
+}
+
+// doSubFast is the fast path of doSub, ok is false if the decimals are not short.
+func doSubFast(from1, from2, to *MyDecimal) (cmp int, ok bool) {
+	v1, wordsFrac1, ok1 := from1.toShort()
+	v2, wordsFrac2, ok2 := from2.toShort()
+	if !ok1 || !ok2 {
+		return 0, false
+	}
+	wordsFracTo := myMax(wordsFrac1, wordsFrac2)
+	hi1, lo1 := scaleShort(v1, wordsFracTo-wordsFrac1)
+	hi2, lo2 := scaleShort(v2, wordsFracTo-wordsFrac2)
+	if hi1 == hi2 && lo1 == lo2 {
+		if to != nil {
+			*to = zeroMyDecimalWithFrac(to.resultFrac)
+		}
+		return 0, true
+	}
+	less := hi1 < hi2 || (hi1 == hi2 && lo1 < lo2)
+	if to == nil {
+		if less == from1.negative {
+			return 1, true
+		}
+		return -1, true
+	}
+	to.negative = from1.negative
+	if less {
+		from1, from2 = from2, from1
+		hi1, lo1, hi2, lo2 = hi2, lo2, hi1, lo1
+		to.negative = !to.negative
+	}
+	lo, borrow := bits.Sub64(lo1, lo2, 0)
+	hi, _ := bits.Sub64(hi1, hi2, borrow)
+	// The leading zero words of the larger one are removed like doSub.
+	wordsInt := digitsToWords(int(from1.digitsInt))
+	for wordsInt > 0 && from1.wordBuf[digitsToWords(int(from1.digitsInt))-wordsInt] == 0 {
+		wordsInt--
+	}
+	to.digitsInt = int8(wordsInt * digitsPerWord)
+	to.digitsFrac = myMaxInt8(from1.digitsFrac, from2.digitsFrac)
+	to.setWords(hi, lo, wordsInt+wordsFracTo)
+	return 0, true
+}
+
+// decimalMulFast is the fast path of DecimalMul, ok is false if the decimals are not short.
+func decimalMulFast(from1, from2, to *MyDecimal) (ok bool) {
+	if int(from1.digitsFrac)+int(from2.digitsFrac) > notFixedDec {
+		return false
+	}
+	v1, wordsFrac1, ok1 := from1.toShort()
+	v2, wordsFrac2, ok2 := from2.toShort()
+	if !ok1 || !ok2 {
+		return false
+	}
+	wordsIntTo := digitsToWords(int(from1.digitsInt) + int(from2.digitsInt))
+	wordsFracTo := wordsFrac1 + wordsFrac2
+	hi, lo := bits.Mul64(v1, v2)
+	to.negative = from1.negative != from2.negative
+	to.digitsFrac = from1.digitsFrac + from2.digitsFrac
+	to.digitsInt = int8(wordsIntTo * digitsPerWord)
+	to.setWords(hi, lo, wordsIntTo+wordsFracTo)
+	to.normalizeProduct(wordsIntTo, wordsFracTo)
+	return true
+}
@@ -820,6 +820,45 @@ func (s *testMyDecimalSuite) TestMul(c *C) {
 	}
 }
 
+func (s *testMyDecimalSuite) TestShortArithmetic(c *C) {
+	// The decimals which take at most 2 words are computed by the fast path.
+	tests := []struct {
+		a, b          string
+		sum, diff     string
+		product       string
+		cmp           int
+		sumDigitsInt  int8
+		diffDigitsInt int8
+	}{
+		{"999999999", "1", "1000000000", "999999998", "999999999", 1, 18, 9},
+		{"999999999.999999999", "0.000000001", "1000000000.000000000", "999999999.999999998", "0.999999999999999999", 1, 18, 9},
+		{"-123.456", "98765.4321", "98641.9761", "-98888.8881", "-12193185.1853376", -1, 9, 9},
+		{"0.5", "0.50", "1.00", "0.00", "0.250", 0, 9, 0},
+		{"-0.000", "1", "1.000", "-1.000", "0.000", -1, 9, 9},
+		{"0", "-0.1", "-0.1", "0.1", "0.0", 1, 0, 9},
+		{"123456789", "0.123456789", "123456789.123456789", "123456788.876543211", "15241578.750190521", 1, 9, 9},
+		{"-12.5", "-12.50", "-25.00", "0.00", "156.250", 0, 9, 0},
+	}
+	for _, tt := range tests {
+		var a, b, sum, diff, product MyDecimal
+		c.Assert(a.FromString([]byte(tt.a)), IsNil)
+		c.Assert(b.FromString([]byte(tt.b)), IsNil)
+		_, _, ok1 := a.toShort()
+		_, _, ok2 := b.toShort()
+		c.Assert(ok1 && ok2, IsTrue)
+		c.Assert(DecimalAdd(&a, &b, &sum), IsNil)
+		c.Assert(sum.String(), Equals, tt.sum)
+		c.Assert(sum.digitsInt, Equals, tt.sumDigitsInt)
+		c.Assert(DecimalSub(&a, &b, &diff), IsNil)
+		c.Assert(diff.String(), Equals, tt.diff)
+		c.Assert(diff.digitsInt, Equals, tt.diffDigitsInt)
+		c.Assert(DecimalMul(&a, &b, &product), IsNil)
+		c.Assert(product.String(), Equals, tt.product)
+		c.Assert(a.Compare(&b), Equals, tt.cmp)
+		c.Assert(b.Compare(&a), Equals, -tt.cmp)
+	}
+}
+
 func (s *testMyDecimalSuite) TestDivMod(c *C) {
 	type tcase struct {
 		a      string
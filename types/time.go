@@ -2546,13 +2546,33 @@ func ParseTimeFromInt64(sc *stmtctx.StatementContext, num int64) (Time, error) {
 // according to layout.
 // See http://dev.mysql.com/doc/refman/5.7/en/date-and-time-functions.html#function_date-format
 func (t Time) DateFormat(layout string) (string, error) {
-	var buf bytes.Buffer
+	return t.DateFormatByLayout(CompileDateFormat(layout))
+}
+
+// DateFormatLayout is the layout of DATE_FORMAT compiled into tokens, so a layout
+// used to format many time values is only parsed once.
+type DateFormatLayout struct {
+	tokens []dateFormatToken
+}
+
+// dateFormatToken is either a literal string or a pattern character following '%'.
+type dateFormatToken struct {
+	literal string
+	pattern rune
+}
+
+// CompileDateFormat compiles the layout of DATE_FORMAT.
+func CompileDateFormat(layout string) *DateFormatLayout {
+	l := &DateFormatLayout{}
+	var literal strings.Builder
 	inPatternMatch := false
 	for _, b := range layout {
 		if inPatternMatch {
-			if err := t.convertDateFormat(b, &buf); err != nil {
-				return "", errors.Trace(err)
+			if literal.Len() > 0 {
+				l.tokens = append(l.tokens, dateFormatToken{literal: literal.String()})
+				literal.Reset()
 			}
+			l.tokens = append(l.tokens, dateFormatToken{pattern: b})
 			inPatternMatch = false
 			continue
 		}
@@ -2561,7 +2581,25 @@ func (t Time) DateFormat(layout string) (string, error) {
 		if b == '%' {
 			inPatternMatch = true
 		} else {
-			buf.WriteRune(b)
+			literal.WriteRune(b)
+		}
+	}
+	if literal.Len() > 0 {
+		l.tokens = append(l.tokens, dateFormatToken{literal: literal.String()})
+	}
+	return l
+}
+
+// DateFormatByLayout is like DateFormat, but formats the time value by the compiled layout.
+func (t Time) DateFormatByLayout(layout *DateFormatLayout) (string, error) {
+	var buf bytes.Buffer
+	for _, token := range layout.tokens {
+		if token.pattern == 0 {
+			buf.WriteString(token.literal)
+			continue
+		}
+		if err := t.convertDateFormat(token.pattern, &buf); err != nil {
+			return "", errors.Trace(err)
 		}
 	}
 	return buf.String(), nil
@@ -2721,6 +2759,44 @@ func abbrDayOfMonth(day int) string {
 // StrToDate converts date string according to format.
 // See https://dev.mysql.com/doc/refman/5.7/en/date-and-time-functions.html#function_date-format
 func (t *Time) StrToDate(sc *stmtctx.StatementContext, date, format string) bool {
+	return t.StrToDateByFormat(sc, date, CompileStrToDateFormat(format))
+}
+
+// StrToDateFormat is the format of STR_TO_DATE compiled into tokens, so a format
+// used to parse many date strings is only parsed once.
+type StrToDateFormat struct {
+	tokens []strToDateToken
+	// invalid is true if the format ends with a single '%', which fails the parsing
+	// unless the date string runs out before it.
+	invalid bool
+}
+
+// strToDateToken is a format control token with its parser, the parser is nil if
+// the token must be matched literally.
+type strToDateToken struct {
+	token string
+	parse dateFormatParser
+}
+
+// CompileStrToDateFormat compiles the format of STR_TO_DATE.
+func CompileStrToDateFormat(format string) *StrToDateFormat {
+	f := &StrToDateFormat{}
+	for {
+		token, remain, succ := getFormatToken(skipWhiteSpace(format))
+		if !succ {
+			f.invalid = true
+			return f
+		}
+		if token == "" {
+			return f
+		}
+		f.tokens = append(f.tokens, strToDateToken{token: token, parse: dateFormatParserTable[token]})
+		format = remain
+	}
+}
+
+// StrToDateByFormat is like StrToDate, but parses the date string by the compiled format.
+func (t *Time) StrToDateByFormat(sc *stmtctx.StatementContext, date string, format *StrToDateFormat) bool {
 	ctx := make(map[string]int)
 	var tm CoreTime
 	success, warning := strToDate(&tm, date, format, ctx)
@@ -2785,35 +2861,34 @@ func mysqlTimeFix(t *CoreTime, ctx map[string]int) error {
 // strToDate converts date string according to format,
 // the value will be stored in argument t or ctx.
 // The second return value is true when success but still need to append a warning.
-func strToDate(t *CoreTime, date string, format string, ctx map[string]int) (success bool, warning bool) {
-	date = skipWhiteSpace(date)
-	format = skipWhiteSpace(format)
-
-	token, formatRemain, succ := getFormatToken(format)
-	if !succ {
-		return false, false
-	}
-
-	if token == "" {
-		if len(date) != 0 {
-			// Extra characters at the end of date are ignored, but a warning should be reported at this case.
-			return true, true
+func strToDate(t *CoreTime, date string, format *StrToDateFormat, ctx map[string]int) (success bool, warning bool) {
+	for _, token := range format.tokens {
+		date = skipWhiteSpace(date)
+		if len(date) == 0 {
+			ctx[token.token] = 0
+			return true, false
 		}
-		// Normal case. Both token and date are empty now.
-		return true, false
-	}
 
-	if len(date) == 0 {
-		ctx[token] = 0
-		return true, false
+		var succ bool
+		if token.parse != nil {
+			date, succ = token.parse(t, date, ctx)
+		} else {
+			date, succ = matchDateWithLiteral(date, token.token)
+		}
+		if !succ {
+			return false, false
+		}
 	}
 
-	dateRemain, succ := matchDateWithToken(t, date, token, ctx)
-	if !succ {
+	if format.invalid {
 		return false, false
 	}
-
-	return strToDate(t, dateRemain, formatRemain, ctx)
+	if len(skipWhiteSpace(date)) != 0 {
+		// Extra characters at the end of date are ignored, but a warning should be reported at this case.
+		return true, true
+	}
+	// Normal case. Both token and date are empty now.
+	return true, false
 }
 
 // getFormatToken takes one format control token from the string.
@@ -2933,11 +3008,7 @@ func GetFormatType(format string) (isDuration, isDate bool) {
 	return
 }
 
-func matchDateWithToken(t *CoreTime, date string, token string, ctx map[string]int) (remain string, succ bool) {
-	if parse, ok := dateFormatParserTable[token]; ok {
-		return parse(t, date, ctx)
-	}
-
+func matchDateWithLiteral(date string, token string) (remain string, succ bool) {
 	if strings.HasPrefix(date, token) {
 		return date[len(token):], true
 	}
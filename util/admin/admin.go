@@ -14,10 +14,14 @@
 package admin
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"math"
 	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pingcap/errors"
@@ -164,8 +168,11 @@ func CancelJobs(txn kv.Transaction, ids []int64) ([]error, error) {
 			}
 
 			job.State = model.JobStateCancelling
-			// Make sure RawArgs isn't overwritten.
-			err := json.Unmarshal(job.RawArgs, &job.Args)
+			// Make sure RawArgs isn't overwritten. Keep the numbers as they are, since float64 can't hold the big
+			// integers in the args, e.g. the TS of the sub-jobs of a multi-schema change job.
+			decoder := json.NewDecoder(bytes.NewReader(job.RawArgs))
+			decoder.UseNumber()
+			err := decoder.Decode(&job.Args)
 			if err != nil {
 				errs[i] = errors.Trace(err)
 				continue
@@ -315,7 +322,11 @@ const (
 // otherwise it returns an error and the corresponding index's offset.
 func CheckIndicesCount(ctx sessionctx.Context, dbName, tableName string, indices []string) (byte, int, error) {
 	// Here we need check all indexes, includes invisible index
+	originUseInvisibleIndexes := ctx.GetSessionVars().OptimizerUseInvisibleIndexes
 	ctx.GetSessionVars().OptimizerUseInvisibleIndexes = true
+	defer func() {
+		ctx.GetSessionVars().OptimizerUseInvisibleIndexes = originUseInvisibleIndexes
+	}()
 	// Add `` for some names like `table name`.
 	exec := ctx.(sqlexec.RestrictedSQLExecutor)
 	stmt, err := exec.ParseWithParams(context.Background(), "SELECT COUNT(*) FROM %n.%n USE INDEX()", dbName, tableName)
@@ -365,6 +376,142 @@ func CheckIndicesCount(ctx sessionctx.Context, dbName, tableName string, indices
 	return 0, 0, nil
 }
 
+// CheckIndicesChecksum compares the count and the checksum of the handles and the index columns computed from the
+// table with the ones computed from each index. The checksums are computed by the aggregations pushed down to the
+// coprocessor, and at most concurrency indices are checked at the same time. It returns the error of the first
+// inconsistent index.
+func CheckIndicesChecksum(ctx sessionctx.Context, dbName string, tblInfo *model.TableInfo, indices []*model.IndexInfo, concurrency int) error {
+	// Here we need check all indexes, includes invisible index
+	originUseInvisibleIndexes := ctx.GetSessionVars().OptimizerUseInvisibleIndexes
+	ctx.GetSessionVars().OptimizerUseInvisibleIndexes = true
+	defer func() {
+		ctx.GetSessionVars().OptimizerUseInvisibleIndexes = originUseInvisibleIndexes
+	}()
+	var snapshot uint64
+	txn, err := ctx.Txn(false)
+	if err != nil {
+		return err
+	}
+	if txn.Valid() {
+		snapshot = txn.StartTS()
+	}
+	if ctx.GetSessionVars().SnapshotTS != 0 {
+		snapshot = ctx.GetSessionVars().SnapshotTS
+	}
+
+	// The statements are parsed in advance, because the parser of the session can't be used concurrently.
+	exec := ctx.(sqlexec.RestrictedSQLExecutor)
+	handleCols := handleColNames(tblInfo)
+	stmts := make([][2]ast.StmtNode, len(indices))
+	for i, idx := range indices {
+		cols := make([]string, 0, len(idx.Columns)+len(handleCols))
+		for _, col := range idx.Columns {
+			cols = append(cols, col.Name.O)
+		}
+		cols = append(cols, handleCols...)
+		for j, idxName := range []string{"", idx.Name.O} {
+			stmts[i][j], err = checksumStmt(exec, dbName, tblInfo.Name.O, idxName, cols)
+			if err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	errs := make([]error, len(indices))
+	offsets := make(chan int, len(indices))
+	for i := range indices {
+		offsets <- i
+	}
+	close(offsets)
+	if concurrency > len(indices) {
+		concurrency = len(indices)
+	}
+	var finished int32
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for offset := range offsets {
+				idx := indices[offset]
+				tblCnt, tblSum, err := getChecksum(exec, stmts[offset][0], snapshot)
+				if err == nil {
+					var idxCnt int64
+					var idxSum uint64
+					idxCnt, idxSum, err = getChecksum(exec, stmts[offset][1], snapshot)
+					if err == nil && (tblCnt != idxCnt || tblSum != idxSum) {
+						err = ErrAdminCheckTable.GenWithStack("table count %d checksum %d != index(%s) count %d checksum %d",
+							tblCnt, tblSum, idx.Name.O, idxCnt, idxSum)
+					}
+				}
+				errs[offset] = err
+				logutil.BgLogger().Info("check index checksum", zap.String("table", tblInfo.Name.O), zap.String("index", idx.Name.O),
+					zap.Int32("finished", atomic.AddInt32(&finished, 1)), zap.Int("total", len(indices)), zap.Error(err))
+			}
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// handleColNames returns the names of the columns which compose the handle of the table.
+func handleColNames(tblInfo *model.TableInfo) []string {
+	if tblInfo.PKIsHandle {
+		if pk := tblInfo.GetPkColInfo(); pk != nil {
+			return []string{pk.Name.O}
+		}
+	}
+	if tblInfo.IsCommonHandle {
+		for _, idx := range tblInfo.Indices {
+			if !idx.Primary {
+				continue
+			}
+			names := make([]string, 0, len(idx.Columns))
+			for _, col := range idx.Columns {
+				names = append(names, col.Name.O)
+			}
+			return names
+		}
+	}
+	return []string{model.ExtraHandleName.O}
+}
+
+// checksumStmt returns the statement which computes the count and the checksum of the columns read from the index,
+// or from the table if the index is empty. The NULL flags are included so that the NULLs in different columns are
+// distinguished.
+func checksumStmt(exec sqlexec.RestrictedSQLExecutor, dbName, tableName, index string, cols []string) (ast.StmtNode, error) {
+	var sql strings.Builder
+	args := make([]interface{}, 0, len(cols)*2+3)
+	sql.WriteString("SELECT COUNT(*), BIT_XOR(CRC32(MD5(CONCAT_WS(0x2")
+	for _, col := range cols {
+		sql.WriteString(", %n, ISNULL(%n)")
+		args = append(args, col, col)
+	}
+	sql.WriteString(")))) FROM %n.%n USE INDEX(")
+	args = append(args, dbName, tableName)
+	if index != "" {
+		sql.WriteString("%n")
+		args = append(args, index)
+	}
+	sql.WriteString(")")
+	return exec.ParseWithParams(context.Background(), sql.String(), args...)
+}
+
+func getChecksum(exec sqlexec.RestrictedSQLExecutor, stmt ast.StmtNode, snapshot uint64) (int64, uint64, error) {
+	rows, _, err := exec.ExecRestrictedStmt(context.Background(), stmt, sqlexec.ExecOptionWithSnapshot(snapshot))
+	if err != nil {
+		return 0, 0, errors.Trace(err)
+	}
+	if len(rows) != 1 {
+		return 0, 0, errors.Errorf("can not get checksum, rows count = %d", len(rows))
+	}
+	return rows[0].GetInt64(0), rows[0].GetUint64(1), nil
+}
+
 // CheckRecordAndIndex is exported for testing.
 func CheckRecordAndIndex(sessCtx sessionctx.Context, txn kv.Transaction, t table.Table, idx table.Index) error {
 	sc := sessCtx.GetSessionVars().StmtCtx
@@ -0,0 +1,284 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/tidb/config"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/logutil"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+)
+
+// Names of the connection events.
+const (
+	// EventConnect is the event of a connection established after authentication.
+	EventConnect = "Connect"
+	// EventDisconnect is the event of a connection closed.
+	EventDisconnect = "Disconnect"
+	// EventChangeUser is the event of a connection changing its user.
+	EventChangeUser = "ChangeUser"
+	// EventReject is the event of a connection rejected, e.g. by the failed authentication.
+	EventReject = "Reject"
+)
+
+// Event is an audit event of a connection or a statement.
+type Event struct {
+	Time time.Time
+	// Class is one of the audit classes, like config.AuditClassConnection.
+	Class string
+	// Name is the name of the connection event, or the label of the statement, like "Select".
+	Name   string
+	ConnID uint64
+	User   string
+	Host   string
+	DB     string
+	// Tables are the tables accessed by the statement, in the form of "db.table".
+	Tables       []string
+	SQL          string
+	Digest       string
+	AffectedRows uint64
+	Duration     time.Duration
+	// Err is the error of the statement or the reason of the rejected connection, it's empty on success.
+	Err string
+}
+
+// Sink is where the audit events are written to. Write is called by the sessions concurrently, so it should be
+// thread-safe and fast.
+type Sink interface {
+	Write(e *Event) error
+	Close() error
+}
+
+type filter struct {
+	users   map[string]struct{}
+	dbs     map[string]struct{}
+	classes map[string]struct{}
+}
+
+func newSet(items []string) map[string]struct{} {
+	if len(items) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		set[item] = struct{}{}
+	}
+	return set
+}
+
+func contains(set map[string]struct{}, item string) bool {
+	if set == nil {
+		return true
+	}
+	_, ok := set[item]
+	return ok
+}
+
+func (f *filter) match(e *Event) bool {
+	if !contains(f.users, e.User) || !contains(f.classes, e.Class) {
+		return false
+	}
+	if f.dbs == nil || e.Class == config.AuditClassConnection {
+		return true
+	}
+	if _, ok := f.dbs[e.DB]; ok {
+		return true
+	}
+	for _, tbl := range e.Tables {
+		for db := range f.dbs {
+			if len(tbl) > len(db) && tbl[:len(db)] == db && tbl[len(db)] == '.' {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var (
+	enabled = atomic.NewBool(false)
+	global  = struct {
+		sync.RWMutex
+		filter filter
+		redact bool
+		sinks  map[string]Sink
+	}{sinks: make(map[string]Sink)}
+)
+
+// FileSinkName is the name of the sink writing to the audit log file.
+const FileSinkName = "file"
+
+// Setup sets up the audit log by the config, the file sink is registered if the filename of the audit log file is set.
+func Setup(cfg *config.Audit) error {
+	global.Lock()
+	global.filter = filter{
+		users:   newSet(cfg.Users),
+		dbs:     newSet(cfg.Databases),
+		classes: newSet(cfg.Classes),
+	}
+	global.redact = cfg.Redact
+	global.Unlock()
+	if cfg.Enable && len(cfg.File.Filename) > 0 {
+		sink, err := NewFileSink(&cfg.File)
+		if err != nil {
+			return err
+		}
+		RegisterSink(FileSinkName, sink)
+	}
+	enabled.Store(cfg.Enable)
+	return nil
+}
+
+// RegisterSink registers the sink of the name, the previous sink of the same name is closed.
+func RegisterSink(name string, sink Sink) {
+	global.Lock()
+	old := global.sinks[name]
+	global.sinks[name] = sink
+	global.Unlock()
+	closeSink(name, old)
+}
+
+// UnregisterSink unregisters and closes the sink of the name.
+func UnregisterSink(name string) {
+	global.Lock()
+	old := global.sinks[name]
+	delete(global.sinks, name)
+	global.Unlock()
+	closeSink(name, old)
+}
+
+// Close disables the audit log and closes all the sinks.
+func Close() {
+	enabled.Store(false)
+	global.Lock()
+	sinks := global.sinks
+	global.sinks = make(map[string]Sink)
+	global.Unlock()
+	for name, sink := range sinks {
+		closeSink(name, sink)
+	}
+}
+
+func closeSink(name string, sink Sink) {
+	if sink == nil {
+		return
+	}
+	if err := sink.Close(); err != nil {
+		logutil.BgLogger().Warn("close audit sink failed", zap.String("sink", name), zap.Error(err))
+	}
+}
+
+// Enabled returns whether the audit log is enabled.
+func Enabled() bool {
+	return enabled.Load()
+}
+
+func write(e *Event) {
+	global.RLock()
+	defer global.RUnlock()
+	if !global.filter.match(e) {
+		return
+	}
+	for name, sink := range global.sinks {
+		if err := sink.Write(e); err != nil {
+			logutil.BgLogger().Warn("write audit event failed", zap.String("sink", name), zap.Error(err))
+		}
+	}
+}
+
+// LogConnection records the connection event of the name, err is the reason of the rejected connection.
+func LogConnection(name string, info *variable.ConnectionInfo, err error) {
+	if !Enabled() || info == nil {
+		return
+	}
+	e := &Event{
+		Time:   time.Now(),
+		Class:  config.AuditClassConnection,
+		Name:   name,
+		ConnID: info.ConnectionID,
+		User:   info.User,
+		Host:   info.ClientIP,
+		DB:     info.DB,
+	}
+	if name == EventDisconnect {
+		e.Duration = time.Duration(info.Duration * float64(time.Millisecond))
+	}
+	if err != nil {
+		e.Err = err.Error()
+	}
+	write(e)
+}
+
+// LogStmt records the statement executed by the session, label is the label of the statement and err is its error.
+// It should be called after the statement is finished, or failed to compile.
+func LogStmt(vars *variable.SessionVars, node ast.StmtNode, label string, err error) {
+	if !Enabled() || vars.InRestrictedSQL {
+		return
+	}
+	stmtCtx := vars.StmtCtx
+	normalizedSQL, digest := stmtCtx.SQLDigest()
+	e := &Event{
+		Time:         time.Now(),
+		Class:        StmtClass(node),
+		Name:         label,
+		ConnID:       vars.ConnectionID,
+		DB:           vars.CurrentDB,
+		Digest:       digest.String(),
+		AffectedRows: stmtCtx.AffectedRows(),
+		Duration:     time.Since(vars.StartTime) + vars.DurationParse,
+	}
+	if vars.User != nil {
+		e.User, e.Host = vars.User.Username, vars.User.Hostname
+	}
+	for _, tbl := range stmtCtx.Tables {
+		e.Tables = append(e.Tables, tbl.DB+"."+tbl.Table)
+	}
+	sort.Strings(e.Tables)
+	global.RLock()
+	redact := global.redact
+	global.RUnlock()
+	if redact {
+		e.SQL = normalizedSQL
+	} else if sensitiveStmt, ok := node.(ast.SensitiveStmtNode); ok {
+		e.SQL = sensitiveStmt.SecureText()
+	} else {
+		e.SQL = stmtCtx.OriginalSQL + vars.PreparedParams.String()
+	}
+	if err != nil {
+		e.Err = err.Error()
+	}
+	write(e)
+}
+
+// StmtClass returns the audit class of the statement.
+func StmtClass(node ast.StmtNode) string {
+	switch node.(type) {
+	case *ast.SelectStmt, *ast.SetOprStmt, *ast.ShowStmt, *ast.ExplainStmt, *ast.ExplainForStmt, *ast.TraceStmt:
+		return config.AuditClassQuery
+	case *ast.InsertStmt, *ast.UpdateStmt, *ast.DeleteStmt, *ast.LoadDataStmt:
+		return config.AuditClassDML
+	case *ast.CreateUserStmt, *ast.AlterUserStmt, *ast.DropUserStmt, *ast.RenameUserStmt, *ast.SetPwdStmt,
+		*ast.GrantStmt, *ast.GrantRoleStmt, *ast.GrantProxyStmt, *ast.RevokeStmt, *ast.RevokeRoleStmt,
+		*ast.SetRoleStmt, *ast.SetDefaultRoleStmt:
+		return config.AuditClassDCL
+	case ast.DDLNode:
+		return config.AuditClassDDL
+	}
+	return config.AuditClassOther
+}
@@ -0,0 +1,194 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/parser"
+	"github.com/pingcap/tidb/config"
+	"github.com/pingcap/tidb/domain"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/session"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/store/mockstore"
+	"github.com/pingcap/tidb/util/audit"
+	"github.com/pingcap/tidb/util/logutil"
+	"github.com/pingcap/tidb/util/testkit"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testAuditSuite{})
+
+type testAuditSuite struct {
+	store kv.Storage
+	dom   *domain.Domain
+}
+
+func (s *testAuditSuite) SetUpSuite(c *C) {
+	var err error
+	s.store, err = mockstore.NewMockStore()
+	c.Assert(err, IsNil)
+	session.DisableStats4Test()
+	s.dom, err = session.BootstrapSession(s.store)
+	c.Assert(err, IsNil)
+}
+
+func (s *testAuditSuite) TearDownSuite(c *C) {
+	s.dom.Close()
+	s.store.Close()
+}
+
+type memorySink struct {
+	sync.Mutex
+	events []*audit.Event
+}
+
+func (m *memorySink) Write(e *audit.Event) error {
+	m.Lock()
+	defer m.Unlock()
+	m.events = append(m.events, e)
+	return nil
+}
+
+func (m *memorySink) Close() error {
+	return nil
+}
+
+func (m *memorySink) take() []*audit.Event {
+	m.Lock()
+	defer m.Unlock()
+	events := m.events
+	m.events = nil
+	return events
+}
+
+func (s *testAuditSuite) TestStmtClass(c *C) {
+	cases := []struct {
+		sql   string
+		class string
+	}{
+		{"select 1", config.AuditClassQuery},
+		{"show tables", config.AuditClassQuery},
+		{"explain select 1", config.AuditClassQuery},
+		{"insert into t values (1)", config.AuditClassDML},
+		{"delete from t", config.AuditClassDML},
+		{"create table t (a int)", config.AuditClassDDL},
+		{"alter table t add column b int", config.AuditClassDDL},
+		{"create user u", config.AuditClassDCL},
+		{"grant select on *.* to u", config.AuditClassDCL},
+		{"set @a = 1", config.AuditClassOther},
+		{"begin", config.AuditClassOther},
+	}
+	p := parser.New()
+	for _, ca := range cases {
+		stmt, err := p.ParseOneStmt(ca.sql, "", "")
+		c.Assert(err, IsNil)
+		c.Assert(audit.StmtClass(stmt), Equals, ca.class, Commentf("sql: %s", ca.sql))
+	}
+}
+
+func (s *testAuditSuite) TestLogStmt(c *C) {
+	sink := &memorySink{}
+	c.Assert(audit.Setup(&config.Audit{Enable: true, Redact: true}), IsNil)
+	audit.RegisterSink("memory", sink)
+	defer audit.Close()
+
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("create table audit_t (a int)")
+	tk.MustExec("insert into audit_t values (1), (2)")
+	_, err := tk.Exec("select * from no_such_table")
+	c.Assert(err, NotNil)
+	events := sink.take()
+	c.Assert(events, HasLen, 4)
+	c.Assert(events[1].Class, Equals, config.AuditClassDDL)
+	c.Assert(events[2].Class, Equals, config.AuditClassDML)
+	c.Assert(events[2].Name, Equals, "Insert")
+	c.Assert(events[2].SQL, Equals, "insert into `audit_t` values ( ? ) , ( ? )")
+	c.Assert(events[2].Tables, DeepEquals, []string{"test.audit_t"})
+	c.Assert(events[2].AffectedRows, Equals, uint64(2))
+	c.Assert(events[2].Err, Equals, "")
+	// The statements failed to compile are recorded too.
+	c.Assert(events[3].Class, Equals, config.AuditClassQuery)
+	c.Assert(events[3].Err, Equals, "[schema:1146]Table 'test.no_such_table' doesn't exist")
+
+	// The sensitive statements are recorded without the passwords when not redacted.
+	c.Assert(audit.Setup(&config.Audit{Enable: true, Classes: []string{config.AuditClassDCL}}), IsNil)
+	tk.MustExec("create user audit_u identified by 'secret'")
+	tk.MustExec("select * from audit_t")
+	events = sink.take()
+	c.Assert(events, HasLen, 1)
+	c.Assert(strings.Contains(events[0].SQL, "secret"), IsFalse)
+
+	// Filter the statements by the databases they access.
+	c.Assert(audit.Setup(&config.Audit{Enable: true, Databases: []string{"mysql"}}), IsNil)
+	tk.MustExec("select * from audit_t")
+	tk.MustExec("select * from mysql.user")
+	events = sink.take()
+	c.Assert(events, HasLen, 1)
+	c.Assert(events[0].Tables, DeepEquals, []string{"mysql.user"})
+
+	// Filter the events by the users.
+	c.Assert(audit.Setup(&config.Audit{Enable: true, Users: []string{"audit_u"}}), IsNil)
+	tk.MustExec("select * from audit_t")
+	c.Assert(sink.take(), HasLen, 0)
+	audit.LogConnection(audit.EventConnect, &variable.ConnectionInfo{User: "audit_u", ClientIP: "127.0.0.1"}, nil)
+	events = sink.take()
+	c.Assert(events, HasLen, 1)
+	c.Assert(events[0].Class, Equals, config.AuditClassConnection)
+	c.Assert(events[0].Host, Equals, "127.0.0.1")
+
+	// Nothing is recorded once disabled.
+	c.Assert(audit.Setup(&config.Audit{Enable: false}), IsNil)
+	tk.MustExec("select * from audit_t")
+	c.Assert(sink.take(), HasLen, 0)
+}
+
+func (s *testAuditSuite) TestFileSink(c *C) {
+	dir, err := ioutil.TempDir("", "audit")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "audit.log")
+	cfg := &config.Audit{Enable: true, Redact: true, File: logutil.NewFileLogConfig(1)}
+	cfg.File.Filename = filename
+	c.Assert(audit.Setup(cfg), IsNil)
+
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("select 1")
+	audit.Close()
+
+	content, err := ioutil.ReadFile(filename)
+	c.Assert(err, IsNil)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	c.Assert(lines, HasLen, 2)
+	var event map[string]interface{}
+	c.Assert(json.Unmarshal([]byte(lines[1]), &event), IsNil)
+	c.Assert(event["class"], Equals, config.AuditClassQuery)
+	c.Assert(event["event"], Equals, "Select")
+	c.Assert(event["sql"], Equals, "select ?")
+	c.Assert(event["db"], Equals, "test")
+	c.Assert(event["time"], NotNil)
+}
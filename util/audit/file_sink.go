@@ -0,0 +1,71 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/util/logutil"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// FileSink writes the audit events to a rotated file, an event per line in JSON.
+type FileSink struct {
+	logger *zap.Logger
+}
+
+// NewFileSink creates a FileSink by the config of the file.
+func NewFileSink(cfg *logutil.FileLogConfig) (*FileSink, error) {
+	// Reuse the rotation of the log file, but always record the events regardless of the log level.
+	_, prop, err := log.InitLogger(&log.Config{Level: "info", File: cfg.FileLogConfig})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	encoder := zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+		TimeKey:        "time",
+		EncodeTime:     zapcore.ISO8601TimeEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
+		LineEnding:     zapcore.DefaultLineEnding,
+	})
+	core := zapcore.NewCore(encoder, prop.Syncer, zapcore.InfoLevel)
+	return &FileSink{logger: zap.New(core)}, nil
+}
+
+// Write implements the Sink interface.
+func (s *FileSink) Write(e *Event) error {
+	if ce := s.logger.Check(zapcore.InfoLevel, ""); ce != nil {
+		ce.Time = e.Time
+		ce.Write(
+			zap.String("class", e.Class),
+			zap.String("event", e.Name),
+			zap.Uint64("conn_id", e.ConnID),
+			zap.String("user", e.User),
+			zap.String("host", e.Host),
+			zap.String("db", e.DB),
+			zap.Strings("tables", e.Tables),
+			zap.String("sql", e.SQL),
+			zap.String("digest", e.Digest),
+			zap.Uint64("affected_rows", e.AffectedRows),
+			zap.Duration("duration", e.Duration),
+			zap.String("error", e.Err),
+		)
+	}
+	return nil
+}
+
+// Close implements the Sink interface.
+func (s *FileSink) Close() error {
+	return s.logger.Sync()
+}
@@ -511,27 +511,16 @@ func (c *Chunk) insert(rowIdx int, row Row) {
 }
 
 // Append appends rows in [begin, end) in another Chunk to a Chunk.
+// The columns are copied in batch, so the column types of the two Chunks must be the same.
 func (c *Chunk) Append(other *Chunk, begin, end int) {
-	for colID, src := range other.columns {
-		dst := c.columns[colID]
-		if src.isFixed() {
-			elemLen := len(src.elemBuf)
-			dst.data = append(dst.data, src.data[begin*elemLen:end*elemLen]...)
-		} else {
-			beginOffset, endOffset := src.offsets[begin], src.offsets[end]
-			dst.data = append(dst.data, src.data[beginOffset:endOffset]...)
-			lastOffset := dst.offsets[len(dst.offsets)-1]
-			for i := begin; i < end; i++ {
-				lastOffset += src.offsets[i+1] - src.offsets[i]
-				dst.offsets = append(dst.offsets, lastOffset)
-			}
-		}
-		for i := begin; i < end; i++ {
-			c.appendSel(colID)
-			dst.appendNullBitmap(!src.IsNull(i))
-			dst.length++
+	if c.sel != nil && len(c.columns) > 0 {
+		for i := c.columns[0].length; i < c.columns[0].length+end-begin; i++ {
+			c.sel = append(c.sel, i)
 		}
 	}
+	for colID, src := range other.columns {
+		c.columns[colID].appendRange(src, begin, end)
+	}
 	c.numVirtualRows += end - begin
 }
 
@@ -17,6 +17,7 @@ import (
 	"bytes"
 	"fmt"
 	"math"
+	"math/rand"
 	"os"
 	"strconv"
 	"strings"
@@ -209,6 +210,69 @@ func (s *testChunkSuite) TestAppend(c *check.C) {
 	}
 }
 
+func (s *testChunkSuite) TestAppendRandomly(c *check.C) {
+	fieldTypes := []*types.FieldType{
+		types.NewFieldType(mysql.TypeLonglong),
+		types.NewFieldType(mysql.TypeVarchar),
+		types.NewFieldType(mysql.TypeDouble),
+		types.NewFieldType(mysql.TypeNewDecimal),
+		types.NewFieldType(mysql.TypeJSON),
+	}
+	genChunk := func(numRows int) *Chunk {
+		chk := NewChunkWithCapacity(fieldTypes, numRows)
+		for i := 0; i < numRows; i++ {
+			for colIdx := range fieldTypes {
+				if rand.Intn(3) == 0 {
+					chk.AppendNull(colIdx)
+					continue
+				}
+				switch colIdx {
+				case 0:
+					chk.AppendInt64(colIdx, rand.Int63())
+				case 1:
+					chk.AppendString(colIdx, strings.Repeat("x", rand.Intn(10)))
+				case 2:
+					chk.AppendFloat64(colIdx, rand.Float64())
+				case 3:
+					chk.AppendMyDecimal(colIdx, types.NewDecFromInt(rand.Int63()))
+				case 4:
+					chk.AppendJSON(colIdx, json.CreateBinary(rand.Int63()))
+				}
+			}
+		}
+		return chk
+	}
+
+	// Chunk.Append must be equivalent to appending the rows one by one.
+	for i := 0; i < 1000; i++ {
+		dst, src := genChunk(rand.Intn(40)), genChunk(rand.Intn(40)+1)
+		if rand.Intn(4) == 0 {
+			src = dst
+			if src.NumRows() == 0 {
+				continue
+			}
+		}
+		begin := rand.Intn(src.NumRows() + 1)
+		end := begin + rand.Intn(src.NumRows()-begin+1)
+
+		expected := dst.CopyConstruct()
+		srcCopy := src.CopyConstruct()
+		for rowIdx := begin; rowIdx < end; rowIdx++ {
+			expected.AppendRow(srcCopy.GetRow(rowIdx))
+		}
+		dst.Append(src, begin, end)
+
+		c.Assert(dst.NumRows(), check.Equals, expected.NumRows())
+		for colIdx, col := range dst.columns {
+			expectedCol := expected.columns[colIdx]
+			c.Assert(col.length, check.Equals, expectedCol.length)
+			c.Assert(col.nullBitmap, check.BytesEquals, expectedCol.nullBitmap)
+			c.Assert(col.offsets, check.DeepEquals, expectedCol.offsets)
+			c.Assert(col.data, check.BytesEquals, expectedCol.data)
+		}
+	}
+}
+
 func (s *testChunkSuite) TestTruncateTo(c *check.C) {
 	fieldTypes := make([]*types.FieldType, 0, 3)
 	fieldTypes = append(fieldTypes, &types.FieldType{Tp: mysql.TypeFloat})
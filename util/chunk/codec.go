@@ -31,11 +31,21 @@ type Codec struct {
 	// fixed size for every element.
 	// NOTE: It's only used for decoding.
 	colTypes []*types.FieldType
+	// dictEncoding indicates the var-length columns may be encoded with dictionaries.
+	dictEncoding bool
 }
 
 // NewCodec creates a new Codec object for encode or decode a Chunk.
 func NewCodec(colTypes []*types.FieldType) *Codec {
-	return &Codec{colTypes}
+	return &Codec{colTypes: colTypes}
+}
+
+// NewDictCodec creates a new Codec object which encodes the var-length columns, such as the low-cardinality string
+// columns, with dictionaries if they're smaller than the standard layout, and decodes them to the standard layout.
+// It's used for the data written and read by TiDB itself, such as the spilled data. The data encoded by it can only
+// be decoded by the Codec created by NewDictCodec.
+func NewDictCodec(colTypes []*types.FieldType) *Codec {
+	return &Codec{colTypes: colTypes, dictEncoding: true}
 }
 
 // Encode encodes a Chunk to a byte slice.
@@ -65,6 +75,13 @@ func (c *Codec) encodeColumn(buffer []byte, col *Column) []byte {
 
 	// encode offsets.
 	if !col.isFixed() {
+		if c.dictEncoding {
+			if dict := buildColumnDict(col); dict != nil {
+				buffer = append(buffer, dictEncodedColumn)
+				return dict.encode(buffer)
+			}
+			buffer = append(buffer, plainEncodedColumn)
+		}
 		numOffsetBytes := (col.length + 1) * 8
 		offsetBytes := i64SliceToBytes(col.offsets)
 		buffer = append(buffer, offsetBytes[:numOffsetBytes]...)
@@ -129,6 +146,13 @@ func (c *Codec) decodeColumn(buffer []byte, col *Column, ordinal int) (remained
 	numFixedBytes := getFixedLen(c.colTypes[ordinal])
 	numDataBytes := int64(numFixedBytes * col.length)
 	if numFixedBytes == -1 {
+		if c.dictEncoding {
+			encoding := buffer[0]
+			buffer = buffer[1:]
+			if encoding == dictEncodedColumn {
+				return decodeDictColumn(buffer, col)
+			}
+		}
 		numOffsetBytes := (col.length + 1) * 8
 		col.offsets = bytesToI64Slice(buffer[:numOffsetBytes:numOffsetBytes])
 		buffer = buffer[numOffsetBytes:]
@@ -244,6 +268,10 @@ type Decoder struct {
 	intermChk    *Chunk
 	codec        *Codec
 	remainedRows int
+
+	// needFilter indicates the filter of DecodeSelected hasn't been evaluated on Decoder.intermChk.
+	needFilter bool
+	selected   []bool
 }
 
 // NewDecoder creates a new Decoder object for decode a Chunk.
@@ -270,6 +298,38 @@ func (c *Decoder) Decode(chk *Chunk) {
 func (c *Decoder) Reset(data []byte) {
 	c.codec.DecodeToChunk(data, c.intermChk)
 	c.remainedRows = c.intermChk.NumRows()
+	c.needFilter = true
+}
+
+// DecodeSelected decodes the remained rows of Decoder.intermChk which pass the filter, and appends them to chk until
+// chk is full. The filter is evaluated on Decoder.intermChk, which references the data being decoded without copying,
+// so only the columns referenced by the filter are accessed for all the rows, and the other columns are copied only
+// for the selected rows. It can't be mixed with Decode and ReuseIntermChk between two Resets.
+func (c *Decoder) DecodeSelected(chk *Chunk, filter func(*Chunk) ([]bool, error)) (err error) {
+	if c.needFilter {
+		c.selected, err = filter(c.intermChk)
+		if err != nil {
+			return err
+		}
+		c.needFilter = false
+	}
+	numRows := c.intermChk.NumRows()
+	rowIdx := numRows - c.remainedRows
+	for rowIdx < numRows && !chk.IsFull() {
+		if !c.selected[rowIdx] {
+			rowIdx++
+			continue
+		}
+		// Append the continuous selected rows in batch.
+		end := rowIdx + 1
+		for end < numRows && c.selected[end] && chk.NumRows()+end-rowIdx < chk.RequiredRows() {
+			end++
+		}
+		chk.Append(c.intermChk, rowIdx, end)
+		rowIdx = end
+	}
+	c.remainedRows = numRows - rowIdx
+	return nil
 }
 
 // IsFinished indicates whether Decoder.intermChk has been dried up.
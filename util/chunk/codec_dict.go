@@ -0,0 +1,120 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunk
+
+import (
+	"encoding/binary"
+)
+
+const (
+	plainEncodedColumn byte = iota
+	dictEncodedColumn
+)
+
+// maxDictSize is the max number of the distinct values of a dictionary, so the codes take at most 2 bytes.
+const maxDictSize = 1 << 16
+
+// columnDict is the dictionary encoding of a var-length column. The layout after the null bitmap is:
+// | number of the distinct values (4 bytes) | code width (1 byte) | offsets of the values | values | codes |
+// The code of a null element is 0, whose value is ignored.
+type columnDict struct {
+	offsets   []int64
+	data      []byte
+	codes     []uint16
+	codeWidth int
+}
+
+// buildColumnDict builds the dictionary of the column, it returns nil if the dictionary encoding isn't smaller than
+// the standard layout.
+func buildColumnDict(col *Column) *columnDict {
+	d := &columnDict{
+		offsets: make([]int64, 1, 8),
+		codes:   make([]uint16, col.length),
+	}
+	ids := make(map[string]uint16)
+	for i := 0; i < col.length; i++ {
+		if col.IsNull(i) {
+			continue
+		}
+		elem := col.GetBytes(i)
+		id, ok := ids[string(elem)]
+		if !ok {
+			if len(ids) == maxDictSize {
+				return nil
+			}
+			id = uint16(len(ids))
+			ids[string(elem)] = id
+			d.data = append(d.data, elem...)
+			d.offsets = append(d.offsets, int64(len(d.data)))
+		}
+		d.codes[i] = id
+	}
+	d.codeWidth = 1
+	if len(ids) > 1<<8 {
+		d.codeWidth = 2
+	}
+	plainSize := int64(col.length+1)*8 + col.offsets[col.length]
+	if d.encodedSize() >= plainSize {
+		return nil
+	}
+	return d
+}
+
+func (d *columnDict) encodedSize() int64 {
+	return 5 + int64(len(d.offsets))*8 + int64(len(d.data)) + int64(len(d.codes)*d.codeWidth)
+}
+
+func (d *columnDict) encode(buffer []byte) []byte {
+	var lenBuffer [4]byte
+	binary.LittleEndian.PutUint32(lenBuffer[:], uint32(len(d.offsets)-1))
+	buffer = append(buffer, lenBuffer[:4]...)
+	buffer = append(buffer, byte(d.codeWidth))
+	buffer = append(buffer, i64SliceToBytes(d.offsets)...)
+	buffer = append(buffer, d.data...)
+	for _, code := range d.codes {
+		if d.codeWidth == 1 {
+			buffer = append(buffer, byte(code))
+		} else {
+			buffer = append(buffer, byte(code), byte(code>>8))
+		}
+	}
+	return buffer
+}
+
+// decodeDictColumn decodes the dictionary encoded data of the column to the standard layout, the length and the null
+// bitmap of the column are already decoded. Unlike the standard layout, the data is copied from the buffer.
+func decodeDictColumn(buffer []byte, col *Column) (remained []byte) {
+	numValues := int(binary.LittleEndian.Uint32(buffer))
+	codeWidth := int(buffer[4])
+	buffer = buffer[5:]
+	numOffsetBytes := (numValues + 1) * 8
+	offsets := bytesToI64Slice(buffer[:numOffsetBytes:numOffsetBytes])
+	buffer = buffer[numOffsetBytes:]
+	values := buffer[:offsets[numValues]]
+	buffer = buffer[offsets[numValues]:]
+
+	col.offsets = make([]int64, 1, col.length+1)
+	col.data = make([]byte, 0, offsets[numValues])
+	for i := 0; i < col.length; i++ {
+		code := int(buffer[i*codeWidth])
+		if codeWidth == 2 {
+			code |= int(buffer[i*codeWidth+1]) << 8
+		}
+		if !col.IsNull(i) {
+			col.data = append(col.data, values[offsets[code]:offsets[code+1]]...)
+		}
+		col.offsets = append(col.offsets, int64(len(col.data)))
+	}
+	return buffer[col.length*codeWidth:]
+}
@@ -77,6 +77,89 @@ func (s *testCodecSuite) TestCodec(c *check.C) {
 	}
 }
 
+func (s *testCodecSuite) TestDecodeSelected(c *check.C) {
+	colTypes := []*types.FieldType{{Tp: mysql.TypeLonglong}, {Tp: mysql.TypeVarchar}}
+	numRows := 10
+	oldChk := NewChunkWithCapacity(colTypes, numRows)
+	for i := 0; i < numRows; i++ {
+		oldChk.AppendInt64(0, int64(i))
+		oldChk.AppendString(1, fmt.Sprintf("%d", i))
+	}
+	codec := NewCodec(colTypes)
+	decoder := NewDecoder(NewChunkWithCapacity(colTypes, 0), colTypes)
+	decoder.Reset(codec.Encode(oldChk))
+
+	filterCalls := 0
+	filter := func(chk *Chunk) ([]bool, error) {
+		filterCalls++
+		selected := make([]bool, chk.NumRows())
+		for i := range selected {
+			selected[i] = chk.GetRow(i).GetInt64(0)%3 != 0
+		}
+		return selected, nil
+	}
+	var got []int64
+	for !decoder.IsFinished() {
+		chk := NewChunkWithCapacity(colTypes, 4)
+		chk.SetRequiredRows(4, 4)
+		c.Assert(decoder.DecodeSelected(chk, filter), check.IsNil)
+		c.Assert(chk.NumRows() <= 4, check.IsTrue)
+		for i := 0; i < chk.NumRows(); i++ {
+			row := chk.GetRow(i)
+			c.Assert(row.GetString(1), check.Equals, fmt.Sprintf("%d", row.GetInt64(0)))
+			got = append(got, row.GetInt64(0))
+		}
+	}
+	c.Assert(got, check.DeepEquals, []int64{1, 2, 4, 5, 7, 8})
+	c.Assert(filterCalls, check.Equals, 1)
+}
+
+func (s *testCodecSuite) TestDictCodec(c *check.C) {
+	colTypes := []*types.FieldType{{Tp: mysql.TypeLonglong}, {Tp: mysql.TypeVarchar}, {Tp: mysql.TypeVarchar}, {Tp: mysql.TypeVarchar}}
+	numRows := 1000
+	chk := NewChunkWithCapacity(colTypes, numRows)
+	for i := 0; i < numRows; i++ {
+		chk.AppendInt64(0, int64(i))
+		// The low-cardinality column with nulls.
+		if i%7 == 0 {
+			chk.AppendNull(1)
+		} else {
+			chk.AppendString(1, fmt.Sprintf("status-%d", i%3))
+		}
+		// The column whose values are distinct.
+		chk.AppendString(2, fmt.Sprintf("%d", i))
+		// The column with more than 256 distinct values.
+		chk.AppendString(3, fmt.Sprintf("a long long value %d", i%300))
+	}
+
+	plain := NewCodec(colTypes).Encode(chk)
+	codec := NewDictCodec(colTypes)
+	buffer := codec.Encode(chk)
+	c.Assert(len(buffer) < len(plain), check.IsTrue)
+
+	newChk := NewChunkWithCapacity(colTypes, numRows)
+	remained := codec.DecodeToChunk(buffer, newChk)
+	c.Assert(remained, check.HasLen, 0)
+	c.Assert(newChk.NumRows(), check.Equals, numRows)
+	for i := 0; i < numRows; i++ {
+		row, expected := newChk.GetRow(i), chk.GetRow(i)
+		c.Assert(row.GetInt64(0), check.Equals, int64(i))
+		c.Assert(row.IsNull(1), check.Equals, expected.IsNull(1))
+		c.Assert(row.GetString(1), check.Equals, expected.GetString(1))
+		c.Assert(row.GetString(2), check.Equals, expected.GetString(2))
+		c.Assert(row.GetString(3), check.Equals, expected.GetString(3))
+	}
+	// The decoded columns are in the standard layout.
+	for i := 1; i < len(colTypes); i++ {
+		c.Assert(newChk.Column(i).offsets, check.DeepEquals, chk.Column(i).offsets)
+		c.Assert(newChk.Column(i).data, check.DeepEquals, chk.Column(i).data)
+	}
+
+	decoded, remained := codec.Decode(buffer)
+	c.Assert(remained, check.HasLen, 0)
+	c.Assert(decoded.GetRow(numRows-1).GetString(3), check.Equals, chk.GetRow(numRows-1).GetString(3))
+}
+
 func (s *testCodecSuite) TestEstimateTypeWidth(c *check.C) {
 	var colType *types.FieldType
 
@@ -127,7 +210,7 @@ func BenchmarkDecode(b *testing.B) {
 		}
 	}
 	chk := NewChunkWithCapacity(colTypes, numRows)
-	codec := &Codec{colTypes}
+	codec := NewCodec(colTypes)
 	buffer := codec.Encode(chk)
 
 	b.ResetTimer()
@@ -155,7 +238,7 @@ func BenchmarkDecodeToChunk(b *testing.B) {
 			Tp: mysql.TypeLonglong,
 		}
 	}
-	codec := &Codec{colTypes}
+	codec := NewCodec(colTypes)
 	buffer := codec.Encode(chk)
 
 	b.ResetTimer()
@@ -186,7 +269,7 @@ func BenchmarkDecodeToChunkWithVariableType(b *testing.B) {
 		chk.AppendMyDecimal(4, types.NewDecFromStringForTest(str))
 		chk.AppendJSON(5, json.CreateBinary(str))
 	}
-	codec := &Codec{colTypes}
+	codec := NewCodec(colTypes)
 	buffer := codec.Encode(chk)
 
 	chk.Reset()
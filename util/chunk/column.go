@@ -187,6 +187,61 @@ func (c *Column) appendMultiSameNullBitmap(notNull bool, num int) {
 	c.nullBitmap[len(c.nullBitmap)-1] &= bitMask
 }
 
+// appendRange appends the rows in [begin, end) of src into this Column. It copies the data, the offsets and the null
+// bitmap in batch instead of cell by cell, so the type size of src must be the same with this Column.
+func (c *Column) appendRange(src *Column, begin, end int) {
+	if c.typeSize() != src.typeSize() {
+		panic(fmt.Sprintf("append column with type size %d to column with type size %d", src.typeSize(), c.typeSize()))
+	}
+	if begin >= end {
+		return
+	}
+	if src.isFixed() {
+		elemLen := len(src.elemBuf)
+		c.data = append(c.data, src.data[begin*elemLen:end*elemLen]...)
+	} else {
+		beginOffset, endOffset := src.offsets[begin], src.offsets[end]
+		c.data = append(c.data, src.data[beginOffset:endOffset]...)
+		delta := c.offsets[len(c.offsets)-1] - beginOffset
+		for i := begin + 1; i <= end; i++ {
+			c.offsets = append(c.offsets, src.offsets[i]+delta)
+		}
+	}
+	c.appendNullBitmapRange(src, begin, end)
+}
+
+// appendNullBitmapRange appends the null bits of the rows in [begin, end) of src, and increases the length of this
+// Column by end - begin. src may be this Column itself.
+func (c *Column) appendNullBitmapRange(src *Column, begin, end int) {
+	// Append bit by bit until the bitmap is byte aligned.
+	for ; begin < end && c.length&7 != 0; begin++ {
+		c.appendNullBitmap(!src.IsNull(begin))
+		c.length++
+	}
+	num := end - begin
+	if num == 0 {
+		return
+	}
+	startByte, shift := begin>>3, uint(begin&7)
+	numBytes := (num + 7) >> 3
+	if shift == 0 {
+		c.nullBitmap = append(c.nullBitmap, src.nullBitmap[startByte:startByte+numBytes]...)
+	} else {
+		for i := startByte; i < startByte+numBytes; i++ {
+			b := src.nullBitmap[i] >> shift
+			if i+1 < len(src.nullBitmap) {
+				b |= src.nullBitmap[i+1] << (8 - shift)
+			}
+			c.nullBitmap = append(c.nullBitmap, b)
+		}
+	}
+	// Clear the bits after the appended rows in the last byte.
+	if remaining := uint(num & 7); remaining != 0 {
+		c.nullBitmap[len(c.nullBitmap)-1] &= byte(1<<remaining) - 1
+	}
+	c.length += num
+}
+
 // AppendNull appends a null value into this Column.
 func (c *Column) AppendNull() {
 	c.appendNullBitmap(false)
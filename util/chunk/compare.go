@@ -20,6 +20,7 @@ import (
 	"github.com/pingcap/parser/mysql"
 	"github.com/pingcap/tidb/types"
 	"github.com/pingcap/tidb/types/json"
+	"github.com/pingcap/tidb/util/collate"
 )
 
 // CompareFunc is a function to compare the two values in Row, the two columns must have the same type.
@@ -83,17 +84,14 @@ func cmpUint64(l Row, lCol int, r Row, rCol int) int {
 }
 
 func genCmpStringFunc(collation string) func(l Row, lCol int, r Row, rCol int) int {
+	compare := collate.GetCompareFunc(collation)
 	return func(l Row, lCol int, r Row, rCol int) int {
-		return cmpStringWithCollationInfo(l, lCol, r, rCol, collation)
-	}
-}
-
-func cmpStringWithCollationInfo(l Row, lCol int, r Row, rCol int, collation string) int {
-	lNull, rNull := l.IsNull(lCol), r.IsNull(rCol)
-	if lNull || rNull {
-		return cmpNull(lNull, rNull)
+		lNull, rNull := l.IsNull(lCol), r.IsNull(rCol)
+		if lNull || rNull {
+			return cmpNull(lNull, rNull)
+		}
+		return compare(l.GetString(lCol), r.GetString(rCol))
 	}
-	return types.CompareString(l.GetString(lCol), r.GetString(rCol), collation)
 }
 
 func cmpFloat32(l Row, lCol int, r Row, rCol int) int {
@@ -16,6 +16,7 @@ package chunk
 import (
 	"io"
 	"os"
+	"sort"
 	"strconv"
 	"sync"
 
@@ -32,13 +33,23 @@ import (
 // ListInDisk represents a slice of chunks storing in temporary disk.
 type ListInDisk struct {
 	fieldTypes []*types.FieldType
-	// offsets stores the offsets in disk of all RowPtr,
-	// the offset of one RowPtr is offsets[RowPtr.ChkIdx][RowPtr.RowIdx].
-	offsets [][]int64
+	// usedCols stores the ascending indexes of the columns persisted in disk,
+	// it maps the i-th column in disk to the usedCols[i]-th column of the row.
+	// All the columns are persisted if it is nil.
+	usedCols []int
+	// rowBegins stores the index of the first row of each chunk among all the rows, and the number of all the rows
+	// at last. The offset in disk of a RowPtr is stored in the index file at 8*(rowBegins[RowPtr.ChkIdx]+RowPtr.RowIdx),
+	// so only O(1) memory is used for each chunk.
+	rowBegins []int64
 	// offWrite is the current offset for writing.
 	offWrite int64
 
-	disk          *os.File
+	disk *os.File
+	// index is the index file which stores the offsets in disk of all the rows in order, they are not encrypted
+	// since they're only the positions in the disk file.
+	index *os.File
+	// offsetsCache caches the offsets of the recently used chunks.
+	offsetsCache  offsetsCache
 	w             io.WriteCloser
 	bufFlushMutex sync.RWMutex
 	diskTracker   *disk.Tracker // track disk usage.
@@ -51,18 +62,35 @@ type ListInDisk struct {
 	ctrCipher *encrypt.CtrCipher
 }
 
-var defaultChunkListInDiskPath = "chunk.ListInDisk"
+var (
+	defaultChunkListInDiskPath      = "chunk.ListInDisk"
+	defaultChunkListInDiskIndexPath = "chunk.ListInDiskIndex"
+)
 
 // NewListInDisk creates a new ListInDisk with field types.
 func NewListInDisk(fieldTypes []*types.FieldType) *ListInDisk {
 	l := &ListInDisk{
 		fieldTypes: fieldTypes,
+		rowBegins:  []int64{0},
 		// TODO(fengliyuan): set the quota of disk usage.
 		diskTracker: disk.NewTracker(memory.LabelForChunkListInDisk, -1),
 	}
 	return l
 }
 
+// NewListInDiskWithUsedCols creates a new ListInDisk which only persists the
+// columns in usedCols. The rows read from it keep the layout of fieldTypes,
+// the pruned columns are filled with NULL.
+func NewListInDiskWithUsedCols(fieldTypes []*types.FieldType, usedCols []int) *ListInDisk {
+	l := NewListInDisk(fieldTypes)
+	if usedCols != nil && len(usedCols) < len(fieldTypes) {
+		l.usedCols = make([]int, len(usedCols))
+		copy(l.usedCols, usedCols)
+		sort.Ints(l.usedCols)
+	}
+	return l
+}
+
 func (l *ListInDisk) initDiskFile() (err error) {
 	err = disk.CheckAndInitTempDir()
 	if err != nil {
@@ -85,7 +113,12 @@ func (l *ListInDisk) initDiskFile() (err error) {
 	l.checksumWriter = checksum.NewWriter(underlying)
 	l.w = l.checksumWriter
 	l.bufFlushMutex = sync.RWMutex{}
-	return
+	return l.initIndexFile()
+}
+
+func (l *ListInDisk) initIndexFile() (err error) {
+	l.index, err = os.CreateTemp(config.GetGlobalConfig().TempStoragePath, defaultChunkListInDiskIndexPath+strconv.Itoa(l.diskTracker.Label()))
+	return errors2.Trace(err)
 }
 
 // Len returns the number of rows in ListInDisk
@@ -139,23 +172,28 @@ func (l *ListInDisk) Add(chk *Chunk) (err error) {
 			return
 		}
 	}
-	chk2 := chunkInDisk{Chunk: chk, offWrite: l.offWrite}
+	chk2 := chunkInDisk{Chunk: chk, offWrite: l.offWrite, usedCols: l.usedCols}
 	n, err := chk2.WriteTo(l.w)
 	l.offWrite += n
 	if err != nil {
 		return
 	}
-	l.offsets = append(l.offsets, chk2.getOffsetsOfRows())
 	l.diskTracker.Consume(n)
+	n2, err := l.index.Write(i64SliceToBytes(chk2.getOffsetsOfRows()))
+	if err != nil {
+		return errors2.Trace(err)
+	}
+	l.diskTracker.Consume(int64(n2))
 	l.numRowsInDisk += chk.NumRows()
+	l.rowBegins = append(l.rowBegins, int64(l.numRowsInDisk))
 	return
 }
 
 // GetChunk gets a Chunk from the ListInDisk by chkIdx.
 func (l *ListInDisk) GetChunk(chkIdx int) (*Chunk, error) {
-	chk := NewChunkWithCapacity(l.fieldTypes, l.NumRowsOfChunk(chkIdx))
-	offsets := l.offsets[chkIdx]
-	for rowIdx := range offsets {
+	numRows := l.NumRowsOfChunk(chkIdx)
+	chk := NewChunkWithCapacity(l.fieldTypes, numRows)
+	for rowIdx := 0; rowIdx < numRows; rowIdx++ {
 		row, err := l.GetRow(RowPtr{ChkIdx: uint32(chkIdx), RowIdx: uint32(rowIdx)})
 		if err != nil {
 			return chk, err
@@ -165,35 +203,222 @@ func (l *ListInDisk) GetChunk(chkIdx int) (*Chunk, error) {
 	return chk, nil
 }
 
+// GetChunkWithCols gets a Chunk from the ListInDisk by chkIdx, only the
+// columns in colIdxs are deserialized, the other columns are NULL. The data
+// of the columns are appended to the columns of the chunk directly.
+func (l *ListInDisk) GetChunkWithCols(chkIdx int, colIdxs []int) (*Chunk, error) {
+	numRows := l.NumRowsOfChunk(chkIdx)
+	chk := NewChunkWithCapacity(l.fieldTypes, numRows)
+	required := l.requiredColumns(colIdxs, true)
+	for rowIdx := 0; rowIdx < numRows; rowIdx++ {
+		cells, err := l.readCells(RowPtr{ChkIdx: uint32(chkIdx), RowIdx: uint32(rowIdx)}, required)
+		if err != nil {
+			return chk, err
+		}
+		for colIdx, col := range chk.columns {
+			if cells.sizes[colIdx] == -1 {
+				col.AppendNull()
+				continue
+			}
+			col.data = append(col.data, cells.cells[colIdx]...)
+			if col.isFixed() {
+				col.appendNullBitmap(true)
+				col.length++
+			} else {
+				col.finishAppendVar()
+			}
+		}
+	}
+	return chk, nil
+}
+
 // GetRow gets a Row from the ListInDisk by RowPtr.
 func (l *ListInDisk) GetRow(ptr RowPtr) (row Row, err error) {
+	r, err := l.getRowReader(ptr)
 	if err != nil {
-		return
-	}
-	off := l.offsets[ptr.ChkIdx][ptr.RowIdx]
-	var underlying io.ReaderAt = l.disk
-	if l.ctrCipher != nil {
-		underlying = NewReaderWithCache(encrypt.NewReader(l.disk, l.ctrCipher), l.cipherWriter.GetCache(), l.cipherWriter.GetCacheDataOffset())
+		return row, err
 	}
-	checksumReader := NewReaderWithCache(checksum.NewReader(underlying), l.checksumWriter.GetCache(), l.checksumWriter.GetCacheDataOffset())
-	r := io.NewSectionReader(checksumReader, off, l.offWrite-off)
-	format := rowInDisk{numCol: len(l.fieldTypes)}
+	format := rowInDisk{numCol: l.numColsInDisk()}
 	_, err = format.ReadFrom(r)
 	if err != nil {
 		return row, err
 	}
+	if l.usedCols != nil {
+		format.expandPrunedColumns(l.usedCols, len(l.fieldTypes))
+	}
 	row = format.toMutRow(l.fieldTypes).ToRow()
 	return row, err
 }
 
+// GetRowWithCols gets a Row from the ListInDisk by RowPtr, only the columns
+// in colIdxs are read from disk and deserialized, the other columns of the
+// returned row are NULL. The offset of each column is calculated from the
+// sizes of the columns stored in the head of the row, so the data of the
+// columns not required is skipped.
+func (l *ListInDisk) GetRowWithCols(ptr RowPtr, colIdxs []int) (row Row, err error) {
+	cells, err := l.readCells(ptr, l.requiredColumns(colIdxs, true))
+	if err != nil {
+		return row, err
+	}
+	return cells.toRow(l.fieldTypes), nil
+}
+
+// GetRestOfRow gets a Row from the ListInDisk by RowPtr. The columns in colIdxs
+// are taken from row, which is returned by GetRowWithCols with the same ptr and
+// colIdxs, and only the other columns are read from disk. So the columns of a
+// row read by GetRowWithCols and then GetRestOfRow are read from disk once.
+func (l *ListInDisk) GetRestOfRow(ptr RowPtr, colIdxs []int, row Row) (Row, error) {
+	cells, err := l.readCells(ptr, l.requiredColumns(colIdxs, false))
+	if err != nil {
+		return Row{}, err
+	}
+	for _, colIdx := range colIdxs {
+		if !row.IsNull(colIdx) {
+			raw := row.GetRaw(colIdx)
+			cells.sizes[colIdx] = int64(len(raw))
+			cells.cells[colIdx] = raw
+		}
+	}
+	return cells.toRow(l.fieldTypes), nil
+}
+
+// requiredColumns returns whether each column is required. The columns in
+// colIdxs are required if in is true, otherwise the other columns are.
+func (l *ListInDisk) requiredColumns(colIdxs []int, in bool) []bool {
+	required := make([]bool, len(l.fieldTypes))
+	if !in {
+		for colIdx := range required {
+			required[colIdx] = true
+		}
+	}
+	for _, colIdx := range colIdxs {
+		required[colIdx] = in
+	}
+	return required
+}
+
+// rowCells is the data of the columns of a row read from disk.
+type rowCells struct {
+	// sizes[i] is the size of the data of the i-th column, it's -1 if the
+	// column is NULL or not read.
+	sizes []int64
+	cells [][]byte
+}
+
+func (c *rowCells) toRow(fields []*types.FieldType) Row {
+	format := diskFormatRow{
+		sizesOfColumns: c.sizes,
+		cells:          make([][]byte, 0, len(c.cells)),
+	}
+	for colIdx, size := range c.sizes {
+		if size != -1 {
+			format.cells = append(format.cells, c.cells[colIdx])
+		}
+	}
+	return format.toMutRow(fields).ToRow()
+}
+
+// readCells reads the data of the required columns of the row pointed by ptr.
+func (l *ListInDisk) readCells(ptr RowPtr, required []bool) (cells rowCells, err error) {
+	r, err := l.getRowReader(ptr)
+	if err != nil {
+		return cells, err
+	}
+	numColsInDisk := l.numColsInDisk()
+	b := make([]byte, 8*numColsInDisk)
+	_, err = io.ReadFull(r, b)
+	if err != nil {
+		return cells, err
+	}
+	sizesInDisk := bytesToI64Slice(b)
+	// offsetsInDisk[i] is the offset of the data of the i-th column in disk.
+	offsetsInDisk := make([]int64, numColsInDisk)
+	off := int64(len(b))
+	for i, size := range sizesInDisk {
+		offsetsInDisk[i] = off
+		if size > 0 {
+			off += size
+		}
+	}
+
+	cells = rowCells{
+		sizes: make([]int64, len(l.fieldTypes)),
+		cells: make([][]byte, len(l.fieldTypes)),
+	}
+	for colIdx := range l.fieldTypes {
+		cells.sizes[colIdx] = -1
+		if !required[colIdx] {
+			continue
+		}
+		idxInDisk := colIdx
+		if l.usedCols != nil {
+			idxInDisk = sort.SearchInts(l.usedCols, colIdx)
+			if idxInDisk == len(l.usedCols) || l.usedCols[idxInDisk] != colIdx {
+				// The column is pruned when spilling.
+				continue
+			}
+		}
+		size := sizesInDisk[idxInDisk]
+		if size == -1 {
+			continue
+		}
+		cell := make([]byte, size)
+		n, err := r.ReadAt(cell, offsetsInDisk[idxInDisk])
+		// The last column of the last row may be read with io.EOF.
+		if err != nil && !(err == io.EOF && int64(n) == size) {
+			return cells, errors2.Trace(err)
+		}
+		cells.sizes[colIdx] = size
+		cells.cells[colIdx] = cell
+	}
+	return cells, nil
+}
+
+// getRowReader returns a reader which reads the row pointed by ptr from the start.
+func (l *ListInDisk) getRowReader(ptr RowPtr) (*io.SectionReader, error) {
+	off, err := l.getOffset(ptr)
+	if err != nil {
+		return nil, err
+	}
+	var underlying io.ReaderAt = l.disk
+	if l.ctrCipher != nil {
+		underlying = NewReaderWithCache(encrypt.NewReader(l.disk, l.ctrCipher), l.cipherWriter.GetCache(), l.cipherWriter.GetCacheDataOffset())
+	}
+	checksumReader := NewReaderWithCache(checksum.NewReader(underlying), l.checksumWriter.GetCache(), l.checksumWriter.GetCacheDataOffset())
+	return io.NewSectionReader(checksumReader, off, l.offWrite-off), nil
+}
+
+// getOffset returns the offset in disk of the row pointed by ptr. The offsets of the chunk are read from the index
+// file by a single read and cached if they're not cached.
+func (l *ListInDisk) getOffset(ptr RowPtr) (int64, error) {
+	if offsets, ok := l.offsetsCache.get(ptr.ChkIdx); ok {
+		return offsets[ptr.RowIdx], nil
+	}
+	b := make([]byte, 8*l.NumRowsOfChunk(int(ptr.ChkIdx)))
+	if _, err := l.index.ReadAt(b, 8*l.rowBegins[ptr.ChkIdx]); err != nil {
+		return 0, errors2.Trace(err)
+	}
+	offsets := bytesToI64Slice(b)
+	l.offsetsCache.put(ptr.ChkIdx, offsets)
+	return offsets[ptr.RowIdx], nil
+}
+
+// numColsInDisk returns the number of the columns persisted in disk.
+func (l *ListInDisk) numColsInDisk() int {
+	if l.usedCols != nil {
+		return len(l.usedCols)
+	}
+	return len(l.fieldTypes)
+}
+
 // NumRowsOfChunk returns the number of rows of a chunk in the ListInDisk.
 func (l *ListInDisk) NumRowsOfChunk(chkID int) int {
-	return len(l.offsets[chkID])
+	return int(l.rowBegins[chkID+1] - l.rowBegins[chkID])
 }
 
 // NumChunks returns the number of chunks in the ListInDisk.
 func (l *ListInDisk) NumChunks() int {
-	return len(l.offsets)
+	return len(l.rowBegins) - 1
 }
 
 // Close releases the disk resource.
@@ -203,9 +428,57 @@ func (l *ListInDisk) Close() error {
 		terror.Call(l.disk.Close)
 		terror.Log(os.Remove(l.disk.Name()))
 	}
+	if l.index != nil {
+		terror.Call(l.index.Close)
+		terror.Log(os.Remove(l.index.Name()))
+	}
 	return nil
 }
 
+// offsetsCacheSize is the number of the chunks whose offsets are cached.
+const offsetsCacheSize = 16
+
+// offsetsCache is a LRU cache of the offsets of the chunks, it's used concurrently by GetRow.
+type offsetsCache struct {
+	sync.Mutex
+	// entries are ordered from the most recently used one.
+	entries []offsetsCacheEntry
+}
+
+type offsetsCacheEntry struct {
+	chkIdx  uint32
+	offsets []int64
+}
+
+func (c *offsetsCache) get(chkIdx uint32) ([]int64, bool) {
+	c.Lock()
+	defer c.Unlock()
+	for i, entry := range c.entries {
+		if entry.chkIdx == chkIdx {
+			copy(c.entries[1:i+1], c.entries[:i])
+			c.entries[0] = entry
+			return entry.offsets, true
+		}
+	}
+	return nil, false
+}
+
+func (c *offsetsCache) put(chkIdx uint32, offsets []int64) {
+	c.Lock()
+	defer c.Unlock()
+	for _, entry := range c.entries {
+		// The offsets may be put by another goroutine.
+		if entry.chkIdx == chkIdx {
+			return
+		}
+	}
+	if len(c.entries) < offsetsCacheSize {
+		c.entries = append(c.entries, offsetsCacheEntry{})
+	}
+	copy(c.entries[1:], c.entries)
+	c.entries[0] = offsetsCacheEntry{chkIdx: chkIdx, offsets: offsets}
+}
+
 // chunkInDisk represents a chunk in disk format. Each row of the chunk
 // is serialized and in sequence ordered. The format of each row is like
 // the struct diskFormatRow, put size of each column first, then the
@@ -220,10 +493,13 @@ func (l *ListInDisk) Close() error {
 // [data of row1 column0], [data of row1 column1], [data of row1 column2]
 //
 // If a column of a row is null, the size of it is -1 and the data is empty.
+// If usedCols is not nil, only the columns in it are serialized.
 type chunkInDisk struct {
 	*Chunk
 	// offWrite is the current offset for writing.
 	offWrite int64
+	// usedCols stores the indexes of the columns to be serialized.
+	usedCols []int
 	// offsetsOfRows stores the offset of each row.
 	offsetsOfRows []int64
 }
@@ -236,7 +512,7 @@ func (chk *chunkInDisk) WriteTo(w io.Writer) (written int64, err error) {
 	chk.offsetsOfRows = make([]int64, 0, numRows)
 	var format *diskFormatRow
 	for rowIdx := 0; rowIdx < numRows; rowIdx++ {
-		format = convertFromRow(chk.GetRow(rowIdx), format)
+		format = convertFromRow(chk.GetRow(rowIdx), format, chk.usedCols)
 		chk.offsetsOfRows = append(chk.offsetsOfRows, chk.offWrite+written)
 
 		n, err = rowInDisk{diskFormatRow: *format}.WriteTo(w)
@@ -315,9 +591,13 @@ type diskFormatRow struct {
 }
 
 // convertFromRow serializes one row of chunk to diskFormatRow, then
-// we can use diskFormatRow to write to disk.
-func convertFromRow(row Row, reuse *diskFormatRow) (format *diskFormatRow) {
+// we can use diskFormatRow to write to disk. Only the columns in usedCols
+// are serialized if usedCols is not nil.
+func convertFromRow(row Row, reuse *diskFormatRow, usedCols []int) (format *diskFormatRow) {
 	numCols := row.Chunk().NumCols()
+	if usedCols != nil {
+		numCols = len(usedCols)
+	}
 	if reuse != nil {
 		format = reuse
 		format.sizesOfColumns = format.sizesOfColumns[:0]
@@ -328,7 +608,11 @@ func convertFromRow(row Row, reuse *diskFormatRow) (format *diskFormatRow) {
 			cells:          make([][]byte, 0, numCols),
 		}
 	}
-	for colIdx := 0; colIdx < numCols; colIdx++ {
+	for i := 0; i < numCols; i++ {
+		colIdx := i
+		if usedCols != nil {
+			colIdx = usedCols[i]
+		}
 		if row.IsNull(colIdx) {
 			format.sizesOfColumns = append(format.sizesOfColumns, -1)
 		} else {
@@ -340,6 +624,20 @@ func convertFromRow(row Row, reuse *diskFormatRow) (format *diskFormatRow) {
 	return
 }
 
+// expandPrunedColumns expands the sizes of the columns read from disk to
+// numCols columns, the columns not in usedCols are regarded as null. The
+// order of cells is kept since usedCols is ascending.
+func (format *diskFormatRow) expandPrunedColumns(usedCols []int, numCols int) {
+	sizes := make([]int64, numCols)
+	for i := range sizes {
+		sizes[i] = -1
+	}
+	for i, colIdx := range usedCols {
+		sizes[colIdx] = format.sizesOfColumns[i]
+	}
+	format.sizesOfColumns = sizes
+}
+
 // toMutRow deserializes diskFormatRow to MutRow.
 func (format *diskFormatRow) toMutRow(fields []*types.FieldType) MutRow {
 	chk := &Chunk{columns: make([]*Column, 0, len(format.sizesOfColumns))}
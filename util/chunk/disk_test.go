@@ -92,6 +92,122 @@ func (s *testChunkSuite) TestListInDisk(c *check.C) {
 	}
 }
 
+func (s *testChunkSuite) TestListInDiskIndex(c *check.C) {
+	numChk, numRow := offsetsCacheSize*2, 3
+	chks, fields := initChunks(numChk, numRow)
+	l := NewListInDisk(fields)
+	defer func() {
+		c.Check(l.Close(), check.IsNil)
+		_, err := os.Stat(l.index.Name())
+		c.Check(os.IsNotExist(err), check.IsTrue)
+	}()
+	for _, chk := range chks {
+		c.Assert(l.Add(chk), check.IsNil)
+	}
+	c.Assert(l.Len(), check.Equals, numChk*numRow)
+	c.Assert(l.NumRowsOfChunk(numChk-1), check.Equals, numRow)
+
+	// Read the rows of the chunks in a random order, the offsets of the recently used chunks are cached.
+	for _, chkIdx := range rand.Perm(numChk) {
+		for _, rowIdx := range rand.Perm(numRow) {
+			row, err := l.GetRow(RowPtr{ChkIdx: uint32(chkIdx), RowIdx: uint32(rowIdx)})
+			c.Assert(err, check.IsNil)
+			c.Assert(row.GetDatumRow(fields), check.DeepEquals, chks[chkIdx].GetRow(rowIdx).GetDatumRow(fields))
+		}
+		offsets, ok := l.offsetsCache.get(uint32(chkIdx))
+		c.Assert(ok, check.IsTrue)
+		c.Assert(offsets, check.HasLen, numRow)
+	}
+	c.Assert(l.offsetsCache.entries, check.HasLen, offsetsCacheSize)
+}
+
+func (s *testChunkSuite) TestListInDiskWithUsedCols(c *check.C) {
+	numChk, numRow := 2, 2
+	chks, fields := initChunks(numChk, numRow)
+	full := NewListInDisk(fields)
+	defer full.Close()
+	l := NewListInDiskWithUsedCols(fields, []int{4, 0})
+	defer l.Close()
+	for _, chk := range chks {
+		c.Assert(full.Add(chk), check.IsNil)
+		c.Assert(l.Add(chk), check.IsNil)
+	}
+	c.Check(l.NumChunks(), check.Equals, numChk)
+	c.Check(l.Len(), check.Equals, numChk*numRow)
+	c.Check(l.GetDiskTracker().BytesConsumed() < full.GetDiskTracker().BytesConsumed(), check.IsTrue)
+
+	for chkIdx := 0; chkIdx < numChk; chkIdx++ {
+		for rowIdx := 0; rowIdx < numRow; rowIdx++ {
+			row, err := l.GetRow(RowPtr{ChkIdx: uint32(chkIdx), RowIdx: uint32(rowIdx)})
+			c.Assert(err, check.IsNil)
+			c.Assert(row.Len(), check.Equals, len(fields))
+			expected := chks[chkIdx].GetRow(rowIdx)
+			c.Check(row.GetString(0), check.Equals, expected.GetString(0))
+			c.Check(row.IsNull(1), check.IsTrue)
+			c.Check(row.IsNull(2), check.IsTrue)
+			c.Check(row.IsNull(3), check.IsTrue)
+			c.Check(row.IsNull(4), check.Equals, expected.IsNull(4))
+			if !expected.IsNull(4) {
+				c.Check(row.GetJSON(4).String(), check.Equals, expected.GetJSON(4).String())
+			}
+		}
+		chk, err := l.GetChunk(chkIdx)
+		c.Assert(err, check.IsNil)
+		c.Check(chk.NumRows(), check.Equals, numRow)
+		c.Check(chk.NumCols(), check.Equals, len(fields))
+	}
+}
+
+func (s *testChunkSuite) TestListInDiskGetRowWithCols(c *check.C) {
+	numChk, numRow := 2, 3
+	chks, fields := initChunks(numChk, numRow)
+	l := NewListInDisk(fields)
+	defer l.Close()
+	pruned := NewListInDiskWithUsedCols(fields, []int{0, 3, 4})
+	defer pruned.Close()
+	for _, chk := range chks {
+		c.Assert(l.Add(chk), check.IsNil)
+		c.Assert(pruned.Add(chk), check.IsNil)
+	}
+
+	colIdxs := []int{4, 1, 3}
+	for chkIdx := 0; chkIdx < numChk; chkIdx++ {
+		for rowIdx := 0; rowIdx < numRow; rowIdx++ {
+			ptr := RowPtr{ChkIdx: uint32(chkIdx), RowIdx: uint32(rowIdx)}
+			expected := chks[chkIdx].GetRow(rowIdx)
+			for _, list := range []*ListInDisk{l, pruned} {
+				row, err := list.GetRowWithCols(ptr, colIdxs)
+				c.Assert(err, check.IsNil)
+				c.Assert(row.Len(), check.Equals, len(fields))
+				c.Check(row.IsNull(0), check.IsTrue)
+				c.Check(row.IsNull(1), check.IsTrue)
+				c.Check(row.IsNull(2), check.IsTrue)
+				c.Check(row.GetInt64(3), check.Equals, expected.GetInt64(3))
+				c.Check(row.IsNull(4), check.Equals, expected.IsNull(4))
+				if !expected.IsNull(4) {
+					c.Check(row.GetJSON(4).String(), check.Equals, expected.GetJSON(4).String())
+				}
+
+				// The rest of the row is read, and the columns read above are reused.
+				rest, err := list.GetRestOfRow(ptr, colIdxs, row)
+				c.Assert(err, check.IsNil)
+				full, err := list.GetRow(ptr)
+				c.Assert(err, check.IsNil)
+				c.Check(rest.GetDatumRow(fields), check.DeepEquals, full.GetDatumRow(fields))
+			}
+		}
+		chk, err := l.GetChunkWithCols(chkIdx, []int{0, 3})
+		c.Assert(err, check.IsNil)
+		c.Assert(chk.NumRows(), check.Equals, numRow)
+		for rowIdx := 0; rowIdx < numRow; rowIdx++ {
+			c.Check(chk.GetRow(rowIdx).GetString(0), check.Equals, chks[chkIdx].GetRow(rowIdx).GetString(0))
+			c.Check(chk.GetRow(rowIdx).IsNull(1), check.IsTrue)
+			c.Check(chk.GetRow(rowIdx).GetInt64(3), check.Equals, chks[chkIdx].GetRow(rowIdx).GetInt64(3))
+			c.Check(chk.GetRow(rowIdx).IsNull(4), check.IsTrue)
+		}
+	}
+}
+
 func BenchmarkListInDiskAdd(b *testing.B) {
 	numChk, numRow := 1, 2
 	chks, fields := initChunks(numChk, numRow)
@@ -151,7 +267,7 @@ func newListInDiskWriteDisk(fieldTypes []*types.FieldType) (*listInDiskWriteDisk
 	}
 	l.disk = disk
 	l.w = disk
-	return &l, nil
+	return &l, l.initIndexFile()
 }
 
 func (l *listInDiskWriteDisk) GetRow(ptr RowPtr) (row Row, err error) {
@@ -159,7 +275,10 @@ func (l *listInDiskWriteDisk) GetRow(ptr RowPtr) (row Row, err error) {
 	if err != nil {
 		return
 	}
-	off := l.offsets[ptr.ChkIdx][ptr.RowIdx]
+	off, err := l.getOffset(ptr)
+	if err != nil {
+		return
+	}
 
 	r := io.NewSectionReader(l.disk, off, l.offWrite-off)
 	format := rowInDisk{numCol: len(l.fieldTypes)}
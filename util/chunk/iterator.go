@@ -310,9 +310,11 @@ func (it *iterator4RowPtr) Error() error {
 	return nil
 }
 
-// NewIterator4RowContainer create a new iterator for RowContainer
+// NewIterator4RowContainer create a new iterator for RowContainer. The rows are iterated in the order they're added,
+// no matter whether the RowContainer is spilled before or during the iteration, since the spilled chunks keep their
+// indexes. The rows are read chunk by chunk, so the spilled rows of a chunk are read from disk together.
 func NewIterator4RowContainer(c *RowContainer) *iterator4RowContainer {
-	return &iterator4RowContainer{c: c}
+	return &iterator4RowContainer{c: c, curChkIdx: -1}
 }
 
 type iterator4RowContainer struct {
@@ -320,6 +322,11 @@ type iterator4RowContainer struct {
 	chkIdx int
 	rowIdx int
 	err    error
+
+	// curChk is the chunk of curChkIdx read from the RowContainer. If the RowContainer is spilled after it's read
+	// from memory, it's still valid, since the spilled chunks are not reused.
+	curChk    *Chunk
+	curChkIdx int
 }
 
 // Len implements the Iterator interface.
@@ -338,6 +345,8 @@ func (it *iterator4RowContainer) setNextPtr() {
 // Begin implements the Iterator interface.
 func (it *iterator4RowContainer) Begin() Row {
 	it.chkIdx, it.rowIdx = 0, -1
+	// The RowContainer may be reset and refilled since the last iteration.
+	it.curChk, it.curChkIdx = nil, -1
 	return it.Next()
 }
 
@@ -356,13 +365,16 @@ func (it *iterator4RowContainer) Current() Row {
 	if it.rowIdx < 0 || it.chkIdx >= it.c.NumChunks() {
 		return it.End()
 	}
-	row, err := it.c.GetRow(RowPtr{uint32(it.chkIdx), uint32(it.rowIdx)})
-	if err != nil {
-		it.err = err
-		it.ReachEnd()
-		return it.End()
+	if it.curChkIdx != it.chkIdx {
+		chk, err := it.c.GetChunk(it.chkIdx)
+		if err != nil {
+			it.err = err
+			it.ReachEnd()
+			return it.End()
+		}
+		it.curChk, it.curChkIdx = chk, it.chkIdx
 	}
-	return row
+	return it.curChk.GetRow(it.rowIdx)
 }
 
 // End implements the Iterator interface.
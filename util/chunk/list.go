@@ -135,6 +135,9 @@ func (l *List) allocChunk() (chk *Chunk) {
 
 // GetRow gets a Row from the list by RowPtr.
 func (l *List) GetRow(ptr RowPtr) Row {
+	if VerifyEnabled {
+		l.verifyRowPtr(ptr)
+	}
 	chk := l.chunks[ptr.ChkIdx]
 	return chk.GetRow(int(ptr.RowIdx))
 }
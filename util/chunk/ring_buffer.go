@@ -0,0 +1,144 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunk
+
+import (
+	"unsafe"
+
+	"github.com/pingcap/tidb/util/memory"
+)
+
+const sizeofRow = int64(unsafe.Sizeof(Row{}))
+
+// RingBuffer keeps the rows of a sliding window, such as the frames of window functions. The rows are appended at
+// the tail and evicted from the head without copying the rows behind, and they are indexed by the logical row number,
+// which is the number of the rows appended before it.
+// RingBuffer only references the rows, the memory of the referenced chunks is tracked until all the rows of a chunk
+// are evicted, so the rows must be appended chunk by chunk.
+// The buffer is doubled when it's full, so it's bounded by the largest window instead of all the appended rows.
+type RingBuffer struct {
+	rows   []Row
+	head   int
+	length int
+	// begin is the logical row number of the head.
+	begin uint64
+
+	// chunks are the chunks referenced by the rows in order, with their memory usage consumed.
+	chunks     []chunkMemUsage
+	memTracker *memory.Tracker
+}
+
+type chunkMemUsage struct {
+	chk *Chunk
+	mem int64
+}
+
+// NewRingBuffer creates a new RingBuffer with the initial capacity.
+func NewRingBuffer(capacity int) *RingBuffer {
+	if capacity < 1 {
+		capacity = 1
+	}
+	r := &RingBuffer{
+		rows:       make([]Row, capacity),
+		memTracker: memory.NewTracker(memory.LabelForRingBuffer, -1),
+	}
+	r.memTracker.Consume(sizeofRow * int64(capacity))
+	return r
+}
+
+// GetMemTracker returns the memory tracker of this RingBuffer.
+func (r *RingBuffer) GetMemTracker() *memory.Tracker {
+	return r.memTracker
+}
+
+// Len returns the number of rows in the RingBuffer.
+func (r *RingBuffer) Len() int {
+	return r.length
+}
+
+// Begin returns the logical row number of the first row in the RingBuffer.
+func (r *RingBuffer) Begin() uint64 {
+	return r.begin
+}
+
+// End returns the logical row number after the last row in the RingBuffer.
+func (r *RingBuffer) End() uint64 {
+	return r.begin + uint64(r.length)
+}
+
+// Append appends a row at the tail of the RingBuffer.
+func (r *RingBuffer) Append(row Row) {
+	if r.length == len(r.rows) {
+		r.grow()
+	}
+	r.rows[(r.head+r.length)%len(r.rows)] = row
+	r.length++
+	if n := len(r.chunks); n == 0 || r.chunks[n-1].chk != row.c {
+		mem := row.c.MemoryUsage()
+		r.chunks = append(r.chunks, chunkMemUsage{chk: row.c, mem: mem})
+		r.memTracker.Consume(mem)
+	}
+}
+
+func (r *RingBuffer) grow() {
+	rows := make([]Row, 2*len(r.rows))
+	n := copy(rows, r.rows[r.head:])
+	copy(rows[n:], r.rows[:r.head])
+	r.memTracker.Consume(sizeofRow * int64(len(rows)-len(r.rows)))
+	r.rows = rows
+	r.head = 0
+}
+
+// GetRow returns the row with the logical row number, which must be in [Begin(), End()).
+func (r *RingBuffer) GetRow(rowNum uint64) Row {
+	return r.rows[(r.head+int(rowNum-r.begin))%len(r.rows)]
+}
+
+// GetRows appends the rows with the logical row numbers in [begin, end) to rows and returns it.
+func (r *RingBuffer) GetRows(begin, end uint64, rows []Row) []Row {
+	if begin >= end {
+		return rows
+	}
+	start := (r.head + int(begin-r.begin)) % len(r.rows)
+	stop := start + int(end-begin)
+	if stop <= len(r.rows) {
+		return append(rows, r.rows[start:stop]...)
+	}
+	rows = append(rows, r.rows[start:]...)
+	return append(rows, r.rows[:stop-len(r.rows)]...)
+}
+
+// EvictTo evicts the rows before the logical row number from the head.
+func (r *RingBuffer) EvictTo(rowNum uint64) {
+	if rowNum > r.End() {
+		rowNum = r.End()
+	}
+	for ; r.begin < rowNum; r.begin++ {
+		row := r.rows[r.head]
+		r.rows[r.head] = Row{}
+		r.head = (r.head + 1) % len(r.rows)
+		r.length--
+		// Release the chunk once its last row is evicted.
+		if r.length == 0 || r.rows[r.head].c != row.c {
+			r.memTracker.Consume(-r.chunks[0].mem)
+			r.chunks[0] = chunkMemUsage{}
+			r.chunks = r.chunks[1:]
+		}
+	}
+}
+
+// Reset evicts all the rows, the logical row number isn't reset.
+func (r *RingBuffer) Reset() {
+	r.EvictTo(r.End())
+}
@@ -0,0 +1,76 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunk
+
+import (
+	"github.com/pingcap/check"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/tidb/types"
+)
+
+func (s *testChunkSuite) TestRingBuffer(c *check.C) {
+	fieldTypes := []*types.FieldType{types.NewFieldType(mysql.TypeLonglong)}
+	genChunk := func(begin, numRows int) *Chunk {
+		chk := NewChunkWithCapacity(fieldTypes, numRows)
+		for i := begin; i < begin+numRows; i++ {
+			chk.AppendInt64(0, int64(i))
+		}
+		return chk
+	}
+
+	r := NewRingBuffer(2)
+	emptyMem := r.GetMemTracker().BytesConsumed()
+	chk1, chk2, chk3 := genChunk(0, 3), genChunk(3, 2), genChunk(5, 4)
+	for _, chk := range []*Chunk{chk1, chk2} {
+		for i := 0; i < chk.NumRows(); i++ {
+			r.Append(chk.GetRow(i))
+		}
+	}
+	c.Assert(r.Len(), check.Equals, 5)
+	c.Assert(r.Begin(), check.Equals, uint64(0))
+	c.Assert(r.End(), check.Equals, uint64(5))
+	ringMem := sizeofRow * 8
+	c.Assert(r.GetMemTracker().BytesConsumed(), check.Equals, ringMem+chk1.MemoryUsage()+chk2.MemoryUsage())
+
+	// The chunk is released after all of its rows are evicted.
+	r.EvictTo(2)
+	c.Assert(r.Len(), check.Equals, 3)
+	c.Assert(r.GetRow(2).GetInt64(0), check.Equals, int64(2))
+	c.Assert(r.GetMemTracker().BytesConsumed(), check.Equals, ringMem+chk1.MemoryUsage()+chk2.MemoryUsage())
+	r.EvictTo(3)
+	c.Assert(r.GetMemTracker().BytesConsumed(), check.Equals, ringMem+chk2.MemoryUsage())
+
+	// The rows wrap around the end of the buffer.
+	for i := 0; i < chk3.NumRows(); i++ {
+		r.Append(chk3.GetRow(i))
+	}
+	c.Assert(r.Begin(), check.Equals, uint64(3))
+	c.Assert(r.End(), check.Equals, uint64(9))
+	c.Assert(r.GetMemTracker().BytesConsumed(), check.Equals, ringMem+chk2.MemoryUsage()+chk3.MemoryUsage())
+	for i := uint64(3); i < 9; i++ {
+		c.Assert(r.GetRow(i).GetInt64(0), check.Equals, int64(i))
+	}
+	rows := r.GetRows(4, 9, nil)
+	c.Assert(rows, check.HasLen, 5)
+	for i, row := range rows {
+		c.Assert(row.GetInt64(0), check.Equals, int64(i+4))
+	}
+	c.Assert(r.GetRows(5, 5, nil), check.HasLen, 0)
+
+	r.Reset()
+	c.Assert(r.Len(), check.Equals, 0)
+	c.Assert(r.Begin(), check.Equals, uint64(9))
+	c.Assert(r.GetMemTracker().BytesConsumed(), check.Equals, ringMem)
+	c.Assert(emptyMem, check.Equals, sizeofRow*2)
+}
@@ -42,8 +42,12 @@ type RowContainer struct {
 	}
 
 	fieldType []*types.FieldType
-	chunkSize int
-	numRow    int
+	// spillUsedCols stores the indexes of the columns needed by the upper
+	// operators, only these columns are persisted when spilling. All the
+	// columns are persisted if it is nil.
+	spillUsedCols []int
+	chunkSize     int
+	numRow        int
 
 	memTracker  *memory.Tracker
 	diskTracker *disk.Tracker
@@ -60,6 +64,13 @@ func NewRowContainer(fieldType []*types.FieldType, chunkSize int) *RowContainer
 	return rc
 }
 
+// SetSpillUsedCols sets the columns needed by the upper operators, the other
+// columns are pruned when spilling and read back as NULL. It should be called
+// before any data is added.
+func (c *RowContainer) SetSpillUsedCols(usedCols []int) {
+	c.spillUsedCols = usedCols
+}
+
 // SpillToDisk spills data to disk. This function may be called in parallel.
 func (c *RowContainer) SpillToDisk() {
 	c.m.Lock()
@@ -79,7 +90,7 @@ func (c *RowContainer) SpillToDisk() {
 	}
 	var err error
 	N := c.m.records.NumChunks()
-	c.m.recordsInDisk = NewListInDisk(c.m.records.FieldTypes())
+	c.m.recordsInDisk = NewListInDiskWithUsedCols(c.m.records.FieldTypes(), c.spillUsedCols)
 	c.m.recordsInDisk.diskTracker.AttachTo(c.diskTracker)
 	for i := 0; i < N; i++ {
 		chk := c.m.records.GetChunk(i)
@@ -203,6 +214,44 @@ func (c *RowContainer) GetRow(ptr RowPtr) (Row, error) {
 	return c.m.records.GetRow(ptr), nil
 }
 
+// GetRowWithCols returns the row the ptr pointed to. If the RowContainer has
+// spilled, only the columns in colIdxs are read from disk and the others are
+// NULL, otherwise the whole row is returned.
+func (c *RowContainer) GetRowWithCols(ptr RowPtr, colIdxs []int) (Row, error) {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	if c.alreadySpilled() {
+		if c.m.spillError != nil {
+			return Row{}, c.m.spillError
+		}
+		return c.m.recordsInDisk.GetRowWithCols(ptr, colIdxs)
+	}
+	return c.m.records.GetRow(ptr), nil
+}
+
+// GetRestOfRow returns the row the ptr pointed to. If the RowContainer has
+// spilled, the columns in colIdxs are taken from row, which is returned by
+// GetRowWithCols with the same ptr and colIdxs, and only the other columns are
+// read from disk, otherwise the whole row is returned.
+func (c *RowContainer) GetRestOfRow(ptr RowPtr, colIdxs []int, row Row) (Row, error) {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	if c.alreadySpilled() {
+		if c.m.spillError != nil {
+			return Row{}, c.m.spillError
+		}
+		return c.m.recordsInDisk.GetRestOfRow(ptr, colIdxs, row)
+	}
+	return c.m.records.GetRow(ptr), nil
+}
+
+// AlreadySpilled indicates that records have spilled out into disk. It's thread-safe.
+func (c *RowContainer) AlreadySpilled() bool {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	return c.alreadySpilled()
+}
+
 // GetMemTracker returns the memory tracker in records, panics if the RowContainer has already spilled.
 func (c *RowContainer) GetMemTracker() *memory.Tracker {
 	return c.memTracker
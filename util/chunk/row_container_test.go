@@ -87,6 +87,38 @@ func (r *rowContainerTestSuite) TestSel(c *check.C) {
 	c.Assert(rc.memTracker.MaxConsumed(), check.Greater, int64(0))
 }
 
+func (r *rowContainerTestSuite) TestIteratorSpilledDuringIteration(c *check.C) {
+	fields := []*types.FieldType{types.NewFieldType(mysql.TypeLonglong)}
+	sz, numChk := 4, 5
+	rc := NewRowContainer(fields, sz)
+	defer func() {
+		c.Assert(rc.Close(), check.IsNil)
+	}()
+	for i := 0; i < numChk; i++ {
+		chk := NewChunkWithCapacity(fields, sz)
+		for j := 0; j < sz; j++ {
+			chk.AppendInt64(0, int64(i*sz+j))
+		}
+		c.Assert(rc.Add(chk), check.IsNil)
+	}
+
+	it := NewIterator4RowContainer(rc)
+	var got []int64
+	for row := it.Begin(); row != it.End(); row = it.Next() {
+		got = append(got, row.GetInt64(0))
+		// Spill in the middle of a chunk, the rows are still iterated in order.
+		if len(got) == sz+2 {
+			rc.SpillToDisk()
+			c.Assert(rc.AlreadySpilledSafeForTest(), check.IsTrue)
+		}
+	}
+	c.Assert(it.Error(), check.IsNil)
+	c.Assert(got, check.HasLen, sz*numChk)
+	for i, v := range got {
+		c.Assert(v, check.Equals, int64(i))
+	}
+}
+
 func (r *rowContainerTestSuite) TestSpillAction(c *check.C) {
 	sz := 4
 	fields := []*types.FieldType{types.NewFieldType(mysql.TypeLonglong)}
@@ -0,0 +1,95 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunk
+
+import (
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/util/israce"
+)
+
+// VerifyEnabled indicates whether the invariants of the chunks are verified where they are passed between the
+// operators, and the row pointers are checked when they are dereferenced. It's only enabled in the race builds,
+// so the memory corruption bugs panic where they happen in the tests without slowing down the release builds.
+const VerifyEnabled = israce.RaceEnabled
+
+// Verify checks the invariants of the chunk: all the columns have the same number of rows, the selected rows are
+// in the columns, and the data, offsets and null bitmap of each column match its number of rows.
+func (c *Chunk) Verify() error {
+	if len(c.columns) == 0 {
+		if c.numVirtualRows < 0 {
+			return errors.Errorf("the chunk has %d virtual rows", c.numVirtualRows)
+		}
+		return nil
+	}
+	numRows := c.columns[0].length
+	for i, col := range c.columns {
+		if col.length != numRows {
+			return errors.Errorf("column %d has %d rows but column 0 has %d rows", i, col.length, numRows)
+		}
+		if err := col.verify(); err != nil {
+			return errors.Annotatef(err, "column %d", i)
+		}
+	}
+	for i, idx := range c.sel {
+		if idx < 0 || idx >= numRows {
+			return errors.Errorf("the selected row %d at %d is out of the %d rows", idx, i, numRows)
+		}
+	}
+	return nil
+}
+
+func (c *Column) verify() error {
+	if c.length < 0 {
+		return errors.Errorf("the column has %d rows", c.length)
+	}
+	if len(c.nullBitmap) < (c.length+7)>>3 {
+		return errors.Errorf("the null bitmap has %d bytes for %d rows", len(c.nullBitmap), c.length)
+	}
+	if c.isFixed() {
+		if len(c.data) != c.length*len(c.elemBuf) {
+			return errors.Errorf("the data has %d bytes for %d rows of %d bytes", len(c.data), c.length, len(c.elemBuf))
+		}
+		return nil
+	}
+	if c.length == 0 && len(c.offsets) == 0 {
+		return nil
+	}
+	if len(c.offsets) != c.length+1 {
+		return errors.Errorf("the column has %d offsets for %d rows", len(c.offsets), c.length)
+	}
+	if c.offsets[0] != 0 {
+		return errors.Errorf("the first offset is %d", c.offsets[0])
+	}
+	for i := 1; i < len(c.offsets); i++ {
+		if c.offsets[i] < c.offsets[i-1] {
+			return errors.Errorf("the offset %d at %d is less than the previous one %d", c.offsets[i], i, c.offsets[i-1])
+		}
+	}
+	if c.offsets[c.length] != int64(len(c.data)) {
+		return errors.Errorf("the last offset is %d but the data has %d bytes", c.offsets[c.length], len(c.data))
+	}
+	return nil
+}
+
+// verifyRowPtr checks whether the row pointer points to a row in the list.
+func (l *List) verifyRowPtr(ptr RowPtr) {
+	if int(ptr.ChkIdx) >= len(l.chunks) || int(ptr.RowIdx) >= l.chunks[ptr.ChkIdx].NumRows() {
+		numRows := 0
+		if int(ptr.ChkIdx) < len(l.chunks) {
+			numRows = l.chunks[ptr.ChkIdx].NumRows()
+		}
+		panic(errors.Errorf("the row pointer (%d, %d) is out of the list with %d chunks, the chunk has %d rows",
+			ptr.ChkIdx, ptr.RowIdx, len(l.chunks), numRows))
+	}
+}
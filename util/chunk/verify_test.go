@@ -0,0 +1,75 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunk
+
+import (
+	"github.com/pingcap/check"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/tidb/types"
+)
+
+func (s *testChunkSuite) TestVerify(c *check.C) {
+	fieldTypes := []*types.FieldType{types.NewFieldType(mysql.TypeLonglong), types.NewFieldType(mysql.TypeVarString)}
+	newValidChunk := func() *Chunk {
+		chk := NewChunkWithCapacity(fieldTypes, 4)
+		for i := 0; i < 10; i++ {
+			chk.AppendInt64(0, int64(i))
+			if i%3 == 0 {
+				chk.AppendNull(1)
+			} else {
+				chk.AppendString(1, "abc")
+			}
+		}
+		return chk
+	}
+	chk := newValidChunk()
+	c.Assert(chk.Verify(), check.IsNil)
+	chk.SetSel([]int{1, 9})
+	c.Assert(chk.Verify(), check.IsNil)
+	c.Assert(NewChunkWithCapacity(fieldTypes, 4).Verify(), check.IsNil)
+
+	chk.SetSel([]int{10})
+	c.Assert(chk.Verify(), check.ErrorMatches, ".*the selected row 10 at 0 is out of the 10 rows.*")
+
+	chk = newValidChunk()
+	chk.AppendInt64(0, 10)
+	c.Assert(chk.Verify(), check.ErrorMatches, "column 1 has 10 rows but column 0 has 11 rows")
+
+	chk = newValidChunk()
+	chk.columns[0].data = chk.columns[0].data[:16]
+	c.Assert(chk.Verify(), check.ErrorMatches, "column 0: the data has 16 bytes for 10 rows of 8 bytes")
+
+	chk = newValidChunk()
+	chk.columns[1].offsets[2] = 7
+	c.Assert(chk.Verify(), check.ErrorMatches, "column 1: the offset 6 at 3 is less than the previous one 7")
+
+	chk = newValidChunk()
+	chk.columns[1].data = chk.columns[1].data[:3]
+	c.Assert(chk.Verify(), check.ErrorMatches, "column 1: the last offset is 18 but the data has 3 bytes")
+
+	chk = newValidChunk()
+	chk.columns[1].nullBitmap = chk.columns[1].nullBitmap[:1]
+	c.Assert(chk.Verify(), check.ErrorMatches, "column 1: the null bitmap has 1 bytes for 10 rows")
+}
+
+func (s *testChunkSuite) TestVerifyRowPtr(c *check.C) {
+	l := NewList([]*types.FieldType{types.NewFieldType(mysql.TypeLonglong)}, 2, 2)
+	for i := 0; i < 3; i++ {
+		row := MutRowFromValues(int64(i)).ToRow()
+		l.AppendRow(row)
+	}
+	l.verifyRowPtr(RowPtr{ChkIdx: 1, RowIdx: 0})
+	c.Assert(func() { l.verifyRowPtr(RowPtr{ChkIdx: 1, RowIdx: 1}) }, check.PanicMatches, ".*the chunk has 1 rows.*")
+	c.Assert(func() { l.verifyRowPtr(RowPtr{ChkIdx: 2, RowIdx: 0}) }, check.PanicMatches, ".*out of the list with 2 chunks.*")
+}
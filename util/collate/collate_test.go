@@ -46,6 +46,7 @@ func testCompareTable(table []compareTable, collate string, c *C) {
 	for i, t := range table {
 		comment := Commentf("%d %v %v", i, t.Left, t.Right)
 		c.Assert(GetCollator(collate).Compare(t.Left, t.Right), Equals, t.Expect, comment)
+		c.Assert(GetCompareFunc(collate)(t.Left, t.Right), Equals, t.Expect, comment)
 	}
 }
 
@@ -0,0 +1,103 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collate
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// CompareFunc compares two strings in a collation, it returns -1, 0 or 1 like Collator.Compare.
+type CompareFunc func(a, b string) int
+
+// GetCompareFunc returns the comparison specialized for the collation. The comparisons of the binary, the
+// general_ci and the unicode_ci collations don't dispatch through the Collator interface, and they skip the
+// common prefix of the strings with the word sized comparisons, so the callers comparing many strings should
+// get the function once instead of calling GetCollator for every pair.
+func GetCompareFunc(collate string) CompareFunc {
+	switch c := GetCollator(collate).(type) {
+	case *binCollator:
+		return strings.Compare
+	case *binPaddingCollator:
+		return compareBinPadding
+	case *generalCICollator:
+		return compareGeneralCI
+	case *unicodeCICollator:
+		return compareUnicodeCI
+	default:
+		return c.Compare
+	}
+}
+
+func compareBinPadding(a, b string) int {
+	return strings.Compare(truncateTailingSpace(a), truncateTailingSpace(b))
+}
+
+func compareGeneralCI(a, b string) int {
+	i := commonPrefixLen(a, b)
+	a = truncateTailingSpace(a[i:])
+	b = truncateTailingSpace(b[i:])
+	ai, bi := 0, 0
+	for ai < len(a) && bi < len(b) {
+		// The weights of the ASCII characters are looked up without decoding.
+		if ca, cb := a[ai], b[bi]; ca < utf8.RuneSelf && cb < utf8.RuneSelf {
+			if ca != cb && plane00[ca] != plane00[cb] {
+				return sign(int(plane00[ca]) - int(plane00[cb]))
+			}
+			ai++
+			bi++
+			continue
+		}
+		var r1, r2 rune
+		r1, ai = decodeRune(a, ai)
+		r2, bi = decodeRune(b, bi)
+		cmp := int(convertRuneGeneralCI(r1)) - int(convertRuneGeneralCI(r2))
+		if cmp != 0 {
+			return sign(cmp)
+		}
+	}
+	return sign((len(a) - ai) - (len(b) - bi))
+}
+
+var unicodeCI = &unicodeCICollator{}
+
+func compareUnicodeCI(a, b string) int {
+	i := commonPrefixLen(a, b)
+	return unicodeCI.Compare(a[i:], b[i:])
+}
+
+// commonPrefixLen returns the length of the common prefix of a and b, which ends at the boundary of the
+// runes and doesn't end with spaces. The weights of the runes in the common prefix are the same, so the prefix
+// can be skipped by the collations whose weights are decided by each rune. The trailing spaces are excluded,
+// since they may be a part of the padding which is truncated before the comparisons.
+func commonPrefixLen(a, b string) int {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	i := 0
+	for i+8 <= n && a[i:i+8] == b[i:i+8] {
+		i += 8
+	}
+	for i < n && a[i] == b[i] {
+		i++
+	}
+	for i > 0 && ((i < len(a) && !utf8.RuneStart(a[i])) || (i < len(b) && !utf8.RuneStart(b[i]))) {
+		i--
+	}
+	for i > 0 && a[i-1] == ' ' {
+		i--
+	}
+	return i
+}
@@ -33,6 +33,8 @@ import (
 type ExecDetails struct {
 	CalleeAddress    string
 	CopTime          time.Duration
+	MPPTime          time.Duration
+	MPPTasks         int
 	BackoffTime      time.Duration
 	LockKeysDuration time.Duration
 	BackoffSleep     map[string]time.Duration
@@ -42,6 +44,8 @@ type ExecDetails struct {
 	LockKeysDetail   *util.LockKeysDetails
 	ScanDetail       *util.ScanDetail
 	TimeDetail       util.TimeDetail
+	// CommitMode is the protocol used to commit the transaction, such as 2pc, async_commit and 1pc.
+	CommitMode string
 }
 
 type stmtExecDetailKeyType struct{}
@@ -52,6 +56,10 @@ var StmtExecDetailKey = stmtExecDetailKeyType{}
 // StmtExecDetails contains stmt level execution detail info.
 type StmtExecDetails struct {
 	WriteSQLRespDuration time.Duration
+	// WriteSQLRespRows and WriteSQLRespBytes are the number of the rows, and the bytes of their packets, written
+	// to the client.
+	WriteSQLRespRows  int64
+	WriteSQLRespBytes int64
 }
 
 const (
@@ -742,6 +750,8 @@ func (e *RuntimeStatsWithConcurrencyInfo) Merge(_ RuntimeStats) {
 type RuntimeStatsWithCommit struct {
 	Commit   *util.CommitDetails
 	LockKeys *util.LockKeysDetails
+	// CommitMode is the protocol used to commit the transaction, it's empty if it's unknown.
+	CommitMode string
 }
 
 // Tp implements the RuntimeStats interface.
@@ -768,11 +778,14 @@ func (e *RuntimeStatsWithCommit) Merge(rs RuntimeStats) {
 		}
 		e.LockKeys.Merge(tmp.LockKeys)
 	}
+	if tmp.CommitMode != "" {
+		e.CommitMode = tmp.CommitMode
+	}
 }
 
 // Clone implements the RuntimeStats interface.
 func (e *RuntimeStatsWithCommit) Clone() RuntimeStats {
-	newRs := RuntimeStatsWithCommit{}
+	newRs := RuntimeStatsWithCommit{CommitMode: e.CommitMode}
 	if e.Commit != nil {
 		newRs.Commit = e.Commit.Clone()
 	}
@@ -837,6 +850,10 @@ func (e *RuntimeStatsWithCommit) String() string {
 			buf.WriteString(", txn_retry:")
 			buf.WriteString(strconv.FormatInt(int64(e.Commit.TxnRetry), 10))
 		}
+		if e.CommitMode != "" {
+			buf.WriteString(", mode:")
+			buf.WriteString(e.CommitMode)
+		}
 		buf.WriteString("}")
 	}
 	if e.LockKeys != nil {
@@ -18,9 +18,11 @@ import (
 	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/sessionctx/stmtctx"
 	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/memory"
+	"github.com/pingcap/tidb/util/runaway"
 	"github.com/pingcap/tidb/util/testleak"
 )
 
@@ -77,3 +79,69 @@ func (s *testSuite) TestLogFormat(c *C) {
 	logFields = genLogFields(costTime, info)
 	c.Assert(logFields[6].String, Equals, "select * from table where `a` > ?")
 }
+
+type mockSessionManager struct {
+	util.SessionManager
+	killed []uint64
+}
+
+func (sm *mockSessionManager) Kill(connectionID uint64, query bool) {
+	sm.killed = append(sm.killed, connectionID)
+}
+
+func (s *testSuite) TestCheckRunaway(c *C) {
+	defer config.RestoreFunc()()
+	config.UpdateGlobal(func(conf *config.Config) {
+		conf.Runaway = config.RunawayRules{MaxMemory: 1 << 20, Action: config.RunawayActionDeprioritize}
+		conf.ResourceGroups = []config.ResourceGroup{
+			{Name: "olap", Runaway: config.RunawayRules{MaxExecutionTime: 1000, Action: config.RunawayActionKill}},
+		}
+	})
+	sm := &mockSessionManager{}
+	newInfo := func(id uint64, group string, memConsumed int64) *util.ProcessInfo {
+		mem := memory.NewTracker(0, -1)
+		mem.Consume(memConsumed)
+		return &util.ProcessInfo{
+			ID:        id,
+			User:      "root",
+			Info:      "select * from t where a > 1",
+			StatsInfo: func(interface{}) map[string]uint64 { return nil },
+			StmtCtx:   &stmtctx.StatementContext{MemTracker: mem, ResourceGroupName: group},
+			RedactSQL: true,
+		}
+	}
+
+	// The statement isn't runaway.
+	info := newInfo(1, "", 1<<10)
+	checkRunaway(sm, info, time.Hour)
+	c.Assert(info.RunawayTriggered.Load(), IsFalse)
+	c.Assert(info.StmtCtx.Deprioritized.Load(), IsFalse)
+
+	// The statement breaks the global memory rule.
+	info = newInfo(2, "", 1<<21)
+	checkRunaway(sm, info, time.Second)
+	c.Assert(info.RunawayTriggered.Load(), IsTrue)
+	c.Assert(info.StmtCtx.Deprioritized.Load(), IsTrue)
+	c.Assert(sm.killed, HasLen, 0)
+
+	// The statement in the group is checked by the rules of the group.
+	info = newInfo(3, "olap", 1<<21)
+	checkRunaway(sm, info, time.Second)
+	c.Assert(info.RunawayTriggered.Load(), IsFalse)
+	checkRunaway(sm, info, 2*time.Second)
+	c.Assert(info.RunawayTriggered.Load(), IsTrue)
+	c.Assert(sm.killed, DeepEquals, []uint64{3})
+
+	records := runaway.Records()
+	c.Assert(len(records) >= 2, IsTrue)
+	record := records[len(records)-1]
+	c.Assert(record.ConnID, Equals, uint64(3))
+	c.Assert(record.ResourceGroup, Equals, "olap")
+	c.Assert(record.Rule, Equals, "execution time 2s > 1s")
+	c.Assert(record.Action, Equals, config.RunawayActionKill)
+	c.Assert(record.Query, Equals, "select * from `t` where `a` > ?")
+	record = records[len(records)-2]
+	c.Assert(record.ConnID, Equals, uint64(2))
+	c.Assert(record.Rule, Equals, "memory 2097152 > 1048576")
+	c.Assert(record.Action, Equals, config.RunawayActionDeprioritize)
+}
@@ -22,9 +22,11 @@ import (
 
 	"github.com/pingcap/log"
 	"github.com/pingcap/parser"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/logutil"
+	"github.com/pingcap/tidb/util/runaway"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -73,6 +75,9 @@ func (eqh *Handle) Run() {
 				if info.MaxExecutionTime > 0 && costTime > time.Duration(info.MaxExecutionTime)*time.Millisecond {
 					sm.Kill(info.ID, true)
 				}
+				if !info.RunawayTriggered.Load() && info.StmtCtx != nil {
+					checkRunaway(sm, info, costTime)
+				}
 			}
 			threshold = atomic.LoadUint64(&variable.ExpensiveQueryTimeThreshold)
 
@@ -86,6 +91,49 @@ func (eqh *Handle) Run() {
 	}
 }
 
+// checkRunaway checks the statement by the runaway rules of its resource group, and takes the action of the rules
+// if it's runaway. The action is taken once for a statement.
+func checkRunaway(sm util.SessionManager, info *util.ProcessInfo, costTime time.Duration) {
+	rules := runaway.Rules(info.StmtCtx.ResourceGroupName)
+	if !rules.Enabled() {
+		return
+	}
+	var scanRows, memory int64
+	if scanDetail := info.StmtCtx.GetExecDetails().ScanDetail; scanDetail != nil {
+		scanRows = scanDetail.ProcessedKeys
+	}
+	if info.StmtCtx.MemTracker != nil {
+		memory = info.StmtCtx.MemTracker.BytesConsumed()
+	}
+	rule := runaway.Check(rules, costTime, scanRows, memory)
+	if len(rule) == 0 {
+		return
+	}
+	info.RunawayTriggered.Store(true)
+	switch rules.Action {
+	case config.RunawayActionKill:
+		sm.Kill(info.ID, true)
+	case config.RunawayActionDeprioritize:
+		info.StmtCtx.Deprioritized.Store(true)
+	}
+	logFields := append([]zap.Field{zap.String("rule", rule), zap.String("action", rules.Action)}, genLogFields(costTime, info)...)
+	logutil.BgLogger().Warn("runaway_query", logFields...)
+	sql := info.Info
+	if info.RedactSQL {
+		sql = parser.Normalize(sql)
+	}
+	runaway.AddRecord(runaway.Record{
+		Time:          time.Now(),
+		ConnID:        info.ID,
+		User:          info.User,
+		DB:            info.DB,
+		ResourceGroup: info.StmtCtx.ResourceGroupName,
+		Rule:          rule,
+		Action:        rules.Action,
+		Query:         sql,
+	})
+}
+
 // LogOnQueryExceedMemQuota prints a log when memory usage of connID is out of memory quota.
 func (eqh *Handle) LogOnQueryExceedMemQuota(connID uint64) {
 	if log.GetLevel() > zapcore.WarnLevel {
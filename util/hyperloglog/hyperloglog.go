@@ -0,0 +1,158 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hyperloglog
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+
+	"github.com/pingcap/errors"
+)
+
+const (
+	// Precision is the number of the bits of a hash value used to choose the register.
+	Precision = 11
+	// RegisterCount is the number of the registers of a Sketch, the standard error of the estimation is about
+	// 1.04 / sqrt(RegisterCount), which is 2.3%.
+	RegisterCount = 1 << Precision
+
+	// encodedFlag is the first byte of an encoded Sketch. It's never the first byte of the states encoded by the
+	// other algorithms of the approximate count distinct, whose first byte is less than 32.
+	encodedFlag byte = 0xff
+	// The registers are encoded as is in the dense encoding, or as the pairs of the index and the value of the
+	// non-zero registers in the sparse encoding.
+	denseEncoding     byte = 0
+	sparseEncoding    byte = 1
+	sparsePairSize         = 3
+	encodedHeaderSize      = 3
+)
+
+var errInvalidEncoding = errors.New("invalid encoded HyperLogLog sketch")
+
+// Sketch is the HyperLogLog sketch of a multiset of hash values, with which the number of the distinct values is
+// estimated in a fixed size. The Sketches built on the different parts of the data can be merged, and a Sketch can be
+// encoded to be sent as the partial state of the aggregation.
+type Sketch struct {
+	registers [RegisterCount]uint8
+}
+
+// Insert inserts a 64-bit hash value.
+func (s *Sketch) Insert(hash uint64) {
+	idx := hash >> (64 - Precision)
+	// The trailing one bounds the number of the leading zeros.
+	rank := uint8(bits.LeadingZeros64(hash<<Precision|1<<(Precision-1))) + 1
+	if rank > s.registers[idx] {
+		s.registers[idx] = rank
+	}
+}
+
+// Merge merges other into s, after which s is the sketch of the union of the two multisets.
+func (s *Sketch) Merge(other *Sketch) {
+	for i, r := range other.registers {
+		if r > s.registers[i] {
+			s.registers[i] = r
+		}
+	}
+}
+
+// Reset resets s to the sketch of an empty multiset.
+func (s *Sketch) Reset() {
+	s.registers = [RegisterCount]uint8{}
+}
+
+// Estimate estimates the number of the distinct hash values. The linear counting is used for the small cardinalities,
+// with which the estimation is much more accurate.
+func (s *Sketch) Estimate() uint64 {
+	m := float64(RegisterCount)
+	sum, zeros := 0.0, 0
+	for _, r := range s.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	if zeros == RegisterCount {
+		return 0
+	}
+	estimate := 0.7213 / (1 + 1.079/m) * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(math.Round(estimate))
+}
+
+// IsEncoded returns whether b is an encoded Sketch.
+func IsEncoded(b []byte) bool {
+	return len(b) > 0 && b[0] == encodedFlag
+}
+
+// Encode appends the encoded s to b. The sparse encoding is used if there are only a few non-zero registers.
+func (s *Sketch) Encode(b []byte) []byte {
+	nonZeros := 0
+	for _, r := range s.registers {
+		if r != 0 {
+			nonZeros++
+		}
+	}
+	if nonZeros*sparsePairSize >= RegisterCount {
+		b = append(b, encodedFlag, Precision, denseEncoding)
+		return append(b, s.registers[:]...)
+	}
+	b = append(b, encodedFlag, Precision, sparseEncoding)
+	var pair [sparsePairSize]byte
+	for i, r := range s.registers {
+		if r != 0 {
+			binary.BigEndian.PutUint16(pair[:], uint16(i))
+			pair[2] = r
+			b = append(b, pair[:]...)
+		}
+	}
+	return b
+}
+
+// MergeEncoded merges the encoded Sketch b into s.
+func (s *Sketch) MergeEncoded(b []byte) error {
+	if len(b) < encodedHeaderSize || b[0] != encodedFlag || b[1] != Precision {
+		return errInvalidEncoding
+	}
+	data := b[encodedHeaderSize:]
+	switch b[2] {
+	case denseEncoding:
+		if len(data) != RegisterCount {
+			return errInvalidEncoding
+		}
+		for i, r := range data {
+			if r > s.registers[i] {
+				s.registers[i] = r
+			}
+		}
+	case sparseEncoding:
+		if len(data)%sparsePairSize != 0 {
+			return errInvalidEncoding
+		}
+		for ; len(data) > 0; data = data[sparsePairSize:] {
+			i, r := binary.BigEndian.Uint16(data), data[2]
+			if int(i) >= RegisterCount {
+				return errInvalidEncoding
+			}
+			if r > s.registers[i] {
+				s.registers[i] = r
+			}
+		}
+	default:
+		return errInvalidEncoding
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hyperloglog
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func TestT(t *testing.T) {
+	CustomVerboseFlag = true
+	TestingT(t)
+}
+
+var _ = Suite(&testHyperLogLogSuite{})
+
+type testHyperLogLogSuite struct{}
+
+func (s *testHyperLogLogSuite) TestEstimate(c *C) {
+	defer testleak.AfterTest(c)()
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{0, 1, 5, 10, 1000, 100000, 1000000} {
+		var left, right Sketch
+		for i := 0; i < n; i++ {
+			hash := r.Uint64()
+			// The duplicated values don't change the estimation.
+			left.Insert(hash)
+			right.Insert(hash)
+		}
+		left.Merge(&right)
+		estimate := float64(left.Estimate())
+		if n <= 10 {
+			c.Assert(estimate, Equals, float64(n))
+			continue
+		}
+		c.Assert(math.Abs(estimate-float64(n))/float64(n) < 0.1, IsTrue, Commentf("n: %v, estimate: %v", n, estimate))
+	}
+}
+
+func (s *testHyperLogLogSuite) TestEncode(c *C) {
+	defer testleak.AfterTest(c)()
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{0, 10, 100000} {
+		var sketch, decoded Sketch
+		for i := 0; i < n; i++ {
+			sketch.Insert(r.Uint64())
+		}
+		encoded := sketch.Encode(nil)
+		c.Assert(IsEncoded(encoded), IsTrue)
+		c.Assert(decoded.MergeEncoded(encoded), IsNil)
+		c.Assert(decoded, Equals, sketch)
+		if n == 10 {
+			c.Assert(len(encoded), Equals, encodedHeaderSize+n*sparsePairSize)
+		}
+	}
+	var sketch Sketch
+	c.Assert(IsEncoded([]byte{0, 1}), IsFalse)
+	c.Assert(sketch.MergeEncoded([]byte{encodedFlag, Precision, sparseEncoding, 0xff, 0xff, 1}), NotNil)
+	c.Assert(sketch.MergeEncoded([]byte{encodedFlag, Precision, denseEncoding, 1}), NotNil)
+}
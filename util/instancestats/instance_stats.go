@@ -0,0 +1,72 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instancestats
+
+import (
+	"runtime"
+	"runtime/pprof"
+)
+
+// Path is the path of the status API which serves the Stats of a TiDB instance.
+const Path = "/info/runtime"
+
+// Task types of the running tasks.
+const (
+	TaskCop = "cop"
+	TaskMPP = "mpp"
+)
+
+// Stats is the runtime statistics of a TiDB instance, which is served by the status server of every instance and
+// collected by the cluster memory tables.
+type Stats struct {
+	Memory       []MemoryItem  `json:"memory"`
+	Goroutines   int           `json:"goroutines"`
+	Threads      int           `json:"threads"`
+	GoMaxProcs   int           `json:"gomaxprocs"`
+	RunningTasks []RunningTask `json:"running_tasks"`
+}
+
+// MemoryItem is the memory usage of a part of an instance.
+type MemoryItem struct {
+	Name  string `json:"name"`
+	Bytes uint64 `json:"bytes"`
+}
+
+// RunningTask is the number of the running tasks of a type sent by an instance.
+type RunningTask struct {
+	Type  string `json:"type"`
+	Count int64  `json:"count"`
+}
+
+// CollectRuntime returns the Stats of the Go runtime. The memory usage and the running tasks tracked by the other
+// components are appended by the caller.
+func CollectRuntime() *Stats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return &Stats{
+		Memory: []MemoryItem{
+			{Name: "heap_alloc", Bytes: m.HeapAlloc},
+			{Name: "heap_inuse", Bytes: m.HeapInuse},
+			{Name: "heap_idle", Bytes: m.HeapIdle},
+			{Name: "heap_released", Bytes: m.HeapReleased},
+			{Name: "stack_inuse", Bytes: m.StackInuse},
+			{Name: "gc_sys", Bytes: m.GCSys},
+			{Name: "other_sys", Bytes: m.OtherSys},
+			{Name: "sys", Bytes: m.Sys},
+		},
+		Goroutines: runtime.NumGoroutine(),
+		Threads:    pprof.Lookup("threadcreate").Count(),
+		GoMaxProcs: runtime.GOMAXPROCS(0),
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lockwaithistory
+
+import (
+	"sync"
+	"time"
+)
+
+// The results of the lock waits.
+const (
+	ResultAcquired = "ACQUIRED"
+	ResultTimeout  = "TIMEOUT"
+	ResultDeadlock = "DEADLOCK"
+	ResultError    = "ERROR"
+)
+
+// LockWaitRecord represents a finished lock wait of a pessimistic transaction on this TiDB node.
+type LockWaitRecord struct {
+	// OccurTime is the time when the lock wait ends.
+	OccurTime time.Time
+	WaitTime  time.Duration
+	TrxID     uint64
+	SQLDigest string
+	// Key and TrxHoldingLock are only known if the wait ends with a deadlock.
+	Key            []byte
+	TrxHoldingLock uint64
+	Result         string
+}
+
+// LockWaitHistory keeps the recent lock waits, the oldest ones are dropped when the capacity is exceeded. All its
+// public APIs are thread safe.
+type LockWaitHistory struct {
+	sync.RWMutex
+
+	records  []*LockWaitRecord
+	capacity int
+}
+
+// NewLockWaitHistory creates an instance of LockWaitHistory.
+func NewLockWaitHistory(capacity uint) *LockWaitHistory {
+	return &LockWaitHistory{capacity: int(capacity)}
+}
+
+// GlobalLockWaitHistory is the global instance of LockWaitHistory. Its capacity should be initialized with `Resize`
+// in `setGlobalVars` in tidb-server/main.go.
+var GlobalLockWaitHistory = NewLockWaitHistory(0)
+
+// Resize updates the capacity of the history, the oldest records are dropped if there are more records.
+func (h *LockWaitHistory) Resize(newCapacity uint) {
+	h.Lock()
+	defer h.Unlock()
+	h.capacity = int(newCapacity)
+	if len(h.records) > h.capacity {
+		// Copy the kept records to release the memory of the dropped ones.
+		h.records = append([]*LockWaitRecord(nil), h.records[len(h.records)-h.capacity:]...)
+	}
+}
+
+// Push adds a record to the history. Be aware that do not modify the record's content after pushing.
+func (h *LockWaitHistory) Push(record *LockWaitRecord) {
+	h.Lock()
+	defer h.Unlock()
+	if h.capacity == 0 {
+		return
+	}
+	if len(h.records) == h.capacity {
+		copy(h.records, h.records[1:])
+		h.records[len(h.records)-1] = record
+		return
+	}
+	h.records = append(h.records, record)
+}
+
+// GetAll gets all the records from the oldest to the latest.
+func (h *LockWaitHistory) GetAll() []*LockWaitRecord {
+	h.RLock()
+	defer h.RUnlock()
+	return append([]*LockWaitRecord(nil), h.records...)
+}
+
+// Clear clears all the records.
+func (h *LockWaitHistory) Clear() {
+	h.Lock()
+	defer h.Unlock()
+	h.records = nil
+}
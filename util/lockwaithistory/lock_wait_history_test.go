@@ -0,0 +1,63 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lockwaithistory
+
+import (
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+type testLockWaitHistorySuite struct{}
+
+var _ = Suite(&testLockWaitHistorySuite{})
+
+func TestT(t *testing.T) {
+	CustomVerboseFlag = true
+	TestingT(t)
+}
+
+func (s *testLockWaitHistorySuite) TestLockWaitHistory(c *C) {
+	defer testleak.AfterTest(c)()
+	h := NewLockWaitHistory(0)
+	h.Push(&LockWaitRecord{TrxID: 1})
+	c.Assert(h.GetAll(), HasLen, 0)
+
+	h.Resize(3)
+	for i := 1; i <= 5; i++ {
+		h.Push(&LockWaitRecord{TrxID: uint64(i)})
+	}
+	checkTrxIDs := func(expected ...uint64) {
+		records := h.GetAll()
+		c.Assert(records, HasLen, len(expected))
+		for i, record := range records {
+			c.Assert(record.TrxID, Equals, expected[i])
+		}
+	}
+	checkTrxIDs(3, 4, 5)
+
+	h.Resize(5)
+	h.Push(&LockWaitRecord{TrxID: 6})
+	checkTrxIDs(3, 4, 5, 6)
+	h.Resize(2)
+	checkTrxIDs(5, 6)
+	h.Push(&LockWaitRecord{TrxID: 7})
+	checkTrxIDs(6, 7)
+
+	h.Clear()
+	checkTrxIDs()
+	h.Push(&LockWaitRecord{TrxID: 8})
+	checkTrxIDs(8)
+}
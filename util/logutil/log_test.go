@@ -22,10 +22,12 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/log"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 const (
@@ -122,3 +124,15 @@ func (s *testLogSuite) TestSetLevel(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(log.GetLevel(), Equals, zap.DebugLevel)
 }
+
+func (s *testLogSuite) TestSlowQueryLogEncoder(c *C) {
+	now := time.Now()
+	b, err := (&slowLogEncoder{}).EncodeEntry(zapcore.Entry{Time: now, Message: "# Txn_start_ts: 1\nselect 1;"}, nil)
+	c.Assert(err, IsNil)
+	c.Assert(b.String(), Equals, "# Time: "+now.Format(SlowLogTimeFormat)+"\n# Txn_start_ts: 1\nselect 1;\n")
+
+	// The slow log in JSON is written as is.
+	b, err = (&slowLogEncoder{}).EncodeEntry(zapcore.Entry{Time: now, Message: `{"Query":"select 1;"}`}, nil)
+	c.Assert(err, IsNil)
+	c.Assert(b.String(), Equals, "{\"Query\":\"select 1;\"}\n")
+}
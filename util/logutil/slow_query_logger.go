@@ -2,6 +2,7 @@ package logutil
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/pingcap/errors"
@@ -43,6 +44,11 @@ type slowLogEncoder struct{}
 
 func (e *slowLogEncoder) EncodeEntry(entry zapcore.Entry, _ []zapcore.Field) (*buffer.Buffer, error) {
 	b := _pool.Get()
+	// The slow log in JSON is a document in one line, which records the time itself.
+	if strings.HasPrefix(entry.Message, "{") {
+		fmt.Fprintf(b, "%s\n", entry.Message)
+		return b, nil
+	}
 	fmt.Fprintf(b, "# Time: %s\n", entry.Time.Format(SlowLogTimeFormat))
 	fmt.Fprintf(b, "%s\n", entry.Message)
 	return b, nil
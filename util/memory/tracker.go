@@ -493,4 +493,6 @@ const (
 	LabelForSimpleTask int = -18
 	// LabelForCTEStorage represents the label of CTE storage
 	LabelForCTEStorage int = -19
+	// LabelForRingBuffer represents the label of the chunk ring buffer
+	LabelForRingBuffer int = -20
 )
@@ -0,0 +1,193 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plancapture
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/parser/terror"
+	"go.uber.org/atomic"
+)
+
+// MaxBundles is the max number of the bundles kept in Dir, the oldest bundles are removed when it's exceeded.
+const MaxBundles = 32
+
+// Dir is the directory of the captured bundles.
+var Dir = filepath.Join(os.TempDir(), "tidb_plan_capture")
+
+// pendingDigests are the digests flagged for capture, each of them is captured on its next execution once.
+var pendingDigests = struct {
+	sync.Mutex
+	digests map[string]struct{}
+	count   atomic.Int32
+}{digests: make(map[string]struct{})}
+
+// bundleMu serializes the writes and the cleanups of the bundles.
+var bundleMu sync.Mutex
+
+// SetPendingDigests replaces the digests flagged for capture.
+func SetPendingDigests(digests []string) {
+	pendingDigests.Lock()
+	defer pendingDigests.Unlock()
+	pendingDigests.digests = make(map[string]struct{}, len(digests))
+	for _, digest := range digests {
+		digest = strings.ToLower(strings.TrimSpace(digest))
+		if len(digest) > 0 {
+			pendingDigests.digests[digest] = struct{}{}
+		}
+	}
+	pendingDigests.count.Store(int32(len(pendingDigests.digests)))
+}
+
+// PendingDigests returns the sorted digests which are not captured yet.
+func PendingDigests() []string {
+	pendingDigests.Lock()
+	defer pendingDigests.Unlock()
+	digests := make([]string, 0, len(pendingDigests.digests))
+	for digest := range pendingDigests.digests {
+		digests = append(digests, digest)
+	}
+	sort.Strings(digests)
+	return digests
+}
+
+// HasPending returns whether any digest is waiting for capture, it's cheap enough to be called by every statement.
+func HasPending() bool {
+	return pendingDigests.count.Load() > 0
+}
+
+// Take removes the digest from the pending digests, it returns true if the digest was pending, which means
+// the caller should capture the statement.
+func Take(digest string) bool {
+	pendingDigests.Lock()
+	defer pendingDigests.Unlock()
+	if _, ok := pendingDigests.digests[digest]; !ok {
+		return false
+	}
+	delete(pendingDigests.digests, digest)
+	pendingDigests.count.Store(int32(len(pendingDigests.digests)))
+	return true
+}
+
+// File is a file in a bundle.
+type File struct {
+	Name    string
+	Content []byte
+}
+
+// BundleInfo describes a captured bundle.
+type BundleInfo struct {
+	Digest      string
+	FileName    string
+	CaptureTime time.Time
+	Size        int64
+}
+
+// WriteBundle writes the files into a zip bundle of the digest in Dir, and removes the oldest bundles
+// if there are more than MaxBundles bundles. It returns the path of the bundle.
+func WriteBundle(digest string, files []File) (string, error) {
+	bundleMu.Lock()
+	defer bundleMu.Unlock()
+	if err := os.MkdirAll(Dir, 0755); err != nil {
+		return "", errors.Trace(err)
+	}
+	path := filepath.Join(Dir, fmt.Sprintf("%s_%d.zip", digest, time.Now().UnixNano()))
+	f, err := os.Create(path)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	zw := zip.NewWriter(f)
+	for _, file := range files {
+		w, err := zw.Create(file.Name)
+		if err == nil {
+			_, err = w.Write(file.Content)
+		}
+		if err != nil {
+			terror.Log(zw.Close())
+			terror.Log(f.Close())
+			terror.Log(os.Remove(path))
+			return "", errors.Trace(err)
+		}
+	}
+	err = zw.Close()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		terror.Log(os.Remove(path))
+		return "", errors.Trace(err)
+	}
+	bundles, err := listBundles()
+	if err != nil {
+		return path, err
+	}
+	for i := 0; i < len(bundles)-MaxBundles; i++ {
+		if err := os.Remove(filepath.Join(Dir, bundles[i].FileName)); err != nil && !os.IsNotExist(err) {
+			return path, errors.Trace(err)
+		}
+	}
+	return path, nil
+}
+
+// ListBundles returns the bundles in Dir from the oldest to the latest.
+func ListBundles() ([]BundleInfo, error) {
+	bundleMu.Lock()
+	defer bundleMu.Unlock()
+	return listBundles()
+}
+
+func listBundles() ([]BundleInfo, error) {
+	entries, err := ioutil.ReadDir(Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Trace(err)
+	}
+	bundles := make([]BundleInfo, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".zip") {
+			continue
+		}
+		sep := strings.LastIndexByte(name, '_')
+		if sep < 0 {
+			continue
+		}
+		nanos, err := strconv.ParseInt(strings.TrimSuffix(name[sep+1:], ".zip"), 10, 64)
+		if err != nil {
+			continue
+		}
+		bundles = append(bundles, BundleInfo{
+			Digest:      name[:sep],
+			FileName:    name,
+			CaptureTime: time.Unix(0, nanos),
+			Size:        entry.Size(),
+		})
+	}
+	sort.Slice(bundles, func(i, j int) bool {
+		return bundles[i].CaptureTime.Before(bundles[j].CaptureTime)
+	})
+	return bundles, nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plancapture
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	. "github.com/pingcap/check"
+)
+
+type testPlanCaptureSuite struct{}
+
+var _ = Suite(&testPlanCaptureSuite{})
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+func (s *testPlanCaptureSuite) TestPendingDigests(c *C) {
+	SetPendingDigests([]string{" BB ", "aa", ""})
+	c.Assert(HasPending(), IsTrue)
+	c.Assert(PendingDigests(), DeepEquals, []string{"aa", "bb"})
+	c.Assert(Take("cc"), IsFalse)
+	c.Assert(Take("bb"), IsTrue)
+	// A digest is captured only once.
+	c.Assert(Take("bb"), IsFalse)
+	c.Assert(PendingDigests(), DeepEquals, []string{"aa"})
+	SetPendingDigests(nil)
+	c.Assert(HasPending(), IsFalse)
+	c.Assert(Take("aa"), IsFalse)
+}
+
+func (s *testPlanCaptureSuite) TestBundles(c *C) {
+	dir, err := ioutil.TempDir("", "plan_capture")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	origDir := Dir
+	Dir = dir
+	defer func() { Dir = origDir }()
+
+	bundles, err := ListBundles()
+	c.Assert(err, IsNil)
+	c.Assert(bundles, HasLen, 0)
+
+	path, err := WriteBundle("aa", []File{{Name: "sql.sql", Content: []byte("select 1")}})
+	c.Assert(err, IsNil)
+	r, err := zip.OpenReader(path)
+	c.Assert(err, IsNil)
+	c.Assert(r.File, HasLen, 1)
+	c.Assert(r.File[0].Name, Equals, "sql.sql")
+	c.Assert(r.Close(), IsNil)
+
+	// The oldest bundles are removed.
+	for i := 0; i < MaxBundles; i++ {
+		_, err = WriteBundle("bb", nil)
+		c.Assert(err, IsNil)
+	}
+	bundles, err = ListBundles()
+	c.Assert(err, IsNil)
+	c.Assert(bundles, HasLen, MaxBundles)
+	for _, bundle := range bundles {
+		c.Assert(bundle.Digest, Equals, "bb")
+		c.Assert(bundle.Size > 0, IsTrue)
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/pingcap/tidb/sessionctx/stmtctx"
 	"github.com/pingcap/tidb/util/execdetails"
 	"github.com/tikv/client-go/v2/oracle"
+	atomic2 "go.uber.org/atomic"
 )
 
 // ProcessInfo is a struct used for show processlist statement.
@@ -47,11 +48,16 @@ type ProcessInfo struct {
 	// MaxExecutionTime is the timeout for select statement, in milliseconds.
 	// If the query takes too long, kill it.
 	MaxExecutionTime uint64
+	// ConnectAttrs are the attributes sent by the client when connecting.
+	ConnectAttrs map[string]string
 
 	State                     uint16
 	Command                   byte
 	ExceedExpensiveTimeThresh bool
 	RedactSQL                 bool
+	// RunawayTriggered is set by the expensive query handler once the statement is found runaway, it's atomic since
+	// the ProcessInfo is shared with the handler.
+	RunawayTriggered atomic2.Bool
 }
 
 // ToRowForShow returns []interface{} for the row data of "SHOW [FULL] PROCESSLIST".
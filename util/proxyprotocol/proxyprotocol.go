@@ -0,0 +1,306 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxyprotocol
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+)
+
+// The types of the TLVs of the PROXY protocol v2.
+const (
+	TLVTypeALPN      byte = 0x01
+	TLVTypeAuthority byte = 0x02
+	TLVTypeCRC32C    byte = 0x03
+	TLVTypeNoop      byte = 0x04
+	TLVTypeUniqueID  byte = 0x05
+	TLVTypeSSL       byte = 0x20
+	TLVTypeNetNS     byte = 0x30
+)
+
+var (
+	v1Prefix    = []byte("PROXY ")
+	v2Signature = []byte{0x0D, 0x0A, 0x0D, 0x0A, 0x00, 0x0D, 0x0A, 0x51, 0x55, 0x49, 0x54, 0x0A}
+)
+
+const (
+	// v1MaxLength is the max length of the v1 header, including the CRLF.
+	v1MaxLength = 107
+	// v2HeaderLength is the length of the fixed part of the v2 header, which is the signature, the version and
+	// command, the address family and protocol, and the length of the rest of the header.
+	v2HeaderLength = 16
+)
+
+// ErrHeader is returned if the PROXY protocol header of a connection is invalid.
+var ErrHeader = errors.New("invalid PROXY protocol header")
+
+// TLV is a Type-Length-Value of the PROXY protocol v2 header.
+type TLV struct {
+	Type  byte
+	Value []byte
+}
+
+// Listener wraps a net.Listener. The connections from the allowed networks must send the PROXY protocol header
+// first, with which the addresses of the connections are replaced.
+type Listener struct {
+	net.Listener
+	allowAll      bool
+	allowed       []*net.IPNet
+	headerTimeout time.Duration
+}
+
+// NewListener creates a Listener. networks is the comma-separated IPs or CIDRs of the proxies, * means all
+// networks. headerTimeout is the timeout to read the header in seconds.
+func NewListener(listener net.Listener, networks string, headerTimeout int) (*Listener, error) {
+	l := &Listener{Listener: listener, headerTimeout: time.Duration(headerTimeout) * time.Second}
+	for _, network := range strings.Split(networks, ",") {
+		network = strings.TrimSpace(network)
+		switch {
+		case network == "":
+		case network == "*":
+			l.allowAll = true
+		case strings.Contains(network, "/"):
+			_, ipNet, err := net.ParseCIDR(network)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			l.allowed = append(l.allowed, ipNet)
+		default:
+			ip := net.ParseIP(network)
+			if ip == nil {
+				return nil, errors.Errorf("invalid PROXY protocol network %s", network)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			l.allowed = append(l.allowed, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		}
+	}
+	return l, nil
+}
+
+// Accept implements the net.Listener interface. The connections from the allowed networks are returned as *Conn.
+func (l *Listener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	if !l.isAllowed(conn.RemoteAddr()) {
+		return conn, nil
+	}
+	return &Conn{Conn: conn, headerTimeout: l.headerTimeout}, nil
+}
+
+func (l *Listener) isAllowed(addr net.Addr) bool {
+	if l.allowAll {
+		return true
+	}
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	for _, ipNet := range l.allowed {
+		if ipNet.Contains(tcpAddr.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// Conn is a connection through the PROXY protocol. The header is read on the first Read or the first call to get
+// the addresses.
+type Conn struct {
+	net.Conn
+	headerTimeout time.Duration
+
+	once       sync.Once
+	err        error
+	remoteAddr net.Addr
+	localAddr  net.Addr
+	tlvs       []TLV
+}
+
+// Read implements the net.Conn interface.
+func (c *Conn) Read(b []byte) (int, error) {
+	c.once.Do(c.readHeader)
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.Conn.Read(b)
+}
+
+// RemoteAddr implements the net.Conn interface, it returns the source address in the header.
+func (c *Conn) RemoteAddr() net.Addr {
+	c.once.Do(c.readHeader)
+	if c.remoteAddr != nil {
+		return c.remoteAddr
+	}
+	return c.Conn.RemoteAddr()
+}
+
+// LocalAddr implements the net.Conn interface, it returns the destination address in the header.
+func (c *Conn) LocalAddr() net.Addr {
+	c.once.Do(c.readHeader)
+	if c.localAddr != nil {
+		return c.localAddr
+	}
+	return c.Conn.LocalAddr()
+}
+
+// TLVs returns the TLVs of the v2 header.
+func (c *Conn) TLVs() []TLV {
+	c.once.Do(c.readHeader)
+	return c.tlvs
+}
+
+func (c *Conn) readHeader() {
+	if c.headerTimeout > 0 {
+		if c.err = c.Conn.SetReadDeadline(time.Now().Add(c.headerTimeout)); c.err != nil {
+			return
+		}
+	}
+	// The shortest header, which is "PROXY UNKNOWN\r\n" of v1, is longer than the signature of v2.
+	buf := make([]byte, len(v2Signature))
+	if _, c.err = io.ReadFull(c.Conn, buf); c.err != nil {
+		c.err = errors.Trace(c.err)
+		return
+	}
+	switch {
+	case bytes.Equal(buf, v2Signature):
+		c.err = c.readV2Header()
+	case bytes.HasPrefix(buf, v1Prefix):
+		c.err = c.readV1Header(buf)
+	default:
+		c.err = errors.Trace(ErrHeader)
+	}
+	if c.err == nil && c.headerTimeout > 0 {
+		c.err = c.Conn.SetReadDeadline(time.Time{})
+	}
+}
+
+// readV1Header reads the rest of the v1 header, which is like "PROXY TCP4 192.168.0.1 192.168.0.11 56324 443\r\n".
+func (c *Conn) readV1Header(buf []byte) error {
+	// Read byte by byte, so the data following the header isn't consumed.
+	var b [1]byte
+	for !bytes.HasSuffix(buf, []byte("\r\n")) {
+		if len(buf) >= v1MaxLength {
+			return errors.Trace(ErrHeader)
+		}
+		if _, err := io.ReadFull(c.Conn, b[:]); err != nil {
+			return errors.Trace(err)
+		}
+		buf = append(buf, b[0])
+	}
+	fields := strings.Fields(string(buf[:len(buf)-2]))
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return errors.Trace(ErrHeader)
+	}
+	src, err := parseTCPAddr(fields[2], fields[4])
+	if err != nil {
+		return err
+	}
+	dst, err := parseTCPAddr(fields[3], fields[5])
+	if err != nil {
+		return err
+	}
+	c.remoteAddr, c.localAddr = src, dst
+	return nil
+}
+
+func parseTCPAddr(ip, port string) (*net.TCPAddr, error) {
+	addr := &net.TCPAddr{IP: net.ParseIP(ip)}
+	if addr.IP == nil {
+		return nil, errors.Trace(ErrHeader)
+	}
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return nil, errors.Trace(ErrHeader)
+	}
+	addr.Port = int(p)
+	return addr, nil
+}
+
+// readV2Header reads the rest of the v2 header, whose signature has been read.
+func (c *Conn) readV2Header() error {
+	var header [v2HeaderLength - 12]byte
+	if _, err := io.ReadFull(c.Conn, header[:]); err != nil {
+		return errors.Trace(err)
+	}
+	version, command := header[0]>>4, header[0]&0x0F
+	if version != 2 || command > 1 {
+		return errors.Trace(ErrHeader)
+	}
+	data := make([]byte, binary.BigEndian.Uint16(header[2:]))
+	if _, err := io.ReadFull(c.Conn, data); err != nil {
+		return errors.Trace(err)
+	}
+	// The LOCAL command is sent by the proxy itself, e.g. for health checks, the addresses are kept.
+	local := command == 0
+
+	var addrLength int
+	switch family, protocol := header[1]>>4, header[1]&0x0F; {
+	case family == 0x1:
+		addrLength = 2*net.IPv4len + 4
+		if !local && protocol == 0x1 && len(data) >= addrLength {
+			c.remoteAddr = &net.TCPAddr{IP: net.IP(data[0:4]), Port: int(binary.BigEndian.Uint16(data[8:]))}
+			c.localAddr = &net.TCPAddr{IP: net.IP(data[4:8]), Port: int(binary.BigEndian.Uint16(data[10:]))}
+		}
+	case family == 0x2:
+		addrLength = 2*net.IPv6len + 4
+		if !local && protocol == 0x1 && len(data) >= addrLength {
+			c.remoteAddr = &net.TCPAddr{IP: net.IP(data[0:16]), Port: int(binary.BigEndian.Uint16(data[32:]))}
+			c.localAddr = &net.TCPAddr{IP: net.IP(data[16:32]), Port: int(binary.BigEndian.Uint16(data[34:]))}
+		}
+	case family == 0x3:
+		// The source and destination of AF_UNIX are 108 bytes each, they're not used.
+		addrLength = 216
+	}
+	if len(data) < addrLength {
+		return errors.Trace(ErrHeader)
+	}
+	tlvs, err := parseTLVs(data[addrLength:])
+	if err != nil {
+		return err
+	}
+	c.tlvs = tlvs
+	return nil
+}
+
+func parseTLVs(data []byte) ([]TLV, error) {
+	var tlvs []TLV
+	for len(data) > 0 {
+		if len(data) < 3 {
+			return nil, errors.Trace(ErrHeader)
+		}
+		length := int(binary.BigEndian.Uint16(data[1:]))
+		if len(data) < 3+length {
+			return nil, errors.Trace(ErrHeader)
+		}
+		tlvs = append(tlvs, TLV{Type: data[0], Value: data[3 : 3+length]})
+		data = data[3+length:]
+	}
+	return tlvs, nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxyprotocol
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/errors"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testProxyProtocolSuite{})
+
+type testProxyProtocolSuite struct{}
+
+// newConn returns a Conn which reads the data.
+func newConn(data []byte) *Conn {
+	client, server := net.Pipe()
+	go func() {
+		// The write fails if the header is invalid and the conn is closed before all the data is read.
+		_, _ = client.Write(data)
+		_ = client.Close()
+	}()
+	return &Conn{Conn: server}
+}
+
+func v2Header(command, family byte, addrs []byte, tlvs ...TLV) []byte {
+	var body []byte
+	body = append(body, addrs...)
+	for _, tlv := range tlvs {
+		body = append(body, tlv.Type, 0, 0)
+		binary.BigEndian.PutUint16(body[len(body)-2:], uint16(len(tlv.Value)))
+		body = append(body, tlv.Value...)
+	}
+	header := append([]byte(nil), v2Signature...)
+	header = append(header, 0x20|command, family, 0, 0)
+	binary.BigEndian.PutUint16(header[14:], uint16(len(body)))
+	return append(header, body...)
+}
+
+func (s *testProxyProtocolSuite) TestV1(c *C) {
+	conn := newConn([]byte("PROXY TCP4 192.168.0.1 192.168.0.11 56324 4000\r\nhello"))
+	c.Assert(conn.RemoteAddr().String(), Equals, "192.168.0.1:56324")
+	c.Assert(conn.LocalAddr().String(), Equals, "192.168.0.11:4000")
+	data, err := ioutil.ReadAll(conn)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "hello")
+
+	conn = newConn([]byte("PROXY TCP6 ::1 ::2 56324 4000\r\n"))
+	c.Assert(conn.RemoteAddr().String(), Equals, "[::1]:56324")
+
+	conn = newConn([]byte("PROXY UNKNOWN\r\nhello"))
+	c.Assert(conn.RemoteAddr(), Equals, conn.Conn.RemoteAddr())
+	data, err = ioutil.ReadAll(conn)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "hello")
+
+	for _, header := range []string{"PROXY TCP4 192.168.0.1\r\n", "PROXY TCP4 a b 1 2\r\n", "GET / HTTP/1.1\r\n"} {
+		conn = newConn([]byte(header))
+		_, err = conn.Read(make([]byte, 1))
+		c.Assert(errors.Cause(err), Equals, ErrHeader, Commentf("header %s", header))
+		c.Assert(conn.Close(), IsNil)
+	}
+}
+
+func (s *testProxyProtocolSuite) TestV2(c *C) {
+	addrs := []byte{192, 168, 0, 1, 192, 168, 0, 11, 0xdc, 0x04, 0x0f, 0xa0}
+	tlvs := []TLV{
+		{Type: TLVTypeAuthority, Value: []byte("tidb.example.com")},
+		{Type: TLVTypeNoop, Value: []byte{}},
+		{Type: TLVTypeUniqueID, Value: []byte{0x01, 0x02}},
+	}
+	conn := newConn(append(v2Header(0x1, 0x11, addrs, tlvs...), "hello"...))
+	c.Assert(conn.RemoteAddr().String(), Equals, "192.168.0.1:56324")
+	c.Assert(conn.LocalAddr().String(), Equals, "192.168.0.11:4000")
+	c.Assert(conn.TLVs(), DeepEquals, tlvs)
+	data, err := ioutil.ReadAll(conn)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "hello")
+
+	addrs = make([]byte, 36)
+	addrs[15], addrs[31], addrs[33], addrs[35] = 1, 2, 1, 2
+	conn = newConn(v2Header(0x1, 0x21, addrs))
+	c.Assert(conn.RemoteAddr().String(), Equals, "[::1]:1")
+	c.Assert(conn.LocalAddr().String(), Equals, "[::2]:2")
+	c.Assert(conn.TLVs(), HasLen, 0)
+
+	// The addresses are kept for the LOCAL command.
+	conn = newConn(v2Header(0x0, 0x00, nil))
+	c.Assert(conn.RemoteAddr(), Equals, conn.Conn.RemoteAddr())
+
+	// The TLV is truncated.
+	conn = newConn(v2Header(0x1, 0x11, append(make([]byte, 12), TLVTypeAuthority, 0x00, 0x05, 'a')))
+	_, err = conn.Read(make([]byte, 1))
+	c.Assert(errors.Cause(err), Equals, ErrHeader)
+	c.Assert(conn.Close(), IsNil)
+}
+
+func (s *testProxyProtocolSuite) TestListener(c *C) {
+	_, err := NewListener(nil, "192.168.0.1,10.0.0.0/8, ::1", 5)
+	c.Assert(err, IsNil)
+	_, err = NewListener(nil, "192.168.0.256", 5)
+	c.Assert(err, NotNil)
+	_, err = NewListener(nil, "10.0.0.0/33", 5)
+	c.Assert(err, NotNil)
+
+	l, err := NewListener(nil, "192.168.0.1,10.0.0.0/8", 5)
+	c.Assert(err, IsNil)
+	c.Assert(l.isAllowed(&net.TCPAddr{IP: net.ParseIP("192.168.0.1")}), IsTrue)
+	c.Assert(l.isAllowed(&net.TCPAddr{IP: net.ParseIP("10.1.2.3")}), IsTrue)
+	c.Assert(l.isAllowed(&net.TCPAddr{IP: net.ParseIP("192.168.0.2")}), IsFalse)
+	c.Assert(l.isAllowed(&net.UnixAddr{Name: "/tmp/tidb.sock"}), IsFalse)
+
+	l, err = NewListener(nil, "*", 5)
+	c.Assert(err, IsNil)
+	c.Assert(l.isAllowed(&net.TCPAddr{IP: net.ParseIP("192.168.0.2")}), IsTrue)
+}
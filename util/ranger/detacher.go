@@ -331,6 +331,12 @@ func (d *rangeDetacher) detachCNFCondAndBuildRangeForIndex(conditions []expressi
 		}
 		// `eqOrInCount` must be 0 when coming here.
 		res.AccessConds, res.RemainedConds = detachColumnCNFConditions(d.sctx, newConditions, checker)
+		if len(res.AccessConds) == 0 {
+			dnfRes, err := d.detachDNFCondFromCNF(newConditions, tpSlice)
+			if err != nil || dnfRes != nil {
+				return dnfRes, err
+			}
+		}
 		ranges, err = d.buildCNFIndexRange(tpSlice, 0, res.AccessConds)
 		if err != nil {
 			return nil, err
@@ -560,6 +566,11 @@ func (d *rangeDetacher) detachDNFCondAndBuildRangeForIndex(condition *expression
 			ranges := res.Ranges
 			accesses = res.AccessConds
 			filters = res.RemainedConds
+			if len(ranges) == 0 {
+				// The item is always false, e.g. `a > 5 and a = 1`, so it adds no range.
+				newAccessItems = append(newAccessItems, item)
+				continue
+			}
 			if len(accesses) == 0 {
 				return FullRange(), nil, true, nil
 			}
@@ -597,6 +608,37 @@ func (d *rangeDetacher) detachDNFCondAndBuildRangeForIndex(condition *expression
 	return totalRanges, []expression.Expression{expression.ComposeDNFCondition(d.sctx, newAccessItems...)}, hasResidual, nil
 }
 
+// detachDNFCondFromCNF builds the ranges from a DNF condition of the CNF conditions on several index columns, such as
+// `a > 1 or (a = 1 and b > 2)` rewritten from the row comparison `(a, b) > (1, 2)`, and the other conditions are
+// remained as filters. It's used only when the CNF conditions build no range on the first index column. The ranges of
+// a DNF condition with residual are used as well since the alternative is a full range, and the DNF condition is kept
+// as a filter then. nil is returned if no DNF condition builds the ranges.
+func (d *rangeDetacher) detachDNFCondFromCNF(conditions []expression.Expression, tpSlice []*types.FieldType) (*DetachRangeResult, error) {
+	var res *DetachRangeResult
+	for i, cond := range conditions {
+		sf, ok := cond.(*expression.ScalarFunction)
+		if !ok || sf.FuncName.L != ast.LogicOr {
+			continue
+		}
+		ranges, accesses, hasResidual, err := d.detachDNFCondAndBuildRangeForIndex(sf, tpSlice)
+		if err != nil {
+			return nil, err
+		}
+		if len(accesses) == 0 || (hasResidual && res != nil) {
+			continue
+		}
+		curRes := &DetachRangeResult{Ranges: ranges, AccessConds: []expression.Expression{cond}}
+		curRes.RemainedConds = append(curRes.RemainedConds, conditions[:i]...)
+		curRes.RemainedConds = append(curRes.RemainedConds, conditions[i+1:]...)
+		if !hasResidual {
+			return curRes, nil
+		}
+		curRes.RemainedConds = append(curRes.RemainedConds, cond)
+		res = curRes
+	}
+	return res, nil
+}
+
 // DetachRangeResult wraps up results when detaching conditions and builing ranges.
 type DetachRangeResult struct {
 	// Ranges is the ranges extracted and built from conditions.
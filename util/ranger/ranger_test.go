@@ -483,6 +483,41 @@ create table t(
 			filterConds: "[]",
 			resultStr:   "[]",
 		},
+		{
+			indexPos:    1,
+			exprStr:     "(c, a) > (1, 'a')",
+			accessConds: "[or(gt(test.t.c, 1), and(eq(test.t.c, 1), gt(test.t.a, a)))]",
+			filterConds: "[]",
+			resultStr:   "[(1 \"a\",1 +inf] (1,+inf]]",
+		},
+		{
+			indexPos:    1,
+			exprStr:     "(c, a) <= (1, 'a') and b = 1",
+			accessConds: "[or(lt(test.t.c, 1), eq(test.t.c, 1))]",
+			filterConds: "[or(lt(test.t.c, 1), and(eq(test.t.c, 1), le(test.t.a, a))) eq(test.t.b, 1)]",
+			resultStr:   "[[-inf,1) [1,1]]",
+		},
+		{
+			indexPos:    1,
+			exprStr:     "(c, a) in ((1, 'a'), (2, 'b'))",
+			accessConds: "[or(and(eq(test.t.c, 1), eq(test.t.a, a)), and(eq(test.t.c, 2), eq(test.t.a, b)))]",
+			filterConds: "[]",
+			resultStr:   "[[1 \"a\",1 \"a\"] [2 \"b\",2 \"b\"]]",
+		},
+		{
+			indexPos:    0,
+			exprStr:     "((a = 'a' and b > 1) or (a = 'c' and c > 1)) and b = 2",
+			accessConds: "[or(eq(test.t.a, a), eq(test.t.a, c))]",
+			filterConds: "[eq(test.t.b, 2) or(and(eq(test.t.a, a), gt(test.t.b, 1)), and(eq(test.t.a, c), gt(test.t.c, 1)))]",
+			resultStr:   `[["a","a"] ["c","c"]]`,
+		},
+		{
+			indexPos:    0,
+			exprStr:     "(a = 'a' and a = 'b') or (a = 'c' and b = 1)",
+			accessConds: "[or(and(eq(test.t.a, a), eq(test.t.a, b)), and(eq(test.t.a, c), eq(test.t.b, 1)))]",
+			filterConds: "[]",
+			resultStr:   `[["c" 1,"c" 1]]`,
+		},
 		{
 			indexPos:    0,
 			exprStr:     "a in (NULL)",
@@ -0,0 +1,86 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runaway
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pingcap/tidb/config"
+)
+
+// MaxRecords is the max number of the records kept in memory, the oldest records are dropped when it's exceeded.
+const MaxRecords = 1024
+
+// Record is a record of the action taken on a runaway statement.
+type Record struct {
+	Time          time.Time
+	ConnID        uint64
+	User          string
+	DB            string
+	ResourceGroup string
+	Rule          string
+	Action        string
+	Query         string
+}
+
+var records = struct {
+	sync.Mutex
+	records []Record
+}{}
+
+// AddRecord adds the record of the action taken on a runaway statement.
+func AddRecord(r Record) {
+	records.Lock()
+	defer records.Unlock()
+	if len(records.records) >= MaxRecords {
+		records.records = append(records.records[:0], records.records[len(records.records)-MaxRecords+1:]...)
+	}
+	records.records = append(records.records, r)
+}
+
+// Records returns the records from the oldest to the latest.
+func Records() []Record {
+	records.Lock()
+	defer records.Unlock()
+	return append([]Record(nil), records.records...)
+}
+
+// Rules returns the rules to check the statements in the resource group. The rules of the group take precedence
+// over the global rules if the group has any.
+func Rules(group string) *config.RunawayRules {
+	cfg := config.GetGlobalConfig()
+	if len(group) > 0 {
+		if g := cfg.GetResourceGroup(group); g != nil && g.Runaway.Enabled() {
+			return &g.Runaway
+		}
+	}
+	return &cfg.Runaway
+}
+
+// Check checks the usage of a running statement by the rules, it returns the description of the broken rule, or
+// an empty string if the statement isn't runaway.
+func Check(rules *config.RunawayRules, execTime time.Duration, scanRows, memory int64) string {
+	if maxTime := time.Duration(rules.MaxExecutionTime) * time.Millisecond; maxTime > 0 && execTime > maxTime {
+		return fmt.Sprintf("execution time %v > %v", execTime.Round(time.Millisecond), maxTime)
+	}
+	if rules.MaxScanRows > 0 && scanRows > rules.MaxScanRows {
+		return fmt.Sprintf("scan rows %d > %d", scanRows, rules.MaxScanRows)
+	}
+	if rules.MaxMemory > 0 && memory > rules.MaxMemory {
+		return fmt.Sprintf("memory %d > %d", memory, rules.MaxMemory)
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runaway
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/config"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testRunawaySuite{})
+
+type testRunawaySuite struct{}
+
+func (s *testRunawaySuite) TestCheck(c *C) {
+	rules := &config.RunawayRules{MaxExecutionTime: 1000, MaxScanRows: 100, MaxMemory: 1 << 20}
+	c.Assert(Check(rules, 500*time.Millisecond, 100, 1<<20), Equals, "")
+	c.Assert(Check(rules, 1500*time.Millisecond, 0, 0), Equals, "execution time 1.5s > 1s")
+	c.Assert(Check(rules, 0, 101, 0), Equals, "scan rows 101 > 100")
+	c.Assert(Check(rules, 0, 0, 1<<20+1), Equals, "memory 1048577 > 1048576")
+	c.Assert(Check(&config.RunawayRules{}, time.Hour, 1<<30, 1<<30), Equals, "")
+}
+
+func (s *testRunawaySuite) TestRules(c *C) {
+	defer config.RestoreFunc()()
+	config.UpdateGlobal(func(conf *config.Config) {
+		conf.Runaway = config.RunawayRules{MaxScanRows: 100, Action: config.RunawayActionLog}
+		conf.ResourceGroups = []config.ResourceGroup{
+			{Name: "olap", Runaway: config.RunawayRules{MaxExecutionTime: 1000, Action: config.RunawayActionKill}},
+			{Name: "oltp"},
+		}
+	})
+	c.Assert(Rules("olap").Action, Equals, config.RunawayActionKill)
+	c.Assert(Rules("oltp").MaxScanRows, Equals, int64(100))
+	c.Assert(Rules("").MaxScanRows, Equals, int64(100))
+}
+
+func (s *testRunawaySuite) TestRecords(c *C) {
+	for i := 0; i < MaxRecords+10; i++ {
+		AddRecord(Record{ConnID: uint64(i)})
+	}
+	records := Records()
+	c.Assert(records, HasLen, MaxRecords)
+	c.Assert(records[0].ConnID, Equals, uint64(10))
+	c.Assert(records[MaxRecords-1].ConnID, Equals, uint64(MaxRecords+9))
+}
@@ -37,6 +37,9 @@ const (
 	clusterLoad           = "cluster_load"
 	clusterLog            = "cluster_log"
 	clusterSystemInfo     = "cluster_systeminfo"
+	clusterMemoryUsage    = "cluster_memory_usage"
+	clusterThreads        = "cluster_threads"
+	clusterRunningTasks   = "cluster_running_tasks"
 	inspectionResult      = "inspection_result"
 	inspectionRules       = "inspection_rules"
 	inspectionSummary     = "inspection_summary"
@@ -108,8 +111,9 @@ func IsInvisibleTable(dbLowerName, tblLowerName string) bool {
 		}
 	case informationSchema:
 		switch tblLowerName {
-		case clusterConfig, clusterHardware, clusterLoad, clusterLog, clusterSystemInfo, inspectionResult,
-			inspectionRules, inspectionSummary, metricsSummary, metricsSummaryByLabel, metricsTables, tidbHotRegions:
+		case clusterConfig, clusterHardware, clusterLoad, clusterLog, clusterSystemInfo, clusterMemoryUsage, clusterThreads,
+			clusterRunningTasks, inspectionResult, inspectionRules, inspectionSummary, metricsSummary, metricsSummaryByLabel,
+			metricsTables, tidbHotRegions:
 			return true
 		}
 	case performanceSchema:
@@ -40,8 +40,9 @@ func (s *testSecurity) TestInvisibleSchema(c *C) {
 
 func (s *testSecurity) TestIsInvisibleTable(c *C) {
 	mysqlTbls := []string{exprPushdownBlacklist, gcDeleteRange, gcDeleteRangeDone, optRuleBlacklist, tidb, globalVariables}
-	infoSchemaTbls := []string{clusterConfig, clusterHardware, clusterLoad, clusterLog, clusterSystemInfo, inspectionResult,
-		inspectionRules, inspectionSummary, metricsSummary, metricsSummaryByLabel, metricsTables, tidbHotRegions}
+	infoSchemaTbls := []string{clusterConfig, clusterHardware, clusterLoad, clusterLog, clusterSystemInfo, clusterMemoryUsage,
+		clusterThreads, clusterRunningTasks, inspectionResult, inspectionRules, inspectionSummary, metricsSummary,
+		metricsSummaryByLabel, metricsTables, tidbHotRegions}
 	perfSChemaTbls := []string{pdProfileAllocs, pdProfileBlock, pdProfileCPU, pdProfileGoroutines, pdProfileMemory,
 		pdProfileMutex, tidbProfileAllocs, tidbProfileBlock, tidbProfileCPU, tidbProfileGoroutines,
 		tidbProfileMemory, tidbProfileMutex, tikvProfileCPU}
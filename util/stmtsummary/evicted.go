@@ -282,6 +282,7 @@ func addInfo(addTo *stmtSummaryByDigestElement, addWith *stmtSummaryByDigestElem
 	if addTo.maxCompileLatency < addWith.maxCompileLatency {
 		addTo.maxCompileLatency = addWith.maxCompileLatency
 	}
+	addTo.latencies.merge(&addWith.latencies)
 
 	// coprocessor
 	addTo.sumNumCopTasks += addWith.sumNumCopTasks
@@ -293,6 +294,14 @@ func addInfo(addTo *stmtSummaryByDigestElement, addWith *stmtSummaryByDigestElem
 		addTo.maxCopWaitTime = addWith.maxCopWaitTime
 		addTo.maxCopWaitAddress = addWith.maxCopWaitAddress
 	}
+	addTo.sumCopTime += addWith.sumCopTime
+	if addTo.maxCopTime < addWith.maxCopTime {
+		addTo.maxCopTime = addWith.maxCopTime
+	}
+	addTo.sumMPPTime += addWith.sumMPPTime
+	if addTo.maxMPPTime < addWith.maxMPPTime {
+		addTo.maxMPPTime = addWith.maxMPPTime
+	}
 
 	// TiKV
 	addTo.sumProcessTime += addWith.sumProcessTime
@@ -0,0 +1,110 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stmtsummary
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// latencyBucketCount is the number of the buckets of latencyHistogram, the last bucket begins at about 36 minutes.
+const latencyBucketCount = 64
+
+// latencyHistogram counts the latencies in the buckets, with which the percentiles are estimated. The buckets are in
+// microseconds, the bucket 0 counts the latencies less than 1us, and each power of 2 is split into 2 buckets, so the
+// upper bound of a bucket is at most 1.5 times of its lower bound. It takes 256 bytes for each summary.
+type latencyHistogram struct {
+	counts [latencyBucketCount]uint32
+}
+
+// latencyBucket returns the bucket index of the latency.
+func latencyBucket(d time.Duration) int {
+	us := uint64(d / time.Microsecond)
+	if us == 0 {
+		return 0
+	}
+	// us is in [2^(l-1), 2^l), the second highest bit decides the half.
+	l := bits.Len64(us)
+	half := 0
+	if l >= 2 {
+		half = int(us>>(l-2)) & 1
+	}
+	idx := 2*(l-1) + half + 1
+	if idx >= latencyBucketCount {
+		idx = latencyBucketCount - 1
+	}
+	return idx
+}
+
+// latencyBucketBounds returns the lower bound and the upper bound of the bucket in microseconds.
+func latencyBucketBounds(idx int) (float64, float64) {
+	if idx == 0 {
+		return 0, 1
+	}
+	l, half := (idx-1)/2+1, float64((idx-1)%2)
+	base := math.Ldexp(1, l-1)
+	return base * (1 + half/2), base * (1 + (half+1)/2)
+}
+
+func (h *latencyHistogram) add(d time.Duration) {
+	idx := latencyBucket(d)
+	if h.counts[idx] < math.MaxUint32 {
+		h.counts[idx]++
+	}
+}
+
+func (h *latencyHistogram) merge(other *latencyHistogram) {
+	for i, count := range other.counts {
+		sum := uint64(h.counts[i]) + uint64(count)
+		if sum > math.MaxUint32 {
+			sum = math.MaxUint32
+		}
+		h.counts[i] = uint32(sum)
+	}
+}
+
+// percentile estimates the latency at the percentile p in (0, 1] by interpolating in the bucket, the result is
+// bounded by [minLatency, maxLatency].
+func (h *latencyHistogram) percentile(p float64, minLatency, maxLatency time.Duration) time.Duration {
+	var total uint64
+	for _, count := range h.counts {
+		total += uint64(count)
+	}
+	if total == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(p * float64(total)))
+	if rank == 0 {
+		rank = 1
+	}
+	var cumulative uint64
+	for idx, count := range h.counts {
+		if count == 0 || cumulative+uint64(count) < rank {
+			cumulative += uint64(count)
+			continue
+		}
+		lower, upper := latencyBucketBounds(idx)
+		us := lower + (upper-lower)*float64(rank-cumulative)/float64(count)
+		d := time.Duration(us * float64(time.Microsecond))
+		if d < minLatency {
+			d = minLatency
+		}
+		if d > maxLatency {
+			d = maxLatency
+		}
+		return d
+	}
+	return maxLatency
+}
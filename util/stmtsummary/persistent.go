@@ -0,0 +1,116 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stmtsummary
+
+import (
+	"math"
+	"time"
+)
+
+// PersistentSummary is the summary of a statement in an expired interval, which is persisted to
+// mysql.statements_summary_history.
+type PersistentSummary struct {
+	BeginTime       time.Time
+	EndTime         time.Time
+	StmtType        string
+	SchemaName      string
+	Digest          string
+	DigestText      string
+	TableNames      string
+	PlanDigest      string
+	SampleUser      string
+	ExecCount       int64
+	SumErrors       int
+	SumWarnings     int
+	SumLatency      time.Duration
+	MaxLatency      time.Duration
+	MinLatency      time.Duration
+	P50Latency      time.Duration
+	P95Latency      time.Duration
+	P99Latency      time.Duration
+	SumCopTime      time.Duration
+	MaxCopTime      time.Duration
+	SumMPPTime      time.Duration
+	MaxMPPTime      time.Duration
+	MaxMem          int64
+	MaxDisk         int64
+	SumAffectedRows uint64
+	FirstSeen       time.Time
+	LastSeen        time.Time
+	QuerySampleText string
+}
+
+// ToPersistentSummaries returns the summaries of the intervals which end in (since, until]. Unlike the current
+// summaries, they won't change any more, so each of them only needs to be persisted once. The summaries of the
+// evicted statements are not returned, since their digests are unknown.
+func (ssMap *stmtSummaryByDigestMap) ToPersistentSummaries(since, until int64) []*PersistentSummary {
+	ssMap.Lock()
+	values := ssMap.summaryMap.Values()
+	ssMap.Unlock()
+
+	var summaries []*PersistentSummary
+	for _, value := range values {
+		ssbd := value.(*stmtSummaryByDigest)
+		for _, ssElement := range ssbd.collectHistorySummaries(math.MaxInt32) {
+			if summary := ssElement.toPersistentSummary(ssbd, since, until); summary != nil {
+				summaries = append(summaries, summary)
+			}
+		}
+	}
+	return summaries
+}
+
+func (ssElement *stmtSummaryByDigestElement) toPersistentSummary(ssbd *stmtSummaryByDigest, since, until int64) *PersistentSummary {
+	ssElement.Lock()
+	defer ssElement.Unlock()
+
+	if ssElement.endTime <= since || ssElement.endTime > until {
+		return nil
+	}
+	sampleUser := ""
+	for key := range ssElement.authUsers {
+		sampleUser = key
+		break
+	}
+	return &PersistentSummary{
+		BeginTime:       time.Unix(ssElement.beginTime, 0),
+		EndTime:         time.Unix(ssElement.endTime, 0),
+		StmtType:        ssbd.stmtType,
+		SchemaName:      ssbd.schemaName,
+		Digest:          ssbd.digest,
+		DigestText:      ssbd.normalizedSQL,
+		TableNames:      ssbd.tableNames,
+		PlanDigest:      ssbd.planDigest,
+		SampleUser:      sampleUser,
+		ExecCount:       ssElement.execCount,
+		SumErrors:       ssElement.sumErrors,
+		SumWarnings:     ssElement.sumWarnings,
+		SumLatency:      ssElement.sumLatency,
+		MaxLatency:      ssElement.maxLatency,
+		MinLatency:      ssElement.minLatency,
+		P50Latency:      ssElement.latencies.percentile(0.5, ssElement.minLatency, ssElement.maxLatency),
+		P95Latency:      ssElement.latencies.percentile(0.95, ssElement.minLatency, ssElement.maxLatency),
+		P99Latency:      ssElement.latencies.percentile(0.99, ssElement.minLatency, ssElement.maxLatency),
+		SumCopTime:      ssElement.sumCopTime,
+		MaxCopTime:      ssElement.maxCopTime,
+		SumMPPTime:      ssElement.sumMPPTime,
+		MaxMPPTime:      ssElement.maxMPPTime,
+		MaxMem:          ssElement.maxMem,
+		MaxDisk:         ssElement.maxDisk,
+		SumAffectedRows: ssElement.sumAffectedRows,
+		FirstSeen:       ssElement.firstSeen,
+		LastSeen:        ssElement.lastSeen,
+		QuerySampleText: ssElement.sampleSQL,
+	}
+}
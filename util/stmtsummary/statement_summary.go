@@ -125,12 +125,18 @@ type stmtSummaryByDigestElement struct {
 	maxParseLatency   time.Duration
 	sumCompileLatency time.Duration
 	maxCompileLatency time.Duration
+	latencies         latencyHistogram
 	// coprocessor
 	sumNumCopTasks       int64
 	maxCopProcessTime    time.Duration
 	maxCopProcessAddress string
 	maxCopWaitTime       time.Duration
 	maxCopWaitAddress    string
+	// The time spent in waiting for the coprocessor and MPP responses in TiDB.
+	sumCopTime time.Duration
+	maxCopTime time.Duration
+	sumMPPTime time.Duration
+	maxMPPTime time.Duration
 	// TiKV
 	sumProcessTime               time.Duration
 	maxProcessTime               time.Duration
@@ -718,6 +724,7 @@ func (ssElement *stmtSummaryByDigestElement) add(sei *StmtExecInfo, intervalSeco
 	if sei.CompileLatency > ssElement.maxCompileLatency {
 		ssElement.maxCompileLatency = sei.CompileLatency
 	}
+	ssElement.latencies.add(sei.TotalLatency)
 
 	// coprocessor
 	numCopTasks := int64(sei.CopTasks.NumCopTasks)
@@ -730,6 +737,14 @@ func (ssElement *stmtSummaryByDigestElement) add(sei *StmtExecInfo, intervalSeco
 		ssElement.maxCopWaitTime = sei.CopTasks.MaxWaitTime
 		ssElement.maxCopWaitAddress = sei.CopTasks.MaxWaitAddress
 	}
+	ssElement.sumCopTime += sei.ExecDetail.CopTime
+	if sei.ExecDetail.CopTime > ssElement.maxCopTime {
+		ssElement.maxCopTime = sei.ExecDetail.CopTime
+	}
+	ssElement.sumMPPTime += sei.ExecDetail.MPPTime
+	if sei.ExecDetail.MPPTime > ssElement.maxMPPTime {
+		ssElement.maxMPPTime = sei.ExecDetail.MPPTime
+	}
 
 	// TiKV
 	ssElement.sumProcessTime += sei.ExecDetail.TimeDetail.ProcessTime
@@ -979,6 +994,13 @@ func (ssElement *stmtSummaryByDigestElement) toDatum(ssbd *stmtSummaryByDigest)
 		ssElement.prevSQL,
 		ssbd.planDigest,
 		plan,
+		int64(ssElement.latencies.percentile(0.5, ssElement.minLatency, ssElement.maxLatency)),
+		int64(ssElement.latencies.percentile(0.95, ssElement.minLatency, ssElement.maxLatency)),
+		int64(ssElement.latencies.percentile(0.99, ssElement.minLatency, ssElement.maxLatency)),
+		avgInt(int64(ssElement.sumCopTime), ssElement.execCount),
+		int64(ssElement.maxCopTime),
+		avgInt(int64(ssElement.sumMPPTime), ssElement.execCount),
+		int64(ssElement.maxMPPTime),
 	)
 }
 
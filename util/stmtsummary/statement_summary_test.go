@@ -574,6 +574,7 @@ func generateAnyExecInfo() *StmtExecInfo {
 		},
 		ExecDetail: &execdetails.ExecDetails{
 			CalleeAddress: "129",
+			CopTime:       300,
 			BackoffTime:   80,
 			RequestCount:  10,
 			CommitDetail: &util.CommitDetails{
@@ -665,7 +666,9 @@ func (s *testStmtSummarySuite) TestToDatum(c *C) {
 		stmtExecInfo1.ExecDetail.CommitDetail.TxnRetry, stmtExecInfo1.ExecDetail.CommitDetail.TxnRetry, 0, 0, 1,
 		fmt.Sprintf("%s:1", boTxnLockName), stmtExecInfo1.MemMax, stmtExecInfo1.MemMax, stmtExecInfo1.DiskMax, stmtExecInfo1.DiskMax,
 		0, 0, 0, 0, 0, stmtExecInfo1.StmtCtx.AffectedRows(),
-		t, t, 0, 0, 0, stmtExecInfo1.OriginalSQL, stmtExecInfo1.PrevSQL, "plan_digest", ""}
+		t, t, 0, 0, 0, stmtExecInfo1.OriginalSQL, stmtExecInfo1.PrevSQL, "plan_digest", "",
+		int64(stmtExecInfo1.TotalLatency), int64(stmtExecInfo1.TotalLatency), int64(stmtExecInfo1.TotalLatency),
+		int64(stmtExecInfo1.ExecDetail.CopTime), int64(stmtExecInfo1.ExecDetail.CopTime), 0, 0}
 	stmtExecInfo1.ExecDetail.CommitDetail.Mu.Unlock()
 	match(c, datums[0], expectedDatum...)
 	datums = s.ssMap.ToHistoryDatum(nil, true)
@@ -713,7 +716,9 @@ func (s *testStmtSummarySuite) TestToDatum(c *C) {
 		stmtExecInfo1.ExecDetail.CommitDetail.TxnRetry, stmtExecInfo1.ExecDetail.CommitDetail.TxnRetry, 0, 0, 1,
 		fmt.Sprintf("%s:1", boTxnLockName), stmtExecInfo1.MemMax, stmtExecInfo1.MemMax, stmtExecInfo1.DiskMax, stmtExecInfo1.DiskMax,
 		0, 0, 0, 0, 0, stmtExecInfo1.StmtCtx.AffectedRows(),
-		t, t, 0, 0, 0, "", "", "", ""}
+		t, t, 0, 0, 0, "", "", "", "",
+		int64(stmtExecInfo1.TotalLatency), int64(stmtExecInfo1.TotalLatency), int64(stmtExecInfo1.TotalLatency),
+		int64(stmtExecInfo1.ExecDetail.CopTime), int64(stmtExecInfo1.ExecDetail.CopTime), 0, 0}
 	expectedDatum[4] = stmtExecInfo2.Digest
 	match(c, datums[0], expectedDatum...)
 	match(c, datums[1], expectedEvictedDatum...)
@@ -1282,3 +1287,69 @@ func (s *testStmtSummarySuite) TestAccessPrivilege(c *C) {
 	datums = s.ssMap.ToHistoryDatum(badUser, true)
 	c.Assert(len(datums), Equals, loops)
 }
+
+func (s *testStmtSummarySuite) TestLatencyHistogram(c *C) {
+	c.Assert(latencyBucket(500*time.Nanosecond), Equals, 0)
+	c.Assert(latencyBucket(time.Microsecond), Equals, 1)
+	c.Assert(latencyBucket(3*time.Microsecond), Equals, 4)
+	c.Assert(latencyBucket(time.Hour), Equals, latencyBucketCount-1)
+	for idx := 1; idx < latencyBucketCount; idx++ {
+		lower, upper := latencyBucketBounds(idx)
+		_, prevUpper := latencyBucketBounds(idx - 1)
+		c.Assert(lower, Equals, prevUpper)
+		c.Assert(upper <= 1.5*lower, IsTrue)
+	}
+
+	h := &latencyHistogram{}
+	c.Assert(h.percentile(0.5, 0, 0), Equals, time.Duration(0))
+	// 90 statements take 1ms~1.09ms, 10 statements take 100ms.
+	for i := 0; i < 90; i++ {
+		h.add(time.Millisecond + time.Duration(i)*time.Microsecond)
+	}
+	for i := 0; i < 10; i++ {
+		h.add(100 * time.Millisecond)
+	}
+	minLatency, maxLatency := time.Millisecond, 100*time.Millisecond
+	p50 := h.percentile(0.5, minLatency, maxLatency)
+	c.Assert(p50 >= time.Millisecond && p50 < 1500*time.Microsecond, IsTrue, Commentf("p50 %v", p50))
+	p95 := h.percentile(0.95, minLatency, maxLatency)
+	c.Assert(p95 > 64*time.Millisecond && p95 <= maxLatency, IsTrue, Commentf("p95 %v", p95))
+	c.Assert(h.percentile(1, minLatency, maxLatency), Equals, maxLatency)
+
+	other := &latencyHistogram{}
+	for i := 0; i < 900; i++ {
+		other.add(time.Millisecond)
+	}
+	h.merge(other)
+	p95 = h.percentile(0.95, minLatency, maxLatency)
+	c.Assert(p95 < 1500*time.Microsecond, IsTrue, Commentf("p95 %v", p95))
+}
+
+func (s *testStmtSummarySuite) TestToPersistentSummaries(c *C) {
+	s.ssMap.Clear()
+	now := time.Now().Unix()
+	// to disable expiration
+	s.ssMap.beginTimeForCurInterval = now + 60
+
+	stmtExecInfo1 := generateAnyExecInfo()
+	s.ssMap.AddStatement(stmtExecInfo1)
+	// The interval hasn't ended.
+	c.Assert(s.ssMap.ToPersistentSummaries(0, now), HasLen, 0)
+
+	end := s.ssMap.beginTimeForCurInterval + 1800
+	summaries := s.ssMap.ToPersistentSummaries(0, end)
+	c.Assert(summaries, HasLen, 1)
+	summary := summaries[0]
+	c.Assert(summary.EndTime.Unix(), Equals, end)
+	c.Assert(summary.Digest, Equals, stmtExecInfo1.Digest)
+	c.Assert(summary.PlanDigest, Equals, stmtExecInfo1.PlanDigest)
+	c.Assert(summary.SampleUser, Equals, stmtExecInfo1.User)
+	c.Assert(summary.ExecCount, Equals, int64(1))
+	c.Assert(summary.P99Latency, Equals, stmtExecInfo1.TotalLatency)
+	c.Assert(summary.SumCopTime, Equals, stmtExecInfo1.ExecDetail.CopTime)
+	c.Assert(summary.MaxMem, Equals, stmtExecInfo1.MemMax)
+	c.Assert(summary.QuerySampleText, Equals, stmtExecInfo1.OriginalSQL)
+
+	// The summary is returned only once.
+	c.Assert(s.ssMap.ToPersistentSummaries(end, end+1800), HasLen, 0)
+}
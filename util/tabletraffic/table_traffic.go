@@ -0,0 +1,152 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tabletraffic
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Traffic is the amount of the data read from and written to a physical table.
+type Traffic struct {
+	ReadKeys   int64
+	ReadBytes  int64
+	WriteKeys  int64
+	WriteBytes int64
+}
+
+// IsEmpty returns whether there is no traffic.
+func (t *Traffic) IsEmpty() bool {
+	return *t == Traffic{}
+}
+
+// TableTraffic is the traffic of a physical table.
+type TableTraffic struct {
+	TableID int64
+	Traffic
+}
+
+// counter accumulates the traffic of a table, the fields are updated atomically.
+type counter struct {
+	readKeys   int64
+	readBytes  int64
+	writeKeys  int64
+	writeBytes int64
+	// taken is the traffic returned by the last TakeDeltas, it's only accessed with takeMu held.
+	taken Traffic
+}
+
+func (c *counter) load() Traffic {
+	return Traffic{
+		ReadKeys:   atomic.LoadInt64(&c.readKeys),
+		ReadBytes:  atomic.LoadInt64(&c.readBytes),
+		WriteKeys:  atomic.LoadInt64(&c.writeKeys),
+		WriteBytes: atomic.LoadInt64(&c.writeBytes),
+	}
+}
+
+var (
+	// counters maps the physical table IDs to their *counter.
+	counters sync.Map
+	takeMu   sync.Mutex
+)
+
+func getCounter(tableID int64) *counter {
+	if c, ok := counters.Load(tableID); ok {
+		return c.(*counter)
+	}
+	c, _ := counters.LoadOrStore(tableID, &counter{})
+	return c.(*counter)
+}
+
+// RecordRead records the keys and bytes read from the physical table.
+func RecordRead(tableID int64, keys, bytes int64) {
+	if tableID <= 0 || (keys == 0 && bytes == 0) {
+		return
+	}
+	c := getCounter(tableID)
+	atomic.AddInt64(&c.readKeys, keys)
+	atomic.AddInt64(&c.readBytes, bytes)
+}
+
+// RecordWrite records the keys and bytes written to the physical table.
+func RecordWrite(tableID int64, keys, bytes int64) {
+	if tableID <= 0 || (keys == 0 && bytes == 0) {
+		return
+	}
+	c := getCounter(tableID)
+	atomic.AddInt64(&c.writeKeys, keys)
+	atomic.AddInt64(&c.writeBytes, bytes)
+}
+
+// Tables returns the total traffic of the tables since TiDB started, ordered by the table IDs.
+func Tables() []TableTraffic {
+	var tables []TableTraffic
+	counters.Range(func(key, value interface{}) bool {
+		tables = append(tables, TableTraffic{TableID: key.(int64), Traffic: value.(*counter).load()})
+		return true
+	})
+	sort.Slice(tables, func(i, j int) bool { return tables[i].TableID < tables[j].TableID })
+	return tables
+}
+
+// TakeDeltas returns the traffic of the tables since the last call of TakeDeltas, the tables without any
+// traffic are skipped. It's used to persist the traffic incrementally.
+func TakeDeltas() []TableTraffic {
+	takeMu.Lock()
+	defer takeMu.Unlock()
+	var deltas []TableTraffic
+	counters.Range(func(key, value interface{}) bool {
+		c := value.(*counter)
+		total := c.load()
+		delta := Traffic{
+			ReadKeys:   total.ReadKeys - c.taken.ReadKeys,
+			ReadBytes:  total.ReadBytes - c.taken.ReadBytes,
+			WriteKeys:  total.WriteKeys - c.taken.WriteKeys,
+			WriteBytes: total.WriteBytes - c.taken.WriteBytes,
+		}
+		c.taken = total
+		if !delta.IsEmpty() {
+			deltas = append(deltas, TableTraffic{TableID: key.(int64), Traffic: delta})
+		}
+		return true
+	})
+	sort.Slice(deltas, func(i, j int) bool { return deltas[i].TableID < deltas[j].TableID })
+	return deltas
+}
+
+// RestoreDeltas gives back the deltas which are taken but failed to be persisted, so they are returned by the
+// next TakeDeltas again.
+func RestoreDeltas(deltas []TableTraffic) {
+	takeMu.Lock()
+	defer takeMu.Unlock()
+	for _, delta := range deltas {
+		c := getCounter(delta.TableID)
+		c.taken.ReadKeys -= delta.ReadKeys
+		c.taken.ReadBytes -= delta.ReadBytes
+		c.taken.WriteKeys -= delta.WriteKeys
+		c.taken.WriteBytes -= delta.WriteBytes
+	}
+}
+
+// Reset clears the traffic of all the tables.
+func Reset() {
+	takeMu.Lock()
+	defer takeMu.Unlock()
+	counters.Range(func(key, _ interface{}) bool {
+		counters.Delete(key)
+		return true
+	})
+}
@@ -0,0 +1,76 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tabletraffic
+
+import (
+	"sync"
+	"testing"
+
+	. "github.com/pingcap/check"
+)
+
+type testTableTrafficSuite struct{}
+
+var _ = SerialSuites(&testTableTrafficSuite{})
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+func (s *testTableTrafficSuite) TestRecord(c *C) {
+	Reset()
+	defer Reset()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			RecordRead(1, 2, 20)
+			RecordWrite(2, 1, 10)
+		}()
+	}
+	wg.Wait()
+	// The traffic of the invalid tables or without any data is ignored.
+	RecordRead(0, 1, 1)
+	RecordWrite(3, 0, 0)
+	c.Assert(Tables(), DeepEquals, []TableTraffic{
+		{TableID: 1, Traffic: Traffic{ReadKeys: 20, ReadBytes: 200}},
+		{TableID: 2, Traffic: Traffic{WriteKeys: 10, WriteBytes: 100}},
+	})
+}
+
+func (s *testTableTrafficSuite) TestTakeDeltas(c *C) {
+	Reset()
+	defer Reset()
+	RecordRead(1, 1, 10)
+	RecordWrite(2, 1, 10)
+	c.Assert(TakeDeltas(), DeepEquals, []TableTraffic{
+		{TableID: 1, Traffic: Traffic{ReadKeys: 1, ReadBytes: 10}},
+		{TableID: 2, Traffic: Traffic{WriteKeys: 1, WriteBytes: 10}},
+	})
+	c.Assert(TakeDeltas(), HasLen, 0)
+
+	RecordRead(1, 2, 20)
+	deltas := TakeDeltas()
+	c.Assert(deltas, DeepEquals, []TableTraffic{{TableID: 1, Traffic: Traffic{ReadKeys: 2, ReadBytes: 20}}})
+	// The restored deltas are taken again.
+	RestoreDeltas(deltas)
+	RecordRead(1, 1, 10)
+	c.Assert(TakeDeltas(), DeepEquals, []TableTraffic{{TableID: 1, Traffic: Traffic{ReadKeys: 3, ReadBytes: 30}}})
+	// The totals are not affected by taking the deltas.
+	c.Assert(Tables(), DeepEquals, []TableTraffic{
+		{TableID: 1, Traffic: Traffic{ReadKeys: 4, ReadBytes: 40}},
+		{TableID: 2, Traffic: Traffic{WriteKeys: 1, WriteBytes: 10}},
+	})
+}
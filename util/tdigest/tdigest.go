@@ -0,0 +1,157 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tdigest
+
+import (
+	"math"
+	"sort"
+	"unsafe"
+)
+
+// DefaultCompression is the default compression of TDigest, with which the error of the quantiles is about 1% in the
+// middle and much less at the tails, and a TDigest keeps at most several hundreds of centroids.
+const DefaultCompression = 100
+
+var centroidSize = int64(unsafe.Sizeof(centroid{}))
+
+type centroid struct {
+	mean   float64
+	weight float64
+}
+
+// TDigest is the merging t-digest sketch of the distribution of values, with which the quantiles are estimated in
+// bounded memory. The values are summarized by the centroids, which are small at the tails and large in the middle,
+// so the extreme quantiles are more accurate. A TDigest can be merged into another one, so the sketches built on
+// different parts of the data can be combined.
+// Each value is kept as a single centroid until the centroids are compressed, so the quantiles are exact for the
+// small inputs.
+type TDigest struct {
+	compression float64
+	// centroids are sorted by the mean.
+	centroids []centroid
+	unmerged  []centroid
+	count     float64
+	min       float64
+	max       float64
+}
+
+// New creates a new TDigest with the compression, the larger compression keeps more centroids and is more accurate.
+func New(compression float64) *TDigest {
+	return &TDigest{
+		compression: compression,
+		min:         math.Inf(1),
+		max:         math.Inf(-1),
+	}
+}
+
+// Add adds a value.
+func (t *TDigest) Add(x float64) {
+	t.add(x, 1)
+}
+
+func (t *TDigest) add(x, w float64) {
+	t.unmerged = append(t.unmerged, centroid{mean: x, weight: w})
+	t.count += w
+	t.min = math.Min(t.min, x)
+	t.max = math.Max(t.max, x)
+	if len(t.unmerged) >= t.bufferSize() {
+		t.compress()
+	}
+}
+
+func (t *TDigest) bufferSize() int {
+	return int(t.compression) * 5
+}
+
+// Merge merges the centroids of other into t.
+func (t *TDigest) Merge(other *TDigest) {
+	for _, c := range other.centroids {
+		t.add(c.mean, c.weight)
+	}
+	for _, c := range other.unmerged {
+		t.add(c.mean, c.weight)
+	}
+}
+
+// Count returns the number of the added values.
+func (t *TDigest) Count() int64 {
+	return int64(t.count)
+}
+
+// MemUsage returns the memory usage of the TDigest.
+func (t *TDigest) MemUsage() int64 {
+	return int64(unsafe.Sizeof(*t)) + int64(cap(t.centroids)+cap(t.unmerged))*centroidSize
+}
+
+// compress merges the unmerged centroids with the centroids. Two adjacent centroids are merged if the merged one
+// isn't larger than 4 * count * q * (1 - q) / compression, where q is the quantile of its center.
+func (t *TDigest) compress() {
+	if len(t.unmerged) == 0 {
+		return
+	}
+	all := append(t.centroids, t.unmerged...)
+	sort.Slice(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+	merged := make([]centroid, 0, len(t.centroids)+1)
+	cur, before := all[0], 0.0
+	for _, c := range all[1:] {
+		weight := cur.weight + c.weight
+		q := (before + weight/2) / t.count
+		if weight <= 4*t.count*q*(1-q)/t.compression {
+			cur.mean += (c.mean - cur.mean) * c.weight / weight
+			cur.weight = weight
+			continue
+		}
+		merged = append(merged, cur)
+		before += cur.weight
+		cur = c
+	}
+	t.centroids = append(merged, cur)
+	t.unmerged = t.unmerged[:0]
+}
+
+// Quantile estimates the value at the quantile q in [0, 1], which is interpolated between the centers of the
+// centroids, where the center of a centroid is at the middle of the ranks it covers. The value of the k-th smallest
+// one of n values is at the quantile (k - 0.5) / n. It returns NaN if there are no values.
+func (t *TDigest) Quantile(q float64) float64 {
+	t.compress()
+	if len(t.centroids) == 0 {
+		return math.NaN()
+	}
+	index := q * t.count
+	if index <= t.centroids[0].weight/2 {
+		return t.interpolate(index, 0, t.min, t.centroids[0].weight/2, t.centroids[0].mean)
+	}
+	before := 0.0
+	for i := 0; i+1 < len(t.centroids); i++ {
+		lo, hi := t.centroids[i], t.centroids[i+1]
+		loCenter := before + lo.weight/2
+		hiCenter := before + lo.weight + hi.weight/2
+		if index <= hiCenter {
+			return t.interpolate(index, loCenter, lo.mean, hiCenter, hi.mean)
+		}
+		before += lo.weight
+	}
+	last := t.centroids[len(t.centroids)-1]
+	return t.interpolate(index, t.count-last.weight/2, last.mean, t.count, t.max)
+}
+
+func (t *TDigest) interpolate(index, loIndex, lo, hiIndex, hi float64) float64 {
+	if hiIndex <= loIndex || index <= loIndex {
+		return lo
+	}
+	if index >= hiIndex {
+		return hi
+	}
+	return lo + (hi-lo)*(index-loIndex)/(hiIndex-loIndex)
+}
@@ -0,0 +1,73 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tdigest
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func TestT(t *testing.T) {
+	CustomVerboseFlag = true
+	TestingT(t)
+}
+
+var _ = Suite(&testTDigestSuite{})
+
+type testTDigestSuite struct{}
+
+func (s *testTDigestSuite) TestExact(c *C) {
+	defer testleak.AfterTest(c)()
+	t := New(DefaultCompression)
+	c.Assert(math.IsNaN(t.Quantile(0.5)), IsTrue)
+	for _, v := range []float64{5, 1, 4, 2, 3} {
+		t.Add(v)
+	}
+	c.Assert(t.Count(), Equals, int64(5))
+	for k := 1; k <= 5; k++ {
+		c.Assert(t.Quantile((float64(k)-0.5)/5), Equals, float64(k))
+	}
+	c.Assert(t.Quantile(0), Equals, float64(1))
+	c.Assert(t.Quantile(1), Equals, float64(5))
+}
+
+func (s *testTDigestSuite) TestAccuracy(c *C) {
+	defer testleak.AfterTest(c)()
+	r := rand.New(rand.NewSource(1))
+	n := 200000
+	values := make([]float64, n)
+	parts := []*TDigest{New(DefaultCompression), New(DefaultCompression), New(DefaultCompression)}
+	for i := range values {
+		values[i] = r.ExpFloat64() * 100
+		parts[i%len(parts)].Add(values[i])
+	}
+	t := New(DefaultCompression)
+	for _, part := range parts {
+		t.Merge(part)
+	}
+	c.Assert(t.Count(), Equals, int64(n))
+	sort.Float64s(values)
+	for _, q := range []float64{0.001, 0.01, 0.1, 0.5, 0.9, 0.99, 0.999} {
+		k := int(math.Ceil(q * float64(n)))
+		rank := sort.SearchFloat64s(values, t.Quantile((float64(k)-0.5)/float64(n)))
+		c.Assert(math.Abs(float64(rank-k))/float64(n) < 0.005, IsTrue, Commentf("quantile %v", q))
+	}
+	c.Assert(len(t.centroids) < 10*DefaultCompression, IsTrue)
+	c.Assert(t.MemUsage() < 100<<10, IsTrue)
+}
@@ -116,6 +116,40 @@ func (s *testSuite) TestIsEnabled(c *C) {
 	c.Assert(tracecpu.GlobalSQLCPUProfiler.IsEnabled(), IsTrue)
 }
 
+func (s *testSuite) TestCollectTopSQL(c *C) {
+	// The statements are labeled during collecting even if top sql is disabled.
+	s.setTopSQLEnable(false)
+	defer s.setTopSQLEnable(true)
+	c.Assert(variable.TopSQLEnabled(), IsFalse)
+
+	sql, plan := "select * from t where a>?", "table-scan"
+	sqlDigest, planDigest := mock.GenSQLDigest(sql), genDigest(plan)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// The statements executed before the profiling starts aren't labeled, and they're not profiled either.
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+			execCtx := tracecpu.CtxWithConnID(context.Background(), 7)
+			topsql.AttachSQLInfo(execCtx, sql, sqlDigest, plan, planDigest)
+			s.mockExecute(10 * time.Millisecond)
+		}
+	}()
+
+	records, err := tracecpu.CollectTopSQL(context.Background(), 3*time.Second, 10)
+	c.Assert(err, IsNil)
+	c.Assert(variable.TopSQLEnabled(), IsFalse)
+	c.Assert(records, HasLen, 1)
+	c.Assert(records[0].SQLDigest, Equals, sqlDigest.String())
+	c.Assert(records[0].PlanDigest, Equals, planDigest.String())
+	c.Assert(records[0].CPUTimeMs > 0, IsTrue)
+	c.Assert(records[0].Connections[7], Equals, records[0].CPUTimeMs)
+}
+
 func mockPlanBinaryDecoderFunc(plan string) (string, error) {
 	return plan, nil
 }
@@ -16,11 +16,14 @@ package tracecpu
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"runtime/pprof"
+	"sort"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -38,6 +41,7 @@ const (
 	labelSQL        = "sql"
 	labelSQLDigest  = "sql_digest"
 	labelPlanDigest = "plan_digest"
+	labelConnID     = "conn_id"
 )
 
 // GlobalSQLCPUProfiler is the global SQL stats profiler.
@@ -319,11 +323,23 @@ func (sp *sqlCPUProfiler) startExportCPUProfile(w io.Writer) error {
 	return nil
 }
 
+// CtxWithConnID wrap the ctx with the connection id, so the cpu time can be attributed to the connection.
+func CtxWithConnID(ctx context.Context, connID uint64) context.Context {
+	return pprof.WithLabels(ctx, pprof.Labels(labelConnID, strconv.FormatUint(connID, 10)))
+}
+
+// SetGoroutineLabels sets the labels in ctx to the current goroutine if ctx carries the sql digest. The workers of
+// the statement, such as the coprocessor and mpp workers, call it to make sure their cpu time is attributed to the
+// statement even if they are started by a goroutine without the labels. It keeps the inherited labels if ctx
+// doesn't carry the sql digest.
+func SetGoroutineLabels(ctx context.Context) {
+	if _, ok := pprof.Label(ctx, labelSQLDigest); ok {
+		pprof.SetGoroutineLabels(ctx)
+	}
+}
+
 func (sp *sqlCPUProfiler) stopExportCPUProfile() error {
-	sp.mu.Lock()
-	ept := sp.mu.ept
-	sp.mu.ept = nil
-	sp.mu.Unlock()
+	ept := sp.takeExportProfileTask()
 	if ept.err != nil {
 		return ept.err
 	}
@@ -334,6 +350,14 @@ func (sp *sqlCPUProfiler) stopExportCPUProfile() error {
 	return nil
 }
 
+func (sp *sqlCPUProfiler) takeExportProfileTask() *exportProfileTask {
+	sp.mu.Lock()
+	ept := sp.mu.ept
+	sp.mu.ept = nil
+	sp.mu.Unlock()
+	return ept
+}
+
 // removeLabel uses to remove labels for export cpu profile data.
 // Since the sql_digest and plan_digest label is strange for other users.
 // If `variable.EnablePProfSQLCPU` is true means wanto keep the `sql` label, otherwise, remove the `sql` label too.
@@ -345,7 +369,7 @@ func (sp *sqlCPUProfiler) removeLabel(p *profile.Profile) {
 	for _, s := range p.Sample {
 		for k := range s.Label {
 			switch k {
-			case labelSQL:
+			case labelSQL, labelConnID:
 				if !keepLabelSQL {
 					delete(s.Label, k)
 				}
@@ -414,6 +438,136 @@ func ProfileHTTPHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// TopSQLRecord is the cpu time consumed by a sql plan, which is aggregated from the labels of the cpu profile.
+type TopSQLRecord struct {
+	SQLDigest  string `json:"sql_digest"`
+	PlanDigest string `json:"plan_digest"`
+	CPUTimeMs  int64  `json:"cpu_time_ms"`
+	// Connections is the cpu time consumed by the sql plan in each connection.
+	Connections map[uint64]int64 `json:"connections,omitempty"`
+}
+
+// CollectTopSQL profiles the cpu for the duration, and returns the top n sql plans which consume the most cpu time.
+// The statements are labeled during the profiling even if the top sql agent is not set.
+func CollectTopSQL(ctx context.Context, d time.Duration, n int) ([]*TopSQLRecord, error) {
+	variable.TopSQLVariable.InstanceProfiling.Inc()
+	defer variable.TopSQLVariable.InstanceProfiling.Dec()
+	if err := GlobalSQLCPUProfiler.startExportCPUProfile(nil); err != nil {
+		return nil, err
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+	ept := GlobalSQLCPUProfiler.takeExportProfileTask()
+	if ept.err != nil {
+		return nil, ept.err
+	}
+	if ept.cpuProfile == nil {
+		return nil, nil
+	}
+	return aggregateTopSQL(ept.cpuProfile, n), nil
+}
+
+// aggregateTopSQL aggregates the cpu-profile sample data by sql_digest and plan_digest labels, and the conn_id label
+// in each of them.
+func aggregateTopSQL(p *profile.Profile, n int) []*TopSQLRecord {
+	type planKey struct {
+		sqlDigest  string
+		planDigest string
+	}
+	type planStats struct {
+		planKey
+		total int64
+		conns map[uint64]int64
+	}
+	idx := len(p.SampleType) - 1
+	planMap := make(map[planKey]*planStats)
+	for _, s := range p.Sample {
+		sqlDigests := s.Label[labelSQLDigest]
+		if len(sqlDigests) == 0 {
+			continue
+		}
+		key := planKey{sqlDigest: sqlDigests[0]}
+		if planDigests := s.Label[labelPlanDigest]; len(planDigests) > 0 {
+			key.planDigest = planDigests[0]
+		}
+		stats, ok := planMap[key]
+		if !ok {
+			stats = &planStats{planKey: key, conns: make(map[uint64]int64)}
+			planMap[key] = stats
+		}
+		stats.total += s.Value[idx]
+		for _, connID := range s.Label[labelConnID] {
+			if id, err := strconv.ParseUint(connID, 10, 64); err == nil {
+				stats.conns[id] += s.Value[idx]
+			}
+		}
+	}
+
+	plans := make([]*planStats, 0, len(planMap))
+	for _, stats := range planMap {
+		plans = append(plans, stats)
+	}
+	sort.Slice(plans, func(i, j int) bool {
+		return plans[i].total > plans[j].total
+	})
+	if n > 0 && len(plans) > n {
+		plans = plans[:n]
+	}
+	records := make([]*TopSQLRecord, 0, len(plans))
+	for _, stats := range plans {
+		record := &TopSQLRecord{
+			SQLDigest:  hex.EncodeToString(hack.Slice(stats.sqlDigest)),
+			PlanDigest: hex.EncodeToString(hack.Slice(stats.planDigest)),
+			CPUTimeMs:  time.Duration(stats.total).Milliseconds(),
+		}
+		if len(stats.conns) > 0 {
+			record.Connections = make(map[uint64]int64, len(stats.conns))
+			for id, cpuTime := range stats.conns {
+				record.Connections[id] = time.Duration(cpuTime).Milliseconds()
+			}
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// TopSQLHTTPHandler profiles the cpu for `seconds` and responds the `top` sql plans which consume the most cpu time,
+// the sql digests can be found in the statement summary tables.
+func TopSQLHTTPHandler(w http.ResponseWriter, r *http.Request) {
+	sec, err := strconv.ParseInt(r.FormValue("seconds"), 10, 64)
+	if sec <= 0 || err != nil {
+		sec = 10
+	}
+	top, err := strconv.Atoi(r.FormValue("top"))
+	if top <= 0 || err != nil {
+		top = 20
+	}
+	if durationExceedsWriteTimeout(r, float64(sec)) {
+		serveError(w, http.StatusBadRequest, "profile duration exceeds server's WriteTimeout")
+		return
+	}
+
+	records, err := CollectTopSQL(r.Context(), time.Duration(sec)*time.Second, top)
+	if err != nil {
+		serveError(w, http.StatusInternalServerError, "Could not collect top sql: "+err.Error())
+		return
+	}
+	if records == nil {
+		records = []*TopSQLRecord{}
+	}
+	js, err := json.MarshalIndent(records, "", " ")
+	if err != nil {
+		serveError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err = w.Write(js); err != nil {
+		logutil.BgLogger().Info("write http response error", zap.Error(err))
+	}
+}
+
 func durationExceedsWriteTimeout(r *http.Request, seconds float64) bool {
 	srv, ok := r.Context().Value(http.ServerContextKey).(*http.Server)
 	return ok && srv.WriteTimeout != 0 && seconds >= srv.WriteTimeout.Seconds()